@@ -0,0 +1,196 @@
+// Command benchdiff compares two `go test -bench` outputs and reports the
+// change of ns/op, B/op and allocs/op for every benchmark found in both.
+//
+// Usage:
+//
+//	go test -bench . -benchmem -count 5 > old.txt
+//	# ... change the code ...
+//	go test -bench . -benchmem -count 5 > new.txt
+//	go run ./cmd/benchdiff -threshold 5 old.txt new.txt
+//
+// When a benchmark was run several times (-count), the samples are averaged and
+// a delta is only reported as significant if it is larger than both the
+// threshold and the spread of the samples themselves.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func main() {
+	threshold := flag.Float64("threshold", 5, "minimum change in percent to be reported as significant")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: benchdiff [-threshold pct] old.txt new.txt\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	oldSet, err := parseFile(flag.Arg(0))
+	if err != nil {
+		log.Fatal(err)
+	}
+	newSet, err := parseFile(flag.Arg(1))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rows := compare(oldSet, newSet, *threshold)
+	if len(rows) == 0 {
+		log.Fatal("no benchmark found in both inputs")
+	}
+	printRows(os.Stdout, rows)
+}
+
+// metrics reported by the testing package, in output order.
+var units = []string{"ns/op", "B/op", "allocs/op"}
+
+// samples holds every value seen per unit for one benchmark.
+type samples map[string][]float64
+
+func parseFile(name string) (map[string]samples, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	set, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", name, err)
+	}
+	return set, nil
+}
+
+// parse reads lines of the form
+//
+//	BenchmarkMapPrealloc-8   	 1000000	      1043 ns/op	     336 B/op	       2 allocs/op
+//
+// and ignores everything else (goos/goarch headers, PASS, ok ...).
+func parse(r io.Reader) (map[string]samples, error) {
+	set := make(map[string]samples)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		// the second field is the iteration count, values come in "<value> <unit>" pairs.
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		name := trimProcs(fields[0])
+		if set[name] == nil {
+			set[name] = make(samples)
+		}
+		for i := 2; i+1 < len(fields); i += 2 {
+			v, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad value %q for %s", fields[i], name)
+			}
+			set[name][fields[i+1]] = append(set[name][fields[i+1]], v)
+		}
+	}
+	return set, scanner.Err()
+}
+
+// trimProcs removes the GOMAXPROCS suffix, "BenchmarkFoo-8" -> "BenchmarkFoo",
+// so results from machines with a different core count can still be compared.
+func trimProcs(name string) string {
+	if i := strings.LastIndexByte(name, '-'); i > 0 {
+		if _, err := strconv.Atoi(name[i+1:]); err == nil {
+			return name[:i]
+		}
+	}
+	return name
+}
+
+type row struct {
+	name        string
+	unit        string
+	old, new    float64
+	delta       float64 // in percent, positive means slower/bigger
+	significant bool
+}
+
+func compare(oldSet, newSet map[string]samples, threshold float64) []row {
+	names := make([]string, 0, len(oldSet))
+	for name := range oldSet {
+		if _, ok := newSet[name]; ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var rows []row
+	for _, name := range names {
+		for _, unit := range units {
+			o, n := oldSet[name][unit], newSet[name][unit]
+			if len(o) == 0 || len(n) == 0 {
+				continue
+			}
+			oldMean, oldSpread := stats(o)
+			newMean, newSpread := stats(n)
+
+			r := row{name: name, unit: unit, old: oldMean, new: newMean}
+			if oldMean != 0 {
+				r.delta = (newMean - oldMean) / oldMean * 100
+			} else if newMean != 0 {
+				r.delta = math.Inf(1)
+			}
+			// a change is only trusted when it is bigger than the noise of both runs.
+			noise := math.Max(oldSpread, newSpread)
+			r.significant = math.Abs(r.delta) >= threshold && math.Abs(r.delta) > noise
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// stats returns the mean and the spread ((max-min)/mean in percent) of values.
+func stats(values []float64) (mean, spread float64) {
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		mean += v
+		lo = math.Min(lo, v)
+		hi = math.Max(hi, v)
+	}
+	mean /= float64(len(values))
+	if mean != 0 {
+		spread = (hi - lo) / mean * 100
+	}
+	return mean, spread
+}
+
+func printRows(w io.Writer, rows []row) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "name\tunit\told\tnew\tdelta\t")
+	for _, r := range rows {
+		delta := "~"
+		if r.significant {
+			delta = fmt.Sprintf("%+.2f%%", r.delta)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", r.name, r.unit, format(r.old), format(r.new), delta)
+	}
+	tw.Flush()
+}
+
+func format(v float64) string {
+	if v == math.Trunc(v) {
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const sample = `goos: linux
+goarch: amd64
+pkg: github.com/YongSangUn/learn-golang/golang_program_design_2024/12.performance/benchmarks
+cpu: AMD EPYC 7B13
+BenchmarkMap/grow/n=1K-8         	   10000	    104300 ns/op	   86544 B/op	      64 allocs/op
+BenchmarkMap/grow/n=1K-8         	   10000	     95700 ns/op	   86544 B/op	      64 allocs/op
+BenchmarkCount/n=1K-16           	      20	    250224 ns/op	 184.15 MB/s	   51689 B/op	       3 allocs/op
+BenchmarkNoMem-8                 	 5000000	       240 ns/op
+BenchmarkLogs-8
+--- BENCH: BenchmarkLogs-8
+    main_test.go:12: a line logged by the benchmark
+BenchmarkFails-8                 	--- FAIL: BenchmarkFails-8
+Benchmark
+PASS
+ok  	github.com/YongSangUn/learn-golang/golang_program_design_2024/12.performance/benchmarks	3.012s
+`
+
+func TestParse(t *testing.T) {
+	got, err := parse(strings.NewReader(sample))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]samples{
+		"BenchmarkMap/grow/n=1K": {"ns/op": {104300, 95700}, "B/op": {86544, 86544}, "allocs/op": {64, 64}},
+		"BenchmarkCount/n=1K":    {"ns/op": {250224}, "MB/s": {184.15}, "B/op": {51689}, "allocs/op": {3}},
+		"BenchmarkNoMem":         {"ns/op": {240}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parse =\n%v\nwant\n%v", got, want)
+	}
+
+	if _, err := parse(strings.NewReader("BenchmarkBad-8  100  fast ns/op\n")); err == nil {
+		t.Error("a value that is not a number was accepted")
+	}
+}
+
+func TestTrimProcs(t *testing.T) {
+	for _, tt := range []struct{ name, want string }{
+		{"BenchmarkFoo-8", "BenchmarkFoo"},
+		{"BenchmarkFoo/n=1K-16", "BenchmarkFoo/n=1K"},
+		{"BenchmarkFoo", "BenchmarkFoo"},
+		{"BenchmarkFoo/lock-free", "BenchmarkFoo/lock-free"},
+		{"BenchmarkFoo/lock-free-4", "BenchmarkFoo/lock-free"},
+	} {
+		if got := trimProcs(tt.name); got != tt.want {
+			t.Errorf("trimProcs(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	oldSet := map[string]samples{
+		"BenchmarkSlower": {"ns/op": {100, 100}, "B/op": {0}, "allocs/op": {0}},
+		"BenchmarkNoisy":  {"ns/op": {100, 140}},
+		"BenchmarkGone":   {"ns/op": {100}},
+		"BenchmarkBelow":  {"ns/op": {100}},
+	}
+	newSet := map[string]samples{
+		"BenchmarkSlower": {"ns/op": {120, 120}, "B/op": {16}, "allocs/op": {0}},
+		"BenchmarkNoisy":  {"ns/op": {130}},
+		"BenchmarkNew":    {"ns/op": {100}},
+		"BenchmarkBelow":  {"ns/op": {104}},
+	}
+	want := []row{
+		{name: "BenchmarkBelow", unit: "ns/op", old: 100, new: 104, delta: 4},
+		// 8% slower, but the old runs were 33% apart: noise.
+		{name: "BenchmarkNoisy", unit: "ns/op", old: 120, new: 130, delta: 100.0 / 12},
+		{name: "BenchmarkSlower", unit: "ns/op", old: 100, new: 120, delta: 20, significant: true},
+		{name: "BenchmarkSlower", unit: "B/op", old: 0, new: 16, delta: math.Inf(1), significant: true},
+		{name: "BenchmarkSlower", unit: "allocs/op", old: 0, new: 0},
+	}
+	got := compare(oldSet, newSet, 5)
+	if len(got) != len(want) {
+		t.Fatalf("compare = %+v\nwant %+v", got, want)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.name != w.name || g.unit != w.unit || g.old != w.old || g.new != w.new ||
+			g.significant != w.significant || math.Abs(g.delta-w.delta) > 1e-9 && g.delta != w.delta {
+			t.Errorf("row %d = %+v, want %+v", i, g, w)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// Command dig-lite looks up DNS records, a small dig built on net.Resolver.
+//
+// Every name and record type is looked up concurrently, each with its own
+// timeout; the results are printed in the order of the command line, as text
+// or as JSON. With -server the queries go to that DNS server instead of the
+// one of /etc/resolv.conf: the resolver's Dial hook replaces the address the
+// pure Go resolver would have used. Names in /etc/hosts are still answered
+// from there for A and AAAA, as for every Go program.
+//
+// Usage:
+//
+//	go run ./cmd/dig-lite example.com
+//	go run ./cmd/dig-lite -server 1.1.1.1:53 -types A,MX,TXT go.dev example.com
+//	go run ./cmd/dig-lite -json -timeout 2s golang.org
+//
+// The exit status is 0 when every lookup succeeded or found no record, 1 when
+// a lookup failed (timeout, unreachable server) and 2 on bad usage.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lookups maps a record type to the resolver call answering it.
+var lookups = map[string]func(ctx context.Context, r *net.Resolver, name string) ([]string, error){
+	"A":     lookupIP("ip4"),
+	"AAAA":  lookupIP("ip6"),
+	"CNAME": lookupCNAME,
+	"MX":    lookupMX,
+	"NS":    lookupNS,
+	"TXT":   lookupTXT,
+}
+
+func lookupIP(network string) func(ctx context.Context, r *net.Resolver, name string) ([]string, error) {
+	return func(ctx context.Context, r *net.Resolver, name string) ([]string, error) {
+		ips, err := r.LookupIP(ctx, network, name)
+		if err != nil {
+			return nil, err
+		}
+		out := make([]string, len(ips))
+		for i, ip := range ips {
+			out[i] = ip.String()
+		}
+		return out, nil
+	}
+}
+
+// lookupCNAME returns nothing for a name that is not an alias: the resolver
+// answers with the name itself then.
+func lookupCNAME(ctx context.Context, r *net.Resolver, name string) ([]string, error) {
+	cname, err := r.LookupCNAME(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(strings.TrimSuffix(cname, "."), strings.TrimSuffix(name, ".")) {
+		return nil, nil
+	}
+	return []string{cname}, nil
+}
+
+func lookupMX(ctx context.Context, r *net.Resolver, name string) ([]string, error) {
+	mxs, err := r.LookupMX(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(mxs))
+	for i, mx := range mxs {
+		out[i] = fmt.Sprintf("%d %s", mx.Pref, mx.Host)
+	}
+	return out, nil
+}
+
+func lookupTXT(ctx context.Context, r *net.Resolver, name string) ([]string, error) {
+	return r.LookupTXT(ctx, name)
+}
+
+func lookupNS(ctx context.Context, r *net.Resolver, name string) ([]string, error) {
+	nss, err := r.LookupNS(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, len(nss))
+	for i, ns := range nss {
+		out[i] = ns.Host
+	}
+	return out, nil
+}
+
+// Answer is the result of one lookup, also the JSON output.
+type Answer struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Records []string `json:"records"`
+	// NotFound is set when the name or the type has no record, not an error.
+	NotFound bool   `json:"not_found,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Millis   int64  `json:"ms"`
+}
+
+func newResolver(server string, timeout time.Duration) *net.Resolver {
+	if server == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	d := net.Dialer{Timeout: timeout}
+	return &net.Resolver{
+		// only the pure Go resolver calls Dial; the cgo one would ask libc,
+		// which knows nothing of our server.
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			// network is udp, or tcp for truncated answers: keep it, change
+			// the address only.
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+func main() {
+	server := flag.String("server", "", "DNS server, host[:port] (default: the system's)")
+	types := flag.String("types", "A,AAAA,CNAME,MX,TXT", "comma-separated record types: "+typeList())
+	timeout := flag.Duration("timeout", 3*time.Second, "timeout of each lookup")
+	asJSON := flag.Bool("json", false, "print JSON")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: dig-lite [flags] name [name ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var wanted []string
+	for _, t := range strings.Split(*types, ",") {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if _, ok := lookups[t]; !ok {
+			fmt.Fprintf(os.Stderr, "dig-lite: unknown type %q, want %s\n", t, typeList())
+			os.Exit(2)
+		}
+		wanted = append(wanted, t)
+	}
+
+	answers := resolveAll(newResolver(*server, *timeout), flag.Args(), wanted, *timeout)
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(answers)
+	} else {
+		printText(answers)
+	}
+	for _, a := range answers {
+		if a.Error != "" {
+			os.Exit(1)
+		}
+	}
+}
+
+func typeList() string {
+	return "A, AAAA, CNAME, MX, NS, TXT"
+}
+
+// resolveAll runs every lookup in its own goroutine; the answers keep the
+// order of names and types, each goroutine writing its own slot.
+func resolveAll(r *net.Resolver, names, types []string, timeout time.Duration) []Answer {
+	answers := make([]Answer, len(names)*len(types))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		for j, typ := range types {
+			wg.Add(1)
+			go func(a *Answer) {
+				defer wg.Done()
+				ctx, cancel := context.WithTimeout(context.Background(), timeout)
+				defer cancel()
+				a.Name, a.Type = name, typ
+				start := time.Now()
+				records, err := lookups[typ](ctx, r, name)
+				a.Millis = time.Since(start).Milliseconds()
+				a.Records = records
+				if a.Records == nil {
+					a.Records = []string{}
+				}
+				var dnsErr *net.DNSError
+				var addrErr *net.AddrError
+				switch {
+				case err == nil:
+				case errors.As(err, &dnsErr) && dnsErr.IsNotFound:
+					a.NotFound = true
+				case errors.As(err, &addrErr):
+					// "no suitable address": the name has addresses, none of
+					// the family asked for.
+					a.NotFound = true
+				default:
+					a.Error = err.Error()
+				}
+			}(&answers[i*len(types)+j])
+		}
+	}
+	wg.Wait()
+	return answers
+}
+
+func printText(answers []Answer) {
+	for i, a := range answers {
+		if i == 0 || a.Name != answers[i-1].Name {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf(";; %s\n", a.Name)
+		}
+		switch {
+		case a.Error != "":
+			fmt.Printf("%-6s error: %s\n", a.Type, a.Error)
+		case len(a.Records) == 0:
+			fmt.Printf("%-6s (none)  %dms\n", a.Type, a.Millis)
+		default:
+			for k, rec := range a.Records {
+				if k == 0 {
+					fmt.Printf("%-6s %s  %dms\n", a.Type, rec, a.Millis)
+				} else {
+					fmt.Printf("%-6s %s\n", "", rec)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Command gogrep searches files for lines matching a regular expression.
+//
+// A walker lists the files and numbers them, a bounded pool of workers
+// searches them concurrently, and the printer writes the results in the order
+// of the numbers: a result that finishes early waits in a map until all the
+// files before it are printed. The output is therefore the same on every run,
+// however the workers are scheduled.
+//
+// Files with a NUL byte in their first 8KB are considered binary and skipped,
+// as grep does.
+//
+// Usage:
+//
+//	go run ./cmd/gogrep -r -n 'go func' golang_program_design_2024
+//	go run ./cmd/gogrep -i todo main.go util.go
+//
+// The exit status is 0 if a line matched, 1 if none did and 2 on error.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sync"
+)
+
+type job struct {
+	seq  int
+	path string
+}
+
+type result struct {
+	seq   int
+	path  string
+	lines []match
+	err   error
+}
+
+type match struct {
+	num  int
+	text string
+}
+
+const binaryProbe = 8000
+
+func main() {
+	ignoreCase := flag.Bool("i", false, "ignore case")
+	lineNumbers := flag.Bool("n", false, "print line numbers")
+	recursive := flag.Bool("r", false, "search directories recursively")
+	workers := flag.Int("j", runtime.NumCPU(), "files searched concurrently")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: gogrep [flags] pattern [path ...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	expr := flag.Arg(0)
+	if *ignoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "gogrep:", err)
+		os.Exit(2)
+	}
+	paths := flag.Args()[1:]
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	opts := options{lineNumbers: *lineNumbers, recursive: *recursive, workers: *workers}
+	os.Exit(run(re, paths, opts, os.Stdout, os.Stderr))
+}
+
+type options struct {
+	lineNumbers bool
+	recursive   bool
+	workers     int
+}
+
+// run searches paths, prints the matches to stdout and the errors to stderr,
+// and returns the exit status.
+func run(re *regexp.Regexp, paths []string, opts options, stdout, stderr io.Writer) int {
+	// like grep, name the file in front of each line unless a single file is searched.
+	showNames := opts.recursive || len(paths) > 1
+
+	jobs := make(chan job)
+	results := make(chan result)
+	walkErrs := make(chan error, 1)
+
+	go func() {
+		defer close(jobs)
+		walkErrs <- walk(paths, opts.recursive, jobs, stderr)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < max(1, opts.workers); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				lines, err := search(re, j.path)
+				results <- result{seq: j.seq, path: j.path, lines: lines, err: err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	out := bufio.NewWriter(stdout)
+	matched, failed := false, false
+
+	next := 0
+	waiting := make(map[int]result)
+	for r := range results {
+		waiting[r.seq] = r
+		for {
+			r, ok := waiting[next]
+			if !ok {
+				break
+			}
+			delete(waiting, next)
+			next++
+
+			if r.err != nil {
+				fmt.Fprintln(stderr, "gogrep:", r.err)
+				failed = true
+				continue
+			}
+			for _, m := range r.lines {
+				matched = true
+				if showNames {
+					fmt.Fprintf(out, "%s:", r.path)
+				}
+				if opts.lineNumbers {
+					fmt.Fprintf(out, "%d:", m.num)
+				}
+				fmt.Fprintln(out, m.text)
+			}
+		}
+	}
+
+	if err := <-walkErrs; err != nil {
+		failed = true
+	}
+	out.Flush()
+	switch {
+	case failed:
+		return 2
+	case !matched:
+		return 1
+	}
+	return 0
+}
+
+// walk sends the files to search, numbered in walk order. Errors are reported
+// as they happen; the last one is returned so the exit status reflects them.
+func walk(paths []string, recursive bool, jobs chan<- job, stderr io.Writer) error {
+	seq := 0
+	var last error
+	report := func(err error) {
+		fmt.Fprintln(stderr, "gogrep:", err)
+		last = err
+	}
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			report(err)
+			continue
+		}
+		if !info.IsDir() {
+			jobs <- job{seq, root}
+			seq++
+			continue
+		}
+		if !recursive {
+			report(fmt.Errorf("%s: is a directory", root))
+			continue
+		}
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				report(err)
+				return nil
+			}
+			if d.IsDir() {
+				if path != root && d.Name()[0] == '.' {
+					return filepath.SkipDir // .git and friends
+				}
+				return nil
+			}
+			if d.Type().IsRegular() {
+				jobs <- job{seq, path}
+				seq++
+			}
+			return nil
+		})
+	}
+	return last
+}
+
+func search(re *regexp.Regexp, path string) ([]match, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReaderSize(f, 64*1024)
+	head, err := br.Peek(binaryProbe)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	if bytes.IndexByte(head, 0) >= 0 {
+		return nil, nil
+	}
+
+	var lines []match
+	sc := bufio.NewScanner(br)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		if re.Match(sc.Bytes()) {
+			lines = append(lines, match{n, sc.Text()})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return lines, fmt.Errorf("%s: %w", path, err)
+	}
+	return lines, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// tree writes files, relative paths to contents, under a temp dir.
+func tree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestRun(t *testing.T) {
+	dir := tree(t, map[string]string{
+		"a.txt":       "hello\nskip\nhello world\n",
+		"b/c.txt":     "say hello\n",
+		"b/d.bin":     "hello\x00binary\nhello\n",
+		".git/config": "hello\n",
+		"e.txt":       "nothing\n",
+	})
+	for _, tt := range []struct {
+		name    string
+		pattern string
+		paths   []string // under DIR
+		opts    options
+		stdout  string // DIR stands for the temp dir
+		stderr  string // a part of it
+		status  int
+	}{
+		{"recursive", "hello", []string{"DIR"}, options{recursive: true, lineNumbers: true},
+			"DIR/a.txt:1:hello\nDIR/a.txt:3:hello world\nDIR/b/c.txt:1:say hello\n", "", 0},
+		{"one file", "hello", []string{"DIR/a.txt"}, options{}, "hello\nhello world\n", "", 0},
+		{"two files", "hello", []string{"DIR/b/c.txt", "DIR/a.txt"}, options{lineNumbers: true},
+			"DIR/b/c.txt:1:say hello\nDIR/a.txt:1:hello\nDIR/a.txt:3:hello world\n", "", 0},
+		{"binary", "hello", []string{"DIR/b/d.bin"}, options{}, "", "", 1},
+		{"dot dir given", "hello", []string{"DIR/.git"}, options{recursive: true}, "DIR/.git/config:hello\n", "", 0},
+		{"no match", "goodbye", []string{"DIR"}, options{recursive: true}, "", "", 1},
+		{"missing path", "world", []string{"DIR/missing", "DIR/a.txt"}, options{},
+			"DIR/a.txt:hello world\n", "no such file", 2},
+		{"directory without -r", "hello", []string{"DIR"}, options{}, "", "is a directory", 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+			for _, p := range tt.paths {
+				paths = append(paths, strings.Replace(p, "DIR", dir, 1))
+			}
+			tt.opts.workers = 4
+			var stdout, stderr strings.Builder
+			status := run(regexp.MustCompile(tt.pattern), paths, tt.opts, &stdout, &stderr)
+			if want := strings.ReplaceAll(tt.stdout, "DIR", dir); stdout.String() != want {
+				t.Errorf("stdout:\n%s\nwant\n%s", stdout.String(), want)
+			}
+			if tt.stderr == "" && stderr.Len() > 0 || !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("stderr %q, want %q", stderr.String(), tt.stderr)
+			}
+			if status != tt.status {
+				t.Errorf("exit status %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+// TestRunOrder searches files that take longer the earlier they come: the
+// workers finish them out of order, the output is in walk order still.
+func TestRunOrder(t *testing.T) {
+	files := make(map[string]string)
+	var want strings.Builder
+	for i := 0; i < 40; i++ {
+		name := fmt.Sprintf("f%02d.txt", i)
+		files[name] = strings.Repeat("filler line\n", (40-i)*50) + fmt.Sprintln("match", i)
+		fmt.Fprintf(&want, "DIR/%s:%d:match %d\n", name, (40-i)*50+1, i)
+	}
+	dir := tree(t, files)
+	opts := options{recursive: true, lineNumbers: true, workers: 8}
+	for i := 0; i < 5; i++ {
+		var stdout, stderr strings.Builder
+		if status := run(regexp.MustCompile("match"), []string{dir}, opts, &stdout, &stderr); status != 0 || stderr.Len() > 0 {
+			t.Fatalf("exit status %d, stderr %q", status, stderr.String())
+		}
+		if w := strings.ReplaceAll(want.String(), "DIR", dir); stdout.String() != w {
+			t.Fatalf("run %d, stdout:\n%s\nwant\n%s", i, stdout.String(), w)
+		}
+	}
+}
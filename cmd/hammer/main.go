@@ -0,0 +1,311 @@
+// Command hammer is a small HTTP load generator.
+//
+// N workers send requests for a fixed duration, optionally throttled to a
+// global request rate. Each worker records latencies in its own histogram, so
+// the hot path needs no lock; the histograms are sent over a channel when the
+// workers finish and merged into the final report. Live progress comes from
+// atomic counters read once per second.
+//
+// Usage:
+//
+//	go run ./cmd/hammer -c 50 -d 10s -rate 500 http://127.0.0.1:8080/todos
+//	go run ./cmd/hammer -demo    // against a built-in server with random latency
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func main() {
+	workers := flag.Int("c", 10, "concurrent workers")
+	duration := flag.Duration("d", 5*time.Second, "test duration")
+	rate := flag.Int("rate", 0, "total requests per second, 0 means as fast as possible")
+	method := flag.String("m", http.MethodGet, "HTTP method")
+	timeout := flag.Duration("timeout", 10*time.Second, "per request timeout")
+	demo := flag.Bool("demo", false, "hammer a built-in test server")
+	flag.Parse()
+
+	target := flag.Arg(0)
+	if *demo {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(time.Duration(rand.ExpFloat64() * float64(2*time.Millisecond)))
+			if rand.Intn(100) == 0 {
+				http.Error(w, "unlucky", http.StatusServiceUnavailable)
+				return
+			}
+			io.WriteString(w, "ok")
+		}))
+		defer srv.Close()
+		target = srv.URL
+	}
+	if target == "" {
+		fmt.Fprintln(os.Stderr, "usage: hammer [flags] url")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *duration)
+	defer cancel()
+
+	client := &http.Client{
+		Timeout: *timeout,
+		// the default keeps only 2 idle connections per host, far too few for N workers.
+		Transport: &http.Transport{MaxIdleConnsPerHost: *workers},
+	}
+
+	var sent, failed atomic.Int64
+	tokens := limiter(ctx, *rate)
+	results := make(chan *stats, *workers)
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			st := newStats()
+			defer func() { results <- st }()
+			for {
+				if tokens != nil {
+					if _, ok := <-tokens; !ok {
+						return
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				code, latency, err := do(ctx, client, *method, target)
+				if ctx.Err() != nil {
+					return // the test is over, this request was cut short
+				}
+				sent.Add(1)
+				if err != nil || code >= 500 {
+					failed.Add(1)
+				}
+				st.record(code, latency, err)
+			}
+		}()
+	}
+
+	go progress(ctx, start, &sent, &failed)
+	wg.Wait()
+	close(results)
+
+	total := newStats()
+	for st := range results {
+		total.merge(st)
+	}
+	total.report(os.Stdout, time.Since(start))
+}
+
+// limiter returns a channel receiving rate tokens per second, or nil for no
+// limit. It is closed when ctx is done, which also releases waiting workers.
+func limiter(ctx context.Context, rate int) <-chan struct{} {
+	if rate <= 0 {
+		return nil
+	}
+	tokens := make(chan struct{})
+	go func() {
+		defer close(tokens)
+		ticker := time.NewTicker(time.Second / time.Duration(rate))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// drop the token if every worker is busy: the rate is a ceiling,
+				// not a debt to be paid back in a burst later.
+				select {
+				case tokens <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return tokens
+}
+
+func do(ctx context.Context, client *http.Client, method, url string) (int, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, time.Since(start), err
+	}
+	// the body must be read to the end for the connection to be reused.
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, time.Since(start), nil
+}
+
+func progress(ctx context.Context, start time.Time, sent, failed *atomic.Int64) {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			elapsed := time.Since(start).Seconds()
+			fmt.Fprintf(os.Stderr, "%4.0fs  %8d requests  %6d failed  %8.1f req/s\n",
+				elapsed, sent.Load(), failed.Load(), float64(sent.Load())/elapsed)
+		}
+	}
+}
+
+// Latencies are counted in logarithmic buckets 5% wide, from 1µs to about an
+// hour: a fixed 440-slot array, cheap to record into and to merge, and
+// accurate to 5% for any percentile.
+const (
+	growth     = 1.05
+	numBuckets = 440
+)
+
+var logGrowth = math.Log(growth)
+
+func bucketOf(d time.Duration) int {
+	us := float64(d) / float64(time.Microsecond)
+	if us < 1 {
+		return 0
+	}
+	return min(numBuckets-1, int(math.Log(us)/logGrowth)+1)
+}
+
+// upperBound is the largest latency counted in bucket i.
+func upperBound(i int) time.Duration {
+	return time.Duration(math.Pow(growth, float64(i)) * float64(time.Microsecond))
+}
+
+type stats struct {
+	buckets [numBuckets]int64
+	count   int64
+	sum     time.Duration
+	max     time.Duration
+	codes   map[int]int64
+	errors  map[string]int64
+}
+
+func newStats() *stats {
+	return &stats{codes: make(map[int]int64), errors: make(map[string]int64)}
+}
+
+func (s *stats) record(code int, latency time.Duration, err error) {
+	if err != nil {
+		s.errors[err.Error()]++
+		return
+	}
+	s.codes[code]++
+	s.buckets[bucketOf(latency)]++
+	s.count++
+	s.sum += latency
+	s.max = max(s.max, latency)
+}
+
+func (s *stats) merge(o *stats) {
+	for i, n := range o.buckets {
+		s.buckets[i] += n
+	}
+	s.count += o.count
+	s.sum += o.sum
+	s.max = max(s.max, o.max)
+	for code, n := range o.codes {
+		s.codes[code] += n
+	}
+	for msg, n := range o.errors {
+		s.errors[msg] += n
+	}
+}
+
+func (s *stats) percentile(p float64) time.Duration {
+	rank := int64(math.Ceil(p / 100 * float64(s.count)))
+	var seen int64
+	for i, n := range s.buckets {
+		seen += n
+		if seen >= rank {
+			return min(upperBound(i), s.max)
+		}
+	}
+	return s.max
+}
+
+func (s *stats) report(w io.Writer, elapsed time.Duration) {
+	fmt.Fprintf(w, "\n%d responses in %v, %.1f req/s\n", s.count, elapsed.Round(time.Millisecond), float64(s.count)/elapsed.Seconds())
+	if s.count == 0 {
+		for msg, n := range s.errors {
+			fmt.Fprintf(w, "  error x%d: %s\n", n, msg)
+		}
+		return
+	}
+
+	fmt.Fprintf(w, "\nlatency  mean %v", (s.sum / time.Duration(s.count)).Round(time.Microsecond))
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		fmt.Fprintf(w, "  p%g %v", p, s.percentile(p).Round(time.Microsecond))
+	}
+	fmt.Fprintf(w, "  max %v\n\n", s.max.Round(time.Microsecond))
+
+	s.histogram(w, 10)
+
+	fmt.Fprintln(w, "\nstatus codes")
+	codes := make([]int, 0, len(s.codes))
+	for code := range s.codes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		fmt.Fprintf(w, "  %d  %d\n", code, s.codes[code])
+	}
+	for msg, n := range s.errors {
+		fmt.Fprintf(w, "  error x%d: %s\n", n, msg)
+	}
+}
+
+// histogram regroups the fine buckets into rows of equal width between the
+// fastest and the slowest response and draws them as bars.
+func (s *stats) histogram(w io.Writer, rows int) {
+	first, last := -1, 0
+	for i, n := range s.buckets {
+		if n > 0 {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	per := max(1, (last-first+rows)/rows)
+
+	var counts []int64
+	var bounds []time.Duration
+	var peak int64
+	for i := first; i <= last; i += per {
+		var n int64
+		for j := i; j < i+per && j <= last; j++ {
+			n += s.buckets[j]
+		}
+		counts = append(counts, n)
+		bounds = append(bounds, upperBound(min(i+per-1, last)))
+		peak = max(peak, n)
+	}
+	for i, n := range counts {
+		bar := strings.Repeat("■", int(40*n/peak))
+		fmt.Fprintf(w, "  <= %-10v %8d %s\n", bounds[i].Round(time.Microsecond), n, bar)
+	}
+}
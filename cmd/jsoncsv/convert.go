@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Cells hold JSON values in a form that reads naturally in a spreadsheet but
+// still round-trips:
+//
+//	number, true, false, null, array   as their JSON text: 42, true, [1,2]
+//	string                             as is: hello
+//	string that looks like JSON         quoted as JSON: "42", "true", ""
+//	missing key                        empty cell
+//
+// The only thing lost is an empty nested object, which has no columns. Keys
+// containing a dot come back as nested objects.
+
+func encodeCell(raw json.RawMessage) (string, error) {
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", err
+		}
+		if s != "" && !json.Valid([]byte(s)) {
+			return s, nil
+		}
+		return string(raw), nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// decodeCell is the reverse of encodeCell, reporting false for a missing key.
+func decodeCell(cell string) (json.RawMessage, bool) {
+	if cell == "" {
+		return nil, false
+	}
+	if json.Valid([]byte(cell)) {
+		return json.RawMessage(cell), true
+	}
+	quoted, _ := json.Marshal(cell)
+	return quoted, true
+}
+
+// forEachRecord decodes the top-level array one element at a time, so only
+// the current record is ever in memory.
+func forEachRecord(r io.Reader, fn func(n int, rec json.RawMessage) error) error {
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('[') {
+		return fmt.Errorf("input must be a JSON array of objects, starts with %v", tok)
+	}
+	for n := 1; dec.More(); n++ {
+		var rec json.RawMessage
+		if err := dec.Decode(&rec); err != nil {
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+		if err := fn(n, rec); err != nil {
+			return fmt.Errorf("record %d: %w", n, err)
+		}
+	}
+	_, err := dec.Token() // the closing ']'
+	return err
+}
+
+// flatten calls emit for every leaf of the object, with its dotted path, in
+// the order of the document.
+func flatten(obj json.RawMessage, prefix string, emit func(key string, value json.RawMessage) error) error {
+	dec := json.NewDecoder(bytes.NewReader(obj))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return fmt.Errorf("not an object")
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := prefix + tok.(string)
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		if bytes.HasPrefix(value, []byte("{")) {
+			if err := flatten(value, key+".", emit); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := emit(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// header collects the union of the flattened keys, in order of first appearance.
+func header(r io.Reader) ([]string, error) {
+	var keys []string
+	seen := make(map[string]bool)
+	err := forEachRecord(r, func(_ int, rec json.RawMessage) error {
+		return flatten(rec, "", func(key string, _ json.RawMessage) error {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+			return nil
+		})
+	})
+	return keys, err
+}
+
+// jsonToCSV writes the records of r as CSV rows under the given header.
+func jsonToCSV(w io.Writer, r io.Reader, keys []string) error {
+	col := make(map[string]int, len(keys))
+	for i, k := range keys {
+		col[k] = i
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(keys); err != nil {
+		return err
+	}
+	row := make([]string, len(keys))
+	err := forEachRecord(r, func(_ int, rec json.RawMessage) error {
+		clear(row)
+		err := flatten(rec, "", func(key string, value json.RawMessage) error {
+			i, ok := col[key]
+			if !ok {
+				return fmt.Errorf("key %q missing from the header", key)
+			}
+			cell, err := encodeCell(value)
+			row[i] = cell
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return cw.Write(row)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// node is a key of the nested object rebuilt from the dotted header.
+type node struct {
+	key      string
+	col      int // the column of a leaf, -1 for an object
+	children []*node
+}
+
+func buildTree(keys []string) (*node, error) {
+	root := &node{col: -1}
+	for i, k := range keys {
+		n := root
+		parts := strings.Split(k, ".")
+		for j, part := range parts {
+			var child *node
+			for _, c := range n.children {
+				if c.key == part {
+					child = c
+				}
+			}
+			leaf := j == len(parts)-1
+			switch {
+			case child == nil:
+				child = &node{key: part, col: -1}
+				n.children = append(n.children, child)
+			case leaf || child.col >= 0:
+				return nil, fmt.Errorf("column %q conflicts with another column", k)
+			}
+			if leaf {
+				child.col = i
+			}
+			n = child
+		}
+	}
+	return root, nil
+}
+
+// writeObject writes the object of n for one row, leaving out missing keys
+// and the objects left empty by them. It reports whether it wrote anything.
+func (n *node) writeObject(buf *bytes.Buffer, row []string) bool {
+	start := buf.Len()
+	buf.WriteByte('{')
+	wrote := false
+	for _, c := range n.children {
+		mark := buf.Len()
+		if wrote {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(c.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		ok := false
+		if c.col >= 0 {
+			var value json.RawMessage
+			if c.col < len(row) {
+				value, ok = decodeCell(row[c.col])
+			}
+			buf.Write(value)
+		} else {
+			ok = c.writeObject(buf, row)
+		}
+		if !ok {
+			buf.Truncate(mark)
+			continue
+		}
+		wrote = true
+	}
+	buf.WriteByte('}')
+	if !wrote && n.key != "" {
+		buf.Truncate(start)
+	}
+	return wrote
+}
+
+// csvToJSON writes the rows of r as a JSON array, one object per line.
+func csvToJSON(w io.Writer, r io.Reader) error {
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	keys, err := cr.Read()
+	if err != nil {
+		return fmt.Errorf("reading the header: %w", err)
+	}
+	tree, err := buildTree(append([]string(nil), keys...))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("[")
+	for n := 0; ; n++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('\n')
+		tree.writeObject(&buf, row)
+		// flush every row: the buffer only ever holds one record.
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+		buf.Reset()
+	}
+	buf.WriteString("\n]\n")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
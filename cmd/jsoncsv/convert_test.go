@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// toCSV runs both passes of the JSON to CSV conversion.
+func toCSV(t *testing.T, in string) string {
+	t.Helper()
+	keys, err := header(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("header: %v", err)
+	}
+	var out bytes.Buffer
+	if err := jsonToCSV(&out, strings.NewReader(in), keys); err != nil {
+		t.Fatalf("jsonToCSV: %v", err)
+	}
+	return out.String()
+}
+
+func toJSON(t *testing.T, in string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := csvToJSON(&out, strings.NewReader(in)); err != nil {
+		t.Fatalf("csvToJSON: %v", err)
+	}
+	return out.String()
+}
+
+func records(t *testing.T, doc string) []json.RawMessage {
+	t.Helper()
+	var recs []json.RawMessage
+	if err := json.Unmarshal([]byte(doc), &recs); err != nil {
+		t.Fatalf("%v in\n%s", err, doc)
+	}
+	return recs
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		csv  string
+	}{
+		{
+			"flat",
+			`[{"id": 1, "name": "Ann"}, {"id": 2, "name": "Bob"}]`,
+			"id,name\n1,Ann\n2,Bob\n",
+		},
+		{
+			"union of the keys, in order of appearance",
+			`[{"a": 1}, {"b": 2, "a": 3}, {"c": null}]`,
+			"a,b,c\n1,,\n3,2,\n,,null\n",
+		},
+		{
+			"nested objects",
+			`[{"id": 1, "address": {"city": "Seoul", "geo": {"lat": 37.5, "lng": 127}}}, {"id": 2, "address": {"city": "Busan"}}]`,
+			"id,address.city,address.geo.lat,address.geo.lng\n1,Seoul,37.5,127\n2,Busan,,\n",
+		},
+		{
+			"every kind of value",
+			`[{"n": -1.5e3, "t": true, "f": false, "z": null, "arr": [1, "two", {"x": 3}], "s": "plain"}]`,
+			"n,t,f,z,arr,s\n-1.5e3,true,false,null,\"[1,\"\"two\"\",{\"\"x\"\":3}]\",plain\n",
+		},
+		{
+			"strings that look like JSON stay strings",
+			`[{"a": "42", "b": "true", "c": "null", "d": "", "e": "[1]", "f": "\"quoted\""}]`,
+			"a,b,c,d,e,f\n\"\"\"42\"\"\",\"\"\"true\"\"\",\"\"\"null\"\"\",\"\"\"\"\"\",\"\"\"[1]\"\"\",\"\"\"\\\"\"quoted\\\"\"\"\"\"\n",
+		},
+		{
+			"commas, quotes, newlines and unicode",
+			`[{"text": "a, \"b\"\nc", "name": "한글 ✓"}]`,
+			"text,name\n\"a, \"\"b\"\"\nc\",한글 ✓\n",
+		},
+		{
+			"a missing nested object",
+			`[{"user": {"name": "x"}}, {"id": 7}]`,
+			"user.name,id\nx,\n,7\n",
+		},
+		{"no records", `[]`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.csv == "" {
+				if keys, err := header(strings.NewReader(tt.in)); err != nil || len(keys) != 0 {
+					t.Errorf("header = %q, %v; want none", keys, err)
+				}
+				return
+			}
+			got := toCSV(t, tt.in)
+			if got != tt.csv {
+				t.Fatalf("CSV:\n%s\nwant:\n%s", got, tt.csv)
+			}
+			back := records(t, toJSON(t, got))
+			want := records(t, tt.in)
+			if len(back) != len(want) {
+				t.Fatalf("%d records back, want %d", len(back), len(want))
+			}
+			for i := range want {
+				if !sameJSON(want[i], back[i]) {
+					t.Errorf("record %d: %s after the round trip, want %s", i+1, back[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+// TestLossy shows the two documented losses: an empty nested object has no
+// column, and a dotted key comes back nested.
+func TestLossy(t *testing.T) {
+	back := records(t, toJSON(t, toCSV(t, `[{"a": 1, "empty": {}, "b.c": 2}]`)))
+	if want := json.RawMessage(`{"a": 1, "b": {"c": 2}}`); len(back) != 1 || !sameJSON(back[0], want) {
+		t.Errorf("got %s, want %s", back, want)
+	}
+}
+
+func TestCSVToJSON(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty cells dropped", "a,b.c,b.d\n,,\n", `[{}]`},
+		{"plain text is a string", "a,b\nhello world,3.0\n", `[{"a": "hello world", "b": 3.0}]`},
+		{"header only", "a,b\n", `[]`},
+	}
+	for _, tt := range tests {
+		got := records(t, toJSON(t, tt.in))
+		want := records(t, tt.want)
+		if len(got) != len(want) {
+			t.Errorf("%s: %d records, want %d", tt.name, len(got), len(want))
+			continue
+		}
+		for i := range want {
+			if !sameJSON(got[i], want[i]) {
+				t.Errorf("%s: record %d = %s, want %s", tt.name, i+1, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, in := range []string{
+		``,
+		`{"a": 1}`,
+		`[{"a": 1}, 2]`,
+		`[{"a": 1}, ["x"]]`,
+		`[{"a": 1}, {"b": `,
+		`[{"a": 1}`,
+	} {
+		if _, err := header(strings.NewReader(in)); err == nil {
+			t.Errorf("header(%q) succeeded", in)
+		}
+	}
+
+	for _, in := range []string{
+		"",
+		"a,a.b\n1,2\n", // a is both a value and an object
+		"a.b,a\n1,2\n", // the same, the other way round
+		"a,a\n1,2\n",   // a twice
+		"a,b\n\"1,2\n", // an unterminated quote
+		"a,b,c\n1\n",   // a short row
+	} {
+		var out bytes.Buffer
+		if err := csvToJSON(&out, strings.NewReader(in)); err == nil {
+			t.Errorf("csvToJSON(%q) succeeded: %s", in, out.String())
+		}
+	}
+
+	// a key the header does not have: the two passes saw different input.
+	var out bytes.Buffer
+	if err := jsonToCSV(&out, strings.NewReader(`[{"a": 1, "b": 2}]`), []string{"a"}); err == nil {
+		t.Error("jsonToCSV with a key missing from the header succeeded")
+	}
+}
+
+// TestStreaming feeds both conversions through pipes: output must come out
+// while the input is still being written, so neither holds the whole input.
+func TestStreaming(t *testing.T) {
+	const n = 1000
+	record := func(i int) string {
+		return fmt.Sprintf(`{"id": %d, "user": {"name": "user %d", "tags": ["a", "b"]}}`, i, i)
+	}
+
+	t.Run("json to csv", func(t *testing.T) {
+		inR, inW := io.Pipe()
+		outR, outW := io.Pipe()
+		go func() {
+			outW.CloseWithError(jsonToCSV(outW, inR, []string{"id", "user.name", "user.tags"}))
+		}()
+		// the input stays open until the first rows were read.
+		release := make(chan struct{})
+		go func() {
+			io.WriteString(inW, "[")
+			for i := 0; i < n; i++ {
+				if i > 0 {
+					io.WriteString(inW, ",")
+				}
+				io.WriteString(inW, record(i))
+			}
+			<-release
+			io.WriteString(inW, "]")
+			inW.Close()
+		}()
+		lines := bufio.NewScanner(outR)
+		readLine := func() string {
+			done := make(chan bool)
+			go func() { done <- lines.Scan() }()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("no output while the input is open")
+			}
+			return lines.Text()
+		}
+		if h := readLine(); h != "id,user.name,user.tags" {
+			t.Fatalf("header %q", h)
+		}
+		if row := readLine(); row != `0,user 0,"[""a"",""b""]"` {
+			t.Fatalf("first row %q", row)
+		}
+		close(release)
+		rows := 1
+		for lines.Scan() {
+			rows++
+		}
+		if err := lines.Err(); err != nil || rows != n {
+			t.Errorf("%d rows, %v; want %d", rows, err, n)
+		}
+	})
+
+	t.Run("csv to json", func(t *testing.T) {
+		inR, inW := io.Pipe()
+		outR, outW := io.Pipe()
+		go func() { outW.CloseWithError(csvToJSON(outW, inR)) }()
+		go func() {
+			io.WriteString(inW, "id,user.name\n1,ann\n")
+			// the input stays open until the first record was read.
+		}()
+		buf := make([]byte, 64)
+		got := make(chan string)
+		go func() {
+			k, _ := io.ReadAtLeast(outR, buf, len("[\n{\"id\":1,\"user\":{\"name\":\"ann\"}}"))
+			got <- string(buf[:k])
+		}()
+		select {
+		case s := <-got:
+			if s != "[\n{\"id\":1,\"user\":{\"name\":\"ann\"}}" {
+				t.Errorf("first record %q", s)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("no output while the input is open")
+		}
+		io.WriteString(inW, "2,bob\n")
+		inW.Close()
+		rest, err := io.ReadAll(outR)
+		if err != nil || string(rest) != ",\n{\"id\":2,\"user\":{\"name\":\"bob\"}}\n]\n" {
+			t.Errorf("rest %q, %v", rest, err)
+		}
+	})
+}
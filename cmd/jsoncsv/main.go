@@ -0,0 +1,234 @@
+// Command jsoncsv converts an array of JSON objects to CSV and back.
+//
+// The CSV header is the union of the keys of all the objects, in order of
+// first appearance; nested objects are flattened into dotted columns
+// ("address.city"), and unflattened again on the way back. Neither direction
+// loads the whole input: records are decoded and written one at a time. To
+// know the header before writing the first row, JSON input is read twice, so
+// standard input is first copied to a temporary file.
+//
+// Usage:
+//
+//	go run ./cmd/jsoncsv users.json > users.csv
+//	go run ./cmd/jsoncsv -o users.json users.csv
+//	cat users.json | go run ./cmd/jsoncsv -to csv
+//	go run ./cmd/jsoncsv -roundtrip users.json   // convert there and back, compare
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+func main() {
+	to := flag.String("to", "", `output format, "csv" or "json"; guessed from the input extension if empty`)
+	output := flag.String("o", "", "output file, standard output if empty")
+	roundtrip := flag.Bool("roundtrip", false, "convert a JSON file to CSV and back and check nothing changed")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: jsoncsv [-to csv|json] [-o output] [input]\n       jsoncsv -roundtrip input.json")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	input := flag.Arg(0)
+	if *roundtrip {
+		if input == "" {
+			flag.Usage()
+			os.Exit(2)
+		}
+		if err := checkRoundtrip(input); err != nil {
+			fmt.Fprintln(os.Stderr, "jsoncsv:", err)
+			os.Exit(1)
+		}
+		fmt.Println("ok:", input, "survives JSON -> CSV -> JSON")
+		return
+	}
+
+	if *to == "" {
+		switch strings.ToLower(filepath.Ext(input)) {
+		case ".json":
+			*to = "csv"
+		case ".csv":
+			*to = "json"
+		default:
+			fmt.Fprintln(os.Stderr, "jsoncsv: cannot guess the direction, use -to")
+			os.Exit(2)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "jsoncsv:", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+
+	var err error
+	switch *to {
+	case "csv":
+		err = convertJSON(bw, input)
+	case "json":
+		err = convertCSV(bw, input)
+	default:
+		err = fmt.Errorf("unknown format %q", *to)
+	}
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "jsoncsv:", err)
+		os.Exit(1)
+	}
+}
+
+// convertJSON reads the input twice: once for the header, once for the rows.
+func convertJSON(w io.Writer, input string) error {
+	f, err := openSeekable(input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	keys, err := header(bufio.NewReader(f))
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no columns: the objects have no values")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return jsonToCSV(w, bufio.NewReader(f), keys)
+}
+
+func convertCSV(w io.Writer, input string) error {
+	if input == "" {
+		return csvToJSON(w, bufio.NewReader(os.Stdin))
+	}
+	f, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return csvToJSON(w, bufio.NewReader(f))
+}
+
+// openSeekable opens the input, or spools standard input to a temporary file
+// (deleted on Close) so that it can be read a second time.
+func openSeekable(input string) (*os.File, error) {
+	if input != "" {
+		return os.Open(input)
+	}
+	tmp, err := os.CreateTemp("", "jsoncsv-*.json")
+	if err != nil {
+		return nil, err
+	}
+	// unlinked right away: the open file stays readable until closed.
+	os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, os.Stdin); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	return tmp, nil
+}
+
+// checkRoundtrip converts the file to CSV and back through temporary files,
+// then compares the two JSON documents record by record.
+func checkRoundtrip(input string) error {
+	dir, err := os.MkdirTemp("", "jsoncsv")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	csvPath, jsonPath := filepath.Join(dir, "out.csv"), filepath.Join(dir, "back.json")
+
+	if err := convertTo(csvPath, func(w io.Writer) error { return convertJSON(w, input) }); err != nil {
+		return err
+	}
+	if err := convertTo(jsonPath, func(w io.Writer) error { return convertCSV(w, csvPath) }); err != nil {
+		return err
+	}
+
+	want, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer want.Close()
+	got, err := os.Open(jsonPath)
+	if err != nil {
+		return err
+	}
+	defer got.Close()
+
+	// walk both arrays in step, so the check is as streaming as the conversion.
+	back := make(chan json.RawMessage)
+	go func() {
+		defer close(back)
+		forEachRecord(bufio.NewReader(got), func(_ int, rec json.RawMessage) error {
+			back <- rec
+			return nil
+		})
+	}()
+	count := 0
+	err = forEachRecord(bufio.NewReader(want), func(n int, rec json.RawMessage) error {
+		other, ok := <-back
+		if !ok {
+			return fmt.Errorf("missing after the round trip")
+		}
+		count = n
+		if !sameJSON(rec, other) {
+			return fmt.Errorf("changed by the round trip:\n  before %s\n  after  %s", rec, other)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if _, extra := <-back; extra {
+		return fmt.Errorf("the round trip produced more than %d records", count)
+	}
+	return nil
+}
+
+func convertTo(path string, convert func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	if err := convert(bw); err != nil {
+		f.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// sameJSON compares values rather than text: key order and spacing may differ.
+func sameJSON(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,319 @@
+// Command learn lists the lessons of every chapter and runs one by name.
+//
+// The lessons of the first chapters are functions registered in
+// internal/lessons by their packages, imported below: they run in this
+// process. The other chapters are programs, a main package of their own
+// (12.performance/pgo), some in a module of their own that this one cannot
+// import (08.database/cmd/database): learn finds them by walking the
+// chapters and runs them with go run.
+//
+// Usage (from the repo root):
+//
+//	go run ./cmd/learn list
+//	go run ./cmd/learn list 04.concurrent
+//	go run ./cmd/learn run 04.concurrent/channel
+//	go run ./cmd/learn run 05.standard_lib/ast ../04.concurrent
+//	go run ./cmd/learn run 12.performance/lookup -test.benchtime 100ms
+//	go run ./cmd/learn run 08.database/cmd/database migrate
+//
+// The arguments after the name are the lesson's own: os.Args for a
+// registered lesson, the program arguments for go run.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/01.basics"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/01.basics/exercise"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/03.interface"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/sync"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/06.generics"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/07.testing"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/13.reflection"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/15.sorting"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/16.probabilistic"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/17.io"
+	_ "github.com/YongSangUn/learn-golang/golang_program_design_2024/18.filesystem"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+// entry is a line of the listing: a registered lesson, or a program found
+// on disk.
+type entry struct {
+	id      string
+	summary string
+	program *program // nil for a registered lesson
+}
+
+func (e entry) chapter() string {
+	chapter, _, _ := strings.Cut(e.id, "/")
+	return chapter
+}
+
+// program is a main package found under the chapters root, run with go run
+// from the root of its module.
+type program struct {
+	module string // directory of the go.mod
+	target string // what go run is given, relative to module
+}
+
+func main() {
+	root := flag.String("root", "golang_program_design_2024", "directory holding the chapters")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: learn [-root dir] list [chapter]")
+		fmt.Fprintln(os.Stderr, "       learn [-root dir] run name [args...]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if _, err := os.Stat(*root); err != nil {
+		log.Fatalf("run learn from the root of the repository: %v", err)
+	}
+
+	entries, err := discover(*root)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch flag.Arg(0) {
+	case "", "list":
+		list(entries, flag.Arg(1))
+	case "run":
+		if flag.NArg() < 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		os.Exit(run(*root, entries, flag.Arg(1), flag.Args()[2:]))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// discover returns the registered lessons and the programs under root,
+// sorted by id.
+func discover(root string) ([]entry, error) {
+	var entries []entry
+	for _, l := range lessons.All() {
+		entries = append(entries, entry{id: l.ID, summary: l.Summary})
+	}
+
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if d.Name() == "testdata" {
+			return filepath.SkipDir
+		}
+		found, err := programs(root, p)
+		entries = append(entries, found...)
+		return err
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].id < entries[j].id })
+	return entries, err
+}
+
+// programs returns the programs of the directory dir. A directory with a
+// single main() is one program, run as a package; a directory with several
+// holds one program per file, each run on its own as the file says.
+func programs(root, dir string) ([]entry, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var mains []*ast.File
+	var names []string
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), name, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		if f.Name.Name == "main" && hasMain(f) {
+			mains = append(mains, f)
+			names = append(names, name)
+		}
+	}
+	if len(mains) == 0 {
+		return nil, nil
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	module, err := moduleDir(abs)
+	if err != nil {
+		return nil, err
+	}
+	rel := func(p string) string {
+		r, _ := filepath.Rel(root, p)
+		return filepath.ToSlash(r)
+	}
+	if len(mains) == 1 {
+		target, _ := filepath.Rel(module, abs)
+		return []entry{{
+			id:      rel(dir),
+			summary: summary(mains[0]),
+			program: &program{module: module, target: "./" + filepath.ToSlash(target)},
+		}}, nil
+	}
+	out := make([]entry, len(mains))
+	for i, f := range mains {
+		target, _ := filepath.Rel(module, filepath.Join(abs, filepath.Base(names[i])))
+		out[i] = entry{
+			id:      strings.TrimSuffix(rel(names[i]), ".go"),
+			summary: summary(f),
+			program: &program{module: module, target: filepath.ToSlash(target)},
+		}
+	}
+	return out, nil
+}
+
+func hasMain(f *ast.File) bool {
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "main" {
+			return true
+		}
+	}
+	return false
+}
+
+// moduleDir returns the nearest directory at or above the absolute path dir
+// with a go.mod: 08.database and a few others are modules of their own.
+func moduleDir(dir string) (string, error) {
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			return d, nil
+		}
+		if filepath.Dir(d) == d {
+			return "", fmt.Errorf("no go.mod above %s", dir)
+		}
+	}
+}
+
+// summary is the first sentence of the comment describing the program: the
+// package comment, or else the first comment after the imports, where the
+// chapters keep their /* ... */ lesson text.
+func summary(f *ast.File) string {
+	doc := f.Doc
+	if doc == nil {
+		after := f.Name.End()
+		for _, decl := range f.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				after = gen.End()
+			}
+		}
+		for _, c := range f.Comments {
+			if c.Pos() > after {
+				doc = c
+				break
+			}
+		}
+	}
+	if doc == nil {
+		return ""
+	}
+	para, _, _ := strings.Cut(doc.Text(), "\n\n")
+	if strings.HasPrefix(para, "Usage") {
+		return ""
+	}
+	text := strings.Join(strings.Fields(para), " ")
+	if i := strings.Index(text, ". "); i >= 0 {
+		text = text[:i+1]
+	}
+	if r := []rune(text); len(r) > 90 {
+		text = string(r[:87]) + "..."
+	}
+	return text
+}
+
+// list prints the entries of the chapter, or all of them, chapter by
+// chapter.
+func list(entries []entry, chapter string) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	last := ""
+	for _, e := range entries {
+		if chapter != "" && e.chapter() != chapter && !strings.HasPrefix(e.id, chapter+"/") {
+			continue
+		}
+		if e.chapter() != last {
+			if last != "" {
+				fmt.Fprintln(tw)
+			}
+			fmt.Fprintln(tw, e.chapter())
+			last = e.chapter()
+		}
+		kind := "lesson"
+		if e.program != nil {
+			kind = "go run"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\n", e.id, kind, e.summary)
+	}
+	tw.Flush()
+	if last == "" {
+		fmt.Fprintf(os.Stderr, "no chapter %s\n", chapter)
+	}
+}
+
+// run runs the entry named id and returns the exit status.
+func run(root string, entries []entry, id string, args []string) int {
+	id = strings.TrimSuffix(strings.TrimSuffix(id, "/"), ".go")
+	for _, e := range entries {
+		if e.id != id {
+			continue
+		}
+		if e.program != nil {
+			return goRun(e.program, args)
+		}
+		l, _ := lessons.Find(id)
+		if err := os.Chdir(filepath.Join(root, filepath.FromSlash(l.Dir()))); err != nil {
+			log.Fatal(err)
+		}
+		os.Args = append([]string{l.ID}, args...)
+		l.Run()
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "learn: no lesson %s, see learn list\n", id)
+	base := id[strings.LastIndex(id, "/")+1:]
+	for _, e := range entries {
+		if strings.Contains(e.id, base) {
+			fmt.Fprintf(os.Stderr, "  did you mean %s?\n", e.id)
+		}
+	}
+	return 2
+}
+
+func goRun(p *program, args []string) int {
+	cmd := exec.Command("go", append([]string{"run", p.target}, args...)...)
+	cmd.Dir = p.module
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	err := cmd.Run()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		return exit.ExitCode()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+// Command runner executes an example binary inside the sandbox and prints its
+// output together with how the run ended.
+//
+// Usage:
+//
+//	go build -o /tmp/learn ./cmd/learn
+//	go run ./cmd/runner -timeout 3s -mem 128 /tmp/learn run 04.concurrent/goroutine
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/sandbox"
+)
+
+func main() {
+	sandbox.Init()
+
+	limits := sandbox.DefaultLimits
+	flag.DurationVar(&limits.WallTime, "timeout", limits.WallTime, "wall clock limit")
+	flag.DurationVar(&limits.CPUTime, "cpu", limits.CPUTime, "CPU time limit")
+	mem := flag.Uint64("mem", limits.Memory>>20, "memory limit in MB")
+	flag.IntVar(&limits.MaxOutput, "output", limits.MaxOutput, "output limit in bytes")
+	flag.Parse()
+	limits.Memory = *mem << 20
+
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: runner [flags] binary [args...]")
+		os.Exit(2)
+	}
+
+	res, err := sandbox.Run(context.Background(), limits, flag.Arg(0), flag.Args()[1:]...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	os.Stdout.Write(res.Output)
+
+	status := fmt.Sprintf("exit code %d", res.ExitCode)
+	switch {
+	case res.TimedOut:
+		status = "killed: timeout"
+	case res.Truncated:
+		status = "killed: output limit reached"
+	}
+	fmt.Fprintf(os.Stderr, "\n--- %s after %v\n", status, res.Duration.Round(time.Millisecond))
+}
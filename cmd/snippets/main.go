@@ -0,0 +1,292 @@
+// Command snippets splits the chapter files into one runnable program per demo.
+//
+// cmd/learn runs a whole lesson file; snippets goes one step further. For
+// every lesson file, snippets finds the demo functions called from the
+// function the file registers in internal/lessons (or from main(), in a
+// program) and writes a copy of the file whose main() calls just that demo:
+//
+//	golang_program_design_2024/04.concurrent/channel.go: selectChannel()
+//	  -> examples/04.concurrent/channel/selectChannel/main.go
+//
+// Usage (from the repo root):
+//
+//	go run ./cmd/snippets
+//	go run ./examples/04.concurrent/channel/selectChannel
+//
+// The examples directory is generated and not committed.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	src := flag.String("src", "golang_program_design_2024", "directory holding the chapters")
+	out := flag.String("out", "examples", "output directory, removed and regenerated")
+	flag.Parse()
+
+	if err := os.RemoveAll(*out); err != nil {
+		log.Fatal(err)
+	}
+
+	var count int
+	err := filepath.WalkDir(*src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(p, ".go") || strings.HasSuffix(p, "_test.go") {
+			return err
+		}
+		n, err := extractFile(*src, p, *out)
+		count += n
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("generated %d examples in %s\n", count, *out)
+}
+
+// extractFile writes one program per demo of the file at p and returns how many.
+func extractFile(root, p, out string) (int, error) {
+	src, err := os.ReadFile(p)
+	if err != nil {
+		return 0, err
+	}
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, p, src, parser.ParseComments)
+	if err != nil {
+		return 0, err
+	}
+
+	funcs := make(map[string]*ast.FuncDecl)
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil {
+			funcs[fn.Name.Name] = fn
+		}
+	}
+	mainFunc := entryPoint(file, funcs)
+	if mainFunc == nil {
+		return 0, nil
+	}
+
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return 0, err
+	}
+	dir := filepath.Join(out, strings.TrimSuffix(rel, ".go"))
+
+	demos := findDemos(mainFunc, funcs)
+	for _, demo := range demos {
+		code, err := render(fset, file, src, filepath.ToSlash(p), mainFunc, demo)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %s: %w", p, demo.Name.Name, err)
+		}
+		target := filepath.Join(dir, demo.Name.Name, "main.go")
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return 0, err
+		}
+		if err := os.WriteFile(target, code, 0o644); err != nil {
+			return 0, err
+		}
+	}
+	return len(demos), nil
+}
+
+// entryPoint returns the function that runs the whole file: main in a
+// program, the function given to lessons.Register in a lesson.
+func entryPoint(file *ast.File, funcs map[string]*ast.FuncDecl) *ast.FuncDecl {
+	if file.Name.Name == "main" {
+		return funcs["main"]
+	}
+	for _, decl := range file.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == "init" {
+			if name := registered(fn); name != "" {
+				return funcs[name]
+			}
+		}
+	}
+	return nil
+}
+
+// registered returns the name of the function that init passes to
+// lessons.Register, if it does.
+func registered(init *ast.FuncDecl) string {
+	var name string
+	ast.Inspect(init.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) != 3 {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "Register" {
+			if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "lessons" {
+				if ident, ok := call.Args[2].(*ast.Ident); ok {
+					name = ident.Name
+				}
+			}
+		}
+		return true
+	})
+	return name
+}
+
+// findDemos returns the top-level functions called from main that take no
+// arguments and return nothing or just an error, in call order and without
+// duplicates. Helpers such as a closure generator are not demos on their own.
+func findDemos(mainFunc *ast.FuncDecl, funcs map[string]*ast.FuncDecl) []*ast.FuncDecl {
+	var demos []*ast.FuncDecl
+	seen := make(map[string]bool)
+	ast.Inspect(mainFunc.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) > 0 {
+			return true
+		}
+		ident, ok := call.Fun.(*ast.Ident)
+		if !ok || seen[ident.Name] {
+			return true
+		}
+		fn, ok := funcs[ident.Name]
+		if !ok || fn == mainFunc || fn.Type.Params.NumFields() > 0 {
+			return true
+		}
+		if results := fn.Type.Results; results.NumFields() == 0 || (results.NumFields() == 1 && types(results) == "error") {
+			seen[ident.Name] = true
+			demos = append(demos, fn)
+		}
+		return true
+	})
+	return demos
+}
+
+// render builds the source of one example: every declaration of the original
+// file except its entry point (and the init registering a lesson), copied
+// verbatim with its doc comment, plus a new main.
+func render(fset *token.FileSet, file *ast.File, src []byte, origin string, entry, demo *ast.FuncDecl) ([]byte, error) {
+	var body bytes.Buffer
+	for _, decl := range file.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			continue
+		}
+		if fn, ok := decl.(*ast.FuncDecl); ok && (fn == entry || fn.Recv == nil && fn.Name.Name == "init" && registered(fn) != "") {
+			continue
+		}
+		body.Write(source(fset, src, decl))
+		body.WriteString("\n\n")
+	}
+
+	name := demo.Name.Name
+	body.WriteString("func main() {\n")
+	if demo.Type.Results.NumFields() == 0 {
+		fmt.Fprintf(&body, "\t%s()\n", name)
+	} else {
+		fmt.Fprintf(&body, "\tif err := %s(); err != nil {\n\t\tfmt.Fprintln(os.Stderr, err)\n\t\tos.Exit(1)\n\t}\n", name)
+	}
+	body.WriteString("}\n")
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by cmd/snippets from %s; DO NOT EDIT.\n\n", origin)
+	buf.WriteString("package main\n\n")
+	buf.WriteString(imports(file, body.Bytes()))
+	buf.Write(body.Bytes())
+	return format.Source(buf.Bytes())
+}
+
+// source returns the text of decl including its doc comment.
+func source(fset *token.FileSet, src []byte, decl ast.Decl) []byte {
+	start := decl.Pos()
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+	case *ast.GenDecl:
+		if d.Doc != nil {
+			start = d.Doc.Pos()
+		}
+	}
+	return src[fset.Position(start).Offset:fset.Position(decl.End()).Offset]
+}
+
+func types(fields *ast.FieldList) string {
+	var names []string
+	for _, f := range fields.List {
+		if ident, ok := f.Type.(*ast.Ident); ok {
+			names = append(names, ident.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// imports keeps the imports of file that body still uses: an import that was
+// only used by the dropped entry point would otherwise not compile. The body is
+// parsed again, since the generated main may add fmt and os.
+func imports(file *ast.File, body []byte) string {
+	parsed, err := parser.ParseFile(token.NewFileSet(), "", append([]byte("package main\n"), body...), 0)
+	if err != nil {
+		return ""
+	}
+	used := make(map[string]bool)
+	ast.Inspect(parsed, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+		return true
+	})
+
+	paths := make(map[string]string) // import path -> alias
+	for _, imp := range file.Imports {
+		p, _ := strconv.Unquote(imp.Path.Value)
+		name := path.Base(p)
+		alias := ""
+		if imp.Name != nil {
+			name, alias = imp.Name.Name, imp.Name.Name
+		}
+		if used[name] || name == "_" || name == "." {
+			paths[p] = alias
+		}
+	}
+	for _, std := range []string{"fmt", "os"} {
+		if _, ok := paths[std]; !ok && used[std] {
+			paths[std] = ""
+		}
+	}
+
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	// standard library first, then the rest, like goimports does.
+	var std, other strings.Builder
+	for _, p := range sorted {
+		group := &std
+		if strings.Contains(strings.SplitN(p, "/", 2)[0], ".") {
+			group = &other
+		}
+		fmt.Fprintf(group, "\t%s %q\n", paths[p], p)
+	}
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	b.WriteString(std.String())
+	if other.Len() > 0 {
+		b.WriteString("\n")
+		b.WriteString(other.String())
+	}
+	b.WriteString(")\n\n")
+	return b.String()
+}
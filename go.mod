@@ -0,0 +1,5 @@
+module github.com/YongSangUn/learn-golang
+
+go 1.22
+
+require golang.org/x/sync v0.7.0
@@ -1,8 +1,17 @@
-package main
+package basics
 
-import "fmt"
+import (
+	"fmt"
 
-func main() {
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+func init() {
+	lessons.Register("01.basics/anon_func_and_closures", "anonymous functions as callbacks, closures", DemoClosures)
+}
+
+// DemoClosures passes functions as callbacks and keeps state in a closure.
+func DemoClosures() {
 	fmt.Println("-> callback")
 	traverse([]int{1, 2, 3}, func(n int) {
 		fmt.Println(n * n)
@@ -0,0 +1,24 @@
+package main
+
+import (
+	basics "github.com/YongSangUn/learn-golang/golang_program_design_2024/01.basics"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/01.basics/exercise"
+)
+
+/*
+basics runs the lessons of chapter 01.basics, in order.
+
+Usage:
+
+	go run ./golang_program_design_2024/01.basics/cmd/basics
+
+One lesson alone: go run ./cmd/learn run 01.basics/defer
+*/
+
+func main() {
+	basics.DemoFunctions()
+	basics.DemoClosures()
+	basics.DemoDefer()
+	basics.DemoErrors()
+	exercise.DemoFibonacci()
+}
@@ -1,6 +1,10 @@
-package main
+package basics
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
 
 /*
 The underlying principle of defer is to use a stack (Last In First Out principle) to store each deferred function.
@@ -24,7 +28,12 @@ func multipleDefers() {
 	fmt.Println("Function body")
 }
 
-func main() {
+func init() {
+	lessons.Register("01.basics/defer", "defer runs last in, first out", DemoDefer)
+}
+
+// DemoDefer shows deferred calls running last in, first out.
+func DemoDefer() {
 	example()
 	multipleDefers()
 
@@ -0,0 +1,2 @@
+// Package basics is chapter 01.basics: functions, closures and defer.
+package basics
@@ -0,0 +1,235 @@
+package basics
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+An error in Go is a value: any type with an Error() string method. A function
+that can fail returns it last, and the caller checks it right away:
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("parse port %q: %w", s, err)
+	}
+
+- Sentinel errors are package-level values compared by identity:
+  io.EOF, sql.ErrNoRows. Callers test them with errors.Is.
+- Custom error types carry data (a field, an id, a status code). Callers
+  get at it with errors.As.
+- fmt.Errorf with %w wraps an error: the message gets context, and the
+  original stays reachable through Unwrap. With %v the message is the same
+  but the chain is cut: errors.Is no longer finds the sentinel.
+- errors.Join (Go 1.20) makes one error of several, for validation or for
+  the results of many goroutines.
+- panic is for bugs, the "cannot happen" of the program: an index out of
+  range, a nil map write. Expected failures (a missing file, bad input) are
+  errors. recover, in a deferred function, turns a panic back into an error
+  at a boundary, e.g. a server that must not die with one bad request.
+*/
+
+func init() {
+	lessons.Register("01.basics/errors", "sentinel errors, custom types, %w, errors.Is/As/Join, panic vs error", DemoErrors)
+}
+
+// DemoErrors runs the error handling demos.
+func DemoErrors() {
+	fmt.Println("-> sentinel errors and wrapping")
+	wrapping()
+
+	fmt.Println("-> custom error types")
+	customTypes()
+
+	fmt.Println("-> errors.Join")
+	joining()
+
+	fmt.Println("-> errors from goroutines")
+	fromGoroutines()
+
+	fmt.Println("-> panic vs error")
+	panics()
+}
+
+// ErrNotFound is a sentinel error: callers compare against it with errors.Is.
+var ErrNotFound = errors.New("not found")
+
+var users = map[int]string{1: "alice", 2: "bob"}
+
+func findUser(id int) (string, error) {
+	name, ok := users[id]
+	if !ok {
+		// %w: the message says which user, and the sentinel is still there.
+		return "", fmt.Errorf("find user %d: %w", id, ErrNotFound)
+	}
+	return name, nil
+}
+
+func wrapping() {
+	_, err := findUser(3)
+	fmt.Println(err)
+	fmt.Println("== ErrNotFound:", err == ErrNotFound)               // false: err is the wrapper
+	fmt.Println("errors.Is:", errors.Is(err, ErrNotFound))           // true: Is walks the chain
+	fmt.Println("unwrapped:", errors.Unwrap(err) == ErrNotFound)     // one level down
+	outer := fmt.Errorf("load profile: %w", err)                     // wrap again: the chain grows
+	fmt.Println(outer, "| Is:", errors.Is(outer, ErrNotFound))       // still true
+	cut := fmt.Errorf("load profile: %v", err)                       // %v formats, does not wrap
+	fmt.Println(cut, "| Is:", errors.Is(cut, ErrNotFound))           // false: the chain is gone
+	both := fmt.Errorf("%w; then %w", ErrNotFound, strconv.ErrRange) // several %w (Go 1.20)
+	fmt.Println(both, "| Is range:", errors.Is(both, strconv.ErrRange))
+}
+
+// ValidationError is a custom error type: the caller can read Field.
+type ValidationError struct {
+	Field string
+	Msg   string
+}
+
+func (e *ValidationError) Error() string { return e.Field + ": " + e.Msg }
+
+func parseAge(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("parse age: %w", err) // wraps a *strconv.NumError
+	}
+	if n < 0 || n > 150 {
+		return 0, &ValidationError{Field: "age", Msg: fmt.Sprintf("%d is out of range", n)}
+	}
+	return n, nil
+}
+
+// mayFail returns a nil *ValidationError as an error: the classic trap.
+func mayFail() error {
+	var e *ValidationError // nil pointer
+	return e               // an interface holding (type *ValidationError, nil): not nil
+}
+
+func customTypes() {
+	for _, in := range []string{"42", "200", "abc"} {
+		_, err := parseAge(in)
+		var verr *ValidationError
+		var numErr *strconv.NumError
+		switch {
+		case err == nil:
+			fmt.Printf("%q: ok\n", in)
+		case errors.As(err, &verr): // As finds the first error of that type in the chain
+			fmt.Printf("%q: invalid field %s (%s)\n", in, verr.Field, verr.Msg)
+		case errors.As(err, &numErr): // works through the %w of parseAge too
+			fmt.Printf("%q: not a number, %s failed on %q\n", in, numErr.Func, numErr.Num)
+		}
+	}
+	// return nil, not a typed nil pointer, from a function returning error.
+	fmt.Println("typed nil is nil:", mayFail() == nil)
+}
+
+type signup struct {
+	Name  string
+	Email string
+	Age   string
+}
+
+// validate returns every problem at once, joined, rather than the first.
+func validate(s signup) error {
+	var errs []error
+	if s.Name == "" {
+		errs = append(errs, &ValidationError{Field: "name", Msg: "is required"})
+	}
+	if s.Email == "" {
+		errs = append(errs, &ValidationError{Field: "email", Msg: "is required"})
+	}
+	if _, err := parseAge(s.Age); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...) // nil when errs is empty
+}
+
+func joining() {
+	err := validate(signup{Age: "-1"})
+	fmt.Println(err) // one line per error
+	var verr *ValidationError
+	fmt.Println("As finds the first:", errors.As(err, &verr), verr.Field)
+	// a joined error has Unwrap() []error: the way to get at all of them.
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		fmt.Println("errors joined:", len(joined.Unwrap()))
+	}
+	fmt.Println("valid signup:", validate(signup{Name: "carol", Email: "c@example.com", Age: "30"}))
+}
+
+// TaskError says which task failed and why; Unwrap keeps the cause
+// reachable for errors.Is and errors.As.
+type TaskError struct {
+	ID  int
+	Err error
+}
+
+func (e *TaskError) Error() string { return fmt.Sprintf("task %d: %v", e.ID, e.Err) }
+func (e *TaskError) Unwrap() error { return e.Err }
+
+var errTimeout = errors.New("timeout")
+
+// fromGoroutines is handleChannelError of 04.concurrent/channel.go, with the
+// errors collected instead of printed: every task sends its error, or nil,
+// and the caller gets them all as one, each still inspectable.
+func fromGoroutines() {
+	const tasks = 5
+	errCh := make(chan error, tasks)
+	var wg sync.WaitGroup
+	for i := 0; i < tasks; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				errCh <- &TaskError{ID: i, Err: errTimeout}
+				return
+			}
+			errCh <- nil
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+
+	var errs []error
+	for err := range errCh {
+		errs = append(errs, err) // errors.Join skips the nils
+	}
+	err := errors.Join(errs...)
+	fmt.Println(err)
+	fmt.Println("any timeout:", errors.Is(err, errTimeout))
+	var te *TaskError
+	if errors.As(err, &te) {
+		fmt.Println("first failed task received:", te.ID)
+	}
+}
+
+// safeDivide turns the panic of an integer division by zero into an error:
+// recover only works in a deferred function, and only in the goroutine that
+// panicked.
+func safeDivide(a, b int) (q int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("recovered: %v", r) // the named result is set after the panic
+		}
+	}()
+	return a / b, nil
+}
+
+// mustAtoi panics instead of returning an error: only for input that cannot
+// be wrong, like a constant in the program. The Must prefix warns the caller,
+// as regexp.MustCompile does.
+func mustAtoi(s string) int {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+func panics() {
+	fmt.Println(safeDivide(10, 2))
+	fmt.Println(safeDivide(1, 0))
+	fmt.Println("mustAtoi:", mustAtoi("8080"))
+}
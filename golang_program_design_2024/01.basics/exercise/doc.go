@@ -0,0 +1,2 @@
+// Package exercise holds the exercises of chapter 01.basics.
+package exercise
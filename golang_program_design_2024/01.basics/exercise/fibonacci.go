@@ -1,6 +1,10 @@
-package main
+package exercise
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
 
 // fibonacci is a function that returns
 // a function that returns an int.
@@ -14,7 +18,12 @@ func fibonacci() func() int {
 	}
 }
 
-func main() {
+func init() {
+	lessons.Register("01.basics/exercise/fibonacci", "exercise: fibonacci numbers from a closure", DemoFibonacci)
+}
+
+// DemoFibonacci prints the first ten fibonacci numbers, from a closure.
+func DemoFibonacci() {
 	f := fibonacci()
 	for i := 0; i < 10; i++ {
 		fmt.Println(f())
@@ -1,8 +1,17 @@
-package main
+package basics
 
-import "fmt"
+import (
+	"fmt"
 
-func main() {
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+func init() {
+	lessons.Register("01.basics/func", "variadic parameters, passing by value and by pointer", DemoFunctions)
+}
+
+// DemoFunctions calls a variadic function and passes values and pointers.
+func DemoFunctions() {
 	fmt.Println("-> mult params")
 	fmt.Println(sum(1, 2, 3, 4))
 
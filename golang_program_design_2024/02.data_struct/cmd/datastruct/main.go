@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	datastruct "github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct"
+)
+
+/*
+datastruct runs the lessons of chapter 02.data_struct, in order.
+
+Usage:
+
+	go run ./golang_program_design_2024/02.data_struct/cmd/datastruct
+
+One lesson alone: go run ./cmd/learn run 02.data_struct/map
+*/
+
+func main() {
+	datastruct.DemoArraysAndSlices()
+	datastruct.DemoMaps()
+	failed := datastruct.DemoStructs()
+	datastruct.DemoContainers()
+	datastruct.DemoGraph()
+	datastruct.DemoHeap()
+	if failed != nil {
+		fmt.Fprintln(os.Stderr, failed)
+		os.Exit(1)
+	}
+}
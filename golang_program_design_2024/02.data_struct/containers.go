@@ -0,0 +1,135 @@
+package datastruct
+
+import (
+	"container/list"
+	"fmt"
+	"strings"
+
+	mylist "github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/list"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/queue"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/stack"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+Beyond the built-in slices and maps, the standard library has one linked
+list, container/list, from before generics: its elements hold an any.
+
+	l := list.New()
+	e := l.PushBack(42)
+	n := e.Value.(int) // a type assertion at every read, a panic if wrong
+
+The packages next to this file are generic:
+
+	list.Singly[T]   forward links only: push at both ends, pop at the front
+	list.Doubly[T]   container/list with a T: nodes to insert at, move, remove
+	stack.Stack[T]   on a slice; stack.Linked[T] on a linked list
+	queue.Queue[T]   on a ring buffer; queue.Linked[T] on a linked list
+
+Each has All, an iterator: a func(yield func(T) bool), called with a
+function returning false to stop early. Go 1.23 ranges over it directly;
+this module is at Go 1.22.
+
+Which one: a slice, nearly always. A linked list pays an allocation per
+element and a pointer jump per step; it is worth it for O(1) removal at a
+node already in hand, like the LRU cache in lru. A stack is a slice with
+Push and Pop; a queue is a ring buffer, not s = s[1:], which keeps moving
+forward in its array.
+*/
+
+func init() {
+	lessons.Register("02.data_struct/containers", "linked lists, stacks and queues, generic, against container/list", DemoContainers)
+}
+
+// DemoContainers uses container/list, then the generic lists, stacks and
+// queues of the chapter.
+func DemoContainers() {
+	containerList()
+	genericLists()
+	stacksAndQueues()
+}
+
+// join returns what the iterator all yields, separated by spaces.
+func join[T any](all func(yield func(T) bool)) string {
+	var parts []string
+	all(func(v T) bool {
+		parts = append(parts, fmt.Sprint(v))
+		return true
+	})
+	return strings.Join(parts, " ")
+}
+
+func containerList() {
+	l := list.New()
+	l.PushBack(1)
+	two := l.PushBack(2)
+	l.PushBack(3)
+	l.MoveToFront(two)
+
+	sum := 0
+	for e := l.Front(); e != nil; e = e.Next() {
+		sum += e.Value.(int) // any: the assertion is on the reader
+	}
+	fmt.Println("container/list:", sum)
+
+	l.PushBack("four") // compiles: nothing says the list holds ints
+	func() {
+		defer func() { fmt.Println("  recovered:", recover()) }()
+		for e := l.Front(); e != nil; e = e.Next() {
+			sum += e.Value.(int)
+		}
+	}()
+}
+
+func genericLists() {
+	var d mylist.Doubly[int]
+	d.PushBack(1)
+	two := d.PushBack(2)
+	d.PushBack(3)
+	d.MoveToFront(two)
+	// d.PushBack("four") does not compile: a Doubly[int] holds ints
+	fmt.Println("Doubly:", join(d.All()), "| backward:", join(d.Backward()))
+
+	var s mylist.Singly[string]
+	for _, w := range []string{"a", "b", "c"} {
+		s.PushBack(w)
+	}
+	s.Reverse()
+	fmt.Println("Singly reversed:", join(s.All()))
+
+	// the first two only: the iterator stops when the function says so
+	var firstTwo []int
+	d.All()(func(v int) bool {
+		firstTwo = append(firstTwo, v)
+		return len(firstTwo) < 2
+	})
+	fmt.Println("first two:", firstTwo)
+}
+
+func stacksAndQueues() {
+	var st stack.Stack[string]
+	var q queue.Queue[string]
+	for _, w := range []string{"first", "second", "third"} {
+		st.Push(w)
+		q.Push(w)
+	}
+	top, _ := st.Pop()
+	front, _ := q.Pop()
+	fmt.Printf("stack pops %q, queue pops %q\n", top, front) // last in vs first in
+
+	// a queue on a plain slice: s = s[1:] moves forward and leaves the
+	// start of its array behind, cap shrinking from the front
+	s := make([]int, 0, 4)
+	for i := 0; i < 4; i++ {
+		s = append(s, i)
+	}
+	s = s[2:]
+	fmt.Printf("slice queue after 2 pops: len %d, cap %d: 2 slots lost until the next copy\n", len(s), cap(s))
+
+	var ring queue.Queue[int]
+	for i := 0; i < 20; i++ {
+		ring.Push(i)
+		ring.Pop() // push one, pop one: the ring reuses its 8 slots
+	}
+	fmt.Println("ring queue after 20 pushes and pops: len", ring.Len())
+}
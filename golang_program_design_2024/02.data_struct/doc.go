@@ -0,0 +1,6 @@
+// Package datastruct is chapter 02.data_struct: arrays, slices, maps and
+// structs, then containers built on them. The generic packages next to it
+// are the containers themselves: sliceutil, lru, the set, orderedmap and
+// skiplist of map.go, the list, stack and queue of containers.go, the graph
+// of graph.go and the heap of heap.go.
+package datastruct
@@ -0,0 +1,149 @@
+package datastruct
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/graph"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/workerpool"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A graph is vertices and edges between them; the graph package keeps them as
+adjacency lists, a map from each vertex to the edges out of it. The
+alternative, an adjacency matrix, answers "is there an edge from u to v" in
+O(1) but takes V² memory: for the sparse graphs of real data, a few edges
+per vertex, lists win.
+
+	BFS    a queue: the vertices by distance, the shortest paths in edges
+	DFS    recursion (a stack): as deep as possible first; the base of
+	       cycle detection and of topological sorting
+	Dijkstra  a priority queue (container/heap): shortest paths by weight
+
+The drawings below are the trees the traversals build: the edge each vertex
+was first reached by.
+*/
+
+func init() {
+	lessons.Register("02.data_struct/graph", "graphs: BFS, DFS, topological sort, cycles, Dijkstra, a BFS on a worker pool", DemoGraph)
+}
+
+// DemoGraph traverses, sorts and searches small graphs, drawing the
+// traversals.
+func DemoGraph() {
+	traversals()
+	topoAndCycles()
+	shortestPaths()
+	concurrentBFS()
+}
+
+// city is the map of the demos: roads, in minutes.
+func city() *graph.Graph[string] {
+	g := graph.New[string](false)
+	for _, r := range []struct {
+		from, to string
+		minutes  float64
+	}{
+		{"home", "bakery", 4},
+		{"home", "park", 7},
+		{"bakery", "school", 3},
+		{"bakery", "station", 9},
+		{"park", "station", 2},
+		{"school", "library", 8},
+		{"station", "library", 3},
+	} {
+		g.AddEdge(r.from, r.to, r.minutes)
+	}
+	return g
+}
+
+func traversals() {
+	g := city()
+	bfs, _ := g.BFS("home")
+	fmt.Println("BFS from home:", strings.Join(bfs.Order, " → "))
+	for depth, level := range bfs.Levels() {
+		fmt.Printf("  level %d: %s\n", depth, strings.Join(level, " "))
+	}
+	fmt.Print(indent(bfs.Tree()))
+
+	dfs, _ := g.DFS("home")
+	fmt.Println("DFS from home:", strings.Join(dfs.Order, " → "))
+	fmt.Print(indent(dfs.Tree()))
+}
+
+func indent(s string) string {
+	return "  " + strings.ReplaceAll(strings.TrimSuffix(s, "\n"), "\n", "\n  ") + "\n"
+}
+
+func topoAndCycles() {
+	// an edge from each package to the ones importing it
+	deps := graph.New[string](true)
+	for _, e := range [][2]string{
+		{"errors", "fmt"}, {"io", "fmt"}, {"io", "bufio"}, {"fmt", "log"}, {"bufio", "main"}, {"log", "main"},
+	} {
+		deps.AddEdge(e[0], e[1], 1)
+	}
+	order, err := deps.TopoSort()
+	fmt.Println("build order:", order, err)
+
+	deps.AddEdge("main", "io", 1) // io imports main: an import cycle
+	_, err = deps.TopoSort()
+	fmt.Println("with main -> io:", err)
+	fmt.Println("city has a cycle:", city().FindCycle())
+}
+
+func shortestPaths() {
+	g := city()
+	path, minutes, err := g.ShortestPath("home", "library")
+	fmt.Printf("home to library: %s, %g minutes %v\n", strings.Join(path, " → "), minutes, err)
+	bfs, _ := g.BFS("home")
+	fmt.Printf("  the fewest roads, by BFS: %d, through %s, %g minutes\n",
+		bfs.Depth["library"], bfs.Parent["library"], sumPath(g, bfsPath(bfs, "library")))
+}
+
+// bfsPath returns the path from the start of t to v in its tree.
+func bfsPath(t *graph.Traversal[string], v string) []string {
+	path := []string{v}
+	for v != t.Start {
+		v = t.Parent[v]
+		path = append([]string{v}, path...)
+	}
+	return path
+}
+
+// sumPath returns the length of path in g, the lightest edge between two
+// vertices counting.
+func sumPath(g *graph.Graph[string], path []string) float64 {
+	total := 0.0
+	for i := 1; i < len(path); i++ {
+		best := -1.0
+		for _, e := range g.Edges(path[i-1]) {
+			if e.To == path[i] && (best < 0 || e.Weight < best) {
+				best = e.Weight
+			}
+		}
+		total += best
+	}
+	return total
+}
+
+func concurrentBFS() {
+	g := city()
+	slowVisit := func(string) error { time.Sleep(20 * time.Millisecond); return nil }
+
+	start := time.Now()
+	bfs, _ := g.BFS("home")
+	for _, v := range bfs.Order {
+		slowVisit(v)
+	}
+	fmt.Printf("visiting %d places one by one: %s\n", len(bfs.Order), time.Since(start).Round(10*time.Millisecond))
+
+	pool := workerpool.New(4, 8)
+	defer pool.Shutdown(context.Background())
+	start = time.Now()
+	levels, err := g.BFSConcurrent(pool, "home", slowVisit)
+	fmt.Printf("a level at a time on 4 workers: %s, levels %v %v\n", time.Since(start).Round(10*time.Millisecond), levels, err)
+}
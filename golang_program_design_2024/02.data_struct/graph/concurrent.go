@@ -0,0 +1,58 @@
+package graph
+
+import (
+	"fmt"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/workerpool"
+)
+
+// BFSConcurrent visits the vertices reachable from start breadth first,
+// like BFS, calling visit on each; the calls of a level run at the same
+// time, on the workers of pool. It returns the levels, as Traversal.Levels
+// would, and the first error of visit, which stops it after that level.
+//
+// The levels are a barrier: the next one is only known once the current
+// one is expanded, and it is expanded after every visit of it returned.
+// That keeps the result the same as BFS, whatever the order the workers
+// finish in. It is worth it when visit is slow, a request or a file to
+// read per vertex; reading the adjacency lists, it would only add the cost
+// of the goroutines. visit must be safe for concurrent use.
+func (g *Graph[V]) BFSConcurrent(pool *workerpool.Pool, start V, visit func(V) error) ([][]V, error) {
+	if !g.Has(start) {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownVertex, start)
+	}
+	seen := map[V]bool{start: true}
+	var levels [][]V
+	for level := []V{start}; len(level) > 0; {
+		levels = append(levels, level)
+		results := make([]<-chan error, 0, len(level))
+		for _, v := range level {
+			res, err := pool.Submit(func() error { return visit(v) })
+			if err != nil {
+				return levels, err
+			}
+			results = append(results, res)
+		}
+		var first error
+		for _, res := range results {
+			if err := <-res; err != nil && first == nil {
+				first = err
+			}
+		}
+		if first != nil {
+			return levels, first
+		}
+
+		var next []V
+		for _, v := range level {
+			for _, e := range g.adj[v] {
+				if !seen[e.To] {
+					seen[e.To] = true
+					next = append(next, e.To)
+				}
+			}
+		}
+		level = next
+	}
+	return levels, nil
+}
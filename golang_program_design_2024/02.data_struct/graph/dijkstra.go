@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	// ErrNoPath is returned by ShortestPath when to cannot be reached.
+	ErrNoPath = errors.New("graph: no path")
+	// ErrNegativeWeight is returned by Dijkstra for a graph with an edge of
+	// negative weight, for which its answer could be wrong.
+	ErrNegativeWeight = errors.New("graph: negative weight")
+)
+
+// Paths is the result of Dijkstra from From: the distance to every vertex
+// reached, and the vertex before it on a shortest path.
+type Paths[V comparable] struct {
+	From V
+	Dist map[V]float64
+	Prev map[V]V
+}
+
+// To returns the shortest path to v, From first, and its length; false if
+// v was not reached.
+func (p *Paths[V]) To(v V) ([]V, float64, bool) {
+	d, ok := p.Dist[v]
+	if !ok {
+		return nil, math.Inf(1), false
+	}
+	path := []V{v}
+	for v != p.From {
+		v = p.Prev[v]
+		path = append(path, v)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, d, true
+}
+
+// item is a vertex in the priority queue of Dijkstra, with the distance it
+// was queued at.
+type item[V comparable] struct {
+	v    V
+	dist float64
+}
+
+// minQueue is a binary min-heap of items by distance, through
+// container/heap: heap.Push and heap.Pop keep the order, with these five
+// methods to reach the slice.
+type minQueue[V comparable] []item[V]
+
+func (q minQueue[V]) Len() int           { return len(q) }
+func (q minQueue[V]) Less(i, j int) bool { return q[i].dist < q[j].dist }
+func (q minQueue[V]) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *minQueue[V]) Push(x any)        { *q = append(*q, x.(item[V])) }
+func (q *minQueue[V]) Pop() any {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// Dijkstra returns the shortest paths from from to every vertex it
+// reaches, the length of a path being the sum of its weights.
+//
+// It settles the vertices closest first, from a priority queue of
+// distances: once the closest one left is taken, no path through the others
+// can be shorter, as long as no weight is negative. A vertex whose
+// distance improves is pushed again rather than updated in the heap; the
+// stale entries are skipped when they come out. O((V+E) log V).
+func (g *Graph[V]) Dijkstra(from V) (*Paths[V], error) {
+	if !g.Has(from) {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownVertex, from)
+	}
+	for _, v := range g.order {
+		for _, e := range g.adj[v] {
+			if e.Weight < 0 {
+				return nil, fmt.Errorf("%w: %v -> %v is %g", ErrNegativeWeight, v, e.To, e.Weight)
+			}
+		}
+	}
+	p := &Paths[V]{From: from, Dist: map[V]float64{from: 0}, Prev: make(map[V]V)}
+	done := make(map[V]bool)
+	q := &minQueue[V]{{v: from}}
+	for q.Len() > 0 {
+		it := heap.Pop(q).(item[V])
+		if done[it.v] {
+			continue // a stale entry: settled at a shorter distance already
+		}
+		done[it.v] = true
+		for _, e := range g.adj[it.v] {
+			d := it.dist + e.Weight
+			if old, ok := p.Dist[e.To]; !ok || d < old {
+				p.Dist[e.To] = d
+				p.Prev[e.To] = it.v
+				heap.Push(q, item[V]{v: e.To, dist: d})
+			}
+		}
+	}
+	return p, nil
+}
+
+// ShortestPath returns the shortest path from from to to, both included,
+// and its length. It is ErrNoPath if to cannot be reached.
+func (g *Graph[V]) ShortestPath(from, to V) ([]V, float64, error) {
+	if !g.Has(to) {
+		return nil, 0, fmt.Errorf("%w: %v", ErrUnknownVertex, to)
+	}
+	p, err := g.Dijkstra(from)
+	if err != nil {
+		return nil, 0, err
+	}
+	path, d, ok := p.To(to)
+	if !ok {
+		return nil, 0, fmt.Errorf("%w from %v to %v", ErrNoPath, from, to)
+	}
+	return path, d, nil
+}
@@ -0,0 +1,87 @@
+// Package graph is a graph of comparable vertices kept as adjacency lists,
+// directed or not, each edge with a weight, and the classic algorithms on
+// it:
+//
+//	BFS, DFS       traversals, as a Traversal: the order, the tree, the levels
+//	TopoSort       an order where every edge goes forward (directed graphs)
+//	FindCycle      a cycle, if there is one
+//	ShortestPath   Dijkstra, for weights of 0 or more
+//	BFSConcurrent  a BFS visiting each level on a workerpool.Pool
+//
+// Everything is deterministic: vertices come out in the order they were
+// added, and neighbors in the order of their edges, never in the random
+// order of a map. Tests and the ASCII drawings of Traversal.Tree depend on
+// it.
+package graph
+
+import "errors"
+
+// ErrUnknownVertex is returned for a vertex that is not in the graph.
+var ErrUnknownVertex = errors.New("graph: unknown vertex")
+
+// Edge is an edge to To, as stored in the adjacency list of its origin.
+type Edge[V comparable] struct {
+	To     V
+	Weight float64
+}
+
+// Graph is a graph of vertices of type V. An undirected graph stores each
+// edge twice, once in the list of each end. The zero value is not usable:
+// make one with New.
+type Graph[V comparable] struct {
+	directed bool
+	adj      map[V][]Edge[V]
+	order    []V // the vertices, in the order they were added
+}
+
+// New returns an empty graph, directed or not.
+func New[V comparable](directed bool) *Graph[V] {
+	return &Graph[V]{directed: directed, adj: make(map[V][]Edge[V])}
+}
+
+// Directed reports whether the edges of g have a direction.
+func (g *Graph[V]) Directed() bool { return g.directed }
+
+// AddVertex adds v, if it is not already in g.
+func (g *Graph[V]) AddVertex(v V) {
+	if _, ok := g.adj[v]; !ok {
+		g.adj[v] = nil
+		g.order = append(g.order, v)
+	}
+}
+
+// AddEdge adds an edge from from to to, both ends added as vertices if
+// needed; both ways in an undirected graph. Edges are not merged: adding
+// the same edge twice makes two.
+func (g *Graph[V]) AddEdge(from, to V, weight float64) {
+	g.AddVertex(from)
+	g.AddVertex(to)
+	g.adj[from] = append(g.adj[from], Edge[V]{To: to, Weight: weight})
+	if !g.directed && from != to {
+		g.adj[to] = append(g.adj[to], Edge[V]{To: from, Weight: weight})
+	}
+}
+
+// Has reports whether v is a vertex of g.
+func (g *Graph[V]) Has(v V) bool {
+	_, ok := g.adj[v]
+	return ok
+}
+
+// Len returns the number of vertices.
+func (g *Graph[V]) Len() int { return len(g.order) }
+
+// Vertices returns the vertices in the order they were added.
+func (g *Graph[V]) Vertices() []V { return append([]V(nil), g.order...) }
+
+// Edges returns the edges out of v, in the order they were added.
+func (g *Graph[V]) Edges(v V) []Edge[V] { return append([]Edge[V](nil), g.adj[v]...) }
+
+// Neighbors returns the vertices the edges out of v go to.
+func (g *Graph[V]) Neighbors(v V) []V {
+	out := make([]V, len(g.adj[v]))
+	for i, e := range g.adj[v] {
+		out[i] = e.To
+	}
+	return out
+}
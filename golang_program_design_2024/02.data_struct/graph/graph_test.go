@@ -0,0 +1,261 @@
+package graph_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/graph"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/workerpool"
+)
+
+// build returns a graph of the edges "A-B" (weight 1) or "A-B:2.5".
+func build(directed bool, edges ...string) *graph.Graph[string] {
+	g := graph.New[string](directed)
+	for _, e := range edges {
+		w := 1.0
+		if spec, weight, ok := strings.Cut(e, ":"); ok {
+			fmt.Sscan(weight, &w)
+			e = spec
+		}
+		from, to, _ := strings.Cut(e, "-")
+		g.AddEdge(from, to, w)
+	}
+	return g
+}
+
+// tree is the graph of the drawings:
+//
+//	A - B - D
+//	|   |
+//	C - E   F - G
+var tree = []string{"A-B", "A-C", "B-D", "B-E", "C-E", "F-G"}
+
+func TestGraph(t *testing.T) {
+	g := build(false, tree...)
+	g.AddVertex("A") // already there: no change
+	g.AddVertex("H")
+	if g.Len() != 8 || !slices.Equal(g.Vertices(), []string{"A", "B", "C", "D", "E", "F", "G", "H"}) {
+		t.Errorf("vertices %v", g.Vertices())
+	}
+	if !slices.Equal(g.Neighbors("B"), []string{"A", "D", "E"}) {
+		t.Errorf("neighbors of B: %v", g.Neighbors("B"))
+	}
+	d := build(true, tree...)
+	if !slices.Equal(d.Neighbors("B"), []string{"D", "E"}) || len(d.Edges("D")) != 0 {
+		t.Errorf("directed: neighbors of B %v, of D %v", d.Neighbors("B"), d.Neighbors("D"))
+	}
+	if g.Directed() || !d.Directed() || g.Has("Z") {
+		t.Error("Directed or Has is wrong")
+	}
+}
+
+func TestTraversals(t *testing.T) {
+	g := build(false, tree...)
+	bfs, err := g.BFS("A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(bfs.Order, []string{"A", "B", "C", "D", "E"}) {
+		t.Errorf("BFS order %v", bfs.Order)
+	}
+	wantLevels := [][]string{{"A"}, {"B", "C"}, {"D", "E"}}
+	if levels := bfs.Levels(); !slices.EqualFunc(levels, wantLevels, slices.Equal[[]string]) {
+		t.Errorf("BFS levels %v", levels)
+	}
+	wantTree := "A\n├── B\n│   ├── D\n│   └── E\n└── C\n"
+	if bfs.Tree() != wantTree {
+		t.Errorf("BFS tree:\n%s\nwant:\n%s", bfs.Tree(), wantTree)
+	}
+
+	dfs, _ := g.DFS("A")
+	if !slices.Equal(dfs.Order, []string{"A", "B", "D", "E", "C"}) {
+		t.Errorf("DFS order %v", dfs.Order)
+	}
+	// C is reached through E: deeper than in the BFS
+	if dfs.Parent["C"] != "E" || dfs.Depth["C"] != 3 {
+		t.Errorf("DFS: C from %s at depth %d", dfs.Parent["C"], dfs.Depth["C"])
+	}
+	wantTree = "A\n└── B\n    ├── D\n    └── E\n        └── C\n"
+	if dfs.Tree() != wantTree {
+		t.Errorf("DFS tree:\n%s\nwant:\n%s", dfs.Tree(), wantTree)
+	}
+
+	alone, _ := g.BFS("F")
+	if !slices.Equal(alone.Order, []string{"F", "G"}) {
+		t.Errorf("BFS from F: %v, want its component only", alone.Order)
+	}
+	for _, f := range []func(string) (*graph.Traversal[string], error){g.BFS, g.DFS} {
+		if _, err := f("Z"); !errors.Is(err, graph.ErrUnknownVertex) {
+			t.Errorf("unknown start: %v", err)
+		}
+	}
+}
+
+func TestTopoSort(t *testing.T) {
+	// dependency -> dependent
+	g := build(true, "shirt-tie", "tie-jacket", "trousers-shoes", "trousers-belt", "belt-jacket", "shirt-belt", "socks-shoes")
+	order, err := g.TopoSort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos := make(map[string]int)
+	for i, v := range order {
+		pos[v] = i
+	}
+	if len(order) != g.Len() {
+		t.Fatalf("order %v misses vertices", order)
+	}
+	for _, v := range g.Vertices() {
+		for _, w := range g.Neighbors(v) {
+			if pos[v] >= pos[w] {
+				t.Errorf("%s after %s in %v", v, w, order)
+			}
+		}
+	}
+
+	g.AddEdge("jacket", "shirt", 1)
+	if _, err := g.TopoSort(); !errors.Is(err, graph.ErrCycle) || !strings.Contains(err.Error(), "[shirt tie jacket shirt]") {
+		t.Errorf("with a cycle: %v", err)
+	}
+	if _, err := build(false, "a-b").TopoSort(); !errors.Is(err, graph.ErrUndirected) {
+		t.Errorf("undirected: %v", err)
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		directed bool
+		edges    []string
+		want     []string
+	}{
+		{"dag", true, []string{"a-b", "a-c", "b-d", "c-d"}, nil},
+		{"directed triangle", true, []string{"a-b", "b-c", "c-a"}, []string{"a", "b", "c", "a"}},
+		{"directed self-loop", true, []string{"a-b", "b-b"}, []string{"b", "b"}},
+		{"cycle after a tail", true, []string{"x-a", "a-b", "b-a"}, []string{"a", "b", "a"}},
+		{"undirected tree", false, []string{"a-b", "a-c", "c-d"}, nil},
+		{"undirected triangle", false, []string{"a-b", "b-c", "c-a"}, []string{"a", "b", "c", "a"}},
+		{"undirected double edge", false, []string{"a-b", "a-b"}, []string{"a", "b", "a"}},
+		{"in a second component", false, []string{"a-b", "c-d", "d-e", "e-c"}, []string{"c", "d", "e", "c"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := build(tt.directed, tt.edges...).FindCycle(); !slices.Equal(got, tt.want) {
+				t.Errorf("FindCycle = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	// the direct edge a-d is longer than the way around
+	g := build(true, "a-b:1", "b-c:2", "c-d:1", "a-d:5", "a-c:4", "d-e:0", "x-a:1")
+	for _, tt := range []struct {
+		to   string
+		path []string
+		dist float64
+	}{
+		{"a", []string{"a"}, 0},
+		{"c", []string{"a", "b", "c"}, 3},
+		{"d", []string{"a", "b", "c", "d"}, 4},
+		{"e", []string{"a", "b", "c", "d", "e"}, 4},
+	} {
+		path, dist, err := g.ShortestPath("a", tt.to)
+		if err != nil || !slices.Equal(path, tt.path) || dist != tt.dist {
+			t.Errorf("a to %s: %v, %g, %v; want %v, %g", tt.to, path, dist, err, tt.path, tt.dist)
+		}
+	}
+	if _, _, err := g.ShortestPath("a", "x"); !errors.Is(err, graph.ErrNoPath) {
+		t.Errorf("to x, against the edge: %v", err)
+	}
+	if _, _, err := g.ShortestPath("a", "z"); !errors.Is(err, graph.ErrUnknownVertex) {
+		t.Errorf("to z: %v", err)
+	}
+	if _, _, err := g.ShortestPath("z", "a"); !errors.Is(err, graph.ErrUnknownVertex) {
+		t.Errorf("from z: %v", err)
+	}
+	g.AddEdge("e", "a", -1)
+	if _, err := g.Dijkstra("a"); !errors.Is(err, graph.ErrNegativeWeight) {
+		t.Errorf("negative weight: %v", err)
+	}
+
+	u := build(false, "a-b:2", "b-c:2", "a-c:5")
+	path, dist, _ := u.ShortestPath("c", "a")
+	if !slices.Equal(path, []string{"c", "b", "a"}) || dist != 4 {
+		t.Errorf("undirected c to a: %v, %g", path, dist)
+	}
+	p, _ := u.Dijkstra("a")
+	if _, d, ok := p.To("zz"); ok || !math.IsInf(d, 1) {
+		t.Error("To of a vertex not reached")
+	}
+}
+
+// TestDijkstraAgainstBFS checks Dijkstra on weights of 1, where the BFS
+// depth is the distance.
+func TestDijkstraAgainstBFS(t *testing.T) {
+	g := graph.New[int](false)
+	for i := 0; i < 100; i++ {
+		g.AddEdge(i, (i*7+3)%100, 1)
+		g.AddEdge(i, (i*13+1)%100, 1)
+	}
+	bfs, _ := g.BFS(0)
+	p, _ := g.Dijkstra(0)
+	for _, v := range bfs.Order {
+		if p.Dist[v] != float64(bfs.Depth[v]) {
+			t.Errorf("vertex %d: Dijkstra %g, BFS %d", v, p.Dist[v], bfs.Depth[v])
+		}
+	}
+	if len(p.Dist) != len(bfs.Order) {
+		t.Errorf("Dijkstra reached %d, BFS %d", len(p.Dist), len(bfs.Order))
+	}
+}
+
+func TestBFSConcurrent(t *testing.T) {
+	g := build(false, tree...)
+	pool := workerpool.New(3, 3)
+	defer pool.Shutdown(context.Background())
+
+	var mu sync.Mutex
+	var visited []string
+	levels, err := g.BFSConcurrent(pool, "A", func(v string) error {
+		mu.Lock()
+		visited = append(visited, v)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bfs, _ := g.BFS("A")
+	if !slices.EqualFunc(levels, bfs.Levels(), slices.Equal[[]string]) {
+		t.Errorf("levels %v, BFS %v", levels, bfs.Levels())
+	}
+	slices.Sort(visited)
+	if !slices.Equal(visited, []string{"A", "B", "C", "D", "E"}) {
+		t.Errorf("visited %v", visited)
+	}
+
+	boom := errors.New("boom")
+	levels, err = g.BFSConcurrent(pool, "A", func(v string) error {
+		if v == "C" {
+			return boom
+		}
+		return nil
+	})
+	if !errors.Is(err, boom) || len(levels) != 2 {
+		t.Errorf("error at level 1: %v after %d levels", err, len(levels))
+	}
+	if _, err := g.BFSConcurrent(pool, "Z", nil); !errors.Is(err, graph.ErrUnknownVertex) {
+		t.Errorf("unknown start: %v", err)
+	}
+
+	pool.Shutdown(context.Background())
+	if _, err := g.BFSConcurrent(pool, "A", func(string) error { return nil }); !errors.Is(err, workerpool.ErrClosed) {
+		t.Errorf("closed pool: %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package graph
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrCycle is returned by TopoSort for a graph with a cycle: no order
+	// puts every edge forward.
+	ErrCycle = errors.New("graph: cycle")
+	// ErrUndirected is returned by TopoSort for an undirected graph.
+	ErrUndirected = errors.New("graph: not directed")
+)
+
+// TopoSort returns the vertices of the directed graph g in an order where
+// every edge goes from an earlier vertex to a later one: tasks after the
+// ones they depend on, with an edge from each dependency to its dependent.
+//
+// It is Kahn's algorithm: take the vertices no edge goes into, remove their
+// edges, and repeat. Vertices left over are on a cycle, or after one: the
+// error wraps ErrCycle and names a cycle.
+func (g *Graph[V]) TopoSort() ([]V, error) {
+	if !g.directed {
+		return nil, ErrUndirected
+	}
+	in := make(map[V]int, len(g.order))
+	for _, v := range g.order {
+		for _, e := range g.adj[v] {
+			in[e.To]++
+		}
+	}
+	var ready []V // in the order of the vertices: a deterministic result
+	for _, v := range g.order {
+		if in[v] == 0 {
+			ready = append(ready, v)
+		}
+	}
+	order := make([]V, 0, len(g.order))
+	for len(ready) > 0 {
+		v := ready[0]
+		ready = ready[1:]
+		order = append(order, v)
+		for _, e := range g.adj[v] {
+			if in[e.To]--; in[e.To] == 0 {
+				ready = append(ready, e.To)
+			}
+		}
+	}
+	if len(order) < len(g.order) {
+		return nil, fmt.Errorf("%w: %v", ErrCycle, g.FindCycle())
+	}
+	return order, nil
+}
+
+// FindCycle returns a cycle of g as the vertices along it, the first one
+// repeated at the end: [A B C A]. It returns nil if g has none.
+//
+// In a directed graph, a DFS finds a cycle when an edge leads back to a
+// vertex still on the current path (gray: entered, not left). In an
+// undirected one, every edge leads back to where it came from; a cycle is
+// an edge to a vertex already seen other than that parent, or a second
+// edge to the parent.
+func (g *Graph[V]) FindCycle() []V {
+	const (
+		white = iota // not seen
+		gray         // on the current path
+		black        // done: no cycle through it
+	)
+	color := make(map[V]int, len(g.order))
+	var path []V
+	var cycle []V
+
+	var dfs func(v V, parent V, hasParent bool) bool
+	dfs = func(v V, parent V, hasParent bool) bool {
+		color[v] = gray
+		path = append(path, v)
+		skippedParent := false
+		for _, e := range g.adj[v] {
+			if !g.directed && hasParent && e.To == parent && !skippedParent {
+				skippedParent = true // the edge we came by, once
+				continue
+			}
+			switch color[e.To] {
+			case gray:
+				cycle = append(cycleFrom(path, e.To), e.To)
+				return true
+			case white:
+				if dfs(e.To, v, true) {
+					return true
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		color[v] = black
+		return false
+	}
+
+	for _, v := range g.order {
+		if color[v] == white && dfs(v, v, false) {
+			return cycle
+		}
+	}
+	return nil
+}
+
+// cycleFrom returns a copy of path from v to its end.
+func cycleFrom[V comparable](path []V, v V) []V {
+	for i := len(path) - 1; i >= 0; i-- {
+		if path[i] == v {
+			return append([]V(nil), path[i:]...)
+		}
+	}
+	return nil
+}
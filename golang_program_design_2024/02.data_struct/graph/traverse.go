@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/queue"
+)
+
+// Traversal is the result of a BFS or a DFS from Start: the vertices
+// reached, in the order they were visited, and the tree of the edges that
+// reached them.
+type Traversal[V comparable] struct {
+	Start  V
+	Order  []V
+	Parent map[V]V   // the vertex each one was reached from; none for Start
+	Depth  map[V]int // edges from Start: the distance, for a BFS
+}
+
+func newTraversal[V comparable](start V) *Traversal[V] {
+	return &Traversal[V]{Start: start, Parent: make(map[V]V), Depth: make(map[V]int)}
+}
+
+func (t *Traversal[V]) visit(v, parent V, isRoot bool) {
+	t.Order = append(t.Order, v)
+	if isRoot {
+		t.Depth[v] = 0
+		return
+	}
+	t.Parent[v] = parent
+	t.Depth[v] = t.Depth[parent] + 1
+}
+
+// BFS visits the vertices reachable from start, breadth first: start,
+// then its neighbors, then theirs. In an unweighted graph, Depth is the
+// length of the shortest path. An unknown start is ErrUnknownVertex.
+func (g *Graph[V]) BFS(start V) (*Traversal[V], error) {
+	if !g.Has(start) {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownVertex, start)
+	}
+	t := newTraversal(start)
+	seen := map[V]bool{start: true}
+	t.visit(start, start, true)
+	var q queue.Queue[V]
+	q.Push(start)
+	for q.Len() > 0 {
+		v, _ := q.Pop()
+		for _, e := range g.adj[v] {
+			if !seen[e.To] {
+				seen[e.To] = true // when queued, not when visited: queued once
+				t.visit(e.To, v, false)
+				q.Push(e.To)
+			}
+		}
+	}
+	return t, nil
+}
+
+// DFS visits the vertices reachable from start, depth first: as far as it
+// goes along the first edge, then back to the last vertex with an edge
+// left. It recurses, one frame per vertex on the current path; goroutine
+// stacks grow as needed, so a long path costs memory, not a crash.
+func (g *Graph[V]) DFS(start V) (*Traversal[V], error) {
+	if !g.Has(start) {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownVertex, start)
+	}
+	t := newTraversal(start)
+	seen := make(map[V]bool)
+	var dfs func(v, parent V, isRoot bool)
+	dfs = func(v, parent V, isRoot bool) {
+		seen[v] = true
+		t.visit(v, parent, isRoot)
+		for _, e := range g.adj[v] {
+			if !seen[e.To] {
+				dfs(e.To, v, false)
+			}
+		}
+	}
+	dfs(start, start, true)
+	return t, nil
+}
+
+// Levels returns the vertices by depth: Start alone, then the vertices one
+// edge away, and so on, each level in the order of the traversal.
+func (t *Traversal[V]) Levels() [][]V {
+	var levels [][]V
+	for _, v := range t.Order {
+		d := t.Depth[v]
+		for len(levels) <= d {
+			levels = append(levels, nil)
+		}
+		levels[d] = append(levels[d], v)
+	}
+	return levels
+}
+
+// children returns the children of each vertex in the tree, in the order
+// of the traversal.
+func (t *Traversal[V]) children() map[V][]V {
+	ch := make(map[V][]V)
+	for _, v := range t.Order {
+		if p, ok := t.Parent[v]; ok {
+			ch[p] = append(ch[p], v)
+		}
+	}
+	return ch
+}
+
+// Tree draws the tree of the traversal, one vertex per line, its children
+// under it in the order they were visited:
+//
+//	A
+//	├── B
+//	│   └── D
+//	└── C
+func (t *Traversal[V]) Tree() string {
+	var b strings.Builder
+	ch := t.children()
+	var draw func(v V, prefix string, last, root bool)
+	draw = func(v V, prefix string, last, root bool) {
+		next := prefix
+		switch {
+		case root:
+			fmt.Fprintf(&b, "%v\n", v)
+		case last:
+			fmt.Fprintf(&b, "%s└── %v\n", prefix, v)
+			next += "    "
+		default:
+			fmt.Fprintf(&b, "%s├── %v\n", prefix, v)
+			next += "│   "
+		}
+		for i, c := range ch[v] {
+			draw(c, next, i == len(ch[v])-1, false)
+		}
+	}
+	draw(t.Start, "", true, true)
+	return b.String()
+}
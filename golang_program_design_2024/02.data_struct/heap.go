@@ -0,0 +1,217 @@
+package datastruct
+
+import (
+	"container/heap"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	genheap "github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/heap"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A priority queue returns the most urgent element first, whatever the order
+it came in. A binary heap does it in O(log n) per Push and Pop; a sorted
+slice would pay O(n) per insertion, an unsorted one O(n) per removal.
+
+Two ways in Go:
+
+	container/heap    implement heap.Interface (sort.Interface plus Push and
+	                  Pop) on a slice type of one's own; heap.Push, heap.Pop
+	                  and heap.Fix keep it ordered
+	heap.Heap[T]      the generic heap of this chapter: a less function,
+	                  nothing to implement, no any
+
+container/heap is the one when an element must change priority while in
+the queue: the element keeps its index, updated by Swap, and heap.Fix moves
+it to its new place. taskQueue below does that; the scheduler uses the
+generic heap to hand jobs to goroutines, the most urgent first.
+*/
+
+func init() {
+	lessons.Register("02.data_struct/heap", "priority queues: container/heap with updates, a generic heap, a job scheduler", DemoHeap)
+}
+
+// DemoHeap runs a task queue on container/heap, then a scheduler on the
+// generic heap.
+func DemoHeap() {
+	taskQueueDemo()
+	schedulerDemo()
+}
+
+// task is an element of a taskQueue. index is its place in the queue,
+// kept up to date by Swap, for heap.Fix and heap.Remove.
+type task struct {
+	name     string
+	priority int // higher first
+	index    int
+}
+
+// taskQueue implements heap.Interface: a max-heap of tasks by priority.
+type taskQueue []*task
+
+func (q taskQueue) Len() int           { return len(q) }
+func (q taskQueue) Less(i, j int) bool { return q[i].priority > q[j].priority }
+func (q taskQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+// Push and Pop are called by heap.Push and heap.Pop, not by the user: they
+// only add at the end and remove from the end, the heap does the rest.
+func (q *taskQueue) Push(x any) {
+	t := x.(*task)
+	t.index = len(*q)
+	*q = append(*q, t)
+}
+
+func (q *taskQueue) Pop() any {
+	old := *q
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	t.index = -1 // no longer in the queue
+	*q = old[:len(old)-1]
+	return t
+}
+
+// update changes the priority of t, in the queue, and restores the order.
+func (q *taskQueue) update(t *task, priority int) {
+	t.priority = priority
+	heap.Fix(q, t.index)
+}
+
+func taskQueueDemo() {
+	tasks := map[string]*task{}
+	q := &taskQueue{}
+	for _, t := range []struct {
+		name     string
+		priority int
+	}{{"write docs", 1}, {"fix bug", 5}, {"review PR", 3}, {"lunch", 2}} {
+		tasks[t.name] = &task{name: t.name, priority: t.priority}
+		heap.Push(q, tasks[t.name])
+	}
+	q.update(tasks["write docs"], 9) // the release is tomorrow
+	heap.Remove(q, tasks["lunch"].index)
+
+	var order []string
+	for q.Len() > 0 {
+		t := heap.Pop(q).(*task)
+		order = append(order, fmt.Sprintf("%s(%d)", t.name, t.priority))
+	}
+	fmt.Println("container/heap:", strings.Join(order, " "))
+}
+
+// job is a unit of work for the scheduler. seq breaks the ties: among jobs
+// of the same priority, the first submitted runs first. A heap alone is not
+// stable.
+type job struct {
+	name     string
+	priority int
+	seq      int
+}
+
+// scheduler hands jobs to its workers, the highest priority first. Workers
+// wait on a sync.Cond while the queue is empty: a channel would give the
+// jobs in the order they were sent, not by priority.
+type scheduler struct {
+	mu         sync.Mutex
+	ready      *sync.Cond
+	jobs       *genheap.Heap[job]
+	seq        int
+	closed     bool
+	dispatched []string // the order jobs were handed out, for the demo
+}
+
+func newScheduler() *scheduler {
+	s := &scheduler{jobs: genheap.New(func(a, b job) bool {
+		if a.priority != b.priority {
+			return a.priority > b.priority
+		}
+		return a.seq < b.seq
+	})}
+	s.ready = sync.NewCond(&s.mu)
+	return s
+}
+
+func (s *scheduler) submit(name string, priority int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	s.jobs.Push(job{name: name, priority: priority, seq: s.seq})
+	s.ready.Signal() // one job: one worker
+}
+
+// close lets the workers return once the queue is empty.
+func (s *scheduler) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.ready.Broadcast()
+}
+
+// next waits for a job, and returns false once closed and empty.
+func (s *scheduler) next() (job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.jobs.Len() == 0 && !s.closed {
+		s.ready.Wait()
+	}
+	j, ok := s.jobs.Pop()
+	if ok {
+		s.dispatched = append(s.dispatched, j.name)
+	}
+	return j, ok
+}
+
+// run starts workers goroutines calling do with each job, and returns a
+// function waiting for them.
+func (s *scheduler) run(workers int, do func(worker int, j job)) (wait func()) {
+	var wg sync.WaitGroup
+	for w := 1; w <= workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j, ok := s.next()
+				if !ok {
+					return
+				}
+				do(w, j)
+			}
+		}()
+	}
+	return wg.Wait
+}
+
+func schedulerDemo() {
+	s := newScheduler()
+	for _, j := range []struct {
+		name     string
+		priority int
+	}{
+		{"thumbnail-1", 1}, {"email-1", 5}, {"thumbnail-2", 1}, {"payment-1", 9},
+		{"email-2", 5}, {"report", 3}, {"payment-2", 9},
+	} {
+		s.submit(j.name, j.priority)
+	}
+
+	var mu sync.Mutex
+	var log []string
+	wait := s.run(2, func(worker int, j job) {
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		log = append(log, fmt.Sprintf("w%d:%s", worker, j.name))
+		mu.Unlock()
+	})
+	// while the first jobs run: an urgent one passes every waiting job
+	time.Sleep(5 * time.Millisecond)
+	s.submit("payment-3", 9)
+	s.close()
+	wait()
+
+	fmt.Println("dispatched:", strings.Join(s.dispatched, " "))
+	fmt.Println("finished:  ", strings.Join(log, " "))
+}
@@ -0,0 +1,111 @@
+// Package heap is a generic binary heap: a priority queue where Pop returns
+// the least element by a less function given once, to New.
+//
+//	h := heap.New(func(a, b Job) bool { return a.Priority > b.Priority })
+//	h.Push(job)
+//	next, ok := h.Pop() // the highest priority first
+//
+// container/heap does the same on any heap.Interface: five methods to
+// write on a type of one's own, and an any at Push and Pop. Heap[T] is
+// those methods written once, with a T; container/heap is still the one
+// for a heap whose elements must be found and changed in place (heap.Fix
+// with the index each element keeps), as the task queue of
+// 02.data_struct/heap.go does.
+//
+// The heap is a complete binary tree stored in a slice: the children of i
+// are 2i+1 and 2i+2, its parent (i-1)/2, and every element is not less
+// than its parent. Push and Pop are O(log n), Peek O(1).
+package heap
+
+// Heap is a binary heap of T. The zero value is not usable: make one with
+// New or From.
+type Heap[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+// New returns an empty heap ordered by less: Pop returns an element no
+// other is less than.
+func New[T any](less func(a, b T) bool) *Heap[T] {
+	return &Heap[T]{less: less}
+}
+
+// From returns a heap of items, which it takes over. Building it at once is
+// O(n), where n Pushes are O(n log n): each element of the first half sinks
+// from the bottom up, and most are near the bottom.
+func From[T any](items []T, less func(a, b T) bool) *Heap[T] {
+	h := &Heap[T]{items: items, less: less}
+	for i := len(items)/2 - 1; i >= 0; i-- {
+		h.down(i)
+	}
+	return h
+}
+
+// Len returns the number of elements.
+func (h *Heap[T]) Len() int { return len(h.items) }
+
+// Push adds v.
+func (h *Heap[T]) Push(v T) {
+	h.items = append(h.items, v)
+	h.up(len(h.items) - 1)
+}
+
+// Pop removes and returns the least element, or false if the heap is
+// empty. The last element takes the place of the root, and sinks.
+func (h *Heap[T]) Pop() (T, bool) {
+	var zero T
+	if len(h.items) == 0 {
+		return zero, false
+	}
+	top := h.items[0]
+	last := len(h.items) - 1
+	h.items[0] = h.items[last]
+	h.items[last] = zero // let the GC take what the element points to
+	h.items = h.items[:last]
+	if last > 0 {
+		h.down(0)
+	}
+	return top, true
+}
+
+// Peek returns the least element without removing it, or false if the heap
+// is empty.
+func (h *Heap[T]) Peek() (T, bool) {
+	if len(h.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return h.items[0], true
+}
+
+// up moves the element at i up while it is less than its parent.
+func (h *Heap[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(h.items[i], h.items[parent]) {
+			return
+		}
+		h.items[i], h.items[parent] = h.items[parent], h.items[i]
+		i = parent
+	}
+}
+
+// down moves the element at i down while a child is less than it,
+// swapping it with the least child.
+func (h *Heap[T]) down(i int) {
+	n := len(h.items)
+	for {
+		least := i
+		if l := 2*i + 1; l < n && h.less(h.items[l], h.items[least]) {
+			least = l
+		}
+		if r := 2*i + 2; r < n && h.less(h.items[r], h.items[least]) {
+			least = r
+		}
+		if least == i {
+			return
+		}
+		h.items[i], h.items[least] = h.items[least], h.items[i]
+		i = least
+	}
+}
@@ -0,0 +1,174 @@
+package heap
+
+import (
+	"container/heap"
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+func intLess(a, b int) bool { return a < b }
+
+// valid reports whether no element of h is less than its parent.
+func valid[T any](h *Heap[T]) bool {
+	for i := 1; i < len(h.items); i++ {
+		if h.less(h.items[i], h.items[(i-1)/2]) {
+			return false
+		}
+	}
+	return true
+}
+
+func drain(h *Heap[int]) []int {
+	var out []int
+	for h.Len() > 0 {
+		v, _ := h.Pop()
+		out = append(out, v)
+	}
+	return out
+}
+
+func TestEmpty(t *testing.T) {
+	h := New(intLess)
+	if _, ok := h.Pop(); ok {
+		t.Error("Pop on an empty heap")
+	}
+	if _, ok := h.Peek(); ok {
+		t.Error("Peek on an empty heap")
+	}
+	h.Push(1)
+	if v, ok := h.Pop(); !ok || v != 1 || h.Len() != 0 {
+		t.Errorf("Pop = %d, %v, Len %d", v, ok, h.Len())
+	}
+}
+
+// TestSorted pushes random numbers, duplicates included, and checks that
+// they come out sorted, the heap valid after every step.
+func TestSorted(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 2, 3, 10, 257} {
+		in := make([]int, n)
+		for i := range in {
+			in[i] = r.Intn(50)
+		}
+		h := New(intLess)
+		for _, v := range in {
+			h.Push(v)
+			if !valid(h) {
+				t.Fatalf("n=%d: invalid after Push(%d): %v", n, v, h.items)
+			}
+		}
+		if top, _ := h.Peek(); top != slices.Min(in) {
+			t.Errorf("n=%d: Peek = %d, want %d", n, top, slices.Min(in))
+		}
+		want := slices.Clone(in)
+		slices.Sort(want)
+		if got := drain(h); !slices.Equal(got, want) {
+			t.Errorf("n=%d: popped %v, want %v", n, got, want)
+		}
+	}
+}
+
+func TestFrom(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, n := range []int{0, 1, 2, 7, 100} {
+		in := r.Perm(n)
+		h := From(slices.Clone(in), intLess)
+		if !valid(h) {
+			t.Fatalf("n=%d: From is not a heap: %v", n, h.items)
+		}
+		want := slices.Clone(in)
+		slices.Sort(want)
+		if got := drain(h); !slices.Equal(got, want) {
+			t.Errorf("n=%d: popped %v", n, got)
+		}
+	}
+}
+
+// TestMaxHeap orders structs by a field, descending.
+func TestMaxHeap(t *testing.T) {
+	type job struct {
+		name     string
+		priority int
+	}
+	h := New(func(a, b job) bool { return a.priority > b.priority })
+	for _, j := range []job{{"low", 1}, {"high", 9}, {"mid", 5}} {
+		h.Push(j)
+	}
+	var names []string
+	for h.Len() > 0 {
+		j, _ := h.Pop()
+		names = append(names, j.name)
+	}
+	if !slices.Equal(names, []string{"high", "mid", "low"}) {
+		t.Errorf("order %v", names)
+	}
+}
+
+func TestPopClears(t *testing.T) {
+	h := New(func(a, b *int) bool { return *a < *b })
+	x, y := 1, 2
+	h.Push(&x)
+	h.Push(&y)
+	h.Pop()
+	if h.items[:2][1] != nil {
+		t.Error("the moved pointer is still in the backing array")
+	}
+}
+
+// intHeap is the same heap through container/heap, for the benchmarks.
+type intHeap []int
+
+func (h intHeap) Len() int           { return len(h) }
+func (h intHeap) Less(i, j int) bool { return h[i] < h[j] }
+func (h intHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *intHeap) Push(x any)        { *h = append(*h, x.(int)) }
+func (h *intHeap) Pop() any {
+	old := *h
+	v := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return v
+}
+
+var sink int
+
+// BenchmarkPushPop pushes and pops n ints with Heap[int] and with
+// container/heap: the interface calls and the boxing of each int in an any
+// are what differ.
+func BenchmarkPushPop(b *testing.B) {
+	for _, v := range []struct {
+		name    string
+		pushPop func(in []int)
+	}{
+		{"Heap", func(in []int) {
+			h := New(intLess)
+			for _, v := range in {
+				h.Push(v)
+			}
+			for h.Len() > 0 {
+				v, _ := h.Pop()
+				sink += v
+			}
+		}},
+		{"container/heap", func(in []int) {
+			h := &intHeap{}
+			for _, v := range in {
+				heap.Push(h, v)
+			}
+			for h.Len() > 0 {
+				sink += heap.Pop(h).(int)
+			}
+		}},
+	} {
+		b.Run(v.name, func(b *testing.B) {
+			benchtools.Sized(b, []int{1 << 10, 64 << 10}, func(b *testing.B, n int) {
+				in := benchtools.Ints(n, n, 3)
+				for i := 0; i < b.N; i++ {
+					v.pushPop(in)
+				}
+			})
+		})
+	}
+}
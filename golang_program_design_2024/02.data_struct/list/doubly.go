@@ -0,0 +1,192 @@
+package list
+
+// Node is an element of a Doubly list. The list returns it from the Push
+// and Insert methods, and takes it back to insert next to it, move it or
+// remove it in O(1): no search.
+type Node[T any] struct {
+	Value      T
+	prev, next *Node[T]
+	list       *Doubly[T] // nil once removed
+}
+
+// Next returns the next node, or nil at the back.
+func (n *Node[T]) Next() *Node[T] {
+	if n.list == nil || n.next == &n.list.root {
+		return nil
+	}
+	return n.next
+}
+
+// Prev returns the previous node, or nil at the front.
+func (n *Node[T]) Prev() *Node[T] {
+	if n.list == nil || n.prev == &n.list.root {
+		return nil
+	}
+	return n.prev
+}
+
+// Doubly is a doubly linked list. The nodes form a ring around root, a
+// sentinel that is not an element: root.next is the front, root.prev the
+// back, and the ends need no nil checks. The zero value is an empty list,
+// ready to use.
+type Doubly[T any] struct {
+	root Node[T]
+	len  int
+}
+
+// lazyInit makes the ring of a zero list: root pointing to itself.
+func (l *Doubly[T]) lazyInit() {
+	if l.root.next == nil {
+		l.root.next, l.root.prev = &l.root, &l.root
+	}
+}
+
+// Len returns the number of elements.
+func (l *Doubly[T]) Len() int { return l.len }
+
+// Front returns the first node, or nil if the list is empty.
+func (l *Doubly[T]) Front() *Node[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.next
+}
+
+// Back returns the last node, or nil if the list is empty.
+func (l *Doubly[T]) Back() *Node[T] {
+	if l.len == 0 {
+		return nil
+	}
+	return l.root.prev
+}
+
+// insert links a new node of v after at and returns it.
+func (l *Doubly[T]) insert(v T, at *Node[T]) *Node[T] {
+	n := &Node[T]{Value: v, prev: at, next: at.next, list: l}
+	at.next.prev = n
+	at.next = n
+	l.len++
+	return n
+}
+
+// PushFront adds v at the front and returns its node.
+func (l *Doubly[T]) PushFront(v T) *Node[T] {
+	l.lazyInit()
+	return l.insert(v, &l.root)
+}
+
+// PushBack adds v at the back and returns its node.
+func (l *Doubly[T]) PushBack(v T) *Node[T] {
+	l.lazyInit()
+	return l.insert(v, l.root.prev)
+}
+
+// InsertBefore adds v just before mark and returns its node. If mark is
+// not a node of l, l is not changed and the result is nil.
+func (l *Doubly[T]) InsertBefore(v T, mark *Node[T]) *Node[T] {
+	if mark.list != l {
+		return nil
+	}
+	return l.insert(v, mark.prev)
+}
+
+// InsertAfter adds v just after mark and returns its node. If mark is not
+// a node of l, l is not changed and the result is nil.
+func (l *Doubly[T]) InsertAfter(v T, mark *Node[T]) *Node[T] {
+	if mark.list != l {
+		return nil
+	}
+	return l.insert(v, mark)
+}
+
+// Remove removes n from l if it is a node of l, and returns its value
+// either way.
+func (l *Doubly[T]) Remove(n *Node[T]) T {
+	if n.list == l {
+		l.unlink(n)
+		n.next, n.prev, n.list = nil, nil, nil // no pointer kept into the list
+	}
+	return n.Value
+}
+
+func (l *Doubly[T]) unlink(n *Node[T]) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+	l.len--
+}
+
+// MoveToFront moves n to the front of l. If n is not a node of l, l is
+// not changed.
+func (l *Doubly[T]) MoveToFront(n *Node[T]) {
+	if n.list != l || l.root.next == n {
+		return
+	}
+	l.unlink(n)
+	l.relink(n, &l.root)
+}
+
+// MoveToBack moves n to the back of l. If n is not a node of l, l is not
+// changed.
+func (l *Doubly[T]) MoveToBack(n *Node[T]) {
+	if n.list != l || l.root.prev == n {
+		return
+	}
+	l.unlink(n)
+	l.relink(n, l.root.prev)
+}
+
+// relink puts back the unlinked n after at, keeping the node.
+func (l *Doubly[T]) relink(n, at *Node[T]) {
+	n.prev, n.next = at, at.next
+	at.next.prev = n
+	at.next = n
+	l.len++
+}
+
+// PopFront removes and returns the first element, or false if the list is
+// empty.
+func (l *Doubly[T]) PopFront() (T, bool) {
+	if l.len == 0 {
+		var zero T
+		return zero, false
+	}
+	return l.Remove(l.root.next), true
+}
+
+// PopBack removes and returns the last element, or false if the list is
+// empty.
+func (l *Doubly[T]) PopBack() (T, bool) {
+	if l.len == 0 {
+		var zero T
+		return zero, false
+	}
+	return l.Remove(l.root.prev), true
+}
+
+// All returns an iterator over the elements, front to back. It reads the
+// next node before yielding, so the loop body may remove the current one
+// through a node it kept.
+func (l *Doubly[T]) All() func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for n := l.Front(); n != nil; {
+			next := n.Next()
+			if !yield(n.Value) {
+				return
+			}
+			n = next
+		}
+	}
+}
+
+// Backward returns an iterator over the elements, back to front.
+func (l *Doubly[T]) Backward() func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for n := l.Back(); n != nil; {
+			prev := n.Prev()
+			if !yield(n.Value) {
+				return
+			}
+			n = prev
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package list
+
+import (
+	"slices"
+	"testing"
+)
+
+// collect returns what the iterator all yields.
+func collect[T any](all func(yield func(T) bool)) []T {
+	var out []T
+	all(func(v T) bool {
+		out = append(out, v)
+		return true
+	})
+	return out
+}
+
+// firstN returns the first n elements of all, stopping it there.
+func firstN[T any](all func(yield func(T) bool), n int) []T {
+	var out []T
+	all(func(v T) bool {
+		out = append(out, v)
+		return len(out) < n
+	})
+	return out
+}
+
+// checkSingly verifies the elements of l and its invariants: Len, Front and
+// Back agree with the nodes.
+func checkSingly(t *testing.T, l *Singly[int], want ...int) {
+	t.Helper()
+	got := collect(l.All())
+	if !slices.Equal(got, want) {
+		t.Fatalf("elements %v, want %v", got, want)
+	}
+	if l.Len() != len(want) {
+		t.Errorf("Len = %d, want %d", l.Len(), len(want))
+	}
+	front, okf := l.Front()
+	back, okb := l.Back()
+	if len(want) == 0 {
+		if okf || okb || l.head != nil || l.tail != nil {
+			t.Errorf("empty list with a head or a tail")
+		}
+		return
+	}
+	if front != want[0] || back != want[len(want)-1] || l.tail.next != nil {
+		t.Errorf("Front %d, Back %d, want %d and %d", front, back, want[0], want[len(want)-1])
+	}
+}
+
+func TestSingly(t *testing.T) {
+	var l Singly[int]
+	checkSingly(t, &l)
+	if _, ok := l.PopFront(); ok {
+		t.Error("PopFront on an empty list")
+	}
+
+	l.PushBack(2)
+	l.PushBack(3)
+	l.PushFront(1)
+	checkSingly(t, &l, 1, 2, 3)
+	if got := firstN(l.All(), 2); !slices.Equal(got, []int{1, 2}) {
+		t.Errorf("stopped iteration = %v", got)
+	}
+
+	l.Reverse()
+	checkSingly(t, &l, 3, 2, 1)
+	l.PushBack(0) // the tail moved with the reversal
+	checkSingly(t, &l, 3, 2, 1, 0)
+
+	if v, ok := l.PopFront(); !ok || v != 3 {
+		t.Errorf("PopFront = %d, %v", v, ok)
+	}
+	checkSingly(t, &l, 2, 1, 0)
+	l.PopFront()
+	l.PopFront()
+	l.PopFront()
+	checkSingly(t, &l)
+	l.PushFront(7) // the tail is set again on an empty list
+	checkSingly(t, &l, 7)
+
+	var empty Singly[int]
+	empty.Reverse()
+	checkSingly(t, &empty)
+}
+
+func TestSinglyDeleteFunc(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   []int
+		want []int
+	}{
+		{"none", []int{1, 3, 5}, []int{1, 3, 5}},
+		{"all", []int{2, 4}, nil},
+		{"head", []int{2, 3, 5}, []int{3, 5}},
+		{"tail", []int{1, 3, 4}, []int{1, 3}},
+		{"middle", []int{1, 2, 4, 5}, []int{1, 5}},
+		{"empty", nil, nil},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var l Singly[int]
+			for _, v := range tt.in {
+				l.PushBack(v)
+			}
+			n := l.DeleteFunc(func(v int) bool { return v%2 == 0 })
+			if n != len(tt.in)-len(tt.want) {
+				t.Errorf("removed %d", n)
+			}
+			checkSingly(t, &l, tt.want...)
+			l.PushBack(9) // the tail is right after a delete at the end
+			checkSingly(t, &l, append(tt.want, 9)...)
+		})
+	}
+}
+
+// checkDoubly verifies the elements of l in both directions, through the
+// iterators and through the nodes.
+func checkDoubly(t *testing.T, l *Doubly[int], want ...int) {
+	t.Helper()
+	if got := collect(l.All()); !slices.Equal(got, want) {
+		t.Fatalf("elements %v, want %v", got, want)
+	}
+	back := slices.Clone(want)
+	slices.Reverse(back)
+	if got := collect(l.Backward()); !slices.Equal(got, back) {
+		t.Errorf("backward %v, want %v", got, back)
+	}
+	var nodes []int
+	for n := l.Front(); n != nil; n = n.Next() {
+		nodes = append(nodes, n.Value)
+	}
+	var prev []int
+	for n := l.Back(); n != nil; n = n.Prev() {
+		prev = append(prev, n.Value)
+	}
+	if !slices.Equal(nodes, want) || !slices.Equal(prev, back) {
+		t.Errorf("through the nodes: %v and back %v", nodes, prev)
+	}
+	if l.Len() != len(want) {
+		t.Errorf("Len = %d, want %d", l.Len(), len(want))
+	}
+}
+
+func TestDoubly(t *testing.T) {
+	var l Doubly[int] // the zero value is ready
+	checkDoubly(t, &l)
+	if l.Front() != nil || l.Back() != nil {
+		t.Error("an empty list has a node")
+	}
+	for _, pop := range []func() (int, bool){l.PopFront, l.PopBack} {
+		if _, ok := pop(); ok {
+			t.Error("Pop on an empty list")
+		}
+	}
+
+	two := l.PushBack(2)
+	four := l.PushBack(4)
+	one := l.PushFront(1)
+	checkDoubly(t, &l, 1, 2, 4)
+	l.InsertAfter(3, two)
+	l.InsertBefore(0, one)
+	checkDoubly(t, &l, 0, 1, 2, 3, 4)
+
+	l.MoveToFront(four)
+	checkDoubly(t, &l, 4, 0, 1, 2, 3)
+	l.MoveToFront(four) // already there
+	l.MoveToBack(one)
+	checkDoubly(t, &l, 4, 0, 2, 3, 1)
+	l.MoveToBack(one)
+	checkDoubly(t, &l, 4, 0, 2, 3, 1)
+
+	if v := l.Remove(two); v != 2 {
+		t.Errorf("Remove = %d", v)
+	}
+	checkDoubly(t, &l, 4, 0, 3, 1)
+	if two.Next() != nil || two.Prev() != nil {
+		t.Error("a removed node still points into the list")
+	}
+	l.Remove(two) // a second time: nothing
+	checkDoubly(t, &l, 4, 0, 3, 1)
+
+	if v, ok := l.PopFront(); !ok || v != 4 {
+		t.Errorf("PopFront = %d, %v", v, ok)
+	}
+	if v, ok := l.PopBack(); !ok || v != 1 {
+		t.Errorf("PopBack = %d, %v", v, ok)
+	}
+	checkDoubly(t, &l, 0, 3)
+	if got := firstN(l.All(), 1); !slices.Equal(got, []int{0}) {
+		t.Errorf("stopped All = %v", got)
+	}
+	if got := firstN(l.Backward(), 1); !slices.Equal(got, []int{3}) {
+		t.Errorf("stopped Backward = %v", got)
+	}
+}
+
+// TestDoublyForeignNode checks that a node of another list, or a removed
+// one, changes nothing: without the check, it would corrupt both lists.
+func TestDoublyForeignNode(t *testing.T) {
+	var a, b Doubly[int]
+	a.PushBack(1)
+	other := b.PushBack(2)
+
+	if a.InsertAfter(9, other) != nil || a.InsertBefore(9, other) != nil {
+		t.Error("inserted next to a node of another list")
+	}
+	a.MoveToFront(other)
+	a.MoveToBack(other)
+	a.Remove(other)
+	checkDoubly(t, &a, 1)
+	checkDoubly(t, &b, 2)
+
+	b.Remove(other)
+	if b.InsertAfter(9, other) != nil {
+		t.Error("inserted next to a removed node")
+	}
+	checkDoubly(t, &b)
+}
+
+// TestDoublyRemoveWhileIterating removes through kept nodes during All.
+func TestDoublyRemoveWhileIterating(t *testing.T) {
+	var l Doubly[int]
+	nodes := map[int]*Node[int]{}
+	for i := 1; i <= 5; i++ {
+		nodes[i] = l.PushBack(i)
+	}
+	var seen []int
+	l.All()(func(v int) bool {
+		seen = append(seen, v)
+		if v%2 == 0 {
+			l.Remove(nodes[v])
+		}
+		return true
+	})
+	if !slices.Equal(seen, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("seen %v", seen)
+	}
+	checkDoubly(t, &l, 1, 3, 5)
+}
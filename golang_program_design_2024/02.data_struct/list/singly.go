@@ -0,0 +1,138 @@
+// Package list has two generic linked lists: Singly, linked forward only,
+// and Doubly, linked both ways with the nodes in the hands of the caller,
+// like container/list.
+//
+// container/list is the Doubly of before generics: its elements hold an
+// any, and every read is a type assertion, e.Value.(*entry), that the
+// compiler cannot check. Doubly[T] holds a T. The rest is the same design:
+// a ring around a sentinel node, O(1) insertion and removal at a node the
+// caller keeps, and a check that the node belongs to the list.
+//
+// A linked list is rarely the fastest sequence in Go: every element is an
+// allocation of its own, and a walk follows pointers all over the heap
+// where a slice reads memory in order. It wins where a slice must move its
+// elements: removing or inserting in the middle, at a node already found,
+// as the LRU of 02.data_struct/lru does on every Get.
+//
+// All returns an iterator, a func(yield func(T) bool): call it with a
+// function returning false to stop. From Go 1.23 on, for v := range
+// l.All() does the same; this module is at Go 1.22, and calls it.
+package list
+
+// snode is a node of a Singly list.
+type snode[T any] struct {
+	value T
+	next  *snode[T]
+}
+
+// Singly is a singly linked list: one pointer per node, to the next. It
+// keeps the tail too, so PushBack is O(1) like PushFront; what it cannot
+// do in O(1) is remove at the back, which needs the node before. The zero
+// value is an empty list.
+type Singly[T any] struct {
+	head, tail *snode[T]
+	len        int
+}
+
+// Len returns the number of elements.
+func (l *Singly[T]) Len() int { return l.len }
+
+// PushFront adds v at the front.
+func (l *Singly[T]) PushFront(v T) {
+	l.head = &snode[T]{value: v, next: l.head}
+	if l.tail == nil {
+		l.tail = l.head
+	}
+	l.len++
+}
+
+// PushBack adds v at the back.
+func (l *Singly[T]) PushBack(v T) {
+	n := &snode[T]{value: v}
+	if l.tail == nil {
+		l.head = n
+	} else {
+		l.tail.next = n
+	}
+	l.tail = n
+	l.len++
+}
+
+// Front returns the first element, or false if the list is empty.
+func (l *Singly[T]) Front() (T, bool) {
+	if l.head == nil {
+		var zero T
+		return zero, false
+	}
+	return l.head.value, true
+}
+
+// Back returns the last element, or false if the list is empty.
+func (l *Singly[T]) Back() (T, bool) {
+	if l.tail == nil {
+		var zero T
+		return zero, false
+	}
+	return l.tail.value, true
+}
+
+// PopFront removes and returns the first element, or false if the list is
+// empty.
+func (l *Singly[T]) PopFront() (T, bool) {
+	if l.head == nil {
+		var zero T
+		return zero, false
+	}
+	n := l.head
+	l.head = n.next
+	if l.head == nil {
+		l.tail = nil
+	}
+	n.next = nil
+	l.len--
+	return n.value, true
+}
+
+// DeleteFunc removes the elements for which del is true and returns how
+// many it removed. It walks the list once, with a pointer to the link to
+// rewrite: the head, or the next of the node before.
+func (l *Singly[T]) DeleteFunc(del func(T) bool) int {
+	removed := 0
+	l.tail = nil
+	for link := &l.head; *link != nil; {
+		n := *link
+		if del(n.value) {
+			*link = n.next
+			n.next = nil
+			removed++
+			continue
+		}
+		l.tail = n
+		link = &n.next
+	}
+	l.len -= removed
+	return removed
+}
+
+// Reverse reverses the list in place, turning each pointer around.
+func (l *Singly[T]) Reverse() {
+	var prev *snode[T]
+	l.tail = l.head
+	for n := l.head; n != nil; {
+		next := n.next
+		n.next = prev
+		prev, n = n, next
+	}
+	l.head = prev
+}
+
+// All returns an iterator over the elements, front to back.
+func (l *Singly[T]) All() func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for n := l.head; n != nil; n = n.next {
+			if !yield(n.value) {
+				return
+			}
+		}
+	}
+}
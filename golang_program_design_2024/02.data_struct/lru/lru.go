@@ -0,0 +1,187 @@
+// Package lru is a least recently used cache: a map for the lookup and a
+// doubly linked list for the order, both O(1). A Get moves the entry to the
+// front of the list; a Put past the capacity drops the entry at the back,
+// the one unused for the longest.
+//
+//	c := lru.New[string, []byte](1000)
+//	c.OnEvict = func(key string, _ []byte) { log.Println("evicted", key) }
+//	c.PutTTL("/users", body, time.Minute)
+//	body, ok := c.Get("/users") // false after a minute, or once evicted
+//
+// Cache is not safe for concurrent use: even Get writes, it reorders the
+// list. Sync wraps one in a mutex; 09.projects/qrservice keeps its PNGs in
+// one.
+package lru
+
+import "time"
+
+// entry is an element of the list, and the value of the map. The list is a
+// ring around a sentinel, root in Cache: root.next is the most recently
+// used entry, root.prev the least, and an empty list is root alone. No nil
+// checks at the ends.
+type entry[K comparable, V any] struct {
+	key        K
+	value      V
+	expires    time.Time // zero: never
+	prev, next *entry[K, V]
+}
+
+// Cache is an LRU cache of at most its capacity of entries. The zero value
+// is not usable: make one with New.
+type Cache[K comparable, V any] struct {
+	// OnEvict, if set, is called with each entry the cache drops by itself:
+	// the least recently used past the capacity, or an expired one found by
+	// Get, Peek or RemoveExpired. Not for Remove, nor for a value replaced
+	// by Put: the caller knows of those.
+	OnEvict func(key K, value V)
+
+	capacity int
+	items    map[K]*entry[K, V]
+	root     entry[K, V]
+	now      func() time.Time // time.Now, replaced by the tests
+}
+
+// New returns an empty cache of capacity entries. It panics if capacity is
+// less than 1.
+func New[K comparable, V any](capacity int) *Cache[K, V] {
+	if capacity < 1 {
+		panic("lru: capacity must be at least 1")
+	}
+	c := &Cache[K, V]{capacity: capacity, items: make(map[K]*entry[K, V], capacity), now: time.Now}
+	c.root.next, c.root.prev = &c.root, &c.root
+	return c
+}
+
+// Get returns the value of key, and makes it the most recently used. An
+// expired entry is removed and not returned.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	e, ok := c.live(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.moveToFront(e)
+	return e.value, true
+}
+
+// Peek returns the value of key without changing the order.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	e, ok := c.live(key)
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Put sets the value of key, with no expiration, and makes it the most
+// recently used.
+func (c *Cache[K, V]) Put(key K, value V) { c.PutTTL(key, value, 0) }
+
+// PutTTL sets the value of key for ttl: after that, the entry is gone for
+// Get and Peek. A ttl of 0 or less never expires. A Put on an existing key
+// replaces its value and its expiration.
+func (c *Cache[K, V]) PutTTL(key K, value V, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.now().Add(ttl)
+	}
+	if e, ok := c.items[key]; ok {
+		e.value, e.expires = value, expires
+		c.moveToFront(e)
+		return
+	}
+	e := &entry[K, V]{key: key, value: value, expires: expires}
+	c.items[key] = e
+	c.insertFront(e)
+	if len(c.items) > c.capacity {
+		c.evict(c.root.prev)
+	}
+}
+
+// Remove removes key, and reports whether it was there.
+func (c *Cache[K, V]) Remove(key K) bool {
+	e, ok := c.items[key]
+	if ok {
+		c.unlink(e)
+		delete(c.items, key)
+	}
+	return ok
+}
+
+// RemoveExpired removes the expired entries and returns how many there
+// were. Get and Peek drop an expired entry they find; this is for the ones
+// nobody asks for again, which would otherwise wait for the capacity to
+// push them out.
+func (c *Cache[K, V]) RemoveExpired() int {
+	now := c.now()
+	n := 0
+	for e := c.root.next; e != &c.root; {
+		next := e.next
+		if e.expired(now) {
+			c.evict(e)
+			n++
+		}
+		e = next
+	}
+	return n
+}
+
+// Len returns the number of entries, expired ones not yet removed included.
+func (c *Cache[K, V]) Len() int { return len(c.items) }
+
+// Keys returns the keys, the most recently used first.
+func (c *Cache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.items))
+	for e := c.root.next; e != &c.root; e = e.next {
+		keys = append(keys, e.key)
+	}
+	return keys
+}
+
+// live returns the entry of key if it has not expired, and evicts it if it
+// has.
+func (c *Cache[K, V]) live(key K) (*entry[K, V], bool) {
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	// the clock is read only for entries with a TTL: time.Now costs more
+	// than the rest of a Get.
+	if !e.expires.IsZero() && e.expired(c.now()) {
+		c.evict(e)
+		return nil, false
+	}
+	return e, true
+}
+
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+func (c *Cache[K, V]) evict(e *entry[K, V]) {
+	c.unlink(e)
+	delete(c.items, e.key)
+	if c.OnEvict != nil {
+		c.OnEvict(e.key, e.value)
+	}
+}
+
+func (c *Cache[K, V]) insertFront(e *entry[K, V]) {
+	e.prev, e.next = &c.root, c.root.next
+	c.root.next.prev = e
+	c.root.next = e
+}
+
+func (c *Cache[K, V]) unlink(e *entry[K, V]) {
+	e.prev.next, e.next.prev = e.next, e.prev
+	e.prev, e.next = nil, nil // no pointer kept into the list
+}
+
+func (c *Cache[K, V]) moveToFront(e *entry[K, V]) {
+	if c.root.next == e {
+		return
+	}
+	c.unlink(e)
+	c.insertFront(e)
+}
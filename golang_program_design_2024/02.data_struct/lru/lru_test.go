@@ -0,0 +1,266 @@
+package lru
+
+import (
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+// clock is a time the tests move by hand.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTest(capacity int) (*Cache[string, int], *clock) {
+	clk := &clock{t: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	c := New[string, int](capacity)
+	c.now = clk.now
+	return c, clk
+}
+
+// check verifies the order of the keys, and that the list and the map
+// agree in both directions.
+func check(t *testing.T, c *Cache[string, int], want ...string) {
+	t.Helper()
+	keys := c.Keys()
+	if !slices.Equal(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+	var back []string
+	for e := c.root.prev; e != &c.root; e = e.prev {
+		back = append(back, e.key)
+	}
+	slices.Reverse(back)
+	if !slices.Equal(back, keys) {
+		t.Errorf("the list read backwards is %v, forwards %v", back, keys)
+	}
+	if c.Len() != len(keys) {
+		t.Errorf("Len = %d, %d entries in the list", c.Len(), len(keys))
+	}
+}
+
+func TestLRU(t *testing.T) {
+	c, _ := newTest(3)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	check(t, c, "c", "b", "a")
+
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %d, %v", v, ok)
+	}
+	check(t, c, "a", "c", "b")
+
+	c.Put("d", 4) // b is the least recently used
+	check(t, c, "d", "a", "c")
+	if _, ok := c.Get("b"); ok {
+		t.Error("b was not evicted")
+	}
+
+	c.Put("c", 30) // a replaced value moves to the front, nothing evicted
+	check(t, c, "c", "d", "a")
+	if v, _ := c.Peek("c"); v != 30 {
+		t.Errorf("c = %d, want 30", v)
+	}
+
+	c.Peek("a") // does not reorder
+	check(t, c, "c", "d", "a")
+
+	if !c.Remove("d") || c.Remove("d") {
+		t.Error("Remove reports wrong")
+	}
+	check(t, c, "c", "a")
+	c.Remove("c")
+	c.Remove("a")
+	check(t, c)
+	c.Put("e", 5)
+	check(t, c, "e")
+}
+
+func TestCapacityOne(t *testing.T) {
+	c, _ := newTest(1)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	check(t, c, "b")
+	c.Get("b")
+	check(t, c, "b")
+}
+
+func TestNewPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("New(0) did not panic")
+		}
+	}()
+	New[string, int](0)
+}
+
+func TestTTL(t *testing.T) {
+	c, clk := newTest(10)
+	var evicted []string
+	c.OnEvict = func(key string, _ int) { evicted = append(evicted, key) }
+
+	c.PutTTL("short", 1, time.Second)
+	c.PutTTL("long", 2, time.Minute)
+	c.Put("forever", 3)
+	c.PutTTL("zero", 4, 0) // never expires either
+
+	clk.advance(time.Second) // the second itself has passed: expired
+	if _, ok := c.Get("short"); ok {
+		t.Error("short is still there after its TTL")
+	}
+	if _, ok := c.Peek("long"); !ok {
+		t.Error("long expired too early")
+	}
+	check(t, c, "zero", "forever", "long")
+
+	c.PutTTL("long", 20, time.Minute) // a Put renews the expiration
+	clk.advance(59 * time.Second)
+	if v, ok := c.Get("long"); !ok || v != 20 {
+		t.Errorf("long = %d, %v after its renewal", v, ok)
+	}
+	c.Put("long", 200) // and a Put without TTL removes it
+	clk.advance(24 * time.Hour)
+	if _, ok := c.Get("long"); !ok {
+		t.Error("long expired after a Put without TTL")
+	}
+
+	c.PutTTL("a", 1, time.Second)
+	c.PutTTL("b", 1, time.Second)
+	clk.advance(time.Second)
+	if n := c.RemoveExpired(); n != 2 {
+		t.Errorf("RemoveExpired = %d, want 2", n)
+	}
+	check(t, c, "long", "zero", "forever")
+	if !slices.Equal(evicted, []string{"short", "b", "a"}) {
+		t.Errorf("evicted %v", evicted)
+	}
+}
+
+func TestOnEvict(t *testing.T) {
+	c, _ := newTest(2)
+	type kv struct {
+		k string
+		v int
+	}
+	var evicted []kv
+	c.OnEvict = func(k string, v int) { evicted = append(evicted, kv{k, v}) }
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("a", 10) // replaced: not an eviction
+	c.Remove("b")  // removed by the caller: not one either
+	c.Put("c", 3)
+	c.Put("d", 4) // a is evicted, with its last value
+	if !slices.Equal(evicted, []kv{{"a", 10}}) {
+		t.Errorf("evicted %v", evicted)
+	}
+}
+
+func TestSync(t *testing.T) {
+	var mu sync.Mutex
+	evictions := 0
+	c := New[int, int](100)
+	c.OnEvict = func(int, int) { mu.Lock(); evictions++; mu.Unlock() }
+	s := NewSync(c)
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := (g*1000 + i) % 300
+				s.Put(k, k)
+				if v, ok := s.Get(k); ok && v != k {
+					t.Errorf("Get(%d) = %d", k, v)
+				}
+				s.Peek(i)
+				if i%100 == 0 {
+					s.Keys()
+					s.RemoveExpired()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if s.Len() != 100 || len(s.Keys()) != 100 {
+		t.Errorf("Len = %d, %d keys, want 100", s.Len(), len(s.Keys()))
+	}
+	if evictions == 0 {
+		t.Error("no eviction with 300 keys in 100 places")
+	}
+}
+
+var sink int
+
+// sizes are the capacities of the caches benchmarked.
+var sizes = []int{1 << 10, 64 << 10}
+
+func BenchmarkGet(b *testing.B) {
+	benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+		c := New[int, int](n)
+		for i := 0; i < n; i++ {
+			c.Put(i, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			v, _ := c.Get(i % n)
+			sink += v
+		}
+	})
+}
+
+// BenchmarkPut puts new keys in a full cache: every Put evicts.
+func BenchmarkPut(b *testing.B) {
+	benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+		c := New[int, int](n)
+		for i := 1; i <= n; i++ {
+			c.Put(-i, i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.Put(i, i)
+		}
+	})
+}
+
+// BenchmarkMap is the lower bound: the map lookup alone, no order kept.
+func BenchmarkMap(b *testing.B) {
+	benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+		m := make(map[int]int, n)
+		for i := 0; i < n; i++ {
+			m[i] = i
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			sink += m[i%n]
+		}
+	})
+}
+
+// BenchmarkSync measures the mutex under contention: one lock for every
+// goroutine, so more of them do not go faster.
+func BenchmarkSync(b *testing.B) {
+	benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+		s := NewSync(New[int, int](n))
+		for i := 0; i < n; i++ {
+			s.Put(i, i)
+		}
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			i := 0
+			for pb.Next() {
+				if i%10 == 0 {
+					s.Put(i%(2*n), i)
+				} else {
+					s.Get(i % (2 * n))
+				}
+				i++
+			}
+		})
+	})
+}
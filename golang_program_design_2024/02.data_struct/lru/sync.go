@@ -0,0 +1,69 @@
+package lru
+
+import (
+	"sync"
+	"time"
+)
+
+// Sync is a Cache safe for concurrent use: every method takes one mutex. A
+// sync.RWMutex would not help, Get changes the order like Put does.
+//
+// OnEvict of the cache is called with the mutex held: it must not call
+// the Sync back, and should be quick.
+type Sync[K comparable, V any] struct {
+	mu sync.Mutex
+	c  *Cache[K, V]
+}
+
+// NewSync wraps c, which must not be used directly any more.
+func NewSync[K comparable, V any](c *Cache[K, V]) *Sync[K, V] {
+	return &Sync[K, V]{c: c}
+}
+
+func (s *Sync[K, V]) Get(key K) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Get(key)
+}
+
+func (s *Sync[K, V]) Peek(key K) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Peek(key)
+}
+
+func (s *Sync[K, V]) Put(key K, value V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.c.Put(key, value)
+}
+
+func (s *Sync[K, V]) PutTTL(key K, value V, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.c.PutTTL(key, value, ttl)
+}
+
+func (s *Sync[K, V]) Remove(key K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Remove(key)
+}
+
+func (s *Sync[K, V]) RemoveExpired() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.RemoveExpired()
+}
+
+func (s *Sync[K, V]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Len()
+}
+
+func (s *Sync[K, V]) Keys() []K {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Keys()
+}
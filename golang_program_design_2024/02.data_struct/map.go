@@ -1,9 +1,16 @@
-package main
+package datastruct
 
 import (
+	"encoding/json"
 	"fmt"
+	"slices"
 	"strconv"
 	"sync"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/orderedmap"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/set"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/skiplist"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
 )
 
 /*
@@ -24,11 +31,25 @@ Key uniqueness:
 Unordered collection:
 
 	The elements in a map are unordered. Each time you traverse a map, the order of the key-value pairs may be different.
+	orderedmap keeps the order the keys were set in, for when it matters: a config written back, JSON for humans.
+
+Lookup cost:
+
+	O(1), but each lookup hashes the key. For a handful of elements a loop over a slice is faster,
+	12.performance/lookup measures where the map starts to win.
 */
-func main() {
+func init() {
+	lessons.Register("02.data_struct/map", "maps: init, operations, concurrent access, sets, insertion order, key order", DemoMaps)
+}
+
+// DemoMaps runs the map demos.
+func DemoMaps() {
 	mapInit()
 	mapOpt()
 	mapAdcanced()
+	mapSet()
+	mapOrdered()
+	mapSorted()
 }
 
 // Notes on map initialization that the zero value of an uninitialized map is nil.
@@ -91,7 +112,7 @@ func mapAdcanced() {
 	// the overhead caused by the dynamic expansion of the map at runtime.
 	myMap := make(map[string]int, 100)
 	for i := 0; i < 102; i++ {
-		myMap[fmt.Sprintln("no.%s", strconv.Itoa(i))] = i
+		myMap[fmt.Sprintf("no.%s", strconv.Itoa(i))] = i
 	}
 	fmt.Println(len(myMap))
 
@@ -102,7 +123,7 @@ func mapAdcanced() {
 	newNums["three"] = 3
 	fmt.Printf("%+v", nums) // Write out the newly increased key-value pair
 
-	// sync.Map
+	// sync.Map; 04.concurrent/concurrentmap compares it with a sharded map.
 	var mySyncMap sync.Map
 	mySyncMap.Store("Alice", 23)
 	mySyncMap.Store("Bob", 25)
@@ -114,3 +135,90 @@ func mapAdcanced() {
 		return true // continue to iterate
 	})
 }
+
+// A map with empty values is a set: map[string]struct{}, struct{} taking no
+// memory. The set package wraps it with the operations of the maths.
+func mapSet() {
+	seen := map[string]struct{}{}
+	seen["go"] = struct{}{}
+	_, ok := seen["go"]
+	fmt.Println("go in the set:", ok)
+
+	backend := set.New("go", "sql", "docker", "linux")
+	frontend := set.New("js", "css", "docker", "linux")
+	// the items of a set come in the order of the map: sorted to print.
+	sorted := func(s set.Set[string]) []string {
+		v := s.Values()
+		slices.Sort(v)
+		return v
+	}
+	fmt.Println("union:       ", sorted(backend.Union(frontend)))
+	fmt.Println("intersection:", sorted(backend.Intersect(frontend)))
+	fmt.Println("difference:  ", sorted(backend.Difference(frontend)))
+	fmt.Println("{go, sql} a subset of backend:", set.New("go", "sql").SubsetOf(backend))
+
+	// Add reports whether the item is new: deduplicating, in order.
+	words := []string{"a", "rose", "is", "a", "rose", "is", "a", "rose"}
+	seenWords := set.New[string]()
+	var unique []string
+	for _, w := range words {
+		if seenWords.Add(w) {
+			unique = append(unique, w)
+		}
+	}
+	fmt.Println(unique)
+}
+
+// Ranging over a map starts at a random place: the same map, ranged twice,
+// most often comes in two orders. An OrderedMap keeps the order of the
+// first Set.
+func mapOrdered() {
+	squares := make(map[int]int)
+	for i := 0; i < 10; i++ {
+		squares[i] = i * i
+	}
+	for i := 0; i < 2; i++ {
+		var keys []int
+		for k := range squares {
+			keys = append(keys, k)
+		}
+		fmt.Println("map range:", keys)
+	}
+
+	ordered := orderedmap.New[string, string]()
+	ordered.Set("fetch", "git pull")
+	ordered.Set("build", "go build")
+	ordered.Set("test", "go test")
+	ordered.Set("deploy", "scp")
+	ordered.Set("build", "go build -trimpath") // rewritten: keeps its place
+	ordered.Delete("test")                     // unlinked, nothing shifted
+	fmt.Println("OrderedMap keys:", ordered.Keys())
+
+	// encoding/json sorts the keys of a map; MarshalJSON keeps the order.
+	plain, _ := json.Marshal(map[string]string{"fetch": "git pull", "build": "go build"})
+	kept, _ := json.Marshal(ordered)
+	fmt.Println(string(plain))
+	fmt.Println(string(kept))
+}
+
+// A skip list keeps the keys sorted, where a map must collect and sort them:
+// the prices of an order book, the best first, and those in a range.
+func mapSorted() {
+	asks := skiplist.New[float64, int](skiplist.DefaultMaxLevel, skiplist.DefaultP)
+	for price, qty := range map[float64]int{101.5: 30, 100.25: 10, 103: 5, 100.75: 20, 102: 15} {
+		asks.Insert(price, qty)
+	}
+	asks.Insert(100.25, 12) // updated in place
+	asks.Delete(103)
+
+	asks.All()(func(price float64, qty int) bool {
+		fmt.Printf("best ask: %v x %d\n", price, qty)
+		return false // the first key is the smallest
+	})
+	fmt.Print("asks in [100.5, 102): ")
+	asks.RangeQuery(100.5, 102)(func(price float64, qty int) bool {
+		fmt.Printf("%v x %d  ", price, qty)
+		return true
+	})
+	fmt.Println()
+}
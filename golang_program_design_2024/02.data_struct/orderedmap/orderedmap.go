@@ -0,0 +1,151 @@
+// Package orderedmap has OrderedMap, a map that remembers the order its
+// keys were first set in: Range, Keys and the JSON encoding go in that
+// order, the same on every run, where ranging over a Go map starts at a
+// random place each time.
+//
+//	m := orderedmap.New[string, int]()
+//	m.Set("b", 2)
+//	m.Set("a", 1)
+//	json.Marshal(m) // {"b":2,"a":1}
+//
+// A map finds the entry of a key, a doubly linked list (list.Doubly) keeps
+// the entries in order. Deleting unlinks the entry of the key in O(1): a
+// slice of keys would have to find it, then shift everything after it.
+package orderedmap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/list"
+)
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// OrderedMap is a map in insertion order. The zero value is an empty map,
+// ready to use. It is not safe for concurrent use.
+type OrderedMap[K comparable, V any] struct {
+	index map[K]*list.Node[entry[K, V]]
+	order list.Doubly[entry[K, V]]
+}
+
+// New returns an empty map.
+func New[K comparable, V any]() *OrderedMap[K, V] {
+	return &OrderedMap[K, V]{}
+}
+
+// Len returns the number of keys.
+func (m *OrderedMap[K, V]) Len() int { return len(m.index) }
+
+// Get returns the value of key, and whether it was there.
+func (m *OrderedMap[K, V]) Get(key K) (V, bool) {
+	if n, ok := m.index[key]; ok {
+		return n.Value.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Set sets the value of key. A new key goes last; a key already there
+// keeps its place, like a key rewritten in a JSON object.
+func (m *OrderedMap[K, V]) Set(key K, value V) {
+	if n, ok := m.index[key]; ok {
+		n.Value.value = value
+		return
+	}
+	if m.index == nil {
+		m.index = make(map[K]*list.Node[entry[K, V]])
+	}
+	m.index[key] = m.order.PushBack(entry[K, V]{key, value})
+}
+
+// Delete removes key, and reports whether it was there.
+func (m *OrderedMap[K, V]) Delete(key K) bool {
+	n, ok := m.index[key]
+	if !ok {
+		return false
+	}
+	delete(m.index, key)
+	m.order.Remove(n)
+	return true
+}
+
+// Keys returns the keys in order.
+func (m *OrderedMap[K, V]) Keys() []K {
+	keys := make([]K, 0, m.Len())
+	for n := m.order.Front(); n != nil; n = n.Next() {
+		keys = append(keys, n.Value.key)
+	}
+	return keys
+}
+
+// Range calls f for each key and value, in order, until f returns false.
+// f may delete the key it is given: the next entry is read before the call.
+func (m *OrderedMap[K, V]) Range(f func(key K, value V) bool) {
+	for n := m.order.Front(); n != nil; {
+		next := n.Next()
+		if !f(n.Value.key, n.Value.value) {
+			return
+		}
+		n = next
+	}
+}
+
+// MarshalJSON encodes the map as a JSON object, its keys in order. The keys
+// are encoded like those of a Go map by encoding/json: a string, an
+// integer, or an encoding.TextMarshaler; a key of another type is an error.
+func (m *OrderedMap[K, V]) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	var err error
+	m.Range(func(key K, value V) bool {
+		var k string
+		if k, err = keyString(key); err != nil {
+			return false
+		}
+		var kb, vb []byte
+		if kb, err = json.Marshal(k); err != nil {
+			return false
+		}
+		if vb, err = json.Marshal(value); err != nil {
+			return false
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(vb)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// keyString is the text of a key in a JSON object.
+func keyString(key any) (string, error) {
+	if tm, ok := key.(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	v := reflect.ValueOf(key)
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("orderedmap: a key of type %T cannot be a JSON object key", key)
+}
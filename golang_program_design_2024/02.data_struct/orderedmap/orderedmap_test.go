@@ -0,0 +1,150 @@
+package orderedmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"slices"
+	"testing"
+)
+
+func TestSetGetDelete(t *testing.T) {
+	var m OrderedMap[string, int] // the zero value is ready to use
+	for i, k := range []string{"c", "a", "b"} {
+		m.Set(k, i)
+	}
+	m.Set("c", 10) // rewritten: keeps its place
+	if got := m.Keys(); !slices.Equal(got, []string{"c", "a", "b"}) {
+		t.Errorf("Keys = %v, want [c a b]", got)
+	}
+	if v, ok := m.Get("c"); !ok || v != 10 {
+		t.Errorf("Get(c) = %d, %v, want 10, true", v, ok)
+	}
+	if _, ok := m.Get("z"); ok {
+		t.Error("Get(z) found a key never set")
+	}
+	if !m.Delete("a") || m.Delete("a") {
+		t.Error("Delete(a) twice: want true, then false")
+	}
+	m.Set("a", 20) // set again after Delete: a new key, last
+	if got := m.Keys(); !slices.Equal(got, []string{"c", "b", "a"}) || m.Len() != 3 {
+		t.Errorf("Keys = %v, Len = %d, want [c b a], 3", got, m.Len())
+	}
+}
+
+func TestRange(t *testing.T) {
+	m := New[int, string]()
+	for i := 0; i < 5; i++ {
+		m.Set(i, fmt.Sprint("v", i))
+	}
+	var keys []int
+	m.Range(func(k int, v string) bool {
+		if v != fmt.Sprint("v", k) {
+			t.Errorf("Range gave %d: %q", k, v)
+		}
+		keys = append(keys, k)
+		return k < 2
+	})
+	if !slices.Equal(keys, []int{0, 1, 2}) {
+		t.Errorf("Range until false = %v, want [0 1 2]", keys)
+	}
+
+	// deleting during Range: the current key, and one not reached yet.
+	keys = keys[:0]
+	m.Range(func(k int, _ string) bool {
+		keys = append(keys, k)
+		m.Delete(k)
+		if k == 1 {
+			m.Delete(3)
+		}
+		return true
+	})
+	if !slices.Equal(keys, []int{0, 1, 2, 4}) || m.Len() != 0 {
+		t.Errorf("Range deleting = %v, %d left, want [0 1 2 4], 0", keys, m.Len())
+	}
+}
+
+// TestOrderStable ranges over the same keys many times: the plain map's
+// order changes from one range to the next, the OrderedMap's never does.
+func TestOrderStable(t *testing.T) {
+	const n = 100
+	plain := make(map[string]int)
+	m := New[string, int]()
+	var want []string
+	for i := 0; i < n; i++ {
+		k := fmt.Sprintf("key%02d", i)
+		plain[k] = i
+		m.Set(k, i)
+		want = append(want, k)
+	}
+
+	plainOrders := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		var keys []string
+		for k := range plain {
+			keys = append(keys, k)
+		}
+		plainOrders[fmt.Sprint(keys)] = true
+
+		keys = keys[:0]
+		m.Range(func(k string, _ int) bool {
+			keys = append(keys, k)
+			return true
+		})
+		if !slices.Equal(keys, want) {
+			t.Fatalf("range %d of the OrderedMap out of insertion order: %v", i, keys)
+		}
+	}
+	// the runtime starts each range of a map at a random place: 20 ranges
+	// in one order are as good as impossible (a typical run sees 15 to 20
+	// different orders).
+	if len(plainOrders) == 1 {
+		t.Error("20 ranges over a plain map came in the same order")
+	}
+	t.Logf("20 ranges: %d orders for the map, 1 for the OrderedMap", len(plainOrders))
+}
+
+func TestMarshalJSON(t *testing.T) {
+	m := New[string, any]()
+	m.Set("zebra", 1)
+	m.Set("apple", []int{2, 3})
+	m.Set("mango", map[string]bool{"ripe": true})
+	m.Set("quote\"d", nil)
+	got, err := json.Marshal(m)
+	want := `{"zebra":1,"apple":[2,3],"mango":{"ripe":true},"quote\"d":null}`
+	if err != nil || string(got) != want {
+		t.Errorf("Marshal = %s, %v, want %s", got, err, want)
+	}
+
+	// the same keys in a plain map: sorted by encoding/json, not in order.
+	plain, _ := json.Marshal(map[string]int{"zebra": 1, "apple": 2})
+	if string(plain) != `{"apple":2,"zebra":1}` {
+		t.Errorf("plain map = %s", plain)
+	}
+
+	if got, _ := json.Marshal(New[int, int]()); string(got) != "{}" {
+		t.Errorf("empty map = %s, want {}", got)
+	}
+	ints := New[int8, bool]()
+	ints.Set(-1, true)
+	ints.Set(7, false)
+	if got, err := json.Marshal(ints); err != nil || string(got) != `{"-1":true,"7":false}` {
+		t.Errorf("int keys = %s, %v", got, err)
+	}
+	addrs := New[netip.Addr, int]() // a TextMarshaler
+	addrs.Set(netip.MustParseAddr("10.0.0.1"), 1)
+	if got, err := json.Marshal(addrs); err != nil || string(got) != `{"10.0.0.1":1}` {
+		t.Errorf("TextMarshaler keys = %s, %v", got, err)
+	}
+
+	floats := New[float64, int]()
+	floats.Set(1.5, 1)
+	if _, err := json.Marshal(floats); err == nil {
+		t.Error("float keys: want an error, like for a map[float64]int")
+	}
+	bad := New[string, func()]()
+	bad.Set("f", func() {})
+	if _, err := json.Marshal(bad); err == nil {
+		t.Error("a value JSON cannot encode: want an error")
+	}
+}
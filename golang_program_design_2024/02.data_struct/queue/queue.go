@@ -0,0 +1,143 @@
+// Package queue has two generic first in, first out queues: Queue, on a
+// slice used as a ring, and Linked, on a singly linked list. Both have the
+// same methods; the tests run the same cases on each.
+//
+// A queue on a plain slice, append at the back and s = s[1:] at the front,
+// looks right and leaks: the slice moves forward in its backing array, the
+// space before it is never used again, and each append past the end copies
+// the live part to a new array. Queue wraps around instead: the head moves
+// forward modulo the capacity, and the array grows, by doubling, only when
+// it is full. Linked needs no growing, and allocates a node per Push.
+//
+// A channel is a queue too, a bounded one, safe between goroutines: the
+// one to use to pass work from goroutine to goroutine. These are for one
+// goroutine: the frontier of a breadth-first search, a buffer of events.
+package queue
+
+// Queue is a queue on a ring buffer that grows as needed. The zero value is
+// an empty queue.
+type Queue[T any] struct {
+	buf  []T
+	head int // index of the front in buf
+	len  int
+}
+
+// Len returns the number of elements.
+func (q *Queue[T]) Len() int { return q.len }
+
+// Push adds v at the back.
+func (q *Queue[T]) Push(v T) {
+	if q.len == len(q.buf) {
+		q.grow()
+	}
+	q.buf[(q.head+q.len)%len(q.buf)] = v
+	q.len++
+}
+
+// grow doubles the buffer, copying the elements to its start in order: the
+// wrapped part, at the start of the old buffer, goes after the rest.
+func (q *Queue[T]) grow() {
+	buf := make([]T, max(2*len(q.buf), 8))
+	n := copy(buf, q.buf[q.head:])
+	copy(buf[n:], q.buf[:q.head])
+	q.buf, q.head = buf, 0
+}
+
+// Pop removes and returns the front, or false if the queue is empty.
+func (q *Queue[T]) Pop() (T, bool) {
+	var zero T
+	if q.len == 0 {
+		return zero, false
+	}
+	v := q.buf[q.head]
+	q.buf[q.head] = zero // let the GC take what the element points to
+	q.head = (q.head + 1) % len(q.buf)
+	q.len--
+	return v, true
+}
+
+// Peek returns the front without removing it, or false if the queue is
+// empty.
+func (q *Queue[T]) Peek() (T, bool) {
+	if q.len == 0 {
+		var zero T
+		return zero, false
+	}
+	return q.buf[q.head], true
+}
+
+// All returns an iterator over the elements, front to back, the order Pop
+// would return them.
+func (q *Queue[T]) All() func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for i := 0; i < q.len; i++ {
+			if !yield(q.buf[(q.head+i)%len(q.buf)]) {
+				return
+			}
+		}
+	}
+}
+
+// node is an element of a Linked queue.
+type node[T any] struct {
+	value T
+	next  *node[T]
+}
+
+// Linked is a queue on a singly linked list: Pop takes the head, Push adds
+// after the tail. The zero value is an empty queue.
+type Linked[T any] struct {
+	head, tail *node[T]
+	len        int
+}
+
+// Len returns the number of elements.
+func (q *Linked[T]) Len() int { return q.len }
+
+// Push adds v at the back.
+func (q *Linked[T]) Push(v T) {
+	n := &node[T]{value: v}
+	if q.tail == nil {
+		q.head = n
+	} else {
+		q.tail.next = n
+	}
+	q.tail = n
+	q.len++
+}
+
+// Pop removes and returns the front, or false if the queue is empty.
+func (q *Linked[T]) Pop() (T, bool) {
+	if q.head == nil {
+		var zero T
+		return zero, false
+	}
+	n := q.head
+	q.head, n.next = n.next, nil
+	if q.head == nil {
+		q.tail = nil
+	}
+	q.len--
+	return n.value, true
+}
+
+// Peek returns the front without removing it, or false if the queue is
+// empty.
+func (q *Linked[T]) Peek() (T, bool) {
+	if q.head == nil {
+		var zero T
+		return zero, false
+	}
+	return q.head.value, true
+}
+
+// All returns an iterator over the elements, front to back.
+func (q *Linked[T]) All() func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for n := q.head; n != nil; n = n.next {
+			if !yield(n.value) {
+				return
+			}
+		}
+	}
+}
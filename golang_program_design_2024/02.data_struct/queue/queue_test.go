@@ -0,0 +1,129 @@
+package queue
+
+import (
+	"slices"
+	"testing"
+)
+
+// queue is what Queue and Linked both are.
+type queue interface {
+	Len() int
+	Push(v int)
+	Pop() (int, bool)
+	Peek() (int, bool)
+	All() func(yield func(int) bool)
+}
+
+var impls = []struct {
+	name string
+	new  func() queue
+}{
+	{"ring", func() queue { return &Queue[int]{} }},
+	{"linked", func() queue { return &Linked[int]{} }},
+}
+
+func elements(q queue) []int {
+	var out []int
+	q.All()(func(v int) bool {
+		out = append(out, v)
+		return true
+	})
+	return out
+}
+
+func TestQueue(t *testing.T) {
+	for _, impl := range impls {
+		t.Run(impl.name, func(t *testing.T) {
+			q := impl.new()
+			if _, ok := q.Pop(); ok {
+				t.Error("Pop on an empty queue")
+			}
+			if _, ok := q.Peek(); ok {
+				t.Error("Peek on an empty queue")
+			}
+
+			for i := 1; i <= 3; i++ {
+				q.Push(i)
+			}
+			if got := elements(q); !slices.Equal(got, []int{1, 2, 3}) {
+				t.Errorf("All = %v", got)
+			}
+			var first []int
+			q.All()(func(v int) bool { first = append(first, v); return false })
+			if !slices.Equal(first, []int{1}) {
+				t.Errorf("stopped All = %v", first)
+			}
+			if v, ok := q.Peek(); !ok || v != 1 || q.Len() != 3 {
+				t.Errorf("Peek = %d, %v, Len %d", v, ok, q.Len())
+			}
+			var popped []int
+			for q.Len() > 0 {
+				v, _ := q.Pop()
+				popped = append(popped, v)
+			}
+			if !slices.Equal(popped, []int{1, 2, 3}) {
+				t.Errorf("popped %v, want first in first out", popped)
+			}
+			q.Push(4) // empty again, then used again
+			if v, _ := q.Pop(); v != 4 {
+				t.Errorf("Pop = %d after emptying", v)
+			}
+
+			// Interleaved, against a slice as the model: two pushes for
+			// one pop, so the ring wraps around and grows while wrapped.
+			var model []int
+			for i := 0; i < 1000; i++ {
+				if i%3 == 2 {
+					v, ok := q.Pop()
+					if ok != (len(model) > 0) || ok && v != model[0] {
+						t.Fatalf("step %d: Pop = %d, %v; model starts %v", i, v, ok, model[:min(3, len(model))])
+					}
+					if ok {
+						model = model[1:]
+					}
+					continue
+				}
+				q.Push(i)
+				model = append(model, i)
+			}
+			if got := elements(q); !slices.Equal(got, model) {
+				t.Errorf("after the mix: %d elements, want %d", len(got), len(model))
+			}
+		})
+	}
+}
+
+// TestRingWraps checks the case grow must handle: full, with the front in
+// the middle of the buffer.
+func TestRingWraps(t *testing.T) {
+	var q Queue[int]
+	for i := 0; i < 8; i++ {
+		q.Push(i)
+	}
+	for i := 0; i < 5; i++ {
+		q.Pop()
+	}
+	for i := 8; i < 13; i++ {
+		q.Push(i) // 8..12 wrap to the start of the buffer
+	}
+	if q.head != 5 || len(q.buf) != 8 {
+		t.Fatalf("head %d, buffer %d: not wrapped", q.head, len(q.buf))
+	}
+	q.Push(13) // full: grows
+	want := []int{5, 6, 7, 8, 9, 10, 11, 12, 13}
+	if got := elements(&q); !slices.Equal(got, want) || q.head != 0 || len(q.buf) != 16 {
+		t.Errorf("after growing: %v, head %d, buffer %d", got, q.head, len(q.buf))
+	}
+}
+
+func TestPopClears(t *testing.T) {
+	var q Queue[*int]
+	x := 1
+	q.Push(&x)
+	q.Pop()
+	for _, p := range q.buf {
+		if p != nil {
+			t.Error("the popped pointer is still in the buffer")
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Package set has Set, a generic set of comparable values, with the
+// operations of the maths: union, intersection, difference, subset. Sync is
+// the same set behind a mutex, for goroutines sharing it.
+//
+//	seen := set.New("a", "b")
+//	if seen.Add("c") { ... } // true: "c" was not there
+//	both := seen.Intersect(set.New("b", "c", "d")) // {b, c}
+//
+// A Set is a map with empty values, map[T]struct{}: struct{} takes no
+// memory, and a Set can be ranged over, or made with make, like the map it
+// is. 06.generics/containers builds the first Set of this kind as a lesson
+// on generic types.
+package set
+
+// Set is a set of comparable values. The zero value is a nil map: it can be
+// read, but Add panics on it; make one with New or make.
+type Set[T comparable] map[T]struct{}
+
+// New returns a set of the given items, duplicates counted once.
+func New[T comparable](items ...T) Set[T] {
+	s := make(Set[T], len(items))
+	for _, v := range items {
+		s[v] = struct{}{}
+	}
+	return s
+}
+
+// Len returns the number of items.
+func (s Set[T]) Len() int { return len(s) }
+
+// Add adds v, and reports whether it was not in the set already: a
+// check-and-add in one lookup, where Contains then Add would take two.
+func (s Set[T]) Add(v T) bool {
+	n := len(s)
+	s[v] = struct{}{}
+	return len(s) > n
+}
+
+// Remove removes v, and reports whether it was in the set.
+func (s Set[T]) Remove(v T) bool {
+	n := len(s)
+	delete(s, v)
+	return len(s) < n
+}
+
+// Contains reports whether v is in the set.
+func (s Set[T]) Contains(v T) bool {
+	_, ok := s[v]
+	return ok
+}
+
+// Clone returns a copy of s. The copy of a nil set is an empty set, not
+// nil: the copy can always be added to.
+func (s Set[T]) Clone() Set[T] {
+	out := make(Set[T], len(s))
+	for v := range s {
+		out[v] = struct{}{}
+	}
+	return out
+}
+
+// Union returns a new set of the items in s or in other.
+func (s Set[T]) Union(other Set[T]) Set[T] {
+	out := make(Set[T], max(len(s), len(other)))
+	for v := range s {
+		out[v] = struct{}{}
+	}
+	for v := range other {
+		out[v] = struct{}{}
+	}
+	return out
+}
+
+// Intersect returns a new set of the items in both s and other. It ranges
+// over the smaller set and looks up in the larger one.
+func (s Set[T]) Intersect(other Set[T]) Set[T] {
+	small, large := s, other
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+	out := make(Set[T])
+	for v := range small {
+		if large.Contains(v) {
+			out[v] = struct{}{}
+		}
+	}
+	return out
+}
+
+// Difference returns a new set of the items in s that are not in other.
+func (s Set[T]) Difference(other Set[T]) Set[T] {
+	out := make(Set[T])
+	for v := range s {
+		if !other.Contains(v) {
+			out[v] = struct{}{}
+		}
+	}
+	return out
+}
+
+// SubsetOf reports whether every item of s is in other. The empty set is a
+// subset of every set.
+func (s Set[T]) SubsetOf(other Set[T]) bool {
+	if len(s) > len(other) {
+		return false
+	}
+	for v := range s {
+		if !other.Contains(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether s and other have the same items.
+func (s Set[T]) Equal(other Set[T]) bool {
+	return len(s) == len(other) && s.SubsetOf(other)
+}
+
+// All returns an iterator over the items, in the order of the map: a
+// different one each time.
+func (s Set[T]) All() func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for v := range s {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns the items in a slice, in no particular order.
+// slices.Sort(s.Values()) sorts them, for a T that can be ordered.
+func (s Set[T]) Values() []T {
+	out := make([]T, 0, len(s))
+	for v := range s {
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,143 @@
+package set
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// sorted returns the items of s in order, to compare with a literal.
+func sorted(s Set[int]) []int {
+	v := s.Values()
+	slices.Sort(v)
+	return v
+}
+
+func TestAddRemove(t *testing.T) {
+	s := New(1, 2, 2, 3)
+	if s.Len() != 3 {
+		t.Fatalf("New(1, 2, 2, 3) has %d items, want 3", s.Len())
+	}
+	if !s.Add(4) || s.Add(4) {
+		t.Error("Add(4) twice: want true, then false")
+	}
+	if !s.Contains(4) || s.Contains(5) {
+		t.Error("Contains(4), Contains(5): want true, false")
+	}
+	if !s.Remove(4) || s.Remove(4) {
+		t.Error("Remove(4) twice: want true, then false")
+	}
+	if got := sorted(s); !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("items = %v, want [1 2 3]", got)
+	}
+}
+
+func TestNil(t *testing.T) {
+	var s Set[int]
+	if s.Len() != 0 || s.Contains(1) || s.Remove(1) {
+		t.Error("a nil set should read as empty")
+	}
+	c := s.Clone()
+	if c == nil || !c.Add(1) {
+		t.Error("the clone of a nil set should be an empty set, ready to use")
+	}
+	if !s.SubsetOf(New(1)) || !s.Equal(New[int]()) {
+		t.Error("a nil set is the empty set")
+	}
+}
+
+func TestOperations(t *testing.T) {
+	a, b := New(1, 2, 3, 4), New(3, 4, 5)
+	for _, tt := range []struct {
+		name string
+		got  Set[int]
+		want []int
+	}{
+		{"a ∪ b", a.Union(b), []int{1, 2, 3, 4, 5}},
+		{"a ∩ b", a.Intersect(b), []int{3, 4}},
+		{"b ∩ a", b.Intersect(a), []int{3, 4}},
+		{"a - b", a.Difference(b), []int{1, 2}},
+		{"b - a", b.Difference(a), []int{5}},
+		{"a ∩ ∅", a.Intersect(New[int]()), []int{}},
+		{"a ∪ nil", a.Union(nil), []int{1, 2, 3, 4}},
+	} {
+		if got := sorted(tt.got); !slices.Equal(got, tt.want) {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got := sorted(a); !slices.Equal(got, []int{1, 2, 3, 4}) {
+		t.Errorf("a changed by the operations: %v", got)
+	}
+}
+
+func TestSubsetEqual(t *testing.T) {
+	for _, tt := range []struct {
+		a, b          Set[int]
+		subset, equal bool
+	}{
+		{New(1, 2), New(1, 2, 3), true, false},
+		{New(1, 2, 3), New(1, 2), false, false},
+		{New(1, 2), New(2, 1), true, true},
+		{New(1, 4), New(1, 2, 3), false, false},
+		{New[int](), New(1), true, false},
+	} {
+		if got := tt.a.SubsetOf(tt.b); got != tt.subset {
+			t.Errorf("%v.SubsetOf(%v) = %v", sorted(tt.a), sorted(tt.b), got)
+		}
+		if got := tt.a.Equal(tt.b); got != tt.equal {
+			t.Errorf("%v.Equal(%v) = %v", sorted(tt.a), sorted(tt.b), got)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	s := New(1, 2, 3, 4, 5)
+	var all []int
+	s.All()(func(v int) bool { all = append(all, v); return true })
+	slices.Sort(all)
+	if !slices.Equal(all, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("All yielded %v", all)
+	}
+	n := 0
+	s.All()(func(int) bool { n++; return n < 2 })
+	if n != 2 {
+		t.Errorf("All went on after false: %d calls", n)
+	}
+}
+
+func TestSync(t *testing.T) {
+	var s Sync[string] // the zero value is ready to use
+	const goroutines, keys = 8, 500
+	var added [goroutines]int
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < keys; i++ {
+				if s.Add(fmt.Sprint(i)) {
+					added[g]++
+				}
+				s.Contains(fmt.Sprint(i + 1))
+			}
+		}()
+	}
+	wg.Wait()
+	total := 0
+	for _, n := range added {
+		total += n
+	}
+	if total != keys || s.Len() != keys {
+		t.Errorf("%d Adds returned true, %d items: want %d of each", total, s.Len(), keys)
+	}
+
+	snap := s.Snapshot()
+	s.Remove("0")
+	if !snap.Contains("0") || s.Contains("0") {
+		t.Error("the snapshot should not change with the set")
+	}
+	if n := NewSync(1, 2, 2).Len(); n != 2 {
+		t.Errorf("NewSync(1, 2, 2) has %d items", n)
+	}
+}
@@ -0,0 +1,59 @@
+package set
+
+import "sync"
+
+// Sync is a Set safe for concurrent use, behind a sync.RWMutex: Contains
+// and Len share the lock, Add and Remove wait for everyone. The zero value
+// is an empty set, ready to use.
+//
+// The set operations are on Set: Snapshot copies the items out, and the
+// union or intersection is computed on the copy, without the lock.
+type Sync[T comparable] struct {
+	mu sync.RWMutex
+	s  Set[T]
+}
+
+// NewSync returns a Sync set of the given items.
+func NewSync[T comparable](items ...T) *Sync[T] {
+	return &Sync[T]{s: New(items...)}
+}
+
+// Add adds v, and reports whether it was not in the set already. Two
+// goroutines adding the same v at once: one gets true, the other false,
+// which makes Add the check of "has anybody done v yet?".
+func (s *Sync[T]) Add(v T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.s == nil {
+		s.s = make(Set[T])
+	}
+	return s.s.Add(v)
+}
+
+// Remove removes v, and reports whether it was in the set.
+func (s *Sync[T]) Remove(v T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.s.Remove(v)
+}
+
+// Contains reports whether v is in the set.
+func (s *Sync[T]) Contains(v T) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.s.Contains(v)
+}
+
+// Len returns the number of items.
+func (s *Sync[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.s)
+}
+
+// Snapshot returns a copy of the items, as a Set of its own.
+func (s *Sync[T]) Snapshot() Set[T] {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.s.Clone()
+}
@@ -0,0 +1,184 @@
+// Package skiplist has SkipList, a map that keeps its keys sorted: Search,
+// Insert and Delete in O(log n) on average, and the keys in order for
+// iterating and for range queries, which a Go map cannot do without
+// sorting its keys first.
+//
+//	l := skiplist.New[int, string](skiplist.DefaultMaxLevel, skiplist.DefaultP)
+//	l.Insert(30, "c")
+//	l.Insert(10, "a")
+//	l.RangeQuery(5, 20)(func(k int, v string) bool { ... }) // 10 a
+//
+// A skip list is a sorted linked list with express lanes: every node is on
+// level 0, a fraction p of them also on level 1, p² on level 2, and so on.
+// A search runs along the highest level until the next key is too large,
+// then drops a level: about 1/p steps per level, log_{1/p}(n) levels. The
+// levels are drawn at random, not rebalanced like the nodes of a balanced
+// tree: the code is short, and the O(log n) is an average, on any order of
+// insertion.
+//
+// Which one: the benchmarks of the tests compare it with an AVL tree and a
+// map. The map is 10x faster to search and 4x to fill, and must sort its
+// keys for anything in order: slower than both for range queries and
+// iteration, and an allocation each time. The AVL tree searches in about
+// half the time, walking fewer nodes. The skip list is the simplest of the
+// ordered two, and the one that goes lock-free (Java's
+// ConcurrentSkipListMap), where rebalancing a tree would lock a subtree.
+package skiplist
+
+import (
+	"cmp"
+	"math/rand"
+)
+
+const (
+	// DefaultMaxLevel is enough levels for 4^32 keys at DefaultP.
+	DefaultMaxLevel = 32
+	// DefaultP is the fraction of the nodes of a level also on the next:
+	// 1/4, the choice of Redis, for fewer pointers per node than 1/2 and
+	// about the same search.
+	DefaultP = 0.25
+)
+
+type node[K cmp.Ordered, V any] struct {
+	key   K
+	value V
+	next  []*node[K, V] // next[i] is the next node on level i
+	// one is the next of a node on level 0 only, 1-p of them: the node and
+	// its next in one allocation, one cache line, where a slice of its own
+	// would cost a second of each.
+	one [1]*node[K, V]
+}
+
+// SkipList is a sorted map. It is not safe for concurrent use.
+type SkipList[K cmp.Ordered, V any] struct {
+	head     node[K, V] // a sentinel before the first key, on every level
+	level    int        // levels in use, at least 1
+	len      int
+	p        float64
+	rnd      *rand.Rand
+	update   []*node[K, V] // scratch of Insert and Delete: the last node before the key, per level
+	maxLevel int
+}
+
+// New returns an empty skip list of at most maxLevel levels, a node on a
+// level being on the next one with probability p. maxLevel around
+// log_{1/p}(n) of the largest n expected is enough; past that the list
+// still works, slower. New panics if maxLevel < 1 or p is not in (0, 1).
+func New[K cmp.Ordered, V any](maxLevel int, p float64) *SkipList[K, V] {
+	if maxLevel < 1 {
+		panic("skiplist: maxLevel < 1")
+	}
+	if p <= 0 || p >= 1 {
+		panic("skiplist: p not in (0, 1)")
+	}
+	return &SkipList[K, V]{
+		head:     node[K, V]{next: make([]*node[K, V], maxLevel)},
+		level:    1,
+		p:        p,
+		rnd:      rand.New(rand.NewSource(rand.Int63())),
+		update:   make([]*node[K, V], maxLevel),
+		maxLevel: maxLevel,
+	}
+}
+
+// Len returns the number of keys.
+func (l *SkipList[K, V]) Len() int { return l.len }
+
+// randomLevel draws the number of levels of a new node: 1, then one more
+// with probability p each time.
+func (l *SkipList[K, V]) randomLevel() int {
+	level := 1
+	for level < l.maxLevel && l.rnd.Float64() < l.p {
+		level++
+	}
+	return level
+}
+
+// seek returns the last node before key on level 0, filling update with
+// the last one before key on every level in use when update is true.
+func (l *SkipList[K, V]) seek(key K, update bool) *node[K, V] {
+	x := &l.head
+	for i := l.level - 1; i >= 0; i-- {
+		for x.next[i] != nil && x.next[i].key < key {
+			x = x.next[i]
+		}
+		if update {
+			l.update[i] = x
+		}
+	}
+	return x
+}
+
+// Search returns the value of key, and whether it was there.
+func (l *SkipList[K, V]) Search(key K) (V, bool) {
+	if x := l.seek(key, false).next[0]; x != nil && x.key == key {
+		return x.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Insert sets the value of key, and reports whether the key is new.
+func (l *SkipList[K, V]) Insert(key K, value V) bool {
+	if x := l.seek(key, true).next[0]; x != nil && x.key == key {
+		x.value = value
+		return false
+	}
+	level := l.randomLevel()
+	for i := l.level; i < level; i++ {
+		l.update[i] = &l.head // the new levels start at the head
+	}
+	l.level = max(l.level, level)
+	x := &node[K, V]{key: key, value: value}
+	if level == 1 {
+		x.next = x.one[:]
+	} else {
+		x.next = make([]*node[K, V], level)
+	}
+	for i := 0; i < level; i++ {
+		x.next[i] = l.update[i].next[i]
+		l.update[i].next[i] = x
+	}
+	l.len++
+	return true
+}
+
+// Delete removes key, and reports whether it was there.
+func (l *SkipList[K, V]) Delete(key K) bool {
+	x := l.seek(key, true).next[0]
+	if x == nil || x.key != key {
+		return false
+	}
+	for i := 0; i < len(x.next); i++ {
+		l.update[i].next[i] = x.next[i]
+	}
+	for l.level > 1 && l.head.next[l.level-1] == nil {
+		l.level--
+	}
+	l.len--
+	return true
+}
+
+// All returns an iterator over the keys and values, in key order.
+func (l *SkipList[K, V]) All() func(yield func(K, V) bool) {
+	return func(yield func(K, V) bool) {
+		for x := l.head.next[0]; x != nil; x = x.next[0] {
+			if !yield(x.key, x.value) {
+				return
+			}
+		}
+	}
+}
+
+// RangeQuery returns an iterator over the keys from lo, included, to hi,
+// excluded, and their values, in key order. Finding lo is a search; the
+// keys after it are a walk along level 0.
+func (l *SkipList[K, V]) RangeQuery(lo, hi K) func(yield func(K, V) bool) {
+	return func(yield func(K, V) bool) {
+		for x := l.seek(lo, false).next[0]; x != nil && x.key < hi; x = x.next[0] {
+			if !yield(x.key, x.value) {
+				return
+			}
+		}
+	}
+}
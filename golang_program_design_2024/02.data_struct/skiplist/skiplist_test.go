@@ -0,0 +1,395 @@
+package skiplist
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+// keys returns what the iterator all yields, the keys only.
+func keys(all func(yield func(int, string) bool)) []int {
+	var out []int
+	all(func(k int, _ string) bool {
+		out = append(out, k)
+		return true
+	})
+	return out
+}
+
+func TestInsertSearchDelete(t *testing.T) {
+	l := New[int, string](DefaultMaxLevel, DefaultP)
+	for _, k := range []int{30, 10, 20, 50, 40} {
+		if !l.Insert(k, fmt.Sprint("v", k)) {
+			t.Errorf("Insert(%d) of a new key returned false", k)
+		}
+	}
+	if l.Insert(20, "twenty") {
+		t.Error("Insert(20) of a key there returned true")
+	}
+	if v, ok := l.Search(20); !ok || v != "twenty" {
+		t.Errorf("Search(20) = %q, %v, want twenty, true", v, ok)
+	}
+	for _, k := range []int{0, 25, 60} {
+		if _, ok := l.Search(k); ok {
+			t.Errorf("Search(%d) found a key never inserted", k)
+		}
+	}
+	if got := keys(l.All()); !slices.Equal(got, []int{10, 20, 30, 40, 50}) || l.Len() != 5 {
+		t.Errorf("All = %v, Len = %d", got, l.Len())
+	}
+	if !l.Delete(10) || !l.Delete(50) || l.Delete(10) || l.Delete(35) {
+		t.Error("Delete: want true for 10 and 50, false for 10 again and 35")
+	}
+	if got := keys(l.All()); !slices.Equal(got, []int{20, 30, 40}) || l.Len() != 3 {
+		t.Errorf("after Delete: %v, Len = %d", got, l.Len())
+	}
+}
+
+func TestRangeQuery(t *testing.T) {
+	l := New[int, string](8, 0.5)
+	for k := 0; k < 100; k += 10 {
+		l.Insert(k, "")
+	}
+	for _, tt := range []struct {
+		lo, hi int
+		want   []int
+	}{
+		{20, 50, []int{20, 30, 40}},
+		{15, 51, []int{20, 30, 40, 50}},
+		{-5, 10, []int{0}},
+		{90, 1000, []int{90}},
+		{41, 49, nil},
+		{50, 20, nil},
+	} {
+		if got := keys(l.RangeQuery(tt.lo, tt.hi)); !slices.Equal(got, tt.want) {
+			t.Errorf("RangeQuery(%d, %d) = %v, want %v", tt.lo, tt.hi, got, tt.want)
+		}
+	}
+	n := 0
+	l.RangeQuery(0, 100)(func(int, string) bool { n++; return n < 3 })
+	if n != 3 {
+		t.Errorf("RangeQuery went on after false: %d calls", n)
+	}
+}
+
+// TestRandom checks the list against a map and a sorted slice of its keys,
+// over random inserts and deletes, and that the levels shrink back.
+func TestRandom(t *testing.T) {
+	for _, p := range []float64{0.25, 0.5} {
+		r := rand.New(rand.NewSource(1))
+		l := New[int, string](12, p)
+		want := make(map[int]string)
+		for i := 0; i < 20000; i++ {
+			k := r.Intn(2000)
+			if r.Intn(3) == 0 {
+				_, had := want[k]
+				delete(want, k)
+				if l.Delete(k) != had {
+					t.Fatalf("p=%v: Delete(%d) disagrees with the map", p, k)
+				}
+			} else {
+				v := fmt.Sprint(i)
+				_, had := want[k]
+				want[k] = v
+				if l.Insert(k, v) == had {
+					t.Fatalf("p=%v: Insert(%d) disagrees with the map", p, k)
+				}
+			}
+		}
+		sorted := make([]int, 0, len(want))
+		for k := range want {
+			sorted = append(sorted, k)
+		}
+		slices.Sort(sorted)
+		if got := keys(l.All()); !slices.Equal(got, sorted) || l.Len() != len(want) {
+			t.Fatalf("p=%v: %d keys in the list, %d in the map, or out of order", p, l.Len(), len(want))
+		}
+		for k, v := range want {
+			if got, ok := l.Search(k); !ok || got != v {
+				t.Fatalf("p=%v: Search(%d) = %q, %v, want %q", p, k, got, ok, v)
+			}
+		}
+		for k := range want {
+			l.Delete(k)
+		}
+		if l.Len() != 0 || l.level != 1 {
+			t.Errorf("p=%v: emptied list has Len %d, %d levels", p, l.Len(), l.level)
+		}
+	}
+}
+
+func TestNewPanics(t *testing.T) {
+	for _, tt := range []struct {
+		maxLevel int
+		p        float64
+	}{{0, 0.5}, {4, 0}, {4, 1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("New(%d, %v) did not panic", tt.maxLevel, tt.p)
+				}
+			}()
+			New[int, int](tt.maxLevel, tt.p)
+		}()
+	}
+}
+
+// avlTree is a sorted map on an AVL tree, the baseline of the benchmarks:
+// a binary search tree kept balanced by rotations, the heights of the two
+// subtrees of any node differing by at most one.
+type avlTree struct{ root *avlNode }
+
+type avlNode struct {
+	key, value  int
+	height      int
+	left, right *avlNode
+}
+
+func height(n *avlNode) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+func (n *avlNode) fix() { n.height = 1 + max(height(n.left), height(n.right)) }
+
+func rotateRight(n *avlNode) *avlNode {
+	l := n.left
+	n.left, l.right = l.right, n
+	n.fix()
+	l.fix()
+	return l
+}
+
+func rotateLeft(n *avlNode) *avlNode {
+	r := n.right
+	n.right, r.left = r.left, n
+	n.fix()
+	r.fix()
+	return r
+}
+
+// balance restores the AVL property at n, whose subtrees are AVL trees
+// differing in height by at most two.
+func balance(n *avlNode) *avlNode {
+	n.fix()
+	switch b := height(n.left) - height(n.right); {
+	case b > 1:
+		if height(n.left.left) < height(n.left.right) {
+			n.left = rotateLeft(n.left)
+		}
+		return rotateRight(n)
+	case b < -1:
+		if height(n.right.right) < height(n.right.left) {
+			n.right = rotateRight(n.right)
+		}
+		return rotateLeft(n)
+	}
+	return n
+}
+
+func avlInsert(n *avlNode, key, value int) *avlNode {
+	switch {
+	case n == nil:
+		return &avlNode{key: key, value: value, height: 1}
+	case key < n.key:
+		n.left = avlInsert(n.left, key, value)
+	case key > n.key:
+		n.right = avlInsert(n.right, key, value)
+	default:
+		n.value = value
+		return n
+	}
+	return balance(n)
+}
+
+func (t *avlTree) Insert(key, value int) { t.root = avlInsert(t.root, key, value) }
+
+func (t *avlTree) Search(key int) (int, bool) {
+	for n := t.root; n != nil; {
+		switch {
+		case key < n.key:
+			n = n.left
+		case key > n.key:
+			n = n.right
+		default:
+			return n.value, true
+		}
+	}
+	return 0, false
+}
+
+// rangeQuery calls f for the keys in [lo, hi), in order, skipping the
+// subtrees entirely out of the range.
+func (n *avlNode) rangeQuery(lo, hi int, f func(k, v int)) {
+	if n == nil {
+		return
+	}
+	if lo < n.key {
+		n.left.rangeQuery(lo, hi, f)
+	}
+	if lo <= n.key && n.key < hi {
+		f(n.key, n.value)
+	}
+	if n.key < hi {
+		n.right.rangeQuery(lo, hi, f)
+	}
+}
+
+func TestAVLBaseline(t *testing.T) {
+	var tree avlTree
+	r := rand.New(rand.NewSource(2))
+	in := r.Perm(1000)
+	for _, k := range in {
+		tree.Insert(k, -k)
+	}
+	var got []int
+	tree.root.rangeQuery(100, 200, func(k, v int) { got = append(got, k) })
+	if len(got) != 100 || !sort.IntsAreSorted(got) || got[0] != 100 {
+		t.Errorf("rangeQuery(100, 200) = %d keys from %v", len(got), got[:1])
+	}
+	if v, ok := tree.Search(500); !ok || v != -500 {
+		t.Errorf("Search(500) = %d, %v", v, ok)
+	}
+	// balanced: at most 1.44 log2(n) levels.
+	if h := height(tree.root); h > 14 {
+		t.Errorf("height %d for 1000 keys", h)
+	}
+}
+
+var sink int
+
+// BenchmarkInsert inserts n keys in random order into a skip list, an AVL
+// tree and a map.
+func BenchmarkInsert(b *testing.B) {
+	benchtools.Sized(b, []int{1 << 10, 1 << 16}, func(b *testing.B, n int) {
+		in := rand.New(rand.NewSource(3)).Perm(n)
+		b.Run("SkipList", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l := New[int, int](DefaultMaxLevel, DefaultP)
+				for _, k := range in {
+					l.Insert(k, k)
+				}
+			}
+		})
+		b.Run("AVL", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var t avlTree
+				for _, k := range in {
+					t.Insert(k, k)
+				}
+			}
+		})
+		b.Run("map", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m := make(map[int]int)
+				for _, k := range in {
+					m[k] = k
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkSearch looks up every key once, in random order.
+func BenchmarkSearch(b *testing.B) {
+	benchtools.Sized(b, []int{1 << 10, 1 << 16}, func(b *testing.B, n int) {
+		in := rand.New(rand.NewSource(4)).Perm(n)
+		l := New[int, int](DefaultMaxLevel, DefaultP)
+		var t avlTree
+		m := make(map[int]int)
+		for _, k := range in {
+			l.Insert(k, k)
+			t.Insert(k, k)
+			m[k] = k
+		}
+		b.Run("SkipList", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, k := range in {
+					v, _ := l.Search(k)
+					sink += v
+				}
+			}
+		})
+		b.Run("AVL", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, k := range in {
+					v, _ := t.Search(k)
+					sink += v
+				}
+			}
+		})
+		b.Run("map", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, k := range in {
+					sink += m[k]
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkOrdered is the workload of a sorted map: range queries of 100
+// consecutive keys, then the whole map in key order. The map has to sort
+// its keys for both.
+func BenchmarkOrdered(b *testing.B) {
+	benchtools.Sized(b, []int{1 << 10, 1 << 16}, func(b *testing.B, n int) {
+		r := rand.New(rand.NewSource(5))
+		in := r.Perm(n)
+		l := New[int, int](DefaultMaxLevel, DefaultP)
+		var t avlTree
+		m := make(map[int]int)
+		for _, k := range in {
+			l.Insert(k, k)
+			t.Insert(k, k)
+			m[k] = k
+		}
+		los := make([]int, 64)
+		for i := range los {
+			los[i] = r.Intn(n - 100)
+		}
+		b.Run("SkipList", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, lo := range los {
+					l.RangeQuery(lo, lo+100)(func(k, v int) bool { sink += v; return true })
+				}
+				l.All()(func(k, v int) bool { sink += v; return true })
+			}
+		})
+		b.Run("AVL", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, lo := range los {
+					t.root.rangeQuery(lo, lo+100, func(k, v int) { sink += v })
+				}
+				t.root.rangeQuery(0, n, func(k, v int) { sink += v })
+			}
+		})
+		b.Run("map, sorted keys", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				keys := make([]int, 0, len(m))
+				for k := range m {
+					keys = append(keys, k)
+				}
+				slices.Sort(keys)
+				for _, lo := range los {
+					i, _ := slices.BinarySearch(keys, lo)
+					for _, k := range keys[i:min(i+100, len(keys))] {
+						sink += m[k]
+					}
+				}
+				for _, k := range keys {
+					sink += m[k]
+				}
+			}
+		})
+	})
+}
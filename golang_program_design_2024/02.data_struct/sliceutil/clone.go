@@ -0,0 +1,50 @@
+package sliceutil
+
+// Assigning a slice or a map copies the header, not the elements: the copy
+// and the original share the same memory, and a write through one shows in
+// the other. The functions below copy one level deeper.
+
+// Clone returns a copy of s with its own backing array. The elements are
+// copied by assignment: if they hold slices, maps or pointers themselves,
+// use CloneFunc.
+func Clone[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	return append(make([]T, 0, len(s)), s...)
+}
+
+// CloneFunc returns a copy of s with each element copied by clone, for
+// elements that hold references:
+//
+//	CloneFunc(matrix, Clone[int]) // [][]int, no row shared
+func CloneFunc[T any](s []T, clone func(T) T) []T {
+	return Map(s, clone)
+}
+
+// CloneMap returns a copy of m. The values are copied by assignment, like
+// the elements in Clone.
+func CloneMap[M ~map[K]V, K comparable, V any](m M) M {
+	if m == nil {
+		return nil
+	}
+	out := make(M, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// CloneMapFunc returns a copy of m with each value copied by clone:
+//
+//	CloneMapFunc(groups, Clone[string]) // map[string][]string
+func CloneMapFunc[M ~map[K]V, K comparable, V any](m M, clone func(V) V) M {
+	if m == nil {
+		return nil
+	}
+	out := make(M, len(m))
+	for k, v := range m {
+		out[k] = clone(v)
+	}
+	return out
+}
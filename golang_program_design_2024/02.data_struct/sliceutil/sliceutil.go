@@ -0,0 +1,111 @@
+// Package sliceutil has the generic slice functions the chapters keep
+// writing by hand: Map, Filter, Reduce, Contains, Unique, Chunk, Reverse and
+// GroupBy, and the copies that structCopy in 02.data_struct/struct.go asks
+// for: Clone, CloneFunc and CloneMap copy the backing array or the buckets,
+// so the copy and the original no longer share memory.
+//
+// The functions never modify their argument: each returns a new slice. A
+// nil argument gives a nil result, so a nil slice stays "no value" through
+// a copy, and encodes as JSON null like before.
+package sliceutil
+
+// Map returns f applied to each element of s.
+func Map[T, U any](s []T, f func(T) U) []U {
+	if s == nil {
+		return nil
+	}
+	out := make([]U, len(s))
+	for i, v := range s {
+		out[i] = f(v)
+	}
+	return out
+}
+
+// Filter returns the elements of s for which keep is true, in order.
+func Filter[T any](s []T, keep func(T) bool) []T {
+	var out []T
+	for _, v := range s {
+		if keep(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Reduce folds s into one value, from the left, starting from init.
+func Reduce[T, A any](s []T, init A, f func(A, T) A) A {
+	acc := init
+	for _, v := range s {
+		acc = f(acc, v)
+	}
+	return acc
+}
+
+// Contains reports whether v is in s.
+func Contains[T comparable](s []T, v T) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Unique returns s without its duplicates, each value at the place of its
+// first occurrence. Unlike slices.Compact, s does not need to be sorted.
+func Unique[T comparable](s []T) []T {
+	// not sized for len(s): with many duplicates, most of it would be
+	// wasted. s[:0:0] is nil for a nil s and empty otherwise.
+	seen := make(map[T]struct{})
+	out := s[:0:0]
+	for _, v := range s {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		out = append(out, v)
+	}
+	return out
+}
+
+// Chunk splits s into slices of size elements; the last one may be shorter.
+// The chunks share the backing array of s, but their capacity ends with
+// them: an append to a chunk reallocates instead of overwriting the next
+// one. Chunk panics if size is less than 1.
+func Chunk[T any](s []T, size int) [][]T {
+	if size < 1 {
+		panic("sliceutil: Chunk size must be at least 1")
+	}
+	if len(s) == 0 {
+		return nil
+	}
+	out := make([][]T, 0, (len(s)+size-1)/size)
+	for i := 0; i < len(s); i += size {
+		end := min(i+size, len(s))
+		out = append(out, s[i:end:end])
+	}
+	return out
+}
+
+// Reverse returns the elements of s in reverse order, in a new slice;
+// slices.Reverse reverses s in place.
+func Reverse[T any](s []T) []T {
+	if s == nil {
+		return nil
+	}
+	out := make([]T, len(s))
+	for i, v := range s {
+		out[len(s)-1-i] = v
+	}
+	return out
+}
+
+// GroupBy returns the elements of s by key, each group in the order of s.
+func GroupBy[T any, K comparable](s []T, key func(T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for _, v := range s {
+		k := key(v)
+		groups[k] = append(groups[k], v)
+	}
+	return groups
+}
@@ -0,0 +1,317 @@
+package sliceutil_test
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/sliceutil"
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+func TestMap(t *testing.T) {
+	if got := sliceutil.Map([]int{1, 2, 3}, strconv.Itoa); !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Errorf("Map = %q", got)
+	}
+	if got := sliceutil.Map(nil, strconv.Itoa); got != nil {
+		t.Errorf("Map(nil) = %#v, want nil", got)
+	}
+	if got := sliceutil.Map([]int{}, strconv.Itoa); got == nil || len(got) != 0 {
+		t.Errorf("Map(empty) = %#v, want an empty slice", got)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	even := func(n int) bool { return n%2 == 0 }
+	for _, tt := range []struct {
+		in, want []int
+	}{
+		{[]int{1, 2, 3, 4, 6}, []int{2, 4, 6}},
+		{[]int{1, 3}, nil},
+		{nil, nil},
+	} {
+		if got := sliceutil.Filter(tt.in, even); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Filter(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReduce(t *testing.T) {
+	sum := sliceutil.Reduce([]int{1, 2, 3, 4}, 0, func(acc, n int) int { return acc + n })
+	if sum != 10 {
+		t.Errorf("sum = %d", sum)
+	}
+	// from the left: ((("" + a) + b) + c)
+	joined := sliceutil.Reduce([]string{"a", "b", "c"}, ">", func(acc, s string) string { return acc + s })
+	if joined != ">abc" {
+		t.Errorf("joined = %q", joined)
+	}
+}
+
+func TestContains(t *testing.T) {
+	s := []string{"go", "rust"}
+	if !sliceutil.Contains(s, "go") || sliceutil.Contains(s, "Go") || sliceutil.Contains(nil, "go") {
+		t.Error("Contains is wrong")
+	}
+}
+
+func TestUnique(t *testing.T) {
+	for _, tt := range []struct {
+		in, want []int
+	}{
+		{[]int{3, 1, 3, 2, 1, 3}, []int{3, 1, 2}},
+		{[]int{1, 2}, []int{1, 2}},
+		{[]int{}, []int{}},
+		{nil, nil},
+	} {
+		in := sliceutil.Clone(tt.in)
+		if got := sliceutil.Unique(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Unique(%v) = %v, want %v", tt.in, got, tt.want)
+		}
+		if !reflect.DeepEqual(in, tt.in) {
+			t.Errorf("Unique modified its argument: %v", tt.in)
+		}
+	}
+}
+
+func TestChunk(t *testing.T) {
+	for _, tt := range []struct {
+		in   []int
+		size int
+		want [][]int
+	}{
+		{[]int{1, 2, 3, 4, 5}, 2, [][]int{{1, 2}, {3, 4}, {5}}},
+		{[]int{1, 2, 3, 4}, 2, [][]int{{1, 2}, {3, 4}}},
+		{[]int{1, 2}, 5, [][]int{{1, 2}}},
+		{nil, 3, nil},
+	} {
+		if got := sliceutil.Chunk(tt.in, tt.size); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Chunk(%v, %d) = %v, want %v", tt.in, tt.size, got, tt.want)
+		}
+	}
+
+	s := []int{1, 2, 3, 4}
+	chunks := sliceutil.Chunk(s, 2)
+	_ = append(chunks[0], 99)
+	if s[2] != 3 {
+		t.Errorf("an append to the first chunk overwrote the second: %v", s)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Chunk with size 0 did not panic")
+		}
+	}()
+	sliceutil.Chunk(s, 0)
+}
+
+func TestReverse(t *testing.T) {
+	s := []string{"a", "b", "c"}
+	if got := sliceutil.Reverse(s); !reflect.DeepEqual(got, []string{"c", "b", "a"}) {
+		t.Errorf("Reverse = %v", got)
+	}
+	if s[0] != "a" {
+		t.Errorf("Reverse modified its argument: %v", s)
+	}
+	if sliceutil.Reverse([]int(nil)) != nil {
+		t.Error("Reverse(nil) is not nil")
+	}
+}
+
+func TestGroupBy(t *testing.T) {
+	words := []string{"go", "rust", "c", "zig", "java", "d"}
+	got := sliceutil.GroupBy(words, func(w string) int { return len(w) })
+	want := map[int][]string{1: {"c", "d"}, 2: {"go"}, 3: {"zig"}, 4: {"rust", "java"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GroupBy = %v, want %v", got, want)
+	}
+}
+
+// TestClone is the pitfall of structCopy: after a copy by assignment, a
+// write through the copy changes the original.
+func TestClone(t *testing.T) {
+	orig := []int{1, 2, 3}
+	shallow := orig
+	deep := sliceutil.Clone(orig)
+	shallow[0], deep[1] = 100, 200
+	if orig[0] != 100 || orig[1] != 2 {
+		t.Errorf("orig = %v, want [100 2 3]", orig)
+	}
+	if sliceutil.Clone([]int(nil)) != nil {
+		t.Error("Clone(nil) is not nil")
+	}
+
+	grid := [][]int{{1, 2}, {3, 4}}
+	outer := sliceutil.Clone(grid)
+	outer[0][0] = 100 // the rows are still shared
+	if grid[0][0] != 100 {
+		t.Error("Clone copied the rows too")
+	}
+	deepGrid := sliceutil.CloneFunc(grid, sliceutil.Clone[int])
+	deepGrid[1][1] = 400
+	if grid[1][1] != 4 {
+		t.Errorf("CloneFunc shares a row: grid = %v", grid)
+	}
+}
+
+func TestCloneMap(t *testing.T) {
+	type groups map[string][]string
+	orig := groups{"a": {"apple"}, "b": {"banana"}}
+
+	shallow := sliceutil.CloneMap(orig)
+	shallow["c"] = []string{"cherry"}
+	shallow["a"][0] = "avocado" // the slices are still shared
+	if _, ok := orig["c"]; ok || orig["a"][0] != "avocado" {
+		t.Errorf("CloneMap: orig = %v", orig)
+	}
+
+	deep := sliceutil.CloneMapFunc(orig, sliceutil.Clone[string])
+	deep["b"][0] = "blueberry"
+	if orig["b"][0] != "banana" {
+		t.Errorf("CloneMapFunc shares a slice: orig = %v", orig)
+	}
+	if sliceutil.CloneMap(groups(nil)) != nil {
+		t.Error("CloneMap(nil) is not nil")
+	}
+}
+
+var sizes = []int{16, 1 << 10, 64 << 10}
+
+var (
+	intSink    []int
+	stringSink []string
+	sumSink    int
+	groupSink  map[int][]string
+)
+
+// The benchmarks compare each function with the loop it replaces. The
+// generic code costs nothing by itself; what can differ is the call to f
+// when it is not inlined, and the allocations each version makes.
+
+func BenchmarkMap(b *testing.B) {
+	double := func(n int) int { return 2 * n }
+	b.Run("sliceutil", func(b *testing.B) {
+		benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+			in := benchtools.Ints(n, 1000, 1)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				intSink = sliceutil.Map(in, double)
+			}
+		})
+	})
+	b.Run("loop", func(b *testing.B) {
+		benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+			in := benchtools.Ints(n, 1000, 1)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				out := make([]int, len(in))
+				for j, v := range in {
+					out[j] = 2 * v
+				}
+				intSink = out
+			}
+		})
+	})
+}
+
+func BenchmarkFilter(b *testing.B) {
+	b.Run("sliceutil", func(b *testing.B) {
+		benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+			in := benchtools.Ints(n, 1000, 1)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				intSink = sliceutil.Filter(in, func(v int) bool { return v%2 == 0 })
+			}
+		})
+	})
+	b.Run("loop", func(b *testing.B) {
+		benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+			in := benchtools.Ints(n, 1000, 1)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				var out []int
+				for _, v := range in {
+					if v%2 == 0 {
+						out = append(out, v)
+					}
+				}
+				intSink = out
+			}
+		})
+	})
+}
+
+func BenchmarkUnique(b *testing.B) {
+	b.Run("sliceutil", func(b *testing.B) {
+		benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+			in := benchtools.Strings(n, 2, 1) // 676 distinct values at most
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				stringSink = sliceutil.Unique(in)
+			}
+		})
+	})
+	b.Run("loop", func(b *testing.B) {
+		benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+			in := benchtools.Strings(n, 2, 1)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				seen := make(map[string]bool)
+				var out []string
+				for _, v := range in {
+					if !seen[v] {
+						seen[v] = true
+						out = append(out, v)
+					}
+				}
+				stringSink = out
+			}
+		})
+	})
+}
+
+func BenchmarkReduce(b *testing.B) {
+	b.Run("sliceutil", func(b *testing.B) {
+		benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+			in := benchtools.Ints(n, 1000, 1)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				sumSink = sliceutil.Reduce(in, 0, func(acc, v int) int { return acc + v })
+			}
+		})
+	})
+	b.Run("loop", func(b *testing.B) {
+		benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+			in := benchtools.Ints(n, 1000, 1)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				acc := 0
+				for _, v := range in {
+					acc += v
+				}
+				sumSink = acc
+			}
+		})
+	})
+}
+
+func BenchmarkGroupBy(b *testing.B) {
+	words := benchtools.Strings(1<<10, 8, 1)
+	for i := range words {
+		words[i] = words[i][:1+i%8]
+	}
+	b.Run("sliceutil", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			groupSink = sliceutil.GroupBy(words, func(w string) int { return len(w) })
+		}
+	})
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			groups := make(map[int][]string)
+			for _, w := range words {
+				groups[len(w)] = append(groups[len(w)], w)
+			}
+			groupSink = groups
+		}
+	})
+}
@@ -0,0 +1,113 @@
+// Package stack has two generic last in, first out stacks: Stack, on a
+// slice, and Linked, on a singly linked list. Both have the same methods;
+// the tests run the same cases on each.
+//
+// Stack is the one to use. Push appends to the slice, amortized O(1), and
+// Pop shortens it, with the elements next to each other in memory. Linked
+// allocates a node per Push, and is here for the comparison, and for the
+// rare stack shared between goroutines without a lock, which takes a
+// linked list (sync/atomic on the head, a Treiber stack).
+//
+// 06.generics/containers has the same Stack as a lesson on methods of
+// generic types.
+package stack
+
+// Stack is a stack on a slice. The zero value is an empty stack.
+type Stack[T any] struct {
+	items []T
+}
+
+// Len returns the number of elements.
+func (s *Stack[T]) Len() int { return len(s.items) }
+
+// Push adds v on top.
+func (s *Stack[T]) Push(v T) { s.items = append(s.items, v) }
+
+// Pop removes and returns the top, or false if the stack is empty.
+func (s *Stack[T]) Pop() (T, bool) {
+	var zero T
+	if len(s.items) == 0 {
+		return zero, false
+	}
+	v := s.items[len(s.items)-1]
+	s.items[len(s.items)-1] = zero // let the GC take what the element points to
+	s.items = s.items[:len(s.items)-1]
+	return v, true
+}
+
+// Peek returns the top without removing it, or false if the stack is
+// empty.
+func (s *Stack[T]) Peek() (T, bool) {
+	if len(s.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return s.items[len(s.items)-1], true
+}
+
+// All returns an iterator over the elements, from the top down, the order
+// Pop would return them.
+func (s *Stack[T]) All() func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for i := len(s.items) - 1; i >= 0; i-- {
+			if !yield(s.items[i]) {
+				return
+			}
+		}
+	}
+}
+
+// node is an element of a Linked stack.
+type node[T any] struct {
+	value T
+	next  *node[T]
+}
+
+// Linked is a stack on a singly linked list: the top is the head, and
+// Push and Pop change the head only. The zero value is an empty stack.
+type Linked[T any] struct {
+	top *node[T]
+	len int
+}
+
+// Len returns the number of elements.
+func (s *Linked[T]) Len() int { return s.len }
+
+// Push adds v on top.
+func (s *Linked[T]) Push(v T) {
+	s.top = &node[T]{value: v, next: s.top}
+	s.len++
+}
+
+// Pop removes and returns the top, or false if the stack is empty.
+func (s *Linked[T]) Pop() (T, bool) {
+	if s.top == nil {
+		var zero T
+		return zero, false
+	}
+	n := s.top
+	s.top, n.next = n.next, nil
+	s.len--
+	return n.value, true
+}
+
+// Peek returns the top without removing it, or false if the stack is
+// empty.
+func (s *Linked[T]) Peek() (T, bool) {
+	if s.top == nil {
+		var zero T
+		return zero, false
+	}
+	return s.top.value, true
+}
+
+// All returns an iterator over the elements, from the top down.
+func (s *Linked[T]) All() func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for n := s.top; n != nil; n = n.next {
+			if !yield(n.value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package stack
+
+import (
+	"slices"
+	"testing"
+)
+
+// stack is what Stack and Linked both are.
+type stack interface {
+	Len() int
+	Push(v int)
+	Pop() (int, bool)
+	Peek() (int, bool)
+	All() func(yield func(int) bool)
+}
+
+var impls = []struct {
+	name string
+	new  func() stack
+}{
+	{"slice", func() stack { return &Stack[int]{} }},
+	{"linked", func() stack { return &Linked[int]{} }},
+}
+
+func elements(s stack) []int {
+	var out []int
+	s.All()(func(v int) bool {
+		out = append(out, v)
+		return true
+	})
+	return out
+}
+
+func TestStack(t *testing.T) {
+	for _, impl := range impls {
+		t.Run(impl.name, func(t *testing.T) {
+			s := impl.new()
+			if _, ok := s.Pop(); ok {
+				t.Error("Pop on an empty stack")
+			}
+			if _, ok := s.Peek(); ok {
+				t.Error("Peek on an empty stack")
+			}
+
+			for i := 1; i <= 3; i++ {
+				s.Push(i)
+			}
+			if got := elements(s); !slices.Equal(got, []int{3, 2, 1}) {
+				t.Errorf("All = %v, want the top first", got)
+			}
+			var first []int
+			s.All()(func(v int) bool { first = append(first, v); return false })
+			if !slices.Equal(first, []int{3}) {
+				t.Errorf("stopped All = %v", first)
+			}
+			if v, ok := s.Peek(); !ok || v != 3 || s.Len() != 3 {
+				t.Errorf("Peek = %d, %v, Len %d", v, ok, s.Len())
+			}
+
+			var popped []int
+			for s.Len() > 0 {
+				v, _ := s.Pop()
+				popped = append(popped, v)
+			}
+			if !slices.Equal(popped, []int{3, 2, 1}) {
+				t.Errorf("popped %v, want last in first out", popped)
+			}
+
+			// interleaved, against a slice as the model
+			var model []int
+			for i := 0; i < 100; i++ {
+				if i%3 == 2 {
+					v, ok := s.Pop()
+					if ok != (len(model) > 0) || ok && v != model[len(model)-1] {
+						t.Fatalf("step %d: Pop = %d, %v; model %v", i, v, ok, model)
+					}
+					if ok {
+						model = model[:len(model)-1]
+					}
+					continue
+				}
+				s.Push(i)
+				model = append(model, i)
+			}
+			slices.Reverse(model)
+			if got := elements(s); !slices.Equal(got, model) {
+				t.Errorf("after the mix: %v, want %v", got, model)
+			}
+		})
+	}
+}
+
+// TestPopClears checks that Pop lets go of what the element points to.
+func TestPopClears(t *testing.T) {
+	var s Stack[*int]
+	x := 1
+	s.Push(&x)
+	s.Pop()
+	if s.items[:1][0] != nil {
+		t.Error("the popped pointer is still in the backing array")
+	}
+}
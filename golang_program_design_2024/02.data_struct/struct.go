@@ -1,22 +1,33 @@
-package main
+package datastruct
 
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/sliceutil"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
 )
 
-func main() {
+func init() {
+	lessons.Register("02.data_struct/struct", "structs: init, JSON tags, copies", lessons.Checked(DemoStructs))
+}
+
+// DemoStructs runs the struct demos: init, JSON, copies.
+func DemoStructs() error {
 	structInit()
-	structJson()
+	if err := structJson(); err != nil {
+		return err
+	}
 	structCopy()
+	return nil
 }
 
+// The validate tags are read by 13.reflection/validate; json ignores them.
 type Person struct {
-	Name string `json:"name"`
-	Age  int    `json:"age"`
+	Name string `json:"name" validate:"required,max=50"`
+	Age  int    `json:"age" validate:"min=0,max=150"`
 	// "omitempty" means that if a field is empty or missing, it will be omitted from the JSON.
-	Emails []string `json:"emails,omitempty"`
+	Emails []string `json:"emails,omitempty" validate:"dive,email"`
 }
 
 func structInit() {
@@ -50,7 +61,7 @@ func structInit() {
 	fmt.Println("Name:", p4.Name)
 }
 
-func structJson() {
+func structJson() error {
 	// serialization and
 	p1 := Person{
 		Name:   "John Doe",
@@ -59,14 +70,14 @@ func structJson() {
 	}
 	jsonData, err := json.Marshal(p1)
 	if err != nil {
-		log.Fatalf("JSON marshaling failed: %s", err)
+		return fmt.Errorf("JSON marshaling failed: %w", err)
 	}
 	fmt.Printf("JSON format: %s\n", jsonData)
 
 	// deserialization.
 	var p2 Person
 	if err := json.Unmarshal(jsonData, &p2); err != nil {
-		log.Fatalf("JSON unmarshaling failed: %s", err)
+		return fmt.Errorf("JSON unmarshaling failed: %w", err)
 	}
 	fmt.Printf("Recovered Struct: %#v\n", p2)
 	// multline string(use raw string literal)
@@ -78,9 +89,10 @@ func structJson() {
 	var p3 Person
 	// key:<city> will not unmarshal.
 	if err := json.Unmarshal([]byte(jsonString), &p3); err != nil {
-		log.Fatalf("JSON unmarshaling failed: %s", err)
+		return fmt.Errorf("JSON unmarshaling failed: %w", err)
 	}
 	fmt.Printf("%#v\n", p3)
+	return nil
 }
 
 type User struct {
@@ -92,6 +104,11 @@ type Data struct {
 	Numbers []int
 }
 
+// Clone returns a copy of d that shares no memory with it.
+func (d Data) Clone() Data {
+	return Data{Numbers: sliceutil.Clone(d.Numbers)}
+}
+
 func structCopy() {
 	// Struct copy by assignment.
 	// Deep copy: If a structure contains only primitive types (such as int,
@@ -127,4 +144,15 @@ func structCopy() {
 	copy(newNumbers, original.Numbers)
 	copied2 := Data{Numbers: newNumbers}
 	fmt.Printf("%+v\n", copied2)
+
+	// A Clone method does it once for the type; sliceutil.Clone copies the
+	// slice, and sliceutil.CloneFunc the slices inside a slice.
+	copied3 := original.Clone()
+	copied3.Numbers[0] = 1
+	fmt.Println("Original:", original.Numbers, "Cloned:", copied3.Numbers) // [100 2 3] [1 2 3]
+
+	grid := [][]int{{1, 2}, {3, 4}}
+	grid2 := sliceutil.CloneFunc(grid, sliceutil.Clone[int])
+	grid2[0][0] = 100
+	fmt.Println("Grid:", grid, "Cloned:", grid2) // [[1 2] [3 4]] [[100 2] [3 4]]
 }
@@ -0,0 +1,17 @@
+package main
+
+import (
+	interfaces "github.com/YongSangUn/learn-golang/golang_program_design_2024/03.interface"
+)
+
+/*
+interfaces runs the lesson of chapter 03.interface.
+
+Usage:
+
+	go run ./golang_program_design_2024/03.interface/cmd/interfaces
+*/
+
+func main() {
+	interfaces.DemoInterfaces()
+}
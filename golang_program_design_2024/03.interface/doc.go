@@ -0,0 +1,3 @@
+// Package interfaces is chapter 03.interface. It cannot be called interface,
+// a keyword.
+package interfaces
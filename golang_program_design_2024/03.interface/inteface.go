@@ -1,8 +1,10 @@
-package main
+package interfaces
 
 import (
 	"fmt"
 	"math"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
 )
 
 /*
@@ -19,7 +21,12 @@ Detailed Explanation:
 	https://draveness.me/golang/docs/part2-foundation/ch04-basic/golang-interface/
 */
 
-func main() {
+func init() {
+	lessons.Register("03.interface/inteface", "interfaces, polymorphism, type assertions", DemoInterfaces)
+}
+
+// DemoInterfaces runs the interface demos.
+func DemoInterfaces() {
 	interface_test()
 	intefaceAdvanced()
 }
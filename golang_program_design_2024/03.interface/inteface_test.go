@@ -0,0 +1,47 @@
+package interfaces
+
+import (
+	"math"
+	"testing"
+)
+
+// An internal test: package interfaces, not interfaces_test, so it sees
+// shape, rect and circle, unexported. See 07.testing for the chapter.
+
+// approxEqual is a test helper comparing floats, which rarely come out
+// exactly equal: within a millionth of want.
+func approxEqual(t *testing.T, what string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-6*math.Max(1, math.Abs(want)) {
+		t.Errorf("%s = %v, want %v", what, got, want)
+	}
+}
+
+func TestShapes(t *testing.T) {
+	tests := []struct {
+		name        string
+		s           shape
+		area, perim float64
+	}{
+		{"rect 2x3", &rect{width: 2, height: 3}, 6, 10},
+		{"square 1x1", &rect{width: 1, height: 1}, 1, 4},
+		{"flat rect", &rect{width: 5}, 0, 10},
+		{"unit circle", &circle{radius: 1}, math.Pi, 2 * math.Pi},
+		{"circle r=4.3", &circle{radius: 4.3}, 58.088048164875275, 27.017696820872222},
+		{"point", &circle{}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			approxEqual(t, "area", tt.s.area(), tt.area)
+			approxEqual(t, "perimeter", tt.s.perimeter(), tt.perim)
+		})
+	}
+}
+
+// TestRectIsShape fails to compile, not to run, if *rect stops satisfying
+// shape: a test can check the types, too.
+func TestRectIsShape(t *testing.T) {
+	var _ shape = (*rect)(nil)
+	var _ shape = (*circle)(nil)
+}
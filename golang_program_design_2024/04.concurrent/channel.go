@@ -1,13 +1,20 @@
-package main
+package concurrent
 
 import (
 	"context"
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
 )
 
-func main() {
+func init() {
+	lessons.Register("04.concurrent/channel", "unbuffered and buffered channels, select, range, errors", DemoChannels)
+}
+
+// DemoChannels runs the channel demos, one after the other.
+func DemoChannels() {
 	initChannel()
 	bufferedChannel()
 	channelBufferedAndCapacity()
@@ -57,6 +64,7 @@ func initChannel() {
 
 func bufferedChannel() {
 	// Create a buffered channel with capacity 2
+	// (how the capacity affects throughput is measured in 12.performance/chanbuf)
 	ch := make(chan int, 2)
 
 	// Send two values to the channel
@@ -194,6 +202,8 @@ func performTask(id int, errCh chan<- error) {
 	}
 }
 
+// handleChannelError prints the errors as they come; 01.basics/errors.go
+// collects the same kind of task errors into one with errors.Join.
 func handleChannelError() {
 	tasks := 5
 	// Create a buffered channel to hold errors from all tasks
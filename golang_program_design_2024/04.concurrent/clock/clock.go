@@ -0,0 +1,181 @@
+// Package clock puts time behind an interface, so that code waiting for
+// timeouts can be tested without waiting: the real clock in production, a
+// fake one, moved by hand, in tests.
+//
+//	clk := clock.NewFake(time.Now())
+//	ctx, cancel := clock.WithTimeout(ctx, clk, time.Hour)
+//	defer cancel()
+//	go work(ctx)
+//	clk.Advance(time.Hour) // ctx is done now, an hour early
+//
+// context.WithTimeout reads the real clock; WithTimeout and WithDeadline
+// here read clk, and give the same errors: ctx.Err() is
+// context.DeadlineExceeded when the deadline passes. Their children made
+// with context.WithCancel report Canceled instead; context.Cause of them is
+// still DeadlineExceeded.
+package clock
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the time of the code that takes one.
+type Clock interface {
+	Now() time.Time
+	// After sends the time on the channel once d has passed.
+	After(d time.Duration) <-chan time.Time
+	// AfterFunc calls f in its own goroutine once d has passed. stop
+	// prevents the call, and reports whether it did.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+// Real returns the clock of package time.
+func Real() Clock { return realClock{} }
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// Fake is a clock that moves only when Advance is called. Timers due by
+// then fire during Advance, in order of their time.
+type Fake struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	f  func() // called by Advance, without the lock
+}
+
+// NewFake returns a fake clock showing now.
+func NewFake(now time.Time) *Fake { return &Fake{now: now} }
+
+// Now returns the time of the clock.
+func (c *Fake) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the time once the clock passes now+d.
+func (c *Fake) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1) // buffered: Advance never blocks on it
+	c.add(d, func() { ch <- c.Now() })
+	return ch
+}
+
+// AfterFunc calls f in its own goroutine once the clock passes now+d.
+func (c *Fake) AfterFunc(d time.Duration, f func()) func() bool {
+	t := c.add(d, func() { go f() })
+	return func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, pending := range c.timers {
+			if pending == t {
+				c.timers = append(c.timers[:i], c.timers[i+1:]...)
+				return true
+			}
+		}
+		return false // fired already, or stopped
+	}
+}
+
+func (c *Fake) add(d time.Duration, f func()) *fakeTimer {
+	c.mu.Lock()
+	t := &fakeTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+	c.mu.Unlock()
+	if d <= 0 {
+		c.Advance(0) // due now, like a real timer of 0
+	}
+	return t
+}
+
+// Advance moves the clock d forward, firing the timers due.
+func (c *Fake) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	var due []*fakeTimer
+	kept := c.timers[:0]
+	for _, t := range c.timers {
+		if t.at.After(c.now) {
+			kept = append(kept, t)
+		} else {
+			due = append(due, t)
+		}
+	}
+	c.timers = kept
+	c.mu.Unlock()
+	sort.SliceStable(due, func(i, j int) bool { return due[i].at.Before(due[j].at) })
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// Timers returns the number of timers waiting: a test can wait for the
+// code under test to be blocked on the clock before advancing it.
+func (c *Fake) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// WaitTimers waits, in real time, up to d for n timers to be waiting on c,
+// and reports whether they were.
+func (c *Fake) WaitTimers(n int, d time.Duration) bool {
+	deadline := time.Now().Add(d)
+	for c.Timers() < n {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return true
+}
+
+// WithDeadline is context.WithDeadline on clk: the context is done when clk
+// passes deadline, or with parent, or on cancel.
+func WithDeadline(parent context.Context, clk Clock, deadline time.Time) (context.Context, context.CancelFunc) {
+	if d, ok := parent.Deadline(); ok && d.Before(deadline) {
+		// the parent ends first: its deadline is the one, as with context.
+		deadline = d
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	c := &deadlineCtx{Context: ctx, deadline: deadline}
+	stop := clk.AfterFunc(deadline.Sub(clk.Now()), func() { cancel(context.DeadlineExceeded) })
+	return c, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// WithTimeout is WithDeadline(parent, clk, clk.Now().Add(d)).
+func WithTimeout(parent context.Context, clk Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	return WithDeadline(parent, clk, clk.Now().Add(d))
+}
+
+// deadlineCtx is a cancelable context showing its deadline, and
+// DeadlineExceeded as its error when that is why it ended.
+type deadlineCtx struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c *deadlineCtx) Deadline() (time.Time, bool) { return c.deadline, true }
+
+func (c *deadlineCtx) Err() error {
+	err := c.Context.Err()
+	if err != nil && context.Cause(c.Context) == context.DeadlineExceeded {
+		return context.DeadlineExceeded
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package clock
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+var t0 = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// TestFake fires the timers due, in the order of their time, and only them.
+func TestFake(t *testing.T) {
+	c := NewFake(t0)
+	var fired []int
+	for i, d := range []time.Duration{3 * time.Second, time.Second, 2 * time.Second} {
+		c.add(d, func() { fired = append(fired, i) })
+	}
+	late := c.After(time.Hour)
+	c.Advance(2 * time.Second)
+	if len(fired) != 2 || fired[0] != 1 || fired[1] != 2 || c.Timers() != 2 {
+		t.Errorf("after 2s: fired %v, %d timers left", fired, c.Timers())
+	}
+	c.Advance(time.Second)
+	if len(fired) != 3 || fired[2] != 0 {
+		t.Errorf("after 3s: fired %v", fired)
+	}
+	testutil.RequireNoRecv(t, late, 0)
+	c.Advance(time.Hour)
+	if at := testutil.RequireRecv(t, late, time.Second); !at.Equal(t0.Add(time.Hour + 3*time.Second)) {
+		t.Errorf("After sent %v", at)
+	}
+	if !c.Now().Equal(t0.Add(time.Hour + 3*time.Second)) {
+		t.Errorf("Now = %v", c.Now())
+	}
+}
+
+func TestFakeAfterFunc(t *testing.T) {
+	c := NewFake(t0)
+	called := make(chan struct{}, 2)
+	stop := c.AfterFunc(time.Second, func() { called <- struct{}{} })
+	if !stop() || stop() {
+		t.Error("stop of a pending timer: want true, then false")
+	}
+	c.Advance(time.Second)
+	testutil.RequireNoRecv(t, called, 10*time.Millisecond)
+
+	stop = c.AfterFunc(time.Second, func() { called <- struct{}{} })
+	c.Advance(time.Second)
+	testutil.RequireRecv(t, called, time.Second)
+	if stop() {
+		t.Error("stop of a fired timer reported true")
+	}
+
+	c.AfterFunc(0, func() { called <- struct{}{} }) // due now
+	testutil.RequireRecv(t, called, time.Second)
+}
+
+func TestWithTimeout(t *testing.T) {
+	c := NewFake(t0)
+	ctx, cancel := WithTimeout(context.Background(), c, time.Hour)
+	defer cancel()
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+	if d, ok := ctx.Deadline(); !ok || !d.Equal(t0.Add(time.Hour)) {
+		t.Errorf("Deadline = %v, %v", d, ok)
+	}
+	c.Advance(time.Hour - time.Nanosecond)
+	if ctx.Err() != nil {
+		t.Fatalf("done before the deadline: %v", ctx.Err())
+	}
+	c.Advance(time.Nanosecond)
+	testutil.RequireClosedWithin(t, child.Done(), time.Second)
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("Err = %v, want DeadlineExceeded", ctx.Err())
+	}
+	if !errors.Is(child.Err(), context.Canceled) || !errors.Is(context.Cause(child), context.DeadlineExceeded) {
+		t.Errorf("child: Err %v, Cause %v", child.Err(), context.Cause(child))
+	}
+}
+
+func TestWithDeadline(t *testing.T) {
+	c := NewFake(t0)
+	// cancelled before the deadline: Canceled, and the timer is gone.
+	ctx, cancel := WithDeadline(context.Background(), c, t0.Add(time.Minute))
+	cancel()
+	if !errors.Is(ctx.Err(), context.Canceled) || c.Timers() != 0 {
+		t.Errorf("cancelled: %v, %d timers", ctx.Err(), c.Timers())
+	}
+
+	// a parent ending first keeps its deadline.
+	parent, cancelParent := WithDeadline(context.Background(), c, t0.Add(time.Second))
+	defer cancelParent()
+	ctx, cancel = WithDeadline(parent, c, t0.Add(time.Hour))
+	defer cancel()
+	if d, _ := ctx.Deadline(); !d.Equal(t0.Add(time.Second)) {
+		t.Errorf("Deadline = %v, want the parent's", d)
+	}
+	c.Advance(time.Second)
+	testutil.RequireClosedWithin(t, ctx.Done(), time.Second)
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		t.Errorf("Err = %v, want DeadlineExceeded", ctx.Err())
+	}
+
+	// a deadline in the past is done at once.
+	ctx, cancel = WithDeadline(context.Background(), c, t0)
+	defer cancel()
+	testutil.RequireClosedWithin(t, ctx.Done(), time.Second)
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	concurrent "github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent"
+	syncdemo "github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/sync"
+)
+
+/*
+concurrent runs the lessons of chapter 04.concurrent, in order: goroutines,
+channels, the sync package, then the worker pool, the pipelines, the rate
+limiters, contexts, the concurrent maps, the ring buffers and consistent
+hashing.
+
+Usage:
+
+	go run ./golang_program_design_2024/04.concurrent/cmd/concurrent
+
+One lesson alone: go run ./cmd/learn run 04.concurrent/channel
+*/
+
+func main() {
+	concurrent.DemoGoroutines()
+	concurrent.DemoChannels()
+	syncdemo.DemoSync()
+	concurrent.DemoWorkerPool()
+	concurrent.DemoPipeline()
+	concurrent.DemoRateLimit()
+	concurrent.DemoContext()
+	concurrent.DemoConcurrentMap()
+	concurrent.DemoRingBuffer()
+	concurrent.DemoConsistentHash()
+}
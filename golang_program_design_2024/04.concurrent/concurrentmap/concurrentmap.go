@@ -0,0 +1,138 @@
+// Package concurrentmap has ShardedMap, a map safe for concurrent use that
+// splits its keys over several maps, each with its own lock: goroutines
+// working on keys of different shards do not wait for each other.
+//
+//	m := concurrentmap.New[string, int](32, concurrentmap.StringHash)
+//	m.Set("alice", 23)
+//	age, ok := m.Get("alice")
+//
+// A single map behind a sync.RWMutex serializes every write, and its readers
+// all update the same lock word; sync.Map is fast for keys written once and
+// read many times, slow for keys rewritten often. Sharding divides the
+// contention by the number of shards, whatever the workload.
+package concurrentmap
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// cacheLine is the usual size of a CPU cache line.
+const cacheLine = 64
+
+type shard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+	// two shards in one cache line would make their locks contend anyway:
+	// each write to one invalidates the line in the caches of the others.
+	_ [cacheLine]byte
+}
+
+// ShardedMap is a map split over a power of two of shards.
+type ShardedMap[K comparable, V any] struct {
+	shards []shard[K, V]
+	mask   uint64
+	hash   func(K) uint64
+}
+
+// New returns a map with shards shards, rounded up to a power of two, at
+// least 1. hash spreads the keys over them: StringHash, IntHash, or any
+// function giving the same value for equal keys.
+func New[K comparable, V any](shards int, hash func(K) uint64) *ShardedMap[K, V] {
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+	m := &ShardedMap[K, V]{shards: make([]shard[K, V], n), mask: uint64(n - 1), hash: hash}
+	for i := range m.shards {
+		m.shards[i].m = make(map[K]V)
+	}
+	return m
+}
+
+// Shards returns the number of shards.
+func (m *ShardedMap[K, V]) Shards() int { return len(m.shards) }
+
+func (m *ShardedMap[K, V]) shard(key K) *shard[K, V] {
+	return &m.shards[m.hash(key)&m.mask]
+}
+
+// Get returns the value of key, and whether it was there.
+func (m *ShardedMap[K, V]) Get(key K) (V, bool) {
+	s := m.shard(key)
+	s.mu.RLock()
+	v, ok := s.m[key]
+	s.mu.RUnlock()
+	return v, ok
+}
+
+// Set sets the value of key.
+func (m *ShardedMap[K, V]) Set(key K, v V) {
+	s := m.shard(key)
+	s.mu.Lock()
+	s.m[key] = v
+	s.mu.Unlock()
+}
+
+// Delete removes key, if it is there.
+func (m *ShardedMap[K, V]) Delete(key K) {
+	s := m.shard(key)
+	s.mu.Lock()
+	delete(s.m, key)
+	s.mu.Unlock()
+}
+
+// Len returns the number of keys. The shards are counted one after the
+// other: with concurrent writes, the total may never have been true at once.
+func (m *ShardedMap[K, V]) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Range calls f for each key and value until f returns false. Each shard is
+// copied under its lock, then f runs without it: f may call the methods of
+// m. Like Len, it is no snapshot of the whole map, only of each shard.
+func (m *ShardedMap[K, V]) Range(f func(key K, v V) bool) {
+	type entry struct {
+		k K
+		v V
+	}
+	var entries []entry
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		entries = entries[:0]
+		for k, v := range s.m {
+			entries = append(entries, entry{k, v})
+		}
+		s.mu.RUnlock()
+		for _, e := range entries {
+			if !f(e.k, e.v) {
+				return
+			}
+		}
+	}
+}
+
+var seed = maphash.MakeSeed()
+
+// StringHash hashes a string key with hash/maphash, the hash of the Go maps.
+func StringHash(s string) uint64 { return maphash.String(seed, s) }
+
+// IntHash hashes an integer key. The bits are mixed (the finalizer of
+// splitmix64): keys 0, 32, 64... would all land in shard 0 of 32 otherwise.
+func IntHash[I ~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr](i I) uint64 {
+	x := uint64(i)
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
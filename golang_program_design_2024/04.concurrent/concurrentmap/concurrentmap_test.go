@@ -0,0 +1,92 @@
+package concurrentmap
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+func TestShards(t *testing.T) {
+	for _, tt := range []struct{ shards, want int }{{5, 8}, {0, 1}, {1, 1}, {32, 32}} {
+		if got := New[int, int](tt.shards, IntHash[int]).Shards(); got != tt.want {
+			t.Errorf("New(%d).Shards() = %d, want %d", tt.shards, got, tt.want)
+		}
+	}
+}
+
+// fill has 8 goroutines set 1000 keys each, "g-i" to g*1000+i.
+func fill(t *testing.T, m *ShardedMap[string, int]) {
+	t.Helper()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				m.Set(fmt.Sprintf("%d-%d", g, i), g*1000+i)
+			}
+		}()
+	}
+	testutil.RequireDone(t, 5*time.Second, wg.Wait)
+}
+
+func TestConcurrentSetGet(t *testing.T) {
+	m := New[string, int](16, StringHash)
+	fill(t, m)
+	if m.Len() != 8000 {
+		t.Errorf("Len = %d, want 8000", m.Len())
+	}
+	for g := 0; g < 8; g++ {
+		for i := 0; i < 1000; i++ {
+			if v, ok := m.Get(fmt.Sprintf("%d-%d", g, i)); !ok || v != g*1000+i {
+				t.Fatalf("Get(%d-%d) = %d, %v", g, i, v, ok)
+			}
+		}
+	}
+
+	for i := 0; i < 1000; i++ {
+		m.Delete(fmt.Sprintf("0-%d", i))
+	}
+	if _, ok := m.Get("0-1"); ok || m.Len() != 7000 {
+		t.Errorf("after Delete: Get(0-1) found %v, Len %d", ok, m.Len())
+	}
+}
+
+func TestRange(t *testing.T) {
+	m := New[string, int](16, StringHash)
+	fill(t, m)
+
+	seen := 0
+	m.Range(func(string, int) bool { seen++; return true })
+	if seen != 8000 {
+		t.Errorf("Range visited %d keys, want 8000", seen)
+	}
+	stopped := 0
+	m.Range(func(string, int) bool { stopped++; return stopped < 10 })
+	if stopped != 10 {
+		t.Errorf("Range went on for %d keys after returning false at 10", stopped)
+	}
+
+	// f runs without the shard's lock: it may write to the map.
+	testutil.RequireDone(t, time.Second, func() {
+		m.Range(func(k string, v int) bool { m.Set(k, v+1); return true })
+	})
+	if v, _ := m.Get("1-0"); v != 1001 {
+		t.Errorf("1-0 = %d after Set inside Range, want 1001", v)
+	}
+}
+
+// TestIntHashSpreads uses keys 0, 32, 64...: without mixing the bits, all
+// of them would be in shard 0 of 32.
+func TestIntHashSpreads(t *testing.T) {
+	used := map[uint64]bool{}
+	for i := 0; i < 1000; i++ {
+		used[IntHash(i*32)&31] = true
+	}
+	if len(used) != 32 {
+		t.Errorf("%d of 32 shards used", len(used))
+	}
+}
@@ -0,0 +1,113 @@
+package concurrent
+
+import (
+	"fmt"
+	"hash/fnv"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/consistenthash"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+The sharded map spreads keys over its shards with hash & mask, the shards
+fixed. Spread them over servers instead, caches say, and the number
+changes: one is added for the load, one crashes. With hash(key) % n, going
+from 4 servers to 5 moves a key unless hash % 4 == hash % 5: 4 keys in 5
+change server, and a cache in front of a database starts nearly empty.
+
+A consistent hash ring moves only what must move. Servers and keys are
+hashed to points of a circle; a key belongs to the first server after it.
+A fifth server takes the keys of the arcs just before its points, 1/5 of
+them, all from the others; a server leaving hands its keys to the next ones
+and nobody else's move. Memcached clients (ketama), Cassandra and DynamoDB
+place their data this way.
+
+The demo measures it: 100,000 keys, what moves when a node joins and
+leaves, against % n; how even the load is with 1 and with 200 virtual
+nodes per server; and GetN, the servers holding the replicas of a key.
+The ring has its tests:
+
+	go test ./golang_program_design_2024/04.concurrent/consistenthash
+*/
+
+func init() {
+	lessons.Register("04.concurrent/consistenthash", "a consistent hash ring: keys moved when nodes join and leave, virtual nodes, replicas", DemoConsistentHash)
+}
+
+// DemoConsistentHash measures the keys a ring moves as nodes join and
+// leave, against hash % n, and how even the load of its nodes is.
+func DemoConsistentHash() {
+	r := consistenthash.New(200, nil)
+	r.Add("cache-a", "cache-b", "cache-c", "cache-d")
+	four := owners(r.Get)
+	r.Add("cache-e")
+	five := owners(r.Get)
+	share, only := moved(four, five, "cache-e")
+	fmt.Printf("4 -> 5 nodes: %4.1f%% of the keys moved, all to cache-e: %v (ideal 20%%)\n", share, only)
+
+	r.Remove("cache-b")
+	share, only = moved(five, owners(r.Get), "cache-b")
+	fmt.Printf("5 -> 4 nodes: %4.1f%% of the keys moved, all from cache-b: %v (ideal 20%%)\n", share, only)
+
+	// the same join with hash % n.
+	mod := func(n int) func(string) string {
+		return func(key string) string {
+			h := fnv.New64a()
+			h.Write([]byte(key))
+			return fmt.Sprint("cache-", h.Sum64()%uint64(n))
+		}
+	}
+	share, _ = moved(owners(mod(4)), owners(mod(5)), "cache-4")
+	fmt.Printf("4 -> 5 nodes with hash %% n: %4.1f%% of the keys moved\n", share)
+
+	// virtual nodes: how far from 1/n the load of each node is.
+	for _, replicas := range []int{1, 200} {
+		vr := consistenthash.New(replicas, nil)
+		vr.Add("cache-a", "cache-b", "cache-c", "cache-d", "cache-e")
+		lo, hi := loads(owners(vr.Get), 5)
+		fmt.Printf("%3d points per node: %4.1f%% to %4.1f%% of the keys per node (ideal 20%%)\n", replicas, lo, hi)
+	}
+
+	fmt.Printf("GetN(user:42, 3) = %v, Get = %s\n", r.GetN("user:42", 3), r.Get("user:42"))
+}
+
+const ringKeys = 100000
+
+// owners returns the node of each of the ringKeys keys.
+func owners(get func(key string) string) []string {
+	out := make([]string, ringKeys)
+	for i := range out {
+		out[i] = get(fmt.Sprint("key-", i))
+	}
+	return out
+}
+
+// moved returns the percentage of keys whose node differs in before and
+// after, and whether each of them moved to, or from, node only.
+func moved(before, after []string, node string) (float64, bool) {
+	n, onlyNode := 0, true
+	for i := range before {
+		if before[i] != after[i] {
+			n++
+			onlyNode = onlyNode && (before[i] == node || after[i] == node)
+		}
+	}
+	return 100 * float64(n) / float64(len(before)), onlyNode
+}
+
+// loads returns the smallest and largest percentage of the keys on a node.
+func loads(owners []string, nodes int) (lo, hi float64) {
+	count := make(map[string]int)
+	for _, o := range owners {
+		count[o]++
+	}
+	lo, hi = 100, 0
+	for _, c := range count {
+		share := 100 * float64(c) / float64(len(owners))
+		lo, hi = min(lo, share), max(hi, share)
+	}
+	if len(count) < nodes {
+		lo = 0 // a node without a key
+	}
+	return lo, hi
+}
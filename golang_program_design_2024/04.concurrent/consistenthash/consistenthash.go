@@ -0,0 +1,182 @@
+// Package consistenthash has Ring, a consistent hash ring: it maps keys to
+// nodes, caches or database shards, so that a node joining or leaving moves
+// only the keys it takes or had, about 1/n of them, where hash(key) % n
+// moves nearly all of them when n changes.
+//
+//	r := consistenthash.New(100, nil)
+//	r.Add("cache-a", "cache-b", "cache-c")
+//	node := r.Get("user:42")          // the node of the key
+//	replicas := r.GetN("user:42", 2) // and the next one, for a copy
+//
+// Each node is hashed to many points of a circle of 2^64 positions, its
+// virtual nodes; a key belongs to the first point at or after its own
+// hash, going round. With one point per node the arcs between them are
+// uneven, and so are the loads; a hundred or more per node even them out.
+//
+// concurrentmap.ShardedMap picks a shard with hash & mask: right for shards
+// that never change in number. The ring is for nodes that come and go.
+package consistenthash
+
+import (
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// Hash hashes a key, or the name of a virtual node, to a point of the ring.
+// Every process sharing a ring must use the same one: hash/maphash, seeded
+// at random per process, would give each its own ring.
+type Hash func(data []byte) uint64
+
+// Ring is a consistent hash ring, safe for concurrent use: Get and GetN
+// share a sync.RWMutex, Add and Remove take it alone.
+type Ring struct {
+	hash     Hash
+	replicas int
+
+	mu     sync.RWMutex
+	points []uint64            // the virtual nodes, sorted
+	owners map[uint64][]string // the nodes on each point, sorted: the first owns it
+	nodes  map[string]bool
+}
+
+// New returns an empty ring placing each node at replicas points, at least
+// 1. A nil hash is FNV-1a with the bits mixed by the finalizer of
+// murmur3: FNV alone leaves "node#1" and "node#2" close on the ring.
+func New(replicas int, hash Hash) *Ring {
+	if hash == nil {
+		hash = fnvMix
+	}
+	return &Ring{
+		hash:     hash,
+		replicas: max(replicas, 1),
+		owners:   make(map[uint64][]string),
+		nodes:    make(map[string]bool),
+	}
+}
+
+func fnvMix(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// point returns the position of the i-th virtual node of node.
+func (r *Ring) point(node string, i int) uint64 {
+	return r.hash([]byte(node + "#" + strconv.Itoa(i)))
+}
+
+// Add adds nodes to the ring; a node already there is left as it is.
+func (r *Ring) Add(nodes ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, node := range nodes {
+		if r.nodes[node] {
+			continue
+		}
+		r.nodes[node] = true
+		for i := 0; i < r.replicas; i++ {
+			p := r.point(node, i)
+			on, taken := r.owners[p]
+			if slices.Contains(on, node) {
+				continue // two of its own virtual nodes on one point
+			}
+			if !taken {
+				r.points = append(r.points, p)
+			}
+			// two nodes on one point, 1 in 2^64 per pair with the default
+			// hash, more with a poor one: the smallest name owns it,
+			// whatever the order of the Adds, on every process alike.
+			// The others keep their claim, for when it leaves.
+			on = append(on, node)
+			slices.Sort(on)
+			r.owners[p] = on
+		}
+	}
+	slices.Sort(r.points)
+}
+
+// Remove removes node from the ring, and reports whether it was there.
+func (r *Ring) Remove(node string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.nodes[node] {
+		return false
+	}
+	delete(r.nodes, node)
+	r.points = slices.DeleteFunc(r.points, func(p uint64) bool {
+		on := r.owners[p]
+		i := slices.Index(on, node)
+		if i < 0 {
+			return false
+		}
+		if on = slices.Delete(on, i, i+1); len(on) > 0 {
+			r.owners[p] = on // a node sharing the point takes it over
+			return false
+		}
+		delete(r.owners, p)
+		return true
+	})
+	return true
+}
+
+// Nodes returns the nodes of the ring, sorted.
+func (r *Ring) Nodes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	nodes := make([]string, 0, len(r.nodes))
+	for n := range r.nodes {
+		nodes = append(nodes, n)
+	}
+	slices.Sort(nodes)
+	return nodes
+}
+
+// Get returns the node of key, or "" if the ring is empty.
+func (r *Ring) Get(key string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.points) == 0 {
+		return ""
+	}
+	return r.owners[r.points[r.search(key)]][0]
+}
+
+// GetN returns the n distinct nodes of key, for n copies of it: its node,
+// then the next different ones going round the ring. Fewer if the ring has
+// fewer than n nodes owning a point: a node whose points are all owned by
+// others, with a hash colliding often, is never one of them.
+func (r *Ring) GetN(key string, n int) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n = min(n, len(r.nodes))
+	if n <= 0 || len(r.points) == 0 {
+		return nil
+	}
+	out := make([]string, 0, n)
+	// once round the ring at most: n counts the nodes, not the owners.
+	for i, start := 0, r.search(key); i < len(r.points) && len(out) < n; i++ {
+		node := r.owners[r.points[(start+i)%len(r.points)]][0]
+		if !slices.Contains(out, node) {
+			out = append(out, node)
+		}
+	}
+	return out
+}
+
+// search returns the index of the first point at or after the hash of key,
+// 0 past the last one: the circle closes.
+func (r *Ring) search(key string) int {
+	i, _ := slices.BinarySearch(r.points, r.hash([]byte(key)))
+	if i == len(r.points) {
+		i = 0
+	}
+	return i
+}
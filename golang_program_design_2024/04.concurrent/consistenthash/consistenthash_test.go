@@ -0,0 +1,181 @@
+package consistenthash
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+const keys = 100000
+
+// owners returns the node of each of the keys.
+func owners(r *Ring) []string {
+	out := make([]string, keys)
+	for i := range out {
+		out[i] = r.Get(fmt.Sprint("key-", i))
+	}
+	return out
+}
+
+// moved returns the share of keys whose node differs in before and after,
+// and whether each of them moved to, or from, node only.
+func moved(before, after []string, node string) (float64, bool) {
+	n, onlyNode := 0, true
+	for i := range before {
+		if before[i] != after[i] {
+			n++
+			onlyNode = onlyNode && (before[i] == node || after[i] == node)
+		}
+	}
+	return float64(n) / float64(len(before)), onlyNode
+}
+
+func TestEmpty(t *testing.T) {
+	r := New(200, nil)
+	if got := r.Get("key"); got != "" {
+		t.Errorf("Get of an empty ring = %q", got)
+	}
+	if got := r.GetN("key", 2); got != nil {
+		t.Errorf("GetN of an empty ring = %q", got)
+	}
+	r.Add("a")
+	if !r.Remove("a") || r.Remove("a") || r.Get("key") != "" {
+		t.Error("the ring is not empty again after Remove")
+	}
+}
+
+// TestJoinLeave adds a fifth node, then removes another: about 1/5 of the
+// keys move each time, all to the new node, then all from the one gone.
+func TestJoinLeave(t *testing.T) {
+	r := New(200, nil)
+	r.Add("cache-a", "cache-b", "cache-c", "cache-d")
+	four := owners(r)
+	r.Add("cache-e")
+	five := owners(r)
+	if share, only := moved(four, five, "cache-e"); share < 0.15 || share > 0.25 || !only {
+		t.Errorf("4 -> 5 nodes: %.1f%% moved, all to cache-e: %v; want about 20%%", 100*share, only)
+	}
+	r.Remove("cache-b")
+	if share, only := moved(five, owners(r), "cache-b"); share < 0.15 || share > 0.25 || !only {
+		t.Errorf("5 -> 4 nodes: %.1f%% moved, all from cache-b: %v; want about 20%%", 100*share, only)
+	}
+	if got := r.Nodes(); !slices.Equal(got, []string{"cache-a", "cache-c", "cache-d", "cache-e"}) {
+		t.Errorf("Nodes = %q", got)
+	}
+}
+
+// TestLoad checks that virtual nodes even out the load: with 200 points
+// per node, no node has 1.5 times the keys of another.
+func TestLoad(t *testing.T) {
+	r := New(200, nil)
+	r.Add("cache-a", "cache-b", "cache-c", "cache-d", "cache-e")
+	count := make(map[string]int)
+	for _, o := range owners(r) {
+		count[o]++
+	}
+	lo, hi := keys, 0
+	for _, c := range count {
+		lo, hi = min(lo, c), max(hi, c)
+	}
+	if len(count) != 5 || float64(hi)/float64(lo) > 1.5 {
+		t.Errorf("%d nodes with keys, %d to %d keys per node", len(count), lo, hi)
+	}
+}
+
+func TestGetN(t *testing.T) {
+	r := New(200, nil)
+	r.Add("cache-a", "cache-b", "cache-c", "cache-d")
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprint("user:", i)
+		got := r.GetN(key, 3)
+		if len(got) != 3 || got[0] != r.Get(key) || got[0] == got[1] || got[0] == got[2] || got[1] == got[2] {
+			t.Fatalf("GetN(%s, 3) = %q, want 3 distinct nodes, Get first", key, got)
+		}
+	}
+	if got := r.GetN("user:42", 10); len(got) != 4 {
+		t.Errorf("GetN beyond the nodes = %q, want the 4 of them", got)
+	}
+	if got := r.GetN("user:42", 0); got != nil {
+		t.Errorf("GetN(0) = %q", got)
+	}
+}
+
+// collide is the default hash but for the first virtual nodes of a and b,
+// on one point, and "key", just before it.
+func collide(data []byte) uint64 {
+	switch string(data) {
+	case "a#0", "b#0":
+		return 1 << 63
+	case "key":
+		return 1<<63 - 1
+	}
+	return fnvMix(data)
+}
+
+// TestCollision checks that a point two nodes share goes to the same one
+// whatever the order of the Adds, and to the other when it leaves.
+func TestCollision(t *testing.T) {
+	for _, order := range [][]string{{"a", "b"}, {"b", "a"}} {
+		r := New(3, collide)
+		r.Add(order...)
+		if got := r.Get("key"); got != "a" {
+			t.Errorf("added %q: the shared point is %q's, want a's", order, got)
+		}
+		r.Remove("a")
+		if got := r.Get("key"); got != "b" {
+			t.Errorf("added %q, removed a: the shared point is %q's, want b's", order, got)
+		}
+		r.Add("a")
+		r.Remove("b")
+		if got := r.Get("key"); got != "a" {
+			t.Errorf("added %q, removed b: the shared point is %q's, want a's", order, got)
+		}
+		r.Remove("a")
+		if got := r.Get("key"); got != "" {
+			t.Errorf("added %q, removed both: Get = %q", order, got)
+		}
+	}
+}
+
+// TestGetNCollisions hashes everything to one point: the ring has three
+// nodes, one of them owning a point, and GetN returns it alone.
+func TestGetNCollisions(t *testing.T) {
+	r := New(10, func([]byte) uint64 { return 42 })
+	r.Add("c", "b", "a")
+	testutil.RequireDone(t, time.Second, func() {
+		if got := r.GetN("key", 3); !slices.Equal(got, []string{"a"}) {
+			t.Errorf("GetN = %q, want [a]", got)
+		}
+	})
+}
+
+// TestConcurrent gets keys while nodes join and leave, for the race
+// detector.
+func TestConcurrent(t *testing.T) {
+	r := New(200, nil)
+	r.Add("cache-a", "cache-b")
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10000; i++ {
+				if r.Get(fmt.Sprint("key-", i)) == "" || len(r.GetN("key", 2)) < 2 {
+					t.Error("a key without its nodes")
+					return
+				}
+			}
+		}()
+	}
+	r.Add("cache-c")
+	r.Remove("cache-c")
+	r.Add("cache-d")
+	wg.Wait()
+	if got := r.Nodes(); !slices.Equal(got, []string{"cache-a", "cache-b", "cache-d"}) {
+		t.Errorf("Nodes = %q", got)
+	}
+}
@@ -0,0 +1,226 @@
+package concurrent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/clock"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A context.Context carries three things down a call tree: a cancellation
+signal (Done, Err), a deadline, and request-scoped values.
+
+- Values: WithValue(ctx, key, v). The key is compared with ==, so two
+  packages using the string "id" overwrite each other. A key of an
+  unexported type cannot collide: type ctxKey int. Wrap it in a pair of
+  functions (withRequestID, requestID) and never export the key.
+- Deadline vs timeout: WithTimeout(ctx, d) is WithDeadline(ctx, now+d). A
+  deadline is absolute, so it travels well: a child can shorten the deadline
+  of its parent, never extend it.
+- Trees: every With* makes a child. Cancelling a node cancels its subtree,
+  not its parent nor its siblings, however many goroutine layers down.
+- Detaching: WithoutCancel(ctx) keeps the values and drops the cancellation,
+  for work that must outlive the request (an audit log write). It has no
+  deadline either: give it its own.
+
+Anti-patterns:
+
+- a context stored in a struct: it outlives the call it belonged to. Pass it
+  as the first argument, named ctx.
+- a nil context: use context.TODO() while the caller has none.
+- a cancel never called: the child stays in its parent until the parent
+  ends, a leak. defer cancel() right after the With*; go vet (lostcancel)
+  reports the ones forgotten.
+- optional parameters passed as values: they are invisible in the
+  signature. Values are for what crosses API boundaries: request IDs, auth.
+- a loop sleeping with time.Sleep: it does not see ctx.Done() until it
+  wakes. Select on ctx.Done() and a timer instead.
+
+Timeouts are slow to check for real: the code waiting here takes a
+clock.Clock, and its tests run an hour-long deadline on a clock.Fake, moved
+by hand, in microseconds:
+
+	go test ./golang_program_design_2024/04.concurrent/ -run Context
+*/
+
+func init() {
+	lessons.Register("04.concurrent/context", "context values, deadlines, trees, detaching, anti-patterns, fake clocks", DemoContext)
+}
+
+// ctxKey is the type of the keys of this package: no other package can make
+// one, so no other package can overwrite them.
+type ctxKey int
+
+const requestIDKey ctxKey = iota
+
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func requestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok
+}
+
+// DemoContext shows each use of a context.
+func DemoContext() {
+	contextValues()
+	contextDeadlines()
+	contextTree()
+	contextDetach()
+	contextPolling()
+}
+
+func contextValues() {
+	ctx := withRequestID(context.Background(), "req-42")
+	id, ok := requestID(ctx)
+	fmt.Printf("typed key: %q, %v\n", id, ok)
+	_, ok = requestID(context.Background())
+	fmt.Println("typed key, absent: ok =", ok)
+
+	// two packages, the same string key: the second hides the first.
+	ctx = context.WithValue(context.Background(), "id", "request")
+	ctx = context.WithValue(ctx, "id", "user")
+	fmt.Println("string keys collide, the request id is lost:", ctx.Value("id"))
+
+	// the same underlying value, another type: another key.
+	type otherKey int
+	ctx = context.WithValue(withRequestID(context.Background(), "req-42"), otherKey(0), "other")
+	id, _ = requestID(ctx)
+	fmt.Printf("typed keys do not collide: %s, %v\n", id, ctx.Value(otherKey(0)))
+}
+
+func contextDeadlines() {
+	// a timeout is a deadline from now.
+	now := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	d, _ := ctx.Deadline()
+	cancel()
+	fmt.Println("a timeout of 1m is a deadline in", d.Sub(now).Round(time.Second))
+
+	// a child cannot extend the deadline of its parent.
+	parent, cancelParent := context.WithTimeout(context.Background(), time.Second)
+	defer cancelParent()
+	child, cancelChild := context.WithTimeout(parent, time.Hour)
+	defer cancelChild()
+	cd, _ := child.Deadline()
+	fmt.Println("a child asking 1h of a parent of 1s gets", time.Until(cd).Round(time.Second))
+
+	// an hour-long timeout in no time, on a fake clock.
+	start := time.Now()
+	clk := clock.NewFake(start)
+	ctx, cancel = clock.WithTimeout(context.Background(), clk, time.Hour)
+	defer cancel()
+	clk.Advance(59 * time.Minute)
+	early := ctx.Err()
+	clk.Advance(time.Minute)
+	<-ctx.Done()
+	fmt.Printf("fake clock: %v at 59m, %v at 1h, in %v\n", early, ctx.Err(), time.Since(start).Round(time.Microsecond))
+}
+
+// layer runs a goroutine holding ctx until it is done, after starting width
+// goroutines one layer down, each on a child context: a request, its
+// handlers, their workers. Every goroutine adds 1 to running while it runs.
+func layer(ctx context.Context, depth, width int, running *atomic.Int32, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		running.Add(1)
+		defer running.Add(-1)
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		if depth > 1 {
+			for i := 0; i < width; i++ {
+				layer(ctx, depth-1, width, running, wg)
+			}
+		}
+		<-ctx.Done()
+	}()
+}
+
+// waitRunning waits up to a second for running to reach want.
+func waitRunning(running *atomic.Int32, want int32) int32 {
+	deadline := time.Now().Add(time.Second)
+	for running.Load() != want && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	return running.Load()
+}
+
+func contextTree() {
+	// 3 layers of 2: 1 + 2 + 4 goroutines per tree, two sibling trees.
+	root, cancelRoot := context.WithCancel(context.Background())
+	defer cancelRoot()
+	left, cancelLeft := context.WithCancel(root)
+	defer cancelLeft()
+	var running atomic.Int32
+	var wg sync.WaitGroup
+	layer(left, 3, 2, &running, &wg)
+	layer(root, 3, 2, &running, &wg)
+	fmt.Println("two trees of 3 layers:", waitRunning(&running, 14), "goroutines")
+	cancelLeft()
+	fmt.Println("the left one cancelled:", waitRunning(&running, 7), "left, root:", root.Err())
+	cancelRoot()
+	wg.Wait()
+	fmt.Println("the root cancelled:", running.Load(), "left")
+}
+
+func contextDetach() {
+	req, cancel := context.WithCancel(withRequestID(context.Background(), "req-7"))
+	detached := context.WithoutCancel(req)
+	cancel() // the request is over
+	id, _ := requestID(detached)
+	_, hasDeadline := detached.Deadline()
+	fmt.Printf("detached: parent %v, detached %v, request id %s, deadline %v\n", req.Err(), detached.Err(), id, hasDeadline)
+}
+
+// pollUntil calls done every interval of clk until it returns true, or ctx
+// ends: the select sees ctx.Done() between two polls.
+func pollUntil(ctx context.Context, clk clock.Clock, interval time.Duration, done func() bool) error {
+	for {
+		if done() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-clk.After(interval):
+		}
+	}
+}
+
+// pollSleeping is pollUntil sleeping between two polls: the anti-pattern. A
+// cancelled ctx is seen only after the sleep.
+func pollSleeping(ctx context.Context, clk clock.Clock, interval time.Duration, done func() bool) error {
+	for ctx.Err() == nil {
+		if done() {
+			return nil
+		}
+		<-clk.After(interval) // time.Sleep(interval), on clk
+	}
+	return ctx.Err()
+}
+
+func contextPolling() {
+	// cancelled 10ms in, polling every 200ms: the select returns at once,
+	// the sleeping loop at the end of its sleep.
+	never := func() bool { return false }
+	for _, p := range []struct {
+		name string
+		poll func(ctx context.Context, clk clock.Clock, interval time.Duration, done func() bool) error
+	}{
+		{"select on Done", pollUntil},
+		{"sleeping", pollSleeping},
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		start := time.Now()
+		err := p.poll(ctx, clock.Real(), 200*time.Millisecond, never)
+		fmt.Printf("%-15s cancelled at 10ms, returns %v at %v\n", p.name+":", err, time.Since(start).Round(time.Millisecond))
+	}
+}
@@ -0,0 +1,165 @@
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/clock"
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+// t0 is the time of the fake clocks: any fixed time does.
+var t0 = time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+func TestContextValues(t *testing.T) {
+	ctx := withRequestID(context.Background(), "req-42")
+	if id, ok := requestID(ctx); !ok || id != "req-42" {
+		t.Errorf("requestID = %q, %v", id, ok)
+	}
+	if id, ok := requestID(context.Background()); ok || id != "" {
+		t.Errorf("requestID without one = %q, %v", id, ok)
+	}
+	type otherKey int
+	ctx = context.WithValue(ctx, otherKey(requestIDKey), "other")
+	if id, _ := requestID(ctx); id != "req-42" {
+		t.Errorf("a key of another type with the same value hid the id: %q", id)
+	}
+}
+
+func TestContextDeadline(t *testing.T) {
+	clk := clock.NewFake(t0)
+	parent, cancel := clock.WithTimeout(context.Background(), clk, time.Second)
+	defer cancel()
+	child, cancelChild := clock.WithTimeout(parent, clk, time.Hour)
+	defer cancelChild()
+	if d, _ := child.Deadline(); !d.Equal(t0.Add(time.Second)) {
+		t.Errorf("the child of a 1s parent asking 1h ends at %v", d.Sub(t0))
+	}
+	clk.Advance(999 * time.Millisecond)
+	if err := child.Err(); err != nil {
+		t.Fatalf("before the deadline: %v", err)
+	}
+	clk.Advance(time.Millisecond)
+	testutil.RequireClosedWithin(t, child.Done(), time.Second)
+	testutil.RequireClosedWithin(t, parent.Done(), time.Second)
+	if !errors.Is(parent.Err(), context.DeadlineExceeded) {
+		t.Errorf("parent: %v, want DeadlineExceeded", parent.Err())
+	}
+}
+
+// TestContextTree cancels a subtree, then reaches every layer of another
+// with a deadline at its top.
+func TestContextTree(t *testing.T) {
+	root, cancelRoot := context.WithCancel(context.Background())
+	defer cancelRoot()
+	left, cancelLeft := context.WithCancel(root)
+	var running atomic.Int32
+	var wg sync.WaitGroup
+	layer(left, 3, 2, &running, &wg)
+	layer(root, 3, 2, &running, &wg)
+	if n := waitRunning(&running, 14); n != 14 {
+		t.Fatalf("%d goroutines in two trees of 7", n)
+	}
+	cancelLeft()
+	if n := waitRunning(&running, 7); n != 7 || root.Err() != nil {
+		t.Errorf("a subtree cancelled: %d running, root %v", n, root.Err())
+	}
+	cancelRoot()
+	testutil.RequireDone(t, time.Second, wg.Wait)
+
+	clk := clock.NewFake(t0)
+	ctx, cancel := clock.WithTimeout(context.Background(), clk, 30*time.Second)
+	defer cancel()
+	layer(ctx, 4, 2, &running, &wg)
+	if n := waitRunning(&running, 15); n != 15 {
+		t.Fatalf("%d goroutines in a tree of 15", n)
+	}
+	clk.Advance(29 * time.Second)
+	testutil.RequireNoRecv(t, ctx.Done(), 10*time.Millisecond)
+	clk.Advance(time.Second)
+	testutil.RequireDone(t, time.Second, wg.Wait)
+}
+
+func TestContextDetach(t *testing.T) {
+	req, cancel := context.WithCancel(withRequestID(context.Background(), "req-7"))
+	detached := context.WithoutCancel(req)
+	cancel()
+	if id, _ := requestID(detached); detached.Err() != nil || id != "req-7" {
+		t.Errorf("detached: %v, id %q", detached.Err(), id)
+	}
+
+	// the audit write after the request: bounded by its own timeout.
+	clk := clock.NewFake(t0)
+	audit, cancelAudit := clock.WithTimeout(detached, clk, 5*time.Second)
+	defer cancelAudit()
+	clk.Advance(5 * time.Second)
+	testutil.RequireClosedWithin(t, audit.Done(), time.Second)
+	if !errors.Is(audit.Err(), context.DeadlineExceeded) {
+		t.Errorf("audit: %v, want DeadlineExceeded", audit.Err())
+	}
+}
+
+// TestContextPoll polls a second apart on a fake clock: until done, until
+// the deadline.
+func TestContextPoll(t *testing.T) {
+	clk := clock.NewFake(t0)
+	var calls atomic.Int32
+	result := make(chan error, 1)
+	go func() {
+		result <- pollUntil(context.Background(), clk, time.Second, func() bool { return calls.Add(1) == 3 })
+	}()
+	for i := 0; i < 2; i++ {
+		clk.WaitTimers(1, time.Second) // the poller waits on the clock
+		clk.Advance(time.Second)
+	}
+	if err := testutil.RequireRecv(t, result, time.Second); err != nil || calls.Load() != 3 {
+		t.Errorf("pollUntil = %v after %d polls, want nil after 3", err, calls.Load())
+	}
+
+	// never done: the deadline of 2.5s ends it after the 3rd poll.
+	calls.Store(0)
+	ctx, cancel := clock.WithTimeout(context.Background(), clk, 2500*time.Millisecond)
+	defer cancel()
+	go func() {
+		result <- pollUntil(ctx, clk, time.Second, func() bool { calls.Add(1); return false })
+	}()
+	for _, d := range []time.Duration{time.Second, time.Second, 500 * time.Millisecond} {
+		clk.WaitTimers(2, time.Second) // the deadline and the poll interval
+		clk.Advance(d)
+	}
+	if err := testutil.RequireRecv(t, result, time.Second); !errors.Is(err, context.DeadlineExceeded) || calls.Load() != 3 {
+		t.Errorf("pollUntil = %v after %d polls, want DeadlineExceeded after 3", err, calls.Load())
+	}
+}
+
+// TestContextPollCancel cancels both pollers while they wait on the clock:
+// the select returns at once, the sleeping one only once the clock moves.
+func TestContextPollCancel(t *testing.T) {
+	never := func() bool { return false }
+	for _, tt := range []struct {
+		name  string
+		poll  func(context.Context, clock.Clock, time.Duration, func() bool) error
+		sleep bool
+	}{
+		{"pollUntil", pollUntil, false},
+		{"pollSleeping", pollSleeping, true},
+	} {
+		clk := clock.NewFake(t0)
+		ctx, cancel := context.WithCancel(context.Background())
+		result := make(chan error, 1)
+		go func() { result <- tt.poll(ctx, clk, time.Minute, never) }()
+		clk.WaitTimers(1, time.Second)
+		cancel()
+		if tt.sleep {
+			testutil.RequireNoRecv(t, result, 20*time.Millisecond)
+			clk.Advance(time.Minute)
+		}
+		if err := testutil.RequireRecv(t, result, time.Second); !errors.Is(err, context.Canceled) {
+			t.Errorf("%s = %v, want Canceled", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,3 @@
+// Package concurrent is chapter 04.concurrent: goroutines and channels. The
+// sync package has a lesson of its own, in package syncdemo below.
+package concurrent
@@ -1,10 +1,12 @@
-package main
+package concurrent
 
 import (
 	"context"
 	"fmt"
 	"runtime"
 	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
 )
 
 /*
@@ -38,9 +40,17 @@ P(represents the context during scheduling):
 G(is the specific Goroutine):
 
 	Represents a Goroutine, which includes information such as the Goroutine's execution stack and instruction set.
+
+A G starts with a 2 KB stack that grows on demand, an M's kernel thread reserves megabytes:
+12.performance/goroutines measures both the memory per goroutine and the stack growth.
 */
 
-func main() {
+func init() {
+	lessons.Register("04.concurrent/goroutine", "starting goroutines and stopping them with a channel or a context", DemoGoroutines)
+}
+
+// DemoGoroutines starts goroutines and stops them, with a channel and with a context.
+func DemoGoroutines() {
 	goroutineHello()
 	safeGoroutine()
 	anonymousFuncGoroutine()
@@ -0,0 +1,81 @@
+package concurrent
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/pipeline"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A pipeline is a series of stages connected by channels: each stage is a
+goroutine receiving values from the previous stage, doing something with
+them, and sending the results to the next one.
+
+	generator -> filter -> fan-out to N workers -> fan-in (merge) -> consumer
+
+- A stage closes its output when its input is done: `for v := range in`
+  ends, the close travels down the pipeline, the consumer's range ends.
+- Fan-out: N goroutines read the same channel, for the slow stage; fan-in
+  (Merge) collects their outputs on one channel.
+- Cancellation travels the other way. A consumer that stops early (it found
+  what it wanted, or failed) leaves every stage blocked on a send for ever:
+  a goroutine leak per stage. With a context, each send is a select on
+  ctx.Done() too, and cancel() unblocks them all.
+
+The pipeline package has the generic stages; the demo runs them, and its
+tests check that a cancelled pipeline leaves no goroutine behind:
+
+	go test ./golang_program_design_2024/04.concurrent/pipeline
+*/
+
+func init() {
+	lessons.Register("04.concurrent/pipeline", "generator, stages, fan-out and fan-in, cancellation with a context", DemoPipeline)
+}
+
+// DemoPipeline runs a pipeline to the end, then cancels one half way, and
+// prints the goroutines left running.
+func DemoPipeline() {
+	baseline := runtime.NumGoroutine()
+	ctx := context.Background()
+
+	// to the end: squares of 1..20, the odd ones, on 4 slow workers.
+	nums := make([]int, 20)
+	for i := range nums {
+		nums[i] = i + 1
+	}
+	slowSquare := func(n int) int {
+		time.Sleep(10 * time.Millisecond) // the slow stage, worth fanning out
+		return n * n
+	}
+	start := time.Now()
+	odd := pipeline.Filter(ctx, pipeline.Generate(ctx, nums...), func(n int) bool { return n%2 == 1 })
+	var got []int
+	for v := range pipeline.Merge(ctx, pipeline.FanOut(ctx, odd, 4, slowSquare)...) {
+		got = append(got, v)
+	}
+	// 10 values of 10ms each: ~30ms on 4 workers, 100ms on one.
+	fmt.Printf("odd squares on 4 workers, in the order they came: %v in %v\n", got, time.Since(start).Round(time.Millisecond))
+
+	// cancelled: an endless generator, the consumer stops after 5 values.
+	ctx, cancel := context.WithCancel(context.Background())
+	endless := make([]int, 1_000_000)
+	for i := range endless {
+		endless[i] = i
+	}
+	merged := pipeline.Merge(ctx, pipeline.FanOut(ctx, pipeline.Generate(ctx, endless...), 8, func(n int) int { return n * 2 })...)
+	received := 0
+	for range merged {
+		received++
+		if received == 5 {
+			break
+		}
+	}
+	fmt.Printf("the consumer stops after %d values: %d goroutines still running\n", received, runtime.NumGoroutine()-baseline)
+	cancel() // every stage waiting on a send or a receive sees ctx.Done()
+	time.Sleep(10 * time.Millisecond)
+	fmt.Printf("after cancel: %d\n", runtime.NumGoroutine()-baseline)
+}
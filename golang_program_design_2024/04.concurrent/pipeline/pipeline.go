@@ -0,0 +1,131 @@
+// Package pipeline has the stages of a channel pipeline: a goroutine per
+// stage, a channel between stages, each stage closing its output when its
+// input is done.
+//
+//	nums := pipeline.Generate(ctx, 1, 2, 3, 4)
+//	squares := pipeline.FanOut(ctx, nums, 4, square) // 4 workers, order lost
+//	for v := range pipeline.Merge(ctx, squares...) {
+//		...
+//	}
+//
+// Every send and every receive also selects on ctx.Done(): when the consumer
+// stops reading and cancels ctx, every stage returns instead of blocking on
+// a send nobody will receive, or on a receive from a stage upstream that
+// sends nothing, and no goroutine is left behind.
+package pipeline
+
+import (
+	"context"
+	"sync"
+)
+
+// Generate sends vs on the returned channel, then closes it.
+func Generate[T any](ctx context.Context, vs ...T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for _, v := range vs {
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Map sends f of each value of in, in order, from one goroutine.
+func Map[T, U any](ctx context.Context, in <-chan T, f func(T) U) <-chan U {
+	out := make(chan U)
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			select {
+			case out <- f(v):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Filter sends the values of in for which keep is true.
+func Filter[T any](ctx context.Context, in <-chan T, keep func(T) bool) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			v, ok := recv(ctx, in)
+			if !ok {
+				return
+			}
+			if !keep(v) {
+				continue
+			}
+			select {
+			case out <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// FanOut starts n Map stages reading the same in: each value goes to
+// whichever worker is free, so a slow f runs n at a time. Merge the outputs
+// back into one channel; the order of the values is lost.
+func FanOut[T, U any](ctx context.Context, in <-chan T, n int, f func(T) U) []<-chan U {
+	outs := make([]<-chan U, n)
+	for i := range outs {
+		outs[i] = Map(ctx, in, f)
+	}
+	return outs
+}
+
+// Merge sends the values of every channel on one, closed once all of them
+// are: the fan-in.
+func Merge[T any](ctx context.Context, chans ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	for _, c := range chans {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				v, ok := recv(ctx, c)
+				if !ok {
+					return
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// recv receives a value from in. It reports false once in is closed or ctx
+// is done: a stage waiting on a silent input still sees the cancellation.
+func recv[T any](ctx context.Context, in <-chan T) (T, bool) {
+	select {
+	case v, ok := <-in:
+		return v, ok
+	case <-ctx.Done():
+		var zero T
+		return zero, false
+	}
+}
@@ -0,0 +1,104 @@
+package pipeline
+
+import (
+	"context"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+func ints(n int) []int {
+	vs := make([]int, n)
+	for i := range vs {
+		vs[i] = i + 1
+	}
+	return vs
+}
+
+func square(n int) int { return n * n }
+
+func odd(n int) bool { return n%2 == 1 }
+
+// TestPipeline runs the stages to the end: the odd squares of 1..20.
+func TestPipeline(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx := context.Background()
+	sq := Map(ctx, Filter(ctx, Generate(ctx, ints(20)...), odd), square)
+	got := testutil.RequireClosedWithin(t, sq, time.Second)
+	if want := []int{1, 9, 25, 49, 81, 121, 169, 225, 289, 361}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v in order", got, want)
+	}
+	testutil.RequireGoroutines(t, before, time.Second)
+}
+
+// TestFanOut runs a slow stage on 4 workers: every value arrives once, in
+// about a quarter of the time of one worker.
+func TestFanOut(t *testing.T) {
+	ctx := context.Background()
+	slow := func(n int) int {
+		time.Sleep(10 * time.Millisecond)
+		return square(n)
+	}
+	start := time.Now()
+	got := testutil.RequireClosedWithin(t, Merge(ctx, FanOut(ctx, Generate(ctx, ints(12)...), 4, slow)...), time.Second)
+	elapsed := time.Since(start)
+	slices.Sort(got) // fan-out loses the order
+	var want []int
+	for _, n := range ints(12) {
+		want = append(want, square(n))
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	// 12 values of 10ms: 30ms on 4 workers, 120ms on one.
+	if elapsed > 100*time.Millisecond {
+		t.Errorf("took %v, the workers did not share the stage", elapsed)
+	}
+}
+
+// TestCancel stops reading half way and cancels: every stage exits, the
+// ones blocked on a send and the ones waiting on a silent input alike.
+func TestCancel(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		build func(ctx context.Context) <-chan int
+	}{
+		{"an endless generator, fanned out", func(ctx context.Context) <-chan int {
+			return Merge(ctx, FanOut(ctx, Generate(ctx, ints(1_000_000)...), 8, square)...)
+		}},
+		{"a filter keeping nothing", func(ctx context.Context) <-chan int {
+			return Filter(ctx, Generate(ctx, ints(1_000_000)...), func(int) bool { return false })
+		}},
+		{"an input that never sends", func(ctx context.Context) <-chan int {
+			silent := make(chan int)
+			return Merge(ctx, Map(ctx, Filter(ctx, silent, odd), square))
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+			ctx, cancel := context.WithCancel(context.Background())
+			out := tt.build(ctx)
+			select {
+			case <-out:
+			case <-time.After(10 * time.Millisecond):
+			}
+			cancel()
+			testutil.RequireClosedWithin(t, out, time.Second)
+			testutil.RequireGoroutines(t, before, time.Second)
+		})
+	}
+}
+
+// TestCancelledFirst builds a pipeline on a done ctx: its output closes. A
+// value may still pass, select picks at random among the cases ready.
+func TestCancelledFirst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got := testutil.RequireClosedWithin(t, Merge(ctx, Map(ctx, Generate(ctx, 1, 2, 3), square)), time.Second)
+	if len(got) > 3 {
+		t.Errorf("got %v", got)
+	}
+}
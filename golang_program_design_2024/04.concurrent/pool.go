@@ -0,0 +1,74 @@
+package concurrent
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/workerpool"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A worker pool bounds how many tasks run at once: handleChannelError starts
+one goroutine per task, fine for 5 tasks, not for 50,000 requests to the same
+server. The workerpool package keeps a fixed set of workers reading a queue:
+
+- Submit waits when the queue is full: back-pressure on the producer;
+- each task has its own result channel;
+- a panicking task is recovered in its worker and becomes an error;
+- Shutdown(ctx) stops new tasks and waits for the queued ones, or for ctx.
+
+The demo runs performTask of channel.go on the pool. The guarantees, no
+goroutine left behind after Shutdown included, are tests:
+
+	go test ./golang_program_design_2024/04.concurrent/workerpool
+*/
+
+func init() {
+	lessons.Register("04.concurrent/pool", "a worker pool: bounded concurrency, results, panics, graceful shutdown", DemoWorkerPool)
+}
+
+// DemoWorkerPool runs performTask on a pool of 3 workers, then shows what
+// becomes of a panicking task and of a Submit after Shutdown.
+func DemoWorkerPool() {
+	poolWithPerformTask()
+
+	pool := workerpool.New(2, 2)
+	res, _ := pool.Submit(func() error { panic("boom") })
+	fmt.Println("a panicking task:", <-res)
+	res, _ = pool.Submit(func() error { return nil })
+	fmt.Println("the next task, the worker lives on:", <-res)
+	pool.Shutdown(context.Background())
+	_, err := pool.Submit(func() error { return nil })
+	fmt.Println("Submit after Shutdown:", err)
+}
+
+// poolWithPerformTask is handleChannelError with a pool: 6 tasks, 3 at a
+// time, each result read from its own channel.
+func poolWithPerformTask() {
+	pool := workerpool.New(3, 6)
+	start := time.Now()
+	results := make([]<-chan error, 6)
+	for i := range results {
+		res, err := pool.Submit(func() error {
+			// performTask reports on a channel; the pool wants a return value.
+			errCh := make(chan error, 1)
+			performTask(i, errCh)
+			return <-errCh
+		})
+		if err != nil {
+			fmt.Println("submit:", err)
+			return
+		}
+		results[i] = res
+	}
+	for i, res := range results {
+		if err := <-res; err != nil {
+			fmt.Printf("task %d: %s\n", i, err)
+		}
+	}
+	// 3 failing tasks take 2s each: 2s with 3 workers, not 6s one by one.
+	fmt.Printf("6 tasks on 3 workers in %s\n", time.Since(start).Round(100*time.Millisecond))
+	pool.Shutdown(context.Background())
+}
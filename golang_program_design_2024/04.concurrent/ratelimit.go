@@ -0,0 +1,73 @@
+package concurrent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/ratelimit"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A worker pool bounds how many requests run at once; a rate limiter bounds how
+many start per second, whatever they take. An API allowing 10 requests a
+second does not care about the concurrency of the client.
+
+- Token bucket: tokens drip in at r per second, up to burst; a request takes
+  one. After a quiet moment, burst requests pass at once, then r per second.
+- Leaky bucket: requests leave at r per second, evenly spaced, the others
+  queue. No bursts: what a server behind it sees is smooth.
+
+Allow answers at once (drop the request, or answer 429); Wait(ctx) waits for
+the turn of the request, or for ctx. The token bucket is what
+golang.org/x/time/rate implements; ratelimit/xrate compares the two. The
+guarantees of each limiter are tests:
+
+	go test ./golang_program_design_2024/04.concurrent/ratelimit
+*/
+
+func init() {
+	lessons.Register("04.concurrent/ratelimit", "token bucket and leaky bucket rate limiters: Allow, Wait(ctx), bursts", DemoRateLimit)
+}
+
+// DemoRateLimit shows the two limiters side by side.
+func DemoRateLimit() {
+	// 8 requests at once, 20 per second: the timeline of each limiter.
+	fmt.Println("8 requests at once, 20/s, ms after the start:")
+	for _, l := range []struct {
+		name string
+		lim  ratelimit.Limiter
+	}{
+		{"token bucket, burst 4", ratelimit.NewTokenBucket(20, 4)},
+		{"leaky bucket", ratelimit.NewLeakyBucket(20, 8)},
+	} {
+		fmt.Printf("  %-22s %s\n", l.name, timeline(l.lim, 8))
+	}
+}
+
+// timeline calls lim.Wait n times from n goroutines, and returns when each
+// one got through, in ms since the start, in order.
+func timeline(lim ratelimit.Limiter, n int) string {
+	start := time.Now()
+	at := make([]time.Duration, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lim.Wait(context.Background())
+			at[i] = time.Since(start) // its own index: no lock needed
+		}()
+	}
+	wg.Wait()
+	slices.Sort(at) // goroutines start in any order
+	ms := make([]string, n)
+	for i, d := range at {
+		ms[i] = fmt.Sprint(d.Milliseconds())
+	}
+	return strings.Join(ms, " ")
+}
@@ -0,0 +1,211 @@
+// Package ratelimit has two rate limiters, safe for concurrent use:
+//
+//	TokenBucket  a bucket of burst tokens refilled at r per second; a
+//	             request takes one. Idle time is saved up to burst, spent
+//	             at once: bursts pass, the average rate holds.
+//	LeakyBucket  requests leave at exactly r per second, one every 1/r;
+//	             the ones waiting for their turn queue, up to capacity.
+//	             No bursts: the output is smooth.
+//
+// Both have Allow, which never waits, and Wait(ctx), which waits for the
+// turn of the request or fails at once if ctx would end before it.
+// TokenBucket follows golang.org/x/time/rate: NewTokenBucket(r, b) is
+// rate.NewLimiter(rate.Limit(r), b), with the same Allow and Wait.
+//
+// Both read the time from a clock.Clock, the real one: their tests run on
+// a clock.Fake and wait for nothing.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/clock"
+)
+
+// ErrWouldExceed is returned by Wait when the turn of the request comes
+// after the deadline of ctx, or after the queue of a LeakyBucket is full.
+var ErrWouldExceed = errors.New("ratelimit: wait would exceed the limit")
+
+// Limiter is what TokenBucket and LeakyBucket have in common.
+type Limiter interface {
+	Allow() bool
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a token bucket limiter.
+type TokenBucket struct {
+	clk    clock.Clock
+	mu     sync.Mutex
+	rate   float64 // tokens per second
+	burst  float64
+	tokens float64 // below zero: tokens reserved by waiting requests
+	last   time.Time
+}
+
+// NewTokenBucket returns a limiter allowing r requests per second on
+// average and bursts of up to burst. The bucket starts full. With r <= 0
+// it is never refilled: the burst passes, then nothing.
+func NewTokenBucket(r float64, burst int) *TokenBucket {
+	return newTokenBucket(clock.Real(), r, burst)
+}
+
+func newTokenBucket(clk clock.Clock, r float64, burst int) *TokenBucket {
+	r = max(r, 0) // a negative rate would drain the bucket
+	return &TokenBucket{clk: clk, rate: r, burst: float64(burst), tokens: float64(burst), last: clk.Now()}
+}
+
+// refill adds the tokens earned since the last call. Called with mu held.
+func (b *TokenBucket) refill(now time.Time) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst // idle time is saved up to burst, no more
+	}
+	b.last = now
+}
+
+// Allow takes a token if one is there.
+func (b *TokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(b.clk.Now())
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Wait takes a token, waiting for it to be refilled if needed. The token is
+// reserved first, so concurrent callers queue in order: the bucket goes
+// below zero, and each caller waits for its own token.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	if b.burst < 1 {
+		return ErrWouldExceed // no token can ever be taken
+	}
+	b.mu.Lock()
+	now := b.clk.Now()
+	b.refill(now)
+	if b.rate == 0 {
+		// no refill: no wait can help, and -b.tokens / b.rate below would
+		// be an infinite wait, which time.Duration turns negative.
+		defer b.mu.Unlock()
+		if b.tokens < 1 {
+			return ErrWouldExceed
+		}
+		b.tokens--
+		return nil
+	}
+	b.tokens--
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+		b.tokens++ // give it back: no point waiting to fail
+		b.mu.Unlock()
+		return ErrWouldExceed
+	}
+	b.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	wake, stop := sleep(b.clk, wait)
+	defer stop()
+	select {
+	case <-wake:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++ // not used: the next caller may have it
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// LeakyBucket is a leaky bucket limiter, as a queue: each request is given
+// the next free slot, one every interval.
+type LeakyBucket struct {
+	clk      clock.Clock
+	mu       sync.Mutex
+	interval time.Duration
+	capacity int       // requests allowed to wait for a slot
+	next     time.Time // the first free slot
+	stopped  bool      // r <= 0: no request ever leaves
+}
+
+// NewLeakyBucket returns a limiter letting r requests per second through,
+// evenly spaced, with up to capacity of them waiting their turn. With
+// r <= 0 nothing gets through: Allow is false, Wait is ErrWouldExceed.
+func NewLeakyBucket(r float64, capacity int) *LeakyBucket {
+	return newLeakyBucket(clock.Real(), r, capacity)
+}
+
+func newLeakyBucket(clk clock.Clock, r float64, capacity int) *LeakyBucket {
+	if r <= 0 {
+		// 1/r would be an infinite interval, which time.Duration turns
+		// negative: every request would pass.
+		return &LeakyBucket{clk: clk, stopped: true}
+	}
+	return &LeakyBucket{clk: clk, interval: time.Duration(float64(time.Second) / r), capacity: capacity}
+}
+
+// Allow lets the request through if its slot is now: nobody is waiting and
+// the last one left at least an interval ago.
+func (b *LeakyBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clk.Now()
+	if b.stopped || b.next.After(now) {
+		return false
+	}
+	b.next = now.Add(b.interval)
+	return true
+}
+
+// Wait waits for the slot of the request. It fails at once if capacity
+// requests are already waiting, or if ctx ends before the slot.
+func (b *LeakyBucket) Wait(ctx context.Context) error {
+	if b.stopped {
+		return ErrWouldExceed
+	}
+	b.mu.Lock()
+	now := b.clk.Now()
+	slot := b.next
+	if slot.Before(now) {
+		slot = now
+	}
+	wait := slot.Sub(now)
+	if wait > time.Duration(b.capacity)*b.interval {
+		b.mu.Unlock()
+		return ErrWouldExceed // the queue is full
+	}
+	if deadline, ok := ctx.Deadline(); ok && slot.After(deadline) {
+		b.mu.Unlock()
+		return ErrWouldExceed
+	}
+	b.next = slot.Add(b.interval)
+	b.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+
+	wake, stop := sleep(b.clk, wait)
+	defer stop()
+	select {
+	case <-wake:
+		return nil
+	case <-ctx.Done():
+		// the slot stays taken: giving it back would let the requests
+		// queued behind it through less than an interval apart.
+		return ctx.Err()
+	}
+}
+
+// sleep returns a channel closed once d has passed on clk, and the stop of
+// its timer.
+func sleep(clk clock.Clock, d time.Duration) (<-chan struct{}, func() bool) {
+	wake := make(chan struct{})
+	stop := clk.AfterFunc(d, func() { close(wake) })
+	return wake, stop
+}
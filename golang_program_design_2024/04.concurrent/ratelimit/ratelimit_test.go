@@ -0,0 +1,244 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/clock"
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+var t0 = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// waits calls Wait n times in a goroutine, sending the error of each.
+func waits(l Limiter, ctx context.Context, n int) <-chan error {
+	errc := make(chan error, n)
+	go func() {
+		for i := 0; i < n; i++ {
+			errc <- l.Wait(ctx)
+		}
+	}()
+	return errc
+}
+
+// step advances clk to the next turn of a waiting request: it comes after
+// d, not a moment before.
+func step(t *testing.T, clk *clock.Fake, errc <-chan error, d time.Duration) {
+	t.Helper()
+	if !clk.WaitTimers(1, time.Second) {
+		t.Fatal("no request waiting on the clock")
+	}
+	clk.Advance(d - time.Millisecond)
+	testutil.RequireNoRecv(t, errc, 10*time.Millisecond)
+	clk.Advance(time.Millisecond)
+	if err := testutil.RequireRecv(t, errc, time.Second); err != nil {
+		t.Fatalf("Wait = %v", err)
+	}
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	clk := clock.NewFake(t0)
+	tb := newTokenBucket(clk, 10, 5)
+	allowed := 0
+	for i := 0; i < 8; i++ {
+		if tb.Allow() {
+			allowed++
+		}
+	}
+	if allowed != 5 {
+		t.Errorf("%d of 8 allowed at once, want the burst, 5", allowed)
+	}
+	clk.Advance(99 * time.Millisecond)
+	if tb.Allow() {
+		t.Error("a token before the 100ms of one at 10/s")
+	}
+	clk.Advance(time.Millisecond)
+	if !tb.Allow() || tb.Allow() {
+		t.Error("100ms at 10/s did not refill exactly one token")
+	}
+	clk.Advance(time.Hour)
+	allowed = 0
+	for tb.Allow() {
+		allowed++
+	}
+	if allowed != 5 {
+		t.Errorf("%d allowed after an hour idle, want the burst, 5", allowed)
+	}
+}
+
+// TestTokenBucketWaitPaces takes 10 tokens at 50/s after a burst of 2: the
+// 8 beyond the burst come 20ms apart.
+func TestTokenBucketWaitPaces(t *testing.T) {
+	clk := clock.NewFake(t0)
+	errc := waits(newTokenBucket(clk, 50, 2), context.Background(), 10)
+	for i := 0; i < 2; i++ {
+		if err := testutil.RequireRecv(t, errc, time.Second); err != nil {
+			t.Fatalf("Wait %d of the burst = %v", i, err)
+		}
+	}
+	for i := 2; i < 10; i++ {
+		step(t, clk, errc, 20*time.Millisecond)
+	}
+	if d := clk.Now().Sub(t0); d != 160*time.Millisecond {
+		t.Errorf("10 Waits took %v, want 160ms", d)
+	}
+}
+
+func TestTokenBucketWaitDeadline(t *testing.T) {
+	clk := clock.NewFake(t0)
+	tb := newTokenBucket(clk, 1, 1)
+	tb.Allow()
+	ctx, cancel := clock.WithTimeout(context.Background(), clk, 999*time.Millisecond)
+	defer cancel()
+	// the clock does not move: Wait fails without waiting.
+	testutil.RequireDone(t, time.Second, func() {
+		if err := tb.Wait(ctx); !errors.Is(err, ErrWouldExceed) {
+			t.Errorf("Wait = %v, want ErrWouldExceed: the token comes after the deadline", err)
+		}
+	})
+	// a deadline after the token: Wait waits for it.
+	ctx, cancel = clock.WithTimeout(context.Background(), clk, 2*time.Second)
+	defer cancel()
+	errc := waits(tb, ctx, 1)
+	step(t, clk, errc, time.Second)
+}
+
+func TestTokenBucketWaitCancel(t *testing.T) {
+	clk := clock.NewFake(t0)
+	tb := newTokenBucket(clk, 10, 1)
+	tb.Allow()
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := waits(tb, ctx, 1)
+	if !clk.WaitTimers(1, time.Second) {
+		t.Fatal("Wait is not waiting on the clock")
+	}
+	cancel()
+	if err := testutil.RequireRecv(t, errc, time.Second); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait = %v, want context.Canceled", err)
+	}
+	if clk.Timers() != 0 {
+		t.Error("the timer of the cancelled Wait is left")
+	}
+	clk.Advance(100 * time.Millisecond) // the token of the cancelled Wait is due
+	if !tb.Allow() {
+		t.Error("the cancelled Wait kept its token")
+	}
+}
+
+func TestTokenBucketConcurrentAllow(t *testing.T) {
+	tb := newTokenBucket(clock.NewFake(t0), 1, 10) // the clock does not move: no refill
+	var passed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tb.Allow() {
+				passed.Add(1)
+			}
+		}()
+	}
+	testutil.RequireDone(t, time.Second, wg.Wait)
+	if passed.Load() != 10 {
+		t.Errorf("%d of 50 allowed, want the 10 tokens", passed.Load())
+	}
+}
+
+func TestTokenBucketNoBurst(t *testing.T) {
+	if err := NewTokenBucket(10, 0).Wait(context.Background()); !errors.Is(err, ErrWouldExceed) {
+		t.Errorf("Wait on a burst of 0 = %v, want ErrWouldExceed", err)
+	}
+}
+
+// TestTokenBucketZeroRate checks that a rate of 0, or below, is a bucket
+// never refilled: the burst passes, then no request, however long it waits.
+func TestTokenBucketZeroRate(t *testing.T) {
+	for _, r := range []float64{0, -10} {
+		clk := clock.NewFake(t0)
+		tb := newTokenBucket(clk, r, 2)
+		if !tb.Allow() {
+			t.Errorf("rate %v: the first token of the burst refused", r)
+		}
+		if err := tb.Wait(context.Background()); err != nil {
+			t.Errorf("rate %v: Wait for the last token = %v", r, err)
+		}
+		clk.Advance(time.Hour)
+		if tb.Allow() {
+			t.Errorf("rate %v: a token after the burst", r)
+		}
+		if err := tb.Wait(context.Background()); !errors.Is(err, ErrWouldExceed) {
+			t.Errorf("rate %v: Wait after the burst = %v, want ErrWouldExceed", r, err)
+		}
+	}
+}
+
+func TestLeakyBucketZeroRate(t *testing.T) {
+	for _, r := range []float64{0, -10} {
+		lb := newLeakyBucket(clock.NewFake(t0), r, 4)
+		if lb.Allow() {
+			t.Errorf("rate %v: Allow = true", r)
+		}
+		if err := lb.Wait(context.Background()); !errors.Is(err, ErrWouldExceed) {
+			t.Errorf("rate %v: Wait = %v, want ErrWouldExceed", r, err)
+		}
+	}
+}
+
+func TestLeakyBucketNoBurst(t *testing.T) {
+	lb := newLeakyBucket(clock.NewFake(t0), 20, 4)
+	if !lb.Allow() || lb.Allow() {
+		t.Error("want 1 of 2 requests of the same instant")
+	}
+}
+
+func TestLeakyBucketSpacing(t *testing.T) {
+	clk := clock.NewFake(t0)
+	errc := waits(newLeakyBucket(clk, 20, 5), context.Background(), 5)
+	if err := testutil.RequireRecv(t, errc, time.Second); err != nil {
+		t.Fatalf("the first Wait = %v", err)
+	}
+	for i := 1; i < 5; i++ {
+		step(t, clk, errc, 50*time.Millisecond)
+	}
+}
+
+// TestLeakyBucketQueueFull sends 4 requests to a queue of 2: one goes now,
+// 2 wait, the 4th is refused at once.
+func TestLeakyBucketQueueFull(t *testing.T) {
+	clk := clock.NewFake(t0)
+	lb := newLeakyBucket(clk, 10, 2)
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		go func() { errs <- lb.Wait(context.Background()) }()
+	}
+	// the clock does not move: the one let through and the one refused.
+	var now []error
+	for i := 0; i < 2; i++ {
+		now = append(now, testutil.RequireRecv(t, errs, time.Second))
+	}
+	if errors.Is(now[0], ErrWouldExceed) == errors.Is(now[1], ErrWouldExceed) {
+		t.Errorf("Waits ending at once: %v, want one nil and one ErrWouldExceed", now)
+	}
+	testutil.RequireNoRecv(t, errs, 10*time.Millisecond)
+	clk.Advance(200 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if err := testutil.RequireRecv(t, errs, time.Second); err != nil {
+			t.Errorf("a queued Wait = %v", err)
+		}
+	}
+}
+
+func TestLeakyBucketDeadline(t *testing.T) {
+	clk := clock.NewFake(t0)
+	lb := newLeakyBucket(clk, 1, 5)
+	lb.Allow()
+	ctx, cancel := clock.WithTimeout(context.Background(), clk, 50*time.Millisecond)
+	defer cancel()
+	if err := lb.Wait(ctx); !errors.Is(err, ErrWouldExceed) {
+		t.Errorf("Wait = %v, want ErrWouldExceed: the slot is after the deadline", err)
+	}
+}
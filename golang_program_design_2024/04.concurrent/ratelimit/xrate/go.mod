@@ -0,0 +1,11 @@
+module github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/ratelimit/xrate
+
+go 1.22
+
+require (
+	github.com/YongSangUn/learn-golang v0.0.0
+	golang.org/x/time v0.5.0
+)
+
+// the ratelimit package of the main module, compared against x/time/rate.
+replace github.com/YongSangUn/learn-golang => ../../../..
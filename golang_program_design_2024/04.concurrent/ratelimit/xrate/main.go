@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/ratelimit"
+	"golang.org/x/time/rate"
+)
+
+/*
+xrate runs the same scenarios on ratelimit.TokenBucket and on
+golang.org/x/time/rate, the limiter of the Go team, and prints both: the two
+are token buckets, and should behave the same.
+
+	ratelimit.NewTokenBucket(r, b)   rate.NewLimiter(rate.Limit(r), b)
+	Allow()                          Allow()
+	Wait(ctx)                        Wait(ctx)
+	1 every 100ms: r = 10            rate.Every(100 * time.Millisecond)
+
+x/time/rate has more: Reserve (take a token now, learn how long to wait,
+cancel it), AllowN/WaitN for several tokens at once, SetLimit and SetBurst
+to change the rate of a running limiter. It is its own module, hence the
+go.mod of this directory.
+
+Usage, from this directory:
+
+	go mod tidy
+	go run .
+*/
+
+// limiter is what both limiters have.
+type limiter interface {
+	Allow() bool
+	Wait(ctx context.Context) error
+}
+
+func main() {
+	type pair struct {
+		name string
+		new  func(r float64, b int) limiter
+	}
+	pairs := []pair{
+		{"ratelimit", func(r float64, b int) limiter { return ratelimit.NewTokenBucket(r, b) }},
+		{"x/time/rate", func(r float64, b int) limiter { return rate.NewLimiter(rate.Limit(r), b) }},
+	}
+
+	fmt.Println("Allow, 10 calls at once, 10/s, burst 4:")
+	for _, p := range pairs {
+		lim := p.new(10, 4)
+		var got []string
+		for i := 0; i < 10; i++ {
+			if lim.Allow() {
+				got = append(got, "y")
+			} else {
+				got = append(got, "n")
+			}
+		}
+		fmt.Printf("  %-12s %s\n", p.name, strings.Join(got, " "))
+	}
+
+	fmt.Println("Wait, 8 goroutines at once, 20/s, burst 4, ms after the start:")
+	for _, p := range pairs {
+		fmt.Printf("  %-12s %s\n", p.name, timeline(p.new(20, 4), 8))
+	}
+
+	fmt.Println("Wait with a 50ms deadline, the next token in 1s:")
+	for _, p := range pairs {
+		lim := p.new(1, 1)
+		lim.Allow()
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		err := lim.Wait(ctx)
+		cancel()
+		fmt.Printf("  %-12s after %s: %v\n", p.name, time.Since(start).Round(time.Millisecond), err)
+	}
+
+	// the throughput of Allow under contention, for a feel of the cost.
+	fmt.Println("Allow, 8 goroutines x 100,000 calls:")
+	for _, p := range pairs {
+		lim := p.new(1e9, 1000)
+		start := time.Now()
+		var wg sync.WaitGroup
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100_000; i++ {
+					lim.Allow()
+				}
+			}()
+		}
+		wg.Wait()
+		fmt.Printf("  %-12s %s\n", p.name, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// timeline calls lim.Wait n times from n goroutines, and returns when each
+// one got through, in ms since the start, in order.
+func timeline(lim limiter, n int) string {
+	start := time.Now()
+	at := make([]time.Duration, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lim.Wait(context.Background())
+			at[i] = time.Since(start) // its own index: no lock needed
+		}()
+	}
+	wg.Wait()
+	slices.Sort(at) // goroutines start in any order
+	ms := make([]string, n)
+	for i, d := range at {
+		ms[i] = fmt.Sprint(d.Milliseconds())
+	}
+	return strings.Join(ms, " ")
+}
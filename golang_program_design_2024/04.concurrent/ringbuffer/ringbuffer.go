@@ -0,0 +1,210 @@
+// Package ringbuffer has two bounded FIFO queues over a fixed array used in
+// a circle: SPSC, lock-free for one producer and one consumer goroutine,
+// and Blocking, safe for any number of them, whose Push waits for room and
+// Pop for an item.
+//
+//	q := ringbuffer.NewSPSC[int](1024)
+//	go func() { q.Push(42) }() // the only goroutine pushing
+//	v := q.Pop()                // the only goroutine popping
+//
+// A buffered channel is a ring buffer too, with a lock: SPSC does without it,
+// where its two goroutines are known; Blocking adds what a channel lacks,
+// TryPop of the oldest item and a Len to read, with Close like a channel's.
+package ringbuffer
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// cacheLine is the usual size of a CPU cache line.
+const cacheLine = 64
+
+// SPSC is a lock-free ring buffer for exactly one producer goroutine and one
+// consumer goroutine. Two producers, or two consumers, corrupt it: each
+// index has one writer, which is what lets it go without a lock.
+type SPSC[T any] struct {
+	buf  []T
+	mask uint64
+	// head and tail count the items popped and pushed since the start; they
+	// only grow, and tail-head is the length. Each is written by one side
+	// and read by the other; the padding keeps them on separate cache
+	// lines, where a write to one would not invalidate the other.
+	_    [cacheLine]byte
+	head atomic.Uint64 // written by the consumer
+	_    [cacheLine - 8]byte
+	tail atomic.Uint64 // written by the producer
+	_    [cacheLine - 8]byte
+}
+
+// NewSPSC returns an SPSC buffer of capacity items, rounded up to a power of
+// two, at least 1: the index of an item is then its count masked, no
+// division.
+func NewSPSC[T any](capacity int) *SPSC[T] {
+	n := 1
+	for n < capacity {
+		n <<= 1
+	}
+	return &SPSC[T]{buf: make([]T, n), mask: uint64(n - 1)}
+}
+
+// Cap returns the capacity.
+func (q *SPSC[T]) Cap() int { return len(q.buf) }
+
+// Len returns the number of items. Read from a third goroutine it may be
+// out of date as soon as it returns.
+func (q *SPSC[T]) Len() int {
+	head := q.head.Load() // before tail: tail-head can never go below 0
+	return int(q.tail.Load() - head)
+}
+
+// TryPush adds v if there is room, and reports whether it did. Only the
+// producer may call it.
+func (q *SPSC[T]) TryPush(v T) bool {
+	tail := q.tail.Load()
+	if tail-q.head.Load() == uint64(len(q.buf)) {
+		return false
+	}
+	q.buf[tail&q.mask] = v
+	// the Store publishes the item: a consumer that loads the new tail sees
+	// the write above, the happens-before of the memory model for atomics.
+	q.tail.Store(tail + 1)
+	return true
+}
+
+// TryPop takes the oldest item if there is one. Only the consumer may call
+// it.
+func (q *SPSC[T]) TryPop() (T, bool) {
+	var zero T
+	head := q.head.Load()
+	if head == q.tail.Load() {
+		return zero, false
+	}
+	v := q.buf[head&q.mask]
+	q.buf[head&q.mask] = zero // no pointer kept alive by the buffer
+	q.head.Store(head + 1)    // hands the slot back to the producer
+	return v, true
+}
+
+// Push adds v, spinning while the buffer is full. At each try it lets other
+// goroutines run (runtime.Gosched), but it never sleeps and keeps its
+// thread: for a consumer that may stop for long, or two sides sharing one
+// CPU, use Blocking.
+func (q *SPSC[T]) Push(v T) {
+	for !q.TryPush(v) {
+		runtime.Gosched()
+	}
+}
+
+// Pop takes the oldest item, spinning while the buffer is empty.
+func (q *SPSC[T]) Pop() T {
+	for {
+		if v, ok := q.TryPop(); ok {
+			return v
+		}
+		runtime.Gosched()
+	}
+}
+
+// Blocking is a ring buffer safe for concurrent use by any number of
+// goroutines: a mutex and two sync.Conds, the bounded buffer of the sync
+// lesson without its reslicing, which leaves the popped items' memory to
+// the next append.
+type Blocking[T any] struct {
+	mu       sync.Mutex
+	notFull  *sync.Cond // signalled when an item is taken
+	notEmpty *sync.Cond // signalled when an item is put
+	buf      []T
+	head     int // index of the oldest item
+	n        int
+	closed   bool
+}
+
+// NewBlocking returns a Blocking buffer of capacity items, at least 1.
+func NewBlocking[T any](capacity int) *Blocking[T] {
+	q := &Blocking[T]{buf: make([]T, max(capacity, 1))}
+	q.notFull = sync.NewCond(&q.mu)
+	q.notEmpty = sync.NewCond(&q.mu)
+	return q
+}
+
+// Cap returns the capacity.
+func (q *Blocking[T]) Cap() int { return len(q.buf) }
+
+// Len returns the number of items.
+func (q *Blocking[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.n
+}
+
+// Push adds v, waiting for room. It returns false if the buffer is closed.
+func (q *Blocking[T]) Push(v T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.n == len(q.buf) && !q.closed {
+		q.notFull.Wait()
+	}
+	return q.push(v)
+}
+
+// TryPush adds v if there is room and the buffer is open, and reports
+// whether it did.
+func (q *Blocking[T]) TryPush(v T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.n < len(q.buf) && q.push(v)
+}
+
+func (q *Blocking[T]) push(v T) bool {
+	if q.closed {
+		return false
+	}
+	q.buf[(q.head+q.n)%len(q.buf)] = v
+	q.n++
+	q.notEmpty.Signal()
+	return true
+}
+
+// Pop takes the oldest item, waiting for one. It returns false once the
+// buffer is closed and empty: the items pushed before Close are still
+// handed out, like the values of a closed channel.
+func (q *Blocking[T]) Pop() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for q.n == 0 && !q.closed {
+		q.notEmpty.Wait()
+	}
+	return q.pop()
+}
+
+// TryPop takes the oldest item if there is one.
+func (q *Blocking[T]) TryPop() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pop()
+}
+
+func (q *Blocking[T]) pop() (T, bool) {
+	var zero T
+	if q.n == 0 {
+		return zero, false
+	}
+	v := q.buf[q.head]
+	q.buf[q.head] = zero
+	q.head = (q.head + 1) % len(q.buf)
+	q.n--
+	q.notFull.Signal()
+	return v, true
+}
+
+// Close makes Push fail and wakes every waiter. Closing twice is harmless,
+// unlike closing a channel twice.
+func (q *Blocking[T]) Close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.notFull.Broadcast()
+	q.notEmpty.Broadcast()
+}
@@ -0,0 +1,147 @@
+package ringbuffer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+func TestSPSCCap(t *testing.T) {
+	for _, tt := range []struct{ capacity, want int }{{1000, 1024}, {1024, 1024}, {0, 1}, {1, 1}, {3, 4}} {
+		if got := NewSPSC[int](tt.capacity).Cap(); got != tt.want {
+			t.Errorf("NewSPSC(%d).Cap() = %d, want %d", tt.capacity, got, tt.want)
+		}
+	}
+}
+
+func TestSPSCTry(t *testing.T) {
+	q := NewSPSC[int](4)
+	pushed := 0
+	for q.TryPush(pushed) {
+		pushed++
+	}
+	if pushed != 4 || q.Len() != 4 {
+		t.Fatalf("%d pushed into a buffer of 4, Len %d", pushed, q.Len())
+	}
+	if v, ok := q.TryPop(); !ok || v != 0 {
+		t.Errorf("TryPop = %d, %v, want the oldest, 0", v, ok)
+	}
+	if !q.TryPush(4) {
+		t.Error("TryPush failed after a TryPop made room")
+	}
+	for want := 1; want <= 4; want++ {
+		if v, ok := q.TryPop(); !ok || v != want {
+			t.Errorf("TryPop = %d, %v, want %d", v, ok, want)
+		}
+	}
+	if _, ok := q.TryPop(); ok {
+		t.Error("TryPop of an empty buffer succeeded")
+	}
+}
+
+// TestSPSCOrder passes many items through a small buffer, so both sides
+// wrap around and wait on each other many times.
+func TestSPSCOrder(t *testing.T) {
+	const n = 200000
+	q := NewSPSC[int](16)
+	go func() {
+		for i := 0; i < n; i++ {
+			q.Push(i)
+		}
+	}()
+	// RequireDone runs the consumer in a goroutine of its own: Errorf, not
+	// Fatalf, from there.
+	testutil.RequireDone(t, 10*time.Second, func() {
+		for i := 0; i < n; i++ {
+			if v := q.Pop(); v != i {
+				t.Errorf("item %d popped as %d", i, v)
+				return
+			}
+		}
+	})
+}
+
+func TestBlockingManyGoroutines(t *testing.T) {
+	const producers, consumers, perProducer = 4, 3, 10000
+	q := NewBlocking[int](8)
+	var prodWG, consWG sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		prodWG.Add(1)
+		go func() {
+			defer prodWG.Done()
+			for i := 0; i < perProducer; i++ {
+				q.Push(p*perProducer + i)
+			}
+		}()
+	}
+	var mu sync.Mutex
+	seen := make(map[int]int)
+	for c := 0; c < consumers; c++ {
+		consWG.Add(1)
+		go func() {
+			defer consWG.Done()
+			for {
+				v, ok := q.Pop()
+				if !ok {
+					return
+				}
+				mu.Lock()
+				seen[v]++
+				mu.Unlock()
+			}
+		}()
+	}
+	testutil.RequireDone(t, 10*time.Second, prodWG.Wait)
+	q.Close() // the consumers drain the buffer, then Pop returns false
+	testutil.RequireDone(t, 10*time.Second, consWG.Wait)
+
+	if len(seen) != producers*perProducer {
+		t.Errorf("%d distinct items, want %d", len(seen), producers*perProducer)
+	}
+	for v, n := range seen {
+		if n != 1 {
+			t.Fatalf("item %d popped %d times", v, n)
+		}
+	}
+	if q.Push(1) || q.TryPush(1) {
+		t.Error("Push after Close succeeded")
+	}
+}
+
+func TestBlockingCloseWakesPush(t *testing.T) {
+	q := NewBlocking[string](1)
+	q.Push("a")
+	if q.TryPush("b") {
+		t.Fatal("TryPush of a full buffer succeeded")
+	}
+	done := make(chan bool)
+	go func() { done <- q.Push("b") }() // waits for room
+	testutil.RequireNoRecv(t, done, 20*time.Millisecond)
+
+	q.Close()
+	if ok := testutil.RequireRecv(t, done, time.Second); ok {
+		t.Error("the waiting Push succeeded after Close")
+	}
+	if v, ok := q.Pop(); !ok || v != "a" {
+		t.Errorf("Pop = %q, %v: the item pushed before Close is lost", v, ok)
+	}
+	if _, ok := q.Pop(); ok {
+		t.Error("Pop of a closed, empty buffer succeeded")
+	}
+}
+
+func TestBlockingPopWaits(t *testing.T) {
+	q := NewBlocking[int](2)
+	got := make(chan int)
+	go func() {
+		v, _ := q.Pop()
+		got <- v
+	}()
+	testutil.RequireNoRecv(t, got, 20*time.Millisecond)
+	q.Push(7)
+	if v := testutil.RequireRecv(t, got, time.Second); v != 7 {
+		t.Errorf("Pop = %d, want 7", v)
+	}
+}
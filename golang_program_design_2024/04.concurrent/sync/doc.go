@@ -0,0 +1,3 @@
+// Package syncdemo is the sync lesson of chapter 04.concurrent, named so it
+// does not shadow the sync package it is about.
+package syncdemo
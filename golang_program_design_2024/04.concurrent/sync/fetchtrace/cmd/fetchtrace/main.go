@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/sync/fetchtrace"
+	"golang.org/x/sync/errgroup"
+)
+
+/*
+fetchtrace fetches URLs concurrently, like errGroup() in sync.go, and prints
+where the time of each request went, as JSON.
+
+Usage:
+
+	go run ./golang_program_design_2024/04.concurrent/sync/fetchtrace/cmd/fetchtrace https://go.dev https://github.com
+	  [{"url":"https://go.dev","status":200,"reused_conn":false,"dns":"3.1ms","connect":"12.4ms","tls":"25.8ms","wait":"41.2ms",...
+
+	go run ./golang_program_design_2024/04.concurrent/sync/fetchtrace/cmd/fetchtrace -demo
+
+-demo runs local httptest servers with artificial delays, plain and TLS,
+and prints the timings of fetches from them. That each timing lands where
+the delay was put is tested:
+
+	go test ./golang_program_design_2024/04.concurrent/sync/fetchtrace/...
+*/
+
+func main() {
+	demo := flag.Bool("demo", false, "fetch from local servers with artificial delays")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of the whole fetch")
+	flag.Parse()
+	if *demo {
+		if err := runDemo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: fetchtrace [-timeout 10s] url...")
+		os.Exit(2)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	timings, err := fetchAll(ctx, http.DefaultClient, flag.Args())
+	printJSON(timings)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// fetchAll fetches the urls concurrently. Unlike errGroup(), a failure does
+// not cancel the others: the point is to see the timings of every request,
+// the failed ones included. The error is the first one.
+func fetchAll(ctx context.Context, client *http.Client, urls []string) ([]fetchtrace.Timings, error) {
+	timings := make([]fetchtrace.Timings, len(urls))
+	var g errgroup.Group
+	for i, url := range urls {
+		g.Go(func() error {
+			var err error
+			timings[i], err = fetchtrace.Fetch(ctx, client, url)
+			return err
+		})
+	}
+	return timings, g.Wait()
+}
+
+// runDemo fetches from local servers with artificial delays, plain and
+// TLS, and prints the timings as JSON: each delay shows where it was put.
+func runDemo() error {
+	// the handler sleeps before the headers, for Wait, and in the middle of
+	// the body, for Total.
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d, err := time.ParseDuration(r.URL.Query().Get("wait")); err == nil {
+			time.Sleep(d)
+		}
+		w.Write([]byte(strings.Repeat("a", 1000)))
+		if d, err := time.ParseDuration(r.URL.Query().Get("body")); err == nil {
+			w.(http.Flusher).Flush()
+			time.Sleep(d)
+		}
+		w.Write([]byte(strings.Repeat("b", 1000)))
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+	gone := httptest.NewServer(handler)
+	gone.Close()
+	ctx := context.Background()
+
+	// a server delay, a slow body, a name to resolve and a server down, at
+	// once on connections of their own.
+	local := strings.Replace(plain.URL, "127.0.0.1", "localhost", 1)
+	urls := []string{plain.URL + "/?wait=50ms", plain.URL + "/?body=50ms", local + "/", gone.URL}
+	timings, err := fetchAll(ctx, &http.Client{Transport: &http.Transport{}}, urls)
+	fmt.Println("plain, at once; the last one fails:", err)
+	if err := printJSON(timings); err != nil {
+		return err
+	}
+
+	// secure.Client() trusts the certificate of the test server; its second
+	// request reuses the connection of the first, no handshake.
+	client := secure.Client()
+	var tls []fetchtrace.Timings
+	for _, q := range []string{"/?wait=50ms", "/"} {
+		t, err := fetchtrace.Fetch(ctx, client, secure.URL+q)
+		if err != nil {
+			return err
+		}
+		tls = append(tls, t)
+	}
+	fmt.Println("\nTLS, one after the other:")
+	return printJSON(tls)
+}
+
+func printJSON(v any) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
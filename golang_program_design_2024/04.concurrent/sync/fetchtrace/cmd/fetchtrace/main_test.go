@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestFetchAll fetches two slow URLs and a dead one: every result comes
+// back, the error is the dead one's, and the slow ones ran side by side.
+func TestFetchAll(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+	}))
+	t.Cleanup(slow.Close)
+	gone := httptest.NewServer(http.NotFoundHandler())
+	gone.Close()
+
+	start := time.Now()
+	all, err := fetchAll(context.Background(), &http.Client{Transport: &http.Transport{}}, []string{slow.URL, slow.URL, gone.URL})
+	if err == nil || len(all) != 3 || all[0].Status != 200 || all[1].Status != 200 || all[2].Error == "" {
+		t.Fatalf("fetchAll = %+v, %v", all, err)
+	}
+	if d := time.Since(start); d >= 2*delay {
+		t.Errorf("fetchAll took %v, the fetches ran one after another", d)
+	}
+}
@@ -0,0 +1,142 @@
+// Package fetchtrace fetches URLs and reports where the time of each request
+// went, with net/http/httptrace: the hooks of a ClientTrace are called by
+// the transport at each step of a request, and Fetch timestamps them.
+//
+//	DNS      resolving the host name, zero for an IP or a reused connection
+//	Connect  the TCP handshake
+//	TLS      the TLS handshake, zero for http:// or a reused connection
+//	Wait     from the request written to the first byte of the response:
+//	         the time the server took, plus one round trip
+//	TTFB     from the start to the first byte of the response
+//	Total    from the start to the last byte of the body
+//
+// The timings marshal to JSON, durations as strings like "12.5ms".
+package fetchtrace
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Duration is a time.Duration that marshals as its String, "12.5ms", rather
+// than as a count of nanoseconds.
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).Round(10 * time.Microsecond).String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	*d = Duration(v)
+	return err
+}
+
+// Timings is the breakdown of one request.
+type Timings struct {
+	URL        string   `json:"url"`
+	Status     int      `json:"status,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	ReusedConn bool     `json:"reused_conn"`
+	DNS        Duration `json:"dns"`
+	Connect    Duration `json:"connect"`
+	TLS        Duration `json:"tls"`
+	Wait       Duration `json:"wait"`
+	TTFB       Duration `json:"ttfb"`
+	Total      Duration `json:"total"`
+	Bytes      int64    `json:"bytes"`
+}
+
+// Fetch GETs url with client, reads the body to the end, and returns the
+// timings. On an error the timings hold the steps done so far, and Error
+// is set too, so a failed request can be reported like the others.
+func Fetch(ctx context.Context, client *http.Client, url string) (Timings, error) {
+	t := Timings{URL: url}
+	var (
+		mu                                   sync.Mutex // the hooks may run on other goroutines
+		dnsStart, connStart, tlsStart, wrote time.Time
+	)
+	since := func(start time.Time) Duration {
+		if start.IsZero() {
+			return 0
+		}
+		return Duration(time.Since(start))
+	}
+	start := time.Now()
+	trace := &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { mu.Lock(); dnsStart = time.Now(); mu.Unlock() },
+		DNSDone:  func(httptrace.DNSDoneInfo) { mu.Lock(); t.DNS = since(dnsStart); mu.Unlock() },
+		// with several addresses, the dialer may race two connections
+		// (happy eyeballs): the first one started and the first one done
+		// are kept.
+		ConnectStart: func(network, addr string) {
+			mu.Lock()
+			if connStart.IsZero() {
+				connStart = time.Now()
+			}
+			mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			mu.Lock()
+			if err == nil && t.Connect == 0 {
+				t.Connect = since(connStart)
+			}
+			mu.Unlock()
+		},
+		TLSHandshakeStart: func() { mu.Lock(); tlsStart = time.Now(); mu.Unlock() },
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mu.Lock()
+			t.TLS = since(tlsStart)
+			mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) { mu.Lock(); t.ReusedConn = info.Reused; mu.Unlock() },
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			mu.Lock()
+			wrote = time.Now()
+			mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			mu.Lock()
+			t.Wait, t.TTFB = since(wrote), since(start)
+			mu.Unlock()
+		},
+	}
+
+	fail := func(err error) (Timings, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		t.Total = since(start)
+		t.Error = err.Error()
+		return t, err
+	}
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, url, nil)
+	if err != nil {
+		return fail(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fail(err)
+	}
+	defer resp.Body.Close()
+	n, err := io.Copy(io.Discard, resp.Body)
+	mu.Lock()
+	t.Status, t.Bytes = resp.StatusCode, n
+	mu.Unlock()
+	if err != nil {
+		return fail(err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	t.Total = since(start)
+	return t, nil
+}
@@ -0,0 +1,148 @@
+package fetchtrace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const think, stream = 100 * time.Millisecond, 50 * time.Millisecond
+
+// delayed answers after thinking for think, then sends half of the body,
+// waits for stream and sends the rest.
+func delayed(t *testing.T, tls bool) *httptest.Server {
+	t.Helper()
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(think)
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte(strings.Repeat("a", 500)))
+		w.(http.Flusher).Flush()
+		time.Sleep(stream)
+		w.Write([]byte(strings.Repeat("b", 500)))
+	})
+	srv := httptest.NewUnstartedServer(h)
+	if tls {
+		srv.StartTLS()
+	} else {
+		srv.Start()
+	}
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFetch(t *testing.T) {
+	srv := delayed(t, false)
+	tm, err := Fetch(context.Background(), srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tm.Status != http.StatusTeapot || tm.Bytes != 1000 || tm.Error != "" || tm.URL != srv.URL {
+		t.Errorf("timings %+v", tm)
+	}
+	// the server's time is in Wait, the streaming of the body after TTFB.
+	if d := time.Duration(tm.Wait); d < think {
+		t.Errorf("Wait %v, less than the %v the server took", d, think)
+	}
+	if tm.TTFB < tm.Wait || tm.Total < tm.TTFB {
+		t.Errorf("Wait %v, TTFB %v, Total %v out of order", tm.Wait, tm.TTFB, tm.Total)
+	}
+	if d := time.Duration(tm.Total - tm.TTFB); d < stream {
+		t.Errorf("Total - TTFB = %v, less than the %v of the body", d, stream)
+	}
+	// an address, no name to resolve; a new connection, no TLS.
+	if tm.DNS != 0 || tm.Connect == 0 || tm.TLS != 0 || tm.ReusedConn {
+		t.Errorf("DNS %v, Connect %v, TLS %v, reused %v", tm.DNS, tm.Connect, tm.TLS, tm.ReusedConn)
+	}
+}
+
+// TestReuse fetches twice with one client: the second request reuses the
+// connection, it has no connect time.
+func TestReuse(t *testing.T) {
+	srv := delayed(t, true)
+	client := srv.Client()
+	first, err := Fetch(context.Background(), client, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.TLS == 0 || first.Connect == 0 || first.ReusedConn {
+		t.Errorf("first request: TLS %v, Connect %v, reused %v", first.TLS, first.Connect, first.ReusedConn)
+	}
+	second, err := Fetch(context.Background(), client, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.ReusedConn || second.Connect != 0 || second.TLS != 0 {
+		t.Errorf("second request: TLS %v, Connect %v, reused %v", second.TLS, second.Connect, second.ReusedConn)
+	}
+	if time.Duration(second.Wait) < think {
+		t.Errorf("second Wait %v, less than %v", second.Wait, think)
+	}
+}
+
+// TestDNS fetches by name: the lookup of localhost is measured.
+func TestDNS(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+	url := strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)
+	tm, err := Fetch(context.Background(), &http.Client{Transport: &http.Transport{}}, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tm.DNS == 0 || tm.Connect == 0 {
+		t.Errorf("DNS %v, Connect %v", tm.DNS, tm.Connect)
+	}
+}
+
+// TestErrors checks that a failed request still reports its steps.
+func TestErrors(t *testing.T) {
+	srv := delayed(t, false)
+	ctx, cancel := context.WithTimeout(context.Background(), think/2)
+	defer cancel()
+	tm, err := Fetch(ctx, srv.Client(), srv.URL)
+	if !errors.Is(err, context.DeadlineExceeded) || tm.Error == "" {
+		t.Errorf("a timeout before the answer: %v, %+v", err, tm)
+	}
+	if tm.Connect == 0 || tm.TTFB != 0 || tm.Total == 0 || tm.Status != 0 {
+		t.Errorf("timings of the timeout: %+v", tm)
+	}
+
+	// a timeout in the middle of the body: the status is known.
+	ctx, cancel = context.WithTimeout(context.Background(), think+stream/2)
+	defer cancel()
+	tm, err = Fetch(ctx, srv.Client(), srv.URL)
+	if err == nil || tm.Status != http.StatusTeapot || tm.TTFB == 0 || tm.Bytes != 500 {
+		t.Errorf("a timeout in the body: %v, %+v", err, tm)
+	}
+
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	if tm, err := Fetch(context.Background(), down.Client(), down.URL); err == nil || tm.Error == "" || tm.Connect != 0 {
+		t.Errorf("a server down: %v, %+v", err, tm)
+	}
+	if tm, err := Fetch(context.Background(), http.DefaultClient, "::bad"); err == nil || tm.Error == "" {
+		t.Errorf("a bad URL: %v, %+v", err, tm)
+	}
+}
+
+func TestDurationJSON(t *testing.T) {
+	b, err := json.Marshal(Timings{URL: "u", Wait: Duration(12345678 * time.Nanosecond)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"wait":"12.35ms"`) || !strings.Contains(string(b), `"dns":"0s"`) {
+		t.Errorf("JSON %s", b)
+	}
+	var back Timings
+	if err := json.Unmarshal(b, &back); err != nil || back.Wait != Duration(12350*time.Microsecond) {
+		t.Errorf("read back: %v, %v", back.Wait, err)
+	}
+	var d Duration
+	if err := json.Unmarshal([]byte(`"soon"`), &d); err == nil {
+		t.Error("an invalid duration was accepted")
+	}
+}
@@ -1,5 +0,0 @@
-module testsync
-
-go 1.20
-
-require golang.org/x/sync v0.7.0
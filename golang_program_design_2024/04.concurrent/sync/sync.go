@@ -1,13 +1,19 @@
-package main
+package syncdemo
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"net/http"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/set"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/ratelimit"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/sync/fetchtrace"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/client"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -23,7 +29,12 @@ Golang supports multiple synchronization mechanisms:
 - Condition variables (sync.Cond)
 */
 
-func main() {
+func init() {
+	lessons.Register("04.concurrent/sync/sync", "Mutex, RWMutex, Cond, atomic, Once, errgroup", DemoSync)
+}
+
+// DemoSync runs the demos of the sync package, then errGroup.
+func DemoSync() {
 	syncMutex()
 	syncRWMutex()
 	syncCond()
@@ -31,11 +42,16 @@ func main() {
 	syncOnce()
 
 	if err := errGroup(); err != nil {
-		fmt.Printf("Error occurred: %v\n", err)
+		xlog.New(chapter, "main").Error("errGroup failed", "err", err)
 	}
 }
 
+// chapter is the value of the "chapter" field of every log record in this file.
+const chapter = "04.concurrent"
+
 func syncMutex() {
+	log := xlog.New(chapter, "syncMutex")
+
 	// without sync
 	var counter1 int
 	for i := 0; i < 1000; i++ {
@@ -44,7 +60,7 @@ func syncMutex() {
 		}()
 	}
 	time.Sleep(2 * time.Second) // wait goroutines done.
-	fmt.Printf("without sync counter1: %d\n", counter1)
+	log.Info("without sync", "counter1", counter1)
 
 	// use sync.Mutex
 	var mu sync.Mutex
@@ -62,26 +78,28 @@ func syncMutex() {
 		}()
 	}
 	wg.Wait()
-	fmt.Printf("use sync counter2: %d\n", counter2)
+	log.Info("use sync", "counter2", counter2)
 }
 
 func syncRWMutex() {
+	log := xlog.New(chapter, "syncRWMutex")
+
 	// Without sync.RWMutex
-	fmt.Println("=== Without sync.RWMutex ===")
+	log.Info("=== Without sync.RWMutex ===")
 	unsafeCounter := 0
 	for i := 0; i < 10; i++ {
 		go func() {
 			unsafeCounter++
-			fmt.Printf("Write: %d\n", unsafeCounter)
+			log.Info("write", "counter", unsafeCounter)
 		}()
 		go func() {
-			fmt.Printf("Read: %d\n", unsafeCounter)
+			log.Info("read", "counter", unsafeCounter)
 		}()
 	}
 	time.Sleep(time.Millisecond * 100)
 
 	// With sync.RWMutex
-	fmt.Println("\n=== With sync.RWMutex ===")
+	log.Info("=== With sync.RWMutex ===")
 	var mu sync.RWMutex
 	safeCounter := 0
 	var wg sync.WaitGroup
@@ -92,13 +110,13 @@ func syncRWMutex() {
 			defer wg.Done()
 			mu.Lock()
 			safeCounter++
-			fmt.Printf("Write: %d\n", safeCounter)
+			log.Info("write", "counter", safeCounter)
 			mu.Unlock()
 		}()
 		go func() {
 			defer wg.Done()
 			mu.RLock()
-			fmt.Printf("Read: %d\n", safeCounter)
+			log.Info("read", "counter", safeCounter)
 			mu.RUnlock()
 		}()
 	}
@@ -112,15 +130,184 @@ sync.Cond is less frequently used in Go concurrent programming for the following
 - specific use cases (mainly for "wait-notify" patterns)
 - often replaceable by channels
 - requires deeper knowledge of concurrent programming
+
+A Cond is a place to wait for a condition on state guarded by a lock, c.L:
+
+- Wait unlocks c.L, sleeps until woken, and locks c.L again before it returns.
+- Signal wakes one waiting goroutine, Broadcast wakes all of them. Neither
+  needs c.L held, but changing the state without it races with the check.
+
+Wait always goes in a for loop, never an if: when Wait returns the
+condition may be false again. The Go runtime does not wake a goroutine for
+nothing, but between the Signal and the moment the woken goroutine gets c.L
+back, another one can take the lock first and consume what was signalled (a
+"stolen" wakeup); a Broadcast wakes every waiter for a change only one can
+use; and code ported from pthreads must expect real spurious wakeups. The
+loop handles the three the same way: check again.
+
+The bounded buffer below is what a buffered channel does for free:
+
+	ch := make(chan int, 2) // put: ch <- v, get: v, ok := <-ch, close: close(ch)
+
+Reach for a Cond when the condition is not "an item is there": several
+conditions on the same state, or waking every waiter at once, again and again
+(a channel can be closed only once).
 */
 
-// TODO: sync.Cond
+// boundedBuffer is a FIFO queue of at most size items. Put waits while it is
+// full, Get while it is empty; both give up once it is closed.
+type boundedBuffer struct {
+	mu       sync.Mutex
+	notFull  *sync.Cond // signalled when an item is taken
+	notEmpty *sync.Cond // signalled when an item is put
+	items    []int
+	size     int
+	closed   bool
+	rewaits  int // wakeups that found the condition still false
+}
+
+func newBoundedBuffer(size int) *boundedBuffer {
+	b := &boundedBuffer{size: size}
+	// both conditions share the lock of the state they are about.
+	b.notFull = sync.NewCond(&b.mu)
+	b.notEmpty = sync.NewCond(&b.mu)
+	return b
+}
+
+// Put adds v, waiting for room. It returns false if the buffer is closed.
+func (b *boundedBuffer) Put(v int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.items) == b.size && !b.closed { // for, not if: see above
+		b.notFull.Wait()
+		if len(b.items) == b.size && !b.closed {
+			b.rewaits++
+		}
+	}
+	if b.closed {
+		return false
+	}
+	b.items = append(b.items, v)
+	b.notEmpty.Signal() // one item: one consumer can use it
+	return true
+}
+
+// Get takes the oldest item, waiting for one. It returns false once the
+// buffer is closed and empty: the items put before Close are still handed out.
+func (b *boundedBuffer) Get() (int, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.items) == 0 && !b.closed {
+		b.notEmpty.Wait()
+		if len(b.items) == 0 && !b.closed {
+			b.rewaits++
+		}
+	}
+	if len(b.items) == 0 {
+		return 0, false
+	}
+	v := b.items[0]
+	b.items = b.items[1:]
+	b.notFull.Signal()
+	return v, true
+}
+
+// Close wakes every waiter: Broadcast, since all of them must see closed,
+// where a Signal would wake one and leave the others asleep for ever.
+func (b *boundedBuffer) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.notFull.Broadcast()
+	b.notEmpty.Broadcast()
+}
+
+// syncCond runs 3 producers and 2 consumers over a buffer of 2 items, then a
+// start gate: workers waiting on a Cond until a Broadcast lets them all go.
 func syncCond() {
+	log := xlog.New(chapter, "syncCond")
+
+	buf := newBoundedBuffer(2)
+	const producers, perProducer, consumers = 3, 5, 2
+
+	var prodWG, consWG sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		prodWG.Add(1)
+		go func() {
+			defer prodWG.Done()
+			for i := 0; i < perProducer; i++ {
+				v := p*100 + i
+				buf.Put(v) // blocks while the 2 slots are taken
+				log.Info("put", "producer", p, "value", v)
+			}
+		}()
+	}
+
+	var mu sync.Mutex
+	sum, got := 0, 0
+	for c := 0; c < consumers; c++ {
+		consWG.Add(1)
+		go func() {
+			defer consWG.Done()
+			for {
+				v, ok := buf.Get()
+				if !ok {
+					return // closed and drained
+				}
+				log.Info("get", "consumer", c, "value", v)
+				time.Sleep(5 * time.Millisecond) // a slow consumer keeps the buffer full
+				mu.Lock()
+				sum += v
+				got++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	prodWG.Wait()
+	buf.Close() // the consumers drain what is left, then Get returns false
+	consWG.Wait()
+
+	want := 0
+	for p := 0; p < producers; p++ {
+		for i := 0; i < perProducer; i++ {
+			want += p*100 + i
+		}
+	}
+	log.Info("bounded buffer done", "items", got, "sum", sum, "want", want, "rewaits", buf.rewaits)
+
+	// Broadcast: a start gate. The workers wait for ready; one Broadcast
+	// releases them all. With a channel this is close(start), usable once; the
+	// Cond can be reset and broadcast again.
+	var gateMu sync.Mutex
+	gate := sync.NewCond(&gateMu)
+	ready := false
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gateMu.Lock()
+			for !ready {
+				gate.Wait()
+			}
+			gateMu.Unlock()
+			log.Info("released", "worker", i)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond) // let the workers reach Wait
+	log.Info("opening the gate")
+	gateMu.Lock()
+	ready = true // change the state under the lock, then wake
+	gateMu.Unlock()
+	gate.Broadcast()
+	wg.Wait()
 }
 
 // syncAtomic simulates concurrent visitors to a website using atomic operations
 // and displays the changing visitor count over time.
 func syncAtomic() {
+	log := xlog.New(chapter, "syncAtomic")
 	var visitorCount int32
 	var wg sync.WaitGroup
 	done := make(chan bool)
@@ -132,7 +319,7 @@ func syncAtomic() {
 			case <-done:
 				return
 			default:
-				fmt.Printf("Current visitors: %d\n", atomic.LoadInt32(&visitorCount))
+				log.Info("current visitors", "count", atomic.LoadInt32(&visitorCount))
 				time.Sleep(100 * time.Millisecond)
 			}
 		}
@@ -152,19 +339,21 @@ func syncAtomic() {
 
 	wg.Wait()
 	done <- true // a true value is sent on the done channel, the goroutine returns and terminates.
-	fmt.Printf("Final visitor count: %d\n", visitorCount)
+	log.Info("final visitors", "count", visitorCount)
 }
 
 func syncOnce() {
+	log := xlog.New(chapter, "syncOnce")
+
 	// sync.Once ensures that the function it wraps is executed only once,
 	// even if called multiple times concurrently.
 	var once sync.Once
 
 	// simulates a resource-intensive operation
 	expensiveOperation := func() {
-		fmt.Println("Performing expensive operation...")
+		log.Info("performing expensive operation...")
 		time.Sleep(2 * time.Second)
-		fmt.Println("Expensive operation completed.")
+		log.Info("expensive operation completed")
 	}
 	var wg sync.WaitGroup
 
@@ -174,22 +363,36 @@ func syncOnce() {
 		go func(id int) {
 			defer wg.Done()
 
-			fmt.Printf("Goroutine %d is trying to execute the operation\n", id)
+			log.Info("trying to execute the operation", "id", id)
 
 			// once.Do ensures the wrapped function is called only once
 			// across all goroutines
 			once.Do(expensiveOperation)
 
-			fmt.Printf("Goroutine %d has finished\n", id)
+			log.Info("finished", "id", id)
 		}(i)
 	}
 
 	wg.Wait()
-	fmt.Println("All goroutines have finished execution")
+	log.Info("all goroutines have finished execution")
+}
+
+// uniqueURLs returns urls without their duplicates, in the order they first
+// appear: Add of a set reports whether the URL is new.
+func uniqueURLs(urls []string) []string {
+	seen := set.New[string]()
+	unique := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if seen.Add(url) {
+			unique = append(unique, url)
+		}
+	}
+	return unique
 }
 
 func errGroup() error {
-	fmt.Println("==> errGroup")
+	log := xlog.New(chapter, "errGroup")
+
 	// Create a new context that we can cancel
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel() // Ensure all resources are freed at the end
@@ -198,15 +401,36 @@ func errGroup() error {
 	// This group will help us manage multiple goroutines and collect their errors
 	group, ctx := errgroup.WithContext(ctx)
 
-	// Slice of URLs we want to fetch
-	urls := []string{
+	// Slice of URLs we want to fetch. A list gathered from several places
+	// repeats itself: the duplicates are dropped first, in order, so no URL
+	// is fetched twice.
+	urls := uniqueURLs([]string{
 		"https://www.google.com",
 		"https://www.github.com",
+		"https://www.google.com",
 		"https://www.invalid-url-for-error-demo.com",
+	})
+
+	// Each goroutine writes the timings of its own request, at its own
+	// index: no lock needed (see fetchtrace for what is measured).
+	timings := make([]fetchtrace.Timings, len(urls))
+	for i, url := range urls {
+		timings[i].URL = url // stays as is for a request cancelled before it started
 	}
 
+	// Throttle the outbound requests: 2 per second, one at once. With a
+	// hundred URLs, starting them all together would look like an attack
+	// to the servers; Wait spaces them out, and ends early with ctx.
+	limiter := ratelimit.NewTokenBucket(2, 1)
+
+	// One client for every request, shared: its pool keeps the connections
+	// for the next request, and it retries a 503 or a reset connection
+	// after a backoff (see 14.http/client). http.DefaultClient would wait
+	// forever for a server that never answers; this one gives up after 10s.
+	hc := client.New(10 * time.Second)
+
 	// For each URL, start a goroutine to fetch it
-	for _, url := range urls {
+	for i, url := range urls {
 		url := url // Create a new variable to avoid closure problems
 
 		// Add a new goroutine to the group
@@ -216,24 +440,32 @@ func errGroup() error {
 			case <-ctx.Done():
 				return ctx.Err()
 			default:
-				// Make the HTTP GET request
-				resp, err := http.Get(url)
+				if err := limiter.Wait(ctx); err != nil {
+					return err // cancelled while waiting for its turn
+				}
+				// Make the HTTP GET request, traced
+				t, err := fetchtrace.Fetch(ctx, hc, url)
+				timings[i] = t
 				if err != nil {
 					return fmt.Errorf("failed to fetch %s: %v", url, err)
 				}
-				defer resp.Body.Close()
 
-				fmt.Printf("Successfully fetched %s, status: %s\n", url, resp.Status)
+				log.Info("fetched", "url", url, "status", t.Status, "ttfb", time.Duration(t.TTFB))
 				return nil
 			}
 		})
 	}
 
 	// Wait for all goroutines to complete and collect any error
-	if err := group.Wait(); err != nil {
+	err := group.Wait()
+	// the latency breakdown of every request, the failed and the cancelled
+	// ones included: where the time went before the error.
+	report, _ := json.MarshalIndent(timings, "", "  ")
+	fmt.Println(string(report))
+	if err != nil {
 		return fmt.Errorf("one of the goroutines failed: %v", err)
 	}
 
-	fmt.Println("All URLs were fetched successfully")
+	log.Info("all URLs were fetched successfully")
 	return nil
 }
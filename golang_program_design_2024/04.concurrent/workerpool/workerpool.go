@@ -0,0 +1,122 @@
+// Package workerpool runs tasks on a fixed number of goroutines. Submitting
+// more tasks than there are workers queues them; a full queue makes Submit
+// wait, so a fast producer cannot pile up unbounded work.
+//
+//	p := workerpool.New(4, 16)
+//	res, err := p.Submit(func() error { return fetch(url) })
+//	...
+//	err = <-res // the error of that task, nil if it succeeded
+//	p.Shutdown(ctx)
+//
+// Each task gets its own result channel, buffered: a result nobody reads
+// never blocks a worker. A task that panics does not kill its worker: the
+// panic becomes a *PanicError on the result channel.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// ErrClosed is returned by Submit once Shutdown has been called.
+var ErrClosed = errors.New("workerpool: pool is shut down")
+
+// PanicError is the result of a task that panicked.
+type PanicError struct {
+	Value any    // what was passed to panic
+	Stack []byte // the stack of the task when it panicked
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("workerpool: task panicked: %v", e.Value) }
+
+type task struct {
+	fn     func() error
+	result chan error
+}
+
+// Pool is a set of workers consuming a queue of tasks.
+type Pool struct {
+	tasks chan task
+	quit  chan struct{} // closed by Shutdown: wakes the Submits waiting for room
+	done  chan struct{} // closed when every worker has returned
+
+	mu     sync.RWMutex // Submit holds it to send, Shutdown to close tasks
+	closed bool
+	once   sync.Once
+}
+
+// New starts workers goroutines, with room for queue tasks waiting for one.
+func New(workers, queue int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{
+		tasks: make(chan task, queue),
+		quit:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range p.tasks { // until Shutdown closes tasks and the queue is drained
+				t.result <- run(t.fn)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(p.done)
+	}()
+	return p
+}
+
+// run calls fn, turning a panic into a *PanicError so the worker lives on.
+func run(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return fn()
+}
+
+// Submit queues fn and returns the channel its error will be sent on, once.
+// It waits while the queue is full, and returns ErrClosed after Shutdown.
+func (p *Pool) Submit(fn func() error) (<-chan error, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrClosed
+	}
+	t := task{fn: fn, result: make(chan error, 1)}
+	select {
+	case p.tasks <- t:
+		return t.result, nil
+	case <-p.quit:
+		return nil, ErrClosed
+	}
+}
+
+// Shutdown stops accepting tasks and waits for the queued and running ones
+// to finish. If ctx ends first it returns ctx.Err(); the workers still
+// finish what they have, and exit after. Calling Shutdown again waits again.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.once.Do(func() {
+		close(p.quit) // first, so a Submit waiting for room lets go of mu
+		p.mu.Lock()
+		p.closed = true
+		close(p.tasks)
+		p.mu.Unlock()
+	})
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,150 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+// TestBounded runs more tasks than workers: never more than 4 at once.
+func TestBounded(t *testing.T) {
+	p := New(4, 8)
+	defer p.Shutdown(context.Background())
+	var running, peak atomic.Int32
+	var results []<-chan error
+	for i := 0; i < 40; i++ {
+		res, err := p.Submit(func() error {
+			n := running.Add(1)
+			for {
+				m := peak.Load()
+				if n <= m || peak.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(2 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, res)
+	}
+	for _, res := range results {
+		if err := testutil.RequireRecv(t, res, time.Second); err != nil {
+			t.Error(err)
+		}
+	}
+	if n := peak.Load(); n > 4 || n < 2 {
+		t.Errorf("peak of %d tasks at once on 4 workers", n)
+	}
+}
+
+func TestResults(t *testing.T) {
+	p := New(2, 0)
+	defer p.Shutdown(context.Background())
+	boom := errors.New("boom")
+	res, _ := p.Submit(func() error { return boom })
+	if err := testutil.RequireRecv(t, res, time.Second); err != boom {
+		t.Errorf("result %v, want the task's error", err)
+	}
+
+	// a panic is a result, and the worker goes on.
+	res, _ = p.Submit(func() error { panic("boom") })
+	var perr *PanicError
+	if err := testutil.RequireRecv(t, res, time.Second); !errors.As(err, &perr) || perr.Value != "boom" || len(perr.Stack) == 0 {
+		t.Errorf("a panicking task: %v, want a *PanicError", err)
+	}
+	for i := 0; i < 4; i++ {
+		res, _ = p.Submit(func() error { return nil })
+		if err := testutil.RequireRecv(t, res, time.Second); err != nil {
+			t.Errorf("a task after the panic: %v", err)
+		}
+	}
+}
+
+// TestShutdown checks that the queued tasks run before Shutdown returns,
+// and that the pool takes no more.
+func TestShutdown(t *testing.T) {
+	p := New(2, 8)
+	var finished atomic.Int32
+	for i := 0; i < 8; i++ {
+		p.Submit(func() error {
+			time.Sleep(5 * time.Millisecond)
+			finished.Add(1)
+			return nil
+		})
+	}
+	if err := p.Shutdown(context.Background()); err != nil || finished.Load() != 8 {
+		t.Errorf("Shutdown = %v with %d of 8 tasks finished", err, finished.Load())
+	}
+	if _, err := p.Submit(func() error { return nil }); !errors.Is(err, ErrClosed) {
+		t.Errorf("Submit after Shutdown = %v, want ErrClosed", err)
+	}
+}
+
+// TestShutdownDeadline shuts down with a task outliving the context: it
+// returns at the deadline, and again once the task ends.
+func TestShutdownDeadline(t *testing.T) {
+	p := New(1, 1)
+	release := make(chan struct{})
+	p.Submit(func() error { <-release; return nil })
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown = %v, want the deadline", err)
+	}
+	close(release)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Errorf("the second Shutdown = %v", err)
+	}
+}
+
+// TestSubmitWaiting fills the pool: a Submit waiting for room is released
+// by Shutdown with ErrClosed.
+func TestSubmitWaiting(t *testing.T) {
+	p := New(1, 0)
+	block := make(chan struct{})
+	p.Submit(func() error { <-block; return nil })
+	submitted := make(chan error, 1)
+	go func() {
+		_, err := p.Submit(func() error { return nil })
+		submitted <- err
+	}()
+	testutil.RequireNoRecv(t, submitted, 20*time.Millisecond)
+
+	shut := make(chan error, 1)
+	go func() { shut <- p.Shutdown(context.Background()) }()
+	if err := testutil.RequireRecv(t, submitted, time.Second); !errors.Is(err, ErrClosed) {
+		t.Errorf("the waiting Submit = %v, want ErrClosed", err)
+	}
+	close(block)
+	if err := testutil.RequireRecv(t, shut, time.Second); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestNoLeak checks that every worker is gone after Shutdown, the panicking
+// and the blocked ones included.
+func TestNoLeak(t *testing.T) {
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		p := New(8, 4)
+		p.Submit(func() error { panic("boom") })
+		release := make(chan struct{})
+		p.Submit(func() error { <-release; return nil })
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		p.Shutdown(ctx) // returns early, the blocked task keeps its worker
+		cancel()
+		close(release)
+		if err := p.Shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testutil.RequireGoroutines(t, before, time.Second)
+}
@@ -0,0 +1,181 @@
+package stdlib
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+The go/parser package turns Go source text into an abstract syntax tree (AST),
+and the go/ast package describes the nodes of that tree.
+
+- token.FileSet: records position information (file, line, column) for every node.
+- parser.ParseFile: parses a single file and returns an *ast.File.
+- ast.Inspect: walks the tree depth-first, calling a function for every node.
+
+Here the input is this repo itself: every chapter file is parsed and the number
+of goroutine launches (`go f()`) and channel makes (`make(chan T)`) is counted,
+so the result can be compared against what you already know about the code.
+
+Usage (from the root of the repository; the lesson runs in this directory, so
+paths are relative to it):
+
+	go run ./cmd/learn run 05.standard_lib/ast                  // scan the parent directory (the whole tutorial)
+	go run ./cmd/learn run 05.standard_lib/ast ../04.concurrent
+*/
+
+func init() {
+	lessons.Register("05.standard_lib/ast", "go/parser and go/ast: count goroutines and channels in this repo", lessons.Checked(DemoAST))
+}
+
+// DemoAST parses an expression and a file, then counts the goroutines and
+// channels of the chapters under os.Args[1], .. by default.
+func DemoAST() error {
+	root := ".."
+	if len(os.Args) > 1 {
+		root = os.Args[1]
+	}
+
+	if err := parseExpr(); err != nil {
+		return err
+	}
+	if err := inspectFile(); err != nil {
+		return err
+	}
+
+	report, err := scanDir(root)
+	if err != nil {
+		return fmt.Errorf("scan failed: %w", err)
+	}
+	printReport(report)
+	return nil
+}
+
+// parseExpr shows the smallest unit: a single expression and its node types.
+func parseExpr() error {
+	expr, err := parser.ParseExpr(`make(chan int, 10)`)
+	if err != nil {
+		return fmt.Errorf("parse expr failed: %w", err)
+	}
+	// ast.Print dumps the full tree, useful when learning which node types to match.
+	ast.Print(nil, expr)
+
+	call := expr.(*ast.CallExpr) // type assertion, see 03.interface
+	fmt.Printf("func: %v, first arg: %T\n", call.Fun, call.Args[0])
+	return nil
+}
+
+// inspectFile parses source held in a string and prints every function declaration
+// together with its position, which comes from the FileSet, not from the node itself.
+func inspectFile() error {
+	src := `package demo
+
+func worker(done chan bool) { done <- true }
+
+func main() {
+	done := make(chan bool)
+	go worker(done)
+	<-done
+}`
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "demo.go", src, 0)
+	if err != nil {
+		return fmt.Errorf("parse file failed: %w", err)
+	}
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		if fn, ok := n.(*ast.FuncDecl); ok {
+			fmt.Printf("%s: func %s\n", fset.Position(fn.Pos()), fn.Name.Name)
+		}
+		return true // return false to skip the children of n.
+	})
+	return nil
+}
+
+// fileStats is the concurrency usage of a single source file.
+type fileStats struct {
+	Path       string
+	Goroutines int // number of `go` statements
+	ChanMakes  int // number of make(chan T) calls
+}
+
+// scanDir parses every .go file under root and collects the stats per file.
+func scanDir(root string) ([]fileStats, error) {
+	var report []fileStats
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && strings.HasPrefix(d.Name(), ".") && path != root {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", path, err)
+		}
+		stats := countConcurrency(file)
+		stats.Path = path
+		report = append(report, stats)
+		return nil
+	})
+	return report, err
+}
+
+// countConcurrency walks a single file and counts the nodes we are interested in.
+func countConcurrency(file *ast.File) fileStats {
+	var stats fileStats
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.GoStmt:
+			stats.Goroutines++
+		case *ast.CallExpr:
+			// make(chan T) is a call whose function is the identifier `make`
+			// and whose first argument is a channel type expression.
+			if ident, ok := node.Fun.(*ast.Ident); ok && ident.Name == "make" && len(node.Args) > 0 {
+				if _, ok := node.Args[0].(*ast.ChanType); ok {
+					stats.ChanMakes++
+				}
+			}
+		}
+		return true
+	})
+	return stats
+}
+
+func printReport(report []fileStats) {
+	// most concurrent files first, file path as tie breaker.
+	sort.Slice(report, func(i, j int) bool {
+		a, b := report[i], report[j]
+		if a.Goroutines+a.ChanMakes != b.Goroutines+b.ChanMakes {
+			return a.Goroutines+a.ChanMakes > b.Goroutines+b.ChanMakes
+		}
+		return a.Path < b.Path
+	})
+
+	var totalGo, totalChan int
+	fmt.Printf("\n%-50s %10s %10s\n", "FILE", "GOROUTINE", "MAKE CHAN")
+	for _, s := range report {
+		if s.Goroutines == 0 && s.ChanMakes == 0 {
+			continue
+		}
+		fmt.Printf("%-50s %10d %10d\n", s.Path, s.Goroutines, s.ChanMakes)
+		totalGo += s.Goroutines
+		totalChan += s.ChanMakes
+	}
+	fmt.Printf("%-50s %10d %10d\n", fmt.Sprintf("total (%d files)", len(report)), totalGo, totalChan)
+}
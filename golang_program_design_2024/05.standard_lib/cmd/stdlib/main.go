@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+)
+
+/*
+stdlib runs the lessons of chapter 05.standard_lib, in order. A lesson that
+fails does not stop the next ones; the exit status is 1 if any failed.
+Their cases are tests: go test ./golang_program_design_2024/05.standard_lib/...
+
+Usage (from the chapter directory: the ast lesson scans the parent
+directory):
+
+	cd golang_program_design_2024/05.standard_lib
+	go run ./cmd/stdlib
+
+One lesson alone, from the root: go run ./cmd/learn run 05.standard_lib/tls
+*/
+
+func main() {
+	stdlib.DemoJSON()
+	stdlib.DemoSerialization()
+	failed := 0
+	for _, demo := range []func() error{stdlib.DemoJSONFast, stdlib.DemoJSONStream, stdlib.DemoImage, stdlib.DemoTLS, stdlib.DemoSMTP, stdlib.DemoAST} {
+		if err := demo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,3 @@
+// Package stdlib is chapter 05.standard_lib: one package of the standard
+// library per file, each a lesson of its own.
+package stdlib
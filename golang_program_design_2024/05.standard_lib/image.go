@@ -0,0 +1,281 @@
+package stdlib
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+The image package describes pixels; the codecs in image/png, image/jpeg and
+image/gif read and write them.
+
+- image.Decode reads any format whose package was imported (even with _):
+  the codecs register themselves in init.
+- An image.Image only promises At(x, y) color.Color, which is slow: an
+  interface call and an allocation per pixel. The concrete types (*image.RGBA,
+  *image.Gray, ...) expose Pix, the bytes of the pixels row after row, Stride
+  bytes apart; fast code works on Pix directly.
+- Bounds() does not have to start at (0, 0): SubImage (a crop) shares the
+  pixels of its parent and keeps their coordinates.
+
+Filters that compute each output pixel from the input alone can split the
+image into horizontal bands, one goroutine per band, with no locking: every
+goroutine writes its own rows.
+
+image_test.go checks the results against golden hashes of their pixels (not
+of the encoded files, whose bytes may change between Go versions): any
+change to a filter that moves a single pixel fails it.
+
+Usage (from the root of the repository):
+
+	go run ./cmd/learn run 05.standard_lib/image
+*/
+
+func init() {
+	lessons.Register("05.standard_lib/image", "image codecs and working on Pix, serial and parallel", lessons.Checked(DemoImage))
+}
+
+// DemoImage encodes and decodes a test picture, filters it and benchmarks
+// the pixel loops.
+func DemoImage() error {
+	src := sourceImage(640, 480)
+	decoded, err := codecs(src)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("== filters")
+	for _, r := range filtered(toRGBA(decoded), runtime.NumCPU()) {
+		b := r.img.Bounds()
+		fmt.Printf("%-10s %4dx%-4d pixels %s\n", r.name, b.Dx(), b.Dy(), pixelHash(pix(r.img)))
+	}
+	fmt.Println()
+	imageBenchmarks(src)
+	return nil
+}
+
+// sourceImage draws a deterministic test picture: gradients and a disc.
+func sourceImage(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: uint8(x * 255 / w), G: uint8(y * 255 / h), B: uint8((x + y) % 256), A: 255}
+			dx, dy := x-w/2, y-h/2
+			if dx*dx+dy*dy < (h/3)*(h/3) {
+				c = color.RGBA{R: 250, G: 200, B: 40, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// codecs writes the picture as PNG and JPEG, reads both back and returns the
+// PNG one: lossless, so identical to src.
+func codecs(src *image.RGBA) (image.Image, error) {
+	fmt.Println("== decode and encode")
+	var pngBuf, jpegBuf bytes.Buffer
+	if err := png.Encode(&pngBuf, src); err != nil {
+		return nil, err
+	}
+	if err := jpeg.Encode(&jpegBuf, src, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+
+	var fromPNG image.Image
+	for _, c := range []struct {
+		name string
+		data []byte
+	}{{"png", pngBuf.Bytes()}, {"jpeg", jpegBuf.Bytes()}} {
+		img, format, err := image.Decode(bytes.NewReader(c.data))
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("%-4s %7d bytes, decoded as %s %T, mean error %.2f\n",
+			c.name, len(c.data), format, img, meanError(src, img))
+		if format == "png" {
+			fromPNG = img
+		}
+	}
+	fmt.Println()
+	return fromPNG, nil
+}
+
+// meanError is the mean absolute difference of the channels, 0 for lossless.
+func meanError(a, b image.Image) float64 {
+	var sum, n float64
+	r := a.Bounds()
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			r1, g1, b1, _ := a.At(x, y).RGBA()
+			r2, g2, b2, _ := b.At(x, y).RGBA()
+			sum += absDiff(r1, r2) + absDiff(g1, g2) + absDiff(b1, b2)
+			n += 3
+		}
+	}
+	return sum / n / 257 // RGBA() returns 16-bit values
+}
+
+func absDiff(a, b uint32) float64 {
+	if a > b {
+		return float64(a - b)
+	}
+	return float64(b - a)
+}
+
+// toRGBA converts any image to *image.RGBA starting at (0, 0), the one
+// layout the filters below handle.
+func toRGBA(img image.Image) *image.RGBA {
+	if rgba, ok := img.(*image.RGBA); ok && rgba.Rect.Min == (image.Point{}) {
+		return rgba
+	}
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+	return out
+}
+
+// bands runs fn over the rows [0, h) split in n bands, concurrently.
+func bands(h, n int, fn func(y0, y1 int)) {
+	if n <= 1 {
+		fn(0, h)
+		return
+	}
+	var wg sync.WaitGroup
+	step := (h + n - 1) / n
+	for y0 := 0; y0 < h; y0 += step {
+		wg.Add(1)
+		go func(y0, y1 int) {
+			defer wg.Done()
+			fn(y0, y1)
+		}(y0, min(y0+step, h))
+	}
+	wg.Wait()
+}
+
+// grayscale uses the integer form of the ITU-R BT.601 weights, the same as
+// color.GrayModel: green counts most, blue least.
+func grayscale(src *image.RGBA, workers int) *image.Gray {
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewGray(image.Rect(0, 0, w, h))
+	bands(h, workers, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			in := src.Pix[y*src.Stride : y*src.Stride+w*4]
+			out := dst.Pix[y*dst.Stride : y*dst.Stride+w]
+			for x := range out {
+				r, g, b := uint32(in[x*4]), uint32(in[x*4+1]), uint32(in[x*4+2])
+				out[x] = uint8((19595*r + 38470*g + 7471*b + 1<<15) >> 16)
+			}
+		}
+	})
+	return dst
+}
+
+// resize scales src to w x h with bilinear interpolation: each output pixel
+// is the weighted mean of the four input pixels around its position. Fixed
+// point (8 fractional bits) keeps the result exact on every machine.
+func resize(src *image.RGBA, w, h, workers int) *image.RGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	bands(h, workers, func(y0, y1 int) {
+		for y := y0; y < y1; y++ {
+			// the centre of the output pixel, mapped into src, in 1/256ths.
+			fy := max(0, ((2*y+1)*sh*256/(2*h))-128)
+			sy, wy := fy>>8, fy&0xff
+			sy1 := min(sy+1, sh-1)
+			for x := 0; x < w; x++ {
+				fx := max(0, ((2*x+1)*sw*256/(2*w))-128)
+				sx, wx := fx>>8, fx&0xff
+				sx1 := min(sx+1, sw-1)
+				p00 := src.Pix[sy*src.Stride+sx*4:]
+				p01 := src.Pix[sy*src.Stride+sx1*4:]
+				p10 := src.Pix[sy1*src.Stride+sx*4:]
+				p11 := src.Pix[sy1*src.Stride+sx1*4:]
+				out := dst.Pix[y*dst.Stride+x*4:]
+				for c := 0; c < 4; c++ {
+					top := int(p00[c])*(256-wx) + int(p01[c])*wx
+					bottom := int(p10[c])*(256-wx) + int(p11[c])*wx
+					out[c] = uint8((top*(256-wy) + bottom*wy + 1<<15) >> 16)
+				}
+			}
+		}
+	})
+	return dst
+}
+
+// crop returns a copy of r; SubImage alone would share the pixels and keep
+// the coordinates of src.
+func crop(src *image.RGBA, r image.Rectangle) *image.RGBA {
+	return toRGBA(src.SubImage(r))
+}
+
+func pixelHash(pix []byte) string {
+	sum := sha256.Sum256(pix)
+	return hex.EncodeToString(sum[:8])
+}
+
+// named is an image with the name of the filters that made it.
+type named struct {
+	name string
+	img  image.Image
+}
+
+// filtered returns src and the results of the filters on it, run on
+// workers bands.
+func filtered(src *image.RGBA, workers int) []named {
+	return []named{
+		{"source", src},
+		{"grayscale", grayscale(src, workers)},
+		{"resize", resize(src, 160, 120, workers)},
+		{"crop", crop(src, image.Rect(220, 140, 420, 340))},
+		// crop the disc, shrink it, make it gray: the filters chained.
+		{"pipeline", grayscale(resize(crop(src, image.Rect(160, 80, 480, 400)), 64, 64, workers), workers)},
+	}
+}
+
+// pix returns the pixels of the image types of the filters.
+func pix(img image.Image) []byte {
+	switch img := img.(type) {
+	case *image.Gray:
+		return img.Pix
+	case *image.RGBA:
+		return img.Pix
+	}
+	return nil
+}
+
+func imageBenchmarks(src *image.RGBA) {
+	fmt.Printf("== serial vs parallel, %d CPUs (testing.Benchmark)\n", runtime.NumCPU())
+	big := resize(src, 1920, 1440, runtime.NumCPU())
+	t := benchtools.NewTable(os.Stdout)
+	for _, c := range []struct {
+		name string
+		fn   func(workers int)
+	}{
+		{"grayscale 1920x1440", func(n int) { grayscale(big, n) }},
+		{"resize to 800x600", func(n int) { resize(big, 800, 600, n) }},
+	} {
+		// with a single CPU the bands only add the cost of the goroutines.
+		counts := []int{1, 4}
+		if runtime.NumCPU() > 4 {
+			counts = append(counts, runtime.NumCPU())
+		}
+		for _, workers := range counts {
+			t.Add(benchtools.Loop(fmt.Sprintf("%s, %d bands", c.name, workers), func() { c.fn(workers) }))
+		}
+		t.Flush()
+	}
+}
@@ -0,0 +1,103 @@
+package stdlib
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// the hashes of the pixels of every result, computed once and checked since.
+var goldenHashes = map[string]string{
+	"source":    "204ab10376c01c3c",
+	"grayscale": "c04b39d565a88858",
+	"resize":    "a12a920214d29cbe",
+	"crop":      "a10cf3cfdf0d2c72",
+	"pipeline":  "ddf74b9386b00a07",
+}
+
+func TestGolden(t *testing.T) {
+	for _, r := range filtered(sourceImage(640, 480), 4) {
+		if got := pixelHash(pix(r.img)); got != goldenHashes[r.name] {
+			t.Errorf("%s: pixels %s, golden %s", r.name, got, goldenHashes[r.name])
+		}
+	}
+}
+
+// TestBands splits the filters in bands of every size: the pixels are
+// those of the serial loop.
+func TestBands(t *testing.T) {
+	src := sourceImage(640, 480)
+	gray, resized := grayscale(src, 1), resize(src, 333, 250, 1)
+	for _, workers := range []int{2, 3, 7, 16, 480, 1000} {
+		if !bytes.Equal(gray.Pix, grayscale(src, workers).Pix) {
+			t.Errorf("grayscale in %d bands differs", workers)
+		}
+		if !bytes.Equal(resized.Pix, resize(src, 333, 250, workers).Pix) {
+			t.Errorf("resize in %d bands differs", workers)
+		}
+	}
+}
+
+func TestCodecs(t *testing.T) {
+	src := sourceImage(64, 48)
+	var pngBuf, jpegBuf bytes.Buffer
+	png.Encode(&pngBuf, src)
+	jpeg.Encode(&jpegBuf, src, &jpeg.Options{Quality: 85})
+	for _, tt := range []struct {
+		data     []byte
+		format   string
+		maxError float64
+	}{
+		{pngBuf.Bytes(), "png", 0}, // lossless
+		{jpegBuf.Bytes(), "jpeg", 8},
+	} {
+		img, format, err := image.Decode(bytes.NewReader(tt.data))
+		if err != nil || format != tt.format {
+			t.Errorf("Decode: %s, %v; want %s", format, err, tt.format)
+			continue
+		}
+		if e := meanError(src, img); e > tt.maxError {
+			t.Errorf("%s: mean error %.2f, want at most %v", format, e, tt.maxError)
+		}
+	}
+}
+
+func TestGrayscale(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 1))
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {200, 100, 50, 255}}
+	for x, c := range colors {
+		src.SetRGBA(x, 0, c)
+	}
+	gray := grayscale(src, 1)
+	for x, c := range colors {
+		if want := color.GrayModel.Convert(c).(color.Gray); gray.GrayAt(x, 0) != want {
+			t.Errorf("%v: %v, want %v as color.GrayModel", c, gray.GrayAt(x, 0), want)
+		}
+	}
+}
+
+func TestCrop(t *testing.T) {
+	src := sourceImage(64, 48)
+	r := image.Rect(10, 20, 30, 25)
+	c := crop(src, r)
+	if c.Bounds() != image.Rect(0, 0, 20, 5) {
+		t.Fatalf("bounds %v, want from (0, 0)", c.Bounds())
+	}
+	if c.RGBAAt(0, 0) != src.RGBAAt(10, 20) || c.RGBAAt(19, 4) != src.RGBAAt(29, 24) {
+		t.Error("the pixels moved")
+	}
+	c.Pix[0]++ // a copy: src unchanged
+	if c.RGBAAt(0, 0) == src.RGBAAt(10, 20) {
+		t.Error("crop shares the pixels of src")
+	}
+}
+
+func TestResizeSame(t *testing.T) {
+	src := sourceImage(64, 48)
+	if got := resize(src, 64, 48, 1); !bytes.Equal(got.Pix, src.Pix) {
+		t.Error("a resize to the same size changed the pixels")
+	}
+}
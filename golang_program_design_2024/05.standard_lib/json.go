@@ -1,11 +1,17 @@
-package main
+package stdlib
 
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
 	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib/jsonstore"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
 )
 
 /*
@@ -21,7 +27,12 @@ Purpose:
 
 Serialization and deserialization ensure data integrity and consistency during storage, transmission, and recovery across different systems.
 */
-func main() {
+func init() {
+	lessons.Register("05.standard_lib/json", "encoding/json: marshal, tags, custom marshalers, streams", DemoJSON)
+}
+
+// DemoJSON runs the encoding/json demos.
+func DemoJSON() {
 	marshaling()
 	structTagTest()
 	unmarshaling()
@@ -31,9 +42,12 @@ func main() {
 	decodeJson()
 }
 
+// chapter is the value of the "chapter" field of every log record in this file.
+const chapter = "05.standard_lib"
+
 func jsonM(data interface{}) {
 	if jsonData, err := json.Marshal(data); err != nil {
-		log.Printf("marshaling failed: %s", err)
+		xlog.New(chapter, "jsonM").Error("marshaling failed", "err", err)
 	} else {
 		fmt.Println(string(jsonData))
 	}
@@ -63,11 +77,13 @@ func marshaling() {
 }
 
 // Struct tags provide metadata for struct fields to control JSON serialization behavior.
+// A field can have several, one per package reading them: validate is read
+// by 13.reflection/validate.
 type User struct {
-	Name         string   `json:"name"`
-	Biographical string   `json:"bio,omitempty"` // the "omitempty" option excludes empty fields from JSON serialization.
-	Password     string   `json:"-"`             // the "-" tag tells json.Marshal to ignore this field.
-	Email        []string `json:"email"`
+	Name         string   `json:"name" validate:"required,min=3,max=50"`
+	Biographical string   `json:"bio,omitempty" validate:"max=200"` // the "omitempty" option excludes empty fields from JSON serialization.
+	Password     string   `json:"-" validate:"required,min=8"`      // the "-" tag tells json.Marshal to ignore this field.
+	Email        []string `json:"email" validate:"max=5,dive,email"`
 }
 
 func structTagTest() {
@@ -84,7 +100,7 @@ func structTagTest() {
 
 func jsonUnm(str string, v any) {
 	if err := json.Unmarshal([]byte(str), v); err != nil {
-		log.Printf("unmarshaling failed: %s", err)
+		xlog.New(chapter, "jsonUnm").Error("unmarshaling failed", "err", err)
 	} else {
 		fmt.Printf("%#v\n", v)
 	}
@@ -129,6 +145,8 @@ type UserError struct {
 }
 
 func marshalError() {
+	log := xlog.New(chapter, "marshalError")
+
 	// marshaling error
 	u1 := UserError{
 		Name: "Alice",
@@ -137,7 +155,7 @@ func marshalError() {
 	}
 	bytes, err := json.Marshal(u1)
 	if err != nil {
-		log.Printf("marshaling failed: %v", err)
+		log.Error("marshaling failed", "err", err)
 	}
 
 	// unmarshaling error
@@ -147,7 +165,7 @@ func marshalError() {
 	var u2 UserError
 	err = json.Unmarshal(data, &u2)
 	if err != nil {
-		// log.Printf("unmarshaling failed: %v", err)
+		// log.Error("unmarshaling failed", "err", err)
 	}
 	fmt.Printf("%+v\n", u2)
 }
@@ -183,29 +201,58 @@ func custom() {
 
 // JSON data can be directly written to any object that implements the io.Writer interface,
 // meaning that JSON data can be directly encoded to files, network connections, and more.
+// jsonstore.Write is json.NewEncoder(w).Encode(v); jsonstore.Save writes a file atomically.
 func encodeJson() {
+	log := xlog.New(chapter, "encodeJson")
 	users := []UserError{
 		{Name: "Alice", Age: 30},
 		{Name: "Bob", Age: 25},
 	}
-	file, _ := os.Create("users.json")
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	if err := encoder.Encode(users); err != nil {
-		log.Fatalf("encode error: %v", err)
+	// any io.Writer: the standard output here, a bytes.Buffer in a test.
+	if err := jsonstore.Write(os.Stdout, users); err != nil {
+		log.Error("encode failed", "err", err)
 	}
 
+	// a file: in a directory of its own, not the working directory.
+	dir, err := os.MkdirTemp("", "users-*")
+	if err != nil {
+		log.Error("temp dir", "err", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "users.json")
+	if err := jsonstore.Save(name, users, 0o644); err != nil {
+		log.Error("save failed", "err", err)
+		return
+	}
+	// a value that cannot be encoded: an error, and the file left as it was.
+	err = jsonstore.Save(name, map[string]any{"users": users, "done": make(chan int)}, 0o644)
+	fmt.Println("save of a channel:", err)
+	data, _ := os.ReadFile(name)
+	fmt.Printf("%s still holds %s", filepath.Base(name), data)
 }
 
 // json.Decoder can read JSON data directly from any object that implements the io.Reader interface, seeking and parsing JSON objects and arrays.
+// jsonstore.Load reads a file of an fs.FS: os.DirFS for the disk, a fstest.MapFS here.
+// Decode reads a whole value; json_stream.go reads a large array one element at a time.
 func decodeJson() {
-	file, _ := os.Open("users.json")
+	fsys := fstest.MapFS{
+		"users.json":  {Data: []byte(`[{"Name":"Alice","Age":30},{"Name":"Bob","Age":25}]`)},
+		"broken.json": {Data: []byte(`[{"Name":"Alice","Age":30},`)},
+	}
 
 	var u []UserError
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&u); err != nil {
-		log.Fatalf("decode error: %v", err)
+	if err := jsonstore.Load(fsys, "users.json", &u); err != nil {
+		xlog.New(chapter, "decodeJson").Error("decode failed", "err", err)
 	}
 	fmt.Println(u)
+
+	// the errors are returned: the caller decides, the program goes on.
+	for _, name := range []string{"broken.json", "missing.json"} {
+		var u []UserError
+		fmt.Println(jsonstore.Load(fsys, name, &u))
+	}
+	var u2 []UserError
+	fmt.Println(jsonstore.Read(strings.NewReader(`[] []`), &u2))
 }
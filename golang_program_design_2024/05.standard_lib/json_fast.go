@@ -0,0 +1,304 @@
+package stdlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+json.Marshal is convenient and slow on a hot path: it looks the type up by
+reflection (cached after the first call), walks the value field by field
+through interfaces, and returns a new []byte each call. At a few hundred
+thousand events a second, the allocations alone keep the GC busy.
+
+Three ways to do better on one struct, from the least to the most work:
+
+- json.NewEncoder on a pooled bytes.Buffer: the same reflection, but the
+  output buffer is reused instead of allocated per call.
+- easyjson-style generated code: a tool (github.com/mailru/easyjson) reads
+  the struct and writes a MarshalJSON method with no reflection, on a
+  buffer type of its own. The method below is written by hand in the shape
+  that generated code takes. Careful: json.Marshal calls MarshalJSON, then
+  re-parses its output to validate and compact it, losing part of the gain;
+  call the method directly.
+- an append-style encoder, func(dst []byte, v) []byte like strconv.Append*:
+  the caller owns the buffer and reuses it, zero allocations per call.
+
+All of them must produce exactly the bytes of json.Marshal, including its
+escaping of <, > and & and its float formatting: checked before measuring.
+The price is code to maintain, kept in sync with the struct by hand or by a
+generator: worth it for one or two hot types, not for every type.
+
+Usage (from the root of the repository):
+
+	go run ./cmd/learn run 05.standard_lib/json_fast
+*/
+
+type Event struct {
+	ID    int64     `json:"id"`
+	Type  string    `json:"type"`
+	User  string    `json:"user"`
+	Time  time.Time `json:"time"`
+	Score float64   `json:"score"`
+	Tags  []string  `json:"tags,omitempty"`
+}
+
+var event = Event{
+	ID:    1234567,
+	Type:  "page_view",
+	User:  "alice <alice@example.com>",
+	Time:  time.Date(2024, 5, 1, 12, 30, 45, 123456789, time.UTC),
+	Score: 0.875,
+	Tags:  []string{"mobile", "eu-west", "a/b test"},
+}
+
+func init() {
+	lessons.Register("05.standard_lib/json_fast", "faster JSON: pooled encoder, generated code, append by hand", lessons.Checked(DemoJSONFast))
+}
+
+// DemoJSONFast checks that the three encoders write the bytes of
+// json.Marshal, then benchmarks them. The error says they did not.
+func DemoJSONFast() error {
+	if !checkEncoders() {
+		return errors.New("json_fast: output differs from json.Marshal")
+	}
+	benchJSON()
+	return nil
+}
+
+// --- 1. encoder on a pooled buffer ---
+
+var bufPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// encodePooled writes e to w through a pooled buffer. The buffer must not be
+// kept after it is returned to the pool, so the result is written out
+// rather than returned.
+func encodePooled(w func([]byte), e *Event) error {
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer bufPool.Put(buf)
+	buf.Reset()
+	if err := json.NewEncoder(buf).Encode(e); err != nil {
+		return err
+	}
+	w(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))) // Encode adds a newline
+	return nil
+}
+
+// --- 2. easyjson-style generated code ---
+
+// jwriter is the buffer the generated code writes to, like easyjson's
+// jwriter.Writer.
+type jwriter struct{ buf []byte }
+
+func (w *jwriter) RawString(s string) { w.buf = append(w.buf, s...) }
+func (w *jwriter) RawByte(c byte)     { w.buf = append(w.buf, c) }
+func (w *jwriter) Int64(n int64)      { w.buf = strconv.AppendInt(w.buf, n, 10) }
+func (w *jwriter) Float64(f float64)  { w.buf = appendFloat(w.buf, f) }
+func (w *jwriter) String(s string)    { w.buf = appendString(w.buf, s) }
+func (w *jwriter) Time(t time.Time) {
+	w.buf = append(w.buf, '"')
+	w.buf = t.AppendFormat(w.buf, time.RFC3339Nano)
+	w.buf = append(w.buf, '"')
+}
+
+// generatedEvent has the generated methods; a type of its own, so json.Marshal
+// of a plain Event still goes through reflection.
+type generatedEvent Event
+
+// MarshalEasyJSON is what a generator writes for Event: one call per field,
+// the keys as constants, no reflection.
+func (v *generatedEvent) MarshalEasyJSON(out *jwriter) {
+	out.RawString(`{"id":`)
+	out.Int64(v.ID)
+	out.RawString(`,"type":`)
+	out.String(v.Type)
+	out.RawString(`,"user":`)
+	out.String(v.User)
+	out.RawString(`,"time":`)
+	out.Time(v.Time)
+	out.RawString(`,"score":`)
+	out.Float64(v.Score)
+	if len(v.Tags) != 0 {
+		out.RawString(`,"tags":[`)
+		for i, t := range v.Tags {
+			if i > 0 {
+				out.RawByte(',')
+			}
+			out.String(t)
+		}
+		out.RawByte(']')
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON implements json.Marshaler.
+func (v generatedEvent) MarshalJSON() ([]byte, error) {
+	w := jwriter{buf: make([]byte, 0, 256)}
+	v.MarshalEasyJSON(&w)
+	return w.buf, nil
+}
+
+// --- 3. append-style encoder ---
+
+// appendEvent appends the JSON of e to dst, like json.Marshal would write it.
+func appendEvent(dst []byte, e *Event) []byte {
+	dst = append(dst, `{"id":`...)
+	dst = strconv.AppendInt(dst, e.ID, 10)
+	dst = append(dst, `,"type":`...)
+	dst = appendString(dst, e.Type)
+	dst = append(dst, `,"user":`...)
+	dst = appendString(dst, e.User)
+	dst = append(dst, `,"time":"`...)
+	dst = e.Time.AppendFormat(dst, time.RFC3339Nano)
+	dst = append(dst, `","score":`...)
+	dst = appendFloat(dst, e.Score)
+	if len(e.Tags) != 0 {
+		dst = append(dst, `,"tags":[`...)
+		for i, t := range e.Tags {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendString(dst, t)
+		}
+		dst = append(dst, ']')
+	}
+	return append(dst, '}')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendString appends s quoted the way encoding/json quotes it: control
+// characters, <, > and & as \u00XX, invalid UTF-8 replaced by U+FFFD, and
+// U+2028 and U+2029, which break JavaScript, escaped.
+func appendString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				dst = append(dst, '\\', c)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// appendFloat formats f as encoding/json does: like ES6, plain notation
+// unless the exponent is very small or very large, and then e-7 rather
+// than e-07. NaN and infinities have no JSON form; json.Marshal fails on
+// them, the caller must not pass them.
+func appendFloat(dst []byte, f float64) []byte {
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+// --- checks and benchmarks ---
+
+func checkEncoders() bool {
+	fmt.Println("== same bytes as json.Marshal")
+	cases := []Event{event, {}, {
+		ID:    -1,
+		Type:  "quote\" back\\slash \n\t\x01 <b>&amp;",
+		User:  "bad utf8 \xff line sep \u2028 é 日本",
+		Score: 1e-7,
+		Tags:  []string{},
+	}, {Score: 1e21}, {Score: -123456.789}}
+	failed := false
+	for i, e := range cases {
+		want, err := json.Marshal(e)
+		if err != nil {
+			panic(err)
+		}
+		var pooled []byte
+		if err := encodePooled(func(b []byte) { pooled = append([]byte(nil), b...) }, &e); err != nil {
+			panic(err)
+		}
+		generated, _ := generatedEvent(e).MarshalJSON()
+		for name, got := range map[string][]byte{
+			"encoder": pooled, "generated": generated, "append": appendEvent(nil, &e),
+		} {
+			if !bytes.Equal(got, want) {
+				failed = true
+				fmt.Printf("case %d, %s:\n  got  %s\n  want %s\n", i, name, got, want)
+			}
+		}
+	}
+	if failed {
+		return false
+	}
+	fmt.Printf("%d cases, 3 encoders: identical\n", len(cases))
+	return true
+}
+
+var sinkBytes []byte
+
+func benchJSON() {
+	fmt.Println("== speed (testing.Benchmark)")
+	gen := generatedEvent(event)
+	var buf []byte
+	t := benchtools.NewTable(os.Stdout)
+	t.Add(benchtools.Loop("json.Marshal", func() { sinkBytes, _ = json.Marshal(&event) }))
+	t.Add(benchtools.Loop("encoder + pool", func() { encodePooled(func(b []byte) { sinkBytes = b }, &event) }))
+	t.Add(benchtools.Loop("generated", func() { sinkBytes, _ = gen.MarshalJSON() }))
+	t.Add(benchtools.Loop("Marshal(generated)", func() { sinkBytes, _ = json.Marshal(gen) }))
+	t.Add(benchtools.Loop("append, reused", func() { buf = appendEvent(buf[:0], &event); sinkBytes = buf }))
+	t.Flush()
+}
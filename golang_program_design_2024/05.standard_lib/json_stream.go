@@ -0,0 +1,476 @@
+package stdlib
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+json.Unmarshal needs the whole document in memory, and builds the whole
+value: a 2 GB export is 2 GB of bytes, then more of structs. A
+json.Decoder reads from an io.Reader and can go one value at a time:
+
+	dec.Token()   the next token: json.Delim for [ ] { }, then string,
+	              float64, json.Number, bool or nil for the rest
+	dec.More()    whether the array or object being read has another element
+	dec.Decode(v) the next whole value, into v
+
+	dec.Token()                // [
+	for dec.More() {
+		var o Sale
+		dec.Decode(&o)         // one element, then the next
+	}
+	dec.Token()                // ]
+
+The memory is the decoder's buffer and one element, whatever the length of
+the array.
+
+NDJSON (newline-delimited JSON, also JSON Lines) puts one value per line,
+no enclosing array: logs, exports, a stream over HTTP. A writer appends a
+line, a reader can start anywhere after a newline, a broken line is
+reported with its number. json.Encoder.Encode already ends each value with
+a newline.
+
+json.RawMessage is a []byte of JSON kept as it is, decoded later or never:
+the "data" of an envelope whose "type" says what it is, a value skipped,
+a field passed on byte for byte.
+*/
+
+func init() {
+	lessons.Register("05.standard_lib/json_stream", "streaming JSON: Token and More, NDJSON, RawMessage, 1M records in constant memory", lessons.Checked(DemoJSONStream))
+}
+
+// DemoJSONStream decodes streams element by element, then measures the
+// memory of a 1M-record stream; json_stream_test.go has the cases.
+func DemoJSONStream() error {
+	for _, demo := range []func() error{tokenWalk, ndjsonLines, rawEnvelopes, streamMillion} {
+		if err := demo(); err != nil {
+			return err
+		}
+	}
+	benchStream()
+	return nil
+}
+
+// Sale is one element of the streams of this file.
+type Sale struct {
+	ID     int64   `json:"id"`
+	User   string  `json:"user"`
+	Amount float64 `json:"amount"`
+}
+
+// expectDelim reads the next token of dec, which must be want.
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("expected %v, got %v", want, tok)
+	}
+	return nil
+}
+
+// decodeArray calls fn for each element of the JSON array at the current
+// position of dec, decoded one at a time. fn must not keep o: it is
+// reused for the next element.
+func decodeArray(dec *json.Decoder, fn func(o *Sale) error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	var o Sale
+	for dec.More() {
+		o = Sale{} // Decode leaves the fields an element lacks as they were
+		if err := dec.Decode(&o); err != nil {
+			return err
+		}
+		if err := fn(&o); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+// streamSales calls fn for each element of the JSON array of r.
+func streamSales(r io.Reader, fn func(o *Sale) error) error {
+	dec := json.NewDecoder(r)
+	if err := decodeArray(dec, fn); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("data after the array")
+	}
+	return nil
+}
+
+// salesPage streams the "sales" array of a page of results, wherever
+// it is among the keys, and returns the other fields undecoded.
+func salesPage(r io.Reader, fn func(o *Sale) error) (map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	rest := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token() // a key: the tokens of an object alternate
+		if err != nil {
+			return nil, err
+		}
+		key := tok.(string)
+		if key == "sales" {
+			if err := decodeArray(dec, fn); err != nil {
+				return nil, fmt.Errorf("sales: %w", err)
+			}
+			continue
+		}
+		var raw json.RawMessage // any value, read whole and kept as bytes
+		if err := dec.Decode(&raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		rest[key] = raw
+	}
+	return rest, expectDelim(dec, '}')
+}
+
+// tokens returns the tokens of the JSON document doc, each as its type
+// and value.
+func tokens(doc string) ([]string, error) {
+	dec := json.NewDecoder(strings.NewReader(doc))
+	var toks []string
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return toks, nil
+		}
+		if err != nil {
+			return toks, err
+		}
+		toks = append(toks, fmt.Sprintf("%T(%v)", tok, tok))
+	}
+}
+
+func tokenWalk() error {
+	fmt.Println("== Token and More")
+	toks, err := tokens(`{"page": 2, "tags": ["a", true, null], "price": 1.5}`)
+	if err != nil {
+		return err
+	}
+	fmt.Println(strings.Join(toks, " "))
+
+	var ids []int64
+	page := `{"page": 2, "sales": [{"id": 1, "amount": 9.5}, {"id": 2, "user": "bob"}], "next": {"cursor": "b2"}}`
+	rest, err := salesPage(strings.NewReader(page), func(o *Sale) error {
+		ids = append(ids, o.ID)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("the sales of a page, one at a time: %v; the other fields, raw: next %s\n", ids, rest["next"])
+
+	err = streamSales(strings.NewReader(`[{"id": 1}, {"id": "two"}]`), func(*Sale) error { return nil })
+	fmt.Println("an element of the wrong type:", err)
+	err = streamSales(strings.NewReader(`{"id": 1}`), func(*Sale) error { return nil })
+	fmt.Printf("not an array: %v\n\n", err)
+	return nil
+}
+
+// writeNDJSON writes one sale per line.
+func writeNDJSON(w io.Writer, sales []Sale) error {
+	enc := json.NewEncoder(w) // Encode ends each value with "\n"
+	for i := range sales {
+		if err := enc.Encode(&sales[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readNDJSON calls fn for each line of r, decoded; blank lines are
+// skipped. A line is limited to the 64 KB of a bufio.Scanner, see
+// 17.io/scanner to raise it.
+func readNDJSON(r io.Reader, fn func(o *Sale) error) error {
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var o Sale
+		if err := json.Unmarshal(sc.Bytes(), &o); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := fn(&o); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+func ndjsonLines() error {
+	fmt.Println("== NDJSON")
+	sales := []Sale{{1, "alice", 9.5}, {2, "bob <b@example.com>", 20}, {3, "", 0.25}}
+	var buf bytes.Buffer
+	if err := writeNDJSON(&buf, sales); err != nil {
+		return err
+	}
+	fmt.Print(buf.String())
+
+	broken := "{\"id\": 1}\n\n{\"id\": 2, \"user\": \n{\"id\": 3}\n"
+	err := readNDJSON(strings.NewReader(broken), func(*Sale) error { return nil })
+	fmt.Println("a broken line, by its number:", err)
+
+	// a Decoder reads NDJSON too, and any whitespace between the values: a
+	// value over two lines, or two on one, pass where readNDJSON would stop.
+	dec := json.NewDecoder(strings.NewReader("{\"id\": 1} {\"id\":\n2}\n"))
+	n := 0
+	for {
+		var o Sale
+		if err := dec.Decode(&o); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		n++
+	}
+	fmt.Printf("json.Decoder: values, not lines: %d sales\n\n", n)
+	return nil
+}
+
+// envelope is a message whose type says how to decode its data.
+type envelope struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type refund struct {
+	Sale   int64   `json:"sale"`
+	Amount float64 `json:"amount"`
+}
+
+// envelopes sums the sales minus the refunds of the stream of envelopes
+// r, and returns the data of the other types as it is.
+func envelopes(r io.Reader) (float64, []json.RawMessage, error) {
+	var total float64
+	var unknown []json.RawMessage
+	dec := json.NewDecoder(r)
+	for dec.More() {
+		var env envelope
+		if err := dec.Decode(&env); err != nil {
+			return total, unknown, err
+		}
+		switch env.Type { // the data decoded once its type is known
+		case "sale":
+			var o Sale
+			if err := json.Unmarshal(env.Data, &o); err != nil {
+				return total, unknown, fmt.Errorf("sale: %w", err)
+			}
+			total += o.Amount
+		case "refund":
+			var r refund
+			if err := json.Unmarshal(env.Data, &r); err != nil {
+				return total, unknown, fmt.Errorf("refund: %w", err)
+			}
+			total -= r.Amount
+		default:
+			unknown = append(unknown, env.Data) // passed on, not decoded
+		}
+	}
+	return total, unknown, nil
+}
+
+func rawEnvelopes() error {
+	fmt.Println("== RawMessage")
+	messages := `{"type": "sale", "data": {"id": 7, "user": "alice", "amount": 12.5}}
+{"type": "refund", "data": {"sale": 7, "amount": 2.5}}
+{"type": "audit", "data": {"who": "ops",  "fields": [1, 2, 3]}}
+`
+	total, unknown, err := envelopes(strings.NewReader(messages))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("sale minus refund: %v; unknown, kept byte for byte: %s\n", total, unknown)
+
+	// RawMessage marshals as the JSON it holds, compacted: json.Marshal
+	// compacts whatever a Marshaler returns.
+	out, err := json.Marshal(envelope{Type: "audit", Data: unknown[0]})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("forwarded, not decoded: %s\n\n", out)
+	return nil
+}
+
+// salesReader yields a JSON array of n sales, or n lines of NDJSON,
+// generated as it is read: the stream never exists whole.
+type salesReader struct {
+	n, i    int
+	ndjson  bool
+	buf     []byte
+	pending []byte // the part of buf not read yet
+	read    int64
+}
+
+func (r *salesReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(r.pending) == 0 && !r.next() {
+			break
+		}
+		c := copy(p[n:], r.pending)
+		r.pending = r.pending[c:]
+		n += c
+	}
+	r.read += int64(n)
+	if n == 0 {
+		return 0, io.EOF
+	}
+	return n, nil
+}
+
+// next generates the bytes of sale i, or the end of the array.
+func (r *salesReader) next() bool {
+	b := r.buf[:0]
+	switch {
+	case r.i < r.n:
+		if !r.ndjson {
+			b = append(b, ",\n"...)
+			if r.i == 0 {
+				b[0] = '['
+			}
+		}
+		b = appendSale(b, r.i)
+		if r.ndjson {
+			b = append(b, '\n')
+		}
+	case r.i == r.n && !r.ndjson:
+		if r.n == 0 {
+			b = append(b, '[')
+		}
+		b = append(b, "\n]\n"...)
+	default:
+		return false
+	}
+	r.i++
+	r.buf, r.pending = b, b
+	return true
+}
+
+// appendSale appends sale i, whose amount is saleAmount(i).
+func appendSale(b []byte, i int) []byte {
+	b = append(b, `{"id":`...)
+	b = strconv.AppendInt(b, int64(i), 10)
+	b = append(b, `,"user":"user-`...)
+	b = strconv.AppendInt(b, int64(i%1000), 10)
+	b = append(b, `","amount":`...)
+	b = strconv.AppendFloat(b, saleAmount(i), 'f', -1, 64)
+	return append(b, '}')
+}
+
+func saleAmount(i int) float64 { return float64(i%10000) / 100 }
+
+// streamed is what sumStream measured of a stream.
+type streamed struct {
+	count     int
+	total     float64
+	read      int64  // bytes
+	peak      uint64 // the most heap sampled
+	allocated uint64 // bytes, in all
+}
+
+// sumStream sums the amounts of a JSON array of n sales, generated as it
+// is read, sampling the heap on the way and counting the bytes allocated.
+func sumStream(n int) (streamed, error) {
+	var s streamed
+	src := &salesReader{n: n}
+	var before, after, ms runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	err := streamSales(src, func(o *Sale) error {
+		s.count++
+		s.total += o.Amount
+		if s.count%(64<<10) == 0 {
+			runtime.ReadMemStats(&ms)
+			s.peak = max(s.peak, ms.HeapAlloc)
+		}
+		return nil
+	})
+	runtime.ReadMemStats(&after)
+	s.read, s.allocated = src.read, after.TotalAlloc-before.TotalAlloc
+	return s, err
+}
+
+// streamMillion sums the amounts of a 1M-element array.
+func streamMillion() error {
+	fmt.Println("== 1M sales, streamed")
+	const n = 1_000_000
+	s, err := sumStream(n)
+	if err != nil {
+		return err
+	}
+	// the heap holds the garbage of the last few MB decoded too, collected
+	// when it doubles: what matters is that it does not follow the stream.
+	// The garbage is a few bytes per sale, the same as in the benchmark
+	// below, whatever the number of sales.
+	fmt.Printf("%d sales, total %.2f: %d MB read, at most %.1f MB of heap, %.1f B/sale allocated\n\n",
+		s.count, s.total, s.read>>20, float64(s.peak)/(1<<20), float64(s.allocated)/n)
+	return nil
+}
+
+// benchStream measures the allocations per sale of reading b.N sales:
+// streamed with Token and More, as NDJSON lines, or read whole with
+// io.ReadAll then json.Unmarshal into a slice. testing.Benchmark raises b.N
+// to a million sales and more, in one stream: a stream still allocates a
+// few bytes per sale, the string of its user, and holds none of them.
+// Reading whole is about as fast, measured here, but allocates some 300
+// bytes per sale, the document and the slice grown to its length, all of
+// it held until the end.
+func benchStream() {
+	fmt.Println("== allocations per sale (testing.Benchmark, b.N sales in one stream)")
+	var sum float64
+	add := func(o *Sale) error {
+		sum += o.Amount
+		return nil
+	}
+	t := benchtools.NewTable(os.Stdout)
+	t.Add(benchtools.Run("array, Token and More", func(b *testing.B) {
+		streamSales(&salesReader{n: b.N}, add)
+	}))
+	t.Add(benchtools.Run("NDJSON, Scanner and Unmarshal", func(b *testing.B) {
+		readNDJSON(&salesReader{n: b.N, ndjson: true}, add)
+	}))
+	t.Add(benchtools.Run("io.ReadAll, Unmarshal []Sale", func(b *testing.B) {
+		readWhole(&salesReader{n: b.N}, add)
+	}))
+	t.Flush()
+}
+
+// readWhole reads the JSON array of r with io.ReadAll, then json.Unmarshal
+// into a slice, and calls fn for each sale.
+func readWhole(r io.Reader, fn func(o *Sale) error) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	var sales []Sale
+	if err := json.Unmarshal(data, &sales); err != nil {
+		return err
+	}
+	for i := range sales {
+		if err := fn(&sales[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package stdlib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+func TestTokens(t *testing.T) {
+	toks, err := tokens(`{"page": 2, "tags": ["a", true, null], "price": 1.5}`)
+	want := []string{"json.Delim({)", "string(page)", "float64(2)", "string(tags)", "json.Delim([)", "string(a)",
+		"bool(true)", "<nil>(<nil>)", "json.Delim(])", "string(price)", "float64(1.5)", "json.Delim(})"}
+	if err != nil || !slices.Equal(toks, want) {
+		t.Errorf("tokens = %q, %v\nwant %q", toks, err, want)
+	}
+	if _, err := tokens(`{"page": }`); err == nil {
+		t.Error("a broken document: no error")
+	}
+}
+
+// collect returns fn for streamSales and the others, and the sales it got.
+func collect() (*[]Sale, func(o *Sale) error) {
+	var sales []Sale
+	return &sales, func(o *Sale) error {
+		sales = append(sales, *o)
+		return nil
+	}
+}
+
+func TestStreamSales(t *testing.T) {
+	var typ *json.UnmarshalTypeError
+	for _, tt := range []struct {
+		name, in string
+		want     []Sale
+		ok       func(error) bool
+	}{
+		{"two", `[{"id": 1, "amount": 9.5}, {"id": 2, "user": "bob"}]`, []Sale{{1, "", 9.5}, {2, "bob", 0}}, nil},
+		{"empty", `[]`, nil, nil},
+		{"a field not reused", `[{"id": 1, "user": "a"}, {"id": 2}]`, []Sale{{1, "a", 0}, {2, "", 0}}, nil},
+		{"the wrong type", `[{"id": 1}, {"id": "two"}]`, []Sale{{1, "", 0}}, func(err error) bool { return errors.As(err, &typ) }},
+		{"not an array", `{"id": 1}`, nil, func(err error) bool { return err != nil }},
+		{"data after", `[] []`, nil, func(err error) bool { return err != nil }},
+		{"cut", `[{"id": 1},`, []Sale{{1, "", 0}}, func(err error) bool { return err != nil }},
+	} {
+		got, fn := collect()
+		err := streamSales(strings.NewReader(tt.in), fn)
+		if tt.ok == nil && err != nil || tt.ok != nil && !tt.ok(err) || !slices.Equal(*got, tt.want) {
+			t.Errorf("%s: %v, %v; want %v", tt.name, *got, err, tt.want)
+		}
+	}
+
+	// fn stops the stream with its error.
+	stop := errors.New("stop")
+	n := 0
+	err := streamSales(strings.NewReader(`[{"id": 1}, {"id": 2}]`), func(*Sale) error {
+		n++
+		return stop
+	})
+	if err != stop || n != 1 {
+		t.Errorf("fn failing: %v after %d sales", err, n)
+	}
+}
+
+func TestSalesPage(t *testing.T) {
+	for _, page := range []string{
+		`{"page": 2, "sales": [{"id": 1}, {"id": 2}], "next": {"cursor": "b2"}}`,
+		`{"next": {"cursor": "b2"}, "sales": [{"id": 1}, {"id": 2}], "page": 2}`, // wherever it is
+	} {
+		got, fn := collect()
+		rest, err := salesPage(strings.NewReader(page), fn)
+		if err != nil || len(*got) != 2 || string(rest["next"]) != `{"cursor": "b2"}` || string(rest["page"]) != "2" || rest["sales"] != nil {
+			t.Errorf("%s: %v, %q, %v", page, *got, rest, err)
+		}
+	}
+	if _, err := salesPage(strings.NewReader(`{"sales": {"id": 1}}`), func(*Sale) error { return nil }); err == nil || !strings.HasPrefix(err.Error(), "sales: ") {
+		t.Errorf("sales not an array: %v", err)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	sales := []Sale{{1, "alice", 9.5}, {2, "bob <b@example.com>", 20}, {3, "", 0.25}}
+	var buf bytes.Buffer
+	if err := writeNDJSON(&buf, sales); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != len(sales) {
+		t.Errorf("%d lines for %d sales:\n%s", n, len(sales), buf.String())
+	}
+	got, fn := collect()
+	if err := readNDJSON(&buf, fn); err != nil || !slices.Equal(*got, sales) {
+		t.Errorf("read back: %v, %v", *got, err)
+	}
+
+	for _, tt := range []struct{ in, err string }{
+		{"{\"id\": 1}\n\n  \n{\"id\": 2}", ""}, // blank lines skipped, no final newline
+		{"{\"id\": 1}\n\n{\"id\": 2, \"user\": \n{\"id\": 3}\n", "line 3: "},
+		{"{\"id\": 1} {\"id\": 2}\n", "line 1: "}, // a Decoder would read two
+	} {
+		err := readNDJSON(strings.NewReader(tt.in), func(*Sale) error { return nil })
+		if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.err)) {
+			t.Errorf("%q: %v, want %q", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestEnvelopes(t *testing.T) {
+	messages := `{"type": "sale", "data": {"id": 7, "user": "alice", "amount": 12.5}}
+{"type": "refund", "data": {"sale": 7, "amount": 2.5}}
+{"type": "audit", "data": {"who": "ops",  "fields": [1, 2, 3]}}
+`
+	total, unknown, err := envelopes(strings.NewReader(messages))
+	if err != nil || total != 10 || len(unknown) != 1 || string(unknown[0]) != `{"who": "ops",  "fields": [1, 2, 3]}` {
+		t.Fatalf("envelopes = %v, %q, %v", total, unknown, err)
+	}
+	out, err := json.Marshal(envelope{Type: "audit", Data: unknown[0]})
+	if err != nil || string(out) != `{"type":"audit","data":{"who":"ops","fields":[1,2,3]}}` {
+		t.Errorf("forwarded: %s, %v", out, err)
+	}
+
+	_, _, err = envelopes(strings.NewReader(`{"type": "refund", "data": {"sale": "seven"}}`))
+	if err == nil || !strings.HasPrefix(err.Error(), "refund: ") {
+		t.Errorf("a bad refund: %v", err)
+	}
+}
+
+// TestSalesReader reads the generated streams whole: they are the JSON they
+// claim to be.
+func TestSalesReader(t *testing.T) {
+	for _, n := range []int{0, 1, 3, 1000} {
+		data, _ := io.ReadAll(&salesReader{n: n})
+		var sales []Sale
+		if err := json.Unmarshal(data, &sales); err != nil || len(sales) != n {
+			t.Errorf("%d sales: %d, %v\n%s", n, len(sales), err, data)
+			continue
+		}
+		for i, o := range sales {
+			if o.ID != int64(i) || o.Amount != saleAmount(i) {
+				t.Errorf("%d sales: sale %d is %+v", n, i, o)
+				break
+			}
+		}
+
+		got, fn := collect()
+		if err := readNDJSON(&salesReader{n: n, ndjson: true}, fn); err != nil || !slices.Equal(*got, sales) {
+			t.Errorf("%d sales, NDJSON: %d, %v", n, len(*got), err)
+		}
+	}
+}
+
+// TestStreamMemory streams 1M sales: the heap does not follow the stream,
+// and the garbage is a few bytes per sale. The race detector makes it 20
+// times slower and allocates for itself: 100K sales, the heap checked alone.
+func TestStreamMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("1M sales")
+	}
+	n := 1_000_000
+	if raceEnabled {
+		n = 100_000
+	}
+	s, err := sumStream(n)
+	var want float64
+	for i := 0; i < n; i++ {
+		want += saleAmount(i)
+	}
+	if err != nil || s.count != n || s.total != want {
+		t.Fatalf("%d sales, total %.2f, %v; want %d, %.2f", s.count, s.total, err, n, want)
+	}
+	if s.peak > 16<<20 {
+		t.Errorf("%.1f MB of heap for %d MB read", float64(s.peak)/(1<<20), s.read>>20)
+	}
+	if perSale := float64(s.allocated) / float64(n); perSale > 64 && !raceEnabled {
+		t.Errorf("%.1f B/sale allocated", perSale)
+	}
+}
+
+// BenchmarkRead reads n sales per operation: the B/op of the streams grows
+// by a few bytes per sale, the whole document's by all of it, with
+// go test -bench Read.
+func BenchmarkRead(b *testing.B) {
+	for _, v := range []struct {
+		name   string
+		read   func(io.Reader, func(*Sale) error) error
+		ndjson bool
+	}{
+		{"stream", streamSales, false},
+		{"ndjson", readNDJSON, true},
+		{"whole", readWhole, false},
+	} {
+		b.Run(v.name, func(b *testing.B) {
+			benchtools.Sized(b, []int{1 << 10, 64 << 10}, func(b *testing.B, n int) {
+				for i := 0; i < b.N; i++ {
+					if err := v.read(&salesReader{n: n, ndjson: v.ndjson}, func(*Sale) error { return nil }); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		})
+	}
+}
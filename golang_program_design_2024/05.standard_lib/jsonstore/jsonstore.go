@@ -0,0 +1,71 @@
+// Package jsonstore keeps a value in a JSON file, one value per file: a
+// list of users, a config, the state of a program between two runs.
+//
+//	var users []User
+//	err := jsonstore.Load(os.DirFS(dir), "users.json", &users)
+//	...
+//	err = jsonstore.Save(filepath.Join(dir, "users.json"), users, 0o644)
+//
+// Reading goes through io.Reader and io/fs.FS, so the code that loads a file
+// is tested with a strings.Reader or a fstest.MapFS instead of a file on
+// disk. Save replaces the file atomically: a reader, or the program after a
+// crash, finds the old value or the new one, never a file cut in the middle.
+package jsonstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/YongSangUn/learn-golang/internal/atomicfile"
+)
+
+// ErrTrailingData is returned by Read for a stream holding more than one
+// JSON value, or garbage after it.
+var ErrTrailingData = errors.New("jsonstore: data after the JSON value")
+
+// Write encodes v as JSON to w, followed by a newline. Nothing is written if
+// v cannot be encoded.
+func Write(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v) // marshalled whole, then one Write
+}
+
+// Read decodes the single JSON value of r into v.
+func Read(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return ErrTrailingData
+	}
+	return nil
+}
+
+// Load decodes the file name of fsys into v. The errors of Read are
+// prefixed with name; those of Open are an *fs.PathError already.
+func Load(fsys fs.FS, name string, v any) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := Read(f, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// Save writes v to the file name, created with perm or replaced, with
+// atomicfile.WriteFile: on failure name is left as it was. v is encoded
+// first, so a value that cannot be encoded does not touch the file.
+func Save(name string, v any, perm fs.FileMode) error {
+	var buf bytes.Buffer
+	if err := Write(&buf, v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return atomicfile.WriteFile(name, buf.Bytes(), perm)
+}
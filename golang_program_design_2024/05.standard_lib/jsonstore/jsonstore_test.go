@@ -0,0 +1,150 @@
+package jsonstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+type user struct {
+	Name string
+	Age  int
+}
+
+var users = []user{{"Alice", 30}, {"Bob", 25}}
+
+const usersJSON = `[{"Name":"Alice","Age":30},{"Name":"Bob","Age":25}]` + "\n"
+
+func TestWriteRead(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, users); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != usersJSON {
+		t.Errorf("Write = %q, want %q", buf.String(), usersJSON)
+	}
+	var got []user
+	if err := Read(&buf, &got); err != nil || !reflect.DeepEqual(got, users) {
+		t.Errorf("Read = %+v, %v, want %+v", got, err, users)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	var buf bytes.Buffer
+	var unsupported *json.UnsupportedTypeError
+	if err := Write(&buf, make(chan int)); !errors.As(err, &unsupported) {
+		t.Errorf("Write(chan) = %v, want *json.UnsupportedTypeError", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Write(chan) wrote %q", buf.String())
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, in string
+		want     func(error) bool
+	}{
+		{"empty", "", func(err error) bool { return err != nil }},
+		{"syntax", `[{"Name":`, func(err error) bool { return err != nil }},
+		{"wrong type", `[{"Name":"Alice","Age":"thirty"}]`, func(err error) bool {
+			var typ *json.UnmarshalTypeError
+			return errors.As(err, &typ)
+		}},
+		{"two values", usersJSON + usersJSON, func(err error) bool { return errors.Is(err, ErrTrailingData) }},
+		{"garbage after", usersJSON + "x", func(err error) bool { return errors.Is(err, ErrTrailingData) }},
+	} {
+		var got []user
+		if err := Read(strings.NewReader(tt.in), &got); !tt.want(err) {
+			t.Errorf("%s: Read = %v", tt.name, err)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"users.json":  {Data: []byte(usersJSON)},
+		"broken.json": {Data: []byte(`[{"Name":"Alice"`)},
+	}
+	var got []user
+	if err := Load(fsys, "users.json", &got); err != nil || !reflect.DeepEqual(got, users) {
+		t.Errorf("Load = %+v, %v, want %+v", got, err, users)
+	}
+	if err := Load(fsys, "missing.json", &got); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load(missing) = %v, want fs.ErrNotExist", err)
+	}
+	if err := Load(fsys, "broken.json", &got); err == nil || !strings.HasPrefix(err.Error(), "broken.json: ") {
+		t.Errorf("Load(broken) = %v, want an error naming the file", err)
+	}
+}
+
+// entries returns the names in dir, to see the temporary files left.
+func entries(t *testing.T, dir string) []string {
+	t.Helper()
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range list {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "users.json")
+	if err := Save(name, users, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	var got []user
+	if err := Load(os.DirFS(dir), "users.json", &got); err != nil || !reflect.DeepEqual(got, users) {
+		t.Errorf("Load after Save = %+v, %v, want %+v", got, err, users)
+	}
+	if info, err := os.Stat(name); err != nil || runtime.GOOS != "windows" && info.Mode().Perm() != 0o640 {
+		t.Errorf("Stat = %v, %v, want mode 0640", info.Mode(), err)
+	}
+
+	// replaced, not appended to.
+	if err := Save(name, users[:1], 0o640); err != nil {
+		t.Fatal(err)
+	}
+	if err := Load(os.DirFS(dir), "users.json", &got); err != nil || len(got) != 1 {
+		t.Errorf("Load after the second Save = %+v, %v", got, err)
+	}
+	if names := entries(t, dir); len(names) != 1 {
+		t.Errorf("files left: %q", names)
+	}
+}
+
+// A failed Save leaves the old file as it was, and no temporary file.
+func TestSaveError(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "users.json")
+	if err := Save(name, users, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := Save(name, map[string]any{"users": users, "done": make(chan int)}, 0o644)
+	if err == nil || !strings.HasPrefix(err.Error(), name+": ") {
+		t.Errorf("Save(chan) = %v, want an error naming the file", err)
+	}
+	if data, _ := os.ReadFile(name); string(data) != usersJSON {
+		t.Errorf("after a failed Save, the file holds %q", data)
+	}
+	if names := entries(t, dir); len(names) != 1 {
+		t.Errorf("files left: %q", names)
+	}
+
+	if err := Save(filepath.Join(dir, "missing", "users.json"), users, 0o644); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Save in a missing directory = %v, want fs.ErrNotExist", err)
+	}
+}
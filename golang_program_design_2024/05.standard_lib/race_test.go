@@ -0,0 +1,7 @@
+//go:build race
+
+package stdlib
+
+// raceEnabled is true under go test -race, whose instrumentation allocates
+// on its own: allocation counts mean nothing then.
+const raceEnabled = true
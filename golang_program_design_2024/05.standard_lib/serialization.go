@@ -0,0 +1,428 @@
+package stdlib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+The same data can be serialized in very different ways. This file compares
+three of them on one struct:
+
+- JSON (encoding/json): text, self-describing (every value comes with its
+  field name), readable by any language, several times bigger and slower
+  than protobuf.
+- gob (encoding/gob): Go only. A stream starts with a description of the
+  types, then only values follow, so one message alone is big but a long
+  stream of them is compact; decoding pays for the reflection it relies on.
+- Protocol Buffers: binary, the schema lives in a .proto file shared by both
+  sides, fields are identified on the wire by their number only.
+
+The protobuf code is normally generated by protoc from the schema and uses the
+google.golang.org/protobuf module. To stay within the standard library, the
+encoder and decoder below are written by hand, byte for byte what generated
+code produces for this schema:
+
+	message Order {
+	  int64  id         = 1;
+	  string customer   = 2;
+	  repeated Item items = 3;
+	  bool   paid       = 4;
+	  int64  created_at = 5;  // unix nanoseconds
+	}
+	message Item {
+	  string sku   = 1;
+	  int32  qty   = 2;
+	  double price = 3;
+	}
+
+Every field is written as a key, (field number << 3 | wire type), followed by
+the value: a varint (wire type 0), 8 fixed bytes (1) or a length and that many
+bytes (2, for strings and nested messages). Zero values are not written.
+
+Usage (from the root of the repository):
+
+	go run ./cmd/learn run 05.standard_lib/serialization
+*/
+
+func init() {
+	lessons.Register("05.standard_lib/serialization", "JSON, gob and protobuf compared: size, speed, schema evolution", DemoSerialization)
+}
+
+// DemoSerialization compares JSON, gob and protobuf on the same struct.
+func DemoSerialization() {
+	sizes()
+	roundTrip()
+	evolution()
+	serializationBenchmarks()
+}
+
+type Item struct {
+	SKU   string
+	Qty   int32
+	Price float64
+}
+
+type Order struct {
+	ID        int64
+	Customer  string
+	Items     []Item
+	Paid      bool
+	CreatedAt time.Time
+}
+
+var sample = Order{
+	ID:       1042,
+	Customer: "Alice",
+	Items: []Item{
+		{SKU: "book-go", Qty: 1, Price: 39.90},
+		{SKU: "pen-blue", Qty: 3, Price: 1.25},
+	},
+	Paid:      true,
+	CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+}
+
+// ---- protobuf wire format, by hand ----
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+func appendKey(b []byte, field, wire int) []byte {
+	return binary.AppendUvarint(b, uint64(field)<<3|uint64(wire))
+}
+
+func appendVarint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.AppendUvarint(appendKey(b, field, wireVarint), v)
+}
+
+func appendBytes(b []byte, field int, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(appendKey(b, field, wireBytes), uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendDouble(b []byte, field int, v float64) []byte {
+	if v == 0 {
+		return b
+	}
+	return binary.LittleEndian.AppendUint64(appendKey(b, field, wireFixed64), math.Float64bits(v))
+}
+
+func boolVarint(v bool) uint64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+func (it Item) marshalProto() []byte {
+	var b []byte
+	b = appendBytes(b, 1, []byte(it.SKU))
+	// negative int32 values are sign-extended to 10 bytes, like generated code does.
+	b = appendVarint(b, 2, uint64(int64(it.Qty)))
+	return appendDouble(b, 3, it.Price)
+}
+
+func (o Order) marshalProto() []byte {
+	var b []byte
+	b = appendVarint(b, 1, uint64(o.ID))
+	b = appendBytes(b, 2, []byte(o.Customer))
+	for _, it := range o.Items {
+		b = appendBytes(b, 3, it.marshalProto())
+	}
+	b = appendVarint(b, 4, boolVarint(o.Paid))
+	if !o.CreatedAt.IsZero() {
+		b = appendVarint(b, 5, uint64(o.CreatedAt.UnixNano()))
+	}
+	return b
+}
+
+// protoField is one decoded field: num and wire type, then the value in v
+// (varint and fixed64) or in data (length-delimited).
+type protoField struct {
+	num, wire int
+	v         uint64
+	data      []byte
+}
+
+// walkProto calls fn for every field of a message. Unknown fields need no
+// special care: the wire type alone says how long the value is, so a decoder
+// can step over fields it has never heard of.
+func walkProto(b []byte, fn func(f protoField) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errors.New("proto: bad key")
+		}
+		b = b[n:]
+		f := protoField{num: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case wireVarint:
+			f.v, n = binary.Uvarint(b)
+			if n <= 0 {
+				return errors.New("proto: bad varint")
+			}
+			b = b[n:]
+		case wireFixed64:
+			if len(b) < 8 {
+				return errors.New("proto: truncated fixed64")
+			}
+			f.v, b = binary.LittleEndian.Uint64(b), b[8:]
+		case wireBytes:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return errors.New("proto: truncated bytes")
+			}
+			f.data, b = b[n:n+int(size)], b[n+int(size):]
+		default:
+			return fmt.Errorf("proto: unsupported wire type %d", f.wire)
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expectWire checks the wire type of a known field: a field whose type changed
+// in the schema shows up here.
+func expectWire(f protoField, wire int) error {
+	if f.wire != wire {
+		return fmt.Errorf("proto: field %d has wire type %d, want %d", f.num, f.wire, wire)
+	}
+	return nil
+}
+
+func (it *Item) unmarshalProto(b []byte) error {
+	return walkProto(b, func(f protoField) error {
+		switch f.num {
+		case 1:
+			it.SKU = string(f.data)
+			return expectWire(f, wireBytes)
+		case 2:
+			it.Qty = int32(f.v)
+			return expectWire(f, wireVarint)
+		case 3:
+			it.Price = math.Float64frombits(f.v)
+			return expectWire(f, wireFixed64)
+		}
+		return nil // unknown field: skipped
+	})
+}
+
+func (o *Order) unmarshalProto(b []byte) error {
+	return walkProto(b, func(f protoField) error {
+		switch f.num {
+		case 1:
+			o.ID = int64(f.v)
+			return expectWire(f, wireVarint)
+		case 2:
+			o.Customer = string(f.data)
+			return expectWire(f, wireBytes)
+		case 3:
+			var it Item
+			if err := it.unmarshalProto(f.data); err != nil {
+				return err
+			}
+			o.Items = append(o.Items, it)
+			return expectWire(f, wireBytes)
+		case 4:
+			o.Paid = f.v != 0
+			return expectWire(f, wireVarint)
+		case 5:
+			o.CreatedAt = time.Unix(0, int64(f.v)).UTC()
+			return expectWire(f, wireVarint)
+		}
+		return nil
+	})
+}
+
+// ---- the comparison ----
+
+func gobEncode(v any) []byte {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func sizes() {
+	fmt.Println("== size of one order")
+	j, _ := json.Marshal(sample)
+	fmt.Printf("json   %4d bytes\n", len(j))
+	fmt.Printf("gob    %4d bytes  (type description included)\n", len(gobEncode(sample)))
+	fmt.Printf("proto  %4d bytes\n", len(sample.marshalProto()))
+
+	// in a stream, gob describes the types once: the next values are small.
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	enc.Encode(sample)
+	first := buf.Len()
+	enc.Encode(sample)
+	fmt.Printf("gob    %4d bytes  (the same order again, on the same encoder)\n\n", buf.Len()-first)
+}
+
+func roundTrip() {
+	fmt.Println("== round trip")
+	var fromJSON, fromGob, fromProto Order
+
+	j, _ := json.Marshal(sample)
+	json.Unmarshal(j, &fromJSON)
+	gob.NewDecoder(bytes.NewReader(gobEncode(sample))).Decode(&fromGob)
+	fromProto.unmarshalProto(sample.marshalProto())
+
+	for _, c := range []struct {
+		name string
+		got  Order
+	}{{"json", fromJSON}, {"gob", fromGob}, {"proto", fromProto}} {
+		// time.Time holds a location pointer, compare the instant with Equal.
+		same := c.got.ID == sample.ID && c.got.Customer == sample.Customer &&
+			fmt.Sprint(c.got.Items) == fmt.Sprint(sample.Items) &&
+			c.got.Paid == sample.Paid && c.got.CreatedAt.Equal(sample.CreatedAt)
+		fmt.Printf("%-6s identical: %v\n", c.name, same)
+	}
+	fmt.Println()
+}
+
+// Schema evolution: v2 of the order adds a coupon, v3 drops Paid, and in v4
+// Qty becomes a string. Old and new programs keep exchanging data.
+
+type OrderV2 struct {
+	ID        int64
+	Customer  string
+	Items     []Item
+	Paid      bool
+	CreatedAt time.Time
+	Coupon    string // new, field 6 in the .proto
+}
+
+func (o OrderV2) marshalProto() []byte {
+	b := Order{o.ID, o.Customer, o.Items, o.Paid, o.CreatedAt}.marshalProto()
+	return appendBytes(b, 6, []byte(o.Coupon))
+}
+
+// unmarshalProto reads the v1 fields, then the one v2 added.
+func (o *OrderV2) unmarshalProto(b []byte) error {
+	var v1 Order
+	if err := v1.unmarshalProto(b); err != nil {
+		return err
+	}
+	*o = OrderV2{ID: v1.ID, Customer: v1.Customer, Items: v1.Items, Paid: v1.Paid, CreatedAt: v1.CreatedAt}
+	return walkProto(b, func(f protoField) error {
+		if f.num == 6 {
+			o.Coupon = string(f.data)
+			return expectWire(f, wireBytes)
+		}
+		return nil
+	})
+}
+
+type OrderV3 struct {
+	ID       int64
+	Customer string
+	Items    []Item
+	// Paid is gone; in the .proto its number 4 is marked reserved so that it
+	// is never reused for something else.
+	CreatedAt time.Time
+}
+
+type ItemV4 struct {
+	SKU   string
+	Qty   string // was int32
+	Price float64
+}
+
+type OrderV4 struct {
+	ID    int64
+	Items []ItemV4
+}
+
+func evolution() {
+	v2 := OrderV2{ID: 7, Customer: "Bob", Items: sample.Items, Paid: true, Coupon: "SPRING"}
+
+	fmt.Println("== new writer (v2, with Coupon), old reader (v1)")
+	var old Order
+	j, _ := json.Marshal(v2)
+	fmt.Printf("json   err=%v  %+v\n", json.Unmarshal(j, &old), old.Customer)
+	old = Order{}
+	fmt.Printf("gob    err=%v  %+v\n", gob.NewDecoder(bytes.NewReader(gobEncode(v2))).Decode(&old), old.Customer)
+	old = Order{}
+	fmt.Printf("proto  err=%v  %+v\n", old.unmarshalProto(v2.marshalProto()), old.Customer)
+	fmt.Println("       all three ignore the unknown field")
+
+	fmt.Println("\n== old writer (v1), new reader (v2): the new field is left empty")
+	var newer OrderV2
+	j, _ = json.Marshal(sample)
+	json.Unmarshal(j, &newer)
+	fmt.Printf("json   coupon=%q\n", newer.Coupon)
+	newer = OrderV2{}
+	gob.NewDecoder(bytes.NewReader(gobEncode(sample))).Decode(&newer)
+	fmt.Printf("gob    coupon=%q\n", newer.Coupon)
+	newer = OrderV2{}
+	newer.unmarshalProto(sample.marshalProto())
+	fmt.Printf("proto  coupon=%q\n", newer.Coupon)
+
+	fmt.Println("\n== a field removed (v3 without Paid)")
+	var v3 OrderV3
+	j, _ = json.Marshal(sample)
+	fmt.Printf("json   err=%v, paid silently dropped\n", json.Unmarshal(j, &v3))
+	v3 = OrderV3{}
+	fmt.Printf("gob    err=%v, paid silently dropped\n", gob.NewDecoder(bytes.NewReader(gobEncode(sample))).Decode(&v3))
+	fmt.Println("proto  field 4 is skipped like any unknown field")
+
+	fmt.Println("\n== a field changing type (v4: Qty int32 -> string) breaks every format")
+	var v4 OrderV4
+	j, _ = json.Marshal(sample)
+	fmt.Printf("json   %v\n", json.Unmarshal(j, &v4))
+	fmt.Printf("gob    %v\n", gob.NewDecoder(bytes.NewReader(gobEncode(sample))).Decode(&v4))
+	// the v4 decoder expects field 2 of Item to be a string (wire type 2).
+	err := walkProto(sample.Items[0].marshalProto(), func(f protoField) error {
+		if f.num == 2 {
+			return expectWire(f, wireBytes)
+		}
+		return nil
+	})
+	fmt.Printf("proto  %v\n", err)
+	fmt.Println("       the safe way is a new field with a new number, never a new type for an old one")
+	fmt.Println()
+}
+
+func serializationBenchmarks() {
+	fmt.Println("== speed (testing.Benchmark)")
+	j, _ := json.Marshal(sample)
+	p := sample.marshalProto()
+
+	// one encoder per stream is how gob is meant to be used, but that makes
+	// every message after the first smaller. Encoding each message on its
+	// own, as done here, is the worst case for gob, the usual one for RPC.
+	g := gobEncode(sample)
+
+	t := benchtools.NewTable(os.Stdout)
+	t.Add(benchtools.Loop("json  marshal", func() { json.Marshal(sample) }))
+	t.Add(benchtools.Loop("gob   marshal", func() { gobEncode(sample) }))
+	t.Add(benchtools.Loop("proto marshal", func() { sample.marshalProto() }))
+	t.Flush()
+	t.Add(benchtools.Loop("json  unmarshal", func() { var o Order; json.Unmarshal(j, &o) }))
+	t.Add(benchtools.Loop("gob   unmarshal", func() { var o Order; gob.NewDecoder(bytes.NewReader(g)).Decode(&o) }))
+	t.Add(benchtools.Loop("proto unmarshal", func() { var o Order; o.unmarshalProto(p) }))
+	t.Flush()
+}
@@ -0,0 +1,345 @@
+package stdlib
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+An email is text: header lines, a blank line, a body. To carry both a plain
+text and an HTML version, the body is multipart/alternative (RFC 2046): parts
+separated by a boundary line, each with its own headers, the client showing
+the last one it can display.
+
+- mime/multipart writes the parts and the boundaries.
+- mime/quotedprintable keeps every line short and ASCII, as SMTP requires,
+  while staying readable; mime.QEncoding does the same for the Subject.
+- html/template escapes the data it inserts: a name like <script> ends up as
+  text, not as markup. text/template, for the text part, does not.
+- net/smtp speaks the protocol: EHLO, STARTTLS to encrypt the connection,
+  AUTH, MAIL FROM, RCPT TO, DATA.
+
+Sending goes through the Sender interface. SMTPSender talks to a server;
+DryRunSender writes the message to an io.Writer instead, so the generated MIME
+can be checked, or looked at, without a server. The demo uses both: a dry
+run, and a send to a tiny SMTP server running in this program; smtp_test.go
+parses the messages back with net/mail.
+
+Usage (from the root of the repository):
+
+	go run ./cmd/learn run 05.standard_lib/smtp
+*/
+
+func init() {
+	lessons.Register("05.standard_lib/smtp", "multipart email with templates, sent over SMTP with STARTTLS", lessons.Checked(DemoSMTP))
+}
+
+// DemoSMTP builds an email, prints its encoding, and sends it to a local
+// SMTP server.
+func DemoSMTP() error {
+	fmt.Println("== dry run")
+	e, err := shippedEmail(mail.Address{Name: "Zoë <script>", Address: "zoe@example.com"},
+		shipping{Name: "Zoë <script>", Order: 1042, Items: []string{"The Go Programming Language", "Gopher plush"}})
+	if err != nil {
+		return err
+	}
+	if err := (DryRunSender{W: os.Stdout}).Send(e); err != nil {
+		return err
+	}
+
+	fmt.Println("\n== send with net/smtp")
+	e, err = shippedEmail(mail.Address{Name: "Bob", Address: "bob@example.com"},
+		shipping{Name: "Bob", Order: 7, Items: []string{"Socks"}})
+	if err != nil {
+		return err
+	}
+	transcript, err := sendLocal(e)
+	fmt.Print(transcript)
+	return err
+}
+
+// Email is a message before encoding.
+type Email struct {
+	From    mail.Address
+	To      []mail.Address
+	Subject string
+	Text    string
+	HTML    string
+}
+
+var (
+	textTmpl = template.Must(template.New("text").Parse(
+		`Hello {{.Name}},
+
+your order #{{.Order}} has shipped. It contains:
+{{range .Items}}  - {{.}}
+{{end}}
+Thanks for shopping with us!
+`))
+	htmlTmpl = htmltemplate.Must(htmltemplate.New("html").Parse(
+		`<html><body>
+<p>Hello {{.Name}},</p>
+<p>your order <b>#{{.Order}}</b> has shipped. It contains:</p>
+<ul>{{range .Items}}<li>{{.}}</li>{{end}}</ul>
+<p>Thanks for shopping with us!</p>
+</body></html>
+`))
+)
+
+type shipping struct {
+	Name  string
+	Order int
+	Items []string
+}
+
+// shippedEmail renders both templates with the same data.
+func shippedEmail(to mail.Address, data shipping) (Email, error) {
+	var text, html bytes.Buffer
+	if err := textTmpl.Execute(&text, data); err != nil {
+		return Email{}, err
+	}
+	if err := htmlTmpl.Execute(&html, data); err != nil {
+		return Email{}, err
+	}
+	return Email{
+		From:    mail.Address{Name: "Go Shop", Address: "shop@example.com"},
+		To:      []mail.Address{to},
+		Subject: fmt.Sprintf("Your order #%d has shipped 📦", data.Order),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// Bytes encodes the email as a multipart/alternative MIME message with CRLF
+// line endings, ready for the DATA command.
+func (e Email) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	to := make([]string, len(e.To))
+	for i, a := range e.To {
+		to[i] = a.String() // quotes and encodes the name as needed
+	}
+	id := make([]byte, 12)
+	rand.Read(id)
+	headers := []struct{ key, value string }{
+		{"From", e.From.String()},
+		{"To", strings.Join(to, ", ")},
+		{"Subject", mime.QEncoding.Encode("utf-8", e.Subject)},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%s@%s>", hex.EncodeToString(id), domain(e.From.Address))},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": mw.Boundary()})},
+	}
+	var head bytes.Buffer
+	for _, h := range headers {
+		fmt.Fprintf(&head, "%s: %s\r\n", h.key, h.value)
+	}
+	head.WriteString("\r\n")
+
+	// the simplest version first: clients show the last one they understand.
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", e.Text},
+		{"text/html; charset=utf-8", e.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		// quoted-printable keeps \n as is; SMTP wants \r\n.
+		if _, err := io.WriteString(qp, strings.ReplaceAll(part.body, "\n", "\r\n")); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
+
+func domain(addr string) string {
+	if _, d, ok := strings.Cut(addr, "@"); ok {
+		return d
+	}
+	return "localhost"
+}
+
+// Sender delivers encoded emails.
+type Sender interface {
+	Send(e Email) error
+}
+
+// DryRunSender writes the messages to W instead of sending them, each
+// preceded by its envelope.
+type DryRunSender struct {
+	W io.Writer
+}
+
+func (s DryRunSender) Send(e Email) error {
+	msg, err := e.Bytes()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(s.W, "MAIL FROM:<%s>\r\n", e.From.Address)
+	for _, to := range e.To {
+		fmt.Fprintf(s.W, "RCPT TO:<%s>\r\n", to.Address)
+	}
+	_, err = fmt.Fprintf(s.W, "\r\n%s", msg)
+	return err
+}
+
+// SMTPSender sends through an SMTP server, upgrading the connection with
+// STARTTLS when the server offers it. Without TLS it refuses to send, unless
+// the server is on localhost: the password would cross the network in clear.
+type SMTPSender struct {
+	Addr     string // host:port, 587 for submission
+	Username string
+	Password string
+	// TLSConfig is used for STARTTLS, nil means the defaults.
+	TLSConfig *tls.Config
+}
+
+func (s SMTPSender) Send(e Email) error {
+	msg, err := e.Bytes()
+	if err != nil {
+		return err
+	}
+	host, _, err := net.SplitHostPort(s.Addr)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.Dial(s.Addr)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		cfg := s.TLSConfig
+		if cfg == nil {
+			cfg = &tls.Config{ServerName: host}
+		}
+		if err := c.StartTLS(cfg); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	} else if host != "localhost" && host != "127.0.0.1" {
+		return errors.New("smtp: server does not support STARTTLS")
+	}
+
+	if s.Username != "" {
+		// PlainAuth itself also refuses to send the password in clear to a
+		// host other than localhost.
+		if err := c.Auth(smtp.PlainAuth("", s.Username, s.Password, host)); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+	if err := c.Mail(e.From.Address); err != nil {
+		return err
+	}
+	for _, to := range e.To {
+		if err := c.Rcpt(to.Address); err != nil {
+			return fmt.Errorf("rcpt %s: %w", to.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// sendLocal sends e with SMTPSender to fakeSMTP, and returns what the
+// server received.
+func sendLocal(e Email) (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	received := make(chan string, 1)
+	go fakeSMTP(l, received)
+
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	s := SMTPSender{Addr: net.JoinHostPort("localhost", port), Username: "shop", Password: "secret"}
+	if err := s.Send(e); err != nil {
+		return "", err
+	}
+	return <-received, nil
+}
+
+// fakeSMTP accepts one session, answers every command with success and sends
+// back the commands it received, the message data included.
+func fakeSMTP(l net.Listener, transcript chan<- string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	var seen strings.Builder
+	tp.PrintfLine("220 localhost fake SMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			break
+		}
+		fmt.Fprintf(&seen, "C: %s\n", line)
+		cmd, _, _ := strings.Cut(strings.ToUpper(line), " ")
+		switch cmd {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-localhost")
+			tp.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			tp.PrintfLine("235 authenticated")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			data, _ := tp.ReadDotLines()
+			for _, l := range data {
+				if strings.HasPrefix(l, "Subject:") || strings.HasPrefix(l, "To:") {
+					fmt.Fprintf(&seen, "   %s\n", l)
+				}
+			}
+			fmt.Fprintf(&seen, "   ... %d lines of data\n", len(data))
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			tp.PrintfLine("221 bye")
+			transcript <- seen.String()
+			return
+		default:
+			tp.PrintfLine("250 ok")
+		}
+	}
+	transcript <- seen.String()
+}
@@ -0,0 +1,112 @@
+package stdlib
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func zoe(t *testing.T) Email {
+	t.Helper()
+	e, err := shippedEmail(mail.Address{Name: "Zoë <script>", Address: "zoe@example.com"},
+		shipping{Name: "Zoë <script>", Order: 1042, Items: []string{"The Go Programming Language", "Gopher plush"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+// TestDryRun parses the output of DryRunSender back with net/mail.
+func TestDryRun(t *testing.T) {
+	e := zoe(t)
+	var out bytes.Buffer
+	if err := (DryRunSender{W: &out}).Send(e); err != nil {
+		t.Fatal(err)
+	}
+	envelope, raw, _ := bytes.Cut(out.Bytes(), []byte("\r\n\r\n"))
+	if string(envelope) != "MAIL FROM:<shop@example.com>\r\nRCPT TO:<zoe@example.com>" {
+		t.Errorf("envelope %q", envelope)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil || subject != e.Subject {
+		t.Errorf("Subject decodes to %q, %v, want %q", subject, err, e.Subject)
+	}
+	if to, err := msg.Header.AddressList("To"); err != nil || len(to) != 1 || *to[0] != e.To[0] {
+		t.Errorf("To: %v, %v", to, err)
+	}
+	if _, err := msg.Header.Date(); err != nil || !strings.HasSuffix(msg.Header.Get("Message-ID"), "@example.com>") {
+		t.Errorf("Date %v, Message-ID %q", err, msg.Header.Get("Message-ID"))
+	}
+
+	mediaType, params, _ := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type %s", mediaType)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var types []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(p) // NextPart undoes the quoted-printable encoding
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		types = append(types, ct)
+		want := "Hello Zoë <script>," // text/template does not escape
+		if ct == "text/html" {
+			want = "Hello Zoë &lt;script&gt;,"
+		}
+		if !bytes.Contains(body, []byte(want)) || !bytes.Contains(body, []byte("\r\n")) {
+			t.Errorf("%s part without %q:\n%s", ct, want, body)
+		}
+	}
+	if !slices.Equal(types, []string{"text/plain", "text/html"}) {
+		t.Errorf("parts %v, want text then html", types)
+	}
+}
+
+// TestLongLines checks that quoted-printable keeps every line of the body
+// short, as SMTP requires.
+func TestLongLines(t *testing.T) {
+	e := zoe(t)
+	e.Text = strings.Repeat("long ", 100)
+	msg, err := e.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, body, _ := strings.Cut(string(msg), "\r\n\r\n")
+	for _, line := range strings.Split(body, "\r\n") {
+		if len(line) > 78 {
+			t.Errorf("a line of %d bytes: %.40s...", len(line), line)
+		}
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	e, err := shippedEmail(mail.Address{Name: "Bob", Address: "bob@example.com"},
+		shipping{Name: "Bob", Order: 7, Items: []string{"Socks"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	transcript, err := sendLocal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"C: EHLO", "C: AUTH PLAIN", "C: MAIL FROM:<shop@example.com>", "C: RCPT TO:<bob@example.com>",
+		"C: DATA", "   Subject: ", "C: QUIT"} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("no %q in the session:\n%s", want, transcript)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package stdlib
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+TLS encrypts a connection and proves who is at the other end. The proof is a
+certificate: a public key with a name (DNS name, IP address) signed by a
+certificate authority (CA) that the other side already trusts.
+
+- crypto/x509 creates and parses certificates; crypto/tls runs the protocol.
+- A server presents its certificate chain; the client checks the signature up
+  to a root in tls.Config.RootCAs (the system roots when nil) and that the
+  certificate names the host it dialed (ServerName).
+- With mutual TLS the server also asks for a client certificate and checks it
+  against ClientCAs: the client is authenticated by the handshake itself,
+  before any request is read.
+
+This file plays the CA: it creates a root certificate, signs a server and a
+client certificate with it, and runs an HTTPS server and a mutual TLS TCP
+server on localhost, printing how each handshake ends; tls_test.go has the
+cases.
+
+Usage (from the root of the repository):
+
+	go run ./cmd/learn run 05.standard_lib/tls
+*/
+
+func init() {
+	lessons.Register("05.standard_lib/tls", "a CA, HTTPS and mutual TLS with crypto/x509 and crypto/tls", lessons.Checked(DemoTLS))
+}
+
+// DemoTLS creates a CA and certificates, then runs HTTPS and mutual TLS
+// handshakes.
+func DemoTLS() error {
+	ca, err := newCA("learn-golang test CA")
+	if err != nil {
+		return err
+	}
+	server, err := ca.issue("localhost", x509.ExtKeyUsageServerAuth)
+	if err != nil {
+		return err
+	}
+	client, err := ca.issue("alice", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("CA certificate, PEM encoded as it would be in ca.pem:\n%s\n", ca.pem())
+
+	other, err := newCA("another CA")
+	if err != nil {
+		return err
+	}
+	stranger, err := other.issue("mallory", x509.ExtKeyUsageClientAuth)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("== HTTPS")
+	https, err := newHTTPSServer(server)
+	if err != nil {
+		return err
+	}
+	defer https.Close()
+	fmt.Println("  client trusting the CA, dialing localhost:", outcome(https.get(ca.pool(), "localhost")))
+	fmt.Println("  client trusting the CA, dialing 127.0.0.1:", outcome(https.get(ca.pool(), "127.0.0.1")))
+	// the system roots do not know our CA.
+	fmt.Println("  client with the system roots:", outcome(https.get(nil, "localhost")))
+	fmt.Println("  client trusting another CA:", outcome(https.get(other.pool(), "localhost")))
+
+	fmt.Println("\n== mutual TLS over TCP")
+	mutual, err := newMutualServer(ca, server)
+	if err != nil {
+		return err
+	}
+	defer mutual.Close()
+	fmt.Println("  client with its certificate:", outcome(mutual.dial(client)))
+	fmt.Println("  client without a certificate:", outcome(mutual.dial()))
+	// the server lists the CAs it accepts; the client finds no matching
+	// certificate and sends none.
+	fmt.Println("  client certificate from another CA:", outcome(mutual.dial(stranger)))
+	// a server certificate cannot authenticate a client: wrong key usage.
+	fmt.Println("  server certificate used as a client one:", outcome(mutual.dial(server)))
+	return nil
+}
+
+// authority is a CA that can sign certificates.
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func serialNumber() *big.Int {
+	n, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	return n
+}
+
+func newCA(name string) (*authority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          serialNumber(),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	// self-signed: the template is both the certificate and its parent.
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &authority{cert: cert, key: key}, nil
+}
+
+func (a *authority) pem() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: a.cert.Raw})
+}
+
+// pool returns a pool trusting only this CA.
+func (a *authority) pool() *x509.CertPool {
+	p := x509.NewCertPool()
+	p.AddCert(a.cert)
+	return p
+}
+
+// issue creates a key and a certificate for name signed by the CA. A server
+// name also goes in the DNS names (and 127.0.0.1 in the IPs): clients check
+// those, not the common name.
+func (a *authority) issue(name string, usage x509.ExtKeyUsage) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serialNumber(),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	if usage == x509.ExtKeyUsageServerAuth {
+		tmpl.DNSNames = []string{name}
+		tmpl.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1)}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	// the chain sent in the handshake; the leaf parsed once, not per handshake.
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// outcome is the reply of a handshake that went through, or its error.
+func outcome(reply string, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return reply
+}
+
+// httpsServer is an HTTPS server on localhost answering "hello over" its
+// TLS version.
+type httpsServer struct {
+	srv  *http.Server
+	port string
+}
+
+func newHTTPSServer(cert tls.Certificate) (*httpsServer, error) {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	})
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "hello over %s", tlsVersion(r.TLS.Version))
+		}),
+		// the failed handshakes would be logged on stderr.
+		ErrorLog: log.New(io.Discard, "", 0),
+	}
+	go srv.Serve(l)
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	return &httpsServer{srv: srv, port: port}, nil
+}
+
+// get fetches / from the server dialing host, trusting rootCAs, the system
+// roots when nil.
+func (s *httpsServer) get(rootCAs *x509.CertPool, host string) (string, error) {
+	c := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: rootCAs}}}
+	resp, err := c.Get("https://" + net.JoinHostPort(host, s.port) + "/")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func (s *httpsServer) Close() error { return s.srv.Close() }
+
+// mutualServer is a TCP server on localhost requiring a client certificate
+// signed by its CA, and greeting the client by the name in it.
+type mutualServer struct {
+	l  net.Listener
+	ca *authority
+}
+
+func newMutualServer(ca *authority, cert tls.Certificate) (*mutualServer, error) {
+	l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    ca.pool(),
+		MinVersion:   tls.VersionTLS13,
+	})
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go greet(conn.(*tls.Conn))
+		}
+	}()
+	return &mutualServer{l: l, ca: ca}, nil
+}
+
+// dial connects with the client certificates certs, trusting the CA of the
+// server, and returns the greeting of the server.
+func (s *mutualServer) dial(certs ...tls.Certificate) (string, error) {
+	conn, err := tls.Dial("tcp", s.l.Addr().String(), &tls.Config{
+		RootCAs:      s.ca.pool(),
+		ServerName:   "localhost",
+		Certificates: certs,
+	})
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	// in TLS 1.3 the client finishes its side of the handshake before the
+	// server checked the client certificate: a rejection only shows up on
+	// the first read.
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	return strings.TrimSuffix(line, "\n"), err
+}
+
+func (s *mutualServer) Close() error { return s.l.Close() }
+
+// greet names the client, as the handshake authenticated it.
+func greet(conn *tls.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	// a rejected client gets a TLS alert, sent by Handshake itself.
+	if err := conn.Handshake(); err != nil {
+		return
+	}
+	state := conn.ConnectionState()
+	fmt.Fprintf(conn, "hello %s, %s with %s\n",
+		state.PeerCertificates[0].Subject.CommonName, tlsVersion(state.Version), tls.CipherSuiteName(state.CipherSuite))
+}
+
+func tlsVersion(v uint16) string {
+	switch v {
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	}
+	return fmt.Sprintf("TLS %#x", v)
+}
@@ -0,0 +1,115 @@
+package stdlib
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+	"testing"
+)
+
+// pki is a CA, a server and a client certificate it signed, and a client
+// certificate signed by another CA.
+type pki struct {
+	ca, other      *authority
+	server, client tls.Certificate
+	stranger       tls.Certificate
+}
+
+func newPKI(t *testing.T) pki {
+	t.Helper()
+	var p pki
+	var err error
+	must := func(err error) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	p.ca, err = newCA("test CA")
+	must(err)
+	p.other, err = newCA("another CA")
+	must(err)
+	p.server, err = p.ca.issue("localhost", x509.ExtKeyUsageServerAuth)
+	must(err)
+	p.client, err = p.ca.issue("alice", x509.ExtKeyUsageClientAuth)
+	must(err)
+	p.stranger, err = p.other.issue("mallory", x509.ExtKeyUsageClientAuth)
+	must(err)
+	return p
+}
+
+func TestIssue(t *testing.T) {
+	p := newPKI(t)
+	for _, tt := range []struct {
+		name  string
+		cert  tls.Certificate
+		usage x509.ExtKeyUsage
+		roots *x509.CertPool
+		ok    bool
+	}{
+		{"server", p.server, x509.ExtKeyUsageServerAuth, p.ca.pool(), true},
+		{"client", p.client, x509.ExtKeyUsageClientAuth, p.ca.pool(), true},
+		{"client as a server", p.client, x509.ExtKeyUsageServerAuth, p.ca.pool(), false},
+		{"another CA", p.stranger, x509.ExtKeyUsageClientAuth, p.ca.pool(), false},
+	} {
+		_, err := tt.cert.Leaf.Verify(x509.VerifyOptions{Roots: tt.roots, KeyUsages: []x509.ExtKeyUsage{tt.usage}})
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Verify = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+	if err := p.server.Leaf.VerifyHostname("127.0.0.1"); err != nil {
+		t.Errorf("the server certificate names 127.0.0.1: %v", err)
+	}
+	if !strings.HasPrefix(string(p.ca.pem()), "-----BEGIN CERTIFICATE-----\n") {
+		t.Errorf("pem: %.40s", p.ca.pem())
+	}
+}
+
+func TestHTTPS(t *testing.T) {
+	p := newPKI(t)
+	s, err := newHTTPSServer(p.server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, tt := range []struct {
+		name  string
+		roots *x509.CertPool
+		host  string
+		ok    bool
+	}{
+		{"trusting the CA, dialing localhost", p.ca.pool(), "localhost", true},
+		{"trusting the CA, dialing 127.0.0.1", p.ca.pool(), "127.0.0.1", true},
+		{"the system roots", nil, "localhost", false},
+		{"trusting another CA", p.other.pool(), "localhost", false},
+	} {
+		reply, err := s.get(tt.roots, tt.host)
+		if ok := err == nil && strings.HasPrefix(reply, "hello over TLS"); ok != tt.ok {
+			t.Errorf("%s: %q, %v; want ok %v", tt.name, reply, err, tt.ok)
+		}
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	p := newPKI(t)
+	s, err := newMutualServer(p.ca, p.server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	reply, err := s.dial(p.client)
+	if err != nil || !strings.HasPrefix(reply, "hello alice, TLS 1.3 with ") {
+		t.Errorf("a client certificate of the CA: %q, %v", reply, err)
+	}
+	for _, tt := range []struct {
+		name  string
+		certs []tls.Certificate
+	}{
+		{"no certificate", nil},
+		{"from another CA", []tls.Certificate{p.stranger}},
+		{"a server certificate", []tls.Certificate{p.server}}, // wrong key usage
+	} {
+		if reply, err := s.dial(tt.certs...); err == nil {
+			t.Errorf("%s: %q, want the handshake refused", tt.name, reply)
+		}
+	}
+}
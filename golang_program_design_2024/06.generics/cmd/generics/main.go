@@ -0,0 +1,22 @@
+package main
+
+import (
+	generics "github.com/YongSangUn/learn-golang/golang_program_design_2024/06.generics"
+)
+
+/*
+generics runs the lessons of chapter 06.generics, in order. Their cases are
+table-driven tests: go test ./golang_program_design_2024/06.generics
+
+Usage:
+
+	go run ./golang_program_design_2024/06.generics/cmd/generics
+
+One lesson alone: go run ./cmd/learn run 06.generics/inference
+*/
+
+func main() {
+	generics.DemoFuncs()
+	generics.DemoContainers()
+	generics.DemoInference()
+}
@@ -0,0 +1,162 @@
+package generics
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A type can have type parameters too: Stack[T] is a family of types, and
+Stack[int] one of them. Each instantiation is its own type: a Stack[int] is
+not a Stack[string], nor a Stack[any].
+
+Methods of a generic type use the parameters of the type, named in the
+receiver:
+
+	func (s *Stack[T]) Push(v T)
+
+A method cannot declare type parameters of its own: there is no
+
+	func (s *Stack[T]) Map[U any](f func(T) U) *Stack[U] // does not compile
+
+(method sets are fixed per type, and an interface could not describe such a
+method). What would be one is written as a function instead: MapStack below.
+For the same reason, a method cannot narrow the constraint: Set[T] holds any
+comparable T, but sorting needs cmp.Ordered, so Sorted is a function too.
+
+Instantiation of the methods happens with the type: s.Push on a
+*Stack[int] is Push with T = int, a method value like any other. A method
+expression names the instantiated type in full: (*Stack[int]).Push.
+*/
+
+func init() {
+	lessons.Register("06.generics/containers", "Stack[T] and Set[T], methods of generic types", DemoContainers)
+}
+
+// Stack is a last in, first out stack. The zero value is an empty stack.
+type Stack[T any] struct {
+	items []T
+}
+
+// Push adds v on top.
+func (s *Stack[T]) Push(v T) { s.items = append(s.items, v) }
+
+// Pop removes and returns the top, or the zero T and false when empty.
+func (s *Stack[T]) Pop() (T, bool) {
+	var zero T
+	if len(s.items) == 0 {
+		return zero, false
+	}
+	v := s.items[len(s.items)-1]
+	s.items[len(s.items)-1] = zero // let the GC take what the element points to
+	s.items = s.items[:len(s.items)-1]
+	return v, true
+}
+
+// Peek returns the top without removing it.
+func (s *Stack[T]) Peek() (T, bool) {
+	if len(s.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return s.items[len(s.items)-1], true
+}
+
+// Len is the number of items.
+func (s *Stack[T]) Len() int { return len(s.items) }
+
+// MapStack is the method Stack[T] cannot have: a new type parameter, U.
+func MapStack[T, U any](s *Stack[T], f func(T) U) *Stack[U] {
+	return &Stack[U]{items: Map(s.items, f)}
+}
+
+// Set is a set of comparable values, a map with empty values.
+type Set[T comparable] map[T]struct{}
+
+// NewSet returns a set of the given items.
+func NewSet[T comparable](items ...T) Set[T] {
+	s := make(Set[T], len(items))
+	for _, v := range items {
+		s.Add(v)
+	}
+	return s
+}
+
+// Add adds v.
+func (s Set[T]) Add(v T) { s[v] = struct{}{} }
+
+// Has reports whether v is in the set.
+func (s Set[T]) Has(v T) bool {
+	_, ok := s[v]
+	return ok
+}
+
+// Remove removes v.
+func (s Set[T]) Remove(v T) { delete(s, v) }
+
+// Union returns the items in s or in other.
+func (s Set[T]) Union(other Set[T]) Set[T] {
+	out := make(Set[T], len(s)+len(other))
+	for v := range s {
+		out.Add(v)
+	}
+	for v := range other {
+		out.Add(v)
+	}
+	return out
+}
+
+// Intersect returns the items in both s and other.
+func (s Set[T]) Intersect(other Set[T]) Set[T] {
+	out := make(Set[T])
+	for v := range s {
+		if other.Has(v) {
+			out.Add(v)
+		}
+	}
+	return out
+}
+
+// Sorted returns the items of s in order: a function, since only a Set of an
+// ordered type can be sorted.
+func Sorted[T cmp.Ordered](s Set[T]) []T {
+	out := make([]T, 0, len(s))
+	for v := range s {
+		out = append(out, v)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// DemoContainers uses Stack and Set on a few types; containers_test.go
+// has their cases.
+func DemoContainers() {
+	fmt.Println("== Stack[T]")
+	var s Stack[int] // the zero value is ready to use
+	for _, v := range []int{1, 2, 3} {
+		s.Push(v)
+	}
+	top, _ := s.Pop()
+	fmt.Printf("push 1 2 3, pop: %d, %d left\n", top, s.Len())
+
+	var words Stack[string]
+	push := words.Push // a method value: Push of Stack[string], nothing left to infer
+	push("generic")
+	push("types")
+	pushInt := (*Stack[int]).Push // a method expression names the instantiated type
+	pushInt(&s, 7)
+	lengths := MapStack(&words, func(w string) int { return len(w) })
+	l, _ := lengths.Peek()
+	fmt.Printf("%T, %T: MapStack gives %T, top %d\n", push, pushInt, lengths, l)
+
+	fmt.Println("== Set[T]")
+	a, b := NewSet(1, 2, 3), NewSet(2, 3, 4)
+	fmt.Println("union    ", Sorted(a.Union(b)))
+	fmt.Println("intersect", Sorted(a.Intersect(b)))
+	type point struct{ X, Y int }
+	pts := NewSet(point{1, 2}, point{1, 2}, point{0, 0}) // comparable, not ordered: no Sorted
+	fmt.Println("points   ", len(pts), pts.Has(point{0, 0}))
+}
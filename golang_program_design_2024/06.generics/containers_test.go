@@ -0,0 +1,106 @@
+package generics
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStack(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		push    []int
+		pops    int
+		want    []int // values popped, in order
+		wantLen int
+	}{
+		{"last in, first out", []int{1, 2, 3}, 3, []int{3, 2, 1}, 0},
+		{"partial", []int{1, 2, 3}, 1, []int{3}, 2},
+		{"pop on empty fails", nil, 1, nil, 0},
+		{"more pops than pushes", []int{1}, 3, []int{1}, 0},
+	} {
+		var s Stack[int] // the zero value is ready to use
+		for _, v := range tc.push {
+			s.Push(v)
+		}
+		var got []int
+		for i := 0; i < tc.pops; i++ {
+			if v, ok := s.Pop(); ok {
+				got = append(got, v)
+			}
+		}
+		if !slices.Equal(got, tc.want) || s.Len() != tc.wantLen {
+			t.Errorf("%s: popped %v, len %d; want %v, len %d", tc.name, got, s.Len(), tc.want, tc.wantLen)
+		}
+	}
+
+	var empty Stack[string]
+	if v, ok := empty.Peek(); ok || v != "" {
+		t.Errorf("Peek on empty = %q, %v", v, ok)
+	}
+	// Pop clears the slot it frees: the backing array keeps no pointer.
+	var ptrs Stack[*int]
+	ptrs.Push(new(int))
+	ptrs.Pop()
+	if ptrs.items[:1][0] != nil {
+		t.Error("Pop left the pointer in the backing array")
+	}
+}
+
+func TestStackMethods(t *testing.T) {
+	var words Stack[string]
+	words.Push("generic")
+	push := words.Push // a method value: Push of Stack[string]
+	push("types")
+	if top, _ := words.Peek(); top != "types" || words.Len() != 2 {
+		t.Errorf("after the method value: top %q, len %d", top, words.Len())
+	}
+
+	pushInt := (*Stack[int]).Push // a method expression names the instantiated type
+	var nums Stack[int]
+	pushInt(&nums, 7)
+	if n, _ := nums.Pop(); n != 7 {
+		t.Errorf("method expression pushed %d", n)
+	}
+
+	lengths := MapStack(&words, func(s string) int { return len(s) })
+	var got []int
+	for lengths.Len() > 0 {
+		l, _ := lengths.Pop()
+		got = append(got, l)
+	}
+	if !slices.Equal(got, []int{5, 7}) || words.Len() != 2 {
+		t.Errorf("MapStack popped %v, the source has %d", got, words.Len())
+	}
+}
+
+func TestSet(t *testing.T) {
+	a, b := NewSet(1, 2, 3), NewSet(2, 3, 4)
+	for _, tc := range []struct {
+		name      string
+		got, want []int
+	}{
+		{"union", Sorted(a.Union(b)), []int{1, 2, 3, 4}},
+		{"intersect", Sorted(a.Intersect(b)), []int{2, 3}},
+		{"intersect nothing", Sorted(a.Intersect(NewSet(9))), []int{}},
+		{"duplicates collapse", Sorted(NewSet(5, 5, 1, 5)), []int{1, 5}},
+		{"empty", Sorted(NewSet[int]()), []int{}}, // nothing to infer T from
+		{"operands unchanged", Sorted(a), []int{1, 2, 3}},
+	} {
+		if !slices.Equal(tc.got, tc.want) {
+			t.Errorf("%s: %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+
+	s := NewSet("go", "rust")
+	s.Remove("rust")
+	s.Remove("absent")
+	if !s.Has("go") || s.Has("rust") || len(s) != 1 {
+		t.Errorf("after Remove: %v", Sorted(s))
+	}
+
+	type point struct{ X, Y int }
+	pts := NewSet(point{1, 2}, point{1, 2}, point{0, 0})
+	if len(pts) != 2 || !pts.Has(point{0, 0}) {
+		t.Errorf("set of structs: %v", pts)
+	}
+}
@@ -0,0 +1,3 @@
+// Package generics is chapter 06.generics: type parameters, constraints,
+// generic containers, and where inference stops.
+package generics
@@ -0,0 +1,123 @@
+package generics
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A type parameter list, in square brackets, makes a function work on any type
+its constraint allows:
+
+	func Map[T, U any](s []T, f func(T) U) []U
+
+- any (interface{}) allows every type, and so only what every type can do:
+  assign, pass around, compare to nil through an interface. No operators.
+- comparable allows the types == works on: what a map key or an Index
+  needs.
+- cmp.Ordered (Go 1.21) allows the types < works on: integers, floats,
+  strings. It replaces golang.org/x/exp/constraints.Ordered, which is the
+  same set outside the standard library.
+- A constraint is an interface, and can list types: ~int means int and every
+  type whose underlying type is int, like a `type Celsius int`.
+
+The caller rarely writes the type arguments: Map(nums, strconv.Itoa) infers
+T = int and U = string from the arguments. inference.go shows where that
+stops working.
+*/
+
+func init() {
+	lessons.Register("06.generics/funcs", "Map, Filter, Reduce and the any, comparable and cmp.Ordered constraints", DemoFuncs)
+}
+
+// Map returns f applied to each element of s.
+func Map[T, U any](s []T, f func(T) U) []U {
+	out := make([]U, 0, len(s))
+	for _, v := range s {
+		out = append(out, f(v))
+	}
+	return out
+}
+
+// Filter returns the elements of s for which keep is true.
+func Filter[T any](s []T, keep func(T) bool) []T {
+	var out []T
+	for _, v := range s {
+		if keep(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Reduce folds s into one value, from the left, starting from init.
+func Reduce[T, A any](s []T, init A, f func(A, T) A) A {
+	acc := init
+	for _, v := range s {
+		acc = f(acc, v)
+	}
+	return acc
+}
+
+// Index returns the position of v in s, or -1: comparable, for ==.
+func Index[T comparable](s []T, v T) int {
+	for i, e := range s {
+		if e == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// Max returns the largest of its arguments: cmp.Ordered, for >.
+func Max[T cmp.Ordered](first T, rest ...T) T {
+	m := first
+	for _, v := range rest {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// Number is a constraint listing types: every integer and float type, and
+// the types defined on them (~).
+type Number interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 |
+		~float32 | ~float64
+}
+
+// Sum adds up s. The zero value of T is the start: var total T.
+func Sum[T Number](s []T) T {
+	var total T
+	for _, v := range s {
+		total += v
+	}
+	return total
+}
+
+// Celsius is a defined type: it satisfies ~float64, not float64.
+type Celsius float64
+
+// DemoFuncs calls the generic functions on a few types; funcs_test.go has
+// their cases.
+func DemoFuncs() {
+	fmt.Println("== Map, Filter, Reduce")
+	nums := []int{1, 2, 3, 4, 5, 6}
+	fmt.Printf("%-44s %q\n", "Map(nums, strconv.Itoa)", Map(nums, strconv.Itoa)) // T=int, U=string, both inferred
+	fmt.Printf("%-44s %v\n", "Map(words, length)", Map([]string{"go", "rust", "c"}, func(s string) int { return len(s) }))
+	fmt.Printf("%-44s %v\n", "Filter(nums, even)", Filter(nums, func(n int) bool { return n%2 == 0 }))
+	fmt.Printf("%-44s %v\n", "Reduce(nums, 0, add)", Reduce(nums, 0, func(a, v int) int { return a + v }))
+	fmt.Printf("%-44s %q\n", "Reduce(letters, \"\", upper), another type", Reduce([]string{"a", "b", "c"}, "", func(a, v string) string { return a + strings.ToUpper(v) }))
+
+	fmt.Println("== constraints")
+	fmt.Printf("%-44s %v\n", "comparable: Index of a struct", Index([]struct{ X, Y int }{{1, 2}, {3, 4}}, struct{ X, Y int }{3, 4}))
+	fmt.Printf("%-44s %v\n", "cmp.Ordered: Max of strings", Max("pear", "apple", "plum"))
+	fmt.Printf("%-44s %v\n", "~float64: Sum of Celsius", Sum([]Celsius{20.5, 21.5}))
+	fmt.Printf("%-44s %v\n", "Number: Sum of uint8 wraps", Sum([]uint8{200, 100}))
+}
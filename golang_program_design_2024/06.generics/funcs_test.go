@@ -0,0 +1,91 @@
+package generics
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   []int
+		want []string
+	}{
+		{"ints to strings", []int{1, 2, 3}, []string{"1", "2", "3"}},
+		{"empty", []int{}, []string{}},
+		{"nil", nil, []string{}},
+	} {
+		if got := Map(tc.in, strconv.Itoa); !slices.Equal(got, tc.want) || got == nil {
+			t.Errorf("%s: Map = %#v, want %q", tc.name, got, tc.want)
+		}
+	}
+	if got := Map([]string{"go", "rust", "c"}, func(s string) int { return len(s) }); !slices.Equal(got, []int{2, 4, 1}) {
+		t.Errorf("strings to lengths: %v", got)
+	}
+}
+
+func TestFilter(t *testing.T) {
+	even := func(n int) bool { return n%2 == 0 }
+	for _, tc := range []struct {
+		name string
+		in   []int
+		want []int
+	}{
+		{"keeps the even", []int{1, 2, 3, 4, 5, 6}, []int{2, 4, 6}},
+		{"none kept", []int{1, 3}, nil},
+		{"all kept", []int{2, 4}, []int{2, 4}},
+		{"nil", nil, nil},
+	} {
+		if got := Filter(tc.in, even); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: Filter = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestReduce(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		got, want any
+	}{
+		{"sum", Reduce([]int{1, 2, 3, 4}, 0, func(a, v int) int { return a + v }), 10},
+		{"to another type", Reduce([]string{"a", "b", "c"}, "", func(a, v string) string { return a + strings.ToUpper(v) }), "ABC"},
+		{"count the long words", Reduce([]string{"go", "generics", "are", "here"}, 0, func(n int, w string) int {
+			if len(w) > 3 {
+				n++
+			}
+			return n
+		}), 2},
+		{"from the left", Reduce([]int{1, 2, 3}, "", func(a string, v int) string { return a + strconv.Itoa(v) }), "123"},
+		{"empty gives init", Reduce([]int(nil), 42, func(a, v int) int { return a + v }), 42},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: Reduce = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+// TestConstraints compares results of several types through any: the type
+// must match too, a Sum of uint8 is a uint8.
+func TestConstraints(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		got, want any
+	}{
+		{"comparable: Index of a string", Index([]string{"a", "b", "c"}, "c"), 2},
+		{"comparable: Index of a struct", Index([]struct{ X, Y int }{{1, 2}, {3, 4}}, struct{ X, Y int }{3, 4}), 1},
+		{"comparable: the first of two", Index([]int{5, 1, 5}, 5), 0},
+		{"comparable: not found", Index([]int{1, 2}, 3), -1},
+		{"Ordered: Max of ints", Max(3, 9, 4), 9},
+		{"Ordered: Max of strings", Max("pear", "apple", "plum"), "plum"},
+		{"Ordered: Max of one", Max(1.5), 1.5},
+		{"~float64: Sum of Celsius", Sum([]Celsius{20.5, 21.5}), Celsius(42)},
+		{"Number: Sum of uint8 wraps", Sum([]uint8{200, 100}), uint8(44)},
+		{"Number: Sum of nothing", Sum[int](nil), 0},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: %v (%T), want %v (%T)", tc.name, tc.got, tc.got, tc.want, tc.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package generics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+Type inference fills in the type arguments from the arguments of the call.
+It is good enough that most calls never name a type, and it stops in a few
+places worth knowing:
+
+ 1. Nothing to infer from. A type parameter used only in the result, or
+    only in arguments left out, must be given: Zero[int](), NewSet[int]().
+ 2. Untyped constants. Max(1, 2.5) is a float64: the untyped constants of
+    one parameter take the default type of the "largest" kind among them.
+    Max(1, 2) is an int, even where a float64 was meant.
+ 3. Defined slice types. Filter(s, f) with s a Path ([]string underneath)
+    infers T = string and returns a []string: the Path type, and its
+    methods, are lost. The fix is the pattern of the slices package:
+    a parameter S ~[]E for the slice itself.
+ 4. nil. Map(nil, f) cannot learn T from an untyped nil; it is learned from
+    f, if f is typed. With nil for a func too, nothing is left.
+ 5. Function values. A generic function is not a value until it is
+    instantiated: f := Map does not compile, f := Map[int, string] does, and
+    so does passing Max where a func(int, ...int) int is expected, inferred
+    from the parameter type.
+*/
+
+func init() {
+	lessons.Register("06.generics/inference", "where type inference stops: results, constants, defined types, nil", DemoInference)
+}
+
+// Zero returns the zero value of T: T appears in no argument.
+func Zero[T any]() T {
+	var zero T
+	return zero
+}
+
+// FilterS is Filter keeping the type of the slice: S is Path, not []string.
+func FilterS[S ~[]E, E any](s S, keep func(E) bool) S {
+	var out S
+	for _, v := range s {
+		if keep(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// Path is a defined slice type with a method of its own.
+type Path []string
+
+func (p Path) String() string { return "/" + strings.Join(p, "/") }
+
+// DemoInference prints the type each pitfall ends up with;
+// inference_test.go has the cases.
+func DemoInference() {
+	fmt.Println("== nothing to infer from")
+	// Zero() alone: "cannot infer T". The type argument is given instead.
+	fmt.Printf("%-36s %#v\n", "Zero[int]()", Zero[int]())
+	fmt.Printf("%-36s %#v\n", "Zero[*Path]()", Zero[*Path]())
+
+	fmt.Println("== untyped constants")
+	fmt.Printf("%-36s %v (%T)\n", "Max(1, 2.5)", Max(1, 2.5), Max(1, 2.5))
+	fmt.Printf("%-36s %v (%T)\n", "Max(7, 2) / 2", Max(7, 2)/2, Max(7, 2)/2)
+	fmt.Printf("%-36s %v (%T)\n", "Max[float64](7, 2) / 2", Max[float64](7, 2)/2, Max[float64](7, 2)/2)
+
+	fmt.Println("== defined slice types")
+	p := Path{"usr", "", "local", "bin"}
+	nonEmpty := func(s string) bool { return s != "" }
+	lost := Filter(p, nonEmpty) // []string: no String method any more
+	kept := FilterS(p, nonEmpty)
+	fmt.Printf("%-36s %v (%T)\n", "Filter(path, nonEmpty)", lost, lost)
+	fmt.Printf("%-36s %v (%T)\n", "FilterS(path, nonEmpty)", kept, kept)
+
+	fmt.Println("== function values")
+	// Map(nil, strconv.Itoa) infers T = int from strconv.Itoa; Map(nil, nil)
+	// does not compile.
+	mapInts := Map[int, string] // instantiated: now a func([]int, func(int) string) []string
+	fmt.Printf("%-36s %T\n", "Map[int, string]", mapInts)
+	var largest func(int, ...int) int = Max // inferred from the variable's type
+	fmt.Printf("%-36s %v\n", "largest := Max; largest(3, 8, 1)", largest(3, 8, 1))
+}
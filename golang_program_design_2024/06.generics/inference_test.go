@@ -0,0 +1,44 @@
+package generics
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestInference(t *testing.T) {
+	p := Path{"usr", "", "local", "bin"}
+	nonEmpty := func(s string) bool { return s != "" }
+	mapInts := Map[int, string]
+	var largest func(int, ...int) int = Max
+
+	for _, tc := range []struct {
+		name      string
+		got, want any
+	}{
+		// nothing to infer from: the type argument is given.
+		{"Zero[int]()", Zero[int](), 0},
+		{"Zero[string]()", Zero[string](), ""},
+		{"Zero[*Path]()", Zero[*Path](), (*Path)(nil)},
+		// untyped constants take the default type of the largest kind.
+		{"Max(1, 2.5) is float64", Max(1, 2.5), 2.5},
+		{"Max(1, 2) is int", Max(1, 2), 2},
+		{"Max(7, 2) / 2 truncates", Max(7, 2) / 2, 3},
+		{"Max[float64](7, 2) / 2 does not", Max[float64](7, 2) / 2, 3.5},
+		// a defined slice type is lost by Filter, kept by FilterS.
+		{"Filter returns []string", fmt.Sprintf("%T", Filter(p, nonEmpty)), "[]string"},
+		{"FilterS returns Path", fmt.Sprintf("%T", FilterS(p, nonEmpty)), "generics.Path"},
+		{"FilterS keeps the method", FilterS(p, nonEmpty).String(), "/usr/local/bin"},
+		// function values are instantiated first.
+		{"Map(nil, typed f)", len(Map(nil, func(n int) string { return "" })), 0},
+		{"Map[int, string] as a value", fmt.Sprintf("%T", mapInts), "func([]int, func(int) string) []string"},
+		{"Max assigned to a func type", largest(3, 8, 1), 8},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s: %v (%T), want %v (%T)", tc.name, tc.got, tc.got, tc.want, tc.want)
+		}
+	}
+	if got := mapInts([]int{1}, func(int) string { return "one" }); !slices.Equal(got, []string{"one"}) {
+		t.Errorf("mapInts = %v", got)
+	}
+}
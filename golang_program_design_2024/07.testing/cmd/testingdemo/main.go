@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	testingdemo "github.com/YongSangUn/learn-golang/golang_program_design_2024/07.testing"
+)
+
+/*
+testingdemo runs the tests of chapter 07.testing with go test -v, and the
+ones of the shapes of 03.interface.
+
+Usage (from the chapter directory: go test runs on . and ../03.interface):
+
+	cd golang_program_design_2024/07.testing
+	go run ./cmd/testingdemo
+
+One lesson alone, from the root: go run ./cmd/learn run 07.testing/testing
+*/
+
+func main() {
+	if err := testingdemo.DemoTesting(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,128 @@
+package testingdemo_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/quick"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+)
+
+// update rewrites the golden files instead of comparing with them:
+// go test -run TestColorGolden -update, then review the diff before commit.
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// t.Parallel: the subtests run together, after the loop has started them
+// all. Each row must be independent of the others; since Go 1.22 each
+// iteration has its own tt, so the closures do not share it.
+func TestColorMarshal(t *testing.T) {
+	t.Parallel() // with the other parallel tests of the package, too
+	tests := []struct {
+		name string
+		in   stdlib.Color
+		want string
+	}{
+		{"tomato", stdlib.Color{Red: 255, Green: 99, Blue: 71}, `"#ff6347"`},
+		{"black", stdlib.Color{}, `"#000000"`},
+		{"white", stdlib.Color{Red: 255, Green: 255, Blue: 255}, `"#ffffff"`},
+		{"one digit values", stdlib.Color{Red: 1, Green: 2, Blue: 3}, `"#010203"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := mustMarshal(t, tt.in); got != tt.want {
+				t.Errorf("Marshal(%+v) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColorUnmarshal(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		in      string
+		want    stdlib.Color
+		wantErr bool
+	}{
+		{"lower case", `"#ff6347"`, stdlib.Color{Red: 255, Green: 99, Blue: 71}, false},
+		{"upper case", `"#FF6347"`, stdlib.Color{Red: 255, Green: 99, Blue: 71}, false},
+		{"no #", `"ff6347"`, stdlib.Color{}, true},
+		{"too short", `"#ff63"`, stdlib.Color{}, true},
+		{"not hex", `"#zz6347"`, stdlib.Color{}, true},
+		{"a number", `16737095`, stdlib.Color{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			var got stdlib.Color
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, want error: %t", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Unmarshal(%s) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestColorRoundTrip lets testing/quick pick the colors: 100 random ones by
+// default, more with -quickchecks 10000. Every color survives the trip.
+func TestColorRoundTrip(t *testing.T) {
+	t.Parallel()
+	roundTrip := func(c stdlib.Color) bool {
+		b, err := json.Marshal(c)
+		if err != nil {
+			return false
+		}
+		var back stdlib.Color
+		return json.Unmarshal(b, &back) == nil && back == c
+	}
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestColorGolden compares the output with testdata/colors.golden: a
+// golden file holds the expected output when it is too long to write in
+// the test. go build ignores testdata directories.
+func TestColorGolden(t *testing.T) {
+	palette := map[string]stdlib.Color{
+		"tomato":    {Red: 255, Green: 99, Blue: 71},
+		"steelblue": {Red: 70, Green: 130, Blue: 180},
+		"gold":      {Red: 255, Green: 215},
+	}
+	got, err := json.MarshalIndent(palette, "", "  ") // map keys sorted: stable
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	golden := filepath.Join("testdata", "colors.golden")
+	if *update {
+		if err := os.WriteFile(golden, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n got %s\nwant %s", golden, got, want)
+	}
+}
+
+// An example is documentation that go test runs: it fails if the output
+// differs from the Output comment.
+func Example_color() {
+	b, _ := json.Marshal(stdlib.Color{Red: 255, Green: 99, Blue: 71})
+	fmt.Println(string(b))
+	// Output: "#ff6347"
+}
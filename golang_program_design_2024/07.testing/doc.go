@@ -0,0 +1,5 @@
+// Package testingdemo is chapter 07.testing: go test, with the Person of
+// 02.data_struct, the Color of 05.standard_lib and the shapes of
+// 03.interface as the code under test. The lessons are the _test.go files;
+// the package itself only runs them.
+package testingdemo
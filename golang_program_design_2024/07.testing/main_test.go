@@ -0,0 +1,27 @@
+package testingdemo_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// workDir is a directory for the tests that write files, made once for the
+// whole package by TestMain.
+var workDir string
+
+// TestMain runs instead of the tests: the setup, the tests with m.Run, then
+// the teardown. os.Exit skips deferred calls, hence no defer here.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "07.testing-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "setup:", err)
+		os.Exit(1)
+	}
+	workDir = dir
+
+	code := m.Run()
+
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,132 @@
+package testingdemo_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	datastruct "github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct"
+)
+
+// mustMarshal is a test helper: t.Helper makes a failure point at the line
+// of the test calling it, not at this one.
+func mustMarshal(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal %+v: %v", v, err)
+	}
+	return string(b)
+}
+
+// A table-driven test: one row per case, one loop, and t.Run to make each
+// row a subtest, failing, reported and selected (-run) on its own.
+func TestPersonJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   datastruct.Person
+		want string
+	}{
+		{
+			name: "all fields",
+			in:   datastruct.Person{Name: "Alice", Age: 30, Emails: []string{"alice@example.com"}},
+			want: `{"name":"Alice","age":30,"emails":["alice@example.com"]}`,
+		},
+		{
+			name: "no emails",
+			in:   datastruct.Person{Name: "Bob", Age: 25},
+			want: `{"name":"Bob","age":25}`, // omitempty
+		},
+		{
+			name: "empty emails",
+			in:   datastruct.Person{Name: "Eve", Age: 40, Emails: []string{}},
+			want: `{"name":"Eve","age":40}`, // empty counts as omitted too
+		},
+		{
+			name: "zero value",
+			want: `{"name":"","age":0}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mustMarshal(t, tt.in); got != tt.want {
+				t.Errorf("Marshal(%+v)\n got %s\nwant %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersonUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    datastruct.Person
+		wantErr bool
+	}{
+		{"all fields", `{"name":"Alice","age":30,"emails":["a@example.com"]}`, datastruct.Person{Name: "Alice", Age: 30, Emails: []string{"a@example.com"}}, false},
+		{"unknown field ignored", `{"name":"John","age":30,"city":"San Francisco"}`, datastruct.Person{Name: "John", Age: 30}, false},
+		{"missing fields are zero", `{"name":"Bob"}`, datastruct.Person{Name: "Bob"}, false},
+		{"age of the wrong type", `{"name":"Alice","age":"unknown"}`, datastruct.Person{}, true},
+		{"not JSON", `name: Alice`, datastruct.Person{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got datastruct.Person
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, want error: %t", tt.in, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal(%s)\n got %#v\nwant %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPersonFile writes to the directory TestMain made, a file and back, as
+// 05.standard_lib/jsonstore does, without a file left behind.
+func TestPersonFile(t *testing.T) {
+	people := []datastruct.Person{
+		{Name: "Alice", Age: 30, Emails: []string{"alice@example.com"}},
+		{Name: "Bob", Age: 25},
+	}
+	path := filepath.Join(workDir, "people.json")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.NewEncoder(f).Encode(people); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	f, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []datastruct.Person
+	if err := json.NewDecoder(f).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, people) {
+		t.Errorf("read back %+v, wrote %+v", got, people)
+	}
+}
+
+// TestPersonQuick checks a property on random Persons made by
+// testing/quick: whatever the fields, Marshal gives valid JSON. Round trips
+// would not hold: quick makes invalid UTF-8, which Marshal replaces, and
+// empty Emails, which come back nil.
+func TestPersonQuick(t *testing.T) {
+	valid := func(p datastruct.Person) bool {
+		b, err := json.Marshal(p)
+		return err == nil && json.Valid(b)
+	}
+	if err := quick.Check(valid, nil); err != nil {
+		t.Error(err) // a *quick.CheckError, with the input that failed
+	}
+}
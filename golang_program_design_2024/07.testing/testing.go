@@ -0,0 +1,53 @@
+package testingdemo
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+go test builds the package with its _test.go files and runs every function
+of these forms:
+
+	func TestXxx(t *testing.T)      a test: t.Error marks it failed and goes on, t.Fatal stops it
+	func BenchmarkXxx(b *testing.B) run with -bench only (12.performance)
+	func ExampleXxx()               a doc example, checked against its // Output: comment
+	func TestMain(m *testing.M)     if present, runs instead of the tests: setup, m.Run(), teardown
+
+A _test.go file of package testingdemo sees its unexported names: an internal
+test, like 03.interface/inteface_test.go for the shapes. One of package
+testingdemo_test sees only what is exported, like any user: an external
+test, the files of this chapter.
+
+	person_test.go  table-driven tests, t.Run subtests, test helpers
+	color_test.go   t.Parallel, testing/quick, golden files in testdata/, an Example
+	main_test.go    TestMain: a temporary directory for the tests, removed after
+
+Usage, from the root:
+
+	go test ./golang_program_design_2024/07.testing/ ./golang_program_design_2024/03.interface/
+	go test -v -run 'TestPersonJSON/no_emails' ./golang_program_design_2024/07.testing/
+	go test -run TestColorGolden -update ./golang_program_design_2024/07.testing/
+
+-v prints every test, -run selects them by a regexp on their name, one per
+level of subtest separated by /, with spaces as _. -count=1 disables the
+cache of the results of packages that did not change.
+*/
+
+func init() {
+	lessons.Register("07.testing/testing", "go test: table-driven tests, subtests, helpers, quick, golden files, TestMain", lessons.Checked(DemoTesting))
+}
+
+// DemoTesting runs go test -v on this chapter and on the shapes of
+// 03.interface. It must run from the chapter directory, as cmd/learn does.
+func DemoTesting() error {
+	cmd := exec.Command("go", "test", "-v", "-count=1", ".", "../03.interface")
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go test: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	database "github.com/YongSangUn/learn-golang/golang_program_design_2024/08.database"
+)
+
+/*
+database runs the lessons of chapter 08.database named on the command line,
+or sqlite and migrate when none is: the redis lesson needs a server, so it
+only runs when asked for. A lesson that fails does not stop the next ones;
+the exit status is 1 if any failed.
+
+Usage (the chapter is a module of its own, run it from its directory):
+
+	cd golang_program_design_2024/08.database
+	go run ./cmd/database
+	go run ./cmd/database sqlite migrate redis
+*/
+
+var demos = map[string]func() error{
+	"sqlite":  database.DemoSQLite,
+	"migrate": database.DemoMigrate,
+	"redis":   database.DemoRedis,
+}
+
+func main() {
+	names := os.Args[1:]
+	if len(names) == 0 {
+		names = []string{"sqlite", "migrate"}
+	}
+	failed := 0
+	for _, name := range names {
+		demo, ok := demos[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "no lesson %s: sqlite, migrate or redis\n", name)
+			os.Exit(2)
+		}
+		if err := demo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,5 @@
+// Package database is chapter 08.database: database/sql on SQLite, schema
+// migrations behind a repository interface, and Redis. It is a module of its
+// own because of the driver and client dependencies; cmd/database runs the
+// lessons.
+package database
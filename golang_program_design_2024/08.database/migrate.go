@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+	"github.com/YongSangUn/learn-golang/internal/migrate"
+	"github.com/YongSangUn/learn-golang/internal/users"
+	_ "modernc.org/sqlite"
+)
+
+/*
+A schema changes with the program. Instead of a CREATE TABLE IF NOT EXISTS
+that can never change again, like in sqlite.go, every change is a numbered
+SQL file (internal/users/migrations), and the schema_version table records
+which ones the database already has. Up applies the missing ones in order,
+each in a transaction with its version row: a failed migration leaves the
+database at the previous version, never half changed.
+
+The code using the users goes through the users.Repository interface. The
+same function below runs on SQLite and on the in-memory implementation, which
+is what lets the service code of other chapters run without a database.
+
+	cd golang_program_design_2024/08.database
+	go run ./cmd/database migrate
+*/
+
+func init() {
+	lessons.Register("08.database/migrate", "numbered SQL migrations and one repository interface, SQL and in memory", lessons.Checked(DemoMigrate))
+}
+
+// DemoMigrate migrates a database in a temporary directory, then runs the
+// same code on the SQL and the in-memory user repositories.
+func DemoMigrate() error {
+	dir, err := os.MkdirTemp("", "learn-migrate")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(dir, "users.db"))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	migrations, err := users.Migrations()
+	if err != nil {
+		return err
+	}
+	fmt.Println("== migrations")
+	for i := 0; i < 2; i++ {
+		// the second run finds everything applied and does nothing.
+		done, err := migrate.Up(ctx, db, migrations)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("run %d applied %d: %v\n", i+1, len(done), done)
+	}
+	applied, _ := migrate.Applied(ctx, db)
+	fmt.Println("schema versions:", len(applied))
+	fmt.Println()
+
+	fmt.Println("== SQL repository")
+	if err := exercise(os.Stdout, users.NewSQLRepository(db)); err != nil {
+		return err
+	}
+	fmt.Println("== memory repository")
+	return exercise(os.Stdout, users.NewMemoryRepository())
+}
+
+// exercise only knows the interface: the two runs print the same lines.
+func exercise(w io.Writer, repo users.Repository) error {
+	ctx := context.Background()
+	alice := &users.User{Name: "Alice", Email: "Alice@Example.com"}
+	bob := &users.User{Name: "Bob", Email: "bob@example.com"}
+	for _, u := range []*users.User{alice, bob} {
+		if err := repo.Create(ctx, u); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "created %d %s <%s>\n", u.ID, u.Name, u.Email)
+	}
+
+	err := repo.Create(ctx, &users.User{Name: "Mallory", Email: "alice@example.com"})
+	fmt.Fprintln(w, "same email again:", err, errors.Is(err, users.ErrEmailTaken))
+
+	u, err := repo.GetByEmail(ctx, "ALICE@example.com")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "by email:", u.Name)
+
+	bob.Name = "Robert"
+	fmt.Fprintln(w, "update:", repo.Update(ctx, bob))
+	bob.Email = alice.Email
+	fmt.Fprintln(w, "update to a taken email:", repo.Update(ctx, bob))
+
+	fmt.Fprintln(w, "delete alice:", repo.Delete(ctx, alice.ID))
+	_, err = repo.Get(ctx, alice.ID)
+	fmt.Fprintln(w, "get alice:", err, errors.Is(err, users.ErrNotFound))
+
+	list, _ := repo.List(ctx)
+	for _, u := range list {
+		fmt.Fprintf(w, "left: %d %s <%s>\n", u.ID, u.Name, u.Email)
+	}
+	fmt.Fprintln(w)
+	return nil
+}
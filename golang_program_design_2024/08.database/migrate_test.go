@@ -0,0 +1,179 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/YongSangUn/learn-golang/internal/migrate"
+	"github.com/YongSangUn/learn-golang/internal/users"
+)
+
+// openSQLite opens an empty database in a temporary directory, closed with
+// the test.
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func load(t *testing.T, files fstest.MapFS) []migrate.Migration {
+	t.Helper()
+	list, err := migrate.Load(files, "m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n == 1
+}
+
+func TestUp(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+	files := fstest.MapFS{
+		"m/0002_add_email.sql": {Data: []byte(`ALTER TABLE person ADD COLUMN email TEXT;`)},
+		"m/0001_create.sql":    {Data: []byte(`CREATE TABLE person (id INTEGER PRIMARY KEY, name TEXT);`)},
+		"m/README.md":          {Data: []byte(`not a migration`)},
+	}
+	ran, err := migrate.Up(ctx, db, load(t, files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"0001_create.sql", "0002_add_email.sql"}; !reflect.DeepEqual(ran, want) {
+		t.Fatalf("Up ran %v, want %v in order", ran, want)
+	}
+	if _, err := db.Exec(`INSERT INTO person (name, email) VALUES ('a', 'a@example.com')`); err != nil {
+		t.Fatalf("the schema after Up: %v", err)
+	}
+
+	// a second run has nothing to do; a new file is the only one run.
+	if ran, err := migrate.Up(ctx, db, load(t, files)); err != nil || len(ran) != 0 {
+		t.Fatalf("the second Up ran %v, %v; want nothing", ran, err)
+	}
+	files["m/0003_index.sql"] = &fstest.MapFile{Data: []byte(`CREATE INDEX person_name ON person (name);`)}
+	if ran, err := migrate.Up(ctx, db, load(t, files)); err != nil || !reflect.DeepEqual(ran, []string{"0003_index.sql"}) {
+		t.Fatalf("Up with a new file ran %v, %v", ran, err)
+	}
+	applied, err := migrate.Applied(ctx, db)
+	if err != nil || len(applied) != 3 {
+		t.Errorf("Applied = %v, %v; want 3 versions", applied, err)
+	}
+}
+
+// TestUpFailure runs a migration failing in its second statement: the
+// first one is rolled back with it, and a fixed file runs on the next Up.
+func TestUpFailure(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+	files := fstest.MapFS{
+		"m/0001_create.sql": {Data: []byte(`CREATE TABLE a (x INTEGER);`)},
+		"m/0002_broken.sql": {Data: []byte(`CREATE TABLE b (x INTEGER); INSERT INTO nowhere VALUES (1);`)},
+	}
+	ran, err := migrate.Up(ctx, db, load(t, files))
+	if err == nil || !strings.Contains(err.Error(), "0002_broken.sql") {
+		t.Fatalf("Up = %v, want the error of 0002_broken.sql", err)
+	}
+	if !reflect.DeepEqual(ran, []string{"0001_create.sql"}) {
+		t.Errorf("Up ran %v before failing, want 0001_create.sql", ran)
+	}
+	if tableExists(t, db, "b") {
+		t.Error("the failed migration left table b")
+	}
+	if applied, _ := migrate.Applied(ctx, db); len(applied) != 1 {
+		t.Errorf("versions after the failure: %v, want 1 only", applied)
+	}
+
+	files["m/0002_broken.sql"] = &fstest.MapFile{Data: []byte(`CREATE TABLE b (x INTEGER);`)}
+	if ran, err := migrate.Up(ctx, db, load(t, files)); err != nil || len(ran) != 1 || !tableExists(t, db, "b") {
+		t.Errorf("Up after the fix ran %v, %v", ran, err)
+	}
+}
+
+// TestUpNewerDatabase refuses a database migrated by a program with more
+// migrations than this one.
+func TestUpNewerDatabase(t *testing.T) {
+	db := openSQLite(t)
+	ctx := context.Background()
+	newer := fstest.MapFS{
+		"m/0001_a.sql": {Data: []byte(`CREATE TABLE a (x INTEGER);`)},
+		"m/0002_b.sql": {Data: []byte(`CREATE TABLE b (x INTEGER);`)},
+	}
+	if _, err := migrate.Up(ctx, db, load(t, newer)); err != nil {
+		t.Fatal(err)
+	}
+	delete(newer, "m/0002_b.sql")
+	if _, err := migrate.Up(ctx, db, load(t, newer)); err == nil || !strings.Contains(err.Error(), "version 2") {
+		t.Errorf("Up with an older list = %v, want an error naming version 2", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{"no number", fstest.MapFS{"m/create.sql": {}}},
+		{"no description", fstest.MapFS{"m/0001.sql": {}}},
+		{"version 0", fstest.MapFS{"m/0000_zero.sql": {}}},
+		{"same version", fstest.MapFS{"m/0001_a.sql": {}, "m/1_b.sql": {}}},
+	} {
+		if list, err := migrate.Load(tt.files, "m"); err == nil {
+			t.Errorf("%s: Load = %v, want an error", tt.name, list)
+		}
+	}
+	if _, err := migrate.Load(fstest.MapFS{}, "m"); err == nil {
+		t.Error("Load of a missing directory succeeded")
+	}
+}
+
+// TestRepositories runs the lesson's code on both implementations: the
+// interface promises the same behaviour, so the output is the same.
+func TestRepositories(t *testing.T) {
+	db := openSQLite(t)
+	migrations, err := users.Migrations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrate.Up(context.Background(), db, migrations); err != nil {
+		t.Fatal(err)
+	}
+	var fromSQL, fromMemory bytes.Buffer
+	if err := exercise(&fromSQL, users.NewSQLRepository(db)); err != nil {
+		t.Fatal(err)
+	}
+	if err := exercise(&fromMemory, users.NewMemoryRepository()); err != nil {
+		t.Fatal(err)
+	}
+	if fromSQL.String() != fromMemory.String() {
+		t.Errorf("SQL:\n%s\nmemory:\n%s", &fromSQL, &fromMemory)
+	}
+	for _, want := range []string{"same email again: users: email already registered true", "left: 2 Robert <bob@example.com>"} {
+		if !strings.Contains(fromSQL.String(), want) {
+			t.Errorf("no %q in\n%s", want, &fromSQL)
+		}
+	}
+}
+
+func TestDemoMigrate(t *testing.T) {
+	if err := DemoMigrate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,215 @@
+// Package people is the person repository of 08.database/sqlite.go, over
+// database/sql and SQLite: a pool opened once, prepared statements,
+// transactions, NULL columns and contexts. sqlite.go runs it as a demo,
+// people_test.go tests it against a database in a temporary file.
+package people
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite" // the "sqlite" driver of Open
+)
+
+// Person is the struct of 02.data_struct/struct.go. In the database a person
+// also has an id, and a nickname that may be NULL.
+type Person struct {
+	ID       int64
+	Name     string
+	Age      int
+	Emails   []string
+	Nickname sql.NullString
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS person (
+	id       INTEGER PRIMARY KEY,
+	name     TEXT    NOT NULL,
+	age      INTEGER NOT NULL CHECK (age >= 0),
+	nickname TEXT    -- NULL when the person has none
+);
+CREATE TABLE IF NOT EXISTS email (
+	person_id INTEGER NOT NULL REFERENCES person(id) ON DELETE CASCADE,
+	address   TEXT    NOT NULL UNIQUE
+);`
+
+// Open opens the SQLite database at path, creating the file and the tables
+// if needed, with a pool of one connection.
+func Open(path string) (*sql.DB, error) {
+	// pragmas in the DSN are applied to every connection of the pool.
+	dsn := "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite has a single writer at a time; with one connection, concurrent
+	// writes queue in the pool instead of failing with "database is locked".
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	// Open does not connect, Ping checks that the database is really there.
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// ErrNotFound is returned for an id with no person.
+var ErrNotFound = errors.New("person not found")
+
+// PersonRepo keeps the SQL of the person table in one place, with the
+// statements used most prepared once.
+type PersonRepo struct {
+	db       *sql.DB
+	getStmt  *sql.Stmt
+	listStmt *sql.Stmt
+}
+
+// NewPersonRepo prepares the statements of the repository on db, which must
+// have the tables of Open.
+func NewPersonRepo(db *sql.DB) (*PersonRepo, error) {
+	r := &PersonRepo{db: db}
+	var err error
+	if r.getStmt, err = db.Prepare(`SELECT id, name, age, nickname FROM person WHERE id = ?`); err != nil {
+		return nil, err
+	}
+	if r.listStmt, err = db.Prepare(`SELECT id, name, age, nickname FROM person WHERE age >= ? ORDER BY name`); err != nil {
+		r.getStmt.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// Close releases the prepared statements; the db stays open.
+func (r *PersonRepo) Close() error {
+	return errors.Join(r.getStmt.Close(), r.listStmt.Close())
+}
+
+// Create inserts the person and its emails in one transaction: either all
+// rows are written, or none.
+func (r *PersonRepo) Create(ctx context.Context, p *Person) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// Rollback after a successful Commit does nothing, so deferring it covers
+	// every early return below.
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO person (name, age, nickname) VALUES (?, ?, ?)`, p.Name, p.Age, p.Nickname)
+	if err != nil {
+		return fmt.Errorf("insert person: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// a statement prepared on the transaction, reused for every email.
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO email (person_id, address) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for _, addr := range p.Emails {
+		if _, err := stmt.ExecContext(ctx, id, addr); err != nil {
+			return fmt.Errorf("insert email %s: %w", addr, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	p.ID = id
+	return nil
+}
+
+// Get returns the person with the id, emails included, or ErrNotFound.
+func (r *PersonRepo) Get(ctx context.Context, id int64) (*Person, error) {
+	var p Person
+	err := r.getStmt.QueryRowContext(ctx, id).Scan(&p.ID, &p.Name, &p.Age, &p.Nickname)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Emails, err = r.emails(ctx, id); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *PersonRepo) emails(ctx context.Context, id int64) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT address FROM email WHERE person_id = ? ORDER BY address`, id)
+	if err != nil {
+		return nil, err
+	}
+	// rows holds a connection of the pool until closed.
+	defer rows.Close()
+	var emails []string
+	for rows.Next() {
+		var addr string
+		if err := rows.Scan(&addr); err != nil {
+			return nil, err
+		}
+		emails = append(emails, addr)
+	}
+	// the loop also ends on an error, which only rows.Err reports.
+	return emails, rows.Err()
+}
+
+// List returns the people at least minAge years old, by name.
+func (r *PersonRepo) List(ctx context.Context, minAge int) ([]Person, error) {
+	rows, err := r.listStmt.QueryContext(ctx, minAge)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var people []Person
+	for rows.Next() {
+		var p Person
+		if err := rows.Scan(&p.ID, &p.Name, &p.Age, &p.Nickname); err != nil {
+			return nil, err
+		}
+		people = append(people, p)
+	}
+	return people, rows.Err()
+}
+
+// UpdateAge sets the age of the person with the id, or returns ErrNotFound.
+func (r *PersonRepo) UpdateAge(ctx context.Context, id int64, age int) error {
+	res, err := r.db.ExecContext(ctx, `UPDATE person SET age = ? WHERE id = ?`, age, id)
+	if err != nil {
+		return err
+	}
+	// no row matched: the person does not exist.
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes the person with the id and its emails, or returns
+// ErrNotFound.
+func (r *PersonRepo) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM person WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package people
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// openTest opens a repository on a new database file in a temporary
+// directory, removed with the test.
+func openTest(t *testing.T) (*PersonRepo, *sql.DB, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "people.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := NewPersonRepo(db)
+	if err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+		db.Close()
+	})
+	return repo, db, path
+}
+
+func count(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestCRUD(t *testing.T) {
+	repo, _, _ := openTest(t)
+	ctx := context.Background()
+
+	alice := &Person{Name: "Alice", Age: 30, Emails: []string{"b@example.com", "a@example.com"}}
+	if err := repo.Create(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	if alice.ID == 0 {
+		t.Fatal("Create did not set the ID")
+	}
+	got, err := repo.Get(ctx, alice.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Person{ID: alice.ID, Name: "Alice", Age: 30, Emails: []string{"a@example.com", "b@example.com"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Get = %+v, want %+v", got, want)
+	}
+
+	if err := repo.UpdateAge(ctx, alice.ID, 31); err != nil {
+		t.Fatal(err)
+	}
+	if p, _ := repo.Get(ctx, alice.ID); p.Age != 31 {
+		t.Errorf("age after UpdateAge = %d", p.Age)
+	}
+	if err := repo.Delete(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, err := range map[string]error{
+		"Get":       func() error { _, err := repo.Get(ctx, alice.ID); return err }(),
+		"UpdateAge": repo.UpdateAge(ctx, alice.ID, 1),
+		"Delete":    repo.Delete(ctx, alice.ID),
+	} {
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s of a deleted person: %v, want ErrNotFound", name, err)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	repo, _, _ := openTest(t)
+	ctx := context.Background()
+	for _, p := range []*Person{{Name: "Carol", Age: 41}, {Name: "Bob", Age: 25}, {Name: "Alice", Age: 30}} {
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range []struct {
+		minAge int
+		want   []string
+	}{
+		{0, []string{"Alice", "Bob", "Carol"}},
+		{30, []string{"Alice", "Carol"}},
+		{50, nil},
+	} {
+		people, err := repo.List(ctx, tt.minAge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, p := range people {
+			names = append(names, p.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("List(%d) = %v, want %v", tt.minAge, names, tt.want)
+		}
+	}
+}
+
+func TestNickname(t *testing.T) {
+	repo, db, _ := openTest(t)
+	ctx := context.Background()
+	for _, nick := range []sql.NullString{{String: "Caz", Valid: true}, {}, {String: "", Valid: true}} {
+		p := &Person{Name: "Carol", Age: 41, Nickname: nick}
+		if err := repo.Create(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := repo.Get(ctx, p.ID)
+		if got.Nickname != nick {
+			t.Errorf("nickname %+v came back as %+v", nick, got.Nickname)
+		}
+	}
+	// NULL and "" are different values in SQL.
+	if n := count(t, db, `SELECT count(*) FROM person WHERE nickname IS NULL`); n != 1 {
+		t.Errorf("%d NULL nicknames, want 1", n)
+	}
+}
+
+func TestCreateRollback(t *testing.T) {
+	repo, db, _ := openTest(t)
+	ctx := context.Background()
+	if err := repo.Create(ctx, &Person{Name: "Alice", Age: 30, Emails: []string{"alice@example.com"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	eve := &Person{Name: "Eve", Age: 22, Emails: []string{"eve@example.com", "alice@example.com"}}
+	if err := repo.Create(ctx, eve); err == nil {
+		t.Fatal("Create with a taken email succeeded")
+	}
+	if eve.ID != 0 {
+		t.Errorf("ID set on a failed Create: %d", eve.ID)
+	}
+	if n := count(t, db, `SELECT count(*) FROM person`); n != 1 {
+		t.Errorf("%d people, want 1: the insert of Eve was not rolled back", n)
+	}
+	if n := count(t, db, `SELECT count(*) FROM email WHERE address = ?`, "eve@example.com"); n != 0 {
+		t.Errorf("the first email of Eve was kept")
+	}
+}
+
+func TestConstraints(t *testing.T) {
+	repo, db, _ := openTest(t)
+	ctx := context.Background()
+	if err := repo.Create(ctx, &Person{Name: "Tim", Age: -1}); err == nil {
+		t.Error("a negative age passed the CHECK")
+	}
+
+	p := &Person{Name: "Bob", Age: 25, Emails: []string{"bob@example.com", "bobby@example.com"}}
+	if err := repo.Create(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	repo.Delete(ctx, p.ID)
+	if n := count(t, db, `SELECT count(*) FROM email`); n != 0 {
+		t.Errorf("%d emails left: ON DELETE CASCADE needs the foreign_keys pragma", n)
+	}
+}
+
+func TestCancelledContext(t *testing.T) {
+	repo, _, _ := openTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := repo.Create(ctx, &Person{Name: "Alice", Age: 30}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Create = %v, want context.Canceled", err)
+	}
+	if _, err := repo.List(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("List = %v, want context.Canceled", err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	repo, db, path := openTest(t)
+	ctx := context.Background()
+	p := &Person{Name: "Alice", Age: 30, Emails: []string{"alice@example.com"}}
+	if err := repo.Create(ctx, p); err != nil {
+		t.Fatal(err)
+	}
+	repo.Close()
+	db.Close()
+
+	// the schema is created IF NOT EXISTS: opening again keeps the rows.
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	repo, err = NewPersonRepo(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer repo.Close()
+	got, err := repo.Get(ctx, p.ID)
+	if err != nil || got.Name != "Alice" || len(got.Emails) != 1 {
+		t.Errorf("after reopening: %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,321 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+	"github.com/redis/go-redis/v9"
+)
+
+/*
+Redis is an in-memory key-value server, used as a cache, a counter store, a
+message bus or a lock service. go-redis talks to it: every command is a method
+returning a Cmd, whose Result (or Err, Val, Int, ...) gives the reply.
+
+- A missing key is not a failure of the server but the error redis.Nil,
+  compared with == or errors.Is.
+- A key can expire: SET with a TTL, and the server deletes it on its own.
+- A pipeline sends many commands in one round trip instead of one each.
+- PUBLISH sends to whoever is subscribed to a channel right now; nothing is
+  stored, a subscriber that is not connected misses the message.
+- SET NX PX stores a key only if it does not exist, with an expiry: the
+  building block of a lock shared by several processes.
+
+Needs a Redis server, REDIS_ADDR (default localhost:6379) says where:
+
+	docker run --rm -p 6379:6379 redis:7
+	cd golang_program_design_2024/08.database
+	go run ./cmd/database redis
+
+The keys used all start with "learn:" and are deleted at the end. The tests
+in redis_test.go need no server: they run against miniredis, a Redis
+written in Go that starts inside the test.
+*/
+
+func init() {
+	lessons.Register("08.database/redis", "go-redis: GET/SET with TTL, pipelines, pub/sub and a lock; needs a server", lessons.Checked(DemoRedis))
+}
+
+// DemoRedis runs the Redis lessons against the server at REDIS_ADDR.
+func DemoRedis() error {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+	// the client is a pool of connections, like sql.DB: one for the program.
+	rdb := redis.NewClient(&redis.Options{Addr: addr})
+	defer rdb.Close()
+
+	ctx := context.Background()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("no redis at %s: %w", addr, err)
+	}
+	defer cleanup(rdb)
+
+	for _, lesson := range []func(context.Context, *redis.Client) error{getSet, pipelines, pubSub, locks} {
+		if err := lesson(ctx, rdb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func cleanup(rdb *redis.Client) {
+	ctx := context.Background()
+	keys, _ := rdb.Keys(ctx, "learn:*").Result()
+	if len(keys) > 0 {
+		rdb.Del(ctx, keys...)
+	}
+}
+
+func getSet(ctx context.Context, rdb *redis.Client) error {
+	fmt.Println("== GET and SET")
+
+	// 0 means no expiry.
+	if err := rdb.Set(ctx, "learn:name", "Alice", 0).Err(); err != nil {
+		return err
+	}
+	name, err := rdb.Get(ctx, "learn:name").Result()
+	fmt.Println("name:", name, err)
+
+	// a missing key is redis.Nil, told apart from a real error.
+	_, err = rdb.Get(ctx, "learn:missing").Result()
+	if errors.Is(err, redis.Nil) {
+		fmt.Println("missing key: redis.Nil")
+	}
+
+	rdb.Set(ctx, "learn:session", "token", 200*time.Millisecond)
+	ttl, _ := rdb.TTL(ctx, "learn:session").Result()
+	fmt.Println("session ttl:", ttl)
+	time.Sleep(300 * time.Millisecond)
+	_, err = rdb.Get(ctx, "learn:session").Result()
+	fmt.Println("after expiry:", err)
+
+	// INCR is atomic on the server: no read-modify-write race between clients.
+	for i := 0; i < 3; i++ {
+		rdb.Incr(ctx, "learn:visits")
+	}
+	visits, _ := rdb.Get(ctx, "learn:visits").Int()
+	fmt.Println("visits:", visits)
+	fmt.Println()
+	return nil
+}
+
+func pipelines(ctx context.Context, rdb *redis.Client) error {
+	fmt.Println("== pipelines")
+	const n = 1000
+
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		rdb.Set(ctx, fmt.Sprintf("learn:one:%d", i), i, time.Minute)
+	}
+	fmt.Printf("%d SETs one by one: %v\n", n, time.Since(start).Round(time.Millisecond))
+
+	// the commands are queued, sent together when the function returns, and
+	// their replies filled in the Cmds.
+	start = time.Now()
+	cmds, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i := 0; i < n; i++ {
+			pipe.Set(ctx, fmt.Sprintf("learn:pipe:%d", i), i, time.Minute)
+		}
+		return nil
+	})
+	fmt.Printf("%d SETs pipelined: %v (%d replies, err=%v)\n", n, time.Since(start).Round(time.Millisecond), len(cmds), err)
+
+	// reading back: keep the Cmds, read them after Exec.
+	pipe := rdb.Pipeline()
+	gets := make([]*redis.StringCmd, 3)
+	for i := range gets {
+		gets[i] = pipe.Get(ctx, fmt.Sprintf("learn:pipe:%d", i))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	for _, c := range gets {
+		fmt.Print(c.Val(), " ")
+	}
+	fmt.Println()
+
+	rdb.MSet(ctx, "learn:balance:alice", 100, "learn:balance:bob", 0)
+	// TxPipelined wraps the commands in MULTI/EXEC: no other client's command
+	// runs in the middle of them.
+	rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.DecrBy(ctx, "learn:balance:alice", 30)
+		pipe.IncrBy(ctx, "learn:balance:bob", 30)
+		return nil
+	})
+	a, _ := rdb.Get(ctx, "learn:balance:alice").Int()
+	b, _ := rdb.Get(ctx, "learn:balance:bob").Int()
+	fmt.Printf("transfer in a transaction: alice %d, bob %d\n\n", a, b)
+	return nil
+}
+
+func pubSub(ctx context.Context, rdb *redis.Client) error {
+	fmt.Println("== pub/sub")
+
+	sub := rdb.Subscribe(ctx, "learn:news")
+	defer sub.Close()
+	// Subscribe returns before the server confirmed; a message published in
+	// between would be lost. Receive waits for the confirmation.
+	if _, err := sub.Receive(ctx); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range sub.Channel() {
+			fmt.Printf("received on %s: %s\n", msg.Channel, msg.Payload)
+			if msg.Payload == "bye" {
+				return
+			}
+		}
+	}()
+
+	for _, m := range []string{"hello", "world", "bye"} {
+		// the reply is the number of subscribers that got it.
+		n, _ := rdb.Publish(ctx, "learn:news", m).Result()
+		fmt.Printf("published %q to %d subscriber(s)\n", m, n)
+	}
+	<-done
+
+	n, _ := rdb.Publish(ctx, "learn:nobody", "lost").Result()
+	fmt.Printf("published to a channel without subscribers: %d receivers, the message is gone\n\n", n)
+	return nil
+}
+
+var ErrLockHeld = errors.New("lock held by someone else")
+
+// Lock is a lock shared by every process using the same Redis. It expires
+// after its TTL, so a process that dies holding it does not block the others
+// forever; the price is that work taking longer than the TTL must Refresh it.
+type Lock struct {
+	rdb   *redis.Client
+	key   string
+	token string
+}
+
+// AcquireLock takes the lock key for ttl, or returns ErrLockHeld.
+func AcquireLock(ctx context.Context, rdb *redis.Client, key string, ttl time.Duration) (*Lock, error) {
+	// the random token tells our lock from the next owner's: see Release.
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(buf)
+
+	// SET key token NX PX ttl
+	ok, err := rdb.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrLockHeld
+	}
+	return &Lock{rdb: rdb, key: key, token: token}, nil
+}
+
+// A plain DEL could delete the lock of someone else: ours expired while we
+// were slow, another process took it, and we would now release theirs. The
+// check and the delete run as one script, atomically on the server.
+var (
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+	refreshScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+)
+
+// Release frees the lock. It returns ErrLockHeld if the lock expired and is
+// now someone else's.
+func (l *Lock) Release(ctx context.Context) error {
+	n, err := releaseScript.Run(ctx, l.rdb, []string{l.key}, l.token).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockHeld
+	}
+	return nil
+}
+
+// Refresh extends the lock to ttl from now, if it is still ours.
+func (l *Lock) Refresh(ctx context.Context, ttl time.Duration) error {
+	n, err := refreshScript.Run(ctx, l.rdb, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockHeld
+	}
+	return nil
+}
+
+func locks(ctx context.Context, rdb *redis.Client) error {
+	fmt.Println("== distributed lock")
+
+	first, err := AcquireLock(ctx, rdb, "learn:lock", time.Second)
+	fmt.Println("first acquire:", err)
+	if err != nil {
+		return err
+	}
+	_, err = AcquireLock(ctx, rdb, "learn:lock", time.Second)
+	fmt.Println("second acquire:", err)
+	fmt.Println("refresh:", first.Refresh(ctx, time.Second))
+	fmt.Println("release:", first.Release(ctx))
+	fmt.Println("release twice:", first.Release(ctx))
+
+	// the lock expires, a second owner takes it: the first one cannot
+	// release it any more.
+	stale, err := AcquireLock(ctx, rdb, "learn:lock", 100*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	time.Sleep(150 * time.Millisecond)
+	owner, err := AcquireLock(ctx, rdb, "learn:lock", time.Second)
+	fmt.Println("acquire after expiry:", err)
+	if err != nil {
+		return err
+	}
+	fmt.Println("stale release:", stale.Release(ctx))
+	owner.Release(ctx)
+
+	// workers (think: processes on several machines) increment a counter with
+	// GET and SET, a race without the lock.
+	var wg sync.WaitGroup
+	for w := 0; w < 5; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				var l *Lock
+				for {
+					var err error
+					if l, err = AcquireLock(ctx, rdb, "learn:lock", time.Second); err == nil {
+						break
+					}
+					time.Sleep(time.Millisecond)
+				}
+				n, _ := rdb.Get(ctx, "learn:counter").Int()
+				rdb.Set(ctx, "learn:counter", n+1, 0)
+				l.Release(ctx)
+			}
+		}()
+	}
+	wg.Wait()
+	n, _ := rdb.Get(ctx, "learn:counter").Int()
+	fmt.Printf("counter after 5 workers x 20 locked increments: %d\n", n)
+	return nil
+}
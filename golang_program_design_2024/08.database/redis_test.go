@@ -0,0 +1,145 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newRedis starts an in-process Redis, miniredis, and a client for it, both
+// closed with the test. Keys expire when the test moves the server's clock
+// with FastForward, not on their own.
+func newRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: srv.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	return srv, rdb
+}
+
+func TestLock(t *testing.T) {
+	_, rdb := newRedis(t)
+	ctx := context.Background()
+
+	l, err := AcquireLock(ctx, rdb, "learn:lock", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireLock(ctx, rdb, "learn:lock", time.Second); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("a second acquire = %v, want ErrLockHeld", err)
+	}
+	if err := l.Refresh(ctx, time.Minute); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if ttl := rdb.PTTL(ctx, "learn:lock").Val(); ttl <= time.Second {
+		t.Errorf("TTL after the refresh: %v, want a minute", ttl)
+	}
+	if err := l.Release(ctx); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if err := l.Release(ctx); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("a second release = %v, want ErrLockHeld", err)
+	}
+	if err := l.Refresh(ctx, time.Second); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("a refresh after the release = %v, want ErrLockHeld", err)
+	}
+	if _, err := AcquireLock(ctx, rdb, "learn:lock", time.Second); err != nil {
+		t.Errorf("acquire after the release: %v", err)
+	}
+}
+
+// TestLockExpired lets a lock expire and another owner take it: the token
+// keeps the first owner from releasing or extending the new lock.
+func TestLockExpired(t *testing.T) {
+	srv, rdb := newRedis(t)
+	ctx := context.Background()
+
+	stale, err := AcquireLock(ctx, rdb, "learn:lock", 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.FastForward(150 * time.Millisecond)
+	owner, err := AcquireLock(ctx, rdb, "learn:lock", time.Second)
+	if err != nil {
+		t.Fatalf("acquire after the expiry: %v", err)
+	}
+	if err := stale.Release(ctx); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("the stale release = %v, want ErrLockHeld", err)
+	}
+	if err := stale.Refresh(ctx, time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("the stale refresh = %v, want ErrLockHeld", err)
+	}
+	if got := rdb.Get(ctx, "learn:lock").Val(); got != owner.token {
+		t.Errorf("the lock holds %q, want the new owner's token", got)
+	}
+	if err := owner.Release(ctx); err != nil {
+		t.Errorf("the owner's release: %v", err)
+	}
+}
+
+// TestLockExclusion increments a counter with GET and SET from several
+// goroutines: with the lock, no increment is lost.
+func TestLockExclusion(t *testing.T) {
+	_, rdb := newRedis(t)
+	ctx := context.Background()
+	const workers, each = 5, 20
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				l, err := AcquireLock(ctx, rdb, "learn:lock", time.Second)
+				for errors.Is(err, ErrLockHeld) {
+					time.Sleep(time.Millisecond)
+					l, err = AcquireLock(ctx, rdb, "learn:lock", time.Second)
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				n, _ := rdb.Get(ctx, "learn:counter").Int()
+				rdb.Set(ctx, "learn:counter", n+1, 0)
+				if err := l.Release(ctx); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n, _ := rdb.Get(ctx, "learn:counter").Int(); n != workers*each {
+		t.Errorf("counter = %d, want %d", n, workers*each)
+	}
+}
+
+// TestLessons runs the lessons that do not wait for a key to expire in real
+// time against miniredis, then checks the cleanup.
+func TestLessons(t *testing.T) {
+	_, rdb := newRedis(t)
+	ctx := context.Background()
+	for name, lesson := range map[string]func(context.Context, *redis.Client) error{
+		"getSet": getSet, "pipelines": pipelines, "pubSub": pubSub,
+	} {
+		if err := lesson(ctx, rdb); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if n, _ := rdb.Get(ctx, "learn:visits").Int(); n != 3 {
+		t.Errorf("visits = %d, want 3", n)
+	}
+	if a, b := rdb.Get(ctx, "learn:balance:alice").Val(), rdb.Get(ctx, "learn:balance:bob").Val(); a != "70" || b != "30" {
+		t.Errorf("balances after the transaction: alice %s, bob %s", a, b)
+	}
+	rdb.Set(ctx, "other:key", 1, 0)
+	cleanup(rdb)
+	if keys := rdb.Keys(ctx, "*").Val(); len(keys) != 1 || keys[0] != "other:key" {
+		t.Errorf("keys after the cleanup: %v, want only other:key", keys)
+	}
+}
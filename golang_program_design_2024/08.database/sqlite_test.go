@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/08.database/people"
+)
+
+// openPeople opens the person repository on a database in a temporary
+// directory, closed with the test.
+func openPeople(t *testing.T) (*people.PersonRepo, *sql.DB) {
+	t.Helper()
+	db, err := people.Open(filepath.Join(t.TempDir(), "people.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := people.NewPersonRepo(db)
+	if err != nil {
+		db.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		repo.Close()
+		db.Close()
+	})
+	return repo, db
+}
+
+// TestDemoSQLite runs the whole lesson: every step checks what it prints.
+func TestDemoSQLite(t *testing.T) {
+	if err := DemoSQLite(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCRUDStep(t *testing.T) {
+	repo, db := openPeople(t)
+	if err := crud(repo); err != nil {
+		t.Fatal(err)
+	}
+	var names string
+	if err := db.QueryRow(`SELECT group_concat(name) FROM person`).Scan(&names); err != nil {
+		t.Fatal(err)
+	}
+	if names != "Alice" {
+		t.Errorf("people left after the step: %q, want Alice", names)
+	}
+}
+
+// TestNullHandling checks both sides of the lesson: NullString reads a NULL
+// back as not valid, a plain string cannot hold it.
+func TestNullHandling(t *testing.T) {
+	repo, db := openPeople(t)
+	if err := nullHandling(repo, db); err != nil {
+		t.Fatal(err)
+	}
+	var nick sql.NullString
+	if err := db.QueryRow(`SELECT nickname FROM person WHERE name = 'Dave'`).Scan(&nick); err != nil || nick.Valid {
+		t.Errorf("Dave's nickname = %+v, %v; want NULL", nick, err)
+	}
+	if err := db.QueryRow(`SELECT nickname FROM person WHERE name = 'Carol'`).Scan(&nick); err != nil || nick != (sql.NullString{String: "Caz", Valid: true}) {
+		t.Errorf("Carol's nickname = %+v, %v; want Caz", nick, err)
+	}
+}
+
+// TestTransactionRollback runs the step on a database where Alice's email is
+// taken, as in the lesson, then without her: Eve is created.
+func TestTransactionRollback(t *testing.T) {
+	repo, db := openPeople(t)
+	ctx := context.Background()
+	alice := &people.Person{Name: "Alice", Age: 30, Emails: []string{"alice@example.com"}}
+	if err := repo.Create(ctx, alice); err != nil {
+		t.Fatal(err)
+	}
+	if err := transactionRollback(repo, db); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Delete(ctx, alice.ID); err != nil {
+		t.Fatal(err)
+	}
+	err := transactionRollback(repo, db)
+	if err == nil || !strings.Contains(err.Error(), "succeeded") {
+		t.Errorf("the step without the email conflict = %v, want a failed check", err)
+	}
+}
+
+// TestContextQueries checks that the timeout interrupts the slow query
+// instead of waiting for its end.
+func TestContextQueries(t *testing.T) {
+	_, db := openPeople(t)
+	start := time.Now()
+	if err := contextQueries(db); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("the step took %v, the query was not interrupted", d)
+	}
+}
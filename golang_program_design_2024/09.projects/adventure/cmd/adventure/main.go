@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/adventure"
+)
+
+/*
+adventure is played by typing commands, type help for the list.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/adventure/cmd/adventure
+	go run ./golang_program_design_2024/09.projects/adventure/cmd/adventure -demo
+
+-demo plays a scripted walkthrough, including a save and a load, to the win.
+Its cases are tests: go test ./golang_program_design_2024/09.projects/adventure/...
+*/
+
+// walkthrough covers every command, a few mistakes, and the save file.
+const walkthrough = `
+look
+take fountain
+dance
+n
+take the lamp
+take bread
+s
+down
+e
+get key
+x key
+w
+use key
+d
+take crown
+save demo
+drop bread
+load demo
+i
+up
+`
+
+func main() {
+	demo := flag.Bool("demo", false, "play the built-in walkthrough")
+	saveDir := flag.String("saves", ".", "directory of the save files")
+	flag.Parse()
+
+	g := adventure.NewGame(adventure.DefaultWorld())
+	g.SaveDir = *saveDir
+
+	if *demo {
+		dir, err := os.MkdirTemp("", "adventure")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer os.RemoveAll(dir)
+		g.SaveDir = dir
+
+		play(g, strings.NewReader(walkthrough), os.Stdout, true)
+		fmt.Printf("\nwon: %v, in %d moves\n", g.State.Won, g.State.Moves)
+		return
+	}
+
+	out, _ := g.Dispatch("look")
+	fmt.Println(out)
+	play(g, os.Stdin, os.Stdout, false)
+}
+
+// play runs the commands read from r until quit or the end of the input,
+// writing the game's answers to w. With echo set the commands are written
+// too, as a transcript.
+func play(g *adventure.Game, r io.Reader, w io.Writer, echo bool) {
+	sc := bufio.NewScanner(r)
+	for {
+		if !echo {
+			fmt.Fprint(w, "\n> ")
+		}
+		if !sc.Scan() {
+			if !echo {
+				fmt.Fprintln(w)
+			}
+			return
+		}
+		line := sc.Text()
+		if echo {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			fmt.Fprintf(w, "\n> %s\n", line)
+		}
+		out, err := g.Dispatch(line)
+		if out != "" {
+			fmt.Fprintln(w, out)
+		}
+		switch {
+		case errors.Is(err, adventure.ErrQuit):
+			return
+		case err != nil:
+			fmt.Fprintln(w, "You can't:", err)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/adventure"
+)
+
+// TestWalkthrough plays the script of -demo: it wins, through a save and a
+// load that puts the dropped bread back in the inventory.
+func TestWalkthrough(t *testing.T) {
+	g := adventure.NewGame(adventure.DefaultWorld())
+	g.SaveDir = t.TempDir()
+	var out strings.Builder
+	play(g, strings.NewReader(walkthrough), &out, true)
+	if !g.State.Won {
+		t.Fatalf("the walkthrough did not win:\n%s", out.String())
+	}
+	for _, want := range []string{"You can't:", "Saved to", "> i\nYou carry an oil lamp, a loaf of bread,", "You win!"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("the transcript has no %q:\n%s", want, out.String())
+		}
+	}
+}
+
+// TestQuit stops reading at quit.
+func TestQuit(t *testing.T) {
+	g := adventure.NewGame(adventure.DefaultWorld())
+	var out strings.Builder
+	play(g, strings.NewReader("quit\nlook\n"), &out, false)
+	if got := out.String(); !strings.Contains(got, "Bye.") || strings.Contains(got, "Hall") {
+		t.Errorf("after quit:\n%s", got)
+	}
+}
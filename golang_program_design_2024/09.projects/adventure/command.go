@@ -0,0 +1,322 @@
+package adventure
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrUnknownCommand = errors.New("unknown command")
+	// ErrQuit is returned by the quit command, the caller ends the game loop.
+	ErrQuit = errors.New("quit")
+)
+
+// Command is one verb the player can type. Every command is its own type,
+// the dispatcher only knows them through this interface, like the shapes of
+// 03.interface.
+type Command interface {
+	// Names returns the verb and its synonyms.
+	Names() []string
+	Help() string
+	// Run executes the command and returns what the player sees. An error is
+	// a command that could not be done ("there is no lamp here"); the game
+	// goes on.
+	Run(g *Game, args []string) (string, error)
+}
+
+// Game is a world being played: the fixed world, the changing state and the
+// commands understood.
+type Game struct {
+	World *World
+	State *State
+	// SaveDir is where the save and load commands keep their files.
+	SaveDir string
+
+	commands []Command
+	byName   map[string]Command
+}
+
+// NewGame starts a game in w with the built-in commands.
+func NewGame(w *World) *Game {
+	g := &Game{World: w, State: NewState(w), SaveDir: ".", byName: make(map[string]Command)}
+	for _, c := range []Command{
+		lookCmd{}, goCmd{}, takeCmd{}, dropCmd{}, examineCmd{}, inventoryCmd{},
+		unlockCmd{}, saveCmd{}, loadCmd{}, helpCmd{}, quitCmd{},
+	} {
+		g.Register(c)
+	}
+	return g
+}
+
+// Register adds a command; a later command takes over the names of an
+// earlier one.
+func (g *Game) Register(c Command) {
+	g.commands = append(g.commands, c)
+	for _, name := range c.Names() {
+		g.byName[name] = c
+	}
+}
+
+// Dispatch parses a line and runs the matching command. Every command that
+// succeeds counts as a move.
+func (g *Game) Dispatch(line string) (string, error) {
+	in, ok := Parse(line)
+	if !ok {
+		return "", nil
+	}
+	c, ok := g.byName[in.Verb]
+	if !ok {
+		return "", fmt.Errorf("%w %q, try help", ErrUnknownCommand, in.Verb)
+	}
+	out, err := c.Run(g, in.Args)
+	if err == nil {
+		g.State.Moves++
+	}
+	return out, err
+}
+
+func (g *Game) room() *Room {
+	return g.World.Rooms[g.State.Room]
+}
+
+// canSee reports whether the current room is lit.
+func (g *Game) canSee() bool {
+	return !g.room().Dark || g.State.carries("lamp")
+}
+
+// findItem looks an item up among ids by id, or by words of its name:
+// "key" and "iron key" both find "an iron key".
+func (g *Game) findItem(ids []string, words []string) (string, bool) {
+	if len(words) == 0 {
+		return "", false
+	}
+	for _, id := range ids {
+		if id == strings.Join(words, " ") {
+			return id, true
+		}
+		name := strings.Fields(g.World.Items[id].Name)
+		if !slices.ContainsFunc(words, func(w string) bool { return !slices.Contains(name, w) }) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+type lookCmd struct{}
+
+func (lookCmd) Names() []string { return []string{"look"} }
+func (lookCmd) Help() string    { return "look around" }
+
+func (lookCmd) Run(g *Game, args []string) (string, error) {
+	r := g.room()
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", r.Name)
+	if !g.canSee() {
+		b.WriteString("It is pitch dark. You can barely find the way back.\n")
+	} else {
+		fmt.Fprintf(&b, "%s\n", r.Description)
+		for _, id := range g.State.RoomItems[r.ID] {
+			fmt.Fprintf(&b, "You see %s.\n", g.World.Items[id].Name)
+		}
+	}
+	fmt.Fprintf(&b, "Exits: %s", strings.Join(g.World.directions(r), ", "))
+	return b.String(), nil
+}
+
+type goCmd struct{}
+
+func (goCmd) Names() []string { return []string{"go", "walk", "move"} }
+func (goCmd) Help() string    { return "go <direction>, or just n, s, e, w, up, down" }
+
+func (goCmd) Run(g *Game, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New("go where?")
+	}
+	dir := args[0]
+	exit, ok := g.room().Exits[dir]
+	if !ok {
+		return "", fmt.Errorf("you cannot go %s from here", dir)
+	}
+	if exit.LockedBy != "" && !g.State.unlocked(g.State.Room, dir) {
+		return "", errors.New("the way is locked")
+	}
+	g.State.Room = exit.To
+	out, _ := lookCmd{}.Run(g, nil)
+	if g.State.Room == g.World.Start && g.State.carries(g.World.Goal) && !g.State.Won {
+		g.State.Won = true
+		out += fmt.Sprintf("\n\nYou brought back %s. You win!", g.World.Items[g.World.Goal].Name)
+	}
+	return out, nil
+}
+
+type takeCmd struct{}
+
+func (takeCmd) Names() []string { return []string{"take", "get", "pick"} }
+func (takeCmd) Help() string    { return "take <item>" }
+
+func (takeCmd) Run(g *Game, args []string) (string, error) {
+	if !g.canSee() {
+		return "", errors.New("it is too dark to find anything")
+	}
+	items := g.State.RoomItems[g.State.Room]
+	id, ok := g.findItem(items, args)
+	if !ok {
+		return "", fmt.Errorf("there is no %s here", strings.Join(args, " "))
+	}
+	item := g.World.Items[id]
+	if !item.Portable {
+		return "", fmt.Errorf("%s is too heavy", item.Name)
+	}
+	g.State.RoomItems[g.State.Room] = slices.DeleteFunc(items, func(s string) bool { return s == id })
+	g.State.Inventory = append(g.State.Inventory, id)
+	return fmt.Sprintf("You take %s.", item.Name), nil
+}
+
+type dropCmd struct{}
+
+func (dropCmd) Names() []string { return []string{"drop"} }
+func (dropCmd) Help() string    { return "drop <item>" }
+
+func (dropCmd) Run(g *Game, args []string) (string, error) {
+	id, ok := g.findItem(g.State.Inventory, args)
+	if !ok {
+		return "", fmt.Errorf("you do not carry %s", strings.Join(args, " "))
+	}
+	g.State.Inventory = slices.DeleteFunc(g.State.Inventory, func(s string) bool { return s == id })
+	g.State.RoomItems[g.State.Room] = append(g.State.RoomItems[g.State.Room], id)
+	return fmt.Sprintf("You drop %s.", g.World.Items[id].Name), nil
+}
+
+type examineCmd struct{}
+
+func (examineCmd) Names() []string { return []string{"examine", "x", "read"} }
+func (examineCmd) Help() string    { return "examine <item>" }
+
+func (examineCmd) Run(g *Game, args []string) (string, error) {
+	visible := g.State.Inventory
+	if g.canSee() {
+		visible = append(slices.Clone(visible), g.State.RoomItems[g.State.Room]...)
+	}
+	id, ok := g.findItem(visible, args)
+	if !ok {
+		return "", fmt.Errorf("you see no %s", strings.Join(args, " "))
+	}
+	return g.World.Items[id].Description, nil
+}
+
+type inventoryCmd struct{}
+
+func (inventoryCmd) Names() []string { return []string{"inventory"} }
+func (inventoryCmd) Help() string    { return "list what you carry" }
+
+func (inventoryCmd) Run(g *Game, args []string) (string, error) {
+	if len(g.State.Inventory) == 0 {
+		return "You carry nothing.", nil
+	}
+	names := make([]string, len(g.State.Inventory))
+	for i, id := range g.State.Inventory {
+		names[i] = g.World.Items[id].Name
+	}
+	return "You carry " + strings.Join(names, ", ") + ".", nil
+}
+
+type unlockCmd struct{}
+
+func (unlockCmd) Names() []string { return []string{"unlock", "open", "use"} }
+func (unlockCmd) Help() string    { return "unlock <direction>, or use <item>" }
+
+func (unlockCmd) Run(g *Game, args []string) (string, error) {
+	for _, dir := range g.World.directions(g.room()) {
+		exit := g.room().Exits[dir]
+		if exit.LockedBy == "" || g.State.unlocked(g.State.Room, dir) {
+			continue
+		}
+		// "unlock down" names the way, "use key" the item.
+		key, _ := g.findItem([]string{exit.LockedBy}, args)
+		if len(args) > 0 && args[0] != dir && key == "" {
+			continue
+		}
+		if !g.State.carries(exit.LockedBy) {
+			return "", errors.New("you have nothing to open it with")
+		}
+		g.State.Unlocked = append(g.State.Unlocked, g.State.Room+"/"+dir)
+		return fmt.Sprintf("You unlock the way %s with %s.", dir, g.World.Items[exit.LockedBy].Name), nil
+	}
+	return "", errors.New("there is nothing to unlock")
+}
+
+type saveCmd struct{}
+
+func (saveCmd) Names() []string { return []string{"save"} }
+func (saveCmd) Help() string    { return "save [name], save the game" }
+
+func (saveCmd) Run(g *Game, args []string) (string, error) {
+	path := g.savePath(args)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if err := g.State.Save(f); err != nil {
+		return "", err
+	}
+	return "Saved to " + path + ".", nil
+}
+
+type loadCmd struct{}
+
+func (loadCmd) Names() []string { return []string{"load", "restore"} }
+func (loadCmd) Help() string    { return "load [name], restore a saved game" }
+
+func (loadCmd) Run(g *Game, args []string) (string, error) {
+	f, err := os.Open(g.savePath(args))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s, err := LoadState(f, g.World)
+	if err != nil {
+		return "", err
+	}
+	// the load itself is counted by Dispatch, undo that.
+	s.Moves--
+	g.State = s
+	return lookCmd{}.Run(g, nil)
+}
+
+func (g *Game) savePath(args []string) string {
+	name := "adventure"
+	if len(args) > 0 {
+		name = filepath.Base(args[0])
+	}
+	return filepath.Join(g.SaveDir, name+".json")
+}
+
+type helpCmd struct{}
+
+func (helpCmd) Names() []string { return []string{"help", "?"} }
+func (helpCmd) Help() string    { return "show this list" }
+
+func (helpCmd) Run(g *Game, args []string) (string, error) {
+	var b strings.Builder
+	for i, c := range g.commands {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(&b, "  %-10s %s", c.Names()[0], c.Help())
+	}
+	return b.String(), nil
+}
+
+type quitCmd struct{}
+
+func (quitCmd) Names() []string { return []string{"quit", "exit"} }
+func (quitCmd) Help() string    { return "leave the game" }
+
+func (quitCmd) Run(g *Game, args []string) (string, error) {
+	return "Bye.", ErrQuit
+}
@@ -0,0 +1,191 @@
+package adventure
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line string
+		want Input
+		ok   bool
+	}{
+		{"look", Input{Verb: "look", Args: []string{}}, true},
+		{"  Take THE Lamp ", Input{Verb: "take", Args: []string{"lamp"}}, true},
+		{"unlock the door with a key", Input{Verb: "unlock", Args: []string{"door", "key"}}, true},
+		{"n", Input{Verb: "go", Args: []string{"north"}}, true},
+		{"DOWN", Input{Verb: "go", Args: []string{"down"}}, true},
+		{"i", Input{Verb: "inventory", Args: []string{}}, true},
+		{"examine iron key", Input{Verb: "examine", Args: []string{"iron", "key"}}, true},
+		{"", Input{}, false},
+		{"   ", Input{}, false},
+		{"the a an", Input{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := Parse(tt.line)
+		if ok != tt.ok || got.Verb != tt.want.Verb || len(got.Args) != len(tt.want.Args) ||
+			(len(got.Args) > 0 && !reflect.DeepEqual(got.Args, tt.want.Args)) {
+			t.Errorf("Parse(%q) = %+v, %v; want %+v, %v", tt.line, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+type step struct {
+	line    string
+	want    string // a part of the output, or of the error
+	wantErr bool
+}
+
+// play dispatches the steps in order and checks each outcome.
+func play(t *testing.T, g *Game, steps []step) {
+	t.Helper()
+	for i, s := range steps {
+		out, err := g.Dispatch(s.line)
+		got := out
+		if err != nil {
+			got = err.Error()
+		}
+		if (err != nil) != s.wantErr || !strings.Contains(got, s.want) {
+			t.Fatalf("step %d, %q: %q, error %v; want %q, error %v", i+1, s.line, out, err, s.want, s.wantErr)
+		}
+	}
+}
+
+// TestWalkthrough plays the game to the end, through the dark cellar and the
+// locked door, with the errors a player meets on the way.
+func TestWalkthrough(t *testing.T) {
+	g := NewGame(DefaultWorld())
+	play(t, g, []step{
+		{"look", "Hall\nA dusty entrance hall", false},
+		{"look", "Exits: down, east, north", false},
+		{"go down", "the way is locked", true},
+		{"unlock down", "nothing to open it with", true},
+		{"go", "go where?", true},
+		{"go west", "cannot go west", true},
+		{"e", "Garden", false},
+		{"take fountain", "a stone fountain is too heavy", true},
+		{"take the iron key", "You take an iron key.", false},
+		{"take key", "there is no key here", true},
+		{"inventory", "You carry an iron key.", false},
+		{"w", "Hall", false},
+		{"use key", "You unlock the way down with an iron key.", false},
+		{"unlock down", "nothing to unlock", true},
+		{"d", "It is pitch dark", false},
+		{"take crown", "too dark", true},
+		{"examine crown", "you see no crown", true},
+		{"examine key", "Heavy and rusty.", false},
+		{"up", "Hall", false},
+		{"n", "You see an oil lamp.", false},
+		{"get lamp", "You take an oil lamp.", false},
+		{"drop key", "You drop an iron key.", false},
+		{"drop key", "you do not carry key", true},
+		{"s", "Hall", false},
+		{"down", "Something glitters", false},
+		{"take crown", "You take a golden crown.", false},
+		{"u", "You brought back a golden crown. You win!", false},
+	})
+	if !g.State.Won || g.State.Room != "hall" {
+		t.Errorf("state after the game: %+v", g.State)
+	}
+	// every command that succeeded is a move.
+	if g.State.Moves != 17 {
+		t.Errorf("%d moves, want 17", g.State.Moves)
+	}
+	// the win is announced once.
+	play(t, g, []step{{"d", "Cellar", false}})
+	if out, _ := g.Dispatch("u"); strings.Contains(out, "win") {
+		t.Errorf("the win again: %q", out)
+	}
+}
+
+func TestDispatchErrors(t *testing.T) {
+	g := NewGame(DefaultWorld())
+	if out, err := g.Dispatch("   "); out != "" || err != nil {
+		t.Errorf("an empty line = %q, %v; want nothing", out, err)
+	}
+	if _, err := g.Dispatch("dance wildly"); !errors.Is(err, ErrUnknownCommand) || !strings.Contains(err.Error(), `"dance"`) {
+		t.Errorf("an unknown verb = %v, want ErrUnknownCommand naming it", err)
+	}
+	if out, err := g.Dispatch("quit"); !errors.Is(err, ErrQuit) || out != "Bye." {
+		t.Errorf("quit = %q, %v; want Bye., ErrQuit", out, err)
+	}
+	if g.State.Moves != 0 {
+		t.Errorf("%d moves after commands that failed", g.State.Moves)
+	}
+}
+
+// shout is a command added by a caller, taking over "look".
+type shout struct{}
+
+func (shout) Names() []string { return []string{"shout", "look"} }
+func (shout) Help() string    { return "shout something" }
+
+func (shout) Run(g *Game, args []string) (string, error) {
+	return strings.ToUpper(strings.Join(args, " ")) + "!", nil
+}
+
+func TestRegister(t *testing.T) {
+	g := NewGame(DefaultWorld())
+	g.Register(shout{})
+	play(t, g, []step{
+		{"shout hello there", "HELLO THERE!", false},
+		{"look", "!", false},
+		{"help", "shout      shout something", false},
+		{"?", "look       look around", false},
+	})
+	help, _ := g.Dispatch("help")
+	if n := strings.Count(help, "\n") + 1; n != 12 {
+		t.Errorf("help lists %d commands, want 12:\n%s", n, help)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	g := NewGame(DefaultWorld())
+	g.SaveDir = t.TempDir()
+	play(t, g, []step{
+		{"e", "Garden", false},
+		{"take key", "You take", false},
+		{"w", "Hall", false},
+		{"unlock down", "You unlock", false},
+		{"save slot1", "Saved to", false},
+		{"n", "Kitchen", false},
+		{"take lamp", "You take", false},
+	})
+	saved := 4 // the save itself is counted after the file was written
+
+	play(t, g, []step{
+		{"load slot1", "Hall", false},
+		{"inventory", "You carry an iron key.", false},
+		{"d", "It is pitch dark", false}, // still unlocked, and no lamp
+	})
+	if g.State.Moves != saved+2 {
+		t.Errorf("%d moves after load and 2 commands, want %d", g.State.Moves, saved+2)
+	}
+	if items := g.State.RoomItems["kitchen"]; !reflect.DeepEqual(items, []string{"lamp", "bread"}) {
+		t.Errorf("kitchen holds %v after the load, want the lamp back", items)
+	}
+
+	play(t, g, []step{
+		{"load nothing", "no such file", true},
+		{"save ../../escape", "Saved to", false}, // the name stays in SaveDir
+		{"load escape", "Cellar", false},
+	})
+}
+
+func TestLoadStateRejects(t *testing.T) {
+	w := DefaultWorld()
+	for _, tt := range []struct{ name, save string }{
+		{"unknown room", `{"room": "attic", "inventory": [], "room_items": {}, "moves": 0}`},
+		{"unknown room of items", `{"room": "hall", "inventory": [], "room_items": {"attic": []}, "moves": 0}`},
+		{"unknown item", `{"room": "hall", "inventory": ["sword"], "room_items": {}, "moves": 0}`},
+		{"unknown field", `{"room": "hall", "health": 3}`},
+		{"not JSON", `room=hall`},
+	} {
+		if _, err := LoadState(strings.NewReader(tt.save), w); err == nil {
+			t.Errorf("%s: LoadState succeeded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package adventure
+
+import "strings"
+
+// Input is a parsed player command: a verb and its objects.
+type Input struct {
+	Verb string
+	Args []string
+}
+
+// words that add nothing to a command: "take the lamp" is "take lamp".
+var fillers = map[string]bool{"the": true, "a": true, "an": true, "to": true, "at": true, "with": true, "on": true}
+
+// shortcuts expand a single word into a full command.
+var shortcuts = map[string]string{
+	"n": "go north", "s": "go south", "e": "go east", "w": "go west",
+	"u": "go up", "d": "go down",
+	"north": "go north", "south": "go south", "east": "go east", "west": "go west",
+	"up": "go up", "down": "go down",
+	"l": "look", "i": "inventory", "inv": "inventory", "q": "quit",
+}
+
+// Parse turns a line into an Input. It returns false for an empty line.
+func Parse(line string) (Input, bool) {
+	line = strings.ToLower(strings.TrimSpace(line))
+	if full, ok := shortcuts[line]; ok {
+		line = full
+	}
+	var words []string
+	for _, w := range strings.Fields(line) {
+		if !fillers[w] {
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 {
+		return Input{}, false
+	}
+	return Input{Verb: words[0], Args: words[1:]}, true
+}
@@ -0,0 +1,73 @@
+package adventure
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// State is everything that changes while playing. The struct tags give the
+// save file readable keys, see json.go in 05.standard_lib.
+type State struct {
+	Room      string              `json:"room"`
+	Inventory []string            `json:"inventory"`
+	RoomItems map[string][]string `json:"room_items"`
+	Unlocked  []string            `json:"unlocked,omitempty"` // "room/direction"
+	Moves     int                 `json:"moves"`
+	Won       bool                `json:"won,omitempty"`
+}
+
+// NewState is the state at the start of a game in w.
+func NewState(w *World) *State {
+	s := &State{Room: w.Start, RoomItems: make(map[string][]string)}
+	for id, r := range w.Rooms {
+		s.RoomItems[id] = slices.Clone(r.Items)
+	}
+	return s
+}
+
+// Save writes the state as indented JSON.
+func (s *State) Save(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// LoadState reads a state written by Save and checks that it fits the world,
+// so a hand-edited save cannot put the player in a room that does not exist.
+func LoadState(r io.Reader, w *World) (*State, error) {
+	var s State
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("reading save: %w", err)
+	}
+	if _, ok := w.Rooms[s.Room]; !ok {
+		return nil, fmt.Errorf("reading save: unknown room %q", s.Room)
+	}
+	items := slices.Clone(s.Inventory)
+	for room, ids := range s.RoomItems {
+		if _, ok := w.Rooms[room]; !ok {
+			return nil, fmt.Errorf("reading save: unknown room %q", room)
+		}
+		items = append(items, ids...)
+	}
+	for _, id := range items {
+		if _, ok := w.Items[id]; !ok {
+			return nil, fmt.Errorf("reading save: unknown item %q", id)
+		}
+	}
+	if s.RoomItems == nil {
+		s.RoomItems = make(map[string][]string)
+	}
+	return &s, nil
+}
+
+func (s *State) carries(item string) bool {
+	return slices.Contains(s.Inventory, item)
+}
+
+func (s *State) unlocked(room, dir string) bool {
+	return slices.Contains(s.Unlocked, room+"/"+dir)
+}
@@ -0,0 +1,98 @@
+// Package adventure is a small text adventure tying several chapters
+// together: the world is made of structs (02.data_struct), every player
+// command implements the Command interface (03.interface), and the game state
+// is saved and restored as JSON (05.standard_lib/json.go).
+//
+// The world itself is fixed data; everything that changes while playing
+// lives in State, so saving a game is just encoding the State.
+package adventure
+
+import "sort"
+
+// Item is something lying in a room or carried by the player.
+type Item struct {
+	ID          string
+	Name        string
+	Description string
+	Portable    bool
+}
+
+// Exit leads from a room to another, optionally behind a lock opened by an item.
+type Exit struct {
+	To       string
+	LockedBy string // item id, empty if the way is open
+}
+
+// Room is a place of the world. Items only lists what lies there at the start.
+type Room struct {
+	ID          string
+	Name        string
+	Description string
+	Exits       map[string]Exit // by direction
+	Items       []string
+	// Dark rooms can only be seen with the lamp.
+	Dark bool
+}
+
+// World is the map and the items, shared by every game.
+type World struct {
+	Start string
+	Rooms map[string]*Room
+	Items map[string]*Item
+	// Goal is the item to bring back to the start room to win.
+	Goal string
+}
+
+func (w *World) directions(r *Room) []string {
+	dirs := make([]string, 0, len(r.Exits))
+	for d := range r.Exits {
+		dirs = append(dirs, d)
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// DefaultWorld is a small house with a locked, dark cellar.
+func DefaultWorld() *World {
+	return &World{
+		Start: "hall",
+		Goal:  "crown",
+		Rooms: map[string]*Room{
+			"hall": {
+				ID: "hall", Name: "Hall",
+				Description: "A dusty entrance hall. A staircase leads down behind an iron door.",
+				Exits: map[string]Exit{
+					"north": {To: "kitchen"},
+					"east":  {To: "garden"},
+					"down":  {To: "cellar", LockedBy: "key"},
+				},
+			},
+			"kitchen": {
+				ID: "kitchen", Name: "Kitchen",
+				Description: "Pots hang above a cold stove.",
+				Exits:       map[string]Exit{"south": {To: "hall"}},
+				Items:       []string{"lamp", "bread"},
+			},
+			"garden": {
+				ID: "garden", Name: "Garden",
+				Description: "Overgrown roses surround a dry fountain.",
+				Exits:       map[string]Exit{"west": {To: "hall"}},
+				Items:       []string{"key", "fountain"},
+			},
+			"cellar": {
+				ID: "cellar", Name: "Cellar",
+				Description: "Barrels line the damp walls. Something glitters in a corner.",
+				Exits:       map[string]Exit{"up": {To: "hall"}},
+				Items:       []string{"crown"},
+				Dark:        true,
+			},
+		},
+		Items: map[string]*Item{
+			"lamp":     {ID: "lamp", Name: "an oil lamp", Description: "It still has some oil.", Portable: true},
+			"bread":    {ID: "bread", Name: "a loaf of bread", Description: "Hard as a stone.", Portable: true},
+			"key":      {ID: "key", Name: "an iron key", Description: "Heavy and rusty.", Portable: true},
+			"fountain": {ID: "fountain", Name: "a stone fountain", Description: "Dry for years.", Portable: false},
+			"crown":    {ID: "crown", Name: "a golden crown", Description: "The lost crown of the house!", Portable: true},
+		},
+	}
+}
@@ -0,0 +1,215 @@
+// Package apiclient wraps http.Client for talking to JSON APIs politely and
+// robustly:
+//
+//   - requests to the same host are spaced out to a maximum rate,
+//   - 429 and 5xx answers and network errors are retried with exponential
+//     backoff, or after the delay the server asks for in Retry-After,
+//   - hooks see every attempt and every response, for logging or metrics,
+//   - GetJSON and DoJSON decode the answer into a Go type.
+//
+// Only requests that are safe to repeat are retried after a 5xx or a network
+// error: idempotent methods, or any request carrying an Idempotency-Key
+// header. Any request is retried after 429 and 503, which say that the server
+// did not process it.
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// StatusError is returned for a response that is not 2xx once the retries
+// are used up.
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string // the start of the body, servers often explain the error there
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// Client sends requests with rate limiting and retries. Its zero value is not
+// usable, use New.
+type Client struct {
+	HTTP    *http.Client
+	BaseURL string // prepended to the paths given to GetJSON and DoJSON
+
+	// HostInterval is the minimum time between two requests to the same
+	// host, 100ms means at most 10 requests per second.
+	HostInterval time.Duration
+	MaxRetries   int
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	// MaxRetryAfter caps the wait a server may ask for; a longer Retry-After
+	// ends the retries instead.
+	MaxRetryAfter time.Duration
+
+	// OnRequest is called before every attempt, the first one is attempt 0.
+	OnRequest func(req *http.Request, attempt int)
+	// OnResponse is called after every attempt with its response or error.
+	OnResponse func(req *http.Request, resp *http.Response, took time.Duration, err error)
+
+	mu   sync.Mutex
+	next map[string]time.Time // per host, when the next request may start
+}
+
+func New(baseURL string) *Client {
+	return &Client{
+		HTTP:          &http.Client{Timeout: 30 * time.Second},
+		BaseURL:       baseURL,
+		HostInterval:  50 * time.Millisecond,
+		MaxRetries:    4,
+		MinBackoff:    100 * time.Millisecond,
+		MaxBackoff:    5 * time.Second,
+		MaxRetryAfter: time.Minute,
+		next:          make(map[string]time.Time),
+	}
+}
+
+// Do sends req, retrying as described in the package doc. A request with a
+// body can only be retried if it has GetBody, which http.NewRequest sets for
+// bytes and strings readers.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+		if err := c.wait(ctx, req.URL.Host); err != nil {
+			return nil, err
+		}
+
+		if c.OnRequest != nil {
+			c.OnRequest(req, attempt)
+		}
+		start := time.Now()
+		resp, err := c.HTTP.Do(req)
+		if c.OnResponse != nil {
+			c.OnResponse(req, resp, time.Since(start), err)
+		}
+
+		delay, retry := c.shouldRetry(req, resp, err, attempt)
+		if !retry {
+			return resp, err
+		}
+		if resp != nil {
+			// drain so the connection is reused for the next attempt.
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// shouldRetry decides whether to try again and after how long.
+func (c *Client) shouldRetry(req *http.Request, resp *http.Response, err error, attempt int) (time.Duration, bool) {
+	if attempt >= c.MaxRetries || req.Context().Err() != nil {
+		return 0, false
+	}
+	if req.Body != nil && req.GetBody == nil {
+		return 0, false // the body is gone, it cannot be sent again
+	}
+	safe := idempotent(req)
+
+	if err != nil {
+		return c.backoff(attempt), safe
+	}
+	code := resp.StatusCode
+	switch {
+	case code == http.StatusTooManyRequests || code == http.StatusServiceUnavailable:
+	case code >= 500 && safe:
+	default:
+		return 0, false
+	}
+	if d, ok := RetryAfter(resp.Header.Get("Retry-After")); ok {
+		return d, d <= c.MaxRetryAfter
+	}
+	return c.backoff(attempt), true
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// backoff doubles the delay at every attempt and waits a random duration
+// between half and all of it, so that clients failing together do not all
+// retry at the same instant.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.MinBackoff << attempt
+	if d > c.MaxBackoff || d <= 0 {
+		d = c.MaxBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// RetryAfter parses a Retry-After value: a number of seconds or an HTTP
+// date. ok is false for a missing or invalid value.
+func RetryAfter(v string) (d time.Duration, ok bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(0, time.Until(t)), true
+	}
+	return 0, false
+}
+
+// wait reserves the next slot of the host and sleeps until it comes, the
+// same scheme as the crawler's hostLimiter.
+func (c *Client) wait(ctx context.Context, host string) error {
+	if c.HostInterval <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	now := time.Now()
+	at := c.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	c.next[host] = at.Add(c.HostInterval)
+	c.mu.Unlock()
+	return sleep(ctx, time.Until(at))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// IsStatus reports whether err is a StatusError with the given code.
+func IsStatus(err error, code int) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.StatusCode == code
+}
@@ -0,0 +1,331 @@
+package apiclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// faulty is a server answering with the next of its scripted handlers at
+// every request, then with the last one.
+type faulty struct {
+	mu       sync.Mutex
+	script   []http.HandlerFunc
+	requests []string // "METHOD body" of every request
+}
+
+func (f *faulty) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	n := len(f.requests)
+	f.requests = append(f.requests, r.Method+" "+string(body))
+	h := f.script[min(n, len(f.script)-1)]
+	f.mu.Unlock()
+	h(w, r)
+}
+
+func (f *faulty) attempts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func status(code int, header ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i+1 < len(header); i += 2 {
+			w.Header().Set(header[i], header[i+1])
+		}
+		w.WriteHeader(code)
+		io.WriteString(w, "status "+http.StatusText(code))
+	}
+}
+
+func ok(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}
+}
+
+// serve starts the script and returns a client for it with short delays.
+func serve(t *testing.T, script ...http.HandlerFunc) (*Client, *faulty) {
+	t.Helper()
+	f := &faulty{script: script}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	c := New(srv.URL)
+	c.HostInterval = 0
+	c.MinBackoff, c.MaxBackoff = time.Millisecond, 5*time.Millisecond
+	return c, f
+}
+
+type user struct {
+	Name string `json:"name"`
+}
+
+func TestRetryThenSuccess(t *testing.T) {
+	c, f := serve(t, status(500), status(502), ok(`{"name": "ann"}`))
+	var seen []int
+	c.OnRequest = func(_ *http.Request, attempt int) { seen = append(seen, attempt) }
+
+	u, err := GetJSON[user](context.Background(), c, "/users/1")
+	if err != nil || u.Name != "ann" {
+		t.Fatalf("GetJSON = %+v, %v", u, err)
+	}
+	if len(f.attempts()) != 3 || len(seen) != 3 || seen[2] != 2 {
+		t.Errorf("%d requests, attempts %v; want 3, [0 1 2]", len(f.attempts()), seen)
+	}
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	c, f := serve(t, status(503))
+	c.MaxRetries = 2
+	_, err := GetJSON[user](context.Background(), c, "/users/1")
+	if !IsStatus(err, 503) || !strings.Contains(err.Error(), "status Service Unavailable") {
+		t.Errorf("GetJSON = %v, want the 503 with its body", err)
+	}
+	if n := len(f.attempts()); n != 3 {
+		t.Errorf("%d requests, want 1 + 2 retries", n)
+	}
+}
+
+func TestNotRetried(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		script []http.HandlerFunc
+		method string
+		want   int
+	}{
+		{"client error", []http.HandlerFunc{status(404)}, "GET", 404},
+		{"POST after a 500: may have been done", []http.HandlerFunc{status(500), ok(`{}`)}, "POST", 500},
+		{"PATCH after a 502", []http.HandlerFunc{status(502), ok(`{}`)}, "PATCH", 502},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, f := serve(t, tt.script...)
+			_, err := DoJSON[user](context.Background(), c, tt.method, "/users", user{"bob"})
+			if !IsStatus(err, tt.want) || len(f.attempts()) != 1 {
+				t.Errorf("%v after %d requests, want %d after 1", err, len(f.attempts()), tt.want)
+			}
+		})
+	}
+}
+
+// TestRetryBody retries requests with a body: each attempt sends it whole.
+func TestRetryBody(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		method string
+		header string
+		first  http.HandlerFunc
+	}{
+		{"POST after a 503: not processed", "POST", "", status(503)},
+		{"POST after a 429", "POST", "", status(429, "Retry-After", "0")},
+		{"POST with an Idempotency-Key", "POST", "Idempotency-Key", status(500)},
+		{"PUT is idempotent", "PUT", "", status(500)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, f := serve(t, tt.first, ok(`{"name": "bob"}`))
+			req, _ := http.NewRequest(tt.method, c.BaseURL+"/users", strings.NewReader(`{"name":"bob"}`))
+			if tt.header != "" {
+				req.Header.Set(tt.header, "k-1")
+			}
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			want := tt.method + ` {"name":"bob"}`
+			if got := f.attempts(); resp.StatusCode != 200 || len(got) != 2 || got[0] != want || got[1] != want {
+				t.Errorf("%d after %q, want 200 after twice %q", resp.StatusCode, got, want)
+			}
+		})
+	}
+}
+
+// TestBodyWithoutGetBody: a body that cannot be read again is sent once.
+func TestBodyWithoutGetBody(t *testing.T) {
+	c, f := serve(t, status(503), ok(`{}`))
+	req, _ := http.NewRequest("PUT", c.BaseURL+"/x", io.NopCloser(strings.NewReader("once")))
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 503 || len(f.attempts()) != 1 {
+		t.Errorf("%d after %d requests, want the 503 after 1", resp.StatusCode, len(f.attempts()))
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	c, f := serve(t, status(429, "Retry-After", "1"), ok(`{"name": "ann"}`))
+	start := time.Now()
+	if _, err := GetJSON[user](context.Background(), c, "/"); err != nil {
+		t.Fatal(err)
+	}
+	// the backoff would be a few milliseconds, the server asked for a second.
+	if d := time.Since(start); d < 900*time.Millisecond || d > 3*time.Second {
+		t.Errorf("retried after %v, want the 1s of Retry-After", d)
+	}
+	if len(f.attempts()) != 2 {
+		t.Errorf("%d requests, want 2", len(f.attempts()))
+	}
+
+	// a wait longer than MaxRetryAfter ends the retries at once.
+	c, f = serve(t, status(503, "Retry-After", "120"), ok(`{}`))
+	start = time.Now()
+	if _, err := GetJSON[user](context.Background(), c, "/"); !IsStatus(err, 503) {
+		t.Errorf("GetJSON = %v, want the 503", err)
+	}
+	if d := time.Since(start); d > time.Second || len(f.attempts()) != 1 {
+		t.Errorf("gave up after %v and %d requests, want at once after 1", d, len(f.attempts()))
+	}
+}
+
+// TestTimeout makes the first attempt hang past the client timeout: the GET
+// is retried and the second attempt answers.
+func TestTimeout(t *testing.T) {
+	hang := func(w http.ResponseWriter, r *http.Request) { <-r.Context().Done() }
+	c, f := serve(t, hang, ok(`{"name": "ann"}`))
+	c.HTTP.Timeout = 100 * time.Millisecond
+	var errs []error
+	c.OnResponse = func(_ *http.Request, _ *http.Response, _ time.Duration, err error) { errs = append(errs, err) }
+
+	u, err := GetJSON[user](context.Background(), c, "/")
+	if err != nil || u.Name != "ann" {
+		t.Fatalf("GetJSON = %+v, %v", u, err)
+	}
+	if len(errs) != 2 || errs[0] == nil || errs[1] != nil || len(f.attempts()) != 2 {
+		t.Errorf("attempt errors %v, %d requests", errs, len(f.attempts()))
+	}
+
+	// a POST that timed out may have been done: not retried.
+	c, f = serve(t, hang, ok(`{}`))
+	c.HTTP.Timeout = 100 * time.Millisecond
+	if _, err := DoJSON[user](context.Background(), c, "POST", "/", user{}); err == nil || len(f.attempts()) != 1 {
+		t.Errorf("POST = %v after %d requests, want the timeout after 1", err, len(f.attempts()))
+	}
+}
+
+func TestConnectionRefused(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	c := New(srv.URL)
+	c.HostInterval = 0
+	c.MinBackoff, c.MaxBackoff, c.MaxRetries = time.Millisecond, time.Millisecond, 3
+	var attempts atomic.Int32
+	c.OnRequest = func(*http.Request, int) { attempts.Add(1) }
+	if _, err := GetJSON[user](context.Background(), c, "/"); err == nil {
+		t.Fatal("GetJSON of a closed server succeeded")
+	}
+	if attempts.Load() != 4 {
+		t.Errorf("%d attempts, want 4", attempts.Load())
+	}
+}
+
+// TestCancelDuringBackoff cancels the context while the client waits to
+// retry: it returns at once with the context error.
+func TestCancelDuringBackoff(t *testing.T) {
+	c, _ := serve(t, status(503))
+	c.MinBackoff, c.MaxBackoff = time.Minute, time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	c.OnResponse = func(*http.Request, *http.Response, time.Duration, error) {
+		time.AfterFunc(20*time.Millisecond, cancel)
+	}
+	start := time.Now()
+	if _, err := GetJSON[user](ctx, c, "/"); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetJSON = %v, want context.Canceled", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("returned after %v", d)
+	}
+}
+
+func TestHostInterval(t *testing.T) {
+	c, f := serve(t, ok(`{}`))
+	c.HostInterval = 30 * time.Millisecond
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			GetJSON[user](context.Background(), c, "/")
+		}()
+	}
+	wg.Wait()
+	// 5 requests, 30ms apart: the last starts 120ms after the first.
+	if d := time.Since(start); d < 110*time.Millisecond || len(f.attempts()) != 5 {
+		t.Errorf("5 requests in %v, want at least 120ms", d)
+	}
+}
+
+func TestDoJSONAnswers(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string // the start of the error, "" for none
+	}{
+		{"no content", status(204), ""},
+		{"not JSON", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html")
+			io.WriteString(w, "<html>")
+		}, "GET"},
+		{"bad JSON", ok(`{"name": `), "GET"},
+		{"wrong type", ok(`{"name": 42}`), "GET"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := serve(t, tt.handler)
+			_, err := GetJSON[user](context.Background(), c, "/")
+			if (tt.want == "") != (err == nil) || (err != nil && !strings.HasPrefix(err.Error(), tt.want)) {
+				t.Errorf("GetJSON = %v, want an error %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfterParse(t *testing.T) {
+	for _, tt := range []struct {
+		v    string
+		want time.Duration
+		ok   bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"7", 7 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0, true}, // in the past
+	} {
+		got, ok := RetryAfter(tt.v)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("RetryAfter(%q) = %v, %v; want %v, %v", tt.v, got, ok, tt.want, tt.ok)
+		}
+	}
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got, ok := RetryAfter(future); !ok || got < 59*time.Minute || got > time.Hour {
+		t.Errorf("RetryAfter(in an hour) = %v, %v", got, ok)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := New("")
+	c.MinBackoff, c.MaxBackoff = 100*time.Millisecond, time.Second
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+		for i := 0; i < 50; i++ {
+			if d := c.backoff(attempt); d < want/2 || d > want {
+				t.Fatalf("backoff(%d) = %v, want in [%v, %v]", attempt, d, want/2, want)
+			}
+		}
+	}
+	if d := c.backoff(100); d < c.MaxBackoff/2 || d > c.MaxBackoff {
+		t.Errorf("backoff(100) = %v: the shift overflowed", d)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/apiclient"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+apidemo runs the client against a local server that misbehaves on purpose:
+it fails the first attempts, asks to slow down with 429 and Retry-After,
+always fails some routes, and answers HTML where JSON is expected. Every
+attempt is logged by the client hooks.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/apiclient/cmd/apidemo
+*/
+
+type user struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type order struct {
+	ID   int    `json:"id"`
+	Item string `json:"item"`
+}
+
+func main() {
+	srv := httptest.NewServer(flakyAPI())
+	defer srv.Close()
+
+	log := xlog.New("09.projects", "apiclient")
+	c := apiclient.New(srv.URL)
+	c.HostInterval = 100 * time.Millisecond
+	c.MaxRetries = 3
+	c.OnRequest = func(req *http.Request, attempt int) {
+		log.Info("request", "method", req.Method, "path", req.URL.Path, "attempt", attempt)
+	}
+	c.OnResponse = func(req *http.Request, resp *http.Response, took time.Duration, err error) {
+		if err != nil {
+			log.Warn("failed", "path", req.URL.Path, "err", err)
+			return
+		}
+		log.Info("response", "path", req.URL.Path, "status", resp.StatusCode, "took", took.Round(time.Microsecond))
+	}
+	ctx := context.Background()
+
+	step("503 twice, then the user")
+	u, err := apiclient.GetJSON[user](ctx, c, "/users/42")
+	fmt.Printf("%+v %v\n", u, err)
+
+	step("429 with Retry-After: 1")
+	start := time.Now()
+	u, err = apiclient.GetJSON[user](ctx, c, "/limited")
+	fmt.Printf("%+v %v after %v\n", u, err, time.Since(start).Round(100*time.Millisecond))
+
+	step("a POST failing with 500 is not retried, it may have been processed")
+	o, err := apiclient.DoJSON[order](ctx, c, http.MethodPost, "/orders", order{Item: "book"})
+	fmt.Printf("%+v %v\n", o, err)
+
+	step("always 500: the retries run out")
+	_, err = apiclient.GetJSON[user](ctx, c, "/broken")
+	fmt.Println("err:", err, "| is 500:", apiclient.IsStatus(err, http.StatusInternalServerError))
+
+	step("HTML instead of JSON")
+	_, err = apiclient.GetJSON[user](ctx, c, "/html")
+	fmt.Println("err:", err)
+
+	step("6 requests at once, spaced by the host interval")
+	start = time.Now()
+	var wg sync.WaitGroup
+	for i := 1; i <= 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			apiclient.GetJSON[user](ctx, c, fmt.Sprintf("/users/%d", i))
+		}()
+	}
+	wg.Wait()
+	fmt.Printf("done in %v, at least 5 intervals of %v\n", time.Since(start).Round(10*time.Millisecond), c.HostInterval)
+}
+
+func step(title string) {
+	fmt.Fprintf(os.Stdout, "\n== %s\n", title)
+}
+
+// flakyAPI answers like a real API having a bad day.
+func flakyAPI() http.Handler {
+	var mu sync.Mutex
+	failures := make(map[string]int)
+	var limited, orders atomic.Int64
+
+	writeJSON := func(w http.ResponseWriter, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if id == "42" {
+			mu.Lock()
+			failures[id]++
+			n := failures[id]
+			mu.Unlock()
+			if n <= 2 {
+				http.Error(w, "warming up", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		writeJSON(w, user{ID: id, Name: "user " + id})
+	})
+	mux.HandleFunc("GET /limited", func(w http.ResponseWriter, r *http.Request) {
+		if limited.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		writeJSON(w, user{ID: "1", Name: "patient"})
+	})
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		if orders.Add(1) == 1 {
+			http.Error(w, "database timeout", http.StatusInternalServerError)
+			return
+		}
+		var o order
+		json.NewDecoder(r.Body).Decode(&o)
+		o.ID = int(orders.Load())
+		writeJSON(w, o)
+	})
+	mux.HandleFunc("GET /broken", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "something is really wrong", http.StatusInternalServerError)
+	})
+	mux.HandleFunc("GET /html", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintln(w, "<h1>maintenance</h1>")
+	})
+	return mux
+}
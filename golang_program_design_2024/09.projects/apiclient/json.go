@@ -0,0 +1,65 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// GetJSON fetches path and decodes the JSON answer into a T.
+func GetJSON[T any](ctx context.Context, c *Client, path string) (T, error) {
+	return DoJSON[T](ctx, c, http.MethodGet, path, nil)
+}
+
+// DoJSON sends body encoded as JSON (none if nil) and decodes the answer into
+// a T. A non-2xx answer is returned as a *StatusError.
+func DoJSON[T any](ctx context.Context, c *Client, method, path string, body any) (T, error) {
+	var out T
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return out, err
+		}
+		reader = bytes.NewReader(data) // lets NewRequest set GetBody, for retries
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return out, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return out, &StatusError{
+			Method:     method,
+			URL:        req.URL.String(),
+			StatusCode: resp.StatusCode,
+			Body:       strings.TrimSpace(string(snippet)),
+		}
+	}
+	if resp.StatusCode == http.StatusNoContent {
+		return out, nil
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		return out, fmt.Errorf("%s %s: expected JSON, got %q", method, req.URL, ct)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return out, fmt.Errorf("%s %s: decoding %T: %w", method, req.URL, out, err)
+	}
+	return out, nil
+}
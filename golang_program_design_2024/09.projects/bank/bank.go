@@ -0,0 +1,47 @@
+// Package bank moves money between accounts from many goroutines at once, in
+// two designs that keep the same promise: no money is ever created or lost.
+//
+// LockBank gives every account its own mutex. A transfer needs two of them,
+// and two transfers A->B and B->A taking their first lock at the same time
+// would wait for each other forever. Taking the locks in a fixed order (the
+// smaller account id first) makes that cycle impossible.
+//
+// ChanBank has no locks at all: one goroutine owns every balance and the
+// transfers are sent to it over a channel, so they run one after another.
+// Simpler to get right, but it is a single queue however many CPUs there are.
+package bank
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrSameAccount       = errors.New("transfer to the same account")
+	ErrNoAccount         = errors.New("no such account")
+	ErrInvalidAmount     = errors.New("amount must be positive")
+)
+
+// Bank is implemented by both designs.
+type Bank interface {
+	Transfer(from, to int, amount int64) error
+	Balance(id int) (int64, error)
+	// Total is the sum of all balances, taken as one consistent snapshot.
+	Total() int64
+	Len() int
+}
+
+func validate(b Bank, from, to int, amount int64) error {
+	switch {
+	case amount <= 0:
+		return ErrInvalidAmount
+	case from == to:
+		return ErrSameAccount
+	case from < 0 || from >= b.Len():
+		return fmt.Errorf("%w: %d", ErrNoAccount, from)
+	case to < 0 || to >= b.Len():
+		return fmt.Errorf("%w: %d", ErrNoAccount, to)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package bank
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+// designs opens the same bank in both designs, closed when the test ends.
+func designs(t testing.TB, n int, initial int64) map[string]Bank {
+	cb := NewChanBank(n, initial)
+	t.Cleanup(cb.Close)
+	return map[string]Bank{"lock": NewLockBank(n, initial), "chan": cb}
+}
+
+func TestTransfer(t *testing.T) {
+	for name, b := range designs(t, 3, 100) {
+		t.Run(name, func(t *testing.T) {
+			tests := []struct {
+				from, to int
+				amount   int64
+				want     error
+			}{
+				{0, 1, 30, nil},
+				{1, 2, 130, nil},
+				{0, 2, 71, ErrInsufficientFunds},
+				{0, 2, 70, nil},
+				{0, 1, 1, ErrInsufficientFunds},
+				{1, 1, 10, ErrSameAccount},
+				{0, 1, 0, ErrInvalidAmount},
+				{0, 1, -5, ErrInvalidAmount},
+				{0, 3, 1, ErrNoAccount},
+				{-1, 0, 1, ErrNoAccount},
+			}
+			for _, tt := range tests {
+				if err := b.Transfer(tt.from, tt.to, tt.amount); !errors.Is(err, tt.want) {
+					t.Errorf("Transfer(%d, %d, %d) = %v, want %v", tt.from, tt.to, tt.amount, err, tt.want)
+				}
+			}
+			for id, want := range []int64{0, 0, 300} {
+				if got, err := b.Balance(id); err != nil || got != want {
+					t.Errorf("Balance(%d) = %d, %v; want %d", id, got, err, want)
+				}
+			}
+			if _, err := b.Balance(3); !errors.Is(err, ErrNoAccount) {
+				t.Errorf("Balance(3) = %v, want ErrNoAccount", err)
+			}
+			if b.Total() != 300 {
+				t.Errorf("Total = %d, want 300", b.Total())
+			}
+		})
+	}
+}
+
+// TestInvariant runs many transfers while checking the total: run it with
+// -race to check the locking too.
+func TestInvariant(t *testing.T) {
+	const accounts, initial = 10, 1000
+	transfers := 20000
+	if testing.Short() {
+		transfers = 2000
+	}
+	for name, b := range designs(t, accounts, initial) {
+		t.Run(name, func(t *testing.T) {
+			var r Report
+			testutil.RequireDone(t, 30*time.Second, func() { r = Simulate(b, 8, transfers, 500) })
+			if len(r.Violations) > 0 {
+				t.Fatalf("%d violations, the first: %s", len(r.Violations), r.Violations[0])
+			}
+			if r.Done+r.Rejected != int64(transfers) || r.Done == 0 || r.Checks < 2 {
+				t.Errorf("report %+v for %d transfers", r, transfers)
+			}
+			var sum int64
+			for id := 0; id < accounts; id++ {
+				balance, _ := b.Balance(id)
+				if balance < 0 {
+					t.Errorf("account %d overdrawn: %d", id, balance)
+				}
+				sum += balance
+			}
+			if sum != accounts*initial {
+				t.Errorf("the balances add up to %d, want %d", sum, accounts*initial)
+			}
+		})
+	}
+}
+
+// TestOppositeTransfers sends money both ways between two accounts at once,
+// the pattern that deadlocks without a lock order.
+func TestOppositeTransfers(t *testing.T) {
+	for name, b := range designs(t, 2, 1000) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func(from int) {
+					defer wg.Done()
+					for i := 0; i < 2000; i++ {
+						b.Transfer(from, 1-from, 1)
+					}
+				}(g % 2)
+			}
+			testutil.RequireDone(t, 10*time.Second, wg.Wait)
+			if b.Total() != 2000 {
+				t.Errorf("Total = %d, want 2000", b.Total())
+			}
+		})
+	}
+}
+
+// BenchmarkTransfer compares the designs with every CPU transferring, over
+// n accounts: few (contended) and many.
+func BenchmarkTransfer(b *testing.B) {
+	for _, name := range []string{"lock", "chan"} {
+		b.Run(name, func(b *testing.B) {
+			benchtools.Sized(b, []int{2, 1 << 10}, func(b *testing.B, accounts int) {
+				bank := designs(b, accounts, 1<<40)[name]
+				b.RunParallel(func(pb *testing.PB) {
+					rng := rand.New(rand.NewSource(rand.Int63()))
+					for pb.Next() {
+						from := rng.Intn(accounts)
+						to := (from + 1 + rng.Intn(accounts-1)) % accounts
+						if err := bank.Transfer(from, to, 1); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+			})
+		})
+	}
+}
+
+func BenchmarkTotal(b *testing.B) {
+	for _, name := range []string{"lock", "chan"} {
+		b.Run(name, func(b *testing.B) {
+			benchtools.Sized(b, []int{16, 1 << 10}, func(b *testing.B, accounts int) {
+				bank := designs(b, accounts, 100)[name]
+				for i := 0; i < b.N; i++ {
+					bank.Total()
+				}
+			})
+		})
+	}
+}
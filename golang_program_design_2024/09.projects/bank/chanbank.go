@@ -0,0 +1,73 @@
+package bank
+
+// ChanBank serializes every operation through one goroutine that owns the
+// balances, "share memory by communicating".
+type ChanBank struct {
+	n   int
+	ops chan func(balances []int64)
+}
+
+// NewChanBank opens n accounts holding initial each. Close stops the goroutine.
+func NewChanBank(n int, initial int64) *ChanBank {
+	b := &ChanBank{n: n, ops: make(chan func([]int64))}
+	balances := make([]int64, n)
+	for i := range balances {
+		balances[i] = initial
+	}
+	go func() {
+		for op := range b.ops {
+			op(balances)
+		}
+	}()
+	return b
+}
+
+// Close stops the bank; it must not be used afterwards.
+func (b *ChanBank) Close() { close(b.ops) }
+
+func (b *ChanBank) Len() int { return b.n }
+
+// do runs op on the owner goroutine and waits for it to finish.
+func (b *ChanBank) do(op func(balances []int64)) {
+	done := make(chan struct{})
+	b.ops <- func(balances []int64) {
+		op(balances)
+		close(done)
+	}
+	<-done
+}
+
+func (b *ChanBank) Transfer(from, to int, amount int64) error {
+	if err := validate(b, from, to, amount); err != nil {
+		return err
+	}
+	var err error
+	b.do(func(balances []int64) {
+		if balances[from] < amount {
+			err = ErrInsufficientFunds
+			return
+		}
+		balances[from] -= amount
+		balances[to] += amount
+	})
+	return err
+}
+
+func (b *ChanBank) Balance(id int) (int64, error) {
+	if id < 0 || id >= b.n {
+		return 0, ErrNoAccount
+	}
+	var balance int64
+	b.do(func(balances []int64) { balance = balances[id] })
+	return balance, nil
+}
+
+func (b *ChanBank) Total() int64 {
+	var sum int64
+	b.do(func(balances []int64) {
+		for _, v := range balances {
+			sum += v
+		}
+	})
+	return sum
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/bank"
+)
+
+/*
+banksim runs the same random transfers against both bank designs, checking
+that no money appears or disappears, then benchmarks them.
+
+Usage:
+
+	go run -race ./golang_program_design_2024/09.projects/bank/cmd/banksim
+	go run ./golang_program_design_2024/09.projects/bank/cmd/banksim -bench
+
+The benchmarks run without -race, which slows locks and channels by an
+order of magnitude and would not compare the designs fairly.
+*/
+
+func main() {
+	accounts := flag.Int("accounts", 100, "number of accounts")
+	workers := flag.Int("workers", 16, "goroutines making transfers")
+	transfers := flag.Int("n", 100_000, "total transfers")
+	bench := flag.Bool("bench", false, "benchmark the two designs instead")
+	flag.Parse()
+
+	if *bench {
+		benchmarks()
+		return
+	}
+
+	chanBank := bank.NewChanBank(*accounts, 1000)
+	defer chanBank.Close()
+
+	failed := false
+	for _, c := range []struct {
+		name string
+		b    bank.Bank
+	}{
+		{"lock ordering", bank.NewLockBank(*accounts, 1000)},
+		{"channel serializer", chanBank},
+	} {
+		r := bank.Simulate(c.b, *workers, *transfers, 500)
+		fmt.Printf("%-20s %d transfers, %d rejected, %d invariant checks, total %d\n",
+			c.name, r.Done, r.Rejected, r.Checks, c.b.Total())
+		for _, v := range r.Violations {
+			fmt.Println("  VIOLATION", v)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// benchmarks compares the designs with few accounts (every transfer fights
+// for the same locks) and many (transfers rarely meet).
+func benchmarks() {
+	for _, accounts := range []int{2, 16, 10_000} {
+		chanBank := bank.NewChanBank(accounts, 1<<40)
+		for _, c := range []struct {
+			name string
+			b    bank.Bank
+		}{
+			{"lock", bank.NewLockBank(accounts, 1<<40)},
+			{"chan", chanBank},
+		} {
+			res := testing.Benchmark(func(b *testing.B) {
+				b.RunParallel(func(pb *testing.PB) {
+					i := rand.Intn(accounts) // spread the goroutines over the accounts
+					for pb.Next() {
+						from := i % accounts
+						c.b.Transfer(from, (from+1)%accounts, 1)
+						i++
+					}
+				})
+			})
+			fmt.Printf("%5d accounts  %s  %s\n", accounts, c.name, res)
+		}
+		chanBank.Close()
+	}
+}
@@ -0,0 +1,73 @@
+package bank
+
+import "sync"
+
+type account struct {
+	mu      sync.Mutex
+	balance int64
+}
+
+// LockBank locks the two accounts of a transfer, always in id order.
+type LockBank struct {
+	accounts []*account
+}
+
+// NewLockBank opens n accounts holding initial each.
+func NewLockBank(n int, initial int64) *LockBank {
+	b := &LockBank{accounts: make([]*account, n)}
+	for i := range b.accounts {
+		b.accounts[i] = &account{balance: initial}
+	}
+	return b
+}
+
+func (b *LockBank) Len() int { return len(b.accounts) }
+
+func (b *LockBank) Transfer(from, to int, amount int64) error {
+	if err := validate(b, from, to, amount); err != nil {
+		return err
+	}
+	// the lock order: whatever the direction of the transfer, the smaller id
+	// is locked first, so no two transfers can each hold what the other waits for.
+	first, second := b.accounts[from], b.accounts[to]
+	if to < from {
+		first, second = second, first
+	}
+	first.mu.Lock()
+	defer first.mu.Unlock()
+	second.mu.Lock()
+	defer second.mu.Unlock()
+
+	src, dst := b.accounts[from], b.accounts[to]
+	if src.balance < amount {
+		return ErrInsufficientFunds
+	}
+	src.balance -= amount
+	dst.balance += amount
+	return nil
+}
+
+func (b *LockBank) Balance(id int) (int64, error) {
+	if id < 0 || id >= len(b.accounts) {
+		return 0, ErrNoAccount
+	}
+	a := b.accounts[id]
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.balance, nil
+}
+
+// Total locks every account, in the same order as Transfer, before summing:
+// reading them one by one would count money that is in flight twice or not
+// at all.
+func (b *LockBank) Total() int64 {
+	for _, a := range b.accounts {
+		a.mu.Lock()
+	}
+	var sum int64
+	for _, a := range b.accounts {
+		sum += a.balance
+		a.mu.Unlock()
+	}
+	return sum
+}
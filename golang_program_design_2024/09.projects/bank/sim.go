@@ -0,0 +1,82 @@
+package bank
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// Report is the outcome of a simulation.
+type Report struct {
+	Done, Rejected int64 // transfers made, and refused for lack of funds
+	Checks         int   // invariant checks run while the transfers went on
+	Violations     []string
+}
+
+// Simulate runs transfers random transfers spread over workers goroutines,
+// while a checker goroutine asserts again and again that the total balance
+// never changes. Run it with -race: a design that forgets a lock shows up
+// either as a violation here or as a data race there.
+func Simulate(b Bank, workers, transfers int, maxAmount int64) Report {
+	want := b.Total()
+	var r Report
+	var done, rejected atomic.Int64
+
+	check := func(when string) {
+		r.Checks++
+		if got := b.Total(); got != want {
+			r.Violations = append(r.Violations, fmt.Sprintf("%s: total is %d, want %d", when, got, want))
+		}
+	}
+
+	stop := make(chan struct{})
+	checker := make(chan struct{})
+	go func() {
+		defer close(checker)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				check("during the run")
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		n := transfers / workers
+		if w < transfers%workers {
+			n++
+		}
+		wg.Add(1)
+		go func(seed int64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewSource(seed))
+			for i := 0; i < n; i++ {
+				from, to := rng.Intn(b.Len()), rng.Intn(b.Len())
+				if from == to {
+					to = (to + 1) % b.Len()
+				}
+				err := b.Transfer(from, to, rng.Int63n(maxAmount)+1)
+				switch {
+				case err == nil:
+					done.Add(1)
+				case errors.Is(err, ErrInsufficientFunds):
+					rejected.Add(1)
+				default:
+					panic(err) // the random ids are always valid
+				}
+			}
+		}(int64(w))
+	}
+	wg.Wait()
+	close(stop)
+	<-checker
+
+	check("at the end")
+	r.Done, r.Rejected = done.Load(), rejected.Load()
+	return r
+}
@@ -0,0 +1,156 @@
+// Package blockchain is a toy blockchain: blocks chained by their hashes,
+// sealed by proof of work.
+//
+// Each block stores the hash of the one before it, so changing a block
+// changes its hash and breaks the link from the next one: rewriting history
+// means recomputing every block after the change. Proof of work makes that
+// expensive: a block is only valid when its hash starts with Difficulty zero
+// bits, and the only way to get one is to try nonces until a hash happens to
+// have them, about 2^Difficulty tries.
+//
+// Mining is embarrassingly parallel, every nonce can be tried independently:
+// Mine splits the nonces between workers racing to find one, and the first
+// to succeed stops the others.
+package blockchain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/bits"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Hash is the SHA-256 of a block header.
+type Hash [32]byte
+
+func (h Hash) String() string { return hex.EncodeToString(h[:]) }
+
+// ZeroBits counts the leading zero bits of h.
+func (h Hash) ZeroBits() int {
+	n := 0
+	for _, b := range h {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+// Block is a payload chained to the block before it.
+type Block struct {
+	Index      uint64
+	Time       time.Time
+	Payload    []byte
+	PrevHash   Hash
+	Difficulty int // leading zero bits the hash must have
+	Nonce      uint64
+	Hash       Hash
+}
+
+// headerSize is the bytes hashed: index, time, previous hash, payload hash,
+// difficulty and nonce.
+const headerSize = 8 + 8 + 32 + 32 + 8 + 8
+
+// header encodes everything the hash covers. The payload enters through its
+// own hash, so trying a nonce costs the same whatever the payload size, and
+// the nonce is last, so only it changes between two tries.
+func (b *Block) header() []byte {
+	buf := make([]byte, 0, headerSize)
+	buf = binary.BigEndian.AppendUint64(buf, b.Index)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(b.Time.UnixNano()))
+	buf = append(buf, b.PrevHash[:]...)
+	payload := sha256.Sum256(b.Payload)
+	buf = append(buf, payload[:]...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(b.Difficulty))
+	return binary.BigEndian.AppendUint64(buf, b.Nonce)
+}
+
+// ComputeHash hashes the block as it is now, nonce included.
+func (b *Block) ComputeHash() Hash {
+	return sha256.Sum256(b.header())
+}
+
+// Mine searches a nonce giving b a hash with b.Difficulty leading zero bits,
+// with workers goroutines (GOMAXPROCS when workers <= 0), and sets b.Nonce
+// and b.Hash. It returns the number of hashes computed, and ctx.Err() if ctx
+// is done before a nonce is found.
+func Mine(ctx context.Context, b *Block, workers int) (tries uint64, err error) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type found struct {
+		nonce uint64
+		hash  Hash
+	}
+	results := make(chan found, workers) // never blocks: at most one send per worker
+	counts := make([]uint64, workers)
+	header := b.header()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// each worker has its own copy of the header, and takes every
+			// workers-th nonce: no two workers try the same one.
+			buf := append([]byte(nil), header...)
+			var n uint64
+			defer func() { counts[w] = n }()
+			for nonce := uint64(w); ; nonce += uint64(workers) {
+				// checking ctx costs more than a hash: once every 4096 tries.
+				if n&0xfff == 0 && ctx.Err() != nil {
+					return
+				}
+				binary.BigEndian.PutUint64(buf[headerSize-8:], nonce)
+				h := Hash(sha256.Sum256(buf))
+				n++
+				if h.ZeroBits() >= b.Difficulty {
+					results <- found{nonce, h}
+					cancel() // the race is won, stop the others
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for _, n := range counts {
+		tries += n
+	}
+
+	select {
+	case f := <-results:
+		// two workers may have found one in the same instant: either is valid.
+		b.Nonce, b.Hash = f.nonce, f.hash
+		return tries, nil
+	default:
+		return tries, ctx.Err()
+	}
+}
+
+// ValidationError points at the first invalid block of a chain.
+type ValidationError struct {
+	Index uint64
+	Err   error
+}
+
+func (e *ValidationError) Error() string { return fmt.Sprintf("block %d: %v", e.Index, e.Err) }
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+var (
+	ErrBadHash    = errors.New("stored hash does not match the content")
+	ErrNotMined   = errors.New("hash does not meet the difficulty")
+	ErrBrokenLink = errors.New("previous hash does not match the previous block")
+	ErrBadIndex   = errors.New("index out of sequence")
+	ErrTimeWarp   = errors.New("block time before the previous block")
+	ErrDifficulty = errors.New("difficulty does not follow the retarget rule")
+)
@@ -0,0 +1,86 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestZeroBits(t *testing.T) {
+	for _, tt := range []struct {
+		h    Hash
+		want int
+	}{
+		{Hash{0x80}, 0},
+		{Hash{0x01}, 7},
+		{Hash{0, 0x40}, 9},
+		{Hash{0, 0, 0, 0x0f}, 28},
+		{Hash{}, 256},
+	} {
+		if got := tt.h.ZeroBits(); got != tt.want {
+			t.Errorf("%x...: %d zero bits, want %d", tt.h[:4], got, tt.want)
+		}
+	}
+}
+
+// TestHeader changes each field in turn: every one is covered by the hash.
+func TestHeader(t *testing.T) {
+	base := Block{Index: 1, Time: time.Unix(100, 0), Payload: []byte("p"), PrevHash: Hash{1}, Difficulty: 8, Nonce: 42}
+	h := base.ComputeHash()
+	for name, change := range map[string]func(b *Block){
+		"index":      func(b *Block) { b.Index++ },
+		"time":       func(b *Block) { b.Time = b.Time.Add(time.Nanosecond) },
+		"payload":    func(b *Block) { b.Payload = []byte("q") },
+		"prev hash":  func(b *Block) { b.PrevHash[31] = 1 },
+		"difficulty": func(b *Block) { b.Difficulty++ },
+		"nonce":      func(b *Block) { b.Nonce++ },
+	} {
+		b := base
+		change(&b)
+		if b.ComputeHash() == h {
+			t.Errorf("the hash does not cover the %s", name)
+		}
+	}
+	if b := base; b.ComputeHash() != h || b.Hash != (Hash{}) {
+		t.Error("ComputeHash is not a pure function of the block")
+	}
+}
+
+func TestMine(t *testing.T) {
+	for _, workers := range []int{1, 4, 0} {
+		b := &Block{Time: time.Unix(0, 0), Payload: []byte("mine me"), Difficulty: 14}
+		tries, err := Mine(context.Background(), b, workers)
+		if err != nil {
+			t.Fatalf("%d workers: %v", workers, err)
+		}
+		if b.Hash != b.ComputeHash() || b.Hash.ZeroBits() < 14 || tries == 0 {
+			t.Errorf("%d workers: nonce %d, hash %s after %d tries", workers, b.Nonce, b.Hash, tries)
+		}
+	}
+}
+
+// TestMineCancel stops a search that would take years, at the deadline and
+// with the context already done.
+func TestMineCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	b := &Block{Payload: []byte("never"), Difficulty: 64}
+	start := time.Now()
+	tries, err := Mine(ctx, b, 0)
+	if !errors.Is(err, context.DeadlineExceeded) || tries == 0 {
+		t.Errorf("Mine = %d tries, %v; want the deadline", tries, err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Mine returned %v after the deadline", d)
+	}
+	if b.Hash != (Hash{}) || b.Nonce != 0 {
+		t.Errorf("a cancelled search set nonce %d, hash %s", b.Nonce, b.Hash)
+	}
+
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Mine(done, &Block{Difficulty: 64}, 2); !errors.Is(err, context.Canceled) {
+		t.Errorf("Mine with ctx done = %v, want context.Canceled", err)
+	}
+}
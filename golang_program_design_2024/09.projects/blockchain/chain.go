@@ -0,0 +1,106 @@
+package blockchain
+
+import (
+	"context"
+	"time"
+)
+
+// Chain is a sequence of mined blocks starting with a genesis block. It is
+// not safe for concurrent use: one goroutine owns it, the miners it starts
+// only ever see a copy of the header.
+type Chain struct {
+	Blocks []*Block
+
+	// Every RetargetEvery blocks the difficulty moves by one bit, towards
+	// blocks taking TargetTime to mine: up when the last ones came twice as
+	// fast, down when they took twice as long. Zero keeps it fixed.
+	TargetTime    time.Duration
+	RetargetEvery int
+	// Workers mining each block, GOMAXPROCS when <= 0.
+	Workers int
+
+	now func() time.Time
+}
+
+// NewChain mines the genesis block at the given difficulty.
+func NewChain(ctx context.Context, difficulty int, targetTime time.Duration, retargetEvery int) (*Chain, error) {
+	c := &Chain{TargetTime: targetTime, RetargetEvery: retargetEvery, now: time.Now}
+	genesis := &Block{Time: c.now(), Payload: []byte("genesis"), Difficulty: difficulty}
+	if _, err := Mine(ctx, genesis, c.Workers); err != nil {
+		return nil, err
+	}
+	c.Blocks = []*Block{genesis}
+	return c, nil
+}
+
+// Last returns the newest block.
+func (c *Chain) Last() *Block { return c.Blocks[len(c.Blocks)-1] }
+
+// Add mines a block holding payload at the end of the chain.
+func (c *Chain) Add(ctx context.Context, payload []byte) (*Block, uint64, error) {
+	prev := c.Last()
+	b := &Block{
+		Index:      prev.Index + 1,
+		Time:       c.now(),
+		Payload:    payload,
+		PrevHash:   prev.Hash,
+		Difficulty: c.nextDifficulty(len(c.Blocks)),
+	}
+	tries, err := Mine(ctx, b, c.Workers)
+	if err != nil {
+		return nil, tries, err
+	}
+	c.Blocks = append(c.Blocks, b)
+	return b, tries, nil
+}
+
+// nextDifficulty is the difficulty of the block at index i. It only depends
+// on the blocks before it, so Validate can check it.
+func (c *Chain) nextDifficulty(i int) int {
+	d := c.Blocks[i-1].Difficulty
+	n := c.RetargetEvery
+	if n <= 0 || c.TargetTime <= 0 || i%n != 0 || i < n+1 {
+		return d
+	}
+	// the time of a block is when its mining started: from block i-n-1 to
+	// block i-1 are the n mining times of blocks i-n-1 to i-2.
+	took := c.Blocks[i-1].Time.Sub(c.Blocks[i-n-1].Time)
+	want := c.TargetTime * time.Duration(n)
+	switch {
+	case took < want/2:
+		return d + 1
+	case took > want*2 && d > 1:
+		return d - 1
+	}
+	return d
+}
+
+// Validate checks every block: its hash, its proof of work, its link to the
+// previous block, and its difficulty. It returns a *ValidationError for the
+// first invalid one.
+func (c *Chain) Validate() error {
+	for i, b := range c.Blocks {
+		fail := func(err error) error { return &ValidationError{Index: uint64(i), Err: err} }
+		if b.ComputeHash() != b.Hash {
+			return fail(ErrBadHash)
+		}
+		if b.Hash.ZeroBits() < b.Difficulty {
+			return fail(ErrNotMined)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := c.Blocks[i-1]
+		switch {
+		case b.Index != prev.Index+1:
+			return fail(ErrBadIndex)
+		case b.PrevHash != prev.Hash:
+			return fail(ErrBrokenLink)
+		case b.Time.Before(prev.Time):
+			return fail(ErrTimeWarp)
+		case b.Difficulty != c.nextDifficulty(i):
+			return fail(ErrDifficulty)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newChain(t *testing.T, difficulty int, payloads ...string) *Chain {
+	t.Helper()
+	c, err := NewChain(context.Background(), difficulty, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range payloads {
+		if _, _, err := c.Add(context.Background(), []byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return c
+}
+
+// TestTamper rewrites a block of a mined chain: each step of the forgery is
+// caught, until every later block is mined again.
+func TestTamper(t *testing.T) {
+	ctx := context.Background()
+	c := newChain(t, 10, "alice pays bob 5", "bob pays carol 2", "carol pays dave 1")
+	if err := c.Validate(); err != nil || len(c.Blocks) != 4 {
+		t.Fatalf("a fresh chain of %d blocks: %v", len(c.Blocks), err)
+	}
+
+	forged := c.Blocks[2]
+	forged.Payload = []byte("bob pays carol 2000")
+	wantInvalid(t, c, 2, ErrBadHash)
+	forged.Hash = forged.ComputeHash()
+	if forged.Hash.ZeroBits() < forged.Difficulty {
+		wantInvalid(t, c, 2, ErrNotMined)
+	}
+	Mine(ctx, forged, 0)
+	wantInvalid(t, c, 3, ErrBrokenLink)
+
+	// rewriting history means mining again every block after the change.
+	for _, b := range c.Blocks[3:] {
+		b.PrevHash = c.Blocks[b.Index-1].Hash
+		Mine(ctx, b, 0)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("every later block mined again: %v", err)
+	}
+}
+
+func wantInvalid(t *testing.T, c *Chain, index uint64, want error) {
+	t.Helper()
+	err := c.Validate()
+	var verr *ValidationError
+	if !errors.As(err, &verr) || verr.Index != index || !errors.Is(err, want) {
+		t.Errorf("Validate = %v, want block %d: %v", err, index, want)
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	ctx := context.Background()
+	c := newChain(t, 4, "a", "b")
+	c.Blocks[2].Index = 5
+	Mine(ctx, c.Blocks[2], 0)
+	wantInvalid(t, c, 2, ErrBadIndex)
+
+	c = newChain(t, 4, "a", "b")
+	c.Blocks[2].Time = c.Blocks[1].Time.Add(-time.Second)
+	Mine(ctx, c.Blocks[2], 0)
+	wantInvalid(t, c, 2, ErrTimeWarp)
+}
+
+// clock returns a now func starting at start and moving by step every call.
+func clock(start time.Time, step time.Duration) func() time.Time {
+	now := start
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+// TestRetarget mines on a fake clock: blocks every millisecond are too fast
+// for a target of 20ms, every 100ms too slow.
+func TestRetarget(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		difficulty int
+		step       time.Duration
+		want       []int
+	}{
+		{"fast", 4, time.Millisecond, []int{4, 4, 4, 4, 5, 5, 6, 6, 7, 7, 8}},
+		{"slow", 4, 100 * time.Millisecond, []int{4, 4, 4, 4, 3, 3, 2, 2, 1, 1, 1}},
+		{"on target", 4, 20 * time.Millisecond, []int{4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4}},
+	} {
+		c, err := NewChain(context.Background(), tt.difficulty, 20*time.Millisecond, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Workers = 1
+		c.now = clock(c.Blocks[0].Time, tt.step)
+		for i := 1; i < len(tt.want); i++ {
+			if _, _, err := c.Add(context.Background(), []byte{byte(i)}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		var got []int
+		for _, b := range c.Blocks {
+			got = append(got, b.Difficulty)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: difficulties %v, want %v", tt.name, got, tt.want)
+		}
+		if err := c.Validate(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+
+		// an easier block than the rule asks for is caught.
+		last := c.Last()
+		last.Difficulty--
+		Mine(context.Background(), last, 0)
+		wantInvalid(t, c, last.Index, ErrDifficulty)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/blockchain"
+)
+
+/*
+miner mines a chain of blocks and prints them.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/blockchain/cmd/miner -difficulty 20 -blocks 5
+	go run ./golang_program_design_2024/09.projects/blockchain/cmd/miner -demo
+
+Every extra bit of difficulty doubles the work: try -difficulty 16, 20, 24.
+
+-demo compares one worker with one per CPU, tampers with a mined chain to
+show what validation catches, retargets the difficulty, and cancels a search
+that would take years. Its cases are tests:
+go test ./golang_program_design_2024/09.projects/blockchain
+*/
+
+func main() {
+	difficulty := flag.Int("difficulty", 18, "leading zero bits of a block hash")
+	blocks := flag.Int("blocks", 5, "blocks to mine after the genesis")
+	workers := flag.Int("workers", runtime.GOMAXPROCS(0), "mining goroutines")
+	demo := flag.Bool("demo", false, "run the tour of mining and validation")
+	flag.Parse()
+
+	if *demo {
+		if err := runDemo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	c, err := blockchain.NewChain(ctx, *difficulty, 0, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	c.Workers = *workers
+	printBlock(c.Last(), 0, time.Since(start))
+	for i := 1; i <= *blocks; i++ {
+		start := time.Now()
+		b, tries, err := c.Add(ctx, []byte(fmt.Sprintf("payment #%d", i)))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		printBlock(b, tries, time.Since(start))
+	}
+	fmt.Println("valid:", c.Validate() == nil)
+}
+
+func printBlock(b *blockchain.Block, tries uint64, took time.Duration) {
+	fmt.Printf("#%d %s nonce=%-9d %-14q %8v", b.Index, b.Hash.String()[:20], b.Nonce, b.Payload, took.Round(time.Millisecond))
+	if tries > 0 {
+		fmt.Printf(" %6.2f MH/s", float64(tries)/took.Seconds()/1e6)
+	}
+	fmt.Println()
+}
+
+func runDemo() error {
+	ctx := context.Background()
+
+	fmt.Println("== one worker vs one per CPU")
+	// the same block mined twice: the same amount of luck is needed, only the
+	// hash rate differs. More workers than CPUs do not help.
+	for _, workers := range []int{1, max(runtime.GOMAXPROCS(0), 4)} {
+		b := &blockchain.Block{Time: time.Unix(0, 0), Payload: []byte("benchmark"), Difficulty: 20}
+		start := time.Now()
+		tries, err := blockchain.Mine(ctx, b, workers)
+		took := time.Since(start)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%2d worker(s): %s after %d tries in %v, %.2f MH/s\n", workers, b.Hash.String()[:16], tries,
+			took.Round(time.Millisecond), float64(tries)/took.Seconds()/1e6)
+	}
+
+	fmt.Println("\n== validation")
+	c, err := blockchain.NewChain(ctx, 14, 0, 0)
+	if err != nil {
+		return err
+	}
+	for _, p := range []string{"alice pays bob 5", "bob pays carol 2", "carol pays dave 1"} {
+		if _, _, err := c.Add(ctx, []byte(p)); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("a freshly mined chain of %d blocks: %v\n", len(c.Blocks), c.Validate())
+
+	tampered := c.Blocks[2]
+	tampered.Payload = []byte("bob pays carol 2000")
+	fmt.Println("payload changed:", c.Validate())
+	tampered.Hash = tampered.ComputeHash()
+	fmt.Println("rehashed without work:", c.Validate())
+	blockchain.Mine(ctx, tampered, 0)
+	fmt.Println("mined again:", c.Validate())
+
+	// rewriting history means mining again every block after the change.
+	for _, b := range c.Blocks[3:] {
+		b.PrevHash = c.Blocks[b.Index-1].Hash
+		blockchain.Mine(ctx, b, 0)
+	}
+	fmt.Println("every later block mined again:", c.Validate())
+
+	fmt.Println("\n== difficulty retarget")
+	// blocks are wanted every 20ms, 4-bit blocks come far faster: every two
+	// blocks the difficulty goes up a bit, until mining slows down.
+	r, err := blockchain.NewChain(ctx, 4, 20*time.Millisecond, 2)
+	if err != nil {
+		return err
+	}
+	r.Workers = 1
+	for i := 0; i < 24; i++ {
+		if _, _, err := r.Add(ctx, []byte(fmt.Sprint(i))); err != nil {
+			return err
+		}
+	}
+	var steps []int
+	for _, b := range r.Blocks {
+		steps = append(steps, b.Difficulty)
+	}
+	fmt.Println("difficulties:", steps)
+	fmt.Println("the retargeted chain:", r.Validate())
+	r.Last().Difficulty = 4
+	blockchain.Mine(ctx, r.Last(), 0)
+	fmt.Println("the last block mined at 4 bits:", r.Validate())
+
+	fmt.Println("\n== cancellation")
+	hard := &blockchain.Block{Payload: []byte("never"), Difficulty: 64}
+	timeout, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	tries, err := blockchain.Mine(timeout, hard, 0)
+	fmt.Printf("a 64-bit search with a deadline of 100ms: %v after %v and %d tries\n",
+		err, time.Since(start).Round(time.Millisecond), tries)
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/cacheserver"
+	"github.com/YongSangUn/learn-golang/internal/config"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/cacheserver/cmd/cacheserver -addr 127.0.0.1:6380
+	printf 'SETEX greeting 10 hello world\nGET greeting\nTTL greeting\nSTATS\nQUIT\n' | nc 127.0.0.1 6380
+
+Settings come from internal/config, e.g. LEARN_MAX_CLIENTS=2 or -read_timeout 30s
+(the idle timeout of a client).
+*/
+
+func main() {
+	cfg, err := config.Load("cacheserver", os.Args[1:])
+	log := xlog.New("09.projects", "cacheserver")
+	if err != nil {
+		log.Error("invalid config", "err", err)
+		os.Exit(2)
+	}
+	if cfg.LogFormat == "json" {
+		xlog.SetHandler(xlog.NewHandler(os.Stderr, true))
+		log = xlog.New("09.projects", "cacheserver")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	store := cacheserver.NewStore(16)
+	go store.RunSweeper(ctx, time.Second)
+
+	l, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		log.Error("listen failed", "err", err)
+		os.Exit(1)
+	}
+	log.Info("listening", "addr", l.Addr().String())
+
+	srv := &cacheserver.Server{
+		Store:        store,
+		Log:          log,
+		IdleTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		MaxClients:   cfg.MaxClients,
+	}
+	if err := srv.Serve(ctx, l); err != nil {
+		log.Error("serve failed", "err", err)
+		os.Exit(1)
+	}
+	log.Info("shut down", "stats", store.Stats())
+}
@@ -0,0 +1,247 @@
+// Package cacheserver is an in-memory cache with per-key TTL served over TCP
+// with a line protocol. Each request is one line, each reply is one line:
+//
+//	SET key value            -> OK (the value may contain spaces)
+//	SETEX key seconds value  -> OK
+//	GET key                  -> VALUE value | NIL
+//	DEL key                  -> INT 1 | INT 0
+//	EXPIRE key seconds       -> INT 1 | INT 0
+//	TTL key                  -> INT seconds (-1: no ttl, -2: missing)
+//	STATS                    -> STATS keys=.. hits=.. misses=.. expired=.. clients=..
+//	QUIT                     -> BYE, then the connection is closed
+//
+// Errors are replied as "ERR message". Try it with `nc 127.0.0.1 8080`.
+package cacheserver
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server accepts connections and runs one goroutine per client.
+type Server struct {
+	Store        *Store
+	Log          *slog.Logger
+	IdleTimeout  time.Duration // a client silent for this long is disconnected, 0: never
+	WriteTimeout time.Duration // 0: no timeout
+	MaxClients   int           // 0: no limit
+
+	clients atomic.Int64
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	conns   map[net.Conn]struct{}
+	closing bool
+}
+
+// Serve accepts connections on l until ctx is cancelled, then closes every
+// client connection and waits for their goroutines to return.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	s.conns = make(map[net.Conn]struct{})
+	// a buffered channel used as a semaphore bounds the number of clients,
+	// a nil one means no bound.
+	var slots chan struct{}
+	if s.MaxClients > 0 {
+		slots = make(chan struct{}, s.MaxClients)
+	}
+
+	go func() {
+		<-ctx.Done()
+		l.Close() // unblocks Accept
+		s.mu.Lock()
+		s.closing = true
+		for c := range s.conns {
+			c.Close() // unblocks the reads of every client
+		}
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.wg.Wait()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		if slots != nil {
+			select {
+			case slots <- struct{}{}:
+			default:
+				fmt.Fprintln(conn, "ERR too many clients")
+				conn.Close()
+				continue
+			}
+		}
+
+		s.track(conn, true)
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if slots != nil {
+				defer func() { <-slots }()
+			}
+			defer s.track(conn, false)
+			s.handle(conn)
+		}()
+	}
+}
+
+func (s *Server) track(conn net.Conn, add bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if add {
+		if s.closing {
+			conn.Close() // accepted right before shutdown, the read fails at once.
+		}
+		s.conns[conn] = struct{}{}
+		s.clients.Add(1)
+	} else {
+		delete(s.conns, conn)
+		s.clients.Add(-1)
+		conn.Close()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	log := s.Log.With("remote", conn.RemoteAddr().String())
+	log.Info("client connected")
+	defer log.Info("client disconnected")
+
+	scanner := bufio.NewScanner(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		conn.SetReadDeadline(deadline(s.IdleTimeout))
+		if !scanner.Scan() {
+			var netErr net.Error
+			if err := scanner.Err(); errors.As(err, &netErr) && netErr.Timeout() {
+				fmt.Fprintln(conn, "ERR idle timeout")
+			}
+			return
+		}
+
+		reply, quit := s.exec(scanner.Text())
+		conn.SetWriteDeadline(deadline(s.WriteTimeout))
+		w.WriteString(reply)
+		w.WriteByte('\n')
+		if err := w.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// deadline returns the time d from now, or the zero time, no deadline, if d
+// is not positive.
+func deadline(d time.Duration) time.Time {
+	if d <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(d)
+}
+
+// exec runs a single command line and returns the reply.
+func (s *Server) exec(line string) (reply string, quit bool) {
+	cmd, args := parse(line)
+	switch cmd {
+	case "":
+		return "ERR empty command", false
+	case "QUIT":
+		return "BYE", true
+	case "STATS":
+		st := s.Store.Stats()
+		return fmt.Sprintf("STATS keys=%d hits=%d misses=%d expired=%d clients=%d",
+			st.Keys, st.Hits, st.Misses, st.Expired, s.clients.Load()), false
+	}
+
+	if len(args) == 0 {
+		return "ERR missing key", false
+	}
+	key := args[0]
+	switch cmd {
+	case "GET":
+		if v, ok := s.Store.Get(key); ok {
+			return "VALUE " + v, false
+		}
+		return "NIL", false
+	case "SET":
+		if len(args) < 2 {
+			return "ERR usage: SET key value", false
+		}
+		s.Store.Set(key, args[1], 0)
+		return "OK", false
+	case "SETEX":
+		if len(args) < 2 {
+			return "ERR usage: SETEX key seconds value", false
+		}
+		secs, value, _ := strings.Cut(args[1], " ")
+		ttl, err := seconds(secs)
+		if err != nil || value == "" {
+			return "ERR usage: SETEX key seconds value", false
+		}
+		s.Store.Set(key, value, ttl)
+		return "OK", false
+	case "DEL":
+		return boolReply(s.Store.Delete(key)), false
+	case "EXPIRE":
+		if len(args) < 2 {
+			return "ERR usage: EXPIRE key seconds", false
+		}
+		ttl, err := seconds(args[1])
+		if err != nil {
+			return "ERR " + err.Error(), false
+		}
+		return boolReply(s.Store.Expire(key, ttl)), false
+	case "TTL":
+		ttl, ok := s.Store.TTL(key)
+		switch {
+		case !ok:
+			return "INT -2", false
+		case ttl < 0:
+			return "INT -1", false
+		}
+		return "INT " + strconv.Itoa(int((ttl+time.Second-1)/time.Second)), false
+	}
+	return "ERR unknown command " + cmd, false
+}
+
+// parse splits a line into the upper-cased command, the key, and the rest of
+// the line, so values may contain spaces.
+func parse(line string) (cmd string, args []string) {
+	line = strings.TrimSpace(line)
+	cmd, rest, _ := strings.Cut(line, " ")
+	cmd = strings.ToUpper(cmd)
+	rest = strings.TrimLeft(rest, " ")
+	if rest == "" {
+		return cmd, nil
+	}
+	key, value, _ := strings.Cut(rest, " ")
+	if value = strings.TrimLeft(value, " "); value == "" {
+		return cmd, []string{key}
+	}
+	return cmd, []string{key, value}
+}
+
+func seconds(s string) (time.Duration, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid seconds %q", s)
+	}
+	return time.Duration(n) * time.Second, nil
+}
+
+func boolReply(ok bool) string {
+	if ok {
+		return "INT 1"
+	}
+	return "INT 0"
+}
@@ -0,0 +1,187 @@
+package cacheserver
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+// TestExec runs a session of commands in order, the clock moved by wait
+// before each of them.
+func TestExec(t *testing.T) {
+	st := NewStore(4)
+	now := fakeNow(st)
+	s := &Server{Store: st}
+	for _, tt := range []struct {
+		wait       time.Duration
+		line, want string
+	}{
+		{0, "", "ERR empty command"},
+		{0, "   ", "ERR empty command"},
+		{0, "GET k", "NIL"},
+		{0, "SET k hello world", "OK"},
+		{0, "get k", "VALUE hello world"},
+		{0, "  set   k   spaced  ", "OK"},
+		{0, "GET k", "VALUE spaced"},
+		{0, "TTL k", "INT -1"},
+		{0, "EXPIRE k 10", "INT 1"},
+		{0, "TTL k", "INT 10"},
+		{9500 * time.Millisecond, "TTL k", "INT 1"}, // rounded up
+		{500 * time.Millisecond, "GET k", "NIL"},
+		{0, "TTL k", "INT -2"},
+		{0, "EXPIRE k 10", "INT 0"},
+		{0, "SETEX s 5 short lived", "OK"},
+		{0, "GET s", "VALUE short lived"},
+		{0, "TTL s", "INT 5"},
+		{5 * time.Second, "GET s", "NIL"},
+		{0, "SET d x", "OK"},
+		{0, "DEL d", "INT 1"},
+		{0, "DEL d", "INT 0"},
+		{0, "STATS", "STATS keys=2 hits=3 misses=3 expired=0 clients=0"},
+
+		// bad arity and bad seconds
+		{0, "GET", "ERR missing key"},
+		{0, "DEL", "ERR missing key"},
+		{0, "SET k", "ERR usage: SET key value"},
+		{0, "SETEX s 5", "ERR usage: SETEX key seconds value"},
+		{0, "SETEX s", "ERR usage: SETEX key seconds value"},
+		{0, "SETEX s five v", "ERR usage: SETEX key seconds value"},
+		{0, "EXPIRE k", "ERR usage: EXPIRE key seconds"},
+		{0, "EXPIRE k soon", `ERR invalid seconds "soon"`},
+		{0, "EXPIRE k 0", `ERR invalid seconds "0"`},
+		{0, "EXPIRE k -1", `ERR invalid seconds "-1"`},
+		{0, "FLUSH k", "ERR unknown command FLUSH"},
+	} {
+		*now = now.Add(tt.wait)
+		if got, quit := s.exec(tt.line); got != tt.want || quit {
+			t.Errorf("%q = %q, quit %v; want %q", tt.line, got, quit, tt.want)
+		}
+	}
+	if got, quit := s.exec("quit"); got != "BYE" || !quit {
+		t.Errorf("quit = %q, quit %v", got, quit)
+	}
+}
+
+// serve runs srv on a localhost port until the test ends.
+func serve(t *testing.T, srv *Server) string {
+	t.Helper()
+	srv.Store = NewStore(4)
+	srv.Log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, l) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := testutil.RequireRecv(t, served, 2*time.Second); err != nil {
+			t.Errorf("Serve = %v after the cancel", err)
+		}
+	})
+	return l.Addr().String()
+}
+
+type client struct {
+	t *testing.T
+	c net.Conn
+	r *bufio.Reader
+}
+
+func dial(t *testing.T, addr string) *client {
+	t.Helper()
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return &client{t: t, c: c, r: bufio.NewReader(c)}
+}
+
+// read returns the next reply line, or the error that ended the connection.
+func (cl *client) read() (string, error) {
+	cl.c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	line, err := cl.r.ReadString('\n')
+	if err != nil {
+		return line, err
+	}
+	return line[:len(line)-1], nil
+}
+
+func (cl *client) do(line string) string {
+	cl.t.Helper()
+	if _, err := io.WriteString(cl.c, line+"\n"); err != nil {
+		cl.t.Fatalf("%s: %v", line, err)
+	}
+	reply, err := cl.read()
+	if err != nil {
+		cl.t.Fatalf("%s: %v", line, err)
+	}
+	return reply
+}
+
+// TestMaxClients serves one client at a time: the second is told so and
+// closed, the first goes on.
+func TestMaxClients(t *testing.T) {
+	addr := serve(t, &Server{MaxClients: 1})
+	first := dial(t, addr)
+	if got := first.do("SET k v"); got != "OK" {
+		t.Fatalf("first client: %q", got)
+	}
+	second := dial(t, addr)
+	if got, err := second.read(); got != "ERR too many clients" || err != nil {
+		t.Errorf("second client: %q, %v", got, err)
+	}
+	if _, err := second.read(); err != io.EOF {
+		t.Errorf("second client after the refusal: %v, want EOF", err)
+	}
+	if got := first.do("GET k"); got != "VALUE v" {
+		t.Errorf("first client after the refusal: %q", got)
+	}
+}
+
+// TestIdleTimeout checks that a silent client is disconnected, and that
+// each command pushes the deadline back.
+func TestIdleTimeout(t *testing.T) {
+	addr := serve(t, &Server{IdleTimeout: 100 * time.Millisecond})
+	cl := dial(t, addr)
+	for i := 0; i < 4; i++ {
+		time.Sleep(40 * time.Millisecond)
+		if got := cl.do("GET k"); got != "NIL" {
+			t.Fatalf("command %d of an active client: %q", i, got)
+		}
+	}
+	start := time.Now()
+	if got, err := cl.read(); got != "ERR idle timeout" || err != nil {
+		t.Errorf("a silent client: %q, %v", got, err)
+	}
+	if _, err := cl.read(); err != io.EOF {
+		t.Errorf("after the idle timeout: %v, want EOF", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("disconnected after %v, the timeout is 100ms", d)
+	}
+}
+
+// TestNoLimits serves with the zero MaxClients and timeouts: no client is
+// refused nor dropped.
+func TestNoLimits(t *testing.T) {
+	addr := serve(t, &Server{})
+	clients := []*client{dial(t, addr), dial(t, addr), dial(t, addr)}
+	time.Sleep(50 * time.Millisecond)
+	for i, cl := range clients {
+		if got := cl.do("SET k v"); got != "OK" {
+			t.Errorf("client %d: %q", i, got)
+		}
+	}
+	if got := clients[0].do("STATS"); got != "STATS keys=1 hits=0 misses=0 expired=0 clients=3" {
+		t.Errorf("STATS = %q", got)
+	}
+}
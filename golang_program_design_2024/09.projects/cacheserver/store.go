@@ -0,0 +1,179 @@
+package cacheserver
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Store is an in-memory map split into shards, each behind its own RWMutex,
+// so goroutines working on different keys rarely wait for each other
+// (compare with the single mutex of syncRWMutex in 04.concurrent/sync).
+type Store struct {
+	shards []*shard
+	now    func() time.Time
+
+	hits, misses, expired atomic.Int64
+}
+
+type shard struct {
+	mu    sync.RWMutex
+	items map[string]item
+}
+
+type item struct {
+	value   string
+	expires time.Time // zero means no TTL
+}
+
+func (it item) expiredAt(now time.Time) bool {
+	return !it.expires.IsZero() && !now.Before(it.expires)
+}
+
+// NewStore creates a store with n shards, n is rounded up to at least 1.
+func NewStore(n int) *Store {
+	if n < 1 {
+		n = 1
+	}
+	s := &Store{shards: make([]*shard, n), now: time.Now}
+	for i := range s.shards {
+		s.shards[i] = &shard{items: make(map[string]item)}
+	}
+	return s
+}
+
+func (s *Store) shard(key string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
+
+// Get returns the value of key. An expired key is reported missing even if
+// the sweeper has not removed it yet.
+func (s *Store) Get(key string) (string, bool) {
+	sh := s.shard(key)
+	sh.mu.RLock()
+	it, ok := sh.items[key]
+	sh.mu.RUnlock()
+	if !ok || it.expiredAt(s.now()) {
+		s.misses.Add(1)
+		return "", false
+	}
+	s.hits.Add(1)
+	return it.value, true
+}
+
+// Set stores value under key, ttl <= 0 means it never expires.
+func (s *Store) Set(key, value string, ttl time.Duration) {
+	it := item{value: value}
+	if ttl > 0 {
+		it.expires = s.now().Add(ttl)
+	}
+	sh := s.shard(key)
+	sh.mu.Lock()
+	sh.items[key] = it
+	sh.mu.Unlock()
+}
+
+// Delete removes key and reports whether it existed.
+func (s *Store) Delete(key string) bool {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	it, ok := sh.items[key]
+	delete(sh.items, key)
+	return ok && !it.expiredAt(s.now())
+}
+
+// Expire sets a new TTL on an existing key and reports whether it exists.
+func (s *Store) Expire(key string, ttl time.Duration) bool {
+	sh := s.shard(key)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	it, ok := sh.items[key]
+	if !ok || it.expiredAt(s.now()) {
+		return false
+	}
+	it.expires = s.now().Add(ttl)
+	sh.items[key] = it
+	return true
+}
+
+// TTL returns the remaining time to live of key, -1 if it has none and
+// ok=false if it does not exist.
+func (s *Store) TTL(key string) (ttl time.Duration, ok bool) {
+	sh := s.shard(key)
+	sh.mu.RLock()
+	it, ok := sh.items[key]
+	sh.mu.RUnlock()
+	now := s.now()
+	switch {
+	case !ok || it.expiredAt(now):
+		return 0, false
+	case it.expires.IsZero():
+		return -1, true
+	}
+	return it.expires.Sub(now), true
+}
+
+// Len counts the keys, including expired ones not swept yet.
+func (s *Store) Len() int {
+	n := 0
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		n += len(sh.items)
+		sh.mu.RUnlock()
+	}
+	return n
+}
+
+// Sweep removes the expired keys one shard at a time and returns how many.
+func (s *Store) Sweep() int {
+	removed := 0
+	now := s.now()
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for key, it := range sh.items {
+			if it.expiredAt(now) {
+				delete(sh.items, key) // deleting while ranging over a map is allowed.
+				removed++
+			}
+		}
+		sh.mu.Unlock()
+	}
+	s.expired.Add(int64(removed))
+	return removed
+}
+
+// RunSweeper calls Sweep every interval until ctx is done.
+func (s *Store) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sweep()
+		}
+	}
+}
+
+// Stats is a snapshot of the store counters.
+type Stats struct {
+	Keys    int
+	Hits    int64
+	Misses  int64
+	Expired int64 // removed by the sweeper
+}
+
+func (s *Store) Stats() Stats {
+	return Stats{
+		Keys:    s.Len(),
+		Hits:    s.hits.Load(),
+		Misses:  s.misses.Load(),
+		Expired: s.expired.Load(),
+	}
+}
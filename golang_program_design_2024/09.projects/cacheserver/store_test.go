@@ -0,0 +1,96 @@
+package cacheserver
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeNow makes s read the time from the returned clock, which the test
+// moves by hand.
+func fakeNow(s *Store) *time.Time {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	return &now
+}
+
+func TestStoreTTL(t *testing.T) {
+	s := NewStore(4)
+	now := fakeNow(s)
+	s.Set("forever", "a", 0)
+	s.Set("short", "b", 10*time.Second)
+
+	if ttl, ok := s.TTL("forever"); ttl != -1 || !ok {
+		t.Errorf("TTL without one = %v, %v; want -1, true", ttl, ok)
+	}
+	*now = now.Add(4 * time.Second)
+	if ttl, ok := s.TTL("short"); ttl != 6*time.Second || !ok {
+		t.Errorf("TTL after 4s = %v, %v; want 6s, true", ttl, ok)
+	}
+	if v, ok := s.Get("short"); v != "b" || !ok {
+		t.Errorf("Get before the TTL = %q, %v", v, ok)
+	}
+
+	// Expire moves the deadline from now, not from the Set.
+	if !s.Expire("short", 10*time.Second) {
+		t.Fatal("Expire of a live key = false")
+	}
+	*now = now.Add(9 * time.Second)
+	if _, ok := s.Get("short"); !ok {
+		t.Error("expired 9s after an Expire of 10s")
+	}
+
+	*now = now.Add(time.Second)
+	if _, ok := s.Get("short"); ok {
+		t.Error("Get at the TTL found the key")
+	}
+	if _, ok := s.TTL("short"); ok {
+		t.Error("TTL of an expired key = true")
+	}
+	if s.Expire("short", time.Minute) || s.Delete("short") {
+		t.Error("Expire or Delete of an expired key = true")
+	}
+	if v, ok := s.Get("forever"); v != "a" || !ok {
+		t.Errorf("Get of a key without TTL = %q, %v", v, ok)
+	}
+
+	st := s.Stats()
+	if st.Hits != 3 || st.Misses != 1 {
+		t.Errorf("hits %d, misses %d; want 3, 1", st.Hits, st.Misses)
+	}
+}
+
+// TestSweep checks that Sweep removes the expired keys of every shard, and
+// them only.
+func TestSweep(t *testing.T) {
+	s := NewStore(8)
+	now := fakeNow(s)
+	for i := 0; i < 100; i++ {
+		ttl := time.Duration(0)
+		if i%2 == 0 {
+			ttl = time.Duration(1+i%10) * time.Second
+		}
+		s.Set(fmt.Sprint("key-", i), "v", ttl)
+	}
+	if n := s.Sweep(); n != 0 {
+		t.Errorf("Sweep before any TTL = %d", n)
+	}
+
+	// the keys of ttl 1s to 5s, i%10 in 0, 2, 4: 30 keys.
+	*now = now.Add(5 * time.Second)
+	if n := s.Sweep(); n != 30 {
+		t.Errorf("Sweep after 5s = %d, want 30", n)
+	}
+	*now = now.Add(time.Hour)
+	if n := s.Sweep(); n != 20 {
+		t.Errorf("Sweep after an hour = %d, want 20", n)
+	}
+	if st := s.Stats(); st.Keys != 50 || st.Expired != 50 {
+		t.Errorf("%d keys, %d expired; want 50, 50", st.Keys, st.Expired)
+	}
+	for i := 1; i < 100; i += 2 {
+		if _, ok := s.Get(fmt.Sprint("key-", i)); !ok {
+			t.Fatalf("key-%d without TTL was swept", i)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package calc
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Node is a node of the syntax tree. The set of nodes is closed: the types
+// below are all there is, and code processing a tree switches on them.
+type Node interface {
+	Pos() int
+	node()
+}
+
+type (
+	// Num is a number literal.
+	Num struct {
+		At    int
+		Value float64
+	}
+	// Var is a variable or a constant read by name.
+	Var struct {
+		At   int
+		Name string
+	}
+	// Unary is -X or +X.
+	Unary struct {
+		At int
+		Op byte
+		X  Node
+	}
+	// Binary is L Op R, Op one of + - * / % ^.
+	Binary struct {
+		At   int
+		Op   byte
+		L, R Node
+	}
+	// Call is Fn(Args...).
+	Call struct {
+		At   int
+		Fn   string
+		Args []Node
+	}
+	// Assign is Name = Value, its value is the one assigned.
+	Assign struct {
+		At    int
+		Name  string
+		Value Node
+	}
+)
+
+func (n *Num) Pos() int    { return n.At }
+func (n *Var) Pos() int    { return n.At }
+func (n *Unary) Pos() int  { return n.At }
+func (n *Binary) Pos() int { return n.At }
+func (n *Call) Pos() int   { return n.At }
+func (n *Assign) Pos() int { return n.At }
+
+func (*Num) node()    {}
+func (*Var) node()    {}
+func (*Unary) node()  {}
+func (*Binary) node() {}
+func (*Call) node()   {}
+func (*Assign) node() {}
+
+// Visitor is called by Walk for each node. Visit returns the visitor used
+// for the children of n, nil to skip them (the shape of go/ast.Visitor).
+type Visitor interface {
+	Visit(n Node) Visitor
+}
+
+// Walk visits n, then its children in order.
+func Walk(v Visitor, n Node) {
+	if v = v.Visit(n); v == nil {
+		return
+	}
+	switch n := n.(type) {
+	case *Unary:
+		Walk(v, n.X)
+	case *Binary:
+		Walk(v, n.L)
+		Walk(v, n.R)
+	case *Call:
+		for _, a := range n.Args {
+			Walk(v, a)
+		}
+	case *Assign:
+		Walk(v, n.Value)
+	}
+}
+
+// inspector turns a function into a Visitor.
+type inspector func(Node) bool
+
+func (f inspector) Visit(n Node) Visitor {
+	if f(n) {
+		return f
+	}
+	return nil
+}
+
+// Inspect walks the tree calling f, which returns false to skip the
+// children of a node.
+func Inspect(n Node, f func(Node) bool) { Walk(inspector(f), n) }
+
+// Names returns the variables a tree reads and the functions it calls,
+// sorted and without duplicates.
+func Names(n Node) (vars, funcs []string) {
+	seenVars, seenFuncs := make(map[string]bool), make(map[string]bool)
+	Inspect(n, func(n Node) bool {
+		switch n := n.(type) {
+		case *Var:
+			seenVars[n.Name] = true
+		case *Call:
+			seenFuncs[n.Fn] = true
+		}
+		return true
+	})
+	return sortedKeys(seenVars), sortedKeys(seenFuncs)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String prints the tree fully parenthesized, which shows how it was parsed:
+// 1+2*3 is (1 + (2 * 3)).
+func String(n Node) string {
+	var b strings.Builder
+	write(&b, n)
+	return b.String()
+}
+
+func write(b *strings.Builder, n Node) {
+	switch n := n.(type) {
+	case *Num:
+		b.WriteString(strconv.FormatFloat(n.Value, 'g', -1, 64))
+	case *Var:
+		b.WriteString(n.Name)
+	case *Unary:
+		b.WriteString("(")
+		b.WriteByte(n.Op)
+		write(b, n.X)
+		b.WriteString(")")
+	case *Binary:
+		b.WriteString("(")
+		write(b, n.L)
+		b.WriteString(" " + string(n.Op) + " ")
+		write(b, n.R)
+		b.WriteString(")")
+	case *Call:
+		b.WriteString(n.Fn + "(")
+		for i, a := range n.Args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			write(b, a)
+		}
+		b.WriteString(")")
+	case *Assign:
+		b.WriteString(n.Name + " = ")
+		write(b, n.Value)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/calc"
+)
+
+/*
+calc evaluates expressions, one per line. Variables assigned on a line are
+kept for the next ones; :tree prints how the last line was parsed, :vars
+the variables.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/calc/cmd/calc
+	> r = 2
+	2
+	> pi * r^2
+	12.566370614359172
+
+	go run ./golang_program_design_2024/09.projects/calc/cmd/calc -demo
+
+-demo runs the session below through the parser and the evaluator, printing
+each line, how it parses, and its value or error. Its cases are tests:
+go test ./golang_program_design_2024/09.projects/calc
+*/
+
+func main() {
+	demo := flag.Bool("demo", false, "run a session of example lines")
+	flag.Parse()
+	if *demo {
+		runDemo()
+		return
+	}
+
+	env := calc.NewEnv()
+	var last calc.Node
+	sc := bufio.NewScanner(os.Stdin)
+	fmt.Print("> ")
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "":
+		case line == ":tree":
+			if last != nil {
+				fmt.Println(calc.String(last))
+			}
+		case line == ":vars":
+			for name, v := range env.Vars {
+				fmt.Printf("%s = %g\n", name, v)
+			}
+		default:
+			n, err := calc.Parse(line)
+			if err != nil {
+				pointAt(line, err)
+				break
+			}
+			last = n
+			v, err := calc.Eval(n, env)
+			if err != nil {
+				pointAt(line, err)
+				break
+			}
+			fmt.Println(v)
+		}
+		fmt.Print("> ")
+	}
+	fmt.Println()
+}
+
+// pointAt prints the error under the line, with a caret at its position.
+func pointAt(line string, err error) {
+	pos := -1
+	var serr *calc.SyntaxError
+	var eerr *calc.EvalError
+	if errors.As(err, &serr) {
+		pos = serr.Pos
+	} else if errors.As(err, &eerr) {
+		pos = eerr.Pos
+	}
+	if pos >= 0 {
+		// the prompt is 2 characters wide.
+		fmt.Printf("%s^\n", strings.Repeat(" ", pos+2))
+	}
+	fmt.Println("error:", err)
+}
+
+// session runs in order in one environment: assignments are seen by the
+// lines after them.
+var session = []string{
+	"1 + 2 * 3",
+	"10 - 4 - 3",
+	"2 ^ 3 ^ 2",
+	"-2 ^ 2",
+	"7 % 3 + 1.5e1",
+	"x = 3",
+	"y = x * 2 + 1",
+	"a = b = 5",
+	"a + b + x + y",
+	"pi * 2 ^ 2",
+	"max(1, y, 3) - min(4, x)",
+	"1 / 0",
+	"5 % (x - 3)",
+	"z + 1",
+	"nope(1)",
+	"sqrt(1, 2)",
+	"pi = 3",
+	"(1 + 2",
+	"2 * x = 4",
+	"3 $ 4",
+}
+
+func runDemo() {
+	env := calc.NewEnv()
+	for _, src := range session {
+		n, err := calc.Parse(src)
+		if err != nil {
+			fmt.Printf("%-26s %v\n", src, err)
+			continue
+		}
+		v, err := calc.Eval(n, env)
+		if err != nil {
+			fmt.Printf("%-26s %-36s %v\n", src, calc.String(n), err)
+			continue
+		}
+		fmt.Printf("%-26s %-36s %g\n", src, calc.String(n), v)
+	}
+
+	// a visitor pass: what a formula depends on, without evaluating it.
+	n, _ := calc.Parse("total = price * qty * (1 + vat) + max(fee, min_fee) - sqrt(price)")
+	vars, funcs := calc.Names(n)
+	fmt.Printf("\n%s\n  reads %v, calls %v\n", calc.String(n), vars, funcs)
+}
@@ -0,0 +1,199 @@
+package calc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var (
+	ErrDivByZero = errors.New("division by zero")
+	ErrUndefined = errors.New("undefined variable")
+	ErrUnknownFn = errors.New("unknown function")
+	ErrArity     = errors.New("wrong number of arguments")
+	ErrConstant  = errors.New("cannot assign to a constant")
+)
+
+// EvalError is an error of evaluation, at the node that caused it.
+type EvalError struct {
+	Pos int
+	Err error
+	// Name is the variable or function concerned, if any.
+	Name string
+}
+
+func (e *EvalError) Error() string {
+	if e.Name != "" {
+		return fmt.Sprintf("at %d: %v: %s", e.Pos, e.Err, e.Name)
+	}
+	return fmt.Sprintf("at %d: %v", e.Pos, e.Err)
+}
+
+func (e *EvalError) Unwrap() error { return e.Err }
+
+// Func is a function callable from expressions. Arity -1 accepts one
+// argument or more.
+type Func struct {
+	Arity int
+	Fn    func(args ...float64) float64
+}
+
+// Builtins are the functions of every new Env.
+var Builtins = map[string]Func{
+	"sqrt":  {1, func(a ...float64) float64 { return math.Sqrt(a[0]) }},
+	"abs":   {1, func(a ...float64) float64 { return math.Abs(a[0]) }},
+	"sin":   {1, func(a ...float64) float64 { return math.Sin(a[0]) }},
+	"cos":   {1, func(a ...float64) float64 { return math.Cos(a[0]) }},
+	"ln":    {1, func(a ...float64) float64 { return math.Log(a[0]) }},
+	"floor": {1, func(a ...float64) float64 { return math.Floor(a[0]) }},
+	"pow":   {2, func(a ...float64) float64 { return math.Pow(a[0], a[1]) }},
+	"hypot": {2, func(a ...float64) float64 { return math.Hypot(a[0], a[1]) }},
+	"min": {-1, func(a ...float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Min(m, v)
+		}
+		return m
+	}},
+	"max": {-1, func(a ...float64) float64 {
+		m := a[0]
+		for _, v := range a[1:] {
+			m = math.Max(m, v)
+		}
+		return m
+	}},
+}
+
+// Constants are readable from every Env and cannot be assigned.
+var Constants = map[string]float64{"pi": math.Pi, "e": math.E}
+
+// Env holds the variables and functions an expression sees. Assignments
+// write to Vars.
+type Env struct {
+	Vars  map[string]float64
+	Funcs map[string]Func
+}
+
+// NewEnv returns an environment with no variables and the builtins.
+func NewEnv() *Env {
+	funcs := make(map[string]Func, len(Builtins))
+	for k, f := range Builtins {
+		funcs[k] = f
+	}
+	return &Env{Vars: make(map[string]float64), Funcs: funcs}
+}
+
+// Lookup returns the value of a constant or variable.
+func (env *Env) Lookup(name string) (float64, bool) {
+	if v, ok := Constants[name]; ok {
+		return v, true
+	}
+	v, ok := env.Vars[name]
+	return v, ok
+}
+
+// Eval evaluates the tree, walking it recursively: the value of a node is
+// computed from the values of its children.
+func Eval(n Node, env *Env) (float64, error) {
+	switch n := n.(type) {
+	case *Num:
+		return n.Value, nil
+	case *Var:
+		v, ok := env.Lookup(n.Name)
+		if !ok {
+			return 0, &EvalError{n.At, ErrUndefined, n.Name}
+		}
+		return v, nil
+	case *Unary:
+		x, err := Eval(n.X, env)
+		if err != nil {
+			return 0, err
+		}
+		if n.Op == '-' {
+			return -x, nil
+		}
+		return x, nil
+	case *Binary:
+		l, err := Eval(n.L, env)
+		if err != nil {
+			return 0, err
+		}
+		r, err := Eval(n.R, env)
+		if err != nil {
+			return 0, err
+		}
+		v, err := Apply(n.Op, l, r)
+		if err != nil {
+			return 0, &EvalError{Pos: n.At, Err: err}
+		}
+		return v, nil
+	case *Call:
+		f, err := env.function(n)
+		if err != nil {
+			return 0, err
+		}
+		args := make([]float64, len(n.Args))
+		for i, a := range n.Args {
+			if args[i], err = Eval(a, env); err != nil {
+				return 0, err
+			}
+		}
+		return f.Fn(args...), nil
+	case *Assign:
+		if _, ok := Constants[n.Name]; ok {
+			return 0, &EvalError{n.At, ErrConstant, n.Name}
+		}
+		v, err := Eval(n.Value, env)
+		if err != nil {
+			return 0, err
+		}
+		env.Vars[n.Name] = v
+		return v, nil
+	}
+	panic(fmt.Sprintf("calc: unknown node %T", n))
+}
+
+// function resolves the function of a call and checks its arity.
+func (env *Env) function(c *Call) (Func, error) {
+	f, ok := env.Funcs[c.Fn]
+	if !ok {
+		return f, &EvalError{c.At, ErrUnknownFn, c.Fn}
+	}
+	if f.Arity >= 0 && len(c.Args) != f.Arity || f.Arity < 0 && len(c.Args) == 0 {
+		return f, &EvalError{c.At, fmt.Errorf("%w: %s takes %s, got %d", ErrArity, c.Fn, arity(f.Arity), len(c.Args)), ""}
+	}
+	return f, nil
+}
+
+func arity(n int) string {
+	if n < 0 {
+		return "at least 1"
+	}
+	return fmt.Sprint(n)
+}
+
+// Apply computes l op r. Division and remainder by zero are errors rather
+// than infinities: a calculator user wants to know.
+func Apply(op byte, l, r float64) (float64, error) {
+	switch op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		if r == 0 {
+			return 0, ErrDivByZero
+		}
+		return l / r, nil
+	case '%':
+		if r == 0 {
+			return 0, ErrDivByZero
+		}
+		return math.Mod(l, r), nil
+	case '^':
+		return math.Pow(l, r), nil
+	}
+	return 0, fmt.Errorf("unknown operator %q", op)
+}
@@ -0,0 +1,113 @@
+package calc
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func eval(src string, env *Env) (float64, error) {
+	n, err := Parse(src)
+	if err != nil {
+		return 0, err
+	}
+	return Eval(n, env)
+}
+
+func TestEval(t *testing.T) {
+	for _, tt := range []struct {
+		src  string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"1 - 2 - 3", -4},
+		{"2 ^ 3 ^ 2", 512},
+		{"-2 ^ 2", -4},
+		{"(-2) ^ 2", 4},
+		{"7 % 4", 3},
+		{"-7 % 4", -3},
+		{"1 / 4", 0.25},
+		{"1.5e3 + .5", 1500.5},
+		{"pi", math.Pi},
+		{"floor(e * 100)", 271},
+		{"sqrt(16) + abs(-2)", 6},
+		{"pow(2, 10)", 1024},
+		{"hypot(3, 4)", 5},
+		{"max(1, 5, 3)", 5},
+		{"min(4)", 4},
+		{"ln(e)", 1},
+		{"x = 2 * 3", 6},
+	} {
+		got, err := eval(tt.src, NewEnv())
+		if err != nil {
+			t.Errorf("%s: %v", tt.src, err)
+		} else if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	for _, tt := range []struct {
+		src  string
+		err  error
+		pos  int
+		name string
+	}{
+		{"1 / 0", ErrDivByZero, 2, ""},
+		{"1 % (2 - 2)", ErrDivByZero, 2, ""},
+		{"1 + nope", ErrUndefined, 4, "nope"},
+		{"nope(1)", ErrUnknownFn, 0, "nope"},
+		{"sqrt(1, 2)", ErrArity, 0, ""},
+		{"pow(1)", ErrArity, 0, ""},
+		{"max()", ErrArity, 0, ""},
+		{"pi = 3", ErrConstant, 0, "pi"},
+		// the first error of the left operand stops the evaluation.
+		{"a / 0 + b", ErrUndefined, 0, "a"},
+	} {
+		_, err := eval(tt.src, NewEnv())
+		var ee *EvalError
+		if !errors.Is(err, tt.err) || !errors.As(err, &ee) {
+			t.Errorf("%s: %v, want %v", tt.src, err, tt.err)
+			continue
+		}
+		if ee.Pos != tt.pos || ee.Name != tt.name {
+			t.Errorf("%s: at %d name %q, want at %d name %q", tt.src, ee.Pos, ee.Name, tt.pos, tt.name)
+		}
+	}
+}
+
+// TestEnv evaluates a session of lines in one environment: assignments
+// last, the builtins of one Env are its own.
+func TestEnv(t *testing.T) {
+	env := NewEnv()
+	for _, tt := range []struct {
+		src  string
+		want float64
+	}{
+		{"r = 2", 2},
+		{"area = pi * r^2", 4 * math.Pi},
+		{"x = y = 3", 3},
+		{"x + y", 6},
+		{"r = r + 1", 3},
+		{"double(r)", 6},
+	} {
+		if tt.src == "double(r)" {
+			env.Funcs["double"] = Func{1, func(a ...float64) float64 { return 2 * a[0] }}
+		}
+		got, err := eval(tt.src, env)
+		if err != nil || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s = %v, %v; want %v", tt.src, got, err, tt.want)
+		}
+	}
+	if _, ok := NewEnv().Funcs["double"]; ok {
+		t.Error("a function added to one Env is in the builtins of the next")
+	}
+	if _, ok := env.Lookup("pi"); !ok {
+		t.Error("pi is not readable")
+	}
+	if _, err := eval("r = nope", env); err == nil || env.Vars["r"] != 3 {
+		t.Errorf("a failed assignment left r = %v", env.Vars["r"])
+	}
+}
@@ -0,0 +1,117 @@
+package calc
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a token.
+type Kind int
+
+const (
+	EOF Kind = iota
+	Number
+	Ident
+	Operator // + - * / % ^ =
+	LParen
+	RParen
+	Comma
+)
+
+var kindNames = [...]string{EOF: "end of input", Number: "number", Ident: "name", Operator: "operator",
+	LParen: "(", RParen: ")", Comma: ","}
+
+func (k Kind) String() string { return kindNames[k] }
+
+// Token is a piece of the input: its kind, its text, and where it starts.
+type Token struct {
+	Kind  Kind
+	Text  string
+	Pos   int // byte offset in the input
+	Value float64
+}
+
+// SyntaxError reports where the input could not be read or parsed.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string { return fmt.Sprintf("syntax error at %d: %s", e.Pos, e.Msg) }
+
+// Lex splits the input into tokens, ending with an EOF token.
+func Lex(src string) ([]Token, error) {
+	var toks []Token
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r >= '0' && r <= '9' || r == '.':
+			j := scanNumber(src, i)
+			v, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, &SyntaxError{i, fmt.Sprintf("bad number %q", src[i:j])}
+			}
+			toks = append(toks, Token{Kind: Number, Text: src[i:j], Pos: i, Value: v})
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			j := i
+			for j < len(src) {
+				r, size := utf8.DecodeRuneInString(src[j:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				j += size
+			}
+			toks = append(toks, Token{Kind: Ident, Text: src[i:j], Pos: i})
+			i = j
+		default:
+			var k Kind
+			switch r {
+			case '+', '-', '*', '/', '%', '^', '=':
+				k = Operator
+			case '(':
+				k = LParen
+			case ')':
+				k = RParen
+			case ',':
+				k = Comma
+			default:
+				return nil, &SyntaxError{i, fmt.Sprintf("unexpected %q", r)}
+			}
+			toks = append(toks, Token{Kind: k, Text: src[i : i+size], Pos: i})
+			i += size
+		}
+	}
+	return append(toks, Token{Kind: EOF, Pos: len(src)}), nil
+}
+
+// scanNumber returns the end of the number starting at i: digits, a
+// fraction, and an exponent like 1.5e-3.
+func scanNumber(src string, i int) int {
+	digits := func() {
+		for i < len(src) && src[i] >= '0' && src[i] <= '9' {
+			i++
+		}
+	}
+	digits()
+	if i < len(src) && src[i] == '.' {
+		i++
+		digits()
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		// "2e" is 2 followed by the name e, only consume a real exponent.
+		if j < len(src) && src[j] >= '0' && src[j] <= '9' {
+			i = j
+			digits()
+		}
+	}
+	return i
+}
@@ -0,0 +1,181 @@
+// Package calc is a calculator language: numbers, variables, the operators
+// + - * / % ^, function calls and assignments, like
+//
+//	r = 2
+//	area = pi * r^2
+//	max(area, hypot(3, 4))
+//
+// It is built like most interpreters: Lex cuts the text into tokens, Parse
+// arranges them into a syntax tree, and Eval computes the value of the tree.
+// The tree is made of a closed set of node types processed with type
+// switches (the type assertions of 03.interface), and Walk offers the
+// visitor of go/ast for passes that only care about a few kinds of node.
+package calc
+
+import "fmt"
+
+// The parser is a Pratt parser: each binary operator has a binding power,
+// and expr(min) keeps extending its left operand with operators binding at
+// least as tightly as min. Precedence and associativity come from this table
+// alone, instead of one grammar function per precedence level.
+//
+//	=        1  right   x = y = 2 is x = (y = 2)
+//	+ -      2  left    1 - 2 - 3 is (1 - 2) - 3
+//	* / %    3  left
+//	unary -  4          -x * y is (-x) * y
+//	^        5  right   2^3^2 is 2^(3^2), and -2^2 is -(2^2)
+var infix = map[string]struct {
+	power int
+	right bool
+}{
+	"=": {1, true},
+	"+": {2, false}, "-": {2, false},
+	"*": {3, false}, "/": {3, false}, "%": {3, false},
+	"^": {5, true},
+}
+
+const unaryPower = 4
+
+type parser struct {
+	toks []Token
+	i    int
+}
+
+// Parse parses one expression.
+func Parse(src string) (Node, error) {
+	toks, err := Lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks}
+	n, err := p.expr(0)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.Kind != EOF {
+		return nil, p.unexpected(t)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() Token { return p.toks[p.i] }
+
+func (p *parser) next() Token {
+	t := p.toks[p.i]
+	if t.Kind != EOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) unexpected(t Token) error {
+	if t.Kind == EOF {
+		return &SyntaxError{t.Pos, "unexpected end of input"}
+	}
+	return &SyntaxError{t.Pos, fmt.Sprintf("unexpected %s %q", t.Kind, t.Text)}
+}
+
+func (p *parser) expect(k Kind) (Token, error) {
+	t := p.next()
+	if t.Kind != k {
+		return t, &SyntaxError{t.Pos, fmt.Sprintf("expected %s, found %s", k, describe(t))}
+	}
+	return t, nil
+}
+
+func describe(t Token) string {
+	if t.Kind == EOF {
+		return t.Kind.String()
+	}
+	return fmt.Sprintf("%q", t.Text)
+}
+
+func (p *parser) expr(min int) (Node, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		op, ok := infix[t.Text]
+		if t.Kind != Operator || !ok || op.power < min {
+			return left, nil
+		}
+		p.next()
+		// a left associative operator only takes tighter operators on its
+		// right, a right associative one takes itself too.
+		next := op.power + 1
+		if op.right {
+			next = op.power
+		}
+		right, err := p.expr(next)
+		if err != nil {
+			return nil, err
+		}
+		if t.Text == "=" {
+			v, ok := left.(*Var)
+			if !ok {
+				return nil, &SyntaxError{t.Pos, "cannot assign to " + String(left)}
+			}
+			left = &Assign{At: v.At, Name: v.Name, Value: right}
+			continue
+		}
+		left = &Binary{At: t.Pos, Op: t.Text[0], L: left, R: right}
+	}
+}
+
+// operand parses what can start an expression: a number, a name, a call, a
+// parenthesized expression, or a sign.
+func (p *parser) operand() (Node, error) {
+	t := p.next()
+	switch t.Kind {
+	case Number:
+		return &Num{At: t.Pos, Value: t.Value}, nil
+	case Ident:
+		if p.peek().Kind == LParen {
+			return p.call(t)
+		}
+		return &Var{At: t.Pos, Name: t.Text}, nil
+	case LParen:
+		n, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(RParen); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case Operator:
+		if t.Text == "-" || t.Text == "+" {
+			x, err := p.expr(unaryPower)
+			if err != nil {
+				return nil, err
+			}
+			return &Unary{At: t.Pos, Op: t.Text[0], X: x}, nil
+		}
+	}
+	return nil, p.unexpected(t)
+}
+
+func (p *parser) call(name Token) (Node, error) {
+	p.next() // (
+	c := &Call{At: name.Pos, Fn: name.Text}
+	if p.peek().Kind == RParen {
+		p.next()
+		return c, nil
+	}
+	for {
+		arg, err := p.expr(0)
+		if err != nil {
+			return nil, err
+		}
+		c.Args = append(c.Args, arg)
+		t, err := p.expect(RParen)
+		if err == nil {
+			return c, nil
+		}
+		if t.Kind != Comma {
+			return nil, &SyntaxError{t.Pos, fmt.Sprintf("expected , or ) in call to %s, found %s", c.Fn, describe(t))}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package calc
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	for _, tt := range []struct {
+		src  string
+		want []Token
+	}{
+		{"", nil},
+		{"1.5e-3", []Token{{Kind: Number, Text: "1.5e-3", Value: 0.0015}}},
+		{".5", []Token{{Kind: Number, Text: ".5", Value: 0.5}}},
+		// 2e is the number 2 and the name e, not an exponent.
+		{"2e", []Token{{Kind: Number, Text: "2", Value: 2}, {Kind: Ident, Text: "e", Pos: 1}}},
+		{"x_1 = max(a,2)", []Token{
+			{Kind: Ident, Text: "x_1"}, {Kind: Operator, Text: "=", Pos: 4}, {Kind: Ident, Text: "max", Pos: 6},
+			{Kind: LParen, Text: "(", Pos: 9}, {Kind: Ident, Text: "a", Pos: 10}, {Kind: Comma, Text: ",", Pos: 11},
+			{Kind: Number, Text: "2", Pos: 12, Value: 2}, {Kind: RParen, Text: ")", Pos: 13},
+		}},
+		// positions are byte offsets, names may be any letters.
+		{"π\t+ 1", []Token{{Kind: Ident, Text: "π"}, {Kind: Operator, Text: "+", Pos: 3}, {Kind: Number, Text: "1", Pos: 5, Value: 1}}},
+	} {
+		got, err := Lex(tt.src)
+		if err != nil {
+			t.Errorf("Lex(%q): %v", tt.src, err)
+			continue
+		}
+		want := append(tt.want, Token{Kind: EOF, Pos: len(tt.src)})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Lex(%q) =\n%v\nwant\n%v", tt.src, got, want)
+		}
+	}
+}
+
+// TestParse prints the trees fully parenthesized: precedence and
+// associativity are in the parentheses.
+func TestParse(t *testing.T) {
+	for _, tt := range []struct{ src, want string }{
+		{"1 + 2 * 3", "(1 + (2 * 3))"},
+		{"(1 + 2) * 3", "((1 + 2) * 3)"},
+		{"1 - 2 - 3", "((1 - 2) - 3)"},
+		{"8 / 4 / 2", "((8 / 4) / 2)"},
+		{"7 % 4 * 2", "((7 % 4) * 2)"},
+		{"2 ^ 3 ^ 2", "(2 ^ (3 ^ 2))"},
+		{"-2 ^ 2", "(-(2 ^ 2))"},
+		{"-x * y", "((-x) * y)"},
+		{"--x", "(-(-x))"},
+		{"+x - -1", "((+x) - (-1))"},
+		{"x = y = 2", "x = y = 2"},
+		{"x = 1 + 2", "x = (1 + 2)"},
+		{"max()", "max()"},
+		{"max(1, 2 * x, f(y))", "max(1, (2 * x), f(y))"},
+		{"pi * r^2", "(pi * (r ^ 2))"},
+		{"((1))", "1"},
+	} {
+		n, err := Parse(tt.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.src, err)
+			continue
+		}
+		if got := String(n); got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tt := range []struct {
+		src string
+		pos int
+		msg string
+	}{
+		{"", 0, "unexpected end of input"},
+		{"1 +", 3, "unexpected end of input"},
+		{"1 2", 2, `unexpected number "2"`},
+		{"(1 + 2", 6, "expected ), found end of input"},
+		{"1 + )", 4, `unexpected ) ")"`},
+		{"* 2", 0, `unexpected operator "*"`},
+		{"max(1 2)", 6, `expected , or ) in call to max, found "2"`},
+		{"max(1,)", 6, `unexpected ) ")"`},
+		{"1 = 2", 2, "cannot assign to 1"},
+		{"x + 1 = 2", 6, "cannot assign to (x + 1)"},
+		{"1.2.3", 3, `unexpected number ".3"`},
+		{"1 + .", 4, `bad number "."`},
+		{"1e5e", 3, ""}, // the name e after 1e5: two operands in a row
+		{"2 $ 3", 2, `unexpected '$'`},
+	} {
+		_, err := Parse(tt.src)
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Parse(%q) = %v, want a SyntaxError", tt.src, err)
+			continue
+		}
+		if se.Pos != tt.pos || !strings.Contains(se.Msg, tt.msg) {
+			t.Errorf("Parse(%q): at %d %q, want at %d %q", tt.src, se.Pos, se.Msg, tt.pos, tt.msg)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	n, err := Parse("a = max(b, sqrt(b * c), a) + min(pi)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vars, funcs := Names(n)
+	if !reflect.DeepEqual(vars, []string{"a", "b", "c", "pi"}) || !reflect.DeepEqual(funcs, []string{"max", "min", "sqrt"}) {
+		t.Errorf("Names = %v, %v", vars, funcs)
+	}
+
+	// Inspect skips the children of a node for which f returns false.
+	var seen []string
+	Inspect(n, func(n Node) bool {
+		if c, ok := n.(*Call); ok {
+			seen = append(seen, c.Fn)
+			return c.Fn != "max"
+		}
+		return true
+	})
+	if !reflect.DeepEqual(seen, []string{"max", "min"}) {
+		t.Errorf("calls visited = %v, want max and min without sqrt", seen)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/calc"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/calcvm"
+)
+
+/*
+calcvm compiles an expression of package calc and prints its bytecode, or
+checks the machine against the tree-walking evaluator and benchmarks both.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/calcvm/cmd/calcvm 'y = 2*pi*r + sqrt(r^2 + 1)'
+	go run ./golang_program_design_2024/09.projects/calcvm/cmd/calcvm -demo
+
+-demo runs a few expressions through calc.Eval and the machine and prints
+both results side by side, then benchmarks the two on the same formula. Its
+cases are tests, random expressions included:
+go test ./golang_program_design_2024/09.projects/calcvm
+*/
+
+func main() {
+	demo := flag.Bool("demo", false, "compare with calc.Eval and run the benchmark")
+	flag.Parse()
+	if *demo {
+		runDemo()
+		return
+	}
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: calcvm 'expression' | calcvm -demo")
+		os.Exit(2)
+	}
+	n, err := calc.Parse(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	p, err := calcvm.Compile(n, calc.NewEnv())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(calc.String(n))
+	fmt.Print(p)
+	fmt.Println(p.Stats())
+}
+
+// fixed cases, run in one environment each way so assignments carry over.
+var fixed = []string{
+	"1 + 2 * 3",
+	"x = 3",
+	"y = x * 2 + 1",
+	"a = b = x ^ 2",
+	"-x ^ 2 + -(-y)",
+	"2 * pi * x",
+	"max(1, y, a, 3) - min(4, x) % 2",
+	"hypot(x, 4) + sqrt(b) + floor(7 / 2)",
+	"x / (y - 7)",
+	"undefined_var + 1",
+	"nope(1)",
+	"sqrt(1, 2)",
+	"pi = 3",
+	"z = 1 + (w = 2) * w",
+	"z + w",
+}
+
+func runDemo() {
+	fmt.Println("== bytecode")
+	src := "area = pi * r^2 + 2 * pi * r * h"
+	n, _ := calc.Parse(src)
+	p, _ := calcvm.Compile(n, calc.NewEnv())
+	fmt.Printf("%s\n%s%s\n\n", src, p, p.Stats())
+
+	fmt.Println("== calc.Eval and the machine")
+	treeEnv, vmEnv := calc.NewEnv(), calc.NewEnv()
+	for _, src := range fixed {
+		want, werr := eval(src, treeEnv)
+		got, gerr := runVM(src, vmEnv)
+		fmt.Printf("%s\n  eval %s\n  vm   %s\n", src, result(want, werr), result(got, gerr))
+	}
+	fmt.Printf("variables: %v, %v\n", treeEnv.Vars, vmEnv.Vars)
+
+	fmt.Println("\n== tree walking vs bytecode")
+	formula := "x^2 + 3*x*sin(x) - sqrt(x + 1) / (2 + max(x, 1)) + 2*pi*x"
+	fmt.Println(formula)
+	n, _ = calc.Parse(formula)
+	env := calc.NewEnv()
+	p, _ = calcvm.Compile(n, env)
+	m := calcvm.NewMachine(p)
+	slot := m.Slot("x")
+
+	tree := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			env.Vars["x"] = float64(i % 100)
+			calc.Eval(n, env)
+		}
+	})
+	vm := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			m.SetSlot(slot, float64(i%100))
+			m.Run()
+		}
+	})
+	compile := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			calcvm.Compile(n, env)
+		}
+	})
+	fmt.Printf("  %-8s %s\n  %-8s %s\n  %-8s %s\n", "eval", tree, "vm", vm, "compile", compile)
+	fmt.Printf("  the machine runs %.1fx faster\n", float64(tree.NsPerOp())/float64(vm.NsPerOp()))
+	// compiling pays off after this many runs.
+	if saved := tree.NsPerOp() - vm.NsPerOp(); saved > 0 {
+		fmt.Printf("  compiling is worth it from about %.0f runs\n", math.Ceil(float64(compile.NsPerOp())/float64(saved)))
+	}
+}
+
+func eval(src string, env *calc.Env) (float64, error) {
+	n, err := calc.Parse(src)
+	if err != nil {
+		return 0, err
+	}
+	return calc.Eval(n, env)
+}
+
+func runVM(src string, env *calc.Env) (float64, error) {
+	n, err := calc.Parse(src)
+	if err != nil {
+		return 0, err
+	}
+	p, err := calcvm.Compile(n, env)
+	if err != nil {
+		return 0, err
+	}
+	return calcvm.Run(p, env)
+}
+
+func result(v float64, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("= %g", v)
+}
@@ -0,0 +1,257 @@
+// Package calcvm compiles the syntax trees of package calc to bytecode for a
+// stack machine, a second way to run them next to calc.Eval.
+//
+// Eval walks the tree on every run: an interface type switch per node, a
+// recursive call per child, a map lookup per variable. Compiling does that
+// work once. The tree becomes a flat list of instructions, variable names
+// become slots of an array, function names become indexes, and constant
+// subtrees like 2*pi are folded into one number. Running is then a loop over
+// the instructions pushing and popping a preallocated stack:
+//
+//	x^2 + 1   =>   load x; const 2; pow; const 1; add
+package calcvm
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/calc"
+)
+
+// Opcode is an instruction of the machine.
+type Opcode uint8
+
+const (
+	OpConst Opcode = iota // push Consts[A]
+	OpLoad                // push the variable of slot A
+	OpStore               // set slot A to the top of the stack, which stays
+	OpNeg
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpPow
+	OpCall // call Funcs[A] with the top B values, push the result
+)
+
+var opNames = [...]string{"const", "load", "store", "neg", "add", "sub", "mul", "div", "mod", "pow", "call"}
+
+func (op Opcode) String() string { return opNames[op] }
+
+var binaryOps = map[byte]Opcode{'+': OpAdd, '-': OpSub, '*': OpMul, '/': OpDiv, '%': OpMod, '^': OpPow}
+
+// Instr is one instruction with its operands.
+type Instr struct {
+	Op   Opcode
+	A, B int32
+}
+
+// Program is a compiled expression.
+type Program struct {
+	Code   []Instr
+	Pos    []int // source position of each instruction, for errors
+	Consts []float64
+	Vars   []string // the name of each slot
+	Funcs  []calc.Func
+	fnames []string
+	// MaxStack is the deepest the stack gets, computed by the compiler so
+	// the machine allocates it once.
+	MaxStack int
+}
+
+type compiler struct {
+	p      *Program
+	env    *calc.Env
+	slots  map[string]int
+	funcs  map[string]int
+	consts map[uint64]int // by bits: 0 and -0 are different constants
+	depth  int
+}
+
+// Compile compiles n. Functions are resolved in env now: an unknown one or
+// a wrong number of arguments is a compile error, not a run time one.
+func Compile(n calc.Node, env *calc.Env) (*Program, error) {
+	c := &compiler{
+		p:      &Program{},
+		env:    env,
+		slots:  make(map[string]int),
+		funcs:  make(map[string]int),
+		consts: make(map[uint64]int),
+	}
+	if err := c.compile(fold(n)); err != nil {
+		return nil, err
+	}
+	return c.p, nil
+}
+
+func (c *compiler) emit(op Opcode, a, b int, pos int) {
+	c.p.Code = append(c.p.Code, Instr{op, int32(a), int32(b)})
+	c.p.Pos = append(c.p.Pos, pos)
+	// how the instruction changes the depth of the stack.
+	switch op {
+	case OpConst, OpLoad:
+		c.depth++
+	case OpAdd, OpSub, OpMul, OpDiv, OpMod, OpPow:
+		c.depth--
+	case OpCall:
+		c.depth -= b - 1
+	}
+	c.p.MaxStack = max(c.p.MaxStack, c.depth)
+}
+
+func (c *compiler) slot(name string) int {
+	i, ok := c.slots[name]
+	if !ok {
+		i = len(c.p.Vars)
+		c.slots[name] = i
+		c.p.Vars = append(c.p.Vars, name)
+	}
+	return i
+}
+
+func (c *compiler) constant(v float64) int {
+	i, ok := c.consts[math.Float64bits(v)]
+	if !ok {
+		i = len(c.p.Consts)
+		c.consts[math.Float64bits(v)] = i
+		c.p.Consts = append(c.p.Consts, v)
+	}
+	return i
+}
+
+// compile emits the code of n: code leaving the value of n on the stack.
+func (c *compiler) compile(n calc.Node) error {
+	switch n := n.(type) {
+	case *calc.Num:
+		c.emit(OpConst, c.constant(n.Value), 0, n.At)
+	case *calc.Var:
+		// constants cannot change, they are compiled as numbers.
+		if v, ok := calc.Constants[n.Name]; ok {
+			c.emit(OpConst, c.constant(v), 0, n.At)
+		} else {
+			c.emit(OpLoad, c.slot(n.Name), 0, n.At)
+		}
+	case *calc.Unary:
+		if err := c.compile(n.X); err != nil {
+			return err
+		}
+		if n.Op == '-' {
+			c.emit(OpNeg, 0, 0, n.At)
+		}
+	case *calc.Binary:
+		// operands first, the operator finds them on the top of the stack.
+		if err := c.compile(n.L); err != nil {
+			return err
+		}
+		if err := c.compile(n.R); err != nil {
+			return err
+		}
+		c.emit(binaryOps[n.Op], 0, 0, n.At)
+	case *calc.Call:
+		f, ok := c.env.Funcs[n.Fn]
+		if !ok {
+			return &calc.EvalError{Pos: n.At, Err: calc.ErrUnknownFn, Name: n.Fn}
+		}
+		if f.Arity >= 0 && len(n.Args) != f.Arity || f.Arity < 0 && len(n.Args) == 0 {
+			return &calc.EvalError{Pos: n.At, Err: fmt.Errorf("%w: %s got %d", calc.ErrArity, n.Fn, len(n.Args))}
+		}
+		for _, a := range n.Args {
+			if err := c.compile(a); err != nil {
+				return err
+			}
+		}
+		i, ok := c.funcs[n.Fn]
+		if !ok {
+			i = len(c.p.Funcs)
+			c.funcs[n.Fn] = i
+			c.p.Funcs = append(c.p.Funcs, f)
+			c.p.fnames = append(c.p.fnames, n.Fn)
+		}
+		c.emit(OpCall, i, len(n.Args), n.At)
+	case *calc.Assign:
+		if _, ok := calc.Constants[n.Name]; ok {
+			return &calc.EvalError{Pos: n.At, Err: calc.ErrConstant, Name: n.Name}
+		}
+		if err := c.compile(n.Value); err != nil {
+			return err
+		}
+		c.emit(OpStore, c.slot(n.Name), 0, n.At)
+	default:
+		panic(fmt.Sprintf("calcvm: unknown node %T", n))
+	}
+	return nil
+}
+
+// fold replaces the operations on constants by their result, bottom up:
+// 2 * pi * r becomes 6.283185307179586 * r. An operation failing, like 1/0,
+// is left in place to fail at run time with its position.
+func fold(n calc.Node) calc.Node {
+	num := func(n calc.Node) (float64, bool) {
+		switch n := n.(type) {
+		case *calc.Num:
+			return n.Value, true
+		case *calc.Var:
+			v, ok := calc.Constants[n.Name]
+			return v, ok
+		}
+		return 0, false
+	}
+	switch n := n.(type) {
+	case *calc.Unary:
+		x := fold(n.X)
+		if v, ok := num(x); ok {
+			if n.Op == '-' {
+				v = -v
+			}
+			return &calc.Num{At: n.At, Value: v}
+		}
+		return &calc.Unary{At: n.At, Op: n.Op, X: x}
+	case *calc.Binary:
+		l, r := fold(n.L), fold(n.R)
+		lv, lok := num(l)
+		rv, rok := num(r)
+		if lok && rok {
+			if v, err := calc.Apply(n.Op, lv, rv); err == nil {
+				return &calc.Num{At: n.At, Value: v}
+			}
+		}
+		return &calc.Binary{At: n.At, Op: n.Op, L: l, R: r}
+	case *calc.Call:
+		args := make([]calc.Node, len(n.Args))
+		for i, a := range n.Args {
+			args[i] = fold(a)
+		}
+		return &calc.Call{At: n.At, Fn: n.Fn, Args: args}
+	case *calc.Assign:
+		return &calc.Assign{At: n.At, Name: n.Name, Value: fold(n.Value)}
+	}
+	return n
+}
+
+// String disassembles the program, one instruction per line.
+func (p *Program) String() string {
+	var b strings.Builder
+	for pc, in := range p.Code {
+		fmt.Fprintf(&b, "%3d  %-6s", pc, in.Op)
+		switch in.Op {
+		case OpConst:
+			fmt.Fprintf(&b, "%g", p.Consts[in.A])
+		case OpLoad, OpStore:
+			fmt.Fprintf(&b, "%s", p.Vars[in.A])
+		case OpCall:
+			fmt.Fprintf(&b, "%s/%d", p.fnames[in.A], in.B)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Stats summarizes the program: instructions, stack depth, slots.
+func (p *Program) Stats() string {
+	vars := append([]string(nil), p.Vars...)
+	sort.Strings(vars)
+	return fmt.Sprintf("%d instructions, stack %d, %d constants, vars %v", len(p.Code), p.MaxStack, len(p.Consts), vars)
+}
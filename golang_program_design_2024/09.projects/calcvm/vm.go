@@ -0,0 +1,132 @@
+package calcvm
+
+import (
+	"math"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/calc"
+)
+
+// Machine runs a program. It can run it many times with different variable
+// values without allocating: the stack and the slots are allocated once.
+// A Machine is not safe for concurrent use, use one per goroutine.
+type Machine struct {
+	p     *Program
+	stack []float64
+	slots []float64
+	set   []bool // slots holding a value, reading another one is an error
+}
+
+func NewMachine(p *Program) *Machine {
+	return &Machine{
+		p:     p,
+		stack: make([]float64, p.MaxStack),
+		slots: make([]float64, len(p.Vars)),
+		set:   make([]bool, len(p.Vars)),
+	}
+}
+
+// Set gives a value to a variable. It reports false if the program does not
+// use the variable.
+func (m *Machine) Set(name string, v float64) bool {
+	for i, n := range m.p.Vars {
+		if n == name {
+			m.slots[i], m.set[i] = v, true
+			return true
+		}
+	}
+	return false
+}
+
+// Slot returns the slot of a variable, for SetSlot, or -1.
+func (m *Machine) Slot(name string) int {
+	for i, n := range m.p.Vars {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// SetSlot is Set without the search by name, for a hot loop.
+func (m *Machine) SetSlot(slot int, v float64) { m.slots[slot], m.set[slot] = v, true }
+
+// Get returns the value of a variable after a run.
+func (m *Machine) Get(name string) (float64, bool) {
+	if i := m.Slot(name); i >= 0 && m.set[i] {
+		return m.slots[i], true
+	}
+	return 0, false
+}
+
+// Run executes the program and returns the value it leaves on the stack.
+func (m *Machine) Run() (float64, error) {
+	code, consts, funcs := m.p.Code, m.p.Consts, m.p.Funcs
+	stack := m.stack
+	sp := 0 // the next free position of the stack
+	for pc, in := range code {
+		switch in.Op {
+		case OpConst:
+			stack[sp] = consts[in.A]
+			sp++
+		case OpLoad:
+			if !m.set[in.A] {
+				return 0, &calc.EvalError{Pos: m.p.Pos[pc], Err: calc.ErrUndefined, Name: m.p.Vars[in.A]}
+			}
+			stack[sp] = m.slots[in.A]
+			sp++
+		case OpStore:
+			m.slots[in.A], m.set[in.A] = stack[sp-1], true
+		case OpNeg:
+			stack[sp-1] = -stack[sp-1]
+		case OpAdd:
+			sp--
+			stack[sp-1] += stack[sp]
+		case OpSub:
+			sp--
+			stack[sp-1] -= stack[sp]
+		case OpMul:
+			sp--
+			stack[sp-1] *= stack[sp]
+		case OpDiv:
+			sp--
+			if stack[sp] == 0 {
+				return 0, &calc.EvalError{Pos: m.p.Pos[pc], Err: calc.ErrDivByZero}
+			}
+			stack[sp-1] /= stack[sp]
+		case OpMod:
+			sp--
+			if stack[sp] == 0 {
+				return 0, &calc.EvalError{Pos: m.p.Pos[pc], Err: calc.ErrDivByZero}
+			}
+			stack[sp-1] = math.Mod(stack[sp-1], stack[sp])
+		case OpPow:
+			sp--
+			stack[sp-1] = math.Pow(stack[sp-1], stack[sp])
+		case OpCall:
+			// the arguments are the top B values, in order: they are passed
+			// as a slice of the stack, without copying.
+			base := sp - int(in.B)
+			stack[base] = funcs[in.A].Fn(stack[base:sp]...)
+			sp = base + 1
+		}
+	}
+	return stack[0], nil
+}
+
+// Run executes p once with the variables of env, and writes the variables
+// the program assigned back to env, as calc.Eval does.
+func Run(p *Program, env *calc.Env) (float64, error) {
+	m := NewMachine(p)
+	for i, name := range p.Vars {
+		if v, ok := env.Vars[name]; ok {
+			m.slots[i], m.set[i] = v, true
+		}
+	}
+	v, err := m.Run()
+	for _, in := range p.Code {
+		if in.Op == OpStore && m.set[in.A] {
+			env.Vars[p.Vars[in.A]] = m.slots[in.A]
+		}
+	}
+	return v, err
+}
@@ -0,0 +1,255 @@
+package calcvm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/calc"
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+func eval(src string, env *calc.Env) (float64, error) {
+	n, err := calc.Parse(src)
+	if err != nil {
+		return 0, err
+	}
+	return calc.Eval(n, env)
+}
+
+func runVM(src string, env *calc.Env) (float64, error) {
+	n, err := calc.Parse(src)
+	if err != nil {
+		return 0, err
+	}
+	p, err := Compile(n, env)
+	if err != nil {
+		return 0, err
+	}
+	return Run(p, env)
+}
+
+// agree compares two results: the same bits (0 and -0 differ), both NaN, or
+// errors of the same kind.
+func agree(want float64, werr error, got float64, gerr error) bool {
+	if werr != nil || gerr != nil {
+		for _, kind := range []error{calc.ErrDivByZero, calc.ErrUndefined, calc.ErrUnknownFn, calc.ErrArity, calc.ErrConstant} {
+			if errors.Is(werr, kind) {
+				return errors.Is(gerr, kind)
+			}
+		}
+		return false
+	}
+	return math.Float64bits(want) == math.Float64bits(got) || math.IsNaN(want) && math.IsNaN(got)
+}
+
+// TestSameAsEval runs lines through calc.Eval and the machine, in one
+// environment each way so assignments carry over.
+func TestSameAsEval(t *testing.T) {
+	treeEnv, vmEnv := calc.NewEnv(), calc.NewEnv()
+	for _, src := range []string{
+		"1 + 2 * 3",
+		"x = 3",
+		"y = x * 2 + 1",
+		"a = b = x ^ 2",
+		"-x ^ 2 + -(-y)",
+		"2 * pi * x",
+		"max(1, y, a, 3) - min(4, x) % 2",
+		"hypot(x, 4) + sqrt(b) + floor(7 / 2)",
+		"-0 * 1",
+		"x / (y - 7)",
+		"1 / 0",
+		"undefined_var + 1",
+		"nope(1)",
+		"sqrt(1, 2)",
+		"pi = 3",
+		"z = 1 + (w = 2) * w",
+		"z + w",
+	} {
+		want, werr := eval(src, treeEnv)
+		got, gerr := runVM(src, vmEnv)
+		if !agree(want, werr, got, gerr) {
+			t.Errorf("%s: eval %v, %v; vm %v, %v", src, want, werr, got, gerr)
+		}
+	}
+	if fmt.Sprint(treeEnv.Vars) != fmt.Sprint(vmEnv.Vars) {
+		t.Errorf("variables: eval %v, vm %v", treeEnv.Vars, vmEnv.Vars)
+	}
+}
+
+// randomTree builds an expression over x, y, small numbers, pi, every
+// operator and a few functions.
+func randomTree(r *rand.Rand, depth int) calc.Node {
+	if depth == 0 || r.Intn(4) == 0 {
+		switch r.Intn(4) {
+		case 0:
+			return &calc.Var{Name: "x"}
+		case 1:
+			return &calc.Var{Name: "y"}
+		case 2:
+			return &calc.Var{Name: "pi"}
+		}
+		return &calc.Num{Value: float64(r.Intn(5))}
+	}
+	switch r.Intn(6) {
+	case 0:
+		return &calc.Unary{Op: '-', X: randomTree(r, depth-1)}
+	case 1:
+		fns := []string{"sqrt", "abs", "sin", "floor"}
+		return &calc.Call{Fn: fns[r.Intn(len(fns))], Args: []calc.Node{randomTree(r, depth-1)}}
+	case 2:
+		fn := []string{"min", "max", "hypot"}[r.Intn(3)]
+		return &calc.Call{Fn: fn, Args: []calc.Node{randomTree(r, depth-1), randomTree(r, depth-1)}}
+	}
+	ops := "+-*/%^"
+	return &calc.Binary{Op: ops[r.Intn(len(ops))], L: randomTree(r, depth-1), R: randomTree(r, depth-1)}
+}
+
+// TestRandom stresses what a list of cases forgets: deep nesting, negative
+// zero, NaN, every operator next to every other.
+func TestRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	trials := 2000
+	if testing.Short() {
+		trials = 200
+	}
+	for i := 0; i < trials; i++ {
+		src := calc.String(randomTree(r, 5))
+		env1, env2 := calc.NewEnv(), calc.NewEnv()
+		env1.Vars["x"], env2.Vars["x"] = 1.5, 1.5
+		env1.Vars["y"], env2.Vars["y"] = -2, -2
+		want, werr := eval(src, env1)
+		got, gerr := runVM(src, env2)
+		if !agree(want, werr, got, gerr) {
+			t.Fatalf("%s: eval %v, %v; vm %v, %v", src, want, werr, got, gerr)
+		}
+	}
+}
+
+func compile(t *testing.T, src string) *Program {
+	t.Helper()
+	n, err := calc.Parse(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Compile(n, calc.NewEnv())
+	if err != nil {
+		t.Fatalf("%s: %v", src, err)
+	}
+	return p
+}
+
+func TestCompile(t *testing.T) {
+	for _, tt := range []struct {
+		src, code string
+		stack     int
+	}{
+		{"x^2 + 1", "  0  load  x\n  1  const 2\n  2  pow   \n  3  const 1\n  4  add   \n", 2},
+		// constant subtrees are folded, the rest is left in order.
+		{"y = 2 * pi * r", "  0  const 6.283185307179586\n  1  load  r\n  2  mul   \n  3  store y\n", 2},
+		{"-(3 - 1) * x", "  0  const -2\n  1  load  x\n  2  mul   \n", 2},
+		// a failing fold stays, to fail at run time with its position.
+		{"1 / 0", "  0  const 1\n  1  const 0\n  2  div   \n", 2},
+		{"max(a, b, c)", "  0  load  a\n  1  load  b\n  2  load  c\n  3  call  max/3\n", 3},
+	} {
+		p := compile(t, tt.src)
+		if p.String() != tt.code || p.MaxStack != tt.stack {
+			t.Errorf("%s: stack %d, code\n%s\nwant stack %d, code\n%s", tt.src, p.MaxStack, p, tt.stack, tt.code)
+		}
+	}
+	if p := compile(t, "x * x + x"); len(p.Vars) != 1 {
+		t.Errorf("x in %d slots, want 1", len(p.Vars))
+	}
+
+	// functions are resolved at compile time.
+	for src, want := range map[string]error{"nope(1)": calc.ErrUnknownFn, "sqrt(1, 2)": calc.ErrArity} {
+		n, _ := calc.Parse(src)
+		if _, err := Compile(n, calc.NewEnv()); !errors.Is(err, want) {
+			t.Errorf("Compile(%s) = %v, want %v", src, err, want)
+		}
+	}
+}
+
+func TestMachine(t *testing.T) {
+	p := compile(t, "area = pi * r^2")
+	m := NewMachine(p)
+	if _, err := m.Run(); !errors.Is(err, calc.ErrUndefined) {
+		t.Errorf("a run without r = %v, want ErrUndefined", err)
+	}
+	if m.Set("h", 1) || m.Slot("h") != -1 {
+		t.Error("h is not used by the program, yet Set or Slot found it")
+	}
+	if !m.Set("r", 2) {
+		t.Fatal("Set(r) = false")
+	}
+	if v, err := m.Run(); err != nil || v != 4*math.Pi {
+		t.Errorf("Run = %v, %v; want 4 pi", v, err)
+	}
+	if v, ok := m.Get("area"); !ok || v != 4*math.Pi {
+		t.Errorf("area = %v, %v after the run", v, ok)
+	}
+
+	// runs after the first one do not allocate.
+	slot := m.Slot("r")
+	allocs := testing.AllocsPerRun(100, func() {
+		m.SetSlot(slot, 3)
+		m.Run()
+	})
+	if allocs != 0 {
+		t.Errorf("%v allocations per run", allocs)
+	}
+}
+
+const formula = "x^2 + 3*x*sin(x) - sqrt(x + 1) / (2 + max(x, 1)) + 2*pi*x"
+
+// formulas is the size of the expressions benchmarked: n times formula.
+var formulas = []int{1, 16}
+
+// parse parses formula repeated n times, added up.
+func parse(b *testing.B, n int) calc.Node {
+	b.Helper()
+	node, err := calc.Parse(strings.Repeat(formula+" + ", n-1) + formula)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return node
+}
+
+func BenchmarkEval(b *testing.B) {
+	benchtools.Sized(b, formulas, func(b *testing.B, n int) {
+		node := parse(b, n)
+		env := calc.NewEnv()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			env.Vars["x"] = float64(i % 100)
+			calc.Eval(node, env)
+		}
+	})
+}
+
+func BenchmarkRun(b *testing.B) {
+	benchtools.Sized(b, formulas, func(b *testing.B, n int) {
+		p, _ := Compile(parse(b, n), calc.NewEnv())
+		m := NewMachine(p)
+		slot := m.Slot("x")
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			m.SetSlot(slot, float64(i%100))
+			m.Run()
+		}
+	})
+}
+
+func BenchmarkCompile(b *testing.B) {
+	benchtools.Sized(b, formulas, func(b *testing.B, n int) {
+		node := parse(b, n)
+		env := calc.NewEnv()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			Compile(node, env)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+// Package chatbot is a chat bot served as a webhook, the way chat platforms
+// talk to bots: the platform POSTs each message of a channel to the bot's
+// URL, and the bot answers later by POSTing to a reply URL.
+//
+// A request goes through, in order:
+//
+//   - the signature: an HMAC of the body with a secret shared with the
+//     platform, and a timestamp bounding replays;
+//   - the dedupe: platforms resend a message they think was lost, the id of
+//     each message accepted within the tolerance is remembered;
+//   - the registry: "/roll 2d6" runs the Handler registered as roll;
+//   - the outbox: the reply is queued and the webhook answers 202 at once,
+//     workers post the replies, retrying the failures.
+package chatbot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Bot is the http.Handler of the webhook.
+type Bot struct {
+	Secret []byte
+	// Tolerance is how far the timestamp of a request can be from now.
+	Tolerance time.Duration
+	// Timeout bounds a handler.
+	Timeout  time.Duration
+	Registry *Registry
+	Outbox   *Outbox
+	Log      *slog.Logger
+	Now      func() time.Time // time.Now if nil, for the demo
+
+	mu   sync.Mutex
+	seen map[string]time.Time // message id to when it was accepted
+}
+
+// New returns a bot with a tolerance of 5 minutes and handlers bounded to
+// 5 seconds.
+func New(secret []byte, reg *Registry, out *Outbox, log *slog.Logger) *Bot {
+	return &Bot{
+		Secret:    secret,
+		Tolerance: 5 * time.Minute,
+		Timeout:   5 * time.Second,
+		Registry:  reg,
+		Outbox:    out,
+		Log:       log,
+		seen:      make(map[string]time.Time),
+	}
+}
+
+const maxBody = 64 << 10
+
+func (b *Bot) now() time.Time {
+	if b.Now != nil {
+		return b.Now()
+	}
+	return time.Now()
+}
+
+func (b *Bot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "bad body", http.StatusBadRequest)
+		return
+	}
+	// nothing is parsed before the signature is checked: an unsigned body
+	// is not even worth decoding.
+	if err := Verify(b.Secret, r.Header, body, b.now(), b.Tolerance); err != nil {
+		b.Log.Warn("rejected webhook", "remote", r.RemoteAddr, "err", err)
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+	var msg Message
+	if err := json.Unmarshal(body, &msg); err != nil || msg.ID == "" || msg.ReplyURL == "" {
+		http.Error(w, "bad message", http.StatusBadRequest)
+		return
+	}
+	if b.isDuplicate(msg.ID) {
+		// 200, not an error: the platform must stop resending it.
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	cmd := b.Registry.Parse(&msg)
+	if cmd == nil {
+		b.accept(msg.ID)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), b.Timeout)
+	defer cancel()
+	text, err := b.Registry.Dispatch(ctx, cmd)
+	if err != nil {
+		b.Log.Error("command failed", "id", msg.ID, "command", cmd.Name, "err", err)
+	}
+	if text != "" {
+		reply := Reply{URL: msg.ReplyURL, Channel: msg.Channel, Text: text, InReplyTo: msg.ID}
+		if err := b.Outbox.Enqueue(reply); err != nil {
+			// not accepted: the resent message must run again.
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+	}
+	b.accept(msg.ID)
+	b.Log.Info("command", "id", msg.ID, "user", msg.User, "command", cmd.Name)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (b *Bot) isDuplicate(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.seen[id]
+	return ok
+}
+
+// accept remembers id. An id only has to be remembered for the tolerance:
+// the message resent after that has a stale timestamp, the signature check
+// rejects it. Forgetting the old ones bounds the map.
+func (b *Bot) accept(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	for old, at := range b.seen {
+		if now.Sub(at) > b.Tolerance {
+			delete(b.seen, old)
+		}
+	}
+	b.seen[id] = now
+}
+
+// Remembered returns the number of message ids remembered for the dedupe.
+func (b *Bot) Remembered() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.seen)
+}
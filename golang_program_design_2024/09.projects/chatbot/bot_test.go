@@ -0,0 +1,337 @@
+package chatbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	secret = []byte("s3cret")
+	t0     = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func discard() *slog.Logger { return slog.New(slog.NewTextHandler(io.Discard, nil)) }
+
+// platform is the chat platform: it receives the replies of the bot, and
+// answers them with the statuses of fail first, then 200.
+type platform struct {
+	*httptest.Server
+	mu      sync.Mutex
+	replies []Reply
+	fail    []int
+	got     chan Reply
+}
+
+func newPlatform(t *testing.T, fail ...int) *platform {
+	t.Helper()
+	p := &platform{fail: fail, got: make(chan Reply, 16)}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := Verify(secret, r.Header, body, time.Now(), time.Minute); err != nil {
+			t.Errorf("a reply with a bad signature: %v", err)
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if len(p.fail) > 0 {
+			status := p.fail[0]
+			p.fail = p.fail[1:]
+			w.WriteHeader(status)
+			return
+		}
+		var reply Reply
+		json.Unmarshal(body, &reply)
+		p.replies = append(p.replies, reply)
+		p.got <- reply
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+// next waits for the next reply the platform accepts.
+func (p *platform) next(t *testing.T) Reply {
+	t.Helper()
+	select {
+	case r := <-p.got:
+		return r
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reply")
+		return Reply{}
+	}
+}
+
+// newBot returns a bot at t0 with the handlers of the demo and one that
+// fails, replying through an outbox retrying without a pause.
+func newBot(t *testing.T) *Bot {
+	t.Helper()
+	reg := NewRegistry()
+	reg.Register("echo", "repeat the arguments", Echo())
+	reg.Register("roll", "roll dice, like 2d6", Roll(1))
+	reg.Register("broken", "always fails", HandlerFunc(func(context.Context, *Command) (string, error) {
+		return "", errors.New("database down")
+	}))
+	reg.Register("quiet", "replies nothing", HandlerFunc(func(context.Context, *Command) (string, error) {
+		return "", nil
+	}))
+	out := NewOutbox(16, 2, secret, discard())
+	out.Backoff = time.Millisecond
+	t.Cleanup(out.Close)
+	b := New(secret, reg, out, discard())
+	b.Now = func() time.Time { return t0 }
+	return b
+}
+
+// post sends msg to the bot, signed at ts with key.
+func post(b *Bot, msg Message, key []byte, ts time.Time) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(msg)
+	r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(body))
+	SignRequest(r, key, body, ts)
+	w := httptest.NewRecorder()
+	b.ServeHTTP(w, r)
+	return w
+}
+
+func TestWebhook(t *testing.T) {
+	p := newPlatform(t)
+	b := newBot(t)
+	for i, tt := range []struct {
+		text   string
+		status int
+		reply  string
+	}{
+		{"/echo hello  world", 202, "hello world"},
+		{"/ECHO loud", 202, "loud"},
+		{"/echo", 202, "usage: nothing to echo\n/echo: repeat the arguments"},
+		{"/roll 0d6", 202, `usage: bad dice "0d6"`},
+		{"/nope", 202, "unknown command /nope, try /help"},
+		{"/broken", 202, "sorry, /broken failed"},
+		{"/help", 202, "/broken   always fails\n/echo     repeat the arguments\n/help     list the commands\n/quiet    replies nothing\n/roll     roll dice, like 2d6"},
+		{"/quiet", 202, ""},
+		{"just chatting", 204, ""},
+		{"/", 204, ""},
+	} {
+		msg := Message{ID: string(rune('a' + i)), Channel: "general", User: "alice", Text: tt.text, ReplyURL: p.URL}
+		if w := post(b, msg, secret, t0); w.Code != tt.status {
+			t.Errorf("%q: %d %s, want %d", tt.text, w.Code, w.Body, tt.status)
+			continue
+		}
+		if tt.reply == "" {
+			continue
+		}
+		r := p.next(t)
+		if !strings.HasPrefix(r.Text, tt.reply) || r.Channel != "general" || r.InReplyTo != msg.ID {
+			t.Errorf("%q: reply %+v, want %q", tt.text, r, tt.reply)
+		}
+	}
+	b.Outbox.Close()
+	if len(p.replies) != 7 {
+		t.Errorf("%d replies, want 7", len(p.replies))
+	}
+}
+
+// TestRejected posts requests the bot must refuse before running anything.
+func TestRejected(t *testing.T) {
+	b := newBot(t)
+	msg := Message{ID: "1", Text: "/echo hi", ReplyURL: "http://127.0.0.1:1"}
+	body, _ := json.Marshal(msg)
+	for _, tt := range []struct {
+		name   string
+		req    func() *http.Request
+		status int
+	}{
+		{"GET", func() *http.Request { return httptest.NewRequest("GET", "/webhook", nil) }, 405},
+		{"unsigned", func() *http.Request { return httptest.NewRequest("POST", "/webhook", bytes.NewReader(body)) }, 401},
+		{"another secret", func() *http.Request {
+			r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(body))
+			SignRequest(r, []byte("guess"), body, t0)
+			return r
+		}, 401},
+		{"replayed", func() *http.Request {
+			r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(body))
+			SignRequest(r, secret, body, t0.Add(-6*time.Minute))
+			return r
+		}, 401},
+		{"body changed", func() *http.Request {
+			r := httptest.NewRequest("POST", "/webhook", strings.NewReader(strings.Replace(string(body), "hi", "ho", 1)))
+			SignRequest(r, secret, body, t0)
+			return r
+		}, 401},
+		{"not json", func() *http.Request {
+			r := httptest.NewRequest("POST", "/webhook", strings.NewReader("hi"))
+			SignRequest(r, secret, []byte("hi"), t0)
+			return r
+		}, 400},
+		{"no reply URL", func() *http.Request {
+			body := []byte(`{"id": "2", "text": "/echo hi"}`)
+			r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(body))
+			SignRequest(r, secret, body, t0)
+			return r
+		}, 400},
+		{"too large", func() *http.Request {
+			body := bytes.Repeat([]byte("a"), maxBody+1)
+			r := httptest.NewRequest("POST", "/webhook", bytes.NewReader(body))
+			SignRequest(r, secret, body, t0)
+			return r
+		}, 413},
+	} {
+		w := httptest.NewRecorder()
+		b.ServeHTTP(w, tt.req())
+		if w.Code != tt.status {
+			t.Errorf("%s: %d %s, want %d", tt.name, w.Code, w.Body, tt.status)
+		}
+	}
+	if n := b.Remembered(); n != 0 {
+		t.Errorf("%d ids remembered from rejected requests", n)
+	}
+}
+
+// TestDedupe resends a message: it runs once, and its id is forgotten once
+// its timestamp is out of the tolerance anyway.
+func TestDedupe(t *testing.T) {
+	p := newPlatform(t)
+	b := newBot(t)
+	msg := Message{ID: "1", Text: "/echo once", ReplyURL: p.URL}
+	if w := post(b, msg, secret, t0); w.Code != 202 {
+		t.Fatalf("first post: %d", w.Code)
+	}
+	if w := post(b, msg, secret, t0.Add(time.Second)); w.Code != 200 {
+		t.Fatalf("resent: %d, want 200", w.Code)
+	}
+	p.next(t)
+	b.Outbox.Close()
+	if len(p.replies) != 1 {
+		t.Errorf("%d replies to one message resent, want 1", len(p.replies))
+	}
+
+	now := t0.Add(10 * time.Minute)
+	b.Now = func() time.Time { return now }
+	post(b, Message{ID: "2", Text: "hello", ReplyURL: p.URL}, secret, now)
+	if n := b.Remembered(); n != 1 {
+		t.Errorf("%d ids remembered, want the old one forgotten", n)
+	}
+}
+
+// TestQueueFull fills the outbox: the webhook answers 503, and the message
+// is not remembered, its resend runs.
+func TestQueueFull(t *testing.T) {
+	p := newPlatform(t)
+	b := newBot(t)
+	b.Outbox = &Outbox{queue: make(chan Reply, 1), Log: discard()} // no workers
+	b.Outbox.queue <- Reply{}
+
+	msg := Message{ID: "1", Text: "/echo hi", ReplyURL: p.URL}
+	w := post(b, msg, secret, t0)
+	if w.Code != 503 || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("full queue: %d %v, want 503 and Retry-After", w.Code, w.Header())
+	}
+	<-b.Outbox.queue
+	if w := post(b, msg, secret, t0); w.Code != 202 {
+		t.Errorf("the resend: %d, want 202", w.Code)
+	}
+}
+
+// TestConcurrent posts commands from many users at once: each is answered
+// once, and Close waits for the replies queued. The webhook answers 503
+// once the outbox is closed.
+func TestConcurrent(t *testing.T) {
+	p := newPlatform(t)
+	p.got = make(chan Reply, 64)
+	b := newBot(t)
+	b.Outbox = NewOutbox(64, 4, secret, discard())
+	t.Cleanup(b.Outbox.Close)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			msg := Message{ID: strconv.Itoa(i), Text: "/roll 2d6", ReplyURL: p.URL}
+			if w := post(b, msg, secret, t0); w.Code != 202 {
+				t.Errorf("message %d: %d", i, w.Code)
+			}
+		}()
+	}
+	wg.Wait()
+	b.Outbox.Close()
+	seen := map[string]bool{}
+	for _, r := range p.replies {
+		seen[r.InReplyTo] = true
+	}
+	if len(p.replies) != 50 || len(seen) != 50 {
+		t.Errorf("%d replies to %d messages, want 50", len(p.replies), len(seen))
+	}
+	if w := post(b, Message{ID: "late", Text: "/echo late", ReplyURL: p.URL}, secret, t0); w.Code != 503 {
+		t.Errorf("after Close: %d, want 503", w.Code)
+	}
+}
+
+func TestOutboxRetries(t *testing.T) {
+	for _, tt := range []struct {
+		name                  string
+		fail                  []int
+		sent, failed, retried int64
+	}{
+		{"ok", nil, 1, 0, 0},
+		{"5xx then ok", []int{500, 503}, 1, 0, 2},
+		{"too many requests", []int{429}, 1, 0, 1},
+		{"4xx is final", []int{400}, 0, 1, 0},
+		{"always failing", []int{500, 500, 500, 500}, 0, 1, 3},
+	} {
+		p := newPlatform(t, tt.fail...)
+		o := NewOutbox(4, 1, secret, discard())
+		o.Backoff = time.Millisecond
+		if err := o.Enqueue(Reply{URL: p.URL, Text: "hi"}); err != nil {
+			t.Fatal(err)
+		}
+		o.Close()
+		if sent, failed, retried := o.Stats(); sent != tt.sent || failed != tt.failed || retried != tt.retried {
+			t.Errorf("%s: sent %d, failed %d, retried %d; want %d, %d, %d", tt.name, sent, failed, retried, tt.sent, tt.failed, tt.retried)
+		}
+		if err := o.Enqueue(Reply{URL: p.URL}); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s: Enqueue after Close = %v, want ErrClosed", tt.name, err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id": "1"}`)
+	signed := func(key []byte, ts time.Time) http.Header {
+		r := httptest.NewRequest("POST", "/", nil)
+		SignRequest(r, key, body, ts)
+		return r.Header
+	}
+	with := func(h http.Header, name, value string) http.Header {
+		h = h.Clone()
+		h.Set(name, value)
+		return h
+	}
+	for _, tt := range []struct {
+		name string
+		h    http.Header
+		want error
+	}{
+		{"valid", signed(secret, t0), nil},
+		{"at the tolerance", signed(secret, t0.Add(-5*time.Minute)), nil},
+		{"from the future", signed(secret, t0.Add(5*time.Minute+time.Second)), ErrStale},
+		{"too old", signed(secret, t0.Add(-5*time.Minute-time.Second)), ErrStale},
+		{"another secret", signed([]byte("other"), t0), ErrBadSignature},
+		{"timestamp changed", with(signed(secret, t0), HeaderTimestamp, "1709294401"), ErrBadSignature},
+		{"bad timestamp", with(signed(secret, t0), HeaderTimestamp, "noon"), ErrBadSignature},
+		{"another scheme", with(signed(secret, t0), HeaderSignature, "v0=abc"), ErrBadSignature},
+		{"no signature", with(signed(secret, t0), HeaderSignature, ""), ErrNoSignature},
+		{"no timestamp", with(signed(secret, t0), HeaderTimestamp, ""), ErrNoSignature},
+	} {
+		if err := Verify(secret, tt.h, body, t0, 5*time.Minute); !errors.Is(err, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+	_ "time/tzdata" // /time takes zone names, the host may have no zoneinfo
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/chatbot"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+chatbot serves the webhook of a chat bot answering /help, /echo, /roll and
+/time.
+
+Usage:
+
+	BOT_SECRET=s3cret go run ./golang_program_design_2024/09.projects/chatbot/cmd/chatbot -addr :8080
+
+	go run ./golang_program_design_2024/09.projects/chatbot/cmd/chatbot -demo
+
+The secret is shared with the platform, which signs each webhook with it;
+the bot signs its replies with it too. -demo plays the platform on a local
+test server, posting webhooks to the bot and printing its replies. The
+handlers, the signatures, dedupe, the queue and its retries are tests:
+
+	go test ./golang_program_design_2024/09.projects/chatbot
+*/
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	workers := flag.Int("workers", 4, "workers posting replies")
+	demo := flag.Bool("demo", false, "post a conversation to the bot on a local server")
+	flag.Parse()
+
+	if *demo {
+		if err := runDemo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	log := xlog.New("09.projects", "chatbot")
+	secret := []byte(os.Getenv("BOT_SECRET"))
+	if len(secret) == 0 {
+		fmt.Fprintln(os.Stderr, "BOT_SECRET is required")
+		os.Exit(2)
+	}
+	out := chatbot.NewOutbox(256, *workers, secret, log)
+	bot := chatbot.New(secret, registry(time.Now), out, log)
+	mux := http.NewServeMux()
+	mux.Handle("POST /webhook", bot)
+	srv := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	log.Info("listening", "addr", *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Error("serve", "err", err)
+		os.Exit(1)
+	}
+	// the webhooks are answered, post the replies still queued.
+	out.Close()
+	sent, failed, _ := out.Stats()
+	log.Info("stopped", "sent", sent, "failed", failed)
+}
+
+func registry(now func() time.Time) *chatbot.Registry {
+	reg := chatbot.NewRegistry()
+	reg.Register("echo", "repeat the text", chatbot.Echo())
+	reg.Register("roll", "roll dice, /roll 2d6", chatbot.Roll(time.Now().UnixNano()))
+	reg.Register("time", "the time in a zone, /time Asia/Seoul", chatbot.Time(now))
+	return reg
+}
+
+// runDemo plays the platform on a local test server: it posts webhooks to
+// the bot, signed or not, and prints the status of each and the reply the
+// bot posts back.
+func runDemo() error {
+	secret := []byte("s3cret")
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if os.Getenv("CHATBOT_LOG") != "" {
+		log = xlog.New("09.projects", "chatbot")
+	}
+	plat := &platform{secret: secret, replies: make(chan chatbot.Reply, 16)}
+	platSrv := httptest.NewServer(plat)
+	defer platSrv.Close()
+
+	noon := func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	out := chatbot.NewOutbox(64, 4, secret, log)
+	defer out.Close()
+	bot := chatbot.New(secret, registry(noon), out, log)
+	botSrv := httptest.NewServer(bot)
+	defer botSrv.Close()
+
+	for i, m := range []struct {
+		text string
+		key  string // the secret signing the webhook, none when empty
+	}{
+		{"/help", "s3cret"},
+		{"/echo hello   world", "s3cret"},
+		{"/roll 3d6", "s3cret"},
+		{"/roll 3x", "s3cret"},
+		{"/time Asia/Seoul", "s3cret"},
+		{"/nope", "s3cret"},
+		{"good morning", "s3cret"},
+		{"/echo unsigned", ""},
+		{"/echo forged", "guess"},
+	} {
+		body, err := json.Marshal(chatbot.Message{ID: fmt.Sprint("m", i), Channel: "general", User: "ana", Text: m.text, ReplyURL: platSrv.URL})
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPost, botSrv.URL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if m.key != "" {
+			chatbot.SignRequest(req, []byte(m.key), body, time.Now())
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		fmt.Printf("ana: %s\n  %s\n", m.text, resp.Status)
+		if resp.StatusCode != http.StatusAccepted {
+			continue
+		}
+		select {
+		case r := <-plat.replies:
+			fmt.Printf("  bot: %s\n", strings.ReplaceAll(r.Text, "\n", "\n       "))
+		case <-time.After(2 * time.Second):
+			return errors.New("no reply from the bot")
+		}
+	}
+	return nil
+}
+
+// platform plays the chat platform receiving the replies: it checks their
+// signature and hands them on.
+type platform struct {
+	secret  []byte
+	replies chan chatbot.Reply
+}
+
+func (p *platform) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if err := chatbot.Verify(p.secret, r.Header, body, time.Now(), time.Minute); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	var reply chatbot.Reply
+	if json.Unmarshal(body, &reply) != nil {
+		http.Error(w, "bad reply", http.StatusBadRequest)
+		return
+	}
+	p.replies <- reply
+}
@@ -0,0 +1,69 @@
+package chatbot
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Echo replies with its arguments.
+func Echo() Handler {
+	return HandlerFunc(func(ctx context.Context, cmd *Command) (string, error) {
+		if len(cmd.Args) == 0 {
+			return "", fmt.Errorf("%w: nothing to echo", ErrUsage)
+		}
+		return strings.Join(cmd.Args, " "), nil
+	})
+}
+
+// Roll rolls dice written NdM, 1d6 without argument: "/roll 2d6" replies
+// "2d6: 3 + 5 = 8". Handlers run concurrently, the generator is behind a
+// mutex.
+func Roll(seed int64) Handler {
+	var mu sync.Mutex
+	rng := rand.New(rand.NewSource(seed))
+	return HandlerFunc(func(ctx context.Context, cmd *Command) (string, error) {
+		spec := "1d6"
+		if len(cmd.Args) > 0 {
+			spec = strings.ToLower(cmd.Args[0])
+		}
+		ns, ms, ok := strings.Cut(spec, "d")
+		n, err1 := strconv.Atoi(ns)
+		m, err2 := strconv.Atoi(ms)
+		if !ok || err1 != nil || err2 != nil || n < 1 || n > 20 || m < 2 || m > 100 {
+			return "", fmt.Errorf("%w: bad dice %q", ErrUsage, spec)
+		}
+		mu.Lock()
+		rolls := make([]string, n)
+		sum := 0
+		for i := range rolls {
+			v := rng.Intn(m) + 1
+			rolls[i] = strconv.Itoa(v)
+			sum += v
+		}
+		mu.Unlock()
+		if n == 1 {
+			return fmt.Sprintf("%s: %d", spec, sum), nil
+		}
+		return fmt.Sprintf("%s: %s = %d", spec, strings.Join(rolls, " + "), sum), nil
+	})
+}
+
+// Time replies with the time now in a time zone, UTC by default.
+func Time(now func() time.Time) Handler {
+	return HandlerFunc(func(ctx context.Context, cmd *Command) (string, error) {
+		name := "UTC"
+		if len(cmd.Args) > 0 {
+			name = cmd.Args[0]
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return "", fmt.Errorf("%w: unknown time zone %q", ErrUsage, name)
+		}
+		return now().In(loc).Format("15:04 MST, Mon 2 Jan"), nil
+	})
+}
@@ -0,0 +1,181 @@
+package chatbot
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func run(h Handler, args ...string) (string, error) {
+	return h.Handle(context.Background(), &Command{Args: args})
+}
+
+func TestRoll(t *testing.T) {
+	roll := Roll(1)
+	for _, tt := range []struct {
+		args []string
+		re   string
+		n    int
+		max  int
+	}{
+		{nil, `^1d6: (\d+)$`, 1, 6},
+		{[]string{"2D6"}, `^2d6: (\d+) \+ (\d+) = (\d+)$`, 2, 6},
+		{[]string{"20d100"}, `^20d100: (\d+)( \+ \d+){19} = (\d+)$`, 20, 100},
+	} {
+		got, err := run(roll, tt.args...)
+		if err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if !regexp.MustCompile(tt.re).MatchString(got) {
+			t.Errorf("%v = %q, want %s", tt.args, got, tt.re)
+			continue
+		}
+		// every die in 1..max, and the sum right.
+		rolls, sum, _ := strings.Cut(strings.SplitN(got, ": ", 2)[1], " = ")
+		if sum == "" {
+			sum = rolls
+		}
+		total := 0
+		for _, r := range strings.Split(rolls, " + ") {
+			v, _ := strconv.Atoi(r)
+			if v < 1 || v > tt.max {
+				t.Errorf("%v: a die of %d", tt.args, v)
+			}
+			total += v
+		}
+		if s, _ := strconv.Atoi(sum); s != total {
+			t.Errorf("%v = %q: the sum is %d", tt.args, got, total)
+		}
+	}
+
+	for _, spec := range []string{"d6", "2d", "0d6", "21d6", "2d1", "2d101", "two", "2x6"} {
+		if _, err := run(roll, spec); !errors.Is(err, ErrUsage) {
+			t.Errorf("%s: %v, want ErrUsage", spec, err)
+		}
+	}
+
+	// the same seed rolls the same dice.
+	a, _ := run(Roll(42), "5d20")
+	b, _ := run(Roll(42), "5d20")
+	if a != b {
+		t.Errorf("two rolls with one seed: %q and %q", a, b)
+	}
+}
+
+// TestRollConcurrent rolls from several goroutines, for the race detector.
+func TestRollConcurrent(t *testing.T) {
+	roll := Roll(1)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := run(roll, "3d6"); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestTime(t *testing.T) {
+	h := Time(func() time.Time { return t0 })
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "12:00 UTC, Fri 1 Mar"},
+		{[]string{"Asia/Seoul"}, "21:00 KST, Fri 1 Mar"},
+		{[]string{"America/New_York"}, "07:00 EST, Fri 1 Mar"},
+	} {
+		if got, err := run(h, tt.args...); err != nil || got != tt.want {
+			t.Errorf("%v = %q, %v; want %q", tt.args, got, err, tt.want)
+		}
+	}
+	if _, err := run(h, "Mars/Olympus"); !errors.Is(err, ErrUsage) {
+		t.Errorf("an unknown zone: %v, want ErrUsage", err)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	if got, err := run(Echo(), "a", "b"); err != nil || got != "a b" {
+		t.Errorf("echo a b = %q, %v", got, err)
+	}
+	if _, err := run(Echo()); !errors.Is(err, ErrUsage) {
+		t.Errorf("echo alone: %v, want ErrUsage", err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Prefix = "!"
+	for _, tt := range []struct {
+		text string
+		want string // name and args, "" for no command
+	}{
+		{"!roll 2d6", "roll [2d6]"},
+		{"  !Echo  a   b ", "echo [a b]"},
+		{"!", ""},
+		{"/roll", ""},
+		{"hello !roll", ""},
+	} {
+		cmd := r.Parse(&Message{Text: tt.text})
+		got := ""
+		if cmd != nil {
+			got = cmd.Name + " [" + strings.Join(cmd.Args, " ") + "]"
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering help twice did not panic")
+		}
+	}()
+	r.Register("help", "again", Echo())
+}
+
+// TestDispatch checks what the user reads, whatever the handler returns.
+func TestDispatch(t *testing.T) {
+	r := NewRegistry()
+	r.Register("echo", "repeat the arguments", Echo())
+	r.Register("broken", "always fails", HandlerFunc(func(context.Context, *Command) (string, error) {
+		return "", errors.New("database down")
+	}))
+	r.Register("slow", "waits for its context", HandlerFunc(func(ctx context.Context, _ *Command) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}))
+	for _, tt := range []struct {
+		text, want string
+		err        bool
+	}{
+		{"/echo hi", "hi", false},
+		{"/echo", "usage: nothing to echo\n/echo: repeat the arguments", false},
+		{"/nope", "unknown command /nope, try /help", false},
+		{"/broken", "sorry, /broken failed", true},
+	} {
+		got, err := r.Dispatch(context.Background(), r.Parse(&Message{Text: tt.text}))
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("%s = %q, %v; want %q", tt.text, got, err, tt.want)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if got, err := r.Dispatch(ctx, r.Parse(&Message{Text: "/slow"})); !errors.Is(err, context.DeadlineExceeded) || got != "sorry, /slow failed" {
+		t.Errorf("a handler past its deadline: %q, %v", got, err)
+	}
+}
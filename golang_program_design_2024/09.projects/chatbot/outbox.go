@@ -0,0 +1,144 @@
+package chatbot
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrQueueFull = errors.New("chatbot: reply queue is full")
+	ErrClosed    = errors.New("chatbot: outbox is closed")
+)
+
+// Reply is a message for the platform.
+type Reply struct {
+	URL       string `json:"-"`
+	Channel   string `json:"channel"`
+	Text      string `json:"text"`
+	InReplyTo string `json:"in_reply_to"`
+}
+
+// Outbox posts replies from a queue with a few workers, retrying failures.
+// The webhook only enqueues: it answers the platform at once, whatever the
+// time the platform then takes to accept the reply.
+type Outbox struct {
+	// Secret signs the replies, nil sends them unsigned.
+	Secret  []byte
+	Client  *http.Client
+	Retries int           // after the first attempt
+	Backoff time.Duration // before the first retry, doubling after
+	Log     *slog.Logger
+
+	queue chan Reply
+	wg    sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	sent, failed, retried atomic.Int64
+}
+
+// NewOutbox starts workers posting the replies of a queue of size replies.
+func NewOutbox(size, workers int, secret []byte, log *slog.Logger) *Outbox {
+	o := &Outbox{
+		Secret:  secret,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+		Retries: 3,
+		Backoff: 200 * time.Millisecond,
+		Log:     log,
+		queue:   make(chan Reply, size),
+	}
+	o.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer o.wg.Done()
+			for r := range o.queue {
+				o.deliver(r)
+			}
+		}()
+	}
+	return o
+}
+
+// Enqueue queues r without waiting: a full queue is an error, for the
+// webhook to turn into a 503 the platform will retry later.
+func (o *Outbox) Enqueue(r Reply) error {
+	// the read lock keeps Close from closing the channel between the check
+	// and the send, which would panic.
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.closed {
+		return ErrClosed
+	}
+	select {
+	case o.queue <- r:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting replies and waits for the queued ones to be posted.
+func (o *Outbox) Close() {
+	o.mu.Lock()
+	if !o.closed {
+		o.closed = true
+		close(o.queue)
+	}
+	o.mu.Unlock()
+	o.wg.Wait()
+}
+
+// Stats returns the replies sent, the ones given up on, and the retries.
+func (o *Outbox) Stats() (sent, failed, retried int64) {
+	return o.sent.Load(), o.failed.Load(), o.retried.Load()
+}
+
+// deliver posts r, retrying network errors and 5xx answers with a growing
+// pause. A 4xx will not get better and is not retried.
+func (o *Outbox) deliver(r Reply) {
+	body, _ := json.Marshal(r)
+	backoff := o.Backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := o.post(r.URL, body)
+		if err == nil {
+			o.sent.Add(1)
+			return
+		}
+		if !retry || attempt == o.Retries {
+			o.failed.Add(1)
+			o.Log.Warn("reply dropped", "to", r.InReplyTo, "attempts", attempt+1, "err", err)
+			return
+		}
+		o.retried.Add(1)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (o *Outbox) post(url string, body []byte) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if o.Secret != nil {
+		SignRequest(req, o.Secret, body, time.Now())
+	}
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, fmt.Errorf("status %s", resp.Status)
+	}
+	return false, nil
+}
@@ -0,0 +1,122 @@
+package chatbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Message is a chat message, as the platform posts it to the webhook.
+type Message struct {
+	ID      string `json:"id"` // unique, the platform resends a message with the same id
+	Channel string `json:"channel"`
+	User    string `json:"user"`
+	Text    string `json:"text"`
+	// ReplyURL is where to post the answer.
+	ReplyURL string `json:"reply_url"`
+}
+
+// Command is a message addressed to the bot: "/roll 2d6" is the command roll
+// with the argument 2d6.
+type Command struct {
+	Name string
+	Args []string
+	Msg  *Message
+}
+
+// Handler answers a command. Its reply is posted to the channel of the
+// message; an empty reply posts nothing.
+type Handler interface {
+	Handle(ctx context.Context, cmd *Command) (string, error)
+}
+
+// HandlerFunc adapts a function to a Handler.
+type HandlerFunc func(ctx context.Context, cmd *Command) (string, error)
+
+func (f HandlerFunc) Handle(ctx context.Context, cmd *Command) (string, error) { return f(ctx, cmd) }
+
+// ErrUsage is returned by a handler given wrong arguments. The bot replies
+// with the error and the help of the command instead of a failure.
+var ErrUsage = errors.New("usage")
+
+// Registry maps command names to handlers.
+type Registry struct {
+	// Prefix marks a message as a command, "/" by default.
+	Prefix string
+
+	mu   sync.RWMutex
+	cmds map[string]entry
+}
+
+type entry struct {
+	help string
+	h    Handler
+}
+
+// NewRegistry returns a registry knowing only the command help, listing
+// the others.
+func NewRegistry() *Registry {
+	r := &Registry{Prefix: "/", cmds: make(map[string]entry)}
+	r.Register("help", "list the commands", HandlerFunc(r.help))
+	return r
+}
+
+// Register adds a command. Registering a name twice panics, like
+// http.ServeMux does for a pattern: it is a programming error.
+func (r *Registry) Register(name, help string, h Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, dup := r.cmds[name]; dup {
+		panic("chatbot: command registered twice: " + name)
+	}
+	r.cmds[name] = entry{help, h}
+}
+
+// Parse returns the command of msg, or nil if msg is not addressed to the
+// bot.
+func (r *Registry) Parse(msg *Message) *Command {
+	text, ok := strings.CutPrefix(strings.TrimSpace(msg.Text), r.Prefix)
+	if !ok || text == "" {
+		return nil
+	}
+	fields := strings.Fields(text)
+	return &Command{Name: strings.ToLower(fields[0]), Args: fields[1:], Msg: msg}
+}
+
+// Dispatch runs the handler of cmd and returns the reply to post. A usage
+// error or an unknown command is a reply, for the user; any other error is
+// returned, for the log, and the user gets a short apology.
+func (r *Registry) Dispatch(ctx context.Context, cmd *Command) (string, error) {
+	r.mu.RLock()
+	e, ok := r.cmds[cmd.Name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Sprintf("unknown command %s%s, try %shelp", r.Prefix, cmd.Name, r.Prefix), nil
+	}
+	reply, err := e.h.Handle(ctx, cmd)
+	switch {
+	case errors.Is(err, ErrUsage):
+		return fmt.Sprintf("%v\n%s%s: %s", err, r.Prefix, cmd.Name, e.help), nil
+	case err != nil:
+		return fmt.Sprintf("sorry, %s%s failed", r.Prefix, cmd.Name), fmt.Errorf("%s: %w", cmd.Name, err)
+	}
+	return reply, nil
+}
+
+func (r *Registry) help(ctx context.Context, cmd *Command) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.cmds))
+	for name := range r.cmds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s%-8s %s\n", r.Prefix, name, r.cmds[name].help)
+	}
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
@@ -0,0 +1,71 @@
+package chatbot
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The headers carrying the signature of a request, both ways: the platform
+// signs the webhooks it sends, the bot signs its replies.
+const (
+	HeaderSignature = "X-Bot-Signature"
+	HeaderTimestamp = "X-Bot-Timestamp"
+)
+
+var (
+	ErrNoSignature  = errors.New("chatbot: request is not signed")
+	ErrBadSignature = errors.New("chatbot: bad signature")
+	ErrStale        = errors.New("chatbot: timestamp out of tolerance")
+)
+
+// Sign returns the signature of body sent at ts: "v1=" and the hex HMAC-SHA256
+// of the timestamp, a dot and the body. Signing the timestamp with the body
+// is what makes it useful: a captured request replayed later has an old
+// timestamp, and changing it breaks the signature.
+func Sign(secret []byte, ts time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d.", ts.Unix())
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest sets the signature headers of req for body.
+func SignRequest(req *http.Request, secret, body []byte, now time.Time) {
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(secret, now, body))
+}
+
+// Verify checks the signature headers against body. A timestamp further than
+// tolerance from now, in the past or the future, is rejected whatever the
+// signature.
+func Verify(secret []byte, h http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	sig, stamp := h.Get(HeaderSignature), h.Get(HeaderTimestamp)
+	if sig == "" || stamp == "" {
+		return ErrNoSignature
+	}
+	sec, err := strconv.ParseInt(stamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: timestamp %q", ErrBadSignature, stamp)
+	}
+	ts := time.Unix(sec, 0)
+	if d := now.Sub(ts); d > tolerance || d < -tolerance {
+		return fmt.Errorf("%w: %v", ErrStale, d.Round(time.Second))
+	}
+	if !strings.HasPrefix(sig, "v1=") {
+		return fmt.Errorf("%w: unknown scheme", ErrBadSignature)
+	}
+	// compare the bytes in constant time: a comparison stopping at the
+	// first difference tells an attacker, timing it, how many bytes of a
+	// guess are right.
+	if !hmac.Equal([]byte(sig), []byte(Sign(secret, ts, body))) {
+		return ErrBadSignature
+	}
+	return nil
+}
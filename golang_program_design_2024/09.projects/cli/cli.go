@@ -0,0 +1,229 @@
+// Package cli dispatches a command line to a tree of subcommands, in the
+// manner of git or kubectl:
+//
+//	learnctl -data-dir /tmp/kv kv put greeting hello
+//	         |                 |  |   '-- arguments of the leaf
+//	         |                 |  '------ subcommand of kv
+//	         |                 '--------- subcommand of the root
+//	         '--------------------------- persistent flag of the root
+//
+// Every command has its own flag.FlagSet. Flags declared in Persistent are
+// also accepted by all the commands below it, so each level of the tree can
+// take its flags after its own name. App.Bind can fill the flags not given
+// on the command line from other sources, such as the environment and a
+// config file with config.LoadFlags.
+//
+// The package only uses the standard flag package; a library such as cobra
+// follows the same shape with more features.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// ErrUsage is returned for a command line that does not parse; the caller
+// usually exits with status 2.
+var ErrUsage = errors.New("usage error")
+
+// Command is a node of the tree: a group of subcommands, an action, or both.
+type Command struct {
+	Name  string
+	Short string // one line for the list of commands
+	// Args describes the arguments in the usage line, e.g. "key value".
+	Args string
+	// Flags are the flags of this command only.
+	Flags *flag.FlagSet
+	// Persistent flags apply to this command and all below it.
+	Persistent *flag.FlagSet
+	// Run executes the command; nil for a pure group, which prints its help.
+	Run func(ctx *Context, args []string) error
+	// Hidden commands are not listed in help.
+	Hidden bool
+	// RawArgs passes everything after the command name to Run unparsed,
+	// flags included.
+	RawArgs bool
+
+	parent *Command
+	sub    []*Command
+}
+
+// Context is what a running command gets: where to write, and the command
+// itself to reach its flags.
+type Context struct {
+	Command *Command
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+// Add registers subcommands and returns c, to build trees in one expression.
+func (c *Command) Add(sub ...*Command) *Command {
+	for _, s := range sub {
+		s.parent = c
+		c.sub = append(c.sub, s)
+	}
+	return c
+}
+
+// Path returns the names from the root, e.g. "learnctl kv put".
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+func (c *Command) find(name string) *Command {
+	for _, s := range c.sub {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// flagSet merges the local flags of c and the persistent flags of c and its
+// ancestors into the FlagSet used to parse the arguments given to c. The
+// flag.Values are shared, so a value set at any level is seen everywhere.
+func (c *Command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.Path(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	add := func(src *flag.FlagSet) {
+		if src == nil {
+			return
+		}
+		src.VisitAll(func(f *flag.Flag) {
+			if fs.Lookup(f.Name) == nil {
+				fs.Var(f.Value, f.Name, f.Usage)
+				fs.Lookup(f.Name).DefValue = f.DefValue
+			}
+		})
+	}
+	add(c.Flags)
+	for p := c; p != nil; p = p.parent {
+		add(p.Persistent)
+	}
+	return fs
+}
+
+// App runs a tree.
+type App struct {
+	Root *Command
+	// Bind, if set, is called before a command runs with its flags and the
+	// names of those given on the command line, to fill in the others.
+	Bind   func(fs *flag.FlagSet, given map[string]bool) error
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Run parses args (without the program name) and runs the command they name.
+func (a *App) Run(args []string) error {
+	if a.Stdout == nil {
+		a.Stdout = os.Stdout
+	}
+	if a.Stderr == nil {
+		a.Stderr = os.Stderr
+	}
+	ctx := &Context{Stdout: a.Stdout, Stderr: a.Stderr}
+
+	c := a.Root
+	set := make(map[string]bool) // flags given on the command line
+	for !c.RawArgs {
+		fs := c.flagSet()
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				a.help(c)
+				return nil
+			}
+			return a.usageError(c, err.Error())
+		}
+		fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		args = fs.Args()
+
+		if len(args) == 0 || len(c.sub) == 0 {
+			break
+		}
+		if args[0] == "help" {
+			a.help(c)
+			return nil
+		}
+		next := c.find(args[0])
+		if next == nil {
+			if c.Run != nil {
+				break // the rest are arguments of c
+			}
+			return a.usageError(c, fmt.Sprintf("unknown command %q", args[0]))
+		}
+		c, args = next, args[1:]
+	}
+
+	if a.Bind != nil {
+		if err := a.Bind(c.flagSet(), set); err != nil {
+			return err
+		}
+	}
+	ctx.Command = c
+	if c.Run == nil {
+		a.help(c)
+		return nil
+	}
+	err := c.Run(ctx, args)
+	if errors.Is(err, ErrUsage) {
+		a.help(c)
+	}
+	return err
+}
+
+func (a *App) usageError(c *Command, msg string) error {
+	fmt.Fprintf(a.Stderr, "%s: %s\n", c.Path(), msg)
+	fmt.Fprintf(a.Stderr, "run '%s -h' for help\n", c.Path())
+	return ErrUsage
+}
+
+func (a *App) help(c *Command) {
+	w := a.Stderr
+	usage := c.Path()
+	if len(c.sub) > 0 {
+		usage += " <command>"
+	}
+	if c.Args != "" {
+		usage += " " + c.Args
+	}
+	fmt.Fprintf(w, "usage: %s [flags]\n", usage)
+	if c.Short != "" {
+		fmt.Fprintf(w, "\n%s\n", c.Short)
+	}
+
+	var visible []*Command
+	for _, s := range c.sub {
+		if !s.Hidden {
+			visible = append(visible, s)
+		}
+	}
+	if len(visible) > 0 {
+		fmt.Fprintln(w, "\ncommands:")
+		for _, s := range visible {
+			fmt.Fprintf(w, "  %-12s %s\n", s.Name, s.Short)
+		}
+	}
+
+	fs := c.flagSet()
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	if len(names) > 0 {
+		sort.Strings(names)
+		fmt.Fprintln(w, "\nflags:")
+		for _, name := range names {
+			f := fs.Lookup(name)
+			def := ""
+			if f.DefValue != "" && f.DefValue != "false" {
+				def = fmt.Sprintf(" (default %s)", f.DefValue)
+			}
+			fmt.Fprintf(w, "  -%-12s %s%s\n", f.Name, f.Usage, def)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/cli"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/du"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/kvstore"
+	"github.com/YongSangUn/learn-golang/internal/config"
+)
+
+/*
+learnctl is one command line in front of the other projects:
+
+	learnctl kv put|get|del|stats|compact   the kvstore project
+	learnctl du [dir]                       the du project
+	learnctl completion bash|zsh            shell completion
+
+Settings come from flags, then LEARNCTL_* variables, then the JSON or YAML
+file given with -config or LEARNCTL_CONFIG, e.g. {"data-dir": "/tmp/learnctl",
+"output": "json"}: the layers of internal/config.
+
+Usage:
+
+	go build -o /tmp/learnctl ./golang_program_design_2024/09.projects/cli/cmd/learnctl
+	/tmp/learnctl kv put greeting hello
+	LEARNCTL_OUTPUT=json /tmp/learnctl kv get greeting
+	/tmp/learnctl du -n 5 .
+	source <(/tmp/learnctl completion bash)
+*/
+
+// settings shared by every command, the persistent flags of the root.
+var (
+	dataDir    string
+	configPath string
+	output     string
+)
+
+func main() {
+	root := &cli.Command{
+		Name:       "learnctl",
+		Short:      "command line front-end of the 09.projects examples",
+		Persistent: flag.NewFlagSet("learnctl", flag.ContinueOnError),
+	}
+	root.Persistent.StringVar(&dataDir, "data-dir", filepath.Join(os.TempDir(), "learnctl"), "directory of the stored data")
+	root.Persistent.StringVar(&configPath, "config", "", "JSON or YAML config file")
+	root.Persistent.StringVar(&output, "output", "text", "output format: text or json")
+
+	app := &cli.App{Root: root, Bind: func(fs *flag.FlagSet, given map[string]bool) error {
+		path := configPath
+		if path == "" {
+			path = os.Getenv("LEARNCTL_CONFIG")
+		}
+		return config.LoadFlags(fs, given, "LEARNCTL_", path)
+	}}
+	root.Add(kvCommand(), duCommand(), app.CompletionCommand())
+
+	if err := app.Run(os.Args[1:]); err != nil {
+		if errors.Is(err, cli.ErrUsage) {
+			os.Exit(2)
+		}
+		fmt.Fprintln(os.Stderr, "learnctl:", err)
+		os.Exit(1)
+	}
+}
+
+// printResult writes v as JSON, or text as is, depending on -output.
+func printResult(ctx *cli.Context, text string, v any) error {
+	switch output {
+	case "json":
+		return json.NewEncoder(ctx.Stdout).Encode(v)
+	case "text":
+		_, err := fmt.Fprintln(ctx.Stdout, text)
+		return err
+	}
+	return fmt.Errorf("unknown output %q: %w", output, cli.ErrUsage)
+}
+
+func kvCommand() *cli.Command {
+	var syncWrites bool
+	kv := &cli.Command{
+		Name:       "kv",
+		Short:      "read and write the key-value store",
+		Persistent: flag.NewFlagSet("kv", flag.ContinueOnError),
+	}
+	kv.Persistent.BoolVar(&syncWrites, "sync", false, "fsync every write")
+
+	// withStore opens the store for one command and closes it after.
+	withStore := func(nargs int, fn func(ctx *cli.Context, s *kvstore.Store, args []string) error) func(*cli.Context, []string) error {
+		return func(ctx *cli.Context, args []string) error {
+			if len(args) != nargs {
+				return cli.ErrUsage
+			}
+			s, err := kvstore.Open(filepath.Join(dataDir, "kv"), kvstore.Options{SyncWrites: syncWrites})
+			if err != nil {
+				return err
+			}
+			return errors.Join(fn(ctx, s, args), s.Close())
+		}
+	}
+
+	return kv.Add(
+		&cli.Command{Name: "get", Short: "print the value of a key", Args: "key",
+			Run: withStore(1, func(ctx *cli.Context, s *kvstore.Store, args []string) error {
+				v, err := s.Get(args[0])
+				if err != nil {
+					return err
+				}
+				return printResult(ctx, string(v), map[string]string{"key": args[0], "value": string(v)})
+			})},
+		&cli.Command{Name: "put", Short: "store a value", Args: "key value",
+			Run: withStore(2, func(ctx *cli.Context, s *kvstore.Store, args []string) error {
+				return s.Put(args[0], []byte(args[1]))
+			})},
+		&cli.Command{Name: "del", Short: "delete a key", Args: "key",
+			Run: withStore(1, func(ctx *cli.Context, s *kvstore.Store, args []string) error {
+				return s.Delete(args[0])
+			})},
+		&cli.Command{Name: "stats", Short: "print the number of keys and the log size",
+			Run: withStore(0, func(ctx *cli.Context, s *kvstore.Store, args []string) error {
+				size, garbage := s.Stats()
+				return printResult(ctx, fmt.Sprintf("keys %d, log %d bytes, %d garbage", s.Len(), size, garbage),
+					map[string]any{"keys": s.Len(), "size": size, "garbage": garbage})
+			})},
+		&cli.Command{Name: "compact", Short: "rewrite the log without dead values",
+			Run: withStore(0, func(ctx *cli.Context, s *kvstore.Store, args []string) error {
+				return s.Compact()
+			})},
+	)
+}
+
+func duCommand() *cli.Command {
+	fs := flag.NewFlagSet("du", flag.ContinueOnError)
+	top := fs.Int("n", 10, "directories listed")
+	workers := fs.Int("j", 0, "stat workers (default: the number of CPUs)")
+	return &cli.Command{
+		Name:  "du",
+		Short: "show the largest directories",
+		Args:  "[dir]",
+		Flags: fs,
+		Run: func(ctx *cli.Context, args []string) error {
+			root := "."
+			switch len(args) {
+			case 0:
+			case 1:
+				root = args[0]
+			default:
+				return cli.ErrUsage
+			}
+			r, err := du.Scan(context.Background(), root, du.Options{Workers: *workers})
+			if err != nil {
+				return err
+			}
+			if output == "json" {
+				return printResult(ctx, "", r.Top(*top))
+			}
+			for _, d := range r.Top(*top) {
+				fmt.Fprintf(ctx.Stdout, "%10d  %s\n", d.Size, d.Path)
+			}
+			fmt.Fprintf(ctx.Stdout, "%10d  total, %d files\n", r.Total, r.Files)
+			return nil
+		},
+	}
+}
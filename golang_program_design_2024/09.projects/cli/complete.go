@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Completion scripts ask the program itself for the candidates: the shell
+// calls "prog __complete word1 word2 ... current", and the program walks its
+// tree like Run does. The script never has to change when commands are added.
+
+const completeCommand = "__complete"
+
+// CompletionCommand returns the "completion" command printing the script
+// for a shell, and registers the hidden command the script calls.
+func (a *App) CompletionCommand() *Command {
+	a.Root.Add(&Command{
+		Name:    completeCommand,
+		Hidden:  true,
+		RawArgs: true,
+		Run: func(ctx *Context, args []string) error {
+			for _, c := range a.complete(args) {
+				fmt.Fprintln(ctx.Stdout, c)
+			}
+			return nil
+		},
+	})
+	return &Command{
+		Name:  "completion",
+		Short: "print the shell completion script (bash or zsh)",
+		Args:  "bash|zsh",
+		Run: func(ctx *Context, args []string) error {
+			if len(args) != 1 {
+				return ErrUsage
+			}
+			switch args[0] {
+			case "bash":
+				fmt.Fprint(ctx.Stdout, bashScript(a.Root.Name))
+			case "zsh":
+				// zsh runs bash completion functions through bashcompinit.
+				fmt.Fprint(ctx.Stdout, "autoload -U +X bashcompinit && bashcompinit\n"+bashScript(a.Root.Name))
+			default:
+				return ErrUsage
+			}
+			return nil
+		},
+	}
+}
+
+func bashScript(prog string) string {
+	fn := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(prog)
+	return fmt.Sprintf(`# %[1]s completion, load with: source <(%[1]s completion bash)
+%[2]s() {
+	local IFS=$'\n'
+	COMPREPLY=($(%[1]s %[3]s "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F %[2]s %[1]s
+`, prog, fn, completeCommand)
+}
+
+// complete returns the candidates for the last word of words.
+func (a *App) complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	cur := words[len(words)-1]
+	c := a.Root
+	var fs *flag.FlagSet
+	skipValue := false
+	for _, w := range words[:len(words)-1] {
+		fs = c.flagSet()
+		switch {
+		case skipValue:
+			skipValue = false
+		case strings.HasPrefix(w, "-"):
+			// "-flag value": the next word is the value, unless boolean or
+			// given as -flag=value.
+			name := strings.TrimLeft(w, "-")
+			if f := fs.Lookup(name); f != nil && !strings.Contains(name, "=") && !isBool(f) {
+				skipValue = true
+			}
+		default:
+			if next := c.find(w); next != nil {
+				c = next
+			}
+		}
+	}
+	if skipValue {
+		return nil // a flag value: let the shell complete files
+	}
+
+	var out []string
+	if strings.HasPrefix(cur, "-") {
+		c.flagSet().VisitAll(func(f *flag.Flag) {
+			if name := "-" + f.Name; strings.HasPrefix(name, cur) {
+				out = append(out, name)
+			}
+		})
+		return out
+	}
+	for _, s := range c.sub {
+		if !s.Hidden && strings.HasPrefix(s.Name, cur) {
+			out = append(out, s.Name)
+		}
+	}
+	return out
+}
+
+func isBool(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/crawler"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/crawler/cmd/crawler -demo
+	go run ./golang_program_design_2024/09.projects/crawler/cmd/crawler -depth 1 -o pages.json https://go.dev
+
+-demo starts a small local site, so the crawler can be tried without network.
+*/
+
+func main() {
+	c := crawler.New()
+	flag.IntVar(&c.MaxDepth, "depth", c.MaxDepth, "maximum link depth")
+	flag.IntVar(&c.Workers, "workers", c.Workers, "concurrent fetches")
+	flag.DurationVar(&c.HostDelay, "delay", c.HostDelay, "delay between requests to the same host")
+	flag.BoolVar(&c.SameHost, "same-host", c.SameHost, "only follow links to the seed hosts")
+	timeout := flag.Duration("timeout", time.Minute, "overall crawl timeout")
+	output := flag.String("o", "", "write the JSON result to this file instead of stdout")
+	demo := flag.Bool("demo", false, "crawl a generated local site")
+	flag.Parse()
+
+	log := xlog.New("09.projects", "crawler")
+
+	seeds := flag.Args()
+	if *demo {
+		site := httptest.NewServer(demoSite())
+		defer site.Close()
+		seeds = []string{site.URL}
+	}
+	if len(seeds) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: crawler [flags] url... (or -demo)")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	start := time.Now()
+	pages, err := c.Crawl(ctx, seeds)
+	if err != nil {
+		// partial results are still written below.
+		log.Warn("crawl interrupted", "err", err)
+	}
+	log.Info("crawl finished", "pages", len(pages), "elapsed", time.Since(start).Round(time.Millisecond))
+
+	out := os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			log.Error("create output", "err", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(pages); err != nil {
+		log.Error("encode", "err", err)
+	}
+}
+
+// demoSite serves /, /page/1 ... /page/9, each linking to a few others, one
+// broken link and one external link that -same-host filters out.
+func demoSite() http.Handler {
+	mux := http.NewServeMux()
+	page := func(w http.ResponseWriter, title string, links ...string) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html><head><title>%s</title></head><body>", title)
+		for _, l := range links {
+			fmt.Fprintf(w, `<a href="%s">%s</a> `, l, l)
+		}
+		fmt.Fprint(w, "</body></html>")
+	}
+	mux.HandleFunc("/{$}", func(w http.ResponseWriter, r *http.Request) {
+		page(w, "home", "/page/1", "/page/2", "/missing", "https://example.com/")
+	})
+	mux.HandleFunc("/page/{n}", func(w http.ResponseWriter, r *http.Request) {
+		var n int
+		fmt.Sscan(r.PathValue("n"), &n)
+		if n < 1 || n > 9 {
+			http.NotFound(w, r)
+			return
+		}
+		page(w, "page "+r.PathValue("n"), fmt.Sprintf("/page/%d", n*2), fmt.Sprintf("/page/%d#top", n*2+1), "/")
+	})
+	return mux
+}
@@ -0,0 +1,266 @@
+// Package crawler fetches pages concurrently, following links up to a depth
+// limit, while staying polite to the sites it visits. It is the errGroup()
+// demo of 04.concurrent/sync grown into a real program:
+//
+//   - errgroup.Group with SetLimit bounds the number of fetching goroutines,
+//   - a mutex-guarded set makes sure each URL is fetched once,
+//   - a per-host limiter spaces out the requests to the same host,
+//   - the context cancels everything on Ctrl-C or on the overall timeout.
+//
+// The crawl runs breadth first, one depth level at a time: the workers of a
+// level only collect links, and the next level starts when they are done.
+// Scheduling children from inside a worker would block on SetLimit once all
+// workers try to do it at the same time.
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Page is the result of fetching one URL; it is exported as JSON.
+type Page struct {
+	URL      string        `json:"url"`
+	Depth    int           `json:"depth"`
+	Status   int           `json:"status,omitempty"`
+	Title    string        `json:"title,omitempty"`
+	Links    []string      `json:"links,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// Crawler holds the settings of a crawl. Its zero value is not usable, use New.
+type Crawler struct {
+	Client    *http.Client
+	MaxDepth  int           // 0 only fetches the seeds
+	Workers   int           // concurrent fetches, defaultWorkers if <= 0
+	HostDelay time.Duration // minimum time between two requests to the same host
+	SameHost  bool          // only follow links to the hosts of the seeds
+	MaxBody   int64         // bytes read per page
+
+	visited *visitedSet
+	limiter *hostLimiter
+}
+
+const defaultWorkers = 8
+
+func New() *Crawler {
+	return &Crawler{
+		Client:    &http.Client{Timeout: 10 * time.Second},
+		MaxDepth:  2,
+		Workers:   defaultWorkers,
+		HostDelay: 200 * time.Millisecond,
+		SameHost:  true,
+		MaxBody:   1 << 20,
+	}
+}
+
+// Crawl fetches the seeds and the pages they link to, and returns every page
+// in URL order. A page that fails to load is reported in its Error field;
+// Crawl itself only fails when ctx is done.
+func (c *Crawler) Crawl(ctx context.Context, seeds []string) ([]Page, error) {
+	c.visited = &visitedSet{seen: make(map[string]bool)}
+	c.limiter = &hostLimiter{delay: c.HostDelay, next: make(map[string]time.Time)}
+
+	hosts := make(map[string]bool)
+	var level []string
+	for _, seed := range seeds {
+		u, err := url.Parse(seed)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, fmt.Errorf("invalid seed %q", seed)
+		}
+		hosts[u.Host] = true
+		if c.visited.add(normalize(u)) {
+			level = append(level, normalize(u))
+		}
+	}
+
+	workers := c.Workers
+	if workers <= 0 {
+		workers = defaultWorkers // SetLimit(0) would block every Go
+	}
+	var (
+		mu    sync.Mutex
+		pages []Page
+	)
+	for depth := 0; depth <= c.MaxDepth && len(level) > 0; depth++ {
+		g, gctx := errgroup.WithContext(ctx)
+		g.SetLimit(workers)
+
+		var next []string
+		for _, link := range level {
+			g.Go(func() error {
+				page := c.fetch(gctx, link, depth)
+				if gctx.Err() != nil {
+					return gctx.Err() // cancelled: stop the whole level
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				pages = append(pages, page)
+				if depth == c.MaxDepth {
+					return nil
+				}
+				for _, l := range page.Links {
+					u, _ := url.Parse(l)
+					if c.SameHost && !hosts[u.Host] {
+						continue
+					}
+					if c.visited.add(l) {
+						next = append(next, l)
+					}
+				}
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			return sortPages(pages), err
+		}
+		level = next
+	}
+	return sortPages(pages), ctx.Err()
+}
+
+func sortPages(pages []Page) []Page {
+	sort.Slice(pages, func(i, j int) bool { return pages[i].URL < pages[j].URL })
+	return pages
+}
+
+func (c *Crawler) fetch(ctx context.Context, link string, depth int) Page {
+	page := Page{URL: link, Depth: depth}
+	start := time.Now()
+	defer func() { page.Duration = time.Since(start) }()
+
+	u, _ := url.Parse(link)
+	if err := c.limiter.wait(ctx, u.Host); err != nil {
+		page.Error = err.Error()
+		return page
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		page.Error = err.Error()
+		return page
+	}
+	req.Header.Set("User-Agent", "learn-golang-crawler/1.0")
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		page.Error = err.Error()
+		return page
+	}
+	defer resp.Body.Close()
+	page.Status = resp.StatusCode
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return page
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.MaxBody))
+	if err != nil {
+		page.Error = err.Error()
+		return page
+	}
+	page.Title = title(body)
+	// resolve against the final URL, redirects may have changed it.
+	page.Links = links(resp.Request.URL, body)
+	return page
+}
+
+var (
+	titleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	hrefRe  = regexp.MustCompile(`(?i)<a\s[^>]*href\s*=\s*["']([^"'#]*)`)
+)
+
+func title(body []byte) string {
+	if m := titleRe.FindSubmatch(body); m != nil {
+		return strings.Join(strings.Fields(string(m[1])), " ")
+	}
+	return ""
+}
+
+// links extracts the absolute http(s) links of a page, without duplicates.
+// A regexp is enough for a crawler demo; a real one would use an HTML parser.
+func links(base *url.URL, body []byte) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, m := range hrefRe.FindAllSubmatch(body, -1) {
+		ref, err := url.Parse(strings.TrimSpace(string(m[1])))
+		if err != nil {
+			continue
+		}
+		u := base.ResolveReference(ref)
+		if u.Scheme != "http" && u.Scheme != "https" {
+			continue
+		}
+		if n := normalize(u); !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// normalize drops the fragment and lower-cases the host, so the visited set
+// does not see /a and /a#top as two pages.
+func normalize(u *url.URL) string {
+	n := *u
+	n.Fragment = ""
+	n.Host = strings.ToLower(n.Host)
+	if n.Path == "" {
+		n.Path = "/"
+	}
+	return n.String()
+}
+
+// visitedSet is a set of URLs safe for concurrent use.
+type visitedSet struct {
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// add reports whether url was new. Checking and inserting under the same lock
+// is what makes it safe: two goroutines can never both get true.
+func (v *visitedSet) add(url string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.seen[url] {
+		return false
+	}
+	v.seen[url] = true
+	return true
+}
+
+// hostLimiter hands out request slots per host, delay apart.
+type hostLimiter struct {
+	mu    sync.Mutex
+	delay time.Duration
+	next  map[string]time.Time
+}
+
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[host] = at.Add(l.delay) // reserve the slot before sleeping
+	l.mu.Unlock()
+
+	timer := time.NewTimer(time.Until(at))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
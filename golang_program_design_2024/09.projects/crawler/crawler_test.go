@@ -0,0 +1,180 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+// site is a test server whose pages link to each other, and which records
+// the paths it served, in order, with the time of each request.
+type site struct {
+	*httptest.Server
+	mu   sync.Mutex
+	hits []string
+	at   []time.Time
+}
+
+// newSite serves pages, the links of each path; a path missing from pages
+// is a 404.
+func newSite(t *testing.T, pages map[string][]string) *site {
+	s := &site{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits = append(s.hits, r.URL.Path)
+		s.at = append(s.at, time.Now())
+		s.mu.Unlock()
+		links, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html><title>page %s</title><body>\n", r.URL.Path)
+		for _, l := range links {
+			fmt.Fprintf(w, "<a href=%q>link</a>\n", l)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *site) served() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hits := slices.Clone(s.hits)
+	slices.Sort(hits)
+	return hits
+}
+
+// crawl runs c from the seeds and returns the URLs crawled with their depth.
+func crawl(t *testing.T, c *Crawler, seeds ...string) map[string]int {
+	t.Helper()
+	var pages []Page
+	testutil.RequireDone(t, 10*time.Second, func() {
+		var err error
+		if pages, err = c.Crawl(context.Background(), seeds); err != nil {
+			t.Errorf("Crawl = %v", err)
+		}
+	})
+	got := make(map[string]int)
+	for _, p := range pages {
+		got[p.URL] = p.Depth
+	}
+	return got
+}
+
+func newCrawler() *Crawler {
+	c := New()
+	c.HostDelay = 0
+	return c
+}
+
+// TestDepth follows a chain of links: the pages past MaxDepth are not
+// fetched.
+func TestDepth(t *testing.T) {
+	s := newSite(t, map[string][]string{
+		"/":  {"/a", "/b"},
+		"/a": {"/c"},
+		"/b": {"/c"},
+		"/c": {"/d"},
+		"/d": {"/e"},
+	})
+	for _, tt := range []struct {
+		depth int
+		want  map[string]int
+	}{
+		{0, map[string]int{"/": 0}},
+		{2, map[string]int{"/": 0, "/a": 1, "/b": 1, "/c": 2}},
+		{9, map[string]int{"/": 0, "/a": 1, "/b": 1, "/c": 2, "/d": 3, "/e": 4}},
+	} {
+		c := newCrawler()
+		c.MaxDepth = tt.depth
+		got := crawl(t, c, s.URL)
+		want := make(map[string]int)
+		for path, d := range tt.want {
+			want[s.URL+path] = d
+		}
+		if !maps.Equal(got, want) {
+			t.Errorf("MaxDepth %d: crawled %v, want %v", tt.depth, got, want)
+		}
+	}
+}
+
+// TestDedup links to the same pages in several ways: each is fetched once.
+func TestDedup(t *testing.T) {
+	s := newSite(t, map[string][]string{
+		"/":  {"/a", "/a#top", "a", "./a#end", "/", "#self", "/b"},
+		"/a": {"/b", "/", "/b#x"},
+		"/b": {"/a", "/b"},
+	})
+	got := crawl(t, newCrawler(), s.URL, s.URL+"/", s.URL+"/#top")
+	if len(got) != 3 {
+		t.Errorf("crawled %v, want /, /a and /b", got)
+	}
+	if hits := s.served(); !slices.Equal(hits, []string{"/", "/a", "/b"}) {
+		t.Errorf("served %q, want each page once", hits)
+	}
+}
+
+// TestSameHost links to a second site: it is crawled only without SameHost.
+func TestSameHost(t *testing.T) {
+	other := newSite(t, map[string][]string{"/": {"/x"}, "/x": nil})
+	s := newSite(t, map[string][]string{"/": {other.URL + "/"}})
+	for _, same := range []bool{true, false} {
+		c := newCrawler()
+		c.SameHost = same
+		got := crawl(t, c, s.URL)
+		_, out := got[other.URL+"/"]
+		if _, deep := got[other.URL+"/x"]; out != !same || deep != !same {
+			t.Errorf("SameHost %v: crawled %v", same, got)
+		}
+	}
+}
+
+// TestHostDelay crawls a site with 4 pages by 4 workers: the requests are
+// still HostDelay apart.
+func TestHostDelay(t *testing.T) {
+	s := newSite(t, map[string][]string{"/": {"/a", "/b", "/c"}, "/a": nil, "/b": nil, "/c": nil})
+	c := newCrawler()
+	c.Workers = 4
+	c.HostDelay = 50 * time.Millisecond
+	if got := crawl(t, c, s.URL); len(got) != 4 {
+		t.Fatalf("crawled %v", got)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 1; i < len(s.at); i++ {
+		// the slots are 50ms apart, the requests sent after them by up to
+		// a little scheduling delay.
+		if gap := s.at[i].Sub(s.at[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("request %d came %v after the one before", i, gap)
+		}
+	}
+}
+
+// TestNoWorkers crawls with a zero Workers, which stands for the default.
+func TestNoWorkers(t *testing.T) {
+	s := newSite(t, map[string][]string{"/": {"/a"}, "/a": nil})
+	c := newCrawler()
+	c.Workers = 0
+	if got := crawl(t, c, s.URL); len(got) != 2 {
+		t.Errorf("crawled %v", got)
+	}
+}
+
+func TestInvalidSeed(t *testing.T) {
+	for _, seed := range []string{"ftp://example.com/", "example.com", "http://%zz"} {
+		if _, err := newCrawler().Crawl(context.Background(), []string{seed}); err == nil {
+			t.Errorf("seed %q accepted", seed)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/cron"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+crondemo prints the next run times of a few expressions, then schedules jobs
+that run every minute, one of which panics, until Ctrl-C.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/cron/cmd/crondemo
+	go run ./golang_program_design_2024/09.projects/cron/cmd/crondemo "30 4 1,15 * 5"
+*/
+
+func main() {
+	specs := []string{
+		"*/15 * * * *",   // every quarter hour
+		"0 9-17 * * 1-5", // office hours, on the hour
+		"30 4 1,15 * 5",  // 4:30 on the 1st, the 15th AND every Friday
+		"0 0 29 2 *",     // leap days only
+		"@weekly",
+	}
+	if len(os.Args) > 1 {
+		specs = os.Args[1:]
+	}
+	nextTimes(specs, time.Date(2024, 2, 27, 10, 7, 0, 0, time.UTC))
+
+	log := xlog.New("09.projects", "cron")
+	s := cron.New(log)
+	s.Add("report", "* * * * *", func(ctx context.Context) {
+		log.Info("generating report")
+	})
+	s.Add("flaky", "* * * * *", func(ctx context.Context) {
+		var m map[string]int
+		m["boom"]++ // panics: assignment to entry in nil map, see mapInit in 02.data_struct
+	})
+	s.Add("slow", "*/2 * * * *", func(ctx context.Context) {
+		select {
+		case <-time.After(90 * time.Second):
+		case <-ctx.Done():
+			log.Info("slow job cancelled")
+		}
+	})
+	for _, e := range s.Entries() {
+		fmt.Printf("%-8s next %s\n", e.Name, e.Next.Format(time.DateTime))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Println("running, press Ctrl-C to stop")
+	s.Run(ctx)
+	fmt.Println("all jobs stopped")
+}
+
+func nextTimes(specs []string, from time.Time) {
+	fmt.Printf("next runs after %s:\n", from.Format("Mon 2006-01-02 15:04"))
+	for _, spec := range specs {
+		sched, err := cron.Parse(spec)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("%-16q", spec)
+		t := from
+		for i := 0; i < 4; i++ {
+			if t = sched.Next(t); t.IsZero() {
+				break
+			}
+			fmt.Printf("  %s", t.Format("Mon 01-02 15:04"))
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+}
@@ -0,0 +1,260 @@
+// Package cron parses standard five-field cron expressions and runs jobs on
+// their schedule.
+//
+//	┌───────────── minute       0-59
+//	│ ┌─────────── hour         0-23
+//	│ │ ┌───────── day of month 1-31
+//	│ │ │ ┌─────── month        1-12 or JAN-DEC
+//	│ │ │ │ ┌───── day of week  0-6 or SUN-SAT (7 is also Sunday)
+//	│ │ │ │ │
+//	* * * * *
+//
+// Each field accepts "*", a value, a range "1-5", a list "1,15,30" and a step
+// "*/15" or "10-50/10". The macros @yearly, @monthly, @weekly, @daily and
+// @hourly are shorthands for the usual expressions.
+package cron
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed expression. Every field is a bit set: bit n is set if
+// the value n matches.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// when both day fields are restricted, a day matches if either does
+	// (the classic cron rule), otherwise both must match.
+	domStar, dowStar bool
+}
+
+type bounds struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteBounds = bounds{"minute", 0, 59, nil}
+	hourBounds   = bounds{"hour", 0, 23, nil}
+	domBounds    = bounds{"day of month", 1, 31, nil}
+	monthBounds  = bounds{"month", 1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is accepted as Sunday and folded onto 0 after parsing.
+	dowBounds = bounds{"day of week", 0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a five-field expression or a macro.
+func Parse(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if m, ok := macros[strings.ToLower(spec)]; ok {
+		spec = m
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron: expected 5 fields, got %d in %q", len(fields), spec)
+	}
+
+	s := &Schedule{domStar: fields[2] == "*" || fields[2] == "?", dowStar: fields[4] == "*" || fields[4] == "?"}
+	var err error
+	for i, f := range []struct {
+		set *uint64
+		b   bounds
+	}{{&s.minute, minuteBounds}, {&s.hour, hourBounds}, {&s.dom, domBounds}, {&s.month, monthBounds}, {&s.dow, dowBounds}} {
+		if *f.set, err = parseField(fields[i], f.b); err != nil {
+			return nil, err
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// MustParse is Parse for expressions known to be valid, it panics otherwise.
+func MustParse(spec string) *Schedule {
+	s, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("cron: %s: invalid step %q", b.name, stepPart)
+			}
+			step = n
+		}
+
+		var lo, hi int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			lo, hi = b.min, b.max
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = value(from, b); err != nil {
+				return 0, err
+			}
+			if hi, err = value(to, b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("cron: %s: invalid range %q", b.name, rangePart)
+			}
+		default:
+			v, err := value(rangePart, b)
+			if err != nil {
+				return 0, err
+			}
+			// "5/15" means from 5 to the end in steps of 15.
+			lo, hi = v, v
+			if hasStep {
+				hi = b.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+func value(s string, b bounds) (int, error) {
+	if v, ok := b.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < b.min || v > b.max {
+		return 0, fmt.Errorf("cron: %s: %q is not in %d-%d", b.name, s, b.min, b.max)
+	}
+	return v, nil
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<v) != 0
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom, dow := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Next returns the first time strictly after t matching the schedule, in the
+// location of t, or the zero time if there is none (e.g. "0 0 30 2 *").
+//
+// Instead of trying every minute, it moves field by field, from the largest
+// to the smallest: a wrong month skips to the next month, a wrong day to the
+// next midnight, and so on. Building each candidate with time.Date keeps
+// months of different lengths correct.
+//
+// Across DST changes it follows the wall clock, like cron. A time the clocks
+// skip does not exist, so it never matches: "30 2 * * *" does not run on the
+// day the clocks go from 02:00 to 03:00. A time the clocks show twice matches
+// once, the first time, unless the schedule runs every hour: "*/15 * * * *"
+// keeps running every 15 minutes while the clocks go back.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// a schedule that matches at all does within 8 years: leap days are 4 years
+	// apart, but for 2096 and 2104 around the skipped 2100. So give up after 9.
+	limit := t.Year() + 9
+
+	for t.Year() <= limit {
+		if !has(s.month, int(t.Month())) {
+			t = later(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = later(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			// the next hour in real time: time.Date with t.Hour()+1 would
+			// name a wall time that may not exist, and go back to t.
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+		if s.hour != everyHour {
+			if end, ok := repeated(t); ok {
+				t = end
+				continue
+			}
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+const everyHour = 1<<24 - 1
+
+// later returns next, the midnight time.Date made, if it is after t. When
+// the clocks skip midnight, time.Date may pick the instant an hour before
+// it, which can be t or before: then Next goes on at the next hour in real
+// time.
+func later(t, next time.Time) time.Time {
+	if next.After(t) {
+		return next
+	}
+	return t.Add(time.Duration(60-t.Minute()) * time.Minute)
+}
+
+// repeated reports whether the wall clock already showed t's time earlier,
+// before the clocks went back, and returns the end of the repeated span.
+func repeated(t time.Time) (time.Time, bool) {
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return time.Time{}, false
+	}
+	_, offset := t.Zone()
+	_, before := start.Add(-time.Second).Zone()
+	end := start.Add(time.Duration(before-offset) * time.Second)
+	return end, t.Before(end)
+}
+
+// String describes the schedule with the values of each field, handy to check
+// what an expression really means.
+func (s *Schedule) String() string {
+	list := func(set uint64) string {
+		var vals []string
+		for set != 0 {
+			v := bits.TrailingZeros64(set)
+			vals = append(vals, strconv.Itoa(v))
+			set &^= 1 << v
+		}
+		return strings.Join(vals, ",")
+	}
+	return fmt.Sprintf("minute=%s hour=%s dom=%s month=%s dow=%s",
+		list(s.minute), list(s.hour), list(s.dom), list(s.month), list(s.dow))
+}
@@ -0,0 +1,190 @@
+package cron
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // the DST cases need zones the host may not have
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+// at parses a wall time in loc. An offset, "-0400", picks one of the two
+// instants of a wall time the clocks show twice.
+func at(t *testing.T, loc *time.Location, s string) time.Time {
+	t.Helper()
+	layout := "2006-01-02 15:04"
+	if len(s) > len(layout) {
+		layout += " -0700"
+	}
+	v, err := time.ParseInLocation(layout, s, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+type nextCase struct {
+	name, spec string
+	from       string
+	want       []string // the next runs, one after the other; "" for none
+}
+
+func testNext(t *testing.T, loc *time.Location, tests []nextCase) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := MustParse(tt.spec)
+			from := at(t, loc, tt.from)
+			for _, want := range tt.want {
+				done := make(chan time.Time)
+				go func() { done <- s.Next(from) }()
+				var got time.Time
+				select {
+				case got = <-done:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("Next(%v) did not return", from)
+				}
+				if want == "" {
+					if !got.IsZero() {
+						t.Errorf("Next(%v) = %v, want none", from, got)
+					}
+					return
+				}
+				if w := at(t, loc, want); !got.Equal(w) || got.Location() != loc {
+					t.Fatalf("Next(%v) = %v, want %v", from, got, w)
+				}
+				from = got
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	testNext(t, time.UTC, []nextCase{
+		{"every minute", "* * * * *", "2024-05-01 10:00", []string{"2024-05-01 10:01", "2024-05-01 10:02"}},
+		{"strictly after", "0 10 * * *", "2024-05-01 10:00", []string{"2024-05-02 10:00"}},
+		{"step", "*/20 * * * *", "2024-05-01 10:05", []string{"2024-05-01 10:20", "2024-05-01 10:40", "2024-05-01 11:00"}},
+		{"office hours", "0 9-17/4 * * MON-FRI", "2024-05-03 14:00", []string{"2024-05-03 17:00", "2024-05-06 09:00", "2024-05-06 13:00"}},
+		{"end of day", "59 23 * * *", "2024-12-31 23:59", []string{"2025-01-01 23:59"}},
+		{"yearly", "@yearly", "2024-05-01 10:00", []string{"2025-01-01 00:00", "2026-01-01 00:00"}},
+		{"7 is Sunday", "0 0 * * 7", "2024-05-01 00:00", []string{"2024-05-05 00:00", "2024-05-12 00:00"}},
+
+		// month ends: a day a month lacks skips that month.
+		{"31st", "0 0 31 * *", "2024-01-31 00:00", []string{"2024-03-31 00:00", "2024-05-31 00:00", "2024-07-31 00:00", "2024-08-31 00:00"}},
+		{"30th skips February", "0 0 30 * *", "2024-01-30 00:00", []string{"2024-03-30 00:00"}},
+		{"last days of the year", "0 12 28-31 12 *", "2024-12-30 13:00", []string{"2024-12-31 12:00", "2025-12-28 12:00"}},
+		{"February 30th", "0 0 30 2 *", "2024-01-01 00:00", []string{""}},
+		{"February 31st", "0 0 31 2 *", "2024-01-01 00:00", []string{""}},
+
+		// leap days
+		{"February 29th", "0 0 29 2 *", "2024-03-01 00:00", []string{"2028-02-29 00:00", "2032-02-29 00:00"}},
+		{"February 29th, from one", "0 6 29 2 *", "2024-02-29 05:00", []string{"2024-02-29 06:00", "2028-02-29 06:00"}},
+		{"no February 29th in 2100", "0 0 29 2 *", "2097-01-01 00:00", []string{"2104-02-29 00:00", "2108-02-29 00:00"}},
+		{"February 29th, 8 years apart", "0 0 29 2 *", "2096-02-29 00:00", []string{"2104-02-29 00:00"}},
+		{"after February 28th", "0 0 * 2 *", "2023-02-28 00:00", []string{"2024-02-01 00:00"}},
+		{"after February 28th, leap year", "0 0 * 2 *", "2024-02-28 00:00", []string{"2024-02-29 00:00", "2025-02-01 00:00"}},
+
+		// both day fields restricted: a day matches if either does.
+		{"13th or Friday", "0 0 13 * 5", "2024-09-01 00:00", []string{"2024-09-06 00:00", "2024-09-13 00:00", "2024-09-20 00:00", "2024-09-27 00:00", "2024-10-04 00:00", "2024-10-11 00:00", "2024-10-13 00:00"}},
+		{"first week or Monday", "0 0 1-7 * MON", "2024-09-07 00:00", []string{"2024-09-09 00:00", "2024-09-16 00:00", "2024-09-23 00:00", "2024-09-30 00:00", "2024-10-01 00:00"}},
+		// one of them "*": both must match, the "*" matches every day.
+		{"Fridays only", "0 0 * * 5", "2024-09-01 00:00", []string{"2024-09-06 00:00", "2024-09-13 00:00"}},
+		{"13th only", "0 0 13 * *", "2024-09-01 00:00", []string{"2024-09-13 00:00", "2024-10-13 00:00"}},
+		{"? like *", "0 0 13 * ?", "2024-09-01 00:00", []string{"2024-09-13 00:00"}},
+		// a step is a restriction: "*/2" is not "*", so the days OR.
+		{"odd days or Sunday", "0 0 */2 * 0", "2024-09-01 00:00", []string{"2024-09-03 00:00", "2024-09-05 00:00", "2024-09-07 00:00", "2024-09-08 00:00", "2024-09-09 00:00"}},
+		{"Friday 13th has no AND", "0 0 13 2 5", "2026-01-01 00:00", []string{"2026-02-06 00:00", "2026-02-13 00:00", "2026-02-20 00:00"}},
+	})
+}
+
+// TestNextSpringForward: on 2024-03-10 in New York the clocks go from 02:00
+// EST to 03:00 EDT, 02:xx does not exist.
+func TestNextSpringForward(t *testing.T) {
+	testNext(t, mustLoad(t, "America/New_York"), []nextCase{
+		{"daily across the change", "0 9 * * *", "2024-03-09 12:00", []string{"2024-03-10 09:00", "2024-03-11 09:00"}},
+		{"in the gap: skipped that day", "30 2 * * *", "2024-03-09 12:00", []string{"2024-03-11 02:30"}},
+		{"right after the gap", "0 3 * * *", "2024-03-10 00:00", []string{"2024-03-10 03:00"}},
+		{"right before the gap", "59 1 * * *", "2024-03-10 00:00", []string{"2024-03-10 01:59", "2024-03-11 01:59"}},
+		{"hourly", "0 * * * *", "2024-03-10 00:30", []string{"2024-03-10 01:00", "2024-03-10 03:00", "2024-03-10 04:00"}},
+		{"every 20 minutes", "*/20 * * * *", "2024-03-10 01:30", []string{"2024-03-10 01:40", "2024-03-10 03:00", "2024-03-10 03:20"}},
+		{"a range over the gap", "0 1-3 * * *", "2024-03-10 00:00", []string{"2024-03-10 01:00", "2024-03-10 03:00", "2024-03-11 01:00"}},
+		{"only in the gap, on that day only", "0 2 10 3 *", "2024-03-01 00:00", []string{"2025-03-10 02:00"}},
+	})
+}
+
+// TestNextFallBack: on 2024-11-03 in New York the clocks go from 02:00 EDT
+// back to 01:00 EST, 01:xx happens twice.
+func TestNextFallBack(t *testing.T) {
+	testNext(t, mustLoad(t, "America/New_York"), []nextCase{
+		{"daily across the change", "0 9 * * *", "2024-11-02 12:00", []string{"2024-11-03 09:00", "2024-11-04 09:00"}},
+		{"in the overlap: once", "30 1 * * *", "2024-11-02 12:00", []string{"2024-11-03 01:30 -0400", "2024-11-04 01:30"}},
+		{"from the second time", "30 1 * * *", "2024-11-03 01:10 -0500", []string{"2024-11-04 01:30"}},
+		{"a range over the overlap", "0 0-2 * * *", "2024-11-02 23:30", []string{"2024-11-03 00:00", "2024-11-03 01:00 -0400", "2024-11-03 02:00", "2024-11-04 00:00"}},
+		// every hour: the schedule follows real time through both hours.
+		{"hourly", "30 * * * *", "2024-11-03 00:45", []string{"2024-11-03 01:30 -0400", "2024-11-03 01:30 -0500", "2024-11-03 02:30"}},
+		{"every 15 minutes", "*/15 * * * *", "2024-11-03 01:40 -0400", []string{"2024-11-03 01:45 -0400", "2024-11-03 01:00 -0500", "2024-11-03 01:15 -0500"}},
+	})
+}
+
+// TestNextMidnightGap: on 2024-09-08 in Santiago the clocks go from 00:00 to
+// 01:00, the day has no midnight.
+func TestNextMidnightGap(t *testing.T) {
+	testNext(t, mustLoad(t, "America/Santiago"), []nextCase{
+		{"midnight skipped", "0 0 * * *", "2024-09-07 12:00", []string{"2024-09-09 00:00"}},
+		{"first hour of the day", "30 1 * * *", "2024-09-07 12:00", []string{"2024-09-08 01:30", "2024-09-09 01:30"}},
+		{"a day from the day before", "0 12 8 9 *", "2024-09-07 23:30", []string{"2024-09-08 12:00"}},
+		{"a month starting in the gap", "0 12 * 9 *", "2024-08-31 12:00", []string{"2024-09-01 12:00"}},
+		{"day of week", "0 12 * * SUN", "2024-09-07 23:59", []string{"2024-09-08 12:00", "2024-09-15 12:00"}},
+	})
+}
+
+// TestNextHalfHour: Lord Howe Island moves its clocks by 30 minutes, from
+// 02:00 to 02:30 on 2024-10-06 and from 02:00 back to 01:30 on 2024-04-07.
+func TestNextHalfHour(t *testing.T) {
+	testNext(t, mustLoad(t, "Australia/Lord_Howe"), []nextCase{
+		{"in the gap", "15 2 * * *", "2024-10-05 12:00", []string{"2024-10-07 02:15"}},
+		{"after the gap", "45 2 * * *", "2024-10-05 12:00", []string{"2024-10-06 02:45", "2024-10-07 02:45"}},
+		{"in the overlap: once", "45 1 * * *", "2024-04-06 12:00", []string{"2024-04-07 01:45 +1100", "2024-04-08 01:45"}},
+		{"before the overlap", "15 1 * * *", "2024-04-06 12:00", []string{"2024-04-07 01:15", "2024-04-08 01:15"}},
+	})
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string // String(), "" for an error
+	}{
+		{"* * * * *", ""},
+		{"0 0 * * *", "minute=0 hour=0 dom=1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,23,24,25,26,27,28,29,30,31 month=1,2,3,4,5,6,7,8,9,10,11,12 dow=0,1,2,3,4,5,6"},
+		{"5,10-12 */6 1 jan,JUL sun-tue", "minute=5,10,11,12 hour=0,6,12,18 dom=1 month=1,7 dow=0,1,2"},
+		{"5/20 0 1 1 7", "minute=5,25,45 hour=0 dom=1 month=1 dow=0"},
+		{"@weekly", "minute=0 hour=0 dom=1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20,21,22,23,24,25,26,27,28,29,30,31 month=1,2,3,4,5,6,7,8,9,10,11,12 dow=0"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.spec, err)
+			continue
+		}
+		if tt.want != "" && s.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.spec, s, tt.want)
+		}
+	}
+
+	for _, spec := range []string{
+		"", "* * * *", "* * * * * *", "@often",
+		"60 * * * *", "* 24 * * *", "* * 0 * *", "* * 32 * *", "* * * 13 *", "* * * * 8",
+		"*/0 * * * *", "*/x * * * *", "5-1 * * * *", "a * * * *", "* * * foo *", "1-x * * * *",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded", spec)
+		}
+	}
+}
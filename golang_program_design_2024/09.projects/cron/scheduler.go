@@ -0,0 +1,140 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Job is the work run on schedule. The context is cancelled when the
+// scheduler shuts down, long jobs should watch it.
+type Job func(ctx context.Context)
+
+type entry struct {
+	name     string
+	schedule *Schedule
+	job      Job
+	next     time.Time
+}
+
+// Scheduler runs jobs on their schedules. Each run gets its own goroutine,
+// and a panic in a job is recovered and logged instead of taking the whole
+// process down.
+type Scheduler struct {
+	Log *slog.Logger
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries []*entry
+	added   chan struct{}
+	running sync.WaitGroup
+}
+
+func New(log *slog.Logger) *Scheduler {
+	return &Scheduler{Log: log, now: time.Now, added: make(chan struct{}, 1)}
+}
+
+// Add registers job under name. It may be called while Run is running.
+func (s *Scheduler) Add(name, spec string, job Job) error {
+	sched, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	s.mu.Lock()
+	s.entries = append(s.entries, &entry{name: name, schedule: sched, job: job, next: sched.Next(s.now())})
+	s.mu.Unlock()
+
+	// wake Run up, the new job may be due before the one it sleeps for.
+	select {
+	case s.added <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Run dispatches jobs until ctx is done, then waits for the running jobs to
+// return. Jobs see the same ctx, so they are asked to stop too.
+func (s *Scheduler) Run(ctx context.Context) {
+	defer s.running.Wait()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		now := s.now()
+		wait := s.dispatch(ctx, now)
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.added:
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatch starts every due job and returns how long to sleep until the next one.
+func (s *Scheduler) dispatch(ctx context.Context, now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	wait := time.Hour
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			continue // never matches again
+		}
+		if !e.next.After(now) {
+			s.start(ctx, e, e.next)
+			e.next = e.schedule.Next(now)
+		}
+		if d := e.next.Sub(now); !e.next.IsZero() && d < wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+func (s *Scheduler) start(ctx context.Context, e *entry, scheduled time.Time) {
+	s.running.Add(1)
+	go func() {
+		defer s.running.Done()
+		defer func() {
+			if r := recover(); r != nil {
+				s.Log.Error("job panicked", "job", e.name, "panic", r, "stack", string(debug.Stack()))
+			}
+		}()
+		start := time.Now()
+		e.job(ctx)
+		s.Log.Info("job done", "job", e.name, "scheduled", scheduled.Format(time.TimeOnly), "took", time.Since(start))
+	}()
+}
+
+// Entry describes a registered job for listing.
+type Entry struct {
+	Name string
+	Spec string
+	Next time.Time
+}
+
+// Entries returns the jobs ordered by their next run.
+func (s *Scheduler) Entries() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Entry, len(s.entries))
+	for i, e := range s.entries {
+		list[i] = Entry{Name: e.name, Spec: e.schedule.String(), Next: e.next}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Next.Before(list[j].Next) })
+	return list
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/download"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+dl downloads a file in parallel chunks. Interrupt it with Ctrl-C and run the
+same command again: it resumes where it stopped.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/download/cmd/dl -o go.tgz -sha256 <sum> https://go.dev/dl/go1.22.0.linux-amd64.tar.gz
+	go run ./golang_program_design_2024/09.projects/download/cmd/dl -demo
+
+-demo serves 8MB of random data from a slow local server that drops some
+connections, interrupts the first download halfway and resumes it; the
+SHA-256 of the result is checked like that of any download. Its cases are
+tests: go test ./golang_program_design_2024/09.projects/download
+*/
+
+func main() {
+	out := flag.String("o", "", "destination file, the last part of the URL if empty")
+	sum := flag.String("sha256", "", "expected SHA-256, hex encoded")
+	workers := flag.Int("j", 4, "parallel chunks")
+	chunk := flag.Int64("chunk", 1<<20, "chunk size in bytes")
+	demo := flag.Bool("demo", false, "download from a built-in flaky server")
+	flag.Parse()
+
+	log := xlog.New("09.projects", "download")
+	d := download.New(log)
+	d.Workers, d.ChunkSize = *workers, *chunk
+	d.Progress = func(done, total int64) {
+		fmt.Fprintf(os.Stderr, "\r%5.1f%%  %d / %d bytes", 100*float64(done)/float64(total), done, total)
+	}
+
+	if *demo {
+		if err := runDemo(d); err != nil {
+			fmt.Fprintln(os.Stderr)
+			log.Error("demo failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: dl [flags] url")
+		os.Exit(2)
+	}
+	url := flag.Arg(0)
+	dest := *out
+	if dest == "" {
+		dest = filepath.Base(url)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := d.Download(ctx, url, dest, *sum); err != nil {
+		fmt.Fprintln(os.Stderr)
+		log.Error("download failed", "err", err)
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stderr)
+	log.Info("downloaded", "file", dest)
+}
+
+func runDemo(d *download.Downloader) error {
+	data := make([]byte, 8<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	sum := sha256.Sum256(data)
+	want := hex.EncodeToString(sum[:])
+
+	// ServeContent handles Range and If-Range; the ETag makes If-Range work.
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		if n%7 == 0 {
+			// drop the connection in the middle of the body.
+			w.Header().Set("Content-Length", "1000000")
+			w.Write(data[:1000])
+			panic(http.ErrAbortHandler)
+		}
+		w.Header().Set("ETag", `"`+want[:16]+`"`)
+		http.ServeContent(slowWriter{w}, r, "data.bin", time.Time{}, bytes.NewReader(data))
+	}))
+	defer srv.Close()
+
+	dir, err := os.MkdirTemp("", "dl")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	dest := filepath.Join(dir, "data.bin")
+	d.ChunkSize = 1 << 20
+
+	fmt.Println("first attempt, interrupted after 1.5s")
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	err = d.Download(ctx, srv.URL, dest, want)
+	cancel()
+	fmt.Printf("\nfirst attempt: %v\n", err)
+	if progress, err := os.ReadFile(dest + ".progress"); err == nil {
+		fmt.Printf("progress file: %s\n", progress)
+	}
+
+	fmt.Println("\nsecond attempt")
+	before := requests.Load()
+	if err := d.Download(context.Background(), srv.URL, dest, want); err != nil {
+		return err
+	}
+	fmt.Printf("\ndone with %d more requests, checksum verified\n", requests.Load()-before)
+	return nil
+}
+
+// slowWriter limits a response to about 500KB/s, so the download takes long
+// enough to be interrupted.
+type slowWriter struct{ http.ResponseWriter }
+
+func (w slowWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		k := min(len(p), 32<<10)
+		m, err := w.ResponseWriter.Write(p[:k])
+		n += m
+		if err != nil {
+			return n, err
+		}
+		p = p[k:]
+		time.Sleep(64 * time.Millisecond)
+	}
+	return n, nil
+}
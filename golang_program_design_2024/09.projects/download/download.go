@@ -0,0 +1,360 @@
+// Package download fetches a file over HTTP in parallel byte ranges and can
+// resume an interrupted download.
+//
+// The file is written to <dest>.part, grown to its final size up front so
+// that every chunk can be written at its own offset (WriteAt) as it arrives.
+// How far each chunk got is kept in <dest>.progress as JSON, saved every
+// second and when the download stops, so an interrupted download starts
+// again from where each chunk was. The ETag of the remote file is sent with
+// If-Range: if the file changed in the meantime the server answers with the
+// whole new file instead of a range, and the download starts over rather
+// than mixing two versions.
+//
+// At the end the SHA-256 of the file is checked and the .part file renamed
+// to its final name.
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	ErrChecksum      = errors.New("checksum mismatch")
+	ErrRemoteChanged = errors.New("remote file changed during the download")
+)
+
+// Downloader holds the settings. Its zero value is not usable, use New.
+type Downloader struct {
+	Client    *http.Client
+	ChunkSize int64
+	Workers   int
+	Retries   int // attempts per chunk after the first
+	Log       *slog.Logger
+	// Progress, if set, is called about every second with the bytes done.
+	Progress func(done, total int64)
+}
+
+func New(log *slog.Logger) *Downloader {
+	return &Downloader{
+		Client:    &http.Client{},
+		ChunkSize: 1 << 20,
+		Workers:   4,
+		Retries:   3,
+		Log:       log,
+	}
+}
+
+// state is the content of the .progress file.
+type state struct {
+	URL       string  `json:"url"`
+	Size      int64   `json:"size"`
+	ETag      string  `json:"etag"`
+	ChunkSize int64   `json:"chunk_size"`
+	Written   []int64 `json:"written"` // bytes written of each chunk
+}
+
+func (s *state) chunk(i int) (start, end int64) {
+	start = int64(i) * s.ChunkSize
+	return start, min(start+s.ChunkSize, s.Size) - 1
+}
+
+// Download fetches url to dest. If wantSHA256 is not empty the file must
+// match it. Cancelling ctx stops the download, keeping what was fetched for
+// the next call.
+func (d *Downloader) Download(ctx context.Context, url, dest, wantSHA256 string) error {
+	size, etag, ranges, err := d.probe(ctx, url)
+	if err != nil {
+		return err
+	}
+	partPath, progressPath := dest+".part", dest+".progress"
+
+	st := d.resume(progressPath, url, size, etag)
+	if st == nil || !ranges {
+		st = &state{URL: url, Size: size, ETag: etag, ChunkSize: d.ChunkSize}
+		if !ranges {
+			st.ChunkSize = max(size, 1) // one chunk, fetched with a plain GET
+		}
+		st.Written = make([]int64, (size+st.ChunkSize-1)/st.ChunkSize)
+		os.Remove(partPath)
+	} else {
+		d.Log.Info("resuming", "done", st.done(), "size", size)
+	}
+
+	f, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Truncate(size); err != nil {
+		return err
+	}
+
+	// save the progress regularly and once more on the way out, whatever
+	// happened: a cancelled download is exactly the one to resume.
+	p := &progress{st: st, path: progressPath}
+	stopSaving := p.saveEvery(time.Second, d.Progress)
+	err = d.fetchChunks(ctx, f, p, ranges)
+	stopSaving()
+	if saveErr := p.save(); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	if err != nil {
+		if errors.Is(err, ErrRemoteChanged) {
+			os.Remove(progressPath) // start from scratch next time
+		}
+		return err
+	}
+
+	if err := verify(f, wantSHA256); err != nil {
+		os.Remove(progressPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(partPath, dest); err != nil {
+		return err
+	}
+	return os.Remove(progressPath)
+}
+
+// probe asks for the first byte, to learn the size, the ETag and whether
+// ranges are supported in one request (some servers do not answer HEAD).
+func (d *Downloader) probe(ctx context.Context, url string) (size int64, etag string, ranges bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, "", false, err
+	}
+	req.Header.Set("Range", "bytes=0-0")
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, "", false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		// Content-Range: bytes 0-0/12345
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes 0-0/%d", &size); err != nil {
+			return 0, "", false, fmt.Errorf("unexpected Content-Range %q", resp.Header.Get("Content-Range"))
+		}
+		return size, resp.Header.Get("ETag"), true, nil
+	case http.StatusOK:
+		if resp.ContentLength < 0 {
+			return 0, "", false, errors.New("the server sends neither ranges nor a length")
+		}
+		return resp.ContentLength, resp.Header.Get("ETag"), false, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		// not even a first byte: an empty file, "Content-Range: bytes */0".
+		if resp.Header.Get("Content-Range") == "bytes */0" {
+			return 0, resp.Header.Get("ETag"), false, nil
+		}
+	}
+	return 0, "", false, fmt.Errorf("GET %s: %s", url, resp.Status)
+}
+
+// resume loads the progress of an earlier attempt, if it is for the same file.
+func (d *Downloader) resume(path, url string, size int64, etag string) *state {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		d.Log.Warn("ignoring unreadable progress file", "err", err)
+		return nil
+	}
+	if st.URL != url || st.Size != size || st.ETag != etag || etag == "" || st.ChunkSize <= 0 ||
+		int64(len(st.Written)) != (size+st.ChunkSize-1)/st.ChunkSize {
+		d.Log.Info("remote file differs from the interrupted download, starting over")
+		return nil
+	}
+	return &st
+}
+
+func (d *Downloader) fetchChunks(ctx context.Context, f *os.File, p *progress, ranges bool) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(d.Workers)
+	for i := range p.st.Written {
+		start, end := p.st.chunk(i)
+		if p.written(i) > end-start {
+			continue // already complete
+		}
+		g.Go(func() error {
+			var err error
+			for attempt := 0; attempt <= d.Retries; attempt++ {
+				if attempt > 0 {
+					d.Log.Warn("retrying chunk", "chunk", i, "attempt", attempt, "err", err)
+					select {
+					case <-time.After(time.Duration(attempt) * 200 * time.Millisecond):
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+				// every attempt continues where the previous one stopped.
+				if err = d.fetchChunk(ctx, f, p, i, ranges); err == nil || ctx.Err() != nil || errors.Is(err, ErrRemoteChanged) {
+					return err
+				}
+			}
+			return fmt.Errorf("chunk %d: %w", i, err)
+		})
+	}
+	return g.Wait()
+}
+
+func (d *Downloader) fetchChunk(ctx context.Context, f *os.File, p *progress, i int, ranges bool) error {
+	start, end := p.st.chunk(i)
+	from := start + p.written(i)
+	if !ranges {
+		from = start
+		p.set(i, 0) // a plain GET always starts at the beginning
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.st.URL, nil)
+	if err != nil {
+		return err
+	}
+	if ranges {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", from, end))
+		if p.st.ETag != "" {
+			req.Header.Set("If-Range", p.st.ETag)
+		}
+	}
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case ranges && resp.StatusCode == http.StatusOK:
+		return ErrRemoteChanged // If-Range failed: this is the whole new file
+	case ranges && resp.StatusCode != http.StatusPartialContent,
+		!ranges && resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("GET %s: %s", p.st.URL, resp.Status)
+	}
+
+	buf := make([]byte, 32<<10)
+	off := from
+	for off <= end {
+		n, err := resp.Body.Read(buf[:min(int64(len(buf)), end-off+1)])
+		if n > 0 {
+			if _, werr := f.WriteAt(buf[:n], off); werr != nil {
+				return werr
+			}
+			off += int64(n)
+			p.set(i, off-start)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if off <= end {
+		return fmt.Errorf("chunk %d: short body, %d bytes missing", i, end-off+1)
+	}
+	return nil
+}
+
+func verify(f *os.File, want string) error {
+	if want == "" {
+		return nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, 1<<62)); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: got %s, want %s", ErrChecksum, got, want)
+	}
+	return nil
+}
+
+// progress guards the state shared by the chunk workers and the saver.
+type progress struct {
+	mu   sync.Mutex
+	st   *state
+	path string
+}
+
+func (p *progress) written(i int) int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.st.Written[i]
+}
+
+func (p *progress) set(i int, n int64) {
+	p.mu.Lock()
+	p.st.Written[i] = n
+	p.mu.Unlock()
+}
+
+func (s *state) done() int64 {
+	var n int64
+	for _, w := range s.Written {
+		n += w
+	}
+	return n
+}
+
+// save writes the progress file atomically: a crash while saving leaves the
+// previous version, never half a JSON document.
+func (p *progress) save() error {
+	p.mu.Lock()
+	data, err := json.Marshal(p.st)
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p.path)
+}
+
+// saveEvery saves and reports the progress every interval until the
+// returned function is called.
+func (p *progress) saveEvery(interval time.Duration, report func(done, total int64)) (stop func()) {
+	quit := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+				p.save()
+				if report != nil {
+					p.mu.Lock()
+					done := p.st.done()
+					p.mu.Unlock()
+					report(done, p.st.Size)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,306 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// remote serves one file through http.ServeContent, with faults to inject.
+type remote struct {
+	mu      sync.Mutex
+	content []byte
+	// noRanges ignores the Range header, like a server without range support.
+	noRanges bool
+	// cut aborts every response after that many bytes of body, if > 0.
+	cut int64
+	// fail answers the next fail range requests with a 500.
+	fail int
+	// onProbe runs after the answer to the first-byte probe.
+	onProbe func()
+
+	served int64 // body bytes sent by the responses that were not cut
+}
+
+func (rm *remote) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rm.mu.Lock()
+	content, cut, onProbe := rm.content, rm.cut, rm.onProbe
+	probe := r.Header.Get("Range") == "bytes=0-0"
+	failing := !probe && rm.fail > 0
+	if failing {
+		rm.fail--
+	}
+	rm.mu.Unlock()
+
+	if failing {
+		http.Error(w, "try again", http.StatusInternalServerError)
+		return
+	}
+	if rm.noRanges {
+		r.Header.Del("Range")
+		r.Header.Del("If-Range")
+	}
+	sum := sha256.Sum256(content)
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sum[:8]))
+	if len(content) == 0 && r.Header.Get("Range") != "" {
+		// what nginx and S3 answer for a range of an empty file; ServeContent
+		// ignores the range and sends a 200.
+		w.Header().Set("Content-Range", "bytes */0")
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		return
+	}
+	cw := &countingWriter{ResponseWriter: w, rm: rm, cut: cut > 0, left: cut}
+	http.ServeContent(cw, r, "file.bin", time.Time{}, bytes.NewReader(content))
+	if probe && onProbe != nil {
+		onProbe()
+	}
+	if cut > 0 && cw.left <= 0 {
+		panic(http.ErrAbortHandler) // the client sees a connection cut mid-body
+	}
+}
+
+type countingWriter struct {
+	http.ResponseWriter
+	rm   *remote
+	cut  bool
+	left int64 // bytes before the cut
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	if !w.cut {
+		w.rm.mu.Lock()
+		w.rm.served += int64(len(p))
+		w.rm.mu.Unlock()
+		return w.ResponseWriter.Write(p)
+	}
+	if w.left <= 0 {
+		return 0, io.ErrShortWrite
+	}
+	p = p[:min(int64(len(p)), w.left)]
+	w.left -= int64(len(p))
+	return w.ResponseWriter.Write(p)
+}
+
+func (rm *remote) set(fn func(rm *remote)) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	fn(rm)
+}
+
+func randomContent(n int) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(int64(n))).Read(b)
+	return b
+}
+
+func sha(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func newDownloader() *Downloader {
+	d := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	d.ChunkSize = 64 << 10
+	d.Workers = 4
+	return d
+}
+
+// serve starts rm until the test ends and returns the URL of its file.
+func serve(t *testing.T, rm *remote) string {
+	srv := httptest.NewServer(rm)
+	t.Cleanup(srv.Close)
+	return srv.URL + "/file.bin"
+}
+
+func fetch(d *Downloader, url, dest, want string) error {
+	return d.Download(context.Background(), url, dest, want)
+}
+
+// requireFile checks the downloaded file and that nothing is left beside it.
+func requireFile(t *testing.T, dest string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%d bytes downloaded, want %d, or they differ", len(got), len(want))
+	}
+	for _, leftover := range []string{".part", ".progress", ".progress.tmp"} {
+		if _, err := os.Stat(dest + leftover); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s left behind: %v", leftover, err)
+		}
+	}
+}
+
+func TestDownload(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		size     int
+		noRanges bool
+	}{
+		{"chunks", 1<<20 + 123, false},
+		{"one chunk", 1000, false},
+		{"exactly chunks", 4 << 16, false},
+		{"one byte", 1, false},
+		{"empty", 0, false},
+		{"no ranges", 300 << 10, true},
+		{"empty, no ranges", 0, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			content := randomContent(tt.size)
+			rm := &remote{content: content, noRanges: tt.noRanges}
+			url := serve(t, rm)
+			dest := filepath.Join(t.TempDir(), "file.bin")
+			if err := fetch(newDownloader(), url, dest, sha(content)); err != nil {
+				t.Fatal(err)
+			}
+			requireFile(t, dest, content)
+		})
+	}
+}
+
+// TestEmptyFileProbe checks the answer the probe gets for an empty file: no
+// byte 0 to send, a 416 with "Content-Range: bytes */0".
+func TestEmptyFileProbe(t *testing.T) {
+	srv := httptest.NewServer(&remote{content: nil})
+	defer srv.Close()
+	size, _, ranges, err := newDownloader().probe(context.Background(), srv.URL)
+	if err != nil || size != 0 || ranges {
+		t.Errorf("probe = %d, %v, %v; want an empty file", size, ranges, err)
+	}
+
+	// a 416 for a file that has a first byte is still an error.
+	odd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "bytes */10")
+		w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+	}))
+	defer odd.Close()
+	if _, _, _, err := newDownloader().probe(context.Background(), odd.URL); err == nil {
+		t.Error("probe of a 416 for 10 bytes succeeded")
+	}
+}
+
+func TestRetryChunk(t *testing.T) {
+	content := randomContent(256 << 10)
+	rm := &remote{content: content, fail: 3}
+	url := serve(t, rm)
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	if err := fetch(newDownloader(), url, dest, sha(content)); err != nil {
+		t.Fatal(err)
+	}
+	requireFile(t, dest, content)
+}
+
+// TestResume cuts every response short on the first run, then downloads
+// again: the second run only fetches what the first one did not get.
+func TestResume(t *testing.T) {
+	content := randomContent(1 << 20)
+	rm := &remote{content: content, cut: 20 << 10}
+	url := serve(t, rm)
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	d := newDownloader()
+	d.Retries = 0
+	if err := fetch(d, url, dest, sha(content)); err == nil {
+		t.Fatal("the first run succeeded with every response cut")
+	}
+	data, err := os.ReadFile(dest + ".progress")
+	if err != nil {
+		t.Fatalf("no progress after the failed run: %v", err)
+	}
+	var st state
+	if err := json.Unmarshal(data, &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.done() == 0 {
+		t.Fatal("the first run saved no progress")
+	}
+
+	rm.set(func(rm *remote) { rm.cut = 0 })
+	if err := fetch(d, url, dest, sha(content)); err != nil {
+		t.Fatal(err)
+	}
+	requireFile(t, dest, content)
+	// the probe sends 1 byte, the chunks the rest.
+	var served int64
+	rm.set(func(rm *remote) { served = rm.served })
+	if want := int64(len(content)) - st.done() + 1; served != want {
+		t.Errorf("the second run fetched %d bytes, want the %d missing", served, want)
+	}
+}
+
+// TestResumeOtherFile changes the remote file between two runs: the progress
+// of the first is for another version, the second run starts over.
+func TestResumeOtherFile(t *testing.T) {
+	old := randomContent(512 << 10)
+	rm := &remote{content: old, cut: 20 << 10}
+	url := serve(t, rm)
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	d := newDownloader()
+	d.Retries = 0
+	fetch(d, url, dest, "")
+
+	fresh := bytes.Repeat([]byte("new version "), 40000)
+	rm.set(func(rm *remote) { rm.content, rm.cut = fresh, 0 })
+	if err := fetch(d, url, dest, sha(fresh)); err != nil {
+		t.Fatal(err)
+	}
+	requireFile(t, dest, fresh)
+}
+
+// TestRemoteChanged changes the file right after the probe: the chunk
+// requests carry the old ETag in If-Range and get the whole new file.
+func TestRemoteChanged(t *testing.T) {
+	old := randomContent(256 << 10)
+	fresh := randomContent(256<<10 + 1)
+	rm := &remote{content: old}
+	url := serve(t, rm)
+	rm.onProbe = func() { rm.set(func(rm *remote) { rm.content, rm.onProbe = fresh, nil }) }
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	if err := fetch(newDownloader(), url, dest, ""); !errors.Is(err, ErrRemoteChanged) {
+		t.Fatalf("Download = %v, want ErrRemoteChanged", err)
+	}
+	if _, err := os.Stat(dest + ".progress"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the progress of the old version was kept: %v", err)
+	}
+
+	if err := fetch(newDownloader(), url, dest, sha(fresh)); err != nil {
+		t.Fatal(err)
+	}
+	requireFile(t, dest, fresh)
+}
+
+func TestChecksum(t *testing.T) {
+	content := randomContent(100 << 10)
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	err := fetch(newDownloader(), serve(t, &remote{content: content}), dest, sha([]byte("something else")))
+	if !errors.Is(err, ErrChecksum) {
+		t.Fatalf("Download = %v, want ErrChecksum", err)
+	}
+	if _, err := os.Stat(dest); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("the file with the wrong checksum was renamed into place: %v", err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	dest := filepath.Join(t.TempDir(), "file.bin")
+	if err := newDownloader().Download(context.Background(), srv.URL, dest, ""); err == nil {
+		t.Error("Download of a 404 succeeded")
+	}
+}
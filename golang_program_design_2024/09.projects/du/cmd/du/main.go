@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/du"
+)
+
+/*
+du prints the largest directories below a path.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/du/cmd/du -n 10 ~/go
+	go run ./golang_program_design_2024/09.projects/du/cmd/du -L -j 64 /mnt/nfs
+
+Ctrl-C stops the walk and prints what was counted so far.
+*/
+
+func main() {
+	top := flag.Int("n", 20, "number of directories to list")
+	follow := flag.Bool("L", false, "follow symbolic links")
+	workers := flag.Int("j", 0, "concurrent stat calls, 0 for 4 per CPU")
+	verbose := flag.Bool("v", false, "list unreadable paths and skipped links")
+	flag.Parse()
+
+	root := "."
+	if flag.NArg() > 0 {
+		root = flag.Arg(0)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+	report, err := du.Scan(ctx, root, du.Options{Workers: *workers, FollowSymlinks: *follow})
+	if report == nil {
+		fmt.Fprintln(os.Stderr, "du:", err)
+		os.Exit(1)
+	}
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "interrupted, partial results:")
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "size\tfiles\t\t")
+	for _, d := range report.Top(*top) {
+		fmt.Fprintf(tw, "%s\t%d\t\t%s\n", human(d.Size), d.Files, d.Path)
+	}
+	tw.Flush()
+	fmt.Printf("\n%s in %d files, %d directories, %v\n",
+		human(report.Total), report.Files, len(report.Dirs), time.Since(start).Round(time.Millisecond))
+
+	if len(report.Errors) > 0 || len(report.Skipped) > 0 {
+		fmt.Printf("%d unreadable paths, %d links skipped (-v to list)\n", len(report.Errors), len(report.Skipped))
+	}
+	if *verbose {
+		for _, err := range report.Errors {
+			fmt.Println("  error:", err)
+		}
+		for _, s := range report.Skipped {
+			fmt.Println("  already counted:", s)
+		}
+	}
+}
+
+func human(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,237 @@
+// Package du computes directory sizes concurrently.
+//
+// One goroutine walks the tree with filepath.WalkDir and sends every file to
+// a pool of workers, which stat it. A collector adds each size to the file's
+// directory and all its parents up to the root. The walker only reads
+// directories, and on a slow or network disk the stat calls are where the
+// time goes, so they are the part worth spreading over several goroutines.
+//
+// Symbolic links are counted as links (their own tiny size) unless
+// FollowSymlinks is set. Following links can loop (a link to a parent) or
+// count a tree twice (two links to the same directory), so every directory
+// reached through a link is resolved to its real path and skipped when that
+// path lies in a tree already walked.
+package du
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Options struct {
+	Workers        int
+	FollowSymlinks bool
+}
+
+// DirSize is the total size of the files below a directory.
+type DirSize struct {
+	Path  string
+	Size  int64
+	Files int
+}
+
+type Report struct {
+	Root  string
+	Total int64
+	Files int
+	Dirs  []DirSize // largest first
+	// Errors holds the paths that could not be read; the totals leave them out.
+	Errors []error
+	// Skipped lists the followed links that pointed into a tree already counted.
+	Skipped []string
+}
+
+// Top returns the n largest directories.
+func (r *Report) Top(n int) []DirSize {
+	return r.Dirs[:min(n, len(r.Dirs))]
+}
+
+type fileJob struct {
+	path   string // where the file is read from
+	dir    string // the directory it is counted in, as seen from the root
+	follow bool   // a link to follow rather than a regular file
+}
+
+type fileSize struct {
+	dir  string
+	size int64
+	err  error
+}
+
+// Scan walks root and returns its report. Cancelling ctx stops the walk, the
+// report then only covers what was seen.
+func Scan(ctx context.Context, root string, opts Options) (*Report, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU() * 4 // mostly waiting on the disk
+	}
+	root = filepath.Clean(root)
+	// WalkDir does not follow a root that is itself a link, walk its target.
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make(chan fileJob, opts.Workers)
+	sizes := make(chan fileSize, opts.Workers)
+	w := &walker{ctx: ctx, jobs: jobs, follow: opts.FollowSymlinks}
+
+	var walkErr error
+	go func() {
+		defer close(jobs)
+		walkErr = w.walk(root, real)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				sizes <- stat(j)
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(sizes)
+	}()
+
+	// the collector: only this goroutine touches the maps.
+	report := &Report{Root: root}
+	totals := make(map[string]*DirSize)
+	for s := range sizes {
+		if s.err != nil {
+			report.Errors = append(report.Errors, s.err)
+			continue
+		}
+		report.Total += s.size
+		report.Files++
+		for dir := s.dir; ; dir = filepath.Dir(dir) {
+			d := totals[dir]
+			if d == nil {
+				d = &DirSize{Path: dir}
+				totals[dir] = d
+			}
+			d.Size += s.size
+			d.Files++
+			if dir == root || dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+
+	// the walker is done once jobs is closed, which happened before sizes was.
+	report.Errors = append(report.Errors, w.errs...)
+	report.Skipped = w.skipped
+	for _, d := range totals {
+		report.Dirs = append(report.Dirs, *d)
+	}
+	sort.Slice(report.Dirs, func(i, j int) bool {
+		if report.Dirs[i].Size != report.Dirs[j].Size {
+			return report.Dirs[i].Size > report.Dirs[j].Size
+		}
+		return report.Dirs[i].Path < report.Dirs[j].Path
+	})
+	if walkErr == nil {
+		walkErr = ctx.Err()
+	}
+	return report, walkErr
+}
+
+func stat(j fileJob) fileSize {
+	var info fs.FileInfo
+	var err error
+	if j.follow {
+		info, err = os.Stat(j.path)
+	} else {
+		info, err = os.Lstat(j.path)
+	}
+	if err != nil {
+		return fileSize{err: err}
+	}
+	return fileSize{dir: j.dir, size: info.Size()}
+}
+
+type walker struct {
+	ctx    context.Context
+	jobs   chan<- fileJob
+	follow bool
+
+	walked  []string // real paths of the trees walked so far
+	errs    []error
+	skipped []string
+}
+
+// walk walks the real directory dir, naming what it finds after the logical
+// path shown in the report (they differ below a followed link).
+func (w *walker) walk(logical, dir string) error {
+	if real, err := filepath.EvalSymlinks(dir); err == nil {
+		if abs, err := filepath.Abs(real); err == nil {
+			w.walked = append(w.walked, abs)
+		}
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			w.errs = append(w.errs, err)
+			return nil
+		}
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+		name := logical
+		if rel, _ := filepath.Rel(dir, path); rel != "." {
+			name = filepath.Join(logical, rel)
+		}
+		switch {
+		case d.IsDir():
+			return nil
+		case d.Type()&fs.ModeSymlink != 0 && w.follow:
+			return w.followLink(name, path)
+		}
+		return w.send(fileJob{path: path, dir: filepath.Dir(name)})
+	})
+}
+
+func (w *walker) followLink(name, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		w.errs = append(w.errs, err) // a dangling link
+		return nil
+	}
+	if !info.IsDir() {
+		return w.send(fileJob{path: path, dir: filepath.Dir(name), follow: true})
+	}
+
+	real, err := filepath.EvalSymlinks(path)
+	if err == nil {
+		real, err = filepath.Abs(real)
+	}
+	if err != nil {
+		w.errs = append(w.errs, err)
+		return nil
+	}
+	for _, t := range w.walked {
+		if real == t || strings.HasPrefix(real, t+string(filepath.Separator)) {
+			w.skipped = append(w.skipped, name+" -> "+real)
+			return nil
+		}
+	}
+	// walk the target under its own real path, so links inside it are seen
+	// as links again. The walk only fails when ctx is done.
+	return w.walk(name, real)
+}
+
+func (w *walker) send(j fileJob) error {
+	select {
+	case w.jobs <- j:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
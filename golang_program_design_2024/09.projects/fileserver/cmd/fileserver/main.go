@@ -0,0 +1,63 @@
+package main
+
+import (
+	"embed"
+	"flag"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/fileserver"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/fileserver/cmd/fileserver            // embedded assets
+	go run ./golang_program_design_2024/09.projects/fileserver/cmd/fileserver -dir .     // a directory
+
+	curl -i localhost:8080/                                   // 200, ETag and Last-Modified
+	curl -i localhost:8080/ -H 'If-None-Match: "<etag>"'      // 304
+	curl -i localhost:8080/ -H 'Range: bytes=0-15'            // 206
+	curl -i localhost:8080/style.css -H 'Accept-Encoding: gzip' -o /dev/null
+*/
+
+// the files under static/ are compiled into the binary.
+//
+//go:embed static
+var static embed.FS
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "listen address")
+	dir := flag.String("dir", "", "serve this directory instead of the embedded assets")
+	maxAge := flag.Duration("max-age", 0, "Cache-Control max-age, 0 means always revalidate")
+	flag.Parse()
+
+	log := xlog.New("09.projects", "fileserver")
+
+	var fsys fs.FS
+	if *dir != "" {
+		fsys = os.DirFS(*dir)
+	} else {
+		// strip the "static/" prefix so the files are served from the root.
+		sub, err := fs.Sub(static, "static")
+		if err != nil {
+			log.Error("embedded assets", "err", err)
+			os.Exit(1)
+		}
+		fsys = sub
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           fileserver.New(fsys, *maxAge),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	log.Info("listening", "addr", *addr, "dir", *dir)
+	if err := srv.ListenAndServe(); err != nil {
+		log.Error("serve failed", "err", err)
+		os.Exit(1)
+	}
+}
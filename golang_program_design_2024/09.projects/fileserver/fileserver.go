@@ -0,0 +1,237 @@
+// Package fileserver serves the files of an fs.FS (a directory via os.DirFS
+// or assets bundled with embed.FS) with the HTTP caching features browsers
+// rely on:
+//
+//   - ETag (a hash of the content) and Last-Modified, answering conditional
+//     requests (If-None-Match, If-Modified-Since) with 304 Not Modified,
+//   - Range requests (206 Partial Content) for resumable downloads and video,
+//   - gzip when the client accepts it and the file type compresses well,
+//   - no way out of the root: "..", absolute paths and backslashes are rejected.
+//
+// Conditional and range handling come from http.ServeContent, reading the
+// file itself: a large file is streamed, never held in memory. This package
+// provides the validators and the compressed variants, and caches them for
+// the most recently served files.
+package fileserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/lru"
+)
+
+const (
+	// cacheEntries bounds the cache of validators: the files served least
+	// recently are computed again when asked for.
+	cacheEntries = 512
+	// maxGzipSize is the largest file compressed in advance. The assets of
+	// a web page are below it; a larger text file is served as it is.
+	maxGzipSize = 256 << 10
+)
+
+// Handler serves files from FS.
+type Handler struct {
+	FS     fs.FS
+	MaxAge time.Duration // Cache-Control max-age, zero means "no-cache" (always revalidate)
+
+	cache *lru.Sync[string, *entry]
+}
+
+// entry holds the validators of a file, and its gzip form if it is small
+// and compresses well. It is reused as long as the file keeps the same size
+// and modification time.
+type entry struct {
+	modTime time.Time
+	size    int64
+	etag    string
+	gzipped []byte // nil if the type does not compress, the file is large, or gzip did not help
+}
+
+func New(fsys fs.FS, maxAge time.Duration) *Handler {
+	return &Handler{FS: fsys, MaxAge: maxAge, cache: lru.NewSync(lru.New[string, *entry](cacheEntries))}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, ok := cleanPath(r.URL.Path)
+	if !ok {
+		http.Error(w, "invalid path", http.StatusBadRequest)
+		return
+	}
+	f, name, info, err := h.open(name)
+	if err != nil {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+	e, err := h.load(name, info)
+	if err != nil {
+		http.Error(w, "read error", http.StatusInternalServerError)
+		return
+	}
+
+	header := w.Header()
+	if h.MaxAge > 0 {
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.MaxAge.Seconds())))
+	} else {
+		header.Set("Cache-Control", "no-cache")
+	}
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		header.Set("Content-Type", ctype)
+	}
+
+	if e.gzipped != nil {
+		// caches must keep one copy per Accept-Encoding.
+		header.Add("Vary", "Accept-Encoding")
+		// a range of a gzip stream is useless to most clients, serve ranges uncompressed.
+		if acceptsGzip(r) && r.Header.Get("Range") == "" {
+			header.Set("ETag", strings.TrimSuffix(e.etag, `"`)+`-gzip"`)
+			header.Set("Content-Encoding", "gzip")
+			http.ServeContent(w, r, name, e.modTime, bytes.NewReader(e.gzipped))
+			return
+		}
+	}
+	header.Set("ETag", e.etag)
+
+	// ServeContent handles If-None-Match, If-Modified-Since, Range and HEAD,
+	// and reads only the bytes it sends: a Range of a video reads that range.
+	content, err := seekable(f, e.size)
+	if err != nil {
+		http.Error(w, "read error", http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, name, e.modTime, content)
+}
+
+// cleanPath turns a URL path into an fs.FS name. fs.ValidPath rejects "..",
+// empty elements and leading slashes, so nothing outside the root is reachable.
+func cleanPath(urlPath string) (string, bool) {
+	if strings.Contains(urlPath, "\\") || strings.Contains(urlPath, "\x00") {
+		return "", false
+	}
+	// reject ".." before path.Clean, which would quietly resolve it.
+	for _, elem := range strings.Split(urlPath, "/") {
+		if elem == ".." {
+			return "", false
+		}
+	}
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		name = "."
+	}
+	return name, fs.ValidPath(name)
+}
+
+// open opens the regular file name, or the index.html of the directory
+// name, and returns it with its name and its FileInfo.
+func (h *Handler) open(name string) (fs.File, string, fs.FileInfo, error) {
+	info, err := fs.Stat(h.FS, name)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if info.IsDir() {
+		name = path.Join(name, "index.html")
+	}
+	f, err := h.FS.Open(name)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	// the FileInfo of the open file: the file may have changed since Stat.
+	if info, err = f.Stat(); err != nil || !info.Mode().IsRegular() {
+		f.Close()
+		return nil, "", nil, fs.ErrNotExist
+	}
+	return f, name, info, nil
+}
+
+// load returns the cached entry of name, computing it when it is new or the
+// file has changed. The file is read on its own, the one being served is
+// left where it is.
+func (h *Handler) load(name string, info fs.FileInfo) (*entry, error) {
+	if e, ok := h.cache.Get(name); ok && e.size == info.Size() && e.modTime.Equal(info.ModTime()) {
+		return e, nil
+	}
+
+	f, err := h.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	e := &entry{modTime: info.ModTime(), size: info.Size()}
+	hash := sha256.New()
+	if compressible(name) && info.Size() <= maxGzipSize {
+		content, err := io.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+		hash.Write(content)
+		e.gzipped = compress(content)
+	} else if _, err := io.Copy(hash, f); err != nil { // streamed, whatever the size
+		return nil, err
+	}
+	e.etag = `"` + hex.EncodeToString(hash.Sum(nil)[:8]) + `"`
+
+	h.cache.Put(name, e)
+	return e, nil
+}
+
+// seekable returns f as the io.ReadSeeker ServeContent needs. The files of
+// os.DirFS, embed.FS and fstest.MapFS are seekers; the rare fs.FS whose
+// files are not (a zip archive's) has the file read in memory, for this
+// request only.
+func seekable(f fs.File, size int64) (io.ReadSeeker, error) {
+	if rs, ok := f.(io.ReadSeeker); ok {
+		return rs, nil
+	}
+	content, err := io.ReadAll(io.LimitReader(f, size))
+	return bytes.NewReader(content), err
+}
+
+func compressible(name string) bool {
+	switch path.Ext(name) {
+	case ".html", ".css", ".js", ".json", ".svg", ".txt", ".xml", ".md", ".go":
+		return true
+	}
+	return false // images, archives and videos are already compressed
+}
+
+// compress returns the gzip form of content, or nil if it is not smaller.
+func compress(content []byte) []byte {
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(content)
+	zw.Close()
+	if buf.Len() >= len(content) {
+		return nil
+	}
+	return buf.Bytes()
+}
+
+// acceptsGzip parses Accept-Encoding, honoring "gzip;q=0" as a refusal.
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(coding) != "gzip" {
+			continue
+		}
+		q := strings.ReplaceAll(params, " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
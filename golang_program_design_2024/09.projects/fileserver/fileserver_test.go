@@ -0,0 +1,304 @@
+package fileserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var modTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+var page = strings.Repeat("<p>hello, file server</p>\n", 100)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":      {Data: []byte(page), ModTime: modTime},
+		"docs/index.html": {Data: []byte("<h1>docs</h1>"), ModTime: modTime},
+		"video.mp4":       {Data: []byte("0123456789abcdef"), ModTime: modTime},
+		"big.txt":         {Data: bytes.Repeat([]byte("0123456789"), maxGzipSize/10+1), ModTime: modTime},
+	}
+}
+
+func serve(h http.Handler, method, target string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestServe(t *testing.T) {
+	h := New(testFS(), time.Hour)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string // a prefix of it
+		ctype  string
+	}{
+		{"root is index.html", "GET", "/", 200, "<p>hello", "text/html; charset=utf-8"},
+		{"sub directory", "GET", "/docs/", 200, "<h1>docs", "text/html; charset=utf-8"},
+		{"file", "GET", "/video.mp4", 200, "0123456789abcdef", "video/mp4"},
+		{"head", "HEAD", "/video.mp4", 200, "", "video/mp4"},
+		{"missing", "GET", "/nope.txt", 404, "not found", ""},
+		{"dot dot", "GET", "/docs/../../etc/passwd", 400, "invalid path", ""},
+		{"backslash", "GET", "/docs\\index.html", 400, "invalid path", ""},
+		{"post", "POST", "/", 405, "method not allowed", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, tt.method, tt.path)
+			if w.Code != tt.status || !strings.HasPrefix(w.Body.String(), tt.body) {
+				t.Fatalf("%d %.40q, want %d %q", w.Code, w.Body, tt.status, tt.body)
+			}
+			if tt.ctype != "" && !strings.HasPrefix(w.Header().Get("Content-Type"), strings.Split(tt.ctype, ";")[0]) {
+				t.Errorf("Content-Type %q, want %q", w.Header().Get("Content-Type"), tt.ctype)
+			}
+			if w.Code == 200 {
+				if w.Header().Get("ETag") == "" || w.Header().Get("Last-Modified") != modTime.Format(http.TimeFormat) {
+					t.Errorf("ETag %q, Last-Modified %q", w.Header().Get("ETag"), w.Header().Get("Last-Modified"))
+				}
+				if w.Header().Get("Cache-Control") != "public, max-age=3600" {
+					t.Errorf("Cache-Control %q", w.Header().Get("Cache-Control"))
+				}
+			}
+		})
+	}
+	if w := serve(New(testFS(), 0), "GET", "/"); w.Header().Get("Cache-Control") != "no-cache" {
+		t.Errorf("MaxAge 0: Cache-Control %q", w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestConditional(t *testing.T) {
+	h := New(testFS(), 0)
+	etag := serve(h, "GET", "/video.mp4").Header().Get("ETag")
+	lastModified := modTime.Format(http.TimeFormat)
+
+	tests := []struct {
+		name   string
+		header []string
+		status int
+	}{
+		{"If-None-Match, same", []string{"If-None-Match", etag}, 304},
+		{"If-None-Match, one of a list", []string{"If-None-Match", `"other", ` + etag}, 304},
+		{"If-None-Match, weak", []string{"If-None-Match", "W/" + etag}, 304},
+		{"If-None-Match, changed", []string{"If-None-Match", `"other"`}, 200},
+		{"If-Modified-Since, same time", []string{"If-Modified-Since", lastModified}, 304},
+		{"If-Modified-Since, earlier", []string{"If-Modified-Since", modTime.Add(-time.Hour).Format(http.TimeFormat)}, 200},
+		// If-None-Match wins over If-Modified-Since (RFC 9110, 13.1.3).
+		{"both, the ETag differs", []string{"If-None-Match", `"other"`, "If-Modified-Since", lastModified}, 200},
+		{"If-Range, same: the range", []string{"Range", "bytes=0-3", "If-Range", etag}, 206},
+		{"If-Range, changed: the whole file", []string{"Range", "bytes=0-3", "If-Range", `"other"`}, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, "GET", "/video.mp4", tt.header...)
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+			if w.Code == 304 && w.Body.Len() != 0 {
+				t.Errorf("a 304 with a body: %q", w.Body)
+			}
+		})
+	}
+}
+
+// TestETagChanges rewrites a file with the same size: the new modification
+// time makes the cached validators stale.
+func TestETagChanges(t *testing.T) {
+	fsys := testFS()
+	h := New(fsys, 0)
+	before := serve(h, "GET", "/video.mp4").Header().Get("ETag")
+	fsys["video.mp4"] = &fstest.MapFile{Data: []byte("fedcba9876543210"), ModTime: modTime.Add(time.Second)}
+	w := serve(h, "GET", "/video.mp4", "If-None-Match", before)
+	if w.Code != 200 || w.Body.String() != "fedcba9876543210" || w.Header().Get("ETag") == before {
+		t.Errorf("%d %q, ETag %s (was %s)", w.Code, w.Body, w.Header().Get("ETag"), before)
+	}
+}
+
+func TestRange(t *testing.T) {
+	h := New(testFS(), 0)
+	tests := []struct {
+		name, rng    string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"first bytes", "bytes=0-3", 206, "0123", "bytes 0-3/16"},
+		{"middle", "bytes=10-12", 206, "abc", "bytes 10-12/16"},
+		{"open end", "bytes=12-", 206, "cdef", "bytes 12-15/16"},
+		{"suffix", "bytes=-2", 206, "ef", "bytes 14-15/16"},
+		{"past the end is cut", "bytes=14-100", 206, "ef", "bytes 14-15/16"},
+		{"unsatisfiable", "bytes=16-20", 416, "", "bytes */16"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, "GET", "/video.mp4", "Range", tt.rng)
+			if w.Code != tt.status || w.Header().Get("Content-Range") != tt.contentRange {
+				t.Fatalf("%d, Content-Range %q; want %d, %q", w.Code, w.Header().Get("Content-Range"), tt.status, tt.contentRange)
+			}
+			if tt.status == 206 && w.Body.String() != tt.body {
+				t.Errorf("body %q, want %q", w.Body, tt.body)
+			}
+		})
+	}
+
+	w := serve(h, "GET", "/video.mp4", "Range", "bytes=0-1,4-5")
+	if w.Code != 206 || !strings.HasPrefix(w.Header().Get("Content-Type"), "multipart/byteranges") {
+		t.Errorf("two ranges: %d, %s", w.Code, w.Header().Get("Content-Type"))
+	}
+}
+
+func gunzip(t *testing.T, b []byte) string {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestGzip(t *testing.T) {
+	h := New(testFS(), 0)
+
+	w := serve(h, "GET", "/", "Accept-Encoding", "br, gzip;q=0.8")
+	if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("Content-Encoding %q, Vary %q", w.Header().Get("Content-Encoding"), w.Header().Get("Vary"))
+	}
+	if w.Body.Len() >= len(page) || gunzip(t, w.Body.Bytes()) != page {
+		t.Errorf("%d gzipped bytes for %d", w.Body.Len(), len(page))
+	}
+	gzipETag := w.Header().Get("ETag")
+	plainETag := serve(h, "GET", "/").Header().Get("ETag")
+	if gzipETag == plainETag || !strings.HasSuffix(gzipETag, `-gzip"`) {
+		t.Errorf("ETag %s gzipped, %s plain: the variants need their own", gzipETag, plainETag)
+	}
+	if w := serve(h, "GET", "/", "Accept-Encoding", "gzip", "If-None-Match", gzipETag); w.Code != 304 {
+		t.Errorf("If-None-Match of the gzip variant: %d", w.Code)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		header []string
+	}{
+		{"not accepted", nil},
+		{"refused with q=0", []string{"Accept-Encoding", "gzip;q=0"}},
+		{"a range", []string{"Accept-Encoding", "gzip", "Range", "bytes=0-2"}},
+	} {
+		if w := serve(h, "GET", "/", tt.header...); w.Header().Get("Content-Encoding") != "" || !strings.HasPrefix(w.Body.String(), "<p>") {
+			t.Errorf("%s: Content-Encoding %q, body %.10q", tt.name, w.Header().Get("Content-Encoding"), w.Body)
+		}
+	}
+	if w := serve(h, "GET", "/video.mp4", "Accept-Encoding", "gzip"); w.Header().Get("Content-Encoding") != "" {
+		t.Error("a video was gzipped")
+	}
+	// above maxGzipSize: served as it is.
+	bigSize := len(testFS()["big.txt"].Data)
+	if w := serve(h, "GET", "/big.txt", "Accept-Encoding", "gzip"); w.Header().Get("Content-Encoding") != "" || w.Body.Len() != bigSize {
+		t.Errorf("big.txt: Content-Encoding %q, %d bytes of %d", w.Header().Get("Content-Encoding"), w.Body.Len(), bigSize)
+	}
+	if e, _ := h.cache.Peek("big.txt"); e == nil || e.gzipped != nil {
+		t.Errorf("big.txt: cache entry %+v, want one without a gzip variant", e)
+	}
+}
+
+// TestDirFS serves a real directory, whose files are *os.File: a range of a
+// large file is read from the disk, and the cache does not hold the file.
+func TestDirFS(t *testing.T) {
+	dir := t.TempDir()
+	big := bytes.Repeat([]byte("abcdefgh"), 1<<17) // 1 MiB
+	if err := os.WriteFile(filepath.Join(dir, "movie.mp4"), big, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h := New(os.DirFS(dir), 0)
+	w := serve(h, "GET", "/movie.mp4", "Range", "bytes=1048570-")
+	if w.Code != 206 || w.Body.String() != "cdefgh" {
+		t.Fatalf("%d %q", w.Code, w.Body)
+	}
+	e, ok := h.cache.Peek("movie.mp4")
+	if !ok || e.gzipped != nil || e.size != int64(len(big)) {
+		t.Errorf("cache entry %+v, %v", e, ok)
+	}
+	if w := serve(h, "GET", "/movie.mp4"); w.Body.Len() != len(big) {
+		t.Errorf("%d bytes of %d", w.Body.Len(), len(big))
+	}
+}
+
+// noSeekFS hides the Seek of its files, like the files of a zip archive.
+type noSeekFS struct{ fs.FS }
+
+type noSeekFile struct{ f fs.File }
+
+func (f noSeekFile) Stat() (fs.FileInfo, error) { return f.f.Stat() }
+func (f noSeekFile) Read(p []byte) (int, error) { return f.f.Read(p) }
+func (f noSeekFile) Close() error               { return f.f.Close() }
+
+func (n noSeekFS) Open(name string) (fs.File, error) {
+	f, err := n.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return noSeekFile{f}, nil
+}
+
+func TestNoSeek(t *testing.T) {
+	h := New(noSeekFS{testFS()}, 0)
+	if w := serve(h, "GET", "/video.mp4", "Range", "bytes=4-7"); w.Code != 206 || w.Body.String() != "4567" {
+		t.Errorf("%d %q", w.Code, w.Body)
+	}
+	if w := serve(h, "GET", "/video.mp4"); w.Body.String() != "0123456789abcdef" {
+		t.Errorf("%q", w.Body)
+	}
+}
+
+func TestCacheBounded(t *testing.T) {
+	fsys := fstest.MapFS{}
+	for i := 0; i < cacheEntries+50; i++ {
+		fsys[fmt.Sprintf("%d.txt", i)] = &fstest.MapFile{Data: []byte("x"), ModTime: modTime}
+	}
+	h := New(fsys, 0)
+	for name := range fsys {
+		serve(h, "GET", "/"+name)
+	}
+	if h.cache.Len() != cacheEntries {
+		t.Errorf("%d cached entries, want at most %d", h.cache.Len(), cacheEntries)
+	}
+}
+
+func TestCleanPath(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"/", ".", true},
+		{"/a/b.txt", "a/b.txt", true},
+		{"/a//b/./c", "a/b/c", true},
+		{"/a/../b", "", false},
+		{"/..", "", false},
+		{"/a\\b", "", false},
+		{"/a\x00b", "", false},
+	} {
+		got, ok := cleanPath(tt.in)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("cleanPath(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
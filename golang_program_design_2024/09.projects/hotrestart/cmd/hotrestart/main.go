@@ -0,0 +1,217 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/hotrestart"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+hotrestart serves HTTP and replaces itself with a new process on SIGHUP,
+without dropping a connection. Every answer names the pid that served it.
+
+Usage:
+
+	go build -o /tmp/hotrestart ./golang_program_design_2024/09.projects/hotrestart/cmd/hotrestart
+	/tmp/hotrestart -addr :8080 &
+	curl localhost:8080/         # pid 1234
+	kill -HUP %1                 # rebuild the binary first to deploy a new version
+	curl localhost:8080/         # pid 1240
+
+	go run ./golang_program_design_2024/09.projects/hotrestart/cmd/hotrestart -demo
+
+-demo starts a server, sends it requests without pause from several clients,
+slow ones included, restarts it twice in the middle and prints how many
+requests failed and which processes served the others. Its cases are tests:
+go test ./golang_program_design_2024/09.projects/hotrestart
+*/
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	demo := flag.Bool("demo", false, "restart a server under load and count failed requests")
+	flag.Parse()
+
+	if *demo {
+		if err := runDemo(); err != nil {
+			xlog.New("09.projects", "hotrestart").Error("demo failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if err := serve(*addr); err != nil {
+		xlog.New("09.projects", "hotrestart").Error("serve failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+func serve(addr string) error {
+	log := xlog.New("09.projects", "hotrestart").With("pid", os.Getpid())
+	l, inherited, err := hotrestart.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "pid %d\n", os.Getpid())
+	})
+	// a request still running when the restart comes: the old process
+	// finishes it before exiting.
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		fmt.Fprintf(w, "pid %d\n", os.Getpid())
+	})
+	srv := &http.Server{Handler: mux}
+	drainer := hotrestart.NewDrainer(srv)
+
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(l) }()
+	log.Info("serving", "addr", l.Addr().String(), "inherited", inherited)
+	if err := hotrestart.Ready(); err != nil {
+		return err
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	for s := range sig {
+		if s == syscall.SIGHUP {
+			pid, err := hotrestart.Restart(l, 10*time.Second)
+			if err != nil {
+				// the new version is broken: keep serving with this one.
+				log.Error("restart failed, still serving", "err", err)
+				continue
+			}
+			log.Info("new process ready, draining", "new_pid", pid)
+		}
+		break
+	}
+
+	if err := drainer.Drain(l, 30*time.Second); err != nil {
+		return err
+	}
+	if err := <-served; !errors.Is(err, net.ErrClosed) {
+		return err
+	}
+	log.Info("drained, exiting")
+	return nil
+}
+
+func runDemo() error {
+	// a free port, given to the server.
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	addr := probe.Addr().String()
+	probe.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	server := exec.Command(exe, "-addr", addr)
+	server.Stdout, server.Stderr = os.Stdout, os.Stderr
+	if err := server.Start(); err != nil {
+		return err
+	}
+	go server.Wait()
+
+	// a new connection per request, so every request needs an Accept.
+	client := &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+	get := func(path string) (int, error) {
+		resp, err := client.Get("http://" + addr + path)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(string(body), "pid ")))
+	}
+	var pid int
+	for start := time.Now(); ; time.Sleep(20 * time.Millisecond) {
+		if pid, err = get("/"); err == nil {
+			break
+		}
+		if time.Since(start) > 5*time.Second {
+			return fmt.Errorf("server did not start: %w", err)
+		}
+	}
+
+	var (
+		requests, failures atomic.Int64
+		mu                 sync.Mutex
+		pids               = map[int]int{}
+		current            atomic.Int64
+		stop               = make(chan struct{})
+		wg                 sync.WaitGroup
+	)
+	current.Store(int64(pid))
+	for c := 0; c < 8; c++ {
+		path := "/"
+		if c < 2 {
+			path = "/slow"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				requests.Add(1)
+				pid, err := get(path)
+				if err != nil {
+					failures.Add(1)
+					fmt.Println("request failed:", err)
+					continue
+				}
+				mu.Lock()
+				pids[pid]++
+				mu.Unlock()
+				current.Store(int64(pid))
+			}
+		}()
+	}
+
+	for i := 0; i < 2; i++ {
+		time.Sleep(time.Second)
+		old := int(current.Load())
+		fmt.Printf("== SIGHUP to %d\n", old)
+		syscall.Kill(old, syscall.SIGHUP)
+		// wait until another process answers.
+		for deadline := time.Now().Add(10 * time.Second); int(current.Load()) == old; time.Sleep(10 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				close(stop)
+				return errors.New("no new process after SIGHUP")
+			}
+		}
+	}
+	time.Sleep(time.Second)
+	close(stop)
+	wg.Wait()
+	syscall.Kill(int(current.Load()), syscall.SIGTERM)
+	time.Sleep(200 * time.Millisecond)
+
+	fmt.Printf("%d requests, %d failed, served by %d processes: %v\n", requests.Load(), failures.Load(), len(pids), pids)
+	return nil
+}
@@ -0,0 +1,206 @@
+// Package hotrestart replaces a running server with a new process without
+// refusing a single connection.
+//
+// The listening socket is what clients connect to; as long as it stays open,
+// connections queue in the kernel even while nobody calls Accept. So the old
+// process hands its socket to the new one instead of closing it:
+//
+//  1. the old process starts its own executable again, passing the listener's
+//     file descriptor as an inherited file (fd 3) and LISTEN_FDS=1;
+//  2. the new process builds its listener from fd 3 (Listen), starts
+//     serving, and reports ready through a pipe (fd 4, Ready);
+//  3. the old process stops accepting and drains (Drainer): the requests in
+//     progress are finished, then it exits.
+//
+// Between 2 and 3 both processes accept on the same socket, which is fine:
+// each connection goes to one of them. A new binary that fails to start
+// never reports ready, and the old process keeps serving.
+//
+// Passing files to a child needs Unix; on Windows Restart returns an error.
+package hotrestart
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+const (
+	envListenFDs = "LISTEN_FDS"
+	envReadyFD   = "HOTRESTART_READY_FD"
+	listenerFD   = 3 // the first of cmd.ExtraFiles
+	readyFD      = 4
+)
+
+// Listen returns the listener inherited from the parent process, or a new
+// one on addr for the first process. inherited tells which.
+func Listen(network, addr string) (l net.Listener, inherited bool, err error) {
+	if os.Getenv(envListenFDs) != "1" {
+		l, err = net.Listen(network, addr)
+		return l, false, err
+	}
+	f := os.NewFile(listenerFD, "listener")
+	// FileListener duplicates the descriptor, the original is ours to close.
+	defer f.Close()
+	l, err = net.FileListener(f)
+	if err != nil {
+		return nil, false, fmt.Errorf("hotrestart: inherited listener: %w", err)
+	}
+	// the children of this process must not think they inherit too.
+	os.Unsetenv(envListenFDs)
+	return l, true, nil
+}
+
+// Ready tells the parent that this process serves and it can drain. It does
+// nothing in a process that was not started by Restart.
+func Ready() error {
+	if os.Getenv(envReadyFD) == "" {
+		return nil
+	}
+	os.Unsetenv(envReadyFD)
+	f := os.NewFile(readyFD, "ready")
+	defer f.Close()
+	_, err := f.Write([]byte{1})
+	return err
+}
+
+// ErrNotReady is returned by Restart when the new process exited or did not
+// report ready in time.
+var ErrNotReady = errors.New("hotrestart: new process did not become ready")
+
+// Restart starts a new copy of the running program, with the same arguments,
+// handing it l, and waits up to timeout for it to call Ready. It returns the
+// pid of the new process; the caller then shuts its own server down.
+func Restart(l net.Listener, timeout time.Duration) (int, error) {
+	fl, ok := l.(interface{ File() (*os.File, error) })
+	if !ok {
+		return 0, fmt.Errorf("hotrestart: %T cannot be passed to a child", l)
+	}
+	// File returns a duplicate of the descriptor, in blocking mode, which is
+	// what the child expects to inherit; l keeps working here.
+	lf, err := fl.File()
+	if err != nil {
+		return 0, err
+	}
+	defer lf.Close()
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+
+	exe, err := os.Executable()
+	if err != nil {
+		w.Close()
+		return 0, err
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), envListenFDs+"=1", envReadyFD+"=1")
+	cmd.ExtraFiles = []*os.File{lf, w}
+	err = cmd.Start()
+	// the child has its copy of w; closing ours means a read of r returns
+	// EOF as soon as the child exits without writing.
+	w.Close()
+	// Start called lf.Fd(), which puts the socket in blocking mode, and lf
+	// shares it with l: an Accept of l would then block in the kernel, and
+	// a Close of l wait for the next connection.
+	if nerr := setNonblock(l); err == nil {
+		err = nerr
+	}
+	if err != nil {
+		return 0, err
+	}
+	// nobody waits for the child: once we exit it is adopted by init.
+	go cmd.Wait()
+
+	ready := make(chan bool, 1)
+	go func() {
+		buf := make([]byte, 1)
+		n, _ := r.Read(buf)
+		ready <- n == 1
+	}()
+	select {
+	case ok := <-ready:
+		if !ok {
+			return 0, ErrNotReady
+		}
+	case <-time.After(timeout):
+		cmd.Process.Kill()
+		return 0, ErrNotReady
+	}
+	return cmd.Process.Pid, nil
+}
+
+// Drainer finishes the connections of an http.Server after its listener was
+// handed over.
+//
+// http.Server.Shutdown is not enough here: a connection accepted just before
+// it, whose request is not read yet, is closed without an answer, and with a
+// second process accepting on the same socket that window is hit under load.
+// Drainer closes the listener first, then lets every accepted connection
+// finish its request.
+type Drainer struct {
+	srv   *http.Server
+	mu    sync.Mutex
+	conns map[net.Conn]http.ConnState
+}
+
+// NewDrainer tracks the connections of srv. It must be called before Serve,
+// it sets srv.ConnState.
+func NewDrainer(srv *http.Server) *Drainer {
+	d := &Drainer{srv: srv, conns: make(map[net.Conn]http.ConnState)}
+	next := srv.ConnState
+	srv.ConnState = func(c net.Conn, state http.ConnState) {
+		d.mu.Lock()
+		switch state {
+		case http.StateClosed, http.StateHijacked:
+			delete(d.conns, c)
+		default:
+			d.conns[c] = state
+		}
+		d.mu.Unlock()
+		if next != nil {
+			next(c, state)
+		}
+	}
+	return d
+}
+
+// Drain stops accepting on l and waits up to timeout for the connections to
+// finish, then closes those left.
+func (d *Drainer) Drain(l net.Listener, timeout time.Duration) error {
+	// Serve returns when l is closed; the socket stays open in the new process.
+	l.Close()
+	// the answers in progress say "Connection: close", and the clients open
+	// their next connection to the new process.
+	d.srv.SetKeepAlivesEnabled(false)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		d.mu.Lock()
+		for c, state := range d.conns {
+			// idle keep-alive connections would wait for a request forever.
+			if state == http.StateIdle {
+				c.Close()
+				delete(d.conns, c)
+			}
+		}
+		left := len(d.conns)
+		d.mu.Unlock()
+		if left == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			d.srv.Close()
+			return fmt.Errorf("hotrestart: %d connections still open after %v", left, timeout)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
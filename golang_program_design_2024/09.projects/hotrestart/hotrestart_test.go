@@ -0,0 +1,170 @@
+package hotrestart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// childEnv makes the test binary, started again by Restart, play the new
+// process: "serve" answers "child", "exit" exits before Ready.
+const childEnv = "HOTRESTART_TEST_CHILD"
+
+func TestMain(m *testing.M) {
+	switch os.Getenv(childEnv) {
+	case "serve":
+		l, inherited, err := Listen("tcp", "")
+		if err != nil || !inherited {
+			fmt.Fprintln(os.Stderr, "child: no inherited listener:", err)
+			os.Exit(2)
+		}
+		go http.Serve(l, answer("child"))
+		if err := Ready(); err != nil {
+			os.Exit(2)
+		}
+		time.Sleep(time.Minute) // killed by the test before
+		os.Exit(0)
+	case "exit":
+		os.Exit(3)
+	}
+	os.Exit(m.Run())
+}
+
+func answer(s string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		io.WriteString(w, s)
+	})
+}
+
+// get sends a request on a new connection, as every request needs an Accept.
+func get(addr, path string) (string, error) {
+	client := &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+	resp, err := client.Get("http://" + addr + path)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func serve(t *testing.T) (net.Listener, *http.Server, *Drainer) {
+	t.Helper()
+	l, inherited, err := Listen("tcp", "127.0.0.1:0")
+	if err != nil || inherited {
+		t.Fatalf("Listen = %v, inherited %v", err, inherited)
+	}
+	srv := &http.Server{Handler: answer("parent")}
+	d := NewDrainer(srv)
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+	return l, srv, d
+}
+
+// TestRestart hands the listener to a new process: once the old one has
+// drained, the same address answers from the new one.
+func TestRestart(t *testing.T) {
+	t.Setenv(childEnv, "serve")
+	l, _, d := serve(t)
+	addr := l.Addr().String()
+	if got, err := get(addr, "/"); got != "parent" {
+		t.Fatalf("before the restart: %q, %v", got, err)
+	}
+
+	pid, err := Restart(l, 10*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if p, err := os.FindProcess(pid); err == nil {
+			p.Kill()
+		}
+	})
+	if err := d.Drain(l, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if got, err := get(addr, "/"); got != "child" {
+			t.Errorf("after the drain: %q, %v; want the new process", got, err)
+		}
+	}
+}
+
+func TestRestartNotReady(t *testing.T) {
+	t.Setenv(childEnv, "exit")
+	l, _, _ := serve(t)
+	if _, err := Restart(l, 10*time.Second); !errors.Is(err, ErrNotReady) {
+		t.Errorf("a new process exiting: %v, want ErrNotReady", err)
+	}
+	// the old process serves on.
+	if got, err := get(l.Addr().String(), "/"); got != "parent" {
+		t.Errorf("after a failed restart: %q, %v", got, err)
+	}
+}
+
+// TestDrain drains with a request in progress and an idle keep-alive
+// connection: the request is answered, the idle connection closed.
+func TestDrain(t *testing.T) {
+	l, _, d := serve(t)
+	addr := l.Addr().String()
+
+	idle, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idle.Close()
+	io.WriteString(idle, "GET / HTTP/1.1\r\nHost: x\r\n\r\n")
+	buf := make([]byte, 512)
+	if n, err := idle.Read(buf); err != nil || !strings.HasSuffix(string(buf[:n]), "parent") {
+		t.Fatalf("keep-alive request: %q, %v", buf[:n], err)
+	}
+
+	slow := make(chan string, 1)
+	go func() {
+		body, err := get(addr, "/slow")
+		if err != nil {
+			body = err.Error()
+		}
+		slow <- body
+	}()
+	time.Sleep(50 * time.Millisecond) // the slow request accepted
+
+	if err := d.Drain(l, 5*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-slow; got != "parent" {
+		t.Errorf("the request in progress: %q", got)
+	}
+	idle.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := idle.Read(buf); err != io.EOF {
+		t.Errorf("the idle connection after Drain: %v, want EOF", err)
+	}
+	if _, err := get(addr, "/"); err == nil {
+		t.Error("a request after Drain, nobody else listening, was answered")
+	}
+}
+
+func TestDrainTimeout(t *testing.T) {
+	l, _, d := serve(t)
+	go get(l.Addr().String(), "/slow")
+	time.Sleep(50 * time.Millisecond)
+	if err := d.Drain(l, 20*time.Millisecond); err == nil {
+		t.Error("Drain with a request longer than its timeout: no error")
+	}
+}
+
+func TestReadyAlone(t *testing.T) {
+	// a process not started by Restart: nothing to tell.
+	if err := Ready(); err != nil {
+		t.Errorf("Ready = %v", err)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package hotrestart
+
+import "net"
+
+// setNonblock does nothing: Restart fails before it on these systems.
+func setNonblock(l net.Listener) error { return nil }
@@ -0,0 +1,26 @@
+//go:build unix
+
+package hotrestart
+
+import (
+	"net"
+	"syscall"
+)
+
+// setNonblock puts the socket of l back in non-blocking mode, the mode the
+// runtime poller expects.
+func setNonblock(l net.Listener) error {
+	sc, ok := l.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var serr error
+	if err := rc.Control(func(fd uintptr) { serr = syscall.SetNonblock(int(fd), true) }); err != nil {
+		return err
+	}
+	return serr
+}
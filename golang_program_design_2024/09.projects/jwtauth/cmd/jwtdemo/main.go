@@ -0,0 +1,196 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/jwtauth"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+jwtdemo serves a small API protected by tokens:
+
+	POST /login   {"user": "alice", "password": "wonderland"} -> {"token": ...}
+	GET  /me      any valid token
+	GET  /admin   a token with the admin role
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/jwtauth/cmd/jwtdemo -addr :8080
+	curl -s -X POST localhost:8080/login -d '{"user":"alice","password":"wonderland"}'
+	curl -s localhost:8080/me -H "Authorization: Bearer $TOKEN"
+
+	go run ./golang_program_design_2024/09.projects/jwtauth/cmd/jwtdemo -demo
+
+-demo runs the API on a local test server and prints the status it answers
+to valid, expired, tampered and forged tokens. Its cases are tests: go test
+./golang_program_design_2024/09.projects/jwtauth/...
+*/
+
+// account stores a salted SHA-256 of the password. A real service would use
+// a slow hash (bcrypt, argon2) to make guessing expensive; the point here is
+// that the password itself is never stored.
+type account struct {
+	salt, hash []byte
+	roles      []string
+}
+
+func newAccount(password string, roles ...string) account {
+	salt := make([]byte, 16)
+	rand.Read(salt)
+	return account{salt: salt, hash: hashPassword(salt, password), roles: roles}
+}
+
+func hashPassword(salt []byte, password string) []byte {
+	sum := sha256.Sum256(append(salt, password...))
+	return sum[:]
+}
+
+var accounts = map[string]account{
+	"alice": newAccount("wonderland", "admin", "user"),
+	"bob":   newAccount("builder", "user"),
+}
+
+func verify(user, password string) ([]string, error) {
+	a, ok := accounts[user]
+	if !ok || !hmac.Equal(hashPassword(a.salt, password), a.hash) {
+		return nil, jwtauth.ErrBadCredentials
+	}
+	return a.roles, nil
+}
+
+func newMux(s *jwtauth.Signer) *http.ServeMux {
+	me := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := jwtauth.ClaimsFrom(r.Context())
+		fmt.Fprintf(w, "hello %s, roles %v\n", c.Subject, c.Roles)
+	})
+	admin := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "welcome to the admin area")
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /login", s.LoginHandler(verify))
+	mux.Handle("GET /me", s.Authenticate(me))
+	mux.Handle("GET /admin", s.Authenticate(jwtauth.RequireRole("admin", admin)))
+	return mux
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	demo := flag.Bool("demo", false, "try the API with good and bad tokens")
+	flag.Parse()
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	s := jwtauth.NewSigner(key, "jwtdemo")
+
+	if *demo {
+		runDemo(s)
+		return
+	}
+	log := xlog.New("09.projects", "jwtdemo")
+	log.Info("listening", "addr", *addr)
+	if err := http.ListenAndServe(*addr, newMux(s)); err != nil {
+		log.Error("serve failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+// tamperPayload makes the holder of token an admin: the payload changes,
+// the signature does not.
+func tamperPayload(token string) string {
+	parts := strings.Split(token, ".")
+	var claims map[string]any
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	json.Unmarshal(payload, &claims)
+	claims["roles"] = []string{"admin"}
+	payload, _ = json.Marshal(claims)
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+	return strings.Join(parts, ".")
+}
+
+// flipSignature flips one bit of the signature of token.
+func flipSignature(token string) string {
+	b := []byte(token)
+	b[len(b)-2] ^= 1
+	return string(b)
+}
+
+// algNone keeps the payload of token with "alg": "none" and no signature.
+func algNone(token string) string {
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	return none + "." + strings.Split(token, ".")[1] + "."
+}
+
+// client calls the API at url.
+type client struct{ url string }
+
+func (c client) get(path, token string) int {
+	req, _ := http.NewRequest(http.MethodGet, c.url+path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func (c client) login(user, password string) (string, int) {
+	body := fmt.Sprintf(`{"user":%q,"password":%q}`, user, password)
+	resp, err := http.Post(c.url+"/login", "application/json", strings.NewReader(body))
+	if err != nil {
+		return "", 0
+	}
+	defer resp.Body.Close()
+	var out struct{ Token string }
+	json.NewDecoder(resp.Body).Decode(&out)
+	return out.Token, resp.StatusCode
+}
+
+func runDemo(s *jwtauth.Signer) {
+	srv := httptest.NewServer(newMux(s))
+	defer srv.Close()
+	c := client{srv.URL}
+	show := func(name string, status int) {
+		fmt.Printf("%-32s %d %s\n", name, status, http.StatusText(status))
+	}
+
+	aliceToken, status := c.login("alice", "wonderland")
+	show("login alice", status)
+	bobToken, _ := c.login("bob", "builder")
+	_, status = c.login("bob", "wrong")
+	show("login with a wrong password", status)
+
+	show("/me without a token", c.get("/me", ""))
+	show("/me as alice", c.get("/me", aliceToken))
+	show("/admin as alice (admin)", c.get("/admin", aliceToken))
+	show("/admin as bob (user)", c.get("/admin", bobToken))
+
+	// a token issued an hour ago, with a 15 minute TTL.
+	past := *s
+	past.Now = func() time.Time { return time.Now().Add(-time.Hour) }
+	expired, _ := past.Issue("alice", "admin")
+	show("expired token", c.get("/me", expired))
+
+	show("bob's payload made admin", c.get("/admin", tamperPayload(bobToken)))
+	show("tampered signature", c.get("/me", flipSignature(aliceToken)))
+	// the same claims signed by another key.
+	forged, _ := jwtauth.NewSigner([]byte("not the server key"), "jwtdemo").Issue("alice", "admin")
+	show("signed with another key", c.get("/admin", forged))
+	show(`alg "none"`, c.get("/admin", algNone(bobToken)))
+	show("garbage", c.get("/me", "not.a.token"))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/jwtauth"
+)
+
+func TestAPI(t *testing.T) {
+	s := jwtauth.NewSigner([]byte("a key of the test, 32 bytes long"), "jwtdemo")
+	srv := httptest.NewServer(newMux(s))
+	defer srv.Close()
+	c := client{srv.URL}
+
+	alice, status := c.login("alice", "wonderland")
+	if status != http.StatusOK || alice == "" {
+		t.Fatalf("login alice: %d", status)
+	}
+	bob, _ := c.login("bob", "builder")
+	for _, tt := range []struct {
+		user, password string
+		status         int
+	}{
+		{"bob", "wrong", http.StatusUnauthorized},
+		{"mallory", "builder", http.StatusUnauthorized},
+	} {
+		if token, status := c.login(tt.user, tt.password); status != tt.status || token != "" {
+			t.Errorf("login %s/%s: %d, want %d", tt.user, tt.password, status, tt.status)
+		}
+	}
+
+	past := *s
+	past.Now = func() time.Time { return time.Now().Add(-time.Hour) }
+	expired, _ := past.Issue("alice", "admin")
+	forged, _ := jwtauth.NewSigner([]byte("not the server key"), "jwtdemo").Issue("alice", "admin")
+	for _, tt := range []struct {
+		name, path, token string
+		status            int
+	}{
+		{"no token", "/me", "", http.StatusUnauthorized},
+		{"alice", "/me", alice, http.StatusOK},
+		{"alice, admin", "/admin", alice, http.StatusOK},
+		{"bob, user", "/me", bob, http.StatusOK},
+		{"bob, not admin", "/admin", bob, http.StatusForbidden},
+		{"expired", "/me", expired, http.StatusUnauthorized},
+		{"payload changed by the client", "/admin", tamperPayload(bob), http.StatusUnauthorized},
+		{"tampered signature", "/me", flipSignature(alice), http.StatusUnauthorized},
+		{"signed with another key", "/admin", forged, http.StatusUnauthorized},
+		{`alg "none"`, "/admin", algNone(bob), http.StatusUnauthorized},
+		{"garbage", "/me", "not.a.token", http.StatusUnauthorized},
+	} {
+		if got := c.get(tt.path, tt.token); got != tt.status {
+			t.Errorf("%s: GET %s = %d, want %d", tt.name, tt.path, got, tt.status)
+		}
+	}
+}
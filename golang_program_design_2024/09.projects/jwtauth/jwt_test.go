@@ -0,0 +1,277 @@
+package jwtauth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	testKey = []byte("0123456789abcdef0123456789abcdef")
+	t0      = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+)
+
+// signerAt returns a signer whose clock reads now.
+func signerAt(now time.Time) *Signer {
+	s := NewSigner(testKey, "learn")
+	s.Now = func() time.Time { return now }
+	return s
+}
+
+// forge signs any header and claims with the test key, for the tokens Issue
+// never makes.
+func forge(h header, c any) string {
+	input := b64.EncodeToString(mustJSON(h)) + "." + b64.EncodeToString(mustJSON(c))
+	return input + "." + b64.EncodeToString(signerAt(t0).sign(input))
+}
+
+func issue(t *testing.T, s *Signer, subject string, roles ...string) string {
+	t.Helper()
+	token, err := s.Issue(subject, roles...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestIssueParse(t *testing.T) {
+	s := signerAt(t0)
+	c, err := s.Parse(issue(t, s, "alice", "admin", "dev"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Subject != "alice" || c.Issuer != "learn" || c.IssuedAt != t0.Unix() || c.ExpiresAt != t0.Add(15*time.Minute).Unix() {
+		t.Errorf("claims %+v", c)
+	}
+	if !c.HasRole("admin") || !c.HasRole("dev") || c.HasRole("root") {
+		t.Errorf("roles %v", c.Roles)
+	}
+}
+
+// TestExpiry parses a 15 minute token at several times around its expiry,
+// with the 30 seconds of leeway.
+func TestExpiry(t *testing.T) {
+	token := issue(t, signerAt(t0), "alice")
+	for _, tt := range []struct {
+		after time.Duration
+		want  error
+	}{
+		{0, nil},
+		{15 * time.Minute, nil},
+		{15*time.Minute + 30*time.Second, nil},
+		{15*time.Minute + 31*time.Second, ErrExpired},
+		{24 * time.Hour, ErrExpired},
+		// issued by a server whose clock is ahead: no iat check.
+		{-time.Minute, nil},
+	} {
+		if _, err := signerAt(t0.Add(tt.after)).Parse(token); !errors.Is(err, tt.want) {
+			t.Errorf("Parse %v after the issue = %v, want %v", tt.after, err, tt.want)
+		}
+	}
+
+	noExpiry := forge(header{Alg: "HS256", Typ: "JWT"}, map[string]any{"sub": "alice", "iss": "learn"})
+	if _, err := signerAt(t0).Parse(noExpiry); !errors.Is(err, ErrExpired) {
+		t.Errorf("a token without exp = %v, want ErrExpired", err)
+	}
+}
+
+func TestNotBefore(t *testing.T) {
+	token := forge(header{Alg: "HS256", Typ: "JWT"}, Claims{
+		Issuer: "learn", Subject: "alice",
+		NotBefore: t0.Add(time.Hour).Unix(), ExpiresAt: t0.Add(2 * time.Hour).Unix(),
+	})
+	for _, tt := range []struct {
+		at   time.Duration
+		want error
+	}{
+		{0, ErrNotYet},
+		{time.Hour - 31*time.Second, ErrNotYet},
+		{time.Hour - 30*time.Second, nil}, // the leeway
+		{time.Hour, nil},
+		{2*time.Hour + time.Minute, ErrExpired},
+	} {
+		if _, err := signerAt(t0.Add(tt.at)).Parse(token); !errors.Is(err, tt.want) {
+			t.Errorf("Parse at +%v = %v, want %v", tt.at, err, tt.want)
+		}
+	}
+}
+
+// TestTampered changes each part of a valid token: the signature no longer
+// matches.
+func TestTampered(t *testing.T) {
+	s := signerAt(t0)
+	token := issue(t, s, "alice", "user")
+	parts := strings.Split(token, ".")
+
+	var c Claims
+	payload, _ := b64.DecodeString(parts[1])
+	json.Unmarshal(payload, &c)
+	c.Roles = []string{"admin"}
+	escalated := parts[0] + "." + b64.EncodeToString(mustJSON(c)) + "." + parts[2]
+
+	sig, _ := b64.DecodeString(parts[2])
+	sig[0] ^= 1
+	flipped := parts[0] + "." + parts[1] + "." + b64.EncodeToString(sig)
+
+	other := NewSigner([]byte("another key, just as long as it.."), "learn")
+	other.Now = s.Now
+
+	for name, token := range map[string]string{
+		"changed roles":     escalated,
+		"flipped bit":       flipped,
+		"no signature":      parts[0] + "." + parts[1] + ".",
+		"signed by another": issue(t, other, "alice", "user"),
+		"another header":    b64.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT","kid":"1"}`)) + "." + parts[1] + "." + parts[2],
+	} {
+		if _, err := s.Parse(token); !errors.Is(err, ErrSignature) {
+			t.Errorf("%s: Parse = %v, want ErrSignature", name, err)
+		}
+	}
+}
+
+// TestAlgorithm offers tokens claiming other algorithms, including "none"
+// without a signature: the header is not trusted.
+func TestAlgorithm(t *testing.T) {
+	claims := Claims{Issuer: "learn", Subject: "alice", ExpiresAt: t0.Add(time.Hour).Unix(), Roles: []string{"admin"}}
+	unsigned := b64.EncodeToString(mustJSON(header{Alg: "none", Typ: "JWT"})) + "." + b64.EncodeToString(mustJSON(claims)) + "."
+	for name, token := range map[string]string{
+		"none":          unsigned,
+		"RS256":         forge(header{Alg: "RS256", Typ: "JWT"}, claims),
+		"HS512":         forge(header{Alg: "HS512", Typ: "JWT"}, claims),
+		"lower case":    forge(header{Alg: "hs256", Typ: "JWT"}, claims),
+		"no alg at all": forge(header{Typ: "JWT"}, claims),
+	} {
+		if _, err := signerAt(t0).Parse(token); !errors.Is(err, ErrAlgorithm) {
+			t.Errorf("%s: Parse = %v, want ErrAlgorithm", name, err)
+		}
+	}
+}
+
+func TestMalformed(t *testing.T) {
+	valid := issue(t, signerAt(t0), "alice")
+	parts := strings.Split(valid, ".")
+	for _, token := range []string{
+		"",
+		"abc",
+		parts[0] + "." + parts[1],
+		valid + ".extra",
+		"!!!." + parts[1] + "." + parts[2],
+		b64.EncodeToString([]byte("not json")) + "." + parts[1] + "." + parts[2],
+		parts[0] + "." + parts[1] + ".!!!",
+		forge(header{Alg: "HS256"}, "a string, not claims"),
+	} {
+		if _, err := signerAt(t0).Parse(token); !errors.Is(err, ErrMalformed) {
+			t.Errorf("Parse(%.40q) = %v, want ErrMalformed", token, err)
+		}
+	}
+}
+
+func TestIssuer(t *testing.T) {
+	token := issue(t, signerAt(t0), "alice")
+	other := signerAt(t0)
+	other.Issuer = "someone else"
+	if _, err := other.Parse(token); !errors.Is(err, ErrIssuer) {
+		t.Errorf("Parse by another issuer = %v, want ErrIssuer", err)
+	}
+	anyIssuer := signerAt(t0)
+	anyIssuer.Issuer = ""
+	if _, err := anyIssuer.Parse(token); err != nil {
+		t.Errorf("Parse without an expected issuer = %v", err)
+	}
+}
+
+// serve runs one request through Authenticate and RequireRole("admin").
+func serve(s *Signer, authorization string) *httptest.ResponseRecorder {
+	h := s.Authenticate(RequireRole("admin", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello " + ClaimsFrom(r.Context()).Subject))
+	})))
+	r := httptest.NewRequest("GET", "/admin", nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMiddleware(t *testing.T) {
+	s := signerAt(t0)
+	expired := issue(t, signerAt(t0.Add(-time.Hour)), "alice", "admin")
+	for _, tt := range []struct {
+		name, authorization string
+		status              int
+		body                string
+	}{
+		{"admin", "Bearer " + issue(t, s, "alice", "admin"), 200, "hello alice"},
+		{"not admin", "Bearer " + issue(t, s, "bob", "user"), 403, "requires role admin"},
+		{"no header", "", 401, "missing bearer token"},
+		{"basic auth", "Basic YWxpY2U6c2VjcmV0", 401, "missing bearer token"},
+		{"empty token", "Bearer ", 401, "missing bearer token"},
+		{"expired", "Bearer " + expired, 401, "token expired"},
+		{"garbage", "Bearer abc", 401, "malformed"},
+	} {
+		w := serve(s, tt.authorization)
+		if w.Code != tt.status || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: %d %s, want %d %q", tt.name, w.Code, w.Body, tt.status, tt.body)
+		}
+		if w.Code == 401 && w.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: a 401 without WWW-Authenticate", tt.name)
+		}
+	}
+
+	// RequireRole alone, without Authenticate in front.
+	w := httptest.NewRecorder()
+	RequireRole("admin", http.NotFoundHandler()).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 401 {
+		t.Errorf("RequireRole without claims: %d, want 401", w.Code)
+	}
+}
+
+func TestLogin(t *testing.T) {
+	s := signerAt(t0)
+	h := s.LoginHandler(func(user, password string) ([]string, error) {
+		switch {
+		case user == "broken":
+			return nil, errors.New("database down")
+		case user != "alice" || password != "secret":
+			return nil, ErrBadCredentials
+		}
+		return []string{"admin"}, nil
+	})
+	login := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("POST", "/login", strings.NewReader(body)))
+		return w
+	}
+
+	w := login(`{"user": "alice", "password": "secret"}`)
+	var out struct {
+		Token     string `json:"token"`
+		ExpiresIn int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil || w.Code != 200 || out.ExpiresIn != 900 {
+		t.Fatalf("login: %d %s", w.Code, w.Body)
+	}
+	if c, err := s.Parse(out.Token); err != nil || c.Subject != "alice" || !c.HasRole("admin") {
+		t.Errorf("the issued token: %+v, %v", c, err)
+	}
+
+	for _, tt := range []struct {
+		body   string
+		status int
+	}{
+		{`{"user": "alice", "password": "wrong"}`, 401},
+		{`{"user": "mallory", "password": "secret"}`, 401},
+		{`{"user": "broken", "password": "x"}`, 500},
+		{`not json`, 400},
+		{`{"user": "` + strings.Repeat("a", 1<<17) + `"}`, 400},
+	} {
+		if w := login(tt.body); w.Code != tt.status || strings.Contains(w.Body.String(), "token") {
+			t.Errorf("login %.40s: %d %s, want %d", tt.body, w.Code, w.Body, tt.status)
+		}
+	}
+}
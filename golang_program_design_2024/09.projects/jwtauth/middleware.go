@@ -0,0 +1,110 @@
+package jwtauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+type claimsKey struct{}
+
+// ClaimsFrom returns the claims stored by Authenticate, or nil.
+func ClaimsFrom(ctx context.Context) *Claims {
+	c, _ := ctx.Value(claimsKey{}).(*Claims)
+	return c
+}
+
+// Authenticate lets through the requests carrying a valid token in an
+// "Authorization: Bearer <token>" header, with the claims in the request
+// context. The others get a 401.
+func (s *Signer) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" {
+			unauthorized(w, "missing bearer token")
+			return
+		}
+		c, err := s.Parse(token)
+		if err != nil {
+			// the reason helps a client tell "log in again" (expired) from a
+			// bug; it reveals nothing about the key.
+			unauthorized(w, err.Error())
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, c)))
+	})
+}
+
+// RequireRole lets through the requests whose claims grant role; the others
+// get a 403: the client is known but not allowed. It goes inside
+// Authenticate.
+func RequireRole(role string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := ClaimsFrom(r.Context())
+		if c == nil {
+			unauthorized(w, "not authenticated")
+			return
+		}
+		if !c.HasRole(role) {
+			writeError(w, http.StatusForbidden, "requires role "+role)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func unauthorized(w http.ResponseWriter, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	writeError(w, http.StatusUnauthorized, msg)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{msg})
+}
+
+// ErrBadCredentials is returned by a Verifier for an unknown user or a wrong
+// password, without telling which.
+var ErrBadCredentials = errors.New("jwtauth: bad credentials")
+
+// Verifier checks a user name and password and returns the roles of the user.
+type Verifier func(user, password string) (roles []string, err error)
+
+// LoginHandler issues tokens: it reads {"user": ..., "password": ...} and
+// answers {"token": ..., "expires_in": seconds}.
+func (s *Signer) LoginHandler(verify Verifier) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct {
+			User     string `json:"user"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&in); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid JSON")
+			return
+		}
+		roles, err := verify(in.User, in.Password)
+		if errors.Is(err, ErrBadCredentials) {
+			writeError(w, http.StatusUnauthorized, "bad credentials")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		token, err := s.Issue(in.User, roles...)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "internal error")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(struct {
+			Token     string `json:"token"`
+			ExpiresIn int    `json:"expires_in"`
+		}{token, int(s.TTL.Seconds())})
+	})
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/kvstore"
+)
+
+/*
+kvdemo walks through the life of the store:
+
+ 1. basic Put/Get/Delete and reopening,
+ 2. compaction of overwritten values,
+ 3. recovery from a torn record at the end of the log,
+ 4. recovery after a writer process is killed in the middle of its writes.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/kvstore/cmd/kvdemo
+*/
+
+func main() {
+	// child mode used by killedWriter: write forever until killed.
+	if len(os.Args) == 3 && os.Args[1] == "writer" {
+		writeForever(os.Args[2])
+		return
+	}
+
+	dir, err := os.MkdirTemp("", "kvstore")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	basics(filepath.Join(dir, "basics"))
+	compaction(filepath.Join(dir, "compaction"))
+	tornWrite(filepath.Join(dir, "torn"))
+	killedWriter(filepath.Join(dir, "killed"))
+}
+
+func basics(dir string) {
+	fmt.Println("==> basics")
+	s, err := kvstore.Open(dir, kvstore.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.Put("lang", []byte("go"))
+	s.Put("year", []byte("2009"))
+	s.Delete("year")
+	s.Close()
+
+	// the index only lives in memory, reopening rebuilds it from the log.
+	s, err = kvstore.Open(dir, kvstore.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer s.Close()
+	v, err := s.Get("lang")
+	fmt.Printf("lang=%s err=%v\n", v, err)
+	_, err = s.Get("year")
+	fmt.Printf("year err=%v\n", err)
+}
+
+func compaction(dir string) {
+	fmt.Println("==> compaction")
+	s, err := kvstore.Open(dir, kvstore.Options{CompactInterval: 50 * time.Millisecond})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer s.Close()
+
+	// the same 10 keys overwritten 100 times: 90% of the log is garbage.
+	for i := 0; i < 1000; i++ {
+		s.Put("key"+strconv.Itoa(i%10), []byte(strconv.Itoa(i)))
+	}
+	size, garbage := s.Stats()
+	fmt.Printf("before: size=%d garbage=%d\n", size, garbage)
+
+	time.Sleep(100 * time.Millisecond) // let the background compaction run
+	size, garbage = s.Stats()
+	v, _ := s.Get("key9")
+	fmt.Printf("after:  size=%d garbage=%d key9=%s\n", size, garbage, v)
+}
+
+func tornWrite(dir string) {
+	fmt.Println("==> torn write")
+	s, err := kvstore.Open(dir, kvstore.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.Put("a", []byte("1"))
+	s.Put("b", []byte("2"))
+	s.Close()
+
+	// simulate a crash during the write of "b": chop the last 3 bytes off.
+	path := filepath.Join(dir, "data.wal")
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-3)
+
+	s, err = kvstore.Open(dir, kvstore.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer s.Close()
+	a, errA := s.Get("a")
+	_, errB := s.Get("b")
+	fmt.Printf("a=%s (%v), b: %v\n", a, errA, errB)
+	// the torn record was cut off, new writes append cleanly after "a".
+	s.Put("b", []byte("2 again"))
+	b, _ := s.Get("b")
+	fmt.Printf("b=%s\n", b)
+}
+
+// writeForever is the child process of killedWriter.
+func writeForever(dir string) {
+	s, err := kvstore.Open(dir, kvstore.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		// value = key repeated, so a reader can check each value is intact.
+		key := "k" + strconv.Itoa(i)
+		s.Put(key, []byte(key+key+key))
+	}
+}
+
+func killedWriter(dir string) {
+	fmt.Println("==> killed writer")
+	self, err := os.Executable()
+	if err != nil {
+		log.Fatal(err)
+	}
+	cmd := exec.Command(self, "writer", dir)
+	if err := cmd.Start(); err != nil {
+		log.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	cmd.Process.Kill() // SIGKILL: no deferred function, no Close, no Sync.
+	cmd.Wait()
+
+	s, err := kvstore.Open(dir, kvstore.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer s.Close()
+
+	// keys are written in order, so every key below Len must be present and intact.
+	n := s.Len()
+	for i := 0; i < n; i++ {
+		key := "k" + strconv.Itoa(i)
+		v, err := s.Get(key)
+		if err != nil || string(v) != key+key+key {
+			log.Fatalf("%s: got %q, %v", key, v, err)
+		}
+	}
+	fmt.Printf("recovered %d keys, all intact\n", n)
+}
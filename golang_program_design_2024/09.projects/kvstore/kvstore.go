@@ -0,0 +1,319 @@
+// Package kvstore is a small disk-backed key-value store in the style of
+// Bitcask: every write is appended to a write-ahead log, an in-memory index
+// maps each key to the offset of its latest value, and the log is compacted
+// from time to time to drop overwritten and deleted entries.
+//
+// On Open the log is replayed to rebuild the index. A record that was only
+// half written when the process died is detected by its checksum and cut off,
+// so the store always comes back with every fully written record.
+package kvstore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	maxKeySize   = 1 << 10
+	maxValueSize = 1 << 20
+
+	logName     = "data.wal"
+	compactName = "data.wal.compact"
+)
+
+var (
+	ErrNotFound = errors.New("kvstore: key not found")
+	ErrEmptyKey = errors.New("kvstore: empty key")
+	ErrTooLarge = errors.New("kvstore: key or value too large")
+	ErrClosed   = errors.New("kvstore: store is closed")
+)
+
+// Options tune durability and compaction. The zero value is usable.
+type Options struct {
+	// SyncWrites calls fsync after every write. Without it a crash may lose the
+	// last writes, but never corrupts the store.
+	SyncWrites bool
+	// CompactInterval is how often the background compaction checks the log,
+	// zero disables it (Compact can still be called by hand).
+	CompactInterval time.Duration
+	// CompactRatio is the share of dead bytes from which compaction runs, 0.5 by default.
+	CompactRatio float64
+}
+
+// location of a live value inside the log.
+type location struct {
+	offset int64 // of the value, not of the record
+	size   int
+}
+
+// Store is safe for concurrent use.
+type Store struct {
+	mu      sync.RWMutex
+	dir     string
+	opts    Options
+	file    *os.File
+	index   map[string]location
+	size    int64 // bytes in the log
+	garbage int64 // bytes of records that are no longer live
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open opens the store in dir, creating it if needed, and replays the log.
+func Open(dir string, opts Options) (*Store, error) {
+	if opts.CompactRatio == 0 {
+		opts.CompactRatio = 0.5
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	// a compaction interrupted before its rename left an unfinished copy behind.
+	os.Remove(filepath.Join(dir, compactName))
+
+	file, err := os.OpenFile(filepath.Join(dir, logName), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{dir: dir, opts: opts, file: file, index: make(map[string]location)}
+	if err := s.replay(); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	if opts.CompactInterval > 0 {
+		s.stop, s.done = make(chan struct{}), make(chan struct{})
+		go s.compactLoop()
+	}
+	return s, nil
+}
+
+// replay rebuilds the index from the log and truncates a torn tail.
+func (s *Store) replay() error {
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(s.file)
+	var offset int64
+	for {
+		rec, err := decode(r)
+		if err == io.EOF {
+			break
+		}
+		if err == errCorrupt {
+			// everything from here on is the remains of an interrupted write.
+			if err := s.file.Truncate(offset); err != nil {
+				return err
+			}
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s.apply(rec, offset)
+		offset += rec.size()
+	}
+	s.size = offset
+	_, err := s.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+// apply updates the index and the garbage counter for a record at offset.
+func (s *Store) apply(rec record, offset int64) {
+	if old, ok := s.index[string(rec.key)]; ok {
+		s.garbage += int64(headerSize + len(rec.key) + old.size)
+	}
+	switch rec.op {
+	case opPut:
+		s.index[string(rec.key)] = location{
+			offset: offset + headerSize + int64(len(rec.key)),
+			size:   len(rec.value),
+		}
+	case opDelete:
+		delete(s.index, string(rec.key))
+		s.garbage += rec.size() // the tombstone itself is dead once applied
+	}
+}
+
+// Get returns the value stored for key.
+func (s *Store) Get(key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.file == nil {
+		return nil, ErrClosed
+	}
+	loc, ok := s.index[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	value := make([]byte, loc.size)
+	if _, err := s.file.ReadAt(value, loc.offset); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Put stores value under key.
+func (s *Store) Put(key string, value []byte) error {
+	if len(key) == 0 {
+		return ErrEmptyKey
+	}
+	if len(key) > maxKeySize || len(value) > maxValueSize {
+		return ErrTooLarge
+	}
+	return s.write(record{op: opPut, key: []byte(key), value: value})
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func (s *Store) Delete(key string) error {
+	s.mu.RLock()
+	_, ok := s.index[key]
+	s.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+	return s.write(record{op: opDelete, key: []byte(key)})
+}
+
+func (s *Store) write(rec record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+	if _, err := s.file.Write(rec.encode(nil)); err != nil {
+		return errors.Join(err, s.rollback())
+	}
+	if s.opts.SyncWrites {
+		if err := s.file.Sync(); err != nil {
+			return errors.Join(err, s.rollback())
+		}
+	}
+	s.apply(rec, s.size)
+	s.size += rec.size()
+	return nil
+}
+
+// rollback cuts the log back to s.size after a failed write. A write can
+// fail halfway: left there, the torn record would shift the offsets of the
+// next ones in the index, and the next replay would stop at it, dropping
+// every record after it. Called with mu held.
+func (s *Store) rollback() error {
+	if err := s.file.Truncate(s.size); err != nil {
+		return err
+	}
+	_, err := s.file.Seek(s.size, io.SeekStart)
+	return err
+}
+
+// Len returns the number of live keys.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.index)
+}
+
+// Stats reports the log size and how much of it is garbage.
+func (s *Store) Stats() (size, garbage int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.size, s.garbage
+}
+
+// Compact rewrites the log with only the live values. The new log is written
+// next to the old one, synced, and renamed over it, so a crash at any point
+// leaves either the old or the new log, never a mix of both.
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+
+	path := filepath.Join(s.dir, compactName)
+	tmp, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	index := make(map[string]location, len(s.index))
+	var offset int64
+	var buf []byte
+	for key, loc := range s.index {
+		value := make([]byte, loc.size)
+		if _, err := s.file.ReadAt(value, loc.offset); err != nil {
+			tmp.Close()
+			return err
+		}
+		rec := record{op: opPut, key: []byte(key), value: value}
+		buf = rec.encode(buf[:0])
+		if _, err := w.Write(buf); err != nil {
+			tmp.Close()
+			return err
+		}
+		index[key] = location{offset: offset + headerSize + int64(len(key)), size: loc.size}
+		offset += rec.size()
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := os.Rename(path, filepath.Join(s.dir, logName)); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	s.file.Close()
+	s.file, s.index, s.size, s.garbage = tmp, index, offset, 0
+	_, err = s.file.Seek(offset, io.SeekStart)
+	return err
+}
+
+func (s *Store) compactLoop() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.opts.CompactInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			size, garbage := s.Stats()
+			if size > 0 && float64(garbage)/float64(size) >= s.opts.CompactRatio {
+				if err := s.Compact(); err != nil && !errors.Is(err, ErrClosed) {
+					fmt.Fprintf(os.Stderr, "kvstore: compaction failed: %v\n", err)
+				}
+			}
+		}
+	}
+}
+
+// Close stops the background compaction and closes the log.
+func (s *Store) Close() error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return ErrClosed
+	}
+	err := s.file.Sync()
+	if cerr := s.file.Close(); err == nil {
+		err = cerr
+	}
+	s.file = nil
+	return err
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+package kvstore
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+// TestPartialWrite makes a Put fail halfway: with RLIMIT_FSIZE a few bytes
+// past the end of the log, write(2) writes up to the limit, then fails with
+// EFBIG (the Go runtime ignores SIGXFSZ). The torn record must not shift
+// the offsets of the next writes, nor hide them from the next replay.
+func TestPartialWrite(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir)
+	defer func() { s.Close() }()
+	s.Put("a", []byte("1"))
+	size, _ := s.Stats()
+
+	var old syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_FSIZE, &old); err != nil {
+		t.Fatal(err)
+	}
+	limited := old
+	limited.Cur = uint64(size) + 10
+	if err := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &limited); err != nil {
+		t.Skip("cannot lower RLIMIT_FSIZE:", err)
+	}
+	err := s.Put("big", make([]byte, 100))
+	if rerr := syscall.Setrlimit(syscall.RLIMIT_FSIZE, &old); rerr != nil {
+		t.Fatal(rerr)
+	}
+	if !errors.Is(err, syscall.EFBIG) {
+		t.Fatalf("Put past RLIMIT_FSIZE = %v, want EFBIG", err)
+	}
+	if after, _ := s.Stats(); after != size {
+		t.Errorf("log size %d after the failed Put, want %d", after, size)
+	}
+
+	s.Put("c", []byte("3"))
+	get(t, s, "c", "3")
+	missing(t, s, "big")
+	s.Close()
+
+	s = open(t, dir)
+	get(t, s, "a", "1")
+	get(t, s, "c", "3")
+	missing(t, s, "big")
+}
@@ -0,0 +1,257 @@
+package kvstore
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func open(t *testing.T, dir string) *Store {
+	t.Helper()
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// get fails the test unless key holds want.
+func get(t *testing.T, s *Store, key, want string) {
+	t.Helper()
+	v, err := s.Get(key)
+	if err != nil || string(v) != want {
+		t.Errorf("Get(%q) = %q, %v; want %q", key, v, err, want)
+	}
+}
+
+func missing(t *testing.T, s *Store, key string) {
+	t.Helper()
+	if v, err := s.Get(key); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(%q) = %q, %v; want ErrNotFound", key, v, err)
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir)
+	s.Put("lang", []byte("go"))
+	s.Put("year", []byte("2009"))
+	s.Put("lang", []byte("golang"))
+	s.Delete("year")
+	s.Delete("never there")
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get("lang"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Get after Close: %v, want ErrClosed", err)
+	}
+
+	// the index only lives in memory, reopening rebuilds it from the log.
+	s = open(t, dir)
+	defer s.Close()
+	get(t, s, "lang", "golang")
+	missing(t, s, "year")
+	if s.Len() != 1 {
+		t.Errorf("Len = %d, want 1", s.Len())
+	}
+}
+
+func TestPutErrors(t *testing.T) {
+	s := open(t, t.TempDir())
+	defer s.Close()
+	tests := []struct {
+		name  string
+		key   string
+		value []byte
+		want  error
+	}{
+		{"empty key", "", []byte("v"), ErrEmptyKey},
+		{"key too large", strings.Repeat("k", maxKeySize+1), nil, ErrTooLarge},
+		{"value too large", "k", make([]byte, maxValueSize+1), ErrTooLarge},
+	}
+	for _, tt := range tests {
+		if err := s.Put(tt.key, tt.value); !errors.Is(err, tt.want) {
+			t.Errorf("%s: Put = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if size, _ := s.Stats(); size != 0 {
+		t.Errorf("the refused Puts wrote %d bytes", size)
+	}
+}
+
+// TestTornTail cuts the log in the middle of its last record, or appends
+// garbage to it: Open keeps every whole record, and the writes after it
+// append cleanly.
+func TestTornTail(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		damage func(path string, size int64) error
+	}{
+		{"cut", func(path string, size int64) error { return os.Truncate(path, size-3) }},
+		{"garbage", func(path string, size int64) error {
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			_, err = f.Write([]byte("not a record at all"))
+			return err
+		}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := open(t, dir)
+			s.Put("a", []byte("1"))
+			s.Put("b", []byte("2"))
+			size, _ := s.Stats()
+			s.Close()
+			if err := tt.damage(filepath.Join(dir, logName), size); err != nil {
+				t.Fatal(err)
+			}
+
+			s = open(t, dir)
+			get(t, s, "a", "1")
+			if tt.name == "cut" {
+				missing(t, s, "b")
+			} else {
+				get(t, s, "b", "2")
+			}
+			s.Put("c", []byte("3"))
+			s.Close()
+
+			s = open(t, dir)
+			defer s.Close()
+			get(t, s, "a", "1")
+			get(t, s, "c", "3")
+		})
+	}
+}
+
+func TestCompact(t *testing.T) {
+	dir := t.TempDir()
+	s := open(t, dir)
+	// the same 10 keys overwritten 100 times: 90% of the log is garbage.
+	for i := 0; i < 1000; i++ {
+		s.Put("key"+strconv.Itoa(i%10), []byte(strconv.Itoa(i)))
+	}
+	s.Delete("key0")
+	before, garbage := s.Stats()
+	if garbage < before*8/10 {
+		t.Errorf("%d bytes of garbage of %d", garbage, before)
+	}
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	after, garbage := s.Stats()
+	if garbage != 0 || after >= before/10 {
+		t.Errorf("after Compact: size %d (was %d), garbage %d", after, before, garbage)
+	}
+	get(t, s, "key9", "999")
+	s.Put("new", []byte("after compaction"))
+	s.Close()
+
+	s = open(t, dir)
+	defer s.Close()
+	get(t, s, "key9", "999")
+	get(t, s, "new", "after compaction")
+	missing(t, s, "key0")
+	if s.Len() != 10 {
+		t.Errorf("Len = %d, want 10", s.Len())
+	}
+}
+
+func TestBackgroundCompaction(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{CompactInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for i := 0; i < 1000; i++ {
+		s.Put("key"+strconv.Itoa(i%10), []byte(strconv.Itoa(i)))
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, garbage := s.Stats(); garbage == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no compaction within 5s")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	get(t, s, "key9", "999")
+}
+
+// The killed writer: TestKilledWriter runs the test binary again with
+// KVSTORE_WRITER set, and this test, in that process, writes until it is
+// killed.
+func TestWriterProcess(t *testing.T) {
+	dir := os.Getenv("KVSTORE_WRITER")
+	if dir == "" {
+		t.Skip("the child of TestKilledWriter")
+	}
+	s, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a reopened store carries on after the keys it has.
+	for i := s.Len(); ; i++ {
+		// value = key repeated, so the reader can check each value is intact.
+		key := "k" + strconv.Itoa(i)
+		if err := s.Put(key, []byte(key+key+key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestKilledWriter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts a process")
+	}
+	dir := t.TempDir()
+	for round := 1; round <= 3; round++ {
+		s := open(t, dir)
+		before := s.Len()
+		logSize, _ := s.Stats()
+		s.Close()
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestWriterProcess$")
+		cmd.Env = append(os.Environ(), "KVSTORE_WRITER="+dir)
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		// wait for the log to grow, so the kill lands in the middle of the writes.
+		path := filepath.Join(dir, logName)
+		for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(time.Millisecond) {
+			if info, err := os.Stat(path); err == nil && info.Size() > logSize+1<<15 {
+				break
+			}
+			if time.Now().After(deadline) {
+				cmd.Process.Kill()
+				t.Fatal("the writer wrote nothing in 10s")
+			}
+		}
+		cmd.Process.Kill() // SIGKILL: no deferred function, no Close, no Sync.
+		cmd.Wait()
+
+		// keys are written in order: every key below Len must be present
+		// and intact.
+		s = open(t, dir)
+		n := s.Len()
+		if n <= before {
+			t.Fatalf("round %d: %d keys, %d before the writer ran", round, n, before)
+		}
+		for i := 0; i < n; i++ {
+			key := "k" + strconv.Itoa(i)
+			if v, err := s.Get(key); err != nil || string(v) != key+key+key {
+				t.Fatalf("round %d: Get(%s) = %q, %v", round, key, v, err)
+			}
+		}
+		s.Close()
+	}
+}
@@ -0,0 +1,85 @@
+package kvstore
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+/*
+Every write is appended to the log as one record:
+
+	+-----------+------+------------+------------+-----+-------+
+	| crc32 (4) | op 1 | keyLen (4) | valLen (4) | key | value |
+	+-----------+------+------------+------------+-----+-------+
+
+All integers are little endian (encoding/binary). The checksum covers
+everything after itself, so a record cut short by a crash, or garbage at the
+end of the file, is detected when the log is replayed.
+*/
+
+const headerSize = 4 + 1 + 4 + 4
+
+const (
+	opPut    byte = 1
+	opDelete byte = 2
+)
+
+// errCorrupt means the record at the current offset is incomplete or damaged.
+var errCorrupt = errors.New("kvstore: corrupt record")
+
+type record struct {
+	op    byte
+	key   []byte
+	value []byte
+}
+
+// size is the number of bytes the encoded record occupies in the log.
+func (r record) size() int64 {
+	return int64(headerSize + len(r.key) + len(r.value))
+}
+
+// encode appends the binary form of r to buf.
+func (r record) encode(buf []byte) []byte {
+	start := len(buf)
+	buf = append(buf, 0, 0, 0, 0, r.op)
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(r.key)))
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(r.value)))
+	buf = append(buf, r.key...)
+	buf = append(buf, r.value...)
+	binary.LittleEndian.PutUint32(buf[start:], crc32.ChecksumIEEE(buf[start+4:]))
+	return buf
+}
+
+// decode reads one record from r. It returns io.EOF at a clean end of the log
+// and errCorrupt for a torn or damaged record.
+func decode(r io.Reader) (record, error) {
+	var header [headerSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return record{}, errCorrupt
+		}
+		return record{}, err
+	}
+	sum := binary.LittleEndian.Uint32(header[0:4])
+	rec := record{op: header[4]}
+	keyLen := binary.LittleEndian.Uint32(header[5:9])
+	valLen := binary.LittleEndian.Uint32(header[9:13])
+	if (rec.op != opPut && rec.op != opDelete) || keyLen > maxKeySize || valLen > maxValueSize {
+		return record{}, errCorrupt
+	}
+
+	body := make([]byte, keyLen+valLen)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return record{}, errCorrupt
+	}
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(body)
+	if crc.Sum32() != sum {
+		return record{}, errCorrupt
+	}
+	rec.key, rec.value = body[:keyLen], body[keyLen:]
+	return rec, nil
+}
@@ -0,0 +1,214 @@
+// Package loadbalancer spreads HTTP requests over a pool of backends.
+//
+// A Strategy chooses the backend of each request among the healthy ones.
+// Health is checked actively: every Interval, each backend is asked for
+// HealthPath; after Fall failed checks in a row it is ejected from the pool,
+// after Rise successful ones it is admitted again. Requiring a few results in
+// a row keeps a backend that fails one check in ten from flapping in and out.
+// A request failing to reach its backend ejects it at once, without waiting
+// for the next check.
+package loadbalancer
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Backend is one server of the pool.
+type Backend struct {
+	URL *url.URL
+
+	proxy  *httputil.ReverseProxy
+	active atomic.Int64 // requests in progress
+
+	mu      sync.Mutex
+	healthy bool
+	streak  int // checks in a row contradicting healthy
+}
+
+// Active returns the number of requests the backend is serving.
+func (b *Backend) Active() int64 { return b.active.Load() }
+
+// Healthy reports whether the backend is in the pool.
+func (b *Backend) Healthy() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.healthy
+}
+
+// Balancer is an http.Handler forwarding each request to a backend.
+type Balancer struct {
+	Strategy   Strategy
+	HealthPath string
+	Interval   time.Duration // between two rounds of checks
+	Timeout    time.Duration // of one check
+	Fall, Rise int
+	Log        *slog.Logger
+	// OnChange is called when a backend is ejected or admitted again.
+	OnChange func(b *Backend, healthy bool)
+
+	backends []*Backend
+	client   *http.Client
+}
+
+// New returns a balancer over the targets, all assumed healthy until checked.
+func New(targets []string, strategy Strategy, log *slog.Logger) (*Balancer, error) {
+	lb := &Balancer{
+		Strategy:   strategy,
+		HealthPath: "/healthz",
+		Interval:   5 * time.Second,
+		Timeout:    time.Second,
+		Fall:       2,
+		Rise:       2,
+		Log:        log,
+		client:     &http.Client{},
+	}
+	for _, t := range targets {
+		u, err := url.Parse(t)
+		if err != nil {
+			return nil, err
+		}
+		b := &Backend{URL: u, healthy: true}
+		b.proxy = &httputil.ReverseProxy{
+			Rewrite: func(pr *httputil.ProxyRequest) {
+				pr.SetURL(u)
+				pr.SetXForwarded()
+			},
+			ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+				if !errors.Is(r.Context().Err(), context.Canceled) {
+					lb.Log.Warn("backend unreachable", "backend", u.Host, "err", err)
+					lb.eject(b)
+				}
+				http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+			},
+		}
+		lb.backends = append(lb.backends, b)
+	}
+	if len(lb.backends) == 0 {
+		return nil, errors.New("loadbalancer: no backends")
+	}
+	return lb, nil
+}
+
+// Backends returns the pool, healthy or not.
+func (lb *Balancer) Backends() []*Backend { return lb.backends }
+
+func (lb *Balancer) healthy() []*Backend {
+	var up []*Backend
+	for _, b := range lb.backends {
+		if b.Healthy() {
+			up = append(up, b)
+		}
+	}
+	return up
+}
+
+func (lb *Balancer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	up := lb.healthy()
+	if len(up) == 0 {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "no healthy backend", http.StatusServiceUnavailable)
+		return
+	}
+	b := lb.Strategy.Next(up)
+	b.active.Add(1)
+	defer b.active.Add(-1)
+	b.proxy.ServeHTTP(w, r)
+}
+
+// Run checks the backends every Interval until ctx is done.
+func (lb *Balancer) Run(ctx context.Context) {
+	ticker := time.NewTicker(lb.Interval)
+	defer ticker.Stop()
+	for {
+		lb.CheckAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckAll checks every backend once, concurrently, and waits for the
+// results: a backend hanging until the timeout does not delay the others.
+func (lb *Balancer) CheckAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, b := range lb.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lb.record(b, lb.check(ctx, b))
+		}()
+	}
+	wg.Wait()
+}
+
+// check reports whether the backend answered its health path with a 2xx in
+// time.
+func (lb *Balancer) check(ctx context.Context, b *Backend) bool {
+	ctx, cancel := context.WithTimeout(ctx, lb.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.URL.JoinPath(lb.HealthPath).String(), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := lb.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode >= 200 && resp.StatusCode < 300
+}
+
+// record counts a check result, and flips the state of the backend once
+// Fall failures or Rise successes are in a row.
+func (lb *Balancer) record(b *Backend, ok bool) {
+	b.mu.Lock()
+	if ok == b.healthy {
+		b.streak = 0
+		b.mu.Unlock()
+		return
+	}
+	b.streak++
+	need := lb.Fall
+	if ok {
+		need = lb.Rise
+	}
+	changed := b.streak >= need
+	if changed {
+		b.healthy, b.streak = ok, 0
+	}
+	b.mu.Unlock()
+	if changed {
+		lb.changed(b, ok)
+	}
+}
+
+func (lb *Balancer) eject(b *Backend) {
+	b.mu.Lock()
+	was := b.healthy
+	b.healthy, b.streak = false, 0
+	b.mu.Unlock()
+	if was {
+		lb.changed(b, false)
+	}
+}
+
+func (lb *Balancer) changed(b *Backend, healthy bool) {
+	if healthy {
+		lb.Log.Info("backend admitted", "backend", b.URL.Host)
+	} else {
+		lb.Log.Warn("backend ejected", "backend", b.URL.Host)
+	}
+	if lb.OnChange != nil {
+		lb.OnChange(b, healthy)
+	}
+}
@@ -0,0 +1,279 @@
+package loadbalancer
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flaky is a backend answering requests with its name, and its health path
+// with 200 or 503 as its health says.
+type flaky struct {
+	*httptest.Server
+	name string
+	down atomic.Bool
+}
+
+func newFlaky(t *testing.T, name string) *flaky {
+	t.Helper()
+	f := &flaky{name: name}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" && f.down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, name)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// changes records the calls of OnChange.
+type changes struct {
+	mu  sync.Mutex
+	got []string
+}
+
+func (c *changes) record(b *Backend, healthy bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	state := "ejected"
+	if healthy {
+		state = "admitted"
+	}
+	c.got = append(c.got, b.URL.Host+" "+state)
+}
+
+func (c *changes) take() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	got := c.got
+	c.got = nil
+	return got
+}
+
+func newBalancer(t *testing.T, strategy Strategy, backends ...*flaky) (*Balancer, *changes) {
+	t.Helper()
+	var targets []string
+	for _, b := range backends {
+		targets = append(targets, b.URL)
+	}
+	lb, err := New(targets, strategy, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &changes{}
+	lb.OnChange = c.record
+	return lb, c
+}
+
+// send sends n requests through lb and counts the answers by body, or by
+// status when it is not 200.
+func send(t *testing.T, lb *Balancer, n int) map[string]int {
+	t.Helper()
+	got := make(map[string]int)
+	for i := 0; i < n; i++ {
+		w := httptest.NewRecorder()
+		lb.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		if w.Code != http.StatusOK {
+			got[http.StatusText(w.Code)]++
+		} else {
+			got[w.Body.String()]++
+		}
+	}
+	return got
+}
+
+func TestRoundRobin(t *testing.T) {
+	lb, _ := newBalancer(t, &RoundRobin{}, newFlaky(t, "a"), newFlaky(t, "b"), newFlaky(t, "c"))
+	if got := send(t, lb, 9); got["a"] != 3 || got["b"] != 3 || got["c"] != 3 {
+		t.Errorf("9 requests over 3 backends: %v", got)
+	}
+}
+
+// TestEjection takes a backend down and up again, one round of checks at a
+// time: Fall failed checks in a row eject it, Rise good ones admit it.
+func TestEjection(t *testing.T) {
+	a, b := newFlaky(t, "a"), newFlaky(t, "b")
+	lb, changes := newBalancer(t, &RoundRobin{}, a, b)
+	ctx := context.Background()
+	host := lb.Backends()[1].URL.Host
+
+	b.down.Store(true)
+	lb.CheckAll(ctx)
+	if !lb.Backends()[1].Healthy() {
+		t.Fatal("ejected after one failed check, Fall is 2")
+	}
+	lb.CheckAll(ctx)
+	if lb.Backends()[1].Healthy() {
+		t.Fatal("still in the pool after two failed checks")
+	}
+	if got := changes.take(); len(got) != 1 || got[0] != host+" ejected" {
+		t.Errorf("OnChange calls: %v", got)
+	}
+	if got := send(t, lb, 4); got["a"] != 4 {
+		t.Errorf("requests with b ejected: %v, want all to a", got)
+	}
+
+	b.down.Store(false)
+	lb.CheckAll(ctx)
+	if lb.Backends()[1].Healthy() {
+		t.Fatal("admitted after one good check, Rise is 2")
+	}
+	lb.CheckAll(ctx)
+	if !lb.Backends()[1].Healthy() {
+		t.Fatal("not admitted after two good checks")
+	}
+	if got := changes.take(); len(got) != 1 || got[0] != host+" admitted" {
+		t.Errorf("OnChange calls: %v", got)
+	}
+	if got := send(t, lb, 4); got["a"] != 2 || got["b"] != 2 {
+		t.Errorf("requests with b back: %v", got)
+	}
+}
+
+// TestFlapping fails every other check: the streak never reaches Fall, the
+// backend stays in the pool.
+func TestFlapping(t *testing.T) {
+	b := newFlaky(t, "b")
+	lb, changes := newBalancer(t, &RoundRobin{}, b)
+	for i := 0; i < 10; i++ {
+		b.down.Store(i%2 == 0)
+		lb.CheckAll(context.Background())
+	}
+	if !lb.Backends()[0].Healthy() {
+		t.Error("a backend failing one check in two was ejected")
+	}
+	if got := changes.take(); len(got) != 0 {
+		t.Errorf("OnChange calls: %v", got)
+	}
+}
+
+// TestHangingCheck lets one backend hang on its health path: the check
+// times out and counts as failed, without holding up the others.
+func TestHangingCheck(t *testing.T) {
+	release := make(chan struct{})
+	hang := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(hang.Close)
+	t.Cleanup(func() { close(release) })
+
+	lb, err := New([]string{hang.URL, newFlaky(t, "a").URL}, &RoundRobin{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lb.Timeout, lb.Fall = 50*time.Millisecond, 1
+	start := time.Now()
+	lb.CheckAll(context.Background())
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("CheckAll took %v", d)
+	}
+	if lb.Backends()[0].Healthy() || !lb.Backends()[1].Healthy() {
+		t.Errorf("health after a hanging check: %v %v", lb.Backends()[0].Healthy(), lb.Backends()[1].Healthy())
+	}
+}
+
+// TestUnreachable stops a backend between two checks: the first request to
+// fail on it ejects it at once, the next ones go to the other.
+func TestUnreachable(t *testing.T) {
+	a, b := newFlaky(t, "a"), newFlaky(t, "b")
+	lb, changes := newBalancer(t, &RoundRobin{}, a, b)
+	b.Close()
+
+	got := send(t, lb, 6)
+	if got[http.StatusText(http.StatusBadGateway)] != 1 || got["a"] != 5 {
+		t.Errorf("requests with b stopped: %v, want one 502 then a", got)
+	}
+	if got := changes.take(); len(got) != 1 {
+		t.Errorf("OnChange calls: %v, want one ejection", got)
+	}
+}
+
+func TestNoHealthy(t *testing.T) {
+	a := newFlaky(t, "a")
+	lb, _ := newBalancer(t, &RoundRobin{}, a)
+	a.down.Store(true)
+	lb.CheckAll(context.Background())
+	lb.CheckAll(context.Background())
+
+	w := httptest.NewRecorder()
+	lb.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("no healthy backend: %d %v", w.Code, w.Header())
+	}
+}
+
+// TestRun runs the checks in the background: a backend going down is
+// ejected after Fall intervals, and Run returns when ctx is done.
+func TestRun(t *testing.T) {
+	b := newFlaky(t, "b")
+	lb, _ := newBalancer(t, &RoundRobin{}, newFlaky(t, "a"), b)
+	lb.Interval = 10 * time.Millisecond
+	ejected := make(chan struct{})
+	var once sync.Once
+	lb.OnChange = func(*Backend, bool) { once.Do(func() { close(ejected) }) }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		lb.Run(ctx)
+		close(done)
+	}()
+	b.down.Store(true)
+	select {
+	case <-ejected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the backend down was not ejected")
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after the cancel")
+	}
+}
+
+func TestLeastConnections(t *testing.T) {
+	backends := []*Backend{{}, {}, {}}
+	backends[0].active.Store(3)
+	backends[1].active.Store(1)
+	backends[2].active.Store(1)
+	if got := (LeastConnections{}).Next(backends); got != backends[1] {
+		t.Error("not the first of the least busy backends")
+	}
+
+	// a backend holding its requests gets no new ones while another is free.
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		io.WriteString(w, "slow")
+	}))
+	t.Cleanup(slow.Close)
+	lb, err := New([]string{slow.URL, newFlaky(t, "fast").URL}, LeastConnections{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	held := make(chan struct{})
+	go func() {
+		defer close(held)
+		send(t, lb, 1)
+	}()
+	for lb.Backends()[0].Active() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if got := send(t, lb, 5); got["fast"] != 5 {
+		t.Errorf("requests while slow is busy: %v", got)
+	}
+	close(release)
+	<-held
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/loadbalancer"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+lbdemo balances requests over backends, checking their health.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/loadbalancer/cmd/lbdemo \
+		-backends http://127.0.0.1:9001,http://127.0.0.1:9002 -strategy least -addr :8080
+
+	go run ./golang_program_design_2024/09.projects/loadbalancer/cmd/lbdemo -demo
+
+-demo runs flaky backends on local test servers, whose health and speed it
+changes while the balancer runs, and prints the distribution of requests,
+the ejections and re-admissions. Its cases are tests:
+go test ./golang_program_design_2024/09.projects/loadbalancer
+*/
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	backends := flag.String("backends", "", "comma separated backend URLs")
+	strategy := flag.String("strategy", "rr", "rr (round robin) or least (least connections)")
+	interval := flag.Duration("interval", 5*time.Second, "health check interval")
+	demo := flag.Bool("demo", false, "balance over flaky local test backends")
+	flag.Parse()
+
+	if *demo {
+		if err := runDemo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	log := xlog.New("09.projects", "loadbalancer")
+	s, ok := strategies[*strategy]
+	if !ok || *backends == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	lb, err := loadbalancer.New(strings.Split(*backends, ","), s(), log)
+	if err != nil {
+		log.Error("invalid backends", "err", err)
+		os.Exit(2)
+	}
+	lb.Interval = *interval
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go lb.Run(ctx)
+	srv := &http.Server{Addr: *addr, Handler: lb, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	log.Info("listening", "addr", *addr, "strategy", *strategy)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Error("serve", "err", err)
+		os.Exit(1)
+	}
+}
+
+var strategies = map[string]func() loadbalancer.Strategy{
+	"rr":    func() loadbalancer.Strategy { return &loadbalancer.RoundRobin{} },
+	"least": func() loadbalancer.Strategy { return loadbalancer.LeastConnections{} },
+}
+
+// flaky is a backend whose health and speed the demo changes at will.
+type flaky struct {
+	name   string
+	sick   atomic.Bool
+	delay  atomic.Int64 // of each request, in nanoseconds
+	served atomic.Int64
+	srv    *httptest.Server
+}
+
+func newFlaky(name string) *flaky {
+	f := &flaky{name: name}
+	f.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			if f.sick.Load() {
+				http.Error(w, "sick", http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintln(w, "ok")
+			return
+		}
+		time.Sleep(time.Duration(f.delay.Load()))
+		f.served.Add(1)
+		fmt.Fprint(w, f.name)
+	}))
+	return f
+}
+
+func runDemo() error {
+	a, b, c := newFlaky("a"), newFlaky("b"), newFlaky("c")
+	defer a.srv.Close()
+	defer b.srv.Close()
+	defer c.srv.Close()
+	all := []*flaky{a, b, c}
+	byHost := make(map[string]*flaky)
+	for _, f := range all {
+		byHost[strings.TrimPrefix(f.srv.URL, "http://")] = f
+	}
+	reset := func() {
+		for _, f := range all {
+			f.served.Store(0)
+		}
+	}
+	counts := func() string { return fmt.Sprintf("a=%d b=%d c=%d", a.served.Load(), b.served.Load(), c.served.Load()) }
+
+	log := xlog.New("09.projects", "loadbalancer")
+	lb, err := loadbalancer.New([]string{a.srv.URL, b.srv.URL, c.srv.URL}, &loadbalancer.RoundRobin{}, log)
+	if err != nil {
+		return err
+	}
+	var mu sync.Mutex
+	var events []string
+	lb.OnChange = func(be *loadbalancer.Backend, healthy bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, fmt.Sprintf("%s:%v", byHost[be.URL.Host].name, healthy))
+	}
+	front := httptest.NewServer(lb)
+	defer front.Close()
+
+	get := func() (int, string) {
+		resp, err := http.Get(front.URL + "/work")
+		if err != nil {
+			return 0, err.Error()
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, strings.TrimSpace(string(body))
+	}
+	burst := func(n int) {
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				get()
+			}()
+			time.Sleep(2 * time.Millisecond) // arrivals spread a little
+		}
+		wg.Wait()
+	}
+	ctx := context.Background()
+
+	fmt.Println("== round robin")
+	for i := 0; i < 30; i++ {
+		get()
+	}
+	fmt.Println("30 requests:", counts())
+
+	fmt.Println("\n== ejection and re-admission")
+	b.sick.Store(true)
+	// Fall and Rise are 2: a single check changes nothing.
+	for i := 1; i <= 2; i++ {
+		lb.CheckAll(ctx)
+		fmt.Printf("b sick, after %d checks: healthy %v\n", i, lb.Backends()[1].Healthy())
+	}
+	reset()
+	for i := 0; i < 20; i++ {
+		get()
+	}
+	fmt.Println("20 requests while b is ejected:", counts())
+
+	b.sick.Store(false)
+	for i := 1; i <= 2; i++ {
+		lb.CheckAll(ctx)
+		fmt.Printf("b well again, after %d checks: healthy %v\n", i, lb.Backends()[1].Healthy())
+	}
+
+	fmt.Println("\n== checks on a ticker")
+	lb.Interval = 20 * time.Millisecond
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		lb.Run(runCtx)
+		close(done)
+	}()
+	waitFor := func(cond func() bool) time.Duration {
+		start := time.Now()
+		for !cond() && time.Since(start) < 2*time.Second {
+			time.Sleep(5 * time.Millisecond)
+		}
+		return time.Since(start)
+	}
+	c.sick.Store(true)
+	took := waitFor(func() bool { return !lb.Backends()[2].Healthy() })
+	fmt.Printf("c sick: healthy %v after %v\n", lb.Backends()[2].Healthy(), took.Round(10*time.Millisecond))
+	c.sick.Store(false)
+	took = waitFor(func() bool { return lb.Backends()[2].Healthy() })
+	fmt.Printf("c well again: healthy %v after %v\n", lb.Backends()[2].Healthy(), took.Round(10*time.Millisecond))
+
+	for _, f := range all {
+		f.sick.Store(true)
+	}
+	waitFor(func() bool {
+		return !lb.Backends()[0].Healthy() && !lb.Backends()[1].Healthy() && !lb.Backends()[2].Healthy()
+	})
+	status, body := get()
+	fmt.Printf("all backends down: %d %s\n", status, body)
+	for _, f := range all {
+		f.sick.Store(false)
+	}
+	waitFor(func() bool {
+		return lb.Backends()[0].Healthy() && lb.Backends()[1].Healthy() && lb.Backends()[2].Healthy()
+	})
+	stop()
+	<-done
+
+	fmt.Println("\n== a backend dying between two checks")
+	dead := newFlaky("dead")
+	lb2, _ := loadbalancer.New([]string{a.srv.URL, dead.srv.URL}, &loadbalancer.RoundRobin{}, log)
+	front2 := httptest.NewServer(lb2)
+	defer front2.Close()
+	dead.srv.Close()
+	var statuses []int
+	for i := 0; i < 4; i++ {
+		resp, err := http.Get(front2.URL)
+		if err == nil {
+			statuses = append(statuses, resp.StatusCode)
+			resp.Body.Close()
+		}
+	}
+	fmt.Println("statuses:", statuses)
+
+	fmt.Println("\n== slow backend: round robin vs least connections")
+	a.delay.Store(int64(200 * time.Millisecond))
+	reset()
+	burst(30)
+	fmt.Println("round robin, a slow:", counts())
+
+	lb.Strategy = loadbalancer.LeastConnections{}
+	reset()
+	burst(30)
+	fmt.Println("least connections, a slow:", counts())
+	a.delay.Store(0)
+
+	mu.Lock()
+	fmt.Println("\nstate changes:", strings.Join(events, " "))
+	mu.Unlock()
+	return nil
+}
@@ -0,0 +1,34 @@
+package loadbalancer
+
+import "sync/atomic"
+
+// Strategy picks the backend of the next request among the healthy ones,
+// never an empty slice. It is called concurrently.
+type Strategy interface {
+	Next(healthy []*Backend) *Backend
+}
+
+// RoundRobin takes the backends in turn: every backend gets the same number
+// of requests, whatever they cost.
+type RoundRobin struct {
+	n atomic.Uint64
+}
+
+func (rr *RoundRobin) Next(healthy []*Backend) *Backend {
+	return healthy[(rr.n.Add(1)-1)%uint64(len(healthy))]
+}
+
+// LeastConnections takes the backend with the fewest requests in progress,
+// the first one on a tie. A slow backend holds its requests longer, and so
+// gets fewer new ones.
+type LeastConnections struct{}
+
+func (LeastConnections) Next(healthy []*Backend) *Backend {
+	best := healthy[0]
+	for _, b := range healthy[1:] {
+		if b.Active() < best.Active() {
+			best = b
+		}
+	}
+	return best
+}
@@ -0,0 +1,308 @@
+package mq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownMessage = errors.New("mq: unknown or already acknowledged message")
+	ErrClosed         = errors.New("mq: broker closed")
+)
+
+// Message is a message as delivered to a consumer.
+type Message struct {
+	ID    uint64
+	Queue string
+	Body  []byte
+	// Deliveries counts how many times the message was handed out, more than
+	// one means an earlier consumer did not acknowledge it in time.
+	Deliveries int
+}
+
+type inflight struct {
+	deadline time.Time
+	owner    uint64 // the connection that pulled it
+}
+
+type queue struct {
+	ready    []uint64 // ids waiting for a consumer, oldest first
+	msgs     map[uint64]*Message
+	inflight map[uint64]inflight
+	// wake is closed, and replaced, whenever a message becomes ready: every
+	// waiting Pull wakes up and one of them gets it.
+	wake chan struct{}
+}
+
+func newQueue() *queue {
+	return &queue{msgs: make(map[uint64]*Message), inflight: make(map[uint64]inflight), wake: make(chan struct{})}
+}
+
+func (q *queue) notify() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
+
+// Options tune a Broker.
+type Options struct {
+	// AckTimeout is how long a consumer has to acknowledge a message before
+	// it is delivered again.
+	AckTimeout time.Duration
+	// SyncWrites fsyncs every push and ack: slower, but a message the broker
+	// said OK to survives a power cut, not just a crash of the process.
+	SyncWrites bool
+}
+
+// Broker holds the queues. All methods are safe for concurrent use.
+type Broker struct {
+	opts   Options
+	path   string
+	store  *store // nil when the broker only lives in memory
+	mu     sync.Mutex
+	queues map[string]*queue
+	nextID uint64
+	closed bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Open starts a broker persisting to path, replaying it first. An empty path
+// keeps everything in memory.
+func Open(path string, opts Options) (*Broker, error) {
+	if opts.AckTimeout <= 0 {
+		opts.AckTimeout = 30 * time.Second
+	}
+	b := &Broker{
+		opts:   opts,
+		path:   path,
+		queues: make(map[string]*queue),
+		nextID: 1,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	if path != "" {
+		if err := b.load(); err != nil {
+			return nil, err
+		}
+	}
+	go b.redeliverLoop()
+	return b, nil
+}
+
+// load replays the file and compacts it down to the messages still waiting.
+// Messages that were in flight when the broker stopped are simply ready again.
+func (b *Broker) load() error {
+	s, err := openStore(b.path, b.opts.SyncWrites)
+	if err != nil {
+		return err
+	}
+	err = s.replay(func(f frame) {
+		q := b.queue(f.queue)
+		switch f.op {
+		case opPush:
+			q.msgs[f.id] = &Message{ID: f.id, Queue: f.queue, Body: f.body}
+			b.nextID = max(b.nextID, f.id+1)
+		case opAck:
+			delete(q.msgs, f.id)
+		}
+	})
+	if err != nil {
+		s.close()
+		return err
+	}
+
+	var live []frame
+	for name, q := range b.queues {
+		for id, m := range q.msgs {
+			q.ready = append(q.ready, id)
+			live = append(live, frame{op: opPush, id: id, queue: name, body: m.Body})
+		}
+		sort.Slice(q.ready, func(i, j int) bool { return q.ready[i] < q.ready[j] })
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].id < live[j].id })
+	if err := s.rewrite(b.path, live); err != nil {
+		s.close()
+		return err
+	}
+	b.store = s
+	return nil
+}
+
+// queue returns the named queue, creating it on first use. b.mu must be held.
+func (b *Broker) queue(name string) *queue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = newQueue()
+		b.queues[name] = q
+	}
+	return q
+}
+
+// Push stores a message and returns its id. The message is on disk before
+// Push returns, so an OK to the producer is a promise.
+func (b *Broker) Push(queue string, body []byte) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return 0, ErrClosed
+	}
+	id := b.nextID
+	if b.store != nil {
+		if err := b.store.append(frame{op: opPush, id: id, queue: queue, body: body}); err != nil {
+			return 0, fmt.Errorf("mq: persisting: %w", err)
+		}
+	}
+	b.nextID++
+	q := b.queue(queue)
+	q.msgs[id] = &Message{ID: id, Queue: queue, Body: body}
+	q.ready = append(q.ready, id)
+	q.notify()
+	return id, nil
+}
+
+// Pull hands out the oldest ready message of queue to owner, waiting up to
+// wait for one to arrive. It returns nil if none did.
+func (b *Broker) Pull(ctx context.Context, queue string, owner uint64, wait time.Duration) (*Message, error) {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return nil, ErrClosed
+		}
+		q := b.queue(queue)
+		if len(q.ready) > 0 {
+			id := q.ready[0]
+			q.ready = q.ready[1:]
+			m := q.msgs[id]
+			m.Deliveries++
+			q.inflight[id] = inflight{deadline: time.Now().Add(b.opts.AckTimeout), owner: owner}
+			out := *m
+			b.mu.Unlock()
+			return &out, nil
+		}
+		wake := q.wake
+		b.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-timer.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-b.stop:
+			return nil, ErrClosed
+		}
+	}
+}
+
+// Ack removes a message delivered to owner for good. A message in flight to
+// another owner is not owner's to acknowledge.
+func (b *Broker) Ack(queue string, owner, id uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return ErrClosed
+	}
+	q, ok := b.queues[queue]
+	if !ok {
+		return ErrUnknownMessage
+	}
+	if f, ok := q.inflight[id]; !ok || f.owner != owner {
+		// never delivered, acked twice, or redelivered after a timeout: in the
+		// last case the ack came too late and the new consumer owns it now.
+		return ErrUnknownMessage
+	}
+	if b.store != nil {
+		if err := b.store.append(frame{op: opAck, id: id, queue: queue}); err != nil {
+			return fmt.Errorf("mq: persisting: %w", err)
+		}
+	}
+	delete(q.inflight, id)
+	delete(q.msgs, id)
+	return nil
+}
+
+// Release makes the unacknowledged messages of owner ready again at once,
+// for a consumer that disconnected: no need to wait for the ack timeout.
+func (b *Broker) Release(owner uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.requeue(func(f inflight) bool { return f.owner == owner })
+}
+
+// requeue puts the in-flight messages matching fn back at the front of their
+// queue, in id order. b.mu must be held.
+func (b *Broker) requeue(match func(inflight) bool) {
+	for _, q := range b.queues {
+		var back []uint64
+		for id, f := range q.inflight {
+			if match(f) {
+				back = append(back, id)
+				delete(q.inflight, id)
+			}
+		}
+		if len(back) == 0 {
+			continue
+		}
+		sort.Slice(back, func(i, j int) bool { return back[i] < back[j] })
+		q.ready = append(back, q.ready...)
+		q.notify()
+	}
+}
+
+func (b *Broker) redeliverLoop() {
+	defer close(b.done)
+	ticker := time.NewTicker(max(b.opts.AckTimeout/4, 10*time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case now := <-ticker.C:
+			b.mu.Lock()
+			b.requeue(func(f inflight) bool { return now.After(f.deadline) })
+			b.mu.Unlock()
+		}
+	}
+}
+
+// QueueStats counts the messages of a queue.
+type QueueStats struct {
+	Ready    int
+	InFlight int // delivered, not acknowledged yet
+}
+
+func (b *Broker) Stats() map[string]QueueStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := make(map[string]QueueStats, len(b.queues))
+	for name, q := range b.queues {
+		s[name] = QueueStats{Ready: len(q.ready), InFlight: len(q.inflight)}
+	}
+	return s
+}
+
+// Close stops the broker and closes its file. Pulls waiting return ErrClosed.
+func (b *Broker) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	close(b.stop)
+	b.mu.Unlock()
+	<-b.done
+
+	if b.store != nil {
+		return b.store.close()
+	}
+	return nil
+}
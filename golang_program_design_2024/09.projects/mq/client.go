@@ -0,0 +1,85 @@
+package mq
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrEmpty is returned by Pull when no message arrived in time.
+var ErrEmpty = errors.New("mq: queue empty")
+
+// Client is a connection to a broker. Requests are sent one at a time, a
+// Client is safe for concurrent use but a slow Pull holds up the others:
+// open one Client per consumer.
+type Client struct {
+	mu   sync.Mutex
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func Dial(addr string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (c *Client) roundTrip(req frame) (frame, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := req.write(c.conn); err != nil {
+		return frame{}, err
+	}
+	reply, err := readFrame(c.r)
+	if err != nil {
+		return frame{}, err
+	}
+	if reply.op == opErr {
+		return frame{}, remoteError(string(reply.body))
+	}
+	return reply, nil
+}
+
+// remoteError turns an ERR reply back into the broker's sentinel error when
+// there is one, so errors.Is works on both sides of the connection.
+func remoteError(msg string) error {
+	for _, err := range []error{ErrUnknownMessage, ErrClosed} {
+		if msg == err.Error() {
+			return err
+		}
+	}
+	return fmt.Errorf("mq: broker: %s", msg)
+}
+
+// Push sends a message to queue and returns the id the broker gave it.
+func (c *Client) Push(queue string, body []byte) (uint64, error) {
+	reply, err := c.roundTrip(frame{op: opPush, queue: queue, body: body})
+	return reply.id, err
+}
+
+// Pull takes the next message of queue, waiting up to wait for one.
+func (c *Client) Pull(queue string, wait time.Duration) (*Message, error) {
+	reply, err := c.roundTrip(frame{op: opPull, queue: queue, id: uint64(wait / time.Millisecond)})
+	if err != nil {
+		return nil, err
+	}
+	if reply.op == opEmpty {
+		return nil, ErrEmpty
+	}
+	return &Message{ID: reply.id, Queue: reply.queue, Body: reply.body}, nil
+}
+
+// Ack confirms that a pulled message was processed.
+func (c *Client) Ack(m *Message) error {
+	_, err := c.roundTrip(frame{op: opAck, queue: m.Queue, id: m.ID})
+	return err
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/mq"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+mq runs the broker, or with -demo an end-to-end scenario against a local one:
+consumers that crash or forget to ack, a broker restart, and a long poll.
+Its cases are tests: go test ./golang_program_design_2024/09.projects/mq
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/mq/cmd/mq -addr :7070 -data queues.log
+	go run ./golang_program_design_2024/09.projects/mq/cmd/mq -demo
+*/
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:7070", "listen address")
+	data := flag.String("data", "mq.log", "persistence file, empty for memory only")
+	ackTimeout := flag.Duration("ack-timeout", 30*time.Second, "redeliver messages not acked within this time")
+	sync := flag.Bool("sync", false, "fsync every push and ack")
+	demo := flag.Bool("demo", false, "run the end-to-end demo")
+	flag.Parse()
+
+	if *demo {
+		if err := runDemo(); err != nil {
+			xlog.New("09.projects", "mq").Error("demo failed", "err", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	log := xlog.New("09.projects", "mq")
+	b, err := mq.Open(*data, mq.Options{AckTimeout: *ackTimeout, SyncWrites: *sync})
+	if err != nil {
+		log.Error("opening broker", "err", err)
+		os.Exit(1)
+	}
+	defer b.Close()
+	for name, st := range b.Stats() {
+		log.Info("queue restored", "queue", name, "messages", st.Ready)
+	}
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Error("listen", "err", err)
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	log.Info("listening", "addr", l.Addr().String())
+	srv := &mq.Server{Broker: b, Log: log}
+	if err := srv.Serve(ctx, l); err != nil {
+		log.Error("serve", "err", err)
+	}
+}
+
+// broker is a broker and its server, which the demo restarts.
+type broker struct {
+	b      *mq.Broker
+	addr   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func start(path string) (*broker, error) {
+	b, err := mq.Open(path, mq.Options{AckTimeout: 300 * time.Millisecond})
+	if err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	br := &broker{b: b, addr: l.Addr().String(), cancel: cancel, done: make(chan struct{})}
+	srv := &mq.Server{Broker: b, Log: xlog.New("09.projects", "mq")}
+	go func() {
+		defer close(br.done)
+		srv.Serve(ctx, l)
+	}()
+	return br, nil
+}
+
+func (br *broker) stop() error {
+	br.cancel()
+	<-br.done
+	return br.b.Close()
+}
+
+func runDemo() error {
+	dir, err := os.MkdirTemp("", "mq")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mq.log")
+
+	br, err := start(path)
+	if err != nil {
+		return err
+	}
+	producer, err := mq.Dial(br.addr)
+	if err != nil {
+		return err
+	}
+	for i := 1; i <= 4; i++ {
+		if _, err := producer.Push("jobs", []byte(fmt.Sprintf("job %d", i))); err != nil {
+			return err
+		}
+	}
+	fmt.Println("pushed 4 jobs")
+
+	processed := make(map[string]int)
+
+	fmt.Println("\n== consumer A takes two jobs, acks one and crashes")
+	a, _ := mq.Dial(br.addr)
+	m1, err := a.Pull("jobs", time.Second)
+	if err != nil {
+		return err
+	}
+	m2, _ := a.Pull("jobs", time.Second)
+	a.Ack(m1)
+	processed[string(m1.Body)]++
+	fmt.Printf("A acked %q, never acks %q\n", m1.Body, m2.Body)
+	a.Close()
+
+	fmt.Println("\n== consumer B takes a job and is too slow to ack it")
+	b, _ := mq.Dial(br.addr)
+	defer b.Close()
+	slow, err := b.Pull("jobs", time.Second)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("B got %q, works on it for longer than the ack timeout\n", slow.Body)
+
+	fmt.Println("\n== consumer C drains the queue")
+	c, _ := mq.Dial(br.addr)
+	defer c.Close()
+	var got []string
+	for {
+		m, err := c.Pull("jobs", 800*time.Millisecond)
+		if errors.Is(err, mq.ErrEmpty) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		got = append(got, string(m.Body))
+		processed[string(m.Body)]++
+		c.Ack(m)
+	}
+	fmt.Printf("C processed %q\n", got)
+	fmt.Println("B's late ack:", b.Ack(slow))
+	fmt.Println("processed, job by job:", processed)
+
+	fmt.Println("\n== restart: unacked messages survive")
+	producer.Push("mail", []byte("welcome"))
+	producer.Push("mail", []byte("newsletter"))
+	d, _ := mq.Dial(br.addr)
+	m, _ := d.Pull("mail", time.Second)
+	d.Ack(m)
+	d.Close()
+	producer.Close()
+	if err := br.stop(); err != nil {
+		return err
+	}
+	if br, err = start(path); err != nil {
+		return err
+	}
+	defer br.stop()
+	stats := br.b.Stats()
+	fmt.Printf("after restart: jobs=%+v mail=%+v\n", stats["jobs"], stats["mail"])
+	e, _ := mq.Dial(br.addr)
+	defer e.Close()
+	if m, err = e.Pull("mail", time.Second); err != nil {
+		return err
+	}
+	e.Ack(m)
+	fmt.Printf("got %q back after the restart\n", m.Body)
+
+	fmt.Println("\n== long poll: the message arrives while the consumer waits")
+	p, _ := mq.Dial(br.addr)
+	defer p.Close()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		p.Push("events", []byte("ping"))
+	}()
+	start := time.Now()
+	m, err = e.Pull("events", 5*time.Second)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("got %q after %v\n", m.Body, time.Since(start).Round(10*time.Millisecond))
+	fmt.Println("queues:", queuesOf(br.b))
+	return nil
+}
+
+func queuesOf(b *mq.Broker) []string {
+	var names []string
+	for name := range b.Stats() {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
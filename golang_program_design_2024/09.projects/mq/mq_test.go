@@ -0,0 +1,378 @@
+package mq
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// serve runs a Server for b on a free port until the test ends and returns
+// its address.
+func serve(t *testing.T, b *Broker) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	s := &Server{Broker: b, Log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	go func() { done <- s.Serve(ctx, l) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return l.Addr().String()
+}
+
+func open(t *testing.T, path string, opts Options) *Broker {
+	t.Helper()
+	b, err := Open(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func dial(t *testing.T, addr string) *Client {
+	t.Helper()
+	c, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// pull takes the next message and checks its body.
+func pull(t *testing.T, c *Client, queue, want string) *Message {
+	t.Helper()
+	m, err := c.Pull(queue, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Pull(%s): %v, want %q", queue, err, want)
+	}
+	if string(m.Body) != want || m.Queue != queue {
+		t.Fatalf("Pull(%s) = %s %q, want %q", queue, m.Queue, m.Body, want)
+	}
+	return m
+}
+
+func TestPushPullAck(t *testing.T) {
+	b := open(t, "", Options{})
+	c := dial(t, serve(t, b))
+
+	for _, body := range []string{"one", "two", "three"} {
+		if _, err := c.Push("jobs", []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Push("other", []byte("elsewhere"))
+
+	// in order, and a queue never sees another's messages.
+	var got []*Message
+	for _, want := range []string{"one", "two", "three"} {
+		got = append(got, pull(t, c, "jobs", want))
+	}
+	if _, err := c.Pull("jobs", 50*time.Millisecond); !errors.Is(err, ErrEmpty) {
+		t.Fatalf("Pull of an empty queue = %v, want ErrEmpty", err)
+	}
+	if s := b.Stats()["jobs"]; s != (QueueStats{Ready: 0, InFlight: 3}) {
+		t.Errorf("stats before the acks: %+v", s)
+	}
+	for _, m := range got {
+		if err := c.Ack(m); err != nil {
+			t.Fatalf("Ack(%d): %v", m.ID, err)
+		}
+	}
+	if err := c.Ack(got[0]); !errors.Is(err, ErrUnknownMessage) {
+		t.Errorf("a second ack = %v, want ErrUnknownMessage", err)
+	}
+	if err := c.Ack(&Message{ID: 99, Queue: "jobs"}); !errors.Is(err, ErrUnknownMessage) {
+		t.Errorf("an ack of an unknown id = %v, want ErrUnknownMessage", err)
+	}
+	if err := c.Ack(&Message{ID: got[0].ID, Queue: "nowhere"}); !errors.Is(err, ErrUnknownMessage) {
+		t.Errorf("an ack on an unknown queue = %v, want ErrUnknownMessage", err)
+	}
+	if s := b.Stats()["jobs"]; s != (QueueStats{}) {
+		t.Errorf("stats after the acks: %+v", s)
+	}
+	if _, err := c.Push("", []byte("x")); err == nil {
+		t.Error("a push without a queue name succeeded")
+	}
+}
+
+// TestLongPoll pushes while a consumer waits: the pull returns the message
+// without waiting for its whole timeout.
+func TestLongPoll(t *testing.T) {
+	addr := serve(t, open(t, "", Options{}))
+	consumer, producer := dial(t, addr), dial(t, addr)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		producer.Push("events", []byte("ping"))
+	}()
+	start := time.Now()
+	m, err := consumer.Pull("events", 10*time.Second)
+	if err != nil || string(m.Body) != "ping" {
+		t.Fatalf("Pull = %v, %v; want ping", m, err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("the pull returned after %v", d)
+	}
+}
+
+// TestAckOwner checks that a consumer cannot acknowledge a message in
+// flight to another one.
+func TestAckOwner(t *testing.T) {
+	addr := serve(t, open(t, "", Options{}))
+	a, other := dial(t, addr), dial(t, addr)
+	a.Push("jobs", []byte("mine"))
+	m := pull(t, a, "jobs", "mine")
+	if err := other.Ack(m); !errors.Is(err, ErrUnknownMessage) {
+		t.Fatalf("an ack from another connection = %v, want ErrUnknownMessage", err)
+	}
+	if err := a.Ack(m); err != nil {
+		t.Fatalf("the owner's ack after the refused one: %v", err)
+	}
+}
+
+// TestRedelivery lets the ack timeout expire: the message goes to the next
+// consumer, and the late ack of the first one is refused while the second
+// one holds it.
+func TestRedelivery(t *testing.T) {
+	b := open(t, "", Options{AckTimeout: 100 * time.Millisecond})
+	addr := serve(t, b)
+	slow, next := dial(t, addr), dial(t, addr)
+	slow.Push("jobs", []byte("job"))
+
+	first := pull(t, slow, "jobs", "job")
+	again := pull(t, next, "jobs", "job")
+	if again.ID != first.ID {
+		t.Fatalf("redelivered id %d, want %d", again.ID, first.ID)
+	}
+	if err := slow.Ack(first); !errors.Is(err, ErrUnknownMessage) {
+		t.Fatalf("the late ack = %v, want ErrUnknownMessage", err)
+	}
+	if s := b.Stats()["jobs"]; s.InFlight != 1 {
+		t.Fatalf("the late ack removed the message: %+v", s)
+	}
+	if err := next.Ack(again); err != nil {
+		t.Fatal(err)
+	}
+
+	// the broker counts the deliveries.
+	b.Push("jobs", []byte("counted"))
+	var m *Message
+	for i := 1; i <= 2; i++ {
+		var err error
+		if m, err = b.Pull(context.Background(), "jobs", 42, time.Second); err != nil || m == nil {
+			t.Fatalf("delivery %d: %v, %v", i, m, err)
+		}
+	}
+	if m.Deliveries != 2 {
+		t.Errorf("Deliveries = %d, want 2", m.Deliveries)
+	}
+}
+
+// TestRelease closes a consumer holding a message: it is ready again at
+// once, long before the ack timeout.
+func TestRelease(t *testing.T) {
+	addr := serve(t, open(t, "", Options{AckTimeout: time.Hour}))
+	gone, next := dial(t, addr), dial(t, addr)
+	gone.Push("jobs", []byte("a"))
+	gone.Push("jobs", []byte("b"))
+	pull(t, gone, "jobs", "a")
+	pull(t, gone, "jobs", "b")
+	gone.Close()
+
+	// in their original order, ahead of newer messages.
+	next.Push("jobs", []byte("c"))
+	for _, want := range []string{"a", "b", "c"} {
+		next.Ack(pull(t, next, "jobs", want))
+	}
+}
+
+// TestReplay restarts the broker on the same file: what was not acked comes
+// back, in order, and the ids go on where they stopped.
+func TestReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mq.log")
+	b, err := Open(path, Options{SyncWrites: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := serve(t, b)
+	c := dial(t, addr)
+	var last uint64
+	for _, body := range []string{"acked", "in flight", "waiting"} {
+		if last, err = c.Push("mail", []byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c.Push("jobs", []byte("job"))
+	c.Ack(pull(t, c, "mail", "acked"))
+	pull(t, c, "mail", "in flight")
+	c.Close()
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	b = open(t, path, Options{})
+	want := map[string]QueueStats{"mail": {Ready: 2}, "jobs": {Ready: 1}}
+	for name, s := range b.Stats() {
+		if s != want[name] {
+			t.Errorf("after the restart %s: %+v, want %+v", name, s, want[name])
+		}
+	}
+	c = dial(t, serve(t, b))
+	pull(t, c, "mail", "in flight")
+	pull(t, c, "mail", "waiting")
+	if id, err := c.Push("mail", []byte("new")); err != nil || id <= last+1 {
+		t.Errorf("a push after the restart got id %d, %v; want more than %d", id, err, last+1)
+	}
+
+	// the replay compacted the file down to the messages still waiting.
+	b.Close()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("acked")) {
+		t.Error("the acked message is still in the file after the compaction")
+	}
+}
+
+// TestTornRecord cuts the file in the middle of the last record, as a crash
+// during a write would: the records before it are replayed.
+func TestTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mq.log")
+	b, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Push("q", []byte("kept"))
+	b.Push("q", []byte("torn"))
+	b.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(path, info.Size()-2); err != nil {
+		t.Fatal(err)
+	}
+
+	b = open(t, path, Options{})
+	if s := b.Stats()["q"]; s.Ready != 1 {
+		t.Fatalf("after the replay: %+v, want the first message only", s)
+	}
+	m, err := b.Pull(context.Background(), "q", 1, time.Second)
+	if err != nil || string(m.Body) != "kept" {
+		t.Fatalf("Pull = %v, %v; want kept", m, err)
+	}
+	// new records go after the last good one.
+	b.Push("q", []byte("after"))
+	b.Close()
+	b = open(t, path, Options{})
+	if s := b.Stats()["q"]; s.Ready != 2 {
+		t.Errorf("after a second replay: %+v, want kept and after", s)
+	}
+}
+
+// shortWriter passes n bytes to w, then fails, like a disk filling up.
+type shortWriter struct {
+	w io.Writer
+	n int
+}
+
+func (s *shortWriter) Write(p []byte) (int, error) {
+	if len(p) <= s.n {
+		s.n -= len(p)
+		return s.w.Write(p)
+	}
+	n, _ := s.w.Write(p[:s.n])
+	s.n = 0
+	return n, errors.New("disk full")
+}
+
+// TestFailedWrite fails a push halfway through its record: the push
+// errors, the part written is cut off, and the next push works.
+func TestFailedWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mq.log")
+	b, err := Open(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Push("q", []byte("kept"))
+	b.mu.Lock()
+	b.store.w = bufio.NewWriterSize(&shortWriter{w: b.store.f, n: 10}, 16)
+	b.mu.Unlock()
+	if _, err := b.Push("q", []byte("lost in the failed write")); err == nil {
+		t.Fatal("Push succeeded with a failing writer")
+	}
+	if _, err := b.Push("q", []byte("after")); err != nil {
+		t.Fatalf("Push after a failed write: %v", err)
+	}
+	b.Close()
+
+	b = open(t, path, Options{})
+	for _, want := range []string{"kept", "after"} {
+		m, err := b.Pull(context.Background(), "q", 1, time.Second)
+		if err != nil || string(m.Body) != want {
+			t.Fatalf("Pull = %v, %v; want %s", m, err, want)
+		}
+	}
+	if s := b.Stats()["q"]; s.Ready != 0 {
+		t.Errorf("after the replay: %+v, want kept and after only", s)
+	}
+}
+
+func TestClosed(t *testing.T) {
+	b := open(t, "", Options{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.Pull(context.Background(), "q", 1, time.Minute)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	b.Close()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("a waiting Pull = %v, want ErrClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a waiting Pull did not return on Close")
+	}
+	if _, err := b.Push("q", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Push after Close = %v, want ErrClosed", err)
+	}
+}
+
+func TestFrame(t *testing.T) {
+	for _, f := range []frame{
+		{op: opPush, queue: "jobs", body: []byte("body")},
+		{op: opOK, id: 1<<64 - 1, queue: "q"},
+		{op: opEmpty},
+	} {
+		got, err := readFrame(bytes.NewReader(f.encode(nil)))
+		if err != nil || got.op != f.op || got.id != f.id || got.queue != f.queue || !bytes.Equal(got.body, f.body) {
+			t.Errorf("round trip of %+v = %+v, %v", f, got, err)
+		}
+	}
+	data := frame{op: opPush, queue: "jobs", body: []byte("body")}.encode(nil)
+	if _, err := readFrame(bytes.NewReader(data[:len(data)-1])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("a cut frame = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if _, err := readFrame(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff})); !errors.Is(err, errFrameTooLarge) {
+		t.Errorf("a huge length = %v, want errFrameTooLarge", err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package mq is a minimal message broker: clients push messages to named
+// queues and pull them; a pulled message must be acknowledged, or it is
+// delivered again (at-least-once delivery). Messages are kept in an
+// append-only file so that they survive a restart of the broker.
+package mq
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+/*
+Clients and the broker exchange length-prefixed frames over TCP:
+
+	+------------+------+--------+---------------+-------+------+
+	| length (4) | op 1 | id (8) | queueLen (2)  | queue | body |
+	+------------+------+--------+---------------+-------+------+
+
+length counts the bytes after itself; integers are big endian, the usual
+network order. Every request gets exactly one reply:
+
+	PUSH queue body        -> OK id
+	PULL queue id=wait ms  -> MSG id body | EMPTY when nothing came in time
+	ACK  queue id          -> OK id
+	any failure            -> ERR body=message
+
+The persistence file stores the same frames, each preceded by a crc32 of the
+frame so that a record torn by a crash is detected on replay.
+*/
+
+const (
+	opPush byte = iota + 1
+	opPull
+	opAck
+	opMsg
+	opOK
+	opEmpty
+	opErr
+)
+
+// maxFrame bounds what a peer can make us allocate.
+const maxFrame = 16 << 20
+
+var errFrameTooLarge = errors.New("mq: frame too large")
+
+type frame struct {
+	op    byte
+	id    uint64
+	queue string
+	body  []byte
+}
+
+// encode appends the binary form of f, length prefix included, to buf.
+func (f frame) encode(buf []byte) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(1+8+2+len(f.queue)+len(f.body)))
+	buf = append(buf, f.op)
+	buf = binary.BigEndian.AppendUint64(buf, f.id)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(f.queue)))
+	buf = append(buf, f.queue...)
+	return append(buf, f.body...)
+}
+
+func (f frame) write(w io.Writer) error {
+	_, err := w.Write(f.encode(nil))
+	return err
+}
+
+// readFrame reads one frame. It returns io.EOF if the stream ends cleanly
+// before the frame and io.ErrUnexpectedEOF if it ends inside it.
+func readFrame(r io.Reader) (frame, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return frame{}, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxFrame {
+		return frame{}, errFrameTooLarge
+	}
+	if n < 1+8+2 {
+		return frame{}, fmt.Errorf("mq: frame of %d bytes is too short", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return frame{}, err
+	}
+	return parseFrame(data)
+}
+
+// parseFrame decodes a frame without its length prefix.
+func parseFrame(data []byte) (frame, error) {
+	f := frame{op: data[0], id: binary.BigEndian.Uint64(data[1:9])}
+	qlen := int(binary.BigEndian.Uint16(data[9:11]))
+	if 11+qlen > len(data) {
+		return frame{}, errors.New("mq: queue name longer than the frame")
+	}
+	f.queue = string(data[11 : 11+qlen])
+	f.body = data[11+qlen:]
+	return f, nil
+}
@@ -0,0 +1,134 @@
+package mq
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxWait caps how long a PULL may block, whatever the client asks for.
+const maxWait = 30 * time.Second
+
+// Server serves a Broker over TCP, one goroutine per connection, the same
+// way as cacheserver.Server.
+type Server struct {
+	Broker *Broker
+	Log    *slog.Logger
+
+	ids   atomic.Uint64
+	wg    sync.WaitGroup
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// Serve accepts connections on l until ctx is cancelled, then closes every
+// connection and waits for their goroutines.
+func (s *Server) Serve(ctx context.Context, l net.Listener) error {
+	s.conns = make(map[net.Conn]struct{})
+	go func() {
+		<-ctx.Done()
+		l.Close()
+		s.mu.Lock()
+		for c := range s.conns {
+			c.Close()
+		}
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.wg.Wait()
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		if ctx.Err() != nil {
+			conn.Close()
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.mu.Lock()
+				delete(s.conns, conn)
+				s.mu.Unlock()
+				conn.Close()
+			}()
+			s.handle(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn) {
+	owner := s.ids.Add(1)
+	log := s.Log.With("remote", conn.RemoteAddr().String(), "conn", owner)
+	log.Info("client connected")
+	defer log.Info("client disconnected")
+	// whatever this client pulled and did not ack is for the others now.
+	defer s.Broker.Release(owner)
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		req, err := readFrame(r)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				log.Warn("bad frame", "err", err)
+			}
+			return
+		}
+		reply := s.exec(ctx, owner, req)
+		if err := reply.write(w); err != nil {
+			return
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) exec(ctx context.Context, owner uint64, req frame) frame {
+	if req.queue == "" {
+		return errFrame(errors.New("missing queue name"))
+	}
+	switch req.op {
+	case opPush:
+		id, err := s.Broker.Push(req.queue, req.body)
+		if err != nil {
+			return errFrame(err)
+		}
+		return frame{op: opOK, id: id, queue: req.queue}
+	case opPull:
+		wait := min(time.Duration(req.id)*time.Millisecond, maxWait)
+		m, err := s.Broker.Pull(ctx, req.queue, owner, wait)
+		switch {
+		case err != nil:
+			return errFrame(err)
+		case m == nil:
+			return frame{op: opEmpty, queue: req.queue}
+		}
+		return frame{op: opMsg, id: m.ID, queue: m.Queue, body: m.Body}
+	case opAck:
+		if err := s.Broker.Ack(req.queue, owner, req.id); err != nil {
+			return errFrame(err)
+		}
+		return frame{op: opOK, id: req.id, queue: req.queue}
+	}
+	return errFrame(errors.New("unknown op"))
+}
+
+func errFrame(err error) frame {
+	return frame{op: opErr, body: []byte(err.Error())}
+}
@@ -0,0 +1,141 @@
+package mq
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// store is the append-only persistence file: one PUSH record per message
+// received and one ACK record per message acknowledged. Replaying it
+// rebuilds the messages still waiting; like kvstore, a torn record at the
+// end is cut off.
+type store struct {
+	f    *os.File
+	w    *bufio.Writer
+	sync bool
+	size int64 // the end of the last record written in full
+}
+
+func openStore(path string, sync bool) (*store, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &store{f: f, w: bufio.NewWriter(f), sync: sync}, nil
+}
+
+// replay calls fn for every record and truncates the file after the last
+// good one, so that new records are not appended after garbage.
+func (s *store) replay(fn func(frame)) error {
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	r := bufio.NewReader(s.f)
+	var good int64
+	for {
+		var sum [4]byte
+		if _, err := io.ReadFull(r, sum[:]); err != nil {
+			break
+		}
+		data, err := readRaw(r)
+		if err != nil || crc32.ChecksumIEEE(data) != binary.BigEndian.Uint32(sum[:]) {
+			break
+		}
+		f, err := parseFrame(data[4:])
+		if err != nil {
+			break
+		}
+		fn(f)
+		good += int64(4 + len(data))
+	}
+	s.size = good
+	return s.rollback()
+}
+
+// readRaw reads a frame and returns its bytes, length prefix included.
+func readRaw(r io.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxFrame || n < 1+8+2 {
+		return nil, errFrameTooLarge
+	}
+	data := make([]byte, 4+n)
+	copy(data, size[:])
+	_, err := io.ReadFull(r, data[4:])
+	return data, err
+}
+
+// append writes records and, when sync is set, waits for them to reach the disk.
+// On failure none of them is kept.
+func (s *store) append(frames ...frame) error {
+	var n int64
+	for _, f := range frames {
+		data := f.encode(nil)
+		var sum [4]byte
+		binary.BigEndian.PutUint32(sum[:], crc32.ChecksumIEEE(data))
+		s.w.Write(sum[:])
+		s.w.Write(data)
+		n += int64(len(sum) + len(data))
+	}
+	if err := s.w.Flush(); err != nil {
+		return errors.Join(err, s.rollback())
+	}
+	if s.sync {
+		if err := s.f.Sync(); err != nil {
+			return errors.Join(err, s.rollback())
+		}
+	}
+	s.size += n
+	return nil
+}
+
+// rollback cuts the file back to s.size after a failed write, as kvstore
+// does, and resets the writer: a bufio.Writer keeps its first error and
+// would fail every append after it. The records written in part would
+// otherwise stay in the file, and the next replay would stop at them.
+func (s *store) rollback() error {
+	s.w.Reset(s.f)
+	if err := s.f.Truncate(s.size); err != nil {
+		return err
+	}
+	_, err := s.f.Seek(s.size, io.SeekStart)
+	return err
+}
+
+// rewrite replaces the file by the records given, the live messages: the
+// file only grows otherwise. It writes a new file and renames it over the old.
+func (s *store) rewrite(path string, frames []frame) error {
+	tmp, err := openStore(path+".compact", false)
+	if err != nil {
+		return err
+	}
+	if err := tmp.f.Truncate(0); err != nil {
+		tmp.f.Close()
+		return err
+	}
+	err = tmp.append(frames...)
+	if err == nil {
+		err = tmp.f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(path+".compact", path)
+	}
+	if err != nil {
+		tmp.f.Close()
+		return errors.Join(err, os.Remove(path+".compact"))
+	}
+	s.f.Close()
+	s.f, s.w, s.size = tmp.f, bufio.NewWriter(tmp.f), tmp.size
+	return nil
+}
+
+func (s *store) close() error {
+	return errors.Join(s.w.Flush(), s.f.Close())
+}
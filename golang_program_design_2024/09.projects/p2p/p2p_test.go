@@ -0,0 +1,282 @@
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func discard() *slog.Logger { return slog.New(slog.NewTextHandler(io.Discard, nil)) }
+
+// writeRandom writes size random bytes to a file in a temporary directory.
+func writeRandom(t *testing.T, name string, size int) (string, []byte) {
+	t.Helper()
+	data := make([]byte, size)
+	rand.New(rand.NewSource(int64(size))).Read(data)
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path, data
+}
+
+// serve starts a peer serving the file at path on a free port, stopped with
+// the test.
+func serve(t *testing.T, path string, chunkSize int) (*Sender, string) {
+	t.Helper()
+	s, err := NewSender(path, chunkSize, discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ln) }()
+	t.Cleanup(func() {
+		s.Close()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return s, ln.Addr().String()
+}
+
+func fetch(t *testing.T, r *Receiver, addr, id string) (string, Stats, error) {
+	t.Helper()
+	dir := t.TempDir()
+	_, stats, err := r.Fetch(context.Background(), addr, id, dir)
+	return dir, stats, err
+}
+
+func checkFile(t *testing.T, path string, want []byte) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s: %d bytes differ from the %d sent", path, len(got), len(want))
+	}
+}
+
+// TestSwarm runs several peers: three receivers fetch from one sender at
+// once, then one of them serves its copy to a fourth peer, which checks it
+// against the id the first sender published.
+func TestSwarm(t *testing.T) {
+	path, data := writeRandom(t, "big.bin", 1<<20+123)
+	seed, addr := serve(t, path, 16<<10)
+	id := seed.Manifest.ID()
+
+	var wg sync.WaitGroup
+	dirs := make([]string, 3)
+	for i := range dirs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := NewReceiver(discard())
+			r.Conns = 2 + i
+			dir, stats, err := fetch(t, r, addr, id)
+			if err != nil {
+				t.Errorf("receiver %d: %v", i, err)
+				return
+			}
+			total := 0
+			for _, n := range stats.PerConn {
+				total += n
+			}
+			if total != len(seed.Manifest.Chunks) || stats.Bad != 0 || len(stats.PerConn) != r.Conns {
+				t.Errorf("receiver %d: %+v", i, stats)
+			}
+			dirs[i] = dir
+		}()
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	for _, dir := range dirs {
+		checkFile(t, filepath.Join(dir, "big.bin"), data)
+		if _, err := os.Stat(filepath.Join(dir, "big.bin.part")); !os.IsNotExist(err) {
+			t.Errorf("%s: the .part file is left: %v", dir, err)
+		}
+	}
+
+	// the copy hashes to the same manifest: any peer can serve the file.
+	_, relay := serve(t, filepath.Join(dirs[0], "big.bin"), 16<<10)
+	dir, _, err := fetch(t, NewReceiver(discard()), relay, id)
+	if err != nil {
+		t.Fatalf("from the relay: %v", err)
+	}
+	checkFile(t, filepath.Join(dir, "big.bin"), data)
+}
+
+// TestTampered damages chunks on the way: each bad one is asked again, on
+// any connection, until the sender gives up lying or the retries run out.
+func TestTampered(t *testing.T) {
+	path, data := writeRandom(t, "file.bin", 100<<10)
+	s, addr := serve(t, path, 8<<10)
+
+	var mu sync.Mutex
+	damaged := map[int]int{}
+	s.Tamper = func(i int, chunk []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		if i%3 == 0 && damaged[i] < 2 {
+			damaged[i]++
+			chunk[0] ^= 0xff
+		}
+	}
+	dir, stats, err := fetch(t, NewReceiver(discard()), addr, s.Manifest.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, filepath.Join(dir, "file.bin"), data)
+	if want := 2 * 5; stats.Bad != want { // chunks 0 3 6 9 12, twice each
+		t.Errorf("%d bad chunks, want %d", stats.Bad, want)
+	}
+
+	// a peer always lying: the retries run out, nothing is left in dir.
+	s.Tamper = func(i int, chunk []byte) {
+		if i == 4 {
+			chunk[0] ^= 0xff
+		}
+	}
+	r := NewReceiver(discard())
+	r.Retries = 2
+	dir, stats, err = fetch(t, r, addr, "")
+	if !errors.Is(err, ErrChunk) || stats.Bad != 3 {
+		t.Errorf("a lying peer: %v, %d bad chunks; want ErrChunk after 3", err, stats.Bad)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("files left after a failed transfer: %v", entries)
+	}
+}
+
+func TestWrongID(t *testing.T) {
+	path, _ := writeRandom(t, "file.bin", 1000)
+	_, addr := serve(t, path, 256)
+	other, _ := writeRandom(t, "other.bin", 2000)
+	m, err := NewManifest(other, 256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, _, err := fetch(t, NewReceiver(discard()), addr, m.ID())
+	if !errors.Is(err, ErrManifest) {
+		t.Errorf("Fetch with another id = %v, want ErrManifest", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("files written for a refused manifest: %v", entries)
+	}
+}
+
+// TestCancel cancels a transfer from a peer slowed down: Fetch returns the
+// cancellation, and removes the partial file.
+func TestCancel(t *testing.T) {
+	path, _ := writeRandom(t, "slow.bin", 64<<10)
+	s, addr := serve(t, path, 1<<10)
+	s.Tamper = func(int, []byte) { time.Sleep(20 * time.Millisecond) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	dir := t.TempDir()
+	start := time.Now()
+	_, _, err := NewReceiver(discard()).Fetch(ctx, addr, "", dir)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Fetch = %v, want the deadline", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("Fetch returned %v after the cancel", d)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("files left after the cancel: %v", entries)
+	}
+}
+
+// TestSizes transfers files around the chunk size, the empty one included.
+func TestSizes(t *testing.T) {
+	for _, size := range []int{0, 1, 1023, 1024, 1025, 10 << 10} {
+		path, data := writeRandom(t, "f.bin", size)
+		s, addr := serve(t, path, 1024)
+		if want := (size + 1023) / 1024; len(s.Manifest.Chunks) != want {
+			t.Errorf("%d bytes: %d chunks, want %d", size, len(s.Manifest.Chunks), want)
+		}
+		dir, _, err := fetch(t, NewReceiver(discard()), addr, s.Manifest.ID())
+		if err != nil {
+			t.Errorf("%d bytes: %v", size, err)
+			continue
+		}
+		checkFile(t, filepath.Join(dir, "f.bin"), data)
+	}
+}
+
+func TestManifestCheck(t *testing.T) {
+	good := Manifest{Name: "f", Size: 10, ChunkSize: 4, Chunks: []string{"a", "b", "c"}}
+	if err := good.check(); err != nil {
+		t.Fatal(err)
+	}
+	for name, change := range map[string]func(m *Manifest){
+		"a path":          func(m *Manifest) { m.Name = "../../etc/passwd" },
+		"a directory":     func(m *Manifest) { m.Name = "a/f" },
+		"dot dot":         func(m *Manifest) { m.Name = ".." },
+		"no name":         func(m *Manifest) { m.Name = "" },
+		"no chunk size":   func(m *Manifest) { m.ChunkSize = 0 },
+		"huge chunks":     func(m *Manifest) { m.ChunkSize = MaxChunkSize + 1 },
+		"negative size":   func(m *Manifest) { m.Size = -1 },
+		"a chunk missing": func(m *Manifest) { m.Chunks = m.Chunks[:2] },
+		"a chunk more":    func(m *Manifest) { m.Chunks = append(m.Chunks, "d") },
+	} {
+		m := good
+		m.Chunks = append([]string(nil), good.Chunks...)
+		change(&m)
+		if err := m.check(); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+// TestProtocol sends the sender requests a receiver never makes.
+func TestProtocol(t *testing.T) {
+	path, _ := writeRandom(t, "f.bin", 100)
+	_, addr := serve(t, path, 64)
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	for _, tt := range []struct {
+		op      byte
+		payload []byte
+		want    byte
+	}{
+		{opChunk, index(2), opErr},
+		{opChunk, []byte{1}, opErr},
+		{opErr, nil, opErr},
+		{opChunk, index(1), opChunk}, // the connection still works
+	} {
+		if err := writeFrame(c, tt.op, tt.payload); err != nil {
+			t.Fatal(err)
+		}
+		op, payload, err := readFrame(c)
+		if err != nil || op != tt.want {
+			t.Errorf("op %d %v: got %d %q, %v; want op %d", tt.op, tt.payload, op, payload, err, tt.want)
+		}
+	}
+
+	if _, _, err := readFrame(bytes.NewReader([]byte{opChunk, 0xff, 0xff, 0xff, 0xff})); !errors.Is(err, errFrameTooLarge) {
+		t.Errorf("a huge frame = %v, want errFrameTooLarge", err)
+	}
+	if _, _, err := readFrame(bytes.NewReader([]byte{opChunk, 0, 0, 0, 4, 1})); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("a cut frame = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
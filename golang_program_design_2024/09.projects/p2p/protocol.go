@@ -0,0 +1,74 @@
+package p2p
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+/*
+A peer asks and the other answers, one frame each, as many times as it
+wants on one connection:
+
+	+--------+------------+---------+
+	| op (1) | length (4) | payload |
+	+--------+------------+---------+
+
+	MANIFEST                -> MANIFEST json
+	CHUNK index (4 bytes)   -> CHUNK index data
+	anything failing        -> ERR message
+
+Integers are big endian. A receiver keeps its connections open and asks
+for chunk after chunk on each.
+*/
+
+const (
+	opManifest byte = iota + 1
+	opChunk
+	opErr
+)
+
+// maxFrame bounds what a peer can make us allocate: the largest chunk and
+// its index.
+const maxFrame = MaxChunkSize + 4
+
+var errFrameTooLarge = errors.New("p2p: frame too large")
+
+func writeFrame(w io.Writer, op byte, parts ...[]byte) error {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	var head [5]byte
+	head[0] = op
+	binary.BigEndian.PutUint32(head[1:], uint32(n))
+	// one write per frame: the header alone would make a tiny TCP segment.
+	buf := make([]byte, 0, 5+n)
+	buf = append(buf, head[:]...)
+	for _, p := range parts {
+		buf = append(buf, p...)
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+func readFrame(r io.Reader) (op byte, payload []byte, err error) {
+	var head [5]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return 0, nil, err
+	}
+	n := binary.BigEndian.Uint32(head[1:])
+	if n > maxFrame {
+		return 0, nil, errFrameTooLarge
+	}
+	payload = make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	return head[0], payload, nil
+}
+
+func index(i int) []byte { return binary.BigEndian.AppendUint32(nil, uint32(i)) }
@@ -0,0 +1,222 @@
+package p2p
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Receiver fetches files from senders.
+type Receiver struct {
+	Conns   int // connections in parallel
+	Retries int // times a chunk is asked again after a bad hash
+	Log     *slog.Logger
+}
+
+// Stats tells how a transfer went.
+type Stats struct {
+	Chunks  int
+	Bad     int   // chunks received with a wrong hash, and asked again
+	PerConn []int // chunks received on each connection
+}
+
+// NewReceiver returns a receiver using 4 connections and asking a chunk
+// again up to 3 times.
+func NewReceiver(log *slog.Logger) *Receiver {
+	return &Receiver{Conns: 4, Retries: 3, Log: log}
+}
+
+// Fetch gets the manifest from the sender at addr, checks it against id
+// unless id is empty, and fetches the file into dir under the name of the
+// manifest. The file is written to name.part and renamed once complete and
+// checked: there is never a partial file under the final name.
+func (r *Receiver) Fetch(ctx context.Context, addr, id, dir string) (*Manifest, Stats, error) {
+	var d net.Dialer
+	first, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, Stats{}, err
+	}
+	m, err := getManifest(first)
+	if err != nil {
+		first.Close()
+		return nil, Stats{}, err
+	}
+	if id != "" && m.ID() != id {
+		first.Close()
+		return nil, Stats{}, fmt.Errorf("%w: got %s", ErrManifest, m.ID())
+	}
+
+	final := filepath.Join(dir, m.Name)
+	part := final + ".part"
+	f, err := os.Create(part)
+	if err != nil {
+		first.Close()
+		return nil, Stats{}, err
+	}
+	stats, err := r.fetch(ctx, first, addr, m, f)
+	if err == nil {
+		err = verify(f, m)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(part, final)
+	}
+	if err != nil {
+		os.Remove(part)
+		return nil, stats, err
+	}
+	return m, stats, nil
+}
+
+func getManifest(c net.Conn) (*Manifest, error) {
+	if err := writeFrame(c, opManifest); err != nil {
+		return nil, err
+	}
+	op, payload, err := readFrame(c)
+	if err != nil {
+		return nil, err
+	}
+	if op != opManifest {
+		return nil, fmt.Errorf("p2p: manifest refused: %s", payload)
+	}
+	var m Manifest
+	if err := json.Unmarshal(payload, &m); err != nil {
+		return nil, fmt.Errorf("p2p: bad manifest: %w", err)
+	}
+	return &m, m.check()
+}
+
+// fetch runs the connections: the first one, already open, and Conns-1
+// more. They take chunk numbers from one channel, so a fast connection
+// simply takes more of them; a bad chunk goes back into the channel, for
+// whichever connection is free next.
+func (r *Receiver) fetch(ctx context.Context, first net.Conn, addr string, m *Manifest, f *os.File) (Stats, error) {
+	if err := f.Truncate(m.Size); err != nil {
+		first.Close()
+		return Stats{}, err
+	}
+	n := len(m.Chunks)
+	stats := Stats{Chunks: n, PerConn: make([]int, max(r.Conns, 1))}
+	// room for every chunk: putting one back never blocks.
+	jobs := make(chan int, n)
+	for i := range n {
+		jobs <- i
+	}
+	if n == 0 {
+		close(jobs)
+	}
+	var (
+		pending atomic.Int64
+		mu      sync.Mutex
+		tries   = make([]int, n)
+	)
+	pending.Store(int64(n))
+
+	g, ctx := errgroup.WithContext(ctx)
+	for w := range stats.PerConn {
+		g.Go(func() error {
+			c := first
+			if w > 0 {
+				var d net.Dialer
+				var err error
+				if c, err = d.DialContext(ctx, "tcp", addr); err != nil {
+					return err
+				}
+			}
+			defer c.Close()
+			// a connection blocked in a read notices the end of ctx
+			// through its deadline.
+			stop := context.AfterFunc(ctx, func() { c.SetDeadline(time.Now()) })
+			defer stop()
+			for {
+				var i int
+				var ok bool
+				select {
+				case i, ok = <-jobs:
+					if !ok {
+						return nil
+					}
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+				chunk, err := getChunk(c, i)
+				if err != nil {
+					if ctx.Err() != nil {
+						// the deadline set above, not the network.
+						return ctx.Err()
+					}
+					return err
+				}
+				sum := sha256.Sum256(chunk)
+				if hex.EncodeToString(sum[:]) != m.Chunks[i] {
+					mu.Lock()
+					tries[i]++
+					stats.Bad++
+					t := tries[i]
+					mu.Unlock()
+					again := t <= r.Retries
+					r.Log.Warn("bad chunk", "chunk", i, "conn", w, "retry", again)
+					if !again {
+						return fmt.Errorf("%w: chunk %d, %d times", ErrChunk, i, t)
+					}
+					jobs <- i
+					continue
+				}
+				off, _ := m.chunk(i)
+				if _, err := f.WriteAt(chunk, off); err != nil {
+					return err
+				}
+				stats.PerConn[w]++
+				if pending.Add(-1) == 0 {
+					close(jobs)
+				}
+			}
+		})
+	}
+	// Wait returns the first error, the cause: the others are the
+	// cancellation it caused.
+	err := g.Wait()
+	return stats, err
+}
+
+func getChunk(c net.Conn, i int) ([]byte, error) {
+	if err := writeFrame(c, opChunk, index(i)); err != nil {
+		return nil, err
+	}
+	op, payload, err := readFrame(c)
+	if err != nil {
+		return nil, err
+	}
+	if op != opChunk || len(payload) < 4 || int(binary.BigEndian.Uint32(payload)) != i {
+		return nil, fmt.Errorf("p2p: chunk %d refused: %q", i, payload)
+	}
+	return payload[4:], nil
+}
+
+// verify hashes the whole file again. Every chunk was checked, this checks
+// the file is made of them in the right places.
+func verify(f *os.File, m *Manifest) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, 0, m.Size)); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != m.SHA256 {
+		return fmt.Errorf("%w: %s", ErrFile, got)
+	}
+	return nil
+}
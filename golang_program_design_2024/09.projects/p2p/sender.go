@@ -0,0 +1,122 @@
+package p2p
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+)
+
+// Sender serves a file to receivers.
+type Sender struct {
+	Manifest *Manifest
+	Log      *slog.Logger
+	// Tamper, if set, can change a chunk before it is sent: it plays a
+	// faulty or lying peer in the demo.
+	Tamper func(i int, chunk []byte)
+
+	file     *os.File
+	manifest []byte
+
+	mu    sync.Mutex
+	conns map[net.Conn]bool
+	ln    net.Listener
+}
+
+// NewSender hashes the file at path, and keeps it open to serve it.
+func NewSender(path string, chunkSize int, log *slog.Logger) (*Sender, error) {
+	m, err := NewManifest(path, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	b, _ := json.Marshal(m)
+	return &Sender{Manifest: m, Log: log, file: f, manifest: b, conns: make(map[net.Conn]bool)}, nil
+}
+
+// Serve accepts connections on ln until Close.
+func (s *Sender) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.ln = ln
+	s.mu.Unlock()
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		s.mu.Lock()
+		s.conns[c] = true
+		s.mu.Unlock()
+		go s.serve(c)
+	}
+}
+
+// Close stops Serve, closes the connections and the file.
+func (s *Sender) Close() error {
+	s.mu.Lock()
+	if s.ln != nil {
+		s.ln.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+	return s.file.Close()
+}
+
+func (s *Sender) serve(c net.Conn) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+	served := 0
+	for {
+		op, payload, err := readFrame(c)
+		if err != nil {
+			if err != io.EOF && !errors.Is(err, net.ErrClosed) {
+				s.Log.Warn("read", "remote", c.RemoteAddr(), "err", err)
+			}
+			s.Log.Debug("connection done", "remote", c.RemoteAddr(), "chunks", served)
+			return
+		}
+		switch {
+		case op == opManifest:
+			err = writeFrame(c, opManifest, s.manifest)
+		case op == opChunk && len(payload) == 4:
+			i := int(binary.BigEndian.Uint32(payload))
+			if i >= len(s.Manifest.Chunks) {
+				err = writeFrame(c, opErr, []byte("no such chunk"))
+				break
+			}
+			off, n := s.Manifest.chunk(i)
+			buf := make([]byte, n)
+			if _, err = s.file.ReadAt(buf, off); err != nil {
+				s.Log.Error("read file", "chunk", i, "err", err)
+				err = writeFrame(c, opErr, []byte("read failed"))
+				break
+			}
+			if s.Tamper != nil {
+				s.Tamper(i, buf)
+			}
+			err = writeFrame(c, opChunk, payload, buf)
+			served++
+		default:
+			err = writeFrame(c, opErr, []byte("bad request"))
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/portscan"
+)
+
+/*
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/portscan/cmd/portscan -demo
+	go run ./golang_program_design_2024/09.projects/portscan/cmd/portscan -host 127.0.0.1 -ports 1-1024 -workers 200
+
+Only scan hosts you own or are allowed to test.
+-demo opens a few listeners on localhost and scans around them.
+*/
+
+func main() {
+	host := flag.String("host", "127.0.0.1", "host to scan")
+	spec := flag.String("ports", "1-1024", "ports, e.g. 22,80,8000-8100")
+	workers := flag.Int("workers", 100, "concurrent dials")
+	timeout := flag.Duration("timeout", 500*time.Millisecond, "per dial timeout")
+	all := flag.Bool("all", false, "also report closed and filtered ports")
+	asJSON := flag.Bool("json", false, "print the report as JSON")
+	demo := flag.Bool("demo", false, "scan listeners opened by this program")
+	flag.Parse()
+
+	if *demo {
+		*host, *spec = "127.0.0.1", demoListeners()
+	}
+	ports, err := portscan.ParsePorts(*spec)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+	results, err := portscan.Scan(ctx, *host, ports, portscan.Options{Workers: *workers, Timeout: *timeout})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "scan interrupted:", err)
+	}
+
+	var report []portscan.Result
+	for _, r := range results {
+		if *all || r.State == portscan.Open {
+			report = append(report, r)
+		}
+	}
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(report)
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PORT\tSTATE\tLATENCY")
+	for _, r := range report {
+		fmt.Fprintf(tw, "%d\t%s\t%v\n", r.Port, r.State, r.Latency.Round(time.Microsecond))
+	}
+	tw.Flush()
+	fmt.Printf("scanned %d ports of %s in %v\n", len(results), *host, time.Since(start).Round(time.Millisecond))
+}
+
+// demoListeners opens three listeners and returns a port range around them.
+// They stay open until the program exits.
+func demoListeners() string {
+	var lo, hi int
+	for i := 0; i < 3; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		go func() {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				conn.Close()
+			}
+		}()
+		port := l.Addr().(*net.TCPAddr).Port
+		fmt.Println("demo listener on port", port)
+		if lo == 0 || port < lo {
+			lo = port
+		}
+		hi = max(hi, port)
+	}
+	return strconv.Itoa(max(1, lo-5)) + "-" + strconv.Itoa(min(65535, hi+5))
+}
@@ -0,0 +1,154 @@
+// Package portscan checks which TCP ports of a host accept connections.
+//
+// A fixed number of worker goroutines read port numbers from a jobs channel
+// and send their findings to a results channel: the classic worker pool.
+// Unlike starting one goroutine per port, the pool bounds both the number of
+// goroutines and the number of sockets open at the same time.
+package portscan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// State of a scanned port.
+type State string
+
+const (
+	Open     State = "open"     // the connection was accepted
+	Closed   State = "closed"   // the host answered with a reset
+	Filtered State = "filtered" // no answer before the timeout
+)
+
+type Result struct {
+	Port    int           `json:"port"`
+	State   State         `json:"state"`
+	Latency time.Duration `json:"latency_ns"`
+}
+
+// Options tune a scan. Zero values get sensible defaults.
+type Options struct {
+	Workers int           // concurrent dials, 100 by default
+	Timeout time.Duration // per dial, 500ms by default
+}
+
+// Scan dials every port of host and returns the results ordered by port.
+// It stops early, returning the results so far, when ctx is done.
+func Scan(ctx context.Context, host string, ports []int, opts Options) ([]Result, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = 100
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 500 * time.Millisecond
+	}
+
+	jobs := make(chan int)
+	results := make(chan Result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for port := range jobs {
+				results <- probe(ctx, host, port, opts.Timeout)
+			}
+		}()
+	}
+
+	// the producer stops feeding jobs when ctx is cancelled.
+	go func() {
+		defer close(jobs)
+		for _, port := range ports {
+			select {
+			case jobs <- port:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// close results once every worker has returned, so the range below ends.
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	report := make([]Result, 0, len(ports))
+	for r := range results {
+		report = append(report, r)
+	}
+	// results arrive in completion order, the report is sorted by port.
+	sort.Slice(report, func(i, j int) bool { return report[i].Port < report[j].Port })
+	return report, ctx.Err()
+}
+
+func probe(ctx context.Context, host string, port int, timeout time.Duration) Result {
+	dialer := net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	r := Result{Port: port, Latency: time.Since(start)}
+	switch {
+	case err == nil:
+		conn.Close()
+		r.State = Open
+	case errors.Is(err, syscall.ECONNREFUSED):
+		r.State = Closed
+	default:
+		r.State = Filtered
+	}
+	return r
+}
+
+// ParsePorts parses a list like "22,80,8000-8100" into sorted unique ports.
+func ParsePorts(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := parsePort(lo)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = parsePort(hi); err != nil {
+				return nil, err
+			}
+			if last < first {
+				return nil, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		for p := first; p <= last; p++ {
+			seen[p] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil, errors.New("no ports given")
+	}
+	ports := make([]int, 0, len(seen))
+	for p := range seen {
+		ports = append(ports, p)
+	}
+	sort.Ints(ports)
+	return ports, nil
+}
+
+func parsePort(s string) (int, error) {
+	p, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || p < 1 || p > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return p, nil
+}
@@ -0,0 +1,156 @@
+package portscan
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// listen opens a listener on localhost that accepts and drops connections
+// until the test ends, and returns its port.
+func listen(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// closedPort returns a port nothing listens on: the port of a listener that
+// was just closed.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port
+}
+
+func TestScan(t *testing.T) {
+	open1, open2 := listen(t), listen(t)
+	closed1, closed2 := closedPort(t), closedPort(t)
+
+	ports := []int{closed1, open1, closed2, open2}
+	results, err := Scan(context.Background(), "127.0.0.1", ports, Options{Workers: 2, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]State{open1: Open, open2: Open, closed1: Closed, closed2: Closed}
+	if len(results) != len(want) {
+		t.Fatalf("%d results for %d ports: %+v", len(results), len(want), results)
+	}
+	for i, r := range results {
+		if r.State != want[r.Port] {
+			t.Errorf("port %d: %s, want %s", r.Port, r.State, want[r.Port])
+		}
+		if i > 0 && results[i-1].Port >= r.Port {
+			t.Errorf("results not sorted by port: %+v", results)
+		}
+	}
+}
+
+// TestScanWorkers scans more ports than workers, and more workers than
+// ports: every port is reported once.
+func TestScanWorkers(t *testing.T) {
+	open := listen(t)
+	ports := []int{open}
+	for i := 0; i < 20; i++ {
+		ports = append(ports, closedPort(t))
+	}
+	for _, workers := range []int{1, 3, 100} {
+		results, err := Scan(context.Background(), "127.0.0.1", ports, Options{Workers: workers})
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen := make(map[int]int)
+		opened := 0
+		for _, r := range results {
+			seen[r.Port]++
+			if r.State == Open {
+				opened++
+			}
+		}
+		if len(results) != len(ports) || len(seen) != len(ports) || opened != 1 {
+			t.Errorf("%d workers: %d results, %d distinct ports, %d open; want %d, %d, 1",
+				workers, len(results), len(seen), opened, len(ports), len(ports))
+		}
+	}
+}
+
+// TestScanCancel cancels a scan before it starts: it returns at once with
+// ctx.Err, and leaves no goroutine behind.
+func TestScanCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ports := make([]int, 1000)
+	for i := range ports {
+		ports[i] = i + 1
+	}
+	start := time.Now()
+	results, err := Scan(ctx, "127.0.0.1", ports, Options{Workers: 10})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Scan = %v, want context.Canceled", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("the cancelled scan took %v", d)
+	}
+	// a job already taken may still be dialled, and fails: never "open".
+	for _, r := range results {
+		if r.State == Open {
+			t.Errorf("port %d open in a cancelled scan", r.Port)
+		}
+	}
+	for deadline := time.Now().Add(time.Second); runtime.NumGoroutine() > before; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines, %d before the scan", runtime.NumGoroutine(), before)
+		}
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    []int
+		wantErr bool
+	}{
+		{"80", []int{80}, false},
+		{"443, 22,80", []int{22, 80, 443}, false},
+		{"8000-8003", []int{8000, 8001, 8002, 8003}, false},
+		{"5-7,6,1-2", []int{1, 2, 5, 6, 7}, false},
+		{"22,,80,", []int{22, 80}, false},
+		{"65535", []int{65535}, false},
+		{"", nil, true},
+		{" , ", nil, true},
+		{"0", nil, true},
+		{"65536", nil, true},
+		{"http", nil, true},
+		{"10-5", nil, true},
+		{"1-x", nil, true},
+		{"-5", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePorts(tt.spec)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePorts(%q) = %v, %v; want %v, error %v", tt.spec, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/progress"
+)
+
+/*
+progressdemo runs simulated downloads, a few at a time, and shows them on a
+live dashboard. Press Ctrl-C in the middle: the jobs stop, the bars show
+where they were, and the cursor comes back.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/progress/cmd/progressdemo
+	go run ./golang_program_design_2024/09.projects/progress/cmd/progressdemo -n 12 -j 4
+	go run ./golang_program_design_2024/09.projects/progress/cmd/progressdemo | cat   # not a terminal: final state only
+*/
+
+var errFlaky = errors.New("connection reset")
+
+func main() {
+	n := flag.Int("n", 8, "number of jobs")
+	workers := flag.Int("j", 3, "jobs running at the same time")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	events := make(chan progress.Event)
+	dash := progress.New(os.Stdout)
+	drawn := make(chan struct{})
+	go func() {
+		defer close(drawn)
+		dash.Run(ctx, events)
+	}()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < *workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				download(ctx, fmt.Sprintf("file-%02d.bin", j), events)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for j := 1; j <= *n; j++ {
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(events)
+	<-drawn
+	if ctx.Err() != nil {
+		fmt.Println("interrupted")
+	}
+}
+
+// download pretends to fetch a file at a random speed; one in six fails
+// halfway. Every send also watches ctx: once the dashboard stopped reading,
+// a plain send would block forever.
+func download(ctx context.Context, name string, events chan<- progress.Event) {
+	total := int64(1+rand.Intn(8)) << 20
+	speed := int64(1+rand.Intn(4)) << 20 // bytes per second
+	fail := rand.Intn(6) == 0
+	send := func(ev progress.Event) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	const tick = 50 * time.Millisecond
+	var done int64
+	for done < total {
+		select {
+		case <-time.After(tick):
+		case <-ctx.Done():
+			return
+		}
+		done = min(total, done+speed*int64(tick)/int64(time.Second))
+		if fail && done > total/2 {
+			send(progress.Event{Name: name, Done: done, Total: total, Finished: true, Err: errFlaky})
+			return
+		}
+		if !send(progress.Event{Name: name, Done: done, Total: total}) {
+			return
+		}
+	}
+	send(progress.Event{Name: name, Done: done, Total: total, Finished: true})
+}
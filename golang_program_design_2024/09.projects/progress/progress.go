@@ -0,0 +1,198 @@
+// Package progress draws live progress bars for concurrent tasks in a
+// terminal.
+//
+// The tasks never touch the terminal: they send Events on a channel, and the
+// single goroutine running Dashboard.Run owns the screen. That is the usual
+// shape of a UI fed by workers: the state has one owner, and redrawing at a
+// fixed rate costs the same whether the workers report ten or ten thousand
+// times a second.
+//
+// Redrawing uses ANSI escape sequences: the cursor goes back up over the bars
+// drawn last time ("\x1b[<n>A") and each line is rewritten and cleared to its
+// end ("\x1b[K"). The cursor is hidden while drawing and shown again however
+// Run returns, cancellation included, so an interrupted program does not
+// leave the terminal without a cursor. When the output is not a terminal,
+// only the final state is printed.
+package progress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Event reports the progress of a task. Tasks are identified by Name; the
+// first event of a name adds its bar.
+type Event struct {
+	Name  string
+	Done  int64
+	Total int64 // 0 when unknown
+	// Finished marks the last event of the task, Err its failure.
+	Finished bool
+	Err      error
+}
+
+type task struct {
+	Event
+	start, end time.Time
+}
+
+const (
+	hideCursor = "\x1b[?25l"
+	showCursor = "\x1b[?25h"
+	clearLine  = "\x1b[K"
+)
+
+// Dashboard renders the bars to Out.
+type Dashboard struct {
+	Out io.Writer
+	// Interval between redraws.
+	Interval time.Duration
+	// Width of a bar in characters.
+	Width int
+	// ANSI enables the live display; New sets it when Out is a terminal.
+	ANSI bool
+
+	tasks  []*task
+	byName map[string]*task
+	drawn  int // lines drawn last time, to move back over
+}
+
+// New returns a dashboard writing to out, live if out is a terminal.
+func New(out io.Writer) *Dashboard {
+	return &Dashboard{Out: out, Interval: 100 * time.Millisecond, Width: 30, ANSI: isTerminal(out), byName: make(map[string]*task)}
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+// Run draws until events is closed or ctx is done, then draws the final
+// state once more and restores the cursor.
+func (d *Dashboard) Run(ctx context.Context, events <-chan Event) {
+	if d.ANSI {
+		fmt.Fprint(d.Out, hideCursor)
+		defer fmt.Fprint(d.Out, showCursor)
+	}
+	ticker := time.NewTicker(d.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				d.draw(true)
+				return
+			}
+			d.apply(ev)
+		case <-ticker.C:
+			if d.ANSI {
+				d.draw(false)
+			}
+		case <-ctx.Done():
+			d.draw(true)
+			return
+		}
+	}
+}
+
+func (d *Dashboard) apply(ev Event) {
+	t, ok := d.byName[ev.Name]
+	if !ok {
+		t = &task{start: time.Now()}
+		d.byName[ev.Name] = t
+		d.tasks = append(d.tasks, t)
+	}
+	if t.Finished {
+		return
+	}
+	t.Event = ev
+	if ev.Finished {
+		t.end = time.Now()
+	}
+}
+
+func (d *Dashboard) draw(final bool) {
+	var b strings.Builder
+	if d.ANSI && d.drawn > 0 {
+		fmt.Fprintf(&b, "\x1b[%dA", d.drawn)
+	}
+	var done, failed int
+	for _, t := range d.tasks {
+		b.WriteString(d.line(t, final))
+		if d.ANSI {
+			b.WriteString(clearLine)
+		}
+		b.WriteByte('\n')
+		switch {
+		case t.Err != nil:
+			failed++
+		case t.Finished:
+			done++
+		}
+	}
+	fmt.Fprintf(&b, "%d/%d done, %d failed", done, len(d.tasks), failed)
+	if d.ANSI {
+		b.WriteString(clearLine)
+	}
+	b.WriteByte('\n')
+	if d.ANSI || final {
+		io.WriteString(d.Out, b.String())
+	}
+	d.drawn = len(d.tasks) + 1
+}
+
+func (d *Dashboard) line(t *task, final bool) string {
+	end := time.Now()
+	if t.Finished {
+		end = t.end
+	}
+	elapsed := end.Sub(t.start)
+
+	var status string
+	switch {
+	case t.Err != nil:
+		status = "failed: " + t.Err.Error()
+	case t.Finished:
+		status = "done"
+	case final:
+		status = "cancelled"
+	default:
+		status = rate(t.Done, elapsed)
+	}
+
+	if t.Total <= 0 {
+		return fmt.Sprintf("%-12s %s %8s  %s", t.Name, strings.Repeat("?", d.Width), size(t.Done), status)
+	}
+	frac := min(float64(t.Done)/float64(t.Total), 1)
+	filled := int(frac * float64(d.Width))
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", d.Width-filled)
+	return fmt.Sprintf("%-12s %s %3.0f%% %8s  %s", t.Name, bar, frac*100, size(t.Done), status)
+}
+
+func rate(n int64, elapsed time.Duration) string {
+	if elapsed <= 0 {
+		return ""
+	}
+	return size(int64(float64(n)/elapsed.Seconds())) + "/s"
+}
+
+func size(n int64) string {
+	const unit, prefixes = 1024, "KMGTPE" // an int64 ends below 8EB
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit && exp < len(prefixes)-1; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), prefixes[exp])
+}
@@ -0,0 +1,26 @@
+package progress
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSize(t *testing.T) {
+	for _, tt := range []struct {
+		n    int64
+		want string
+	}{
+		{0, "0B"},
+		{1023, "1023B"},
+		{1024, "1.0KB"},
+		{1536, "1.5KB"},
+		{5 << 30, "5.0GB"},
+		{1 << 50, "1.0PB"},
+		{3 << 60, "3.0EB"},
+		{math.MaxInt64, "8.0EB"},
+	} {
+		if got := size(tt.n); got != tt.want {
+			t.Errorf("size(%d) = %s, want %s", tt.n, got, tt.want)
+		}
+	}
+}
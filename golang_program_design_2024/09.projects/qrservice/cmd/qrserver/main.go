@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/qrservice"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+qrserver serves QR codes of URLs as PNG images.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/qrservice/cmd/qrserver -addr :8080
+	curl -o go.png 'localhost:8080/qr?url=https://go.dev&scale=10'
+
+	go run ./golang_program_design_2024/09.projects/qrservice/cmd/qrserver -print https://go.dev
+	go run ./golang_program_design_2024/09.projects/qrservice/cmd/qrserver -demo
+
+-print draws the code in the terminal instead, phones read it from the
+screen. -demo encodes a URL, sends a few requests to the handlers on a local
+test server, cached, conditional and refused ones, and prints the answers.
+Its cases are tests: go test ./golang_program_design_2024/09.projects/qrservice/...
+*/
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	cacheSize := flag.Int("cache", 1024, "images kept in the cache")
+	text := flag.String("print", "", "draw the QR code of this text in the terminal and exit")
+	demo := flag.Bool("demo", false, "run the handlers on a local test server")
+	flag.Parse()
+
+	if *text != "" {
+		code, err := qrservice.Encode([]byte(*text), qrservice.M)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		drawText(os.Stdout, code)
+		return
+	}
+	if *demo {
+		if err := runDemo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	log := xlog.New("09.projects", "qrservice")
+	s := qrservice.NewServer(*cacheSize, log)
+	srv := &http.Server{Addr: *addr, Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	log.Info("listening", "addr", *addr)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Error("serve", "err", err)
+		os.Exit(1)
+	}
+}
+
+// drawText prints two rows of modules per line with half block characters.
+// Terminals are usually dark, so the glyphs draw the light modules, border
+// included, and the background shows through as the dark ones.
+func drawText(w io.Writer, code *qrservice.Code) {
+	const border = 2
+	dark := func(x, y int) bool {
+		if x < 0 || y < 0 || x >= code.Size || y >= code.Size {
+			return false
+		}
+		return code.Dark(x, y)
+	}
+	for y := -border; y < code.Size+border; y += 2 {
+		var b strings.Builder
+		for x := -border; x < code.Size+border; x++ {
+			switch top, bottom := dark(x, y), dark(x, y+1); {
+			case !top && !bottom:
+				b.WriteString("█")
+			case !top:
+				b.WriteString("▀")
+			case !bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteString(" ")
+			}
+		}
+		fmt.Fprintln(w, b.String())
+	}
+}
+
+func runDemo() error {
+	code, err := qrservice.Encode([]byte("https://example.com"), qrservice.M)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("https://example.com: version %d, %dx%d, mask %d\n", code.Version, code.Size, code.Size, code.Mask)
+
+	s := qrservice.NewServer(2, xlog.New("09.projects", "qrservice"))
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+	var etag string
+	for _, r := range []struct {
+		target      string
+		conditional bool // with the ETag of the previous answer
+	}{
+		{"/qr?url=" + url.QueryEscape("https://go.dev") + "&scale=4", false},
+		{"/qr?url=" + url.QueryEscape("https://go.dev") + "&scale=4", false},
+		{"/qr?url=" + url.QueryEscape("https://go.dev") + "&scale=4", true},
+		{"/qr?url=" + url.QueryEscape("javascript:alert(1)"), false},
+		{"/qr?url=" + url.QueryEscape("https://go.dev/"+strings.Repeat("x", 300)), false},
+	} {
+		req, err := http.NewRequest(http.MethodGet, srv.URL+r.target, nil)
+		if err != nil {
+			return err
+		}
+		if r.conditional {
+			req.Header.Set("If-None-Match", etag)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		etag = resp.Header.Get("ETag")
+		fmt.Printf("GET %.40s\n  %d %s, %d bytes, cache %s\n", r.target, resp.StatusCode, resp.Header.Get("Content-Type"), len(body), resp.Header.Get("X-Cache"))
+	}
+	hits, misses, cached := s.Stats()
+	fmt.Printf("cache: %d hits, %d misses, %d images\n\n", hits, misses, cached)
+	drawText(os.Stdout, code)
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/qrservice"
+)
+
+// TestDrawText draws two rows per line, the border of two modules light.
+func TestDrawText(t *testing.T) {
+	code, err := qrservice.Encode([]byte("go.dev"), qrservice.M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	drawText(&b, code)
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	side := code.Size + 4
+	if len(lines) != (side+1)/2 {
+		t.Fatalf("%d lines for %d rows", len(lines), side)
+	}
+	for i, line := range lines {
+		if n := utf8.RuneCountInString(line); n != side {
+			t.Fatalf("line %d has %d runes, want %d", i, n, side)
+		}
+	}
+	// two light border rows, then the first two rows of the code: dark at
+	// column 0, the edge of the finder.
+	if lines[0] != strings.Repeat("█", side) || !strings.HasPrefix(lines[1], "██ ") {
+		t.Errorf("the top of the drawing:\n%s\n%s", lines[0], lines[1])
+	}
+}
@@ -0,0 +1,434 @@
+package qrservice
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+// Level is the error correction level: the share of the symbol that can be
+// damaged and still read, about 7% (L), 15% (M), 25% (Q) or 30% (H).
+type Level int
+
+const (
+	L Level = iota
+	M
+	Q
+	H
+)
+
+func (l Level) String() string { return "LMQH"[l : l+1] }
+
+// ErrTooLong is returned for data that does not fit in the largest version
+// supported.
+var ErrTooLong = errors.New("qr: data too long")
+
+// MaxVersion is the largest version Encode produces, a 57x57 symbol holding
+// up to 213 bytes at level M: plenty for URLs. Versions go up to 40, larger
+// ones only need more rows in the tables below.
+const MaxVersion = 10
+
+// blocks describes how the codewords of a version and level are split:
+// g1 blocks of d1 data codewords, then g2 blocks of d1+1, each followed by
+// ec error correction codewords.
+type blocks struct{ ec, g1, d1, g2 int }
+
+func (b blocks) data() int { return b.g1*b.d1 + b.g2*(b.d1+1) }
+
+// blockTable is indexed by version, then level, from ISO/IEC 18004 table 9.
+var blockTable = [MaxVersion + 1][4]blocks{
+	1:  {{7, 1, 19, 0}, {10, 1, 16, 0}, {13, 1, 13, 0}, {17, 1, 9, 0}},
+	2:  {{10, 1, 34, 0}, {16, 1, 28, 0}, {22, 1, 22, 0}, {28, 1, 16, 0}},
+	3:  {{15, 1, 55, 0}, {26, 1, 44, 0}, {18, 2, 17, 0}, {22, 2, 13, 0}},
+	4:  {{20, 1, 80, 0}, {18, 2, 32, 0}, {26, 2, 24, 0}, {16, 4, 9, 0}},
+	5:  {{26, 1, 108, 0}, {24, 2, 43, 0}, {18, 2, 15, 2}, {22, 2, 11, 2}},
+	6:  {{18, 2, 68, 0}, {16, 4, 27, 0}, {24, 4, 19, 0}, {28, 4, 15, 0}},
+	7:  {{20, 2, 78, 0}, {18, 4, 31, 0}, {18, 2, 14, 4}, {26, 4, 13, 1}},
+	8:  {{24, 2, 97, 0}, {22, 2, 38, 2}, {22, 4, 18, 2}, {26, 4, 14, 2}},
+	9:  {{30, 2, 116, 0}, {22, 3, 36, 2}, {20, 4, 16, 4}, {24, 4, 12, 4}},
+	10: {{18, 2, 68, 2}, {26, 4, 43, 1}, {24, 6, 19, 2}, {28, 6, 15, 2}},
+}
+
+// alignTable lists the row and column centres of the alignment patterns.
+var alignTable = [MaxVersion + 1][]int{
+	2: {6, 18}, 3: {6, 22}, 4: {6, 26}, 5: {6, 30}, 6: {6, 34},
+	7: {6, 22, 38}, 8: {6, 24, 42}, 9: {6, 26, 46}, 10: {6, 28, 50},
+}
+
+// Code is an encoded symbol: a square of Size x Size modules.
+type Code struct {
+	Version int
+	Level   Level
+	Mask    int
+	Size    int
+
+	dark []bool // row major
+	fn   []bool // function patterns, left alone by data and masks
+}
+
+// Dark reports whether the module at column x, row y is dark.
+func (c *Code) Dark(x, y int) bool { return c.dark[y*c.Size+x] }
+
+// Encode encodes data in byte mode in the smallest version that holds it at
+// the given level.
+func Encode(data []byte, level Level) (*Code, error) {
+	version := 0
+	for v := 1; v <= MaxVersion; v++ {
+		if bitsNeeded(v, len(data)) <= blockTable[v][level].data()*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrTooLong
+	}
+
+	c := &Code{Version: version, Level: level, Size: 17 + 4*version}
+	c.dark = make([]bool, c.Size*c.Size)
+	c.fn = make([]bool, c.Size*c.Size)
+	c.drawFunctionPatterns()
+	c.drawCodewords(c.interleave(c.dataCodewords(data)))
+
+	// try the eight masks and keep the one that scores best: no large areas
+	// of one colour, nothing looking like a finder pattern.
+	best := -1
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if p := c.penalty(); best < 0 || p < best {
+			best, c.Mask = p, mask
+		}
+		c.applyMask(mask) // XOR again: undone
+	}
+	c.applyMask(c.Mask)
+	c.drawFormat(c.Mask)
+	return c, nil
+}
+
+// countBits is the size of the length field of byte mode.
+func countBits(version int) int {
+	if version < 10 {
+		return 8
+	}
+	return 16
+}
+
+func bitsNeeded(version, n int) int { return 4 + countBits(version) + 8*n }
+
+// bitBuffer appends values bit by bit, most significant first.
+type bitBuffer []bool
+
+func (b *bitBuffer) append(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, v>>i&1 == 1)
+	}
+}
+
+// dataCodewords builds the data part: mode, length, bytes, then padding up
+// to the capacity of the version.
+func (c *Code) dataCodewords(data []byte) []byte {
+	capacity := blockTable[c.Version][c.Level].data() * 8
+	var bb bitBuffer
+	bb.append(0b0100, 4) // byte mode
+	bb.append(len(data), countBits(c.Version))
+	for _, b := range data {
+		bb.append(int(b), 8)
+	}
+	// terminator of up to four zeros, then zeros to a byte boundary.
+	bb.append(0, min(4, capacity-len(bb)))
+	bb.append(0, (8-len(bb)%8)%8)
+
+	out := make([]byte, len(bb)/8, capacity/8)
+	for i, bit := range bb {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	// the remaining bytes alternate between two fixed pad values.
+	for pad := byte(0xec); len(out) < cap(out); pad ^= 0xec ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// interleave splits the data into blocks, computes the error correction of
+// each, and takes the codewords one block after the other: damage to one
+// area of the symbol is spread over several blocks.
+func (c *Code) interleave(data []byte) []byte {
+	spec := blockTable[c.Version][c.Level]
+	var dataBlocks, ecBlocks [][]byte
+	for i := 0; i < spec.g1+spec.g2; i++ {
+		n := spec.d1
+		if i >= spec.g1 {
+			n++
+		}
+		dataBlocks = append(dataBlocks, data[:n])
+		ecBlocks = append(ecBlocks, rsRemainder(data[:n], spec.ec))
+		data = data[n:]
+	}
+
+	var out []byte
+	for i := 0; i <= spec.d1; i++ {
+		for _, b := range dataBlocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < spec.ec; i++ {
+		for _, b := range ecBlocks {
+			out = append(out, b[i])
+		}
+	}
+	return out
+}
+
+func (c *Code) set(x, y int, dark bool) {
+	c.dark[y*c.Size+x] = dark
+	c.fn[y*c.Size+x] = true
+}
+
+func (c *Code) drawFunctionPatterns() {
+	for i := 0; i < c.Size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+
+	// finder patterns in three corners, with their light separator.
+	for _, p := range [][2]int{{3, 3}, {c.Size - 4, 3}, {3, c.Size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x < 0 || x >= c.Size || y < 0 || y >= c.Size {
+					continue
+				}
+				d := max(abs(dx), abs(dy))
+				c.set(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+
+	// alignment patterns on the grid of alignTable, except where a finder is.
+	pos := alignTable[c.Version]
+	last := len(pos) - 1
+	for i, y := range pos {
+		for j, x := range pos {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	// reserve the format areas now, drawFormat fills them per mask.
+	c.drawFormat(0)
+	c.drawVersion()
+}
+
+// drawFormat writes the level and mask, protected by a BCH code, twice:
+// around the top left finder, and split between the two other ones.
+func (c *Code) drawFormat(mask int) {
+	levelBits := [4]int{L: 1, M: 0, Q: 3, H: 2}
+	data := levelBits[c.Level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412 // the XOR keeps it from being all light
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		c.set(c.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, c.Size-15+i, bit(i))
+	}
+	c.set(8, c.Size-8, true) // always dark
+}
+
+// drawVersion writes the version, from 7 on, in two 6x3 blocks next to the
+// top right and bottom left finders.
+func (c *Code) drawVersion() {
+	if c.Version < 7 {
+		return
+	}
+	rem := c.Version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1f25
+	}
+	bits := c.Version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := bits>>i&1 == 1
+		a, b := c.Size-11+i%3, i/3
+		c.set(a, b, dark)
+		c.set(b, a, dark)
+	}
+}
+
+// drawCodewords places the bits in the modules left free, in two-module
+// wide columns zigzagging up and down from the bottom right corner. The
+// vertical timing pattern is skipped over.
+func (c *Code) drawCodewords(codewords []byte) {
+	i := 0
+	for right := c.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < c.Size; vert++ {
+			y := vert
+			if upward {
+				y = c.Size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.fn[y*c.Size+x] {
+					continue
+				}
+				// modules past the last codeword (the remainder bits) stay light.
+				if i < len(codewords)*8 {
+					c.dark[y*c.Size+x] = codewords[i/8]>>(7-i%8)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// masks are the eight patterns a symbol can be XORed with, by column x and
+// row y.
+var masks = [8]func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.fn[y*c.Size+x] && masks[mask](x, y) {
+				c.dark[y*c.Size+x] = !c.dark[y*c.Size+x]
+			}
+		}
+	}
+}
+
+// penalty scores the symbol with the four rules of the standard, lower is
+// easier to read.
+func (c *Code) penalty() int {
+	n := c.Size
+	p := 0
+	line := make([]bool, n)
+	for _, vertical := range []bool{false, true} {
+		for a := 0; a < n; a++ {
+			for b := 0; b < n; b++ {
+				if vertical {
+					line[b] = c.Dark(a, b)
+				} else {
+					line[b] = c.Dark(b, a)
+				}
+			}
+			// rule 1: runs of five or more modules of the same colour.
+			run := 1
+			for b := 1; b <= n; b++ {
+				if b < n && line[b] == line[b-1] {
+					run++
+					continue
+				}
+				if run >= 5 {
+					p += run - 2
+				}
+				run = 1
+			}
+			// rule 3: 1:1:3:1:1 finder-like patterns with four light modules
+			// on one side.
+			for b := 0; b+11 <= n; b++ {
+				if matches(line[b:], finderLeft) || matches(line[b:], finderRight) {
+					p += 40
+				}
+			}
+		}
+	}
+
+	// rule 2: 2x2 blocks of one colour.
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			d := c.Dark(x, y)
+			if d {
+				dark++
+			}
+			if x+1 < n && y+1 < n && d == c.Dark(x+1, y) && d == c.Dark(x, y+1) && d == c.Dark(x+1, y+1) {
+				p += 3
+			}
+		}
+	}
+
+	// rule 4: 10 points for every 5% the dark share strays from 50%.
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return p + k*10
+}
+
+var (
+	finderLeft  = []bool{false, false, false, false, true, false, true, true, true, false, true}
+	finderRight = []bool{true, false, true, true, true, false, true, false, false, false, false}
+)
+
+func matches(line, pattern []bool) bool {
+	for i, v := range pattern {
+		if line[i] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Image renders the symbol with scale pixels per module and the four module
+// wide light border readers expect around it.
+func (c *Code) Image(scale int) *image.Paletted {
+	const border = 4
+	scale = max(scale, 1)
+	side := (c.Size + 2*border) * scale
+	img := image.NewPaletted(image.Rect(0, 0, side, side), color.Palette{color.White, color.Black})
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Dark(x, y) {
+				continue
+			}
+			for py := 0; py < scale; py++ {
+				row := img.Pix[((y+border)*scale+py)*img.Stride:]
+				for px := 0; px < scale; px++ {
+					row[(x+border)*scale+px] = 1
+				}
+			}
+		}
+	}
+	return img
+}
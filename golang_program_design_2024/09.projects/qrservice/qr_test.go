@@ -0,0 +1,92 @@
+package qrservice
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	for _, tt := range []struct {
+		n       int
+		level   Level
+		version int
+	}{
+		{1, M, 1},
+		{14, M, 1},
+		{15, M, 2},
+		{19, M, 2}, // https://example.com
+		{150, M, 8},
+		{213, M, 10},
+		{17, L, 1},
+		{7, H, 1},
+		{8, H, 2},
+	} {
+		code, err := Encode(bytes.Repeat([]byte("a"), tt.n), tt.level)
+		if err != nil {
+			t.Errorf("%d bytes at %s: %v", tt.n, tt.level, err)
+			continue
+		}
+		if code.Version != tt.version || code.Size != 17+4*tt.version || code.Level != tt.level {
+			t.Errorf("%d bytes at %s: version %d, size %d; want version %d", tt.n, tt.level, code.Version, code.Size, tt.version)
+		}
+	}
+	if _, err := Encode(bytes.Repeat([]byte("a"), 214), M); !errors.Is(err, ErrTooLong) {
+		t.Errorf("214 bytes at M = %v, want ErrTooLong", err)
+	}
+}
+
+// TestFunctionPatterns checks what every reader looks for first: a finder
+// in three corners and their separators, the timing lines and the dark module.
+func TestFunctionPatterns(t *testing.T) {
+	code, err := Encode([]byte("https://example.com"), M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := code.Size
+	for _, corner := range [][2]int{{0, 0}, {n - 7, 0}, {0, n - 7}} {
+		x, y := corner[0], corner[1]
+		if !code.Dark(x, y) || !code.Dark(x+6, y+6) || code.Dark(x+1, y+1) || !code.Dark(x+3, y+3) {
+			t.Errorf("no finder at %v", corner)
+		}
+	}
+	// the light separators around the finders.
+	if code.Dark(7, 7) || code.Dark(n-8, 7) || code.Dark(7, n-8) {
+		t.Error("a separator is dark")
+	}
+	for i := 8; i < n-8; i++ {
+		if code.Dark(i, 6) != (i%2 == 0) || code.Dark(6, i) != (i%2 == 0) {
+			t.Errorf("timing pattern broken at %d", i)
+		}
+	}
+	if !code.Dark(8, n-8) {
+		t.Error("no dark module")
+	}
+}
+
+// TestRSRemainder uses the example of ISO 18004, HELLO WORLD at 1-M.
+func TestRSRemainder(t *testing.T) {
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	if got := rsRemainder(data, 10); !bytes.Equal(got, want) {
+		t.Errorf("rsRemainder = %v, want %v", got, want)
+	}
+}
+
+func TestImage(t *testing.T) {
+	code, err := Encode([]byte("go.dev"), M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := code.Image(3)
+	if side := (code.Size + 8) * 3; img.Bounds().Dx() != side || img.Bounds().Dy() != side {
+		t.Fatalf("image %v, want %dpx", img.Bounds(), side)
+	}
+	// the border is light, the top left module of the finder dark.
+	if img.ColorIndexAt(11, 11) != 0 || img.ColorIndexAt(12, 12) != 1 || img.ColorIndexAt(14, 14) != 1 {
+		t.Error("border or finder at the wrong place")
+	}
+	if code.Image(0).Bounds().Dx() != code.Size+8 {
+		t.Error("a scale under 1 is not clamped to 1")
+	}
+}
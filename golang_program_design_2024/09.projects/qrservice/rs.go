@@ -0,0 +1,63 @@
+package qrservice
+
+// Reed-Solomon error correction over GF(256), the field QR codes use: bytes
+// are polynomials over GF(2) reduced modulo x^8+x^4+x^3+x^2+1 (0x11d).
+// Addition is XOR; multiplication goes through log and exp tables of the
+// generator element 2.
+
+var gfExp, gfLog = func() (exp [510]byte, log [256]byte) {
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	// doubled, so gfMul can index log[a]+log[b] without a modulo.
+	copy(exp[255:], exp[:255])
+	return exp, log
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+// rsGenerator returns the coefficients of (x-2^0)(x-2^1)...(x-2^(n-1)),
+// highest degree first, without the leading 1.
+func rsGenerator(n int) []byte {
+	g := make([]byte, n)
+	g[n-1] = 1
+	root := byte(1)
+	for i := 0; i < n; i++ {
+		// multiply by (x - root): shift, then add root times the old value.
+		for j := 0; j < n; j++ {
+			g[j] = gfMul(g[j], root)
+			if j+1 < n {
+				g[j] ^= g[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return g
+}
+
+// rsRemainder returns the n error correction codewords of data: the
+// remainder of data(x)*x^n divided by the generator.
+func rsRemainder(data []byte, n int) []byte {
+	gen := rsGenerator(n)
+	rem := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for i, g := range gen {
+			rem[i] ^= gfMul(g, factor)
+		}
+	}
+	return rem
+}
@@ -0,0 +1,152 @@
+// Package qrservice generates QR codes for URLs and serves them as PNG
+// images over HTTP:
+//
+//	GET  /qr?url=https://go.dev&scale=8  -> image/png
+//	POST /qr (form field url, scale)     -> image/png
+//	GET  /                               -> a form to try it
+//
+// The encoder is written from the standard (byte mode, versions 1 to 10,
+// levels L to H, Reed-Solomon error correction, mask selection by penalty
+// score), with no dependency outside the standard library.
+//
+// Encoding and compressing a PNG costs far more than serving bytes, and the
+// same URLs come back again and again, so the PNGs are kept in an LRU cache
+// keyed by URL, level and scale. Responses carry an ETag, so a browser
+// asking again gets a 304 without a body.
+package qrservice
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/lru"
+)
+
+// Server generates and caches the images.
+type Server struct {
+	Level Level
+	// Scale is the default size of a module in pixels, MaxScale the largest a
+	// request may ask for.
+	Scale, MaxScale int
+	Log             *slog.Logger
+
+	cache        *lru.Sync[string, []byte]
+	hits, misses atomic.Int64
+}
+
+// NewServer returns a server caching up to cacheSize images.
+func NewServer(cacheSize int, log *slog.Logger) *Server {
+	return &Server{Level: M, Scale: 8, MaxScale: 32, Log: log, cache: lru.NewSync(lru.New[string, []byte](max(cacheSize, 1)))}
+}
+
+// Handler returns the routes of the service.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.form)
+	mux.HandleFunc("GET /qr", s.qr)
+	mux.HandleFunc("POST /qr", s.qr)
+	return mux
+}
+
+// Stats reports the cache activity.
+func (s *Server) Stats() (hits, misses int64, cached int) {
+	return s.hits.Load(), s.misses.Load(), s.cache.Len()
+}
+
+const formPage = `<!doctype html>
+<title>QR codes</title>
+<form method="post" action="/qr">
+<input name="url" size="60" placeholder="https://go.dev">
+<button>Generate</button>
+</form>
+`
+
+func (s *Server) form(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, formPage)
+}
+
+var errBadURL = errors.New("url must be an absolute http or https URL")
+
+// validURL accepts what is worth putting in a QR code for a browser.
+func validURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errBadURL
+	}
+	return nil
+}
+
+func (s *Server) qr(w http.ResponseWriter, r *http.Request) {
+	// FormValue reads the query string, and the body of a POSTed form.
+	raw := r.FormValue("url")
+	if err := validURL(raw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	scale := s.Scale
+	if v := r.FormValue("scale"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > s.MaxScale {
+			http.Error(w, fmt.Sprintf("scale must be between 1 and %d", s.MaxScale), http.StatusBadRequest)
+			return
+		}
+		scale = n
+	}
+
+	key := fmt.Sprintf("%s|%d|%s", s.Level, scale, raw)
+	img, ok := s.cache.Get(key)
+	if ok {
+		s.hits.Add(1)
+		w.Header().Set("X-Cache", "HIT")
+	} else {
+		s.misses.Add(1)
+		var err error
+		if img, err = s.render(raw, scale); errors.Is(err, ErrTooLong) {
+			http.Error(w, "url too long for a QR code", http.StatusRequestEntityTooLarge)
+			return
+		} else if err != nil {
+			s.Log.Error("render", "url", raw, "err", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		s.cache.Put(key, img)
+		w.Header().Set("X-Cache", "MISS")
+		s.Log.Info("generated", "url", raw, "scale", scale, "bytes", len(img))
+	}
+
+	// the image only depends on the key: it can be cached anywhere, forever.
+	sum := sha256.Sum256([]byte(key))
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if r.Method == http.MethodGet && r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(img)))
+	w.Write(img)
+}
+
+func (s *Server) render(data string, scale int) ([]byte, error) {
+	code, err := Encode([]byte(data), s.Level)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, code.Image(scale)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
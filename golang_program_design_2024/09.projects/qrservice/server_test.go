@@ -0,0 +1,138 @@
+package qrservice
+
+import (
+	"bytes"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newServer(t *testing.T, cacheSize int) (*Server, *httptest.Server) {
+	t.Helper()
+	s := NewServer(cacheSize, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	srv := httptest.NewServer(s.Handler())
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+// get requests target with the header pairs given, and reads the body.
+func get(t *testing.T, srv *httptest.Server, target string, header ...string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+target, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+func qr(u, extra string) string { return "/qr?url=" + url.QueryEscape(u) + extra }
+
+func TestQR(t *testing.T) {
+	_, srv := newServer(t, 8)
+	resp, img := get(t, srv, qr("https://go.dev", "&scale=4"))
+	if resp.StatusCode != 200 || resp.Header.Get("Content-Type") != "image/png" || resp.Header.Get("X-Cache") != "MISS" {
+		t.Fatalf("GET /qr: %d %v", resp.StatusCode, resp.Header)
+	}
+	decoded, err := png.Decode(bytes.NewReader(img))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// go.dev fits version 1: 21 modules and a border of 4 on both sides.
+	if want := (21 + 8) * 4; decoded.Bounds().Dx() != want {
+		t.Errorf("image of %v, want %dpx", decoded.Bounds().Size(), want)
+	}
+
+	form := url.Values{"url": {"https://go.dev"}, "scale": {"4"}}
+	posted, err := srv.Client().Post(srv.URL+"/qr", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(posted.Body)
+	posted.Body.Close()
+	if posted.StatusCode != 200 || posted.Header.Get("X-Cache") != "HIT" || !bytes.Equal(body, img) {
+		t.Errorf("POST of the form: %d %v, want the image of the GET", posted.StatusCode, posted.Header)
+	}
+
+	page, html := get(t, srv, "/")
+	if !strings.HasPrefix(page.Header.Get("Content-Type"), "text/html") || !bytes.Contains(html, []byte("<form")) {
+		t.Errorf("GET /: %v\n%s", page.Header, html)
+	}
+}
+
+// TestCache fills a cache of two images: the same request hits it, another
+// scale is another image, and the least recently used one is evicted.
+func TestCache(t *testing.T) {
+	s, srv := newServer(t, 2)
+	first, img := get(t, srv, qr("https://go.dev", "&scale=4"))
+	again, same := get(t, srv, qr("https://go.dev", "&scale=4"))
+	if again.Header.Get("X-Cache") != "HIT" || !bytes.Equal(same, img) {
+		t.Errorf("the second request: %s, same bytes %v", again.Header.Get("X-Cache"), bytes.Equal(same, img))
+	}
+	other, _ := get(t, srv, qr("https://go.dev", "&scale=5"))
+	if other.Header.Get("X-Cache") != "MISS" || other.Header.Get("ETag") == first.Header.Get("ETag") {
+		t.Errorf("another scale: %v", other.Header)
+	}
+	get(t, srv, qr("https://pkg.go.dev", ""))
+	evicted, _ := get(t, srv, qr("https://go.dev", "&scale=4"))
+	if evicted.Header.Get("X-Cache") != "MISS" {
+		t.Error("the least recently used image is still cached")
+	}
+	if hits, misses, cached := s.Stats(); hits != 1 || misses != 4 || cached != 2 {
+		t.Errorf("hits %d, misses %d, cached %d; want 1, 4, 2", hits, misses, cached)
+	}
+}
+
+func TestConditional(t *testing.T) {
+	_, srv := newServer(t, 8)
+	resp, _ := get(t, srv, qr("https://go.dev", ""))
+	etag := resp.Header.Get("ETag")
+	if etag == "" || !strings.Contains(resp.Header.Get("Cache-Control"), "immutable") {
+		t.Fatalf("caching headers: %v", resp.Header)
+	}
+	notModified, body := get(t, srv, qr("https://go.dev", ""), "If-None-Match", etag)
+	if notModified.StatusCode != http.StatusNotModified || len(body) != 0 {
+		t.Errorf("If-None-Match with the ETag: %d, %d bytes", notModified.StatusCode, len(body))
+	}
+	if stale, _ := get(t, srv, qr("https://go.dev", ""), "If-None-Match", `"other"`); stale.StatusCode != 200 {
+		t.Errorf("If-None-Match with another ETag: %d", stale.StatusCode)
+	}
+}
+
+func TestQRErrors(t *testing.T) {
+	_, srv := newServer(t, 8)
+	for _, tt := range []struct {
+		name, target string
+		status       int
+	}{
+		{"missing url", "/qr", http.StatusBadRequest},
+		{"relative url", qr("/just/a/path", ""), http.StatusBadRequest},
+		{"not http", qr("javascript:alert(1)", ""), http.StatusBadRequest},
+		{"no host", qr("https://", ""), http.StatusBadRequest},
+		{"scale too large", qr("https://go.dev", "&scale=1000"), http.StatusBadRequest},
+		{"scale zero", qr("https://go.dev", "&scale=0"), http.StatusBadRequest},
+		{"scale not a number", qr("https://go.dev", "&scale=big"), http.StatusBadRequest},
+		{"url too long for version 10", qr("https://go.dev/"+strings.Repeat("x", 300), ""), http.StatusRequestEntityTooLarge},
+	} {
+		resp, _ := get(t, srv, tt.target)
+		if resp.StatusCode != tt.status || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+			t.Errorf("%s: %d %s, want %d", tt.name, resp.StatusCode, resp.Header.Get("Content-Type"), tt.status)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/replserver"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+replserver serves a shell with a shared key-value store over TCP.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/replserver/cmd/replserver -addr :7000 -idle 2m
+	telnet localhost 7000     (or nc localhost 7000)
+	guest1> set greeting "hello, world"
+	ok
+	guest1> stats
+	goroutines 4
+	...
+
+	go run ./golang_program_design_2024/09.projects/replserver/cmd/replserver -demo
+
+-demo prints two sessions over localhost: what they share and what they
+keep to themselves. The commands, the parser and the idle timeout are
+tests:
+
+	go test ./golang_program_design_2024/09.projects/replserver
+*/
+
+func main() {
+	addr := flag.String("addr", ":7000", "listen address")
+	idle := flag.Duration("idle", 5*time.Minute, "close sessions idle for this long")
+	demo := flag.Bool("demo", false, "print two sessions over localhost")
+	flag.Parse()
+	if *demo {
+		if err := runDemo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	log := xlog.New("09.projects", "replserver")
+	srv := replserver.New(replserver.NewStore(), log)
+	srv.IdleTimeout = *idle
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Error("listen", "err", err)
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	log.Info("listening", "addr", ln.Addr())
+	if err := srv.Serve(ln); err != nil {
+		log.Error("serve", "err", err)
+		os.Exit(1)
+	}
+}
+
+// client is the other end of a session.
+type client struct {
+	c    net.Conn
+	r    *bufio.Reader
+	name string // in the last prompt
+}
+
+// prompt matches the end of an output: a line of a name and "> ".
+var prompt = regexp.MustCompile(`(^|\n)(\S+)> $`)
+
+// read returns what the server writes up to its next prompt, without the
+// prompt. It stops at the end of the session. An output line ending in "> "
+// would pass for a prompt: the values of the demo have none.
+func (cl *client) read() (string, error) {
+	cl.c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var b strings.Builder
+	for {
+		r, err := cl.r.ReadByte()
+		if err != nil {
+			return b.String(), err
+		}
+		b.WriteByte(r)
+		if r == ' ' {
+			s := b.String()
+			if m := prompt.FindStringSubmatchIndex(s); m != nil {
+				cl.name = s[m[4]:m[5]]
+				return s[:m[3]], nil
+			}
+		}
+	}
+}
+
+// do sends a line and prints it after the prompt, with its output.
+func (cl *client) do(line string) error {
+	fmt.Printf("%s> %s\n", cl.name, line)
+	cl.c.SetWriteDeadline(time.Now().Add(2 * time.Second))
+	if _, err := io.WriteString(cl.c, line+"\r\n"); err != nil {
+		return err
+	}
+	out, err := cl.read()
+	fmt.Print(out)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// runDemo serves on a free port of localhost and prints two sessions
+// sharing the store, their names and histories their own.
+func runDemo() error {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if os.Getenv("REPL_LOG") != "" {
+		log = xlog.New("09.projects", "replserver")
+	}
+	srv := replserver.New(replserver.NewStore(), log)
+	defer srv.Close()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	go srv.Serve(ln)
+
+	clients := map[string]*client{}
+	for _, who := range []string{"ana", "bob"} {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			return err
+		}
+		cl := &client{c: c, r: bufio.NewReader(c)}
+		if _, err := cl.read(); err != nil {
+			return err
+		}
+		clients[who] = cl
+	}
+	for _, step := range []struct{ who, line string }{
+		{"ana", "name ana"},
+		{"ana", `set greeting "hello, world"`},
+		{"ana", "incr visits"},
+		{"bob", "get greeting"},
+		{"bob", "incr visits 10"},
+		{"bob", "name ana"},
+		{"bob", "name bob"},
+		{"bob", "set lang"},
+		{"bob", "who"},
+		{"ana", "keys"},
+		{"ana", "history"},
+		{"bob", "quit"},
+	} {
+		if err := clients[step.who].do(step.line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package replserver
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type command struct {
+	usage, help string
+	min, max    int // arguments, max -1 for any number
+	run         func(s *Server, ss *session, args []string) error
+}
+
+// commands is filled in init: help reads it, it cannot be in its own
+// initializer.
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"help":    {"help [command]", "list the commands, or explain one", 0, 1, cmdHelp},
+		"get":     {"get key", "print the value of key", 1, 1, cmdGet},
+		"set":     {"set key value", "set key, quote a value with spaces", 2, 2, cmdSet},
+		"del":     {"del key", "delete key", 1, 1, cmdDel},
+		"incr":    {"incr key [n]", "add n, 1 by default, to the integer at key", 1, 2, cmdIncr},
+		"keys":    {"keys [prefix]", "list the keys, those starting with prefix", 0, 1, cmdKeys},
+		"stats":   {"stats", "goroutines, sessions, memory of the server", 0, 0, cmdStats},
+		"who":     {"who", "list the sessions", 0, 0, cmdWho},
+		"name":    {"name [new]", "print or change the name of this session", 0, 1, cmdName},
+		"history": {"history", "the commands of this session", 0, 0, cmdHistory},
+		"quit":    {"quit", "end the session", 0, 0, cmdQuit},
+	}
+	commands["exit"] = commands["quit"]
+}
+
+func cmdHelp(s *Server, ss *session, args []string) error {
+	if len(args) == 1 {
+		c, ok := commands[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown command %q", args[0])
+		}
+		fmt.Fprintf(ss.w, "%s\n  %s\n", c.usage, c.help)
+		return nil
+	}
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		if name != "exit" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(ss.w, "  %-16s %s\n", commands[name].usage, commands[name].help)
+	}
+	return nil
+}
+
+func cmdGet(s *Server, ss *session, args []string) error {
+	v, ok := s.Store.Get(args[0])
+	if !ok {
+		return fmt.Errorf("%s is not set", args[0])
+	}
+	fmt.Fprintln(ss.w, quote(v))
+	return nil
+}
+
+func cmdSet(s *Server, ss *session, args []string) error {
+	s.Store.Set(args[0], args[1])
+	fmt.Fprintln(ss.w, "ok")
+	return nil
+}
+
+func cmdDel(s *Server, ss *session, args []string) error {
+	if !s.Store.Delete(args[0]) {
+		return fmt.Errorf("%s is not set", args[0])
+	}
+	fmt.Fprintln(ss.w, "ok")
+	return nil
+}
+
+func cmdIncr(s *Server, ss *session, args []string) error {
+	n := int64(1)
+	if len(args) == 2 {
+		var err error
+		if n, err = strconv.ParseInt(args[1], 10, 64); err != nil {
+			return errors.New("n must be an integer")
+		}
+	}
+	v, err := s.Store.Incr(args[0], n)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(ss.w, v)
+	return nil
+}
+
+func cmdKeys(s *Server, ss *session, args []string) error {
+	prefix := ""
+	if len(args) == 1 {
+		prefix = args[0]
+	}
+	keys := s.Store.Keys(prefix)
+	for _, k := range keys {
+		fmt.Fprintln(ss.w, quote(k))
+	}
+	fmt.Fprintf(ss.w, "(%d keys)\n", len(keys))
+	return nil
+}
+
+func cmdStats(s *Server, ss *session, args []string) error {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	fmt.Fprintf(ss.w, "goroutines %d\n", runtime.NumGoroutine())
+	fmt.Fprintf(ss.w, "sessions   %d\n", s.Sessions())
+	fmt.Fprintf(ss.w, "keys       %d\n", s.Store.Len())
+	fmt.Fprintf(ss.w, "commands   %d\n", s.commands.Load())
+	fmt.Fprintf(ss.w, "heap       %.1f MB in use, %d GC cycles\n", float64(m.HeapInuse)/(1<<20), m.NumGC)
+	fmt.Fprintf(ss.w, "uptime     %v\n", time.Since(s.started).Round(time.Second))
+	return nil
+}
+
+func cmdWho(s *Server, ss *session, args []string) error {
+	s.mu.Lock()
+	list := make([]*session, 0, len(s.sessions))
+	for _, o := range s.sessions {
+		list = append(list, o)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].id < list[j].id })
+	lines := make([]string, len(list))
+	for i, o := range list {
+		me := ""
+		if o == ss {
+			me = " (you)"
+		}
+		lines[i] = fmt.Sprintf("%3d  %-12s %-21s for %v%s", o.id, o.name, o.conn.RemoteAddr(), time.Since(o.since).Round(time.Second), me)
+	}
+	s.mu.Unlock()
+	// written after the unlock: a slow client must not hold the lock of
+	// every session.
+	for _, l := range lines {
+		fmt.Fprintln(ss.w, l)
+	}
+	return nil
+}
+
+func cmdName(s *Server, ss *session, args []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(args) == 0 {
+		fmt.Fprintln(ss.w, ss.name)
+		return nil
+	}
+	for _, o := range s.sessions {
+		if o != ss && o.name == args[0] {
+			return fmt.Errorf("%s is taken", args[0])
+		}
+	}
+	ss.name = args[0]
+	fmt.Fprintln(ss.w, "ok")
+	return nil
+}
+
+func cmdHistory(s *Server, ss *session, args []string) error {
+	for i, line := range ss.history {
+		fmt.Fprintf(ss.w, "%3d  %s\n", i+1, line)
+	}
+	return nil
+}
+
+func cmdQuit(s *Server, ss *session, args []string) error {
+	fmt.Fprintln(ss.w, "bye")
+	ss.quit = true
+	return nil
+}
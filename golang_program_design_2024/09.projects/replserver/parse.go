@@ -0,0 +1,55 @@
+package replserver
+
+import (
+	"errors"
+	"strings"
+)
+
+var errUnterminated = errors.New("unterminated quote")
+
+// parseLine splits a command line in words, like a shell does: spaces
+// separate words, double quotes keep spaces in a word and a backslash
+// escapes the next character.
+//
+//	set greeting "hello, world"  =>  [set greeting hello, world]
+func parseLine(line string) ([]string, error) {
+	var words []string
+	var w strings.Builder
+	inWord, quoted, escaped := false, false, false
+	for _, r := range line {
+		switch {
+		case escaped:
+			w.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped, inWord = true, true
+		case r == '"':
+			quoted, inWord = !quoted, true
+		case (r == ' ' || r == '\t') && !quoted:
+			if inWord {
+				words = append(words, w.String())
+				w.Reset()
+				inWord = false
+			}
+		default:
+			w.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quoted || escaped {
+		return nil, errUnterminated
+	}
+	if inWord {
+		words = append(words, w.String())
+	}
+	return words, nil
+}
+
+// quote writes s so that parseLine reads it back as one word.
+func quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"\\") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
@@ -0,0 +1,185 @@
+// Package replserver serves an interactive shell over TCP, telnet style: a
+// client connects, gets a prompt, types commands and reads their output.
+//
+// Each connection is a session with a goroutine of its own and state of its
+// own (a name, a history); the key-value store is shared by all of them.
+// A session idle for too long is closed, so that forgotten clients do not
+// hold a goroutine and a socket forever.
+//
+// ServeConn runs a session on any net.Conn, which is how the demo tests it:
+// with net.Pipe, an in-memory connection, no port and no network.
+package replserver
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server accepts sessions.
+type Server struct {
+	Store       *Store
+	IdleTimeout time.Duration
+	Log         *slog.Logger
+
+	started  time.Time
+	commands atomic.Int64
+
+	mu       sync.Mutex
+	sessions map[int]*session
+	nextID   int
+	ln       net.Listener
+	closed   bool
+	wg       sync.WaitGroup
+}
+
+// New returns a server closing sessions idle for 5 minutes.
+func New(store *Store, log *slog.Logger) *Server {
+	return &Server{
+		Store:       store,
+		IdleTimeout: 5 * time.Minute,
+		Log:         log,
+		started:     time.Now(),
+		sessions:    make(map[int]*session),
+	}
+}
+
+// Serve accepts connections on ln until Close.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.ln = ln
+	s.mu.Unlock()
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		go s.ServeConn(c)
+	}
+}
+
+// Close stops accepting, ends every session and waits for them.
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	if s.ln != nil {
+		s.ln.Close()
+	}
+	for _, ss := range s.sessions {
+		ss.conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// Sessions returns the number of open sessions.
+func (s *Server) Sessions() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+// session is the state of one connection. Only its goroutine touches it,
+// except name, read by who from other sessions under Server.mu.
+type session struct {
+	id      int
+	name    string
+	conn    net.Conn
+	w       *bufio.Writer
+	history []string
+	since   time.Time
+	quit    bool
+}
+
+// ServeConn runs a session on c until the client quits, goes idle or the
+// server closes, and closes c.
+func (s *Server) ServeConn(c net.Conn) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		c.Close()
+		return
+	}
+	s.nextID++
+	ss := &session{id: s.nextID, name: fmt.Sprint("guest", s.nextID), conn: c, w: bufio.NewWriter(c), since: time.Now()}
+	s.sessions[ss.id] = ss
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, ss.id)
+		s.mu.Unlock()
+		c.Close()
+		s.wg.Done()
+	}()
+
+	log := s.Log.With("session", ss.id, "remote", c.RemoteAddr())
+	log.Info("session started")
+	fmt.Fprintf(ss.w, "welcome %s, type help for the commands\n", ss.name)
+	sc := bufio.NewScanner(c)
+	for !ss.quit {
+		fmt.Fprintf(ss.w, "%s> ", s.nameOf(ss))
+		if ss.w.Flush() != nil {
+			break
+		}
+		// the deadline is pushed back before each read: it bounds the
+		// silence of the client, not the length of the session.
+		c.SetReadDeadline(time.Now().Add(s.IdleTimeout))
+		if !sc.Scan() {
+			var ne net.Error
+			if err := sc.Err(); errors.As(err, &ne) && ne.Timeout() {
+				fmt.Fprintf(ss.w, "\nidle for %v, bye\n", s.IdleTimeout)
+				ss.w.Flush()
+				log.Info("session idle")
+				return
+			}
+			break
+		}
+		line := strings.TrimSpace(strings.TrimSuffix(sc.Text(), "\r"))
+		if line == "" {
+			continue
+		}
+		s.run(ss, line)
+	}
+	ss.w.Flush()
+	log.Info("session ended", "commands", len(ss.history))
+}
+
+func (s *Server) nameOf(ss *session) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ss.name
+}
+
+// run parses and runs one line, writing its output or its error.
+func (s *Server) run(ss *session, line string) {
+	words, err := parseLine(line)
+	if err != nil {
+		fmt.Fprintln(ss.w, "error:", err)
+		return
+	}
+	ss.history = append(ss.history, line)
+	s.commands.Add(1)
+	name, args := strings.ToLower(words[0]), words[1:]
+	c, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(ss.w, "error: unknown command %q, try help\n", name)
+		return
+	}
+	if len(args) < c.min || c.max >= 0 && len(args) > c.max {
+		fmt.Fprintf(ss.w, "error: usage: %s\n", c.usage)
+		return
+	}
+	if err := c.run(s, ss, args); err != nil {
+		fmt.Fprintln(ss.w, "error:", err)
+	}
+}
@@ -0,0 +1,325 @@
+package replserver
+
+import (
+	"bufio"
+	"io"
+	"log/slog"
+	"net"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+func newServer(t *testing.T) *Server {
+	t.Helper()
+	s := New(NewStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// client is the other end of a session over net.Pipe.
+type client struct {
+	t    *testing.T
+	c    net.Conn
+	r    *bufio.Reader
+	name string // in the last prompt
+}
+
+var prompt = regexp.MustCompile(`(^|\n)(\S+)> $`)
+
+// dial opens a session and reads the welcome line.
+func dial(t *testing.T, s *Server) (*client, string) {
+	t.Helper()
+	c1, c2 := net.Pipe()
+	go s.ServeConn(c2)
+	t.Cleanup(func() { c1.Close() })
+	cl := &client{t: t, c: c1, r: bufio.NewReader(c1)}
+	welcome, _ := cl.read()
+	return cl, welcome
+}
+
+// read returns what the server writes up to its next prompt, or up to the
+// end of the session, and the error that ended it.
+func (cl *client) read() (string, error) {
+	cl.c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var b strings.Builder
+	for {
+		r, err := cl.r.ReadByte()
+		if err != nil {
+			return b.String(), err
+		}
+		b.WriteByte(r)
+		if r == ' ' {
+			s := b.String()
+			if m := prompt.FindStringSubmatchIndex(s); m != nil {
+				cl.name = s[m[4]:m[5]]
+				return s[:m[3]], nil
+			}
+		}
+	}
+}
+
+// do sends a line and returns its output, without the last newline.
+func (cl *client) do(line string) string {
+	cl.t.Helper()
+	cl.c.SetWriteDeadline(time.Now().Add(2 * time.Second))
+	if _, err := io.WriteString(cl.c, line+"\r\n"); err != nil {
+		cl.t.Fatalf("%s: %v", line, err)
+	}
+	out, err := cl.read()
+	if err != nil && err != io.EOF {
+		cl.t.Fatalf("%s: %v", line, err)
+	}
+	return strings.TrimSuffix(out, "\n")
+}
+
+func TestSession(t *testing.T) {
+	cl, welcome := dial(t, newServer(t))
+	if welcome != "welcome guest1, type help for the commands\n" || cl.name != "guest1" {
+		t.Fatalf("welcome %q, prompt %q", welcome, cl.name)
+	}
+	for _, tt := range []struct{ line, want string }{
+		{"set greeting hello", "ok"},
+		{"get greeting", "hello"},
+		{`set greeting "hello, world"`, "ok"},
+		{"get greeting", `"hello, world"`},
+		{`set "a \"b\"" x\ y`, "ok"},
+		{`get "a \"b\""`, `"x y"`},
+		{"GET greeting", `"hello, world"`},
+		{"get missing", "error: missing is not set"},
+		{"incr hits", "1"},
+		{"incr hits 10", "11"},
+		{"incr hits -20", "-9"},
+		{"incr hits x", "error: n must be an integer"},
+		{"incr greeting", "error: greeting is not an integer"},
+		{"keys", "\"a \\\"b\\\"\"\ngreeting\nhits\n(3 keys)"},
+		{"keys h", "hits\n(1 keys)"},
+		{"del hits", "ok"},
+		{"del hits", "error: hits is not set"},
+		{"set onlykey", "error: usage: set key value"},
+		{"get a b", "error: usage: get key"},
+		{"frobnicate", `error: unknown command "frobnicate", try help`},
+		{`set x "open`, "error: unterminated quote"},
+		{"help get", "get key\n  print the value of key"},
+		{"", ""},
+		{"   ", ""},
+	} {
+		if got := cl.do(tt.line); got != tt.want {
+			t.Errorf("%q:\n%s\nwant\n%s", tt.line, got, tt.want)
+		}
+	}
+	if got := cl.do("help"); strings.Count(got, "\n") != len(commands)-2 || strings.Contains(got, "exit") {
+		t.Errorf("help lists:\n%s", got)
+	}
+
+	// the history has the commands that parsed, blank lines excluded.
+	got := cl.do("history")
+	lines := strings.Split(got, "\n")
+	if len(lines) != 23 || lines[0] != "  1  set greeting hello" || lines[22] != " 23  history" {
+		t.Errorf("history of %d lines:\n%s", len(lines), got)
+	}
+
+	if got := cl.do("quit"); got != "bye" {
+		t.Errorf("quit: %q", got)
+	}
+	if _, err := cl.r.ReadByte(); err != io.EOF {
+		t.Errorf("after quit: %v, want the connection closed", err)
+	}
+}
+
+// TestSessions runs two sessions at once: they share the store, not their
+// names nor their history.
+func TestSessions(t *testing.T) {
+	s := newServer(t)
+	alice, _ := dial(t, s)
+	bob, _ := dial(t, s)
+	if n := s.Sessions(); n != 2 {
+		t.Errorf("Sessions = %d, want 2", n)
+	}
+
+	alice.do("name alice")
+	if alice.name != "alice" {
+		t.Errorf("prompt after name: %q", alice.name)
+	}
+	if got := bob.do("name alice"); got != "error: alice is taken" {
+		t.Errorf("bob takes alice's name: %q", got)
+	}
+	alice.do("set shared 42")
+	if got := bob.do("get shared"); got != "42" {
+		t.Errorf("bob reads %q, want alice's 42", got)
+	}
+	if got := bob.do("history"); got != "  1  name alice\n  2  get shared\n  3  history" {
+		t.Errorf("bob's history:\n%s", got)
+	}
+
+	who := strings.Split(bob.do("who"), "\n")
+	if len(who) != 2 || !strings.Contains(who[0], "alice") || !strings.HasSuffix(who[1], "(you)") || !strings.Contains(who[1], "guest2") {
+		t.Errorf("who:\n%s", strings.Join(who, "\n"))
+	}
+	if got := bob.do("stats"); !strings.Contains(got, "sessions   2\n") || !strings.Contains(got, "keys       1\n") {
+		t.Errorf("stats:\n%s", got)
+	}
+
+	alice.do("exit")
+	waitSessions(t, s, 1)
+}
+
+func waitSessions(t *testing.T, s *Server, want int) {
+	t.Helper()
+	for deadline := time.Now().Add(2 * time.Second); s.Sessions() != want; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d sessions, want %d", s.Sessions(), want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestIncrConcurrent increments one key from several sessions: no increment
+// is lost.
+func TestIncrConcurrent(t *testing.T) {
+	s := newServer(t)
+	const sessions, each = 4, 50
+	var wg sync.WaitGroup
+	for i := 0; i < sessions; i++ {
+		cl, _ := dial(t, s)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < each; j++ {
+				cl.do("incr n")
+			}
+		}()
+	}
+	wg.Wait()
+	if v, _ := s.Store.Get("n"); v != "200" {
+		t.Errorf("n = %s, want %d", v, sessions*each)
+	}
+}
+
+func TestIdle(t *testing.T) {
+	s := newServer(t)
+	s.IdleTimeout = 50 * time.Millisecond
+	cl, _ := dial(t, s)
+	cl.do("set k v") // activity pushes the deadline back
+	out, err := cl.read()
+	if err != io.EOF || out != "\nidle for 50ms, bye\n" {
+		t.Errorf("an idle session: %q, %v", out, err)
+	}
+	waitSessions(t, s, 0)
+
+	// a session typing more slowly than the timeout in total, but never
+	// silent for that long, stays.
+	cl, _ = dial(t, s)
+	for i := 1; i <= 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		if got := cl.do("incr n"); got != strconv.Itoa(i) {
+			t.Fatalf("command %d of an active session: %q", i, got)
+		}
+	}
+}
+
+// TestServe runs sessions over TCP: they share the store, and Close ends
+// them and Serve.
+func TestServe(t *testing.T) {
+	s := newServer(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ln) }()
+	tcp := func() *client {
+		c, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { c.Close() })
+		cl := &client{t: t, c: c, r: bufio.NewReader(c)}
+		cl.read()
+		return cl
+	}
+	a, b := tcp(), tcp()
+	a.do("set lang go")
+	if got := b.do("get lang"); got != "go" {
+		t.Errorf("the other session reads %q", got)
+	}
+
+	s.Close()
+	if err := testutil.RequireRecv(t, served, 2*time.Second); err != nil {
+		t.Errorf("Serve = %v after Close", err)
+	}
+	for _, cl := range []*client{a, b} {
+		if _, err := cl.read(); err != io.EOF {
+			t.Errorf("a session after Close: %v, want EOF", err)
+		}
+	}
+}
+
+// TestClose closes the server with a session open: it ends, and a
+// connection after the close is closed at once.
+func TestClose(t *testing.T) {
+	s := New(NewStore(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	cl, _ := dial(t, s)
+	done := make(chan struct{})
+	go func() {
+		s.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not end the session")
+	}
+	if _, err := cl.read(); err != io.EOF {
+		t.Errorf("the session after Close: %v, want EOF", err)
+	}
+
+	c1, c2 := net.Pipe()
+	go s.ServeConn(c2)
+	c1.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := c1.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("a connection after Close: %v, want EOF", err)
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	for _, tt := range []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"  get   key  ", []string{"get", "key"}},
+		{"set k\tv", []string{"set", "k", "v"}},
+		{`set k "a b"`, []string{"set", "k", "a b"}},
+		{`set k a" "b`, []string{"set", "k", "a b"}},
+		{`set k ""`, []string{"set", "k", ""}},
+		{`set k \"`, []string{"set", "k", `"`}},
+		{`set k a\ b`, []string{"set", "k", "a b"}},
+		{`set k "say \"hi\""`, []string{"set", "k", `say "hi"`}},
+		{`set k "tab\	in"`, []string{"set", "k", "tab\tin"}},
+	} {
+		got, err := parseLine(tt.line)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseLine(%q) = %q, %v; want %q", tt.line, got, err, tt.want)
+		}
+	}
+	for _, line := range []string{`"open`, `a\`, `a "b \"`} {
+		if _, err := parseLine(line); err != errUnterminated {
+			t.Errorf("parseLine(%q) = %v, want errUnterminated", line, err)
+		}
+	}
+
+	// quote writes what parseLine reads back as one word.
+	for _, s := range []string{"plain", "", "a b", `"`, `\`, `a\"b c`, "tab\there"} {
+		got, err := parseLine("set " + quote(s))
+		if err != nil || len(got) != 2 || got[1] != s {
+			t.Errorf("quote(%q) = %s, read back as %q, %v", s, quote(s), got, err)
+		}
+	}
+}
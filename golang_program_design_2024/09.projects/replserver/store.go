@@ -0,0 +1,77 @@
+package replserver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Store is the key-value store shared by all the sessions.
+type Store struct {
+	mu sync.RWMutex
+	m  map[string]string
+}
+
+func NewStore() *Store { return &Store{m: make(map[string]string)} }
+
+func (s *Store) Get(k string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.m[k]
+	return v, ok
+}
+
+func (s *Store) Set(k, v string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[k] = v
+}
+
+// Delete removes k and reports whether it was there.
+func (s *Store) Delete(k string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.m[k]
+	delete(s.m, k)
+	return ok
+}
+
+// Incr adds n to the integer at k, 0 if k is not set, and returns the
+// result. Reading and writing under one lock is what makes it safe: a get
+// then a set from two sessions would lose increments.
+func (s *Store) Incr(k string, n int64) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var v int64
+	if old, ok := s.m[k]; ok {
+		var err error
+		if v, err = strconv.ParseInt(old, 10, 64); err != nil {
+			return 0, fmt.Errorf("%s is not an integer", k)
+		}
+	}
+	v += n
+	s.m[k] = strconv.FormatInt(v, 10)
+	return v, nil
+}
+
+// Keys returns the keys starting with prefix, sorted.
+func (s *Store) Keys(prefix string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var keys []string
+	for k := range s.m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.m)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/report"
+)
+
+/*
+report simulates a day of visits, aggregates them and writes the tables in
+every format asked for: report.csv, report.xlsx, report.md.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/report/cmd/report -out /tmp/report
+	go run ./golang_program_design_2024/09.projects/report/cmd/report -formats xlsx -n 100000
+	go run ./golang_program_design_2024/09.projects/report/cmd/report -demo
+
+The markdown format is defined in this file, outside the package: a new
+format only has to implement report.Format and be registered.
+
+-demo prints the first table in markdown and the size of the report in
+every format, written in memory. Its cases are tests, the CSV and XLSX files
+read back with encoding/csv, archive/zip and encoding/xml:
+go test ./golang_program_design_2024/09.projects/report/...
+*/
+
+// markdown is a format plugged in from outside the package.
+type markdown struct{}
+
+func (markdown) Name() string { return "md" }
+func (markdown) Ext() string  { return ".md" }
+
+func (markdown) Write(w io.Writer, tables []report.Table) error {
+	for _, t := range tables {
+		fmt.Fprintf(w, "## %s\n\n|", t.Title)
+		for _, c := range t.Columns {
+			fmt.Fprintf(w, " %s |", c.Name)
+		}
+		fmt.Fprint(w, "\n|")
+		for _, c := range t.Columns {
+			if c.Kind == report.Text {
+				fmt.Fprint(w, " --- |")
+			} else {
+				fmt.Fprint(w, " ---: |")
+			}
+		}
+		fmt.Fprintln(w)
+		for _, row := range t.Rows {
+			fmt.Fprint(w, "|")
+			for _, v := range row {
+				switch v := v.(type) {
+				case float64:
+					fmt.Fprintf(w, " %.1f%% |", v*100)
+				default:
+					fmt.Fprintf(w, " %v |", v)
+				}
+			}
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func main() {
+	report.Register(markdown{})
+
+	n := flag.Int("n", 5000, "number of simulated visits")
+	formats := flag.String("formats", "csv,xlsx,md", "comma-separated formats: "+strings.Join(report.Formats(), ", "))
+	out := flag.String("out", ".", "output directory")
+	demo := flag.Bool("demo", false, "print the reports instead of writing files")
+	flag.Parse()
+
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tables := report.Aggregate(report.Generate(day, *n, 1), runtime.NumCPU())
+
+	if *demo {
+		if err := runDemo(tables); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	for _, name := range strings.Split(*formats, ",") {
+		f, ok := report.Lookup(strings.TrimSpace(name))
+		if !ok {
+			fmt.Fprintf(os.Stderr, "unknown format %q, want one of %s\n", name, strings.Join(report.Formats(), ", "))
+			os.Exit(2)
+		}
+		path := filepath.Join(*out, "report"+f.Ext())
+		if err := writeFile(path, f, tables); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println("wrote", path)
+	}
+}
+
+func writeFile(path string, f report.Format, tables []report.Table) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := f.Write(file, tables); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func runDemo(tables []report.Table) error {
+	md, _ := report.Lookup("md")
+	md.Write(os.Stdout, tables[:1])
+	for _, name := range report.Formats() {
+		f, _ := report.Lookup(name)
+		var buf bytes.Buffer
+		if err := f.Write(&buf, tables); err != nil {
+			return err
+		}
+		fmt.Printf("%-4s %6d bytes\n", name, buf.Len())
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/report"
+)
+
+func TestMarkdown(t *testing.T) {
+	tables := []report.Table{{
+		Title:   "pages",
+		Columns: []report.Column{{Name: "page", Kind: report.Text}, {Name: "stay", Kind: report.Duration}, {Name: "share", Kind: report.Float}},
+		Rows:    [][]any{{"/docs", 1500 * time.Millisecond, 0.755}},
+	}}
+	var b strings.Builder
+	if err := (markdown{}).Write(&b, tables); err != nil {
+		t.Fatal(err)
+	}
+	want := "## pages\n\n| page | stay | share |\n| --- | ---: | ---: |\n| /docs | 1.5s | 75.5% |\n\n"
+	if b.String() != want {
+		t.Errorf("markdown\n%s\nwant\n%s", b.String(), want)
+	}
+}
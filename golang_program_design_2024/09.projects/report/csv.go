@@ -0,0 +1,37 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// CSV writes the tables one after the other, each headed by a row holding
+// its title alone. (No blank line between them: csv readers skip empty
+// lines, a reader counting records would not see it.) Durations are written in
+// seconds, so a spreadsheet can sum them.
+type CSV struct{}
+
+func (CSV) Name() string { return "csv" }
+func (CSV) Ext() string  { return ".csv" }
+
+func (CSV) Write(w io.Writer, tables []Table) error {
+	cw := csv.NewWriter(w)
+	for _, t := range tables {
+		cw.Write([]string{t.Title})
+		header := make([]string, len(t.Columns))
+		for j, c := range t.Columns {
+			header[j] = c.Name
+		}
+		cw.Write(header)
+		for _, row := range t.Rows {
+			rec := make([]string, len(row))
+			for j, v := range row {
+				rec[j] = cellText(v)
+			}
+			cw.Write(rec)
+		}
+	}
+	// Write buffers and keeps the first error for Flush and Error.
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,48 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var sample = []Table{
+	{
+		Title:   "pages",
+		Columns: []Column{{"page", Text}, {"visits", Int}, {"average stay", Duration}, {"share", Float}},
+		Rows: [][]any{
+			{"/docs", 3, 1500 * time.Millisecond, 0.75},
+			{`say "hi", <b>`, 1, time.Second, 0.25},
+		},
+	},
+	{Title: "hours", Columns: []Column{{"hour", Text}, {"visits", Int}}, Rows: [][]any{{"09:00", 4}}},
+}
+
+// TestCSV reads the file back with encoding/csv: a title and a header per
+// table, then its rows.
+func TestCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (CSV{}).Write(&buf, sample); err != nil {
+		t.Fatal(err)
+	}
+	r := csv.NewReader(&buf)
+	r.FieldsPerRecord = -1 // title rows have a single field
+	got, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"pages"},
+		{"page", "visits", "average stay", "share"},
+		{"/docs", "3", "1.500", "0.7500"},
+		{`say "hi", <b>`, "1", "1.000", "0.2500"},
+		{"hours"},
+		{"hour", "visits"},
+		{"09:00", "4"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("read back\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,217 @@
+// Package report turns visit records into summary tables and writes them in
+// any registered output format.
+//
+// The data is the website of the syncAtomic example of 04.concurrent/sync:
+// visitors arrive, stay for a while and leave. Aggregate reduces the visits
+// to tables; a Format writes tables. The two sides only meet through Table,
+// so a new format is one type implementing Format and a call to Register,
+// without touching the aggregation.
+package report
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Visit is one visitor on one page.
+type Visit struct {
+	Visitor  int
+	Page     string
+	Start    time.Time
+	Duration time.Duration
+}
+
+// Generate simulates n visits over the day starting at day. The same seed
+// gives the same visits.
+func Generate(day time.Time, n int, seed int64) []Visit {
+	r := rand.New(rand.NewSource(seed))
+	pages := []string{"/", "/docs", "/blog", "/pricing", "/login"}
+	visits := make([]Visit, n)
+	for i := range visits {
+		// more visitors during the day than at night.
+		hour := int(12 + r.NormFloat64()*4)
+		hour = min(max(hour, 0), 23)
+		visits[i] = Visit{
+			Visitor: r.Intn(n / 3),
+			Page:    pages[min(int(r.ExpFloat64()), len(pages)-1)],
+			Start:   day.Add(time.Duration(hour)*time.Hour + time.Duration(r.Intn(3600))*time.Second),
+			// as in syncAtomic, every visit lasts a different time.
+			Duration: time.Duration(500+r.Intn(1000)*10) * time.Millisecond,
+		}
+	}
+	return visits
+}
+
+// Kind tells a Format how to write the values of a column.
+type Kind int
+
+const (
+	Text Kind = iota
+	Int
+	Float
+	Duration
+)
+
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Table is what the formats write. Every row has one value per column: a
+// string, an int, a float64 or a time.Duration, following the column Kind.
+type Table struct {
+	Title   string
+	Columns []Column
+	Rows    [][]any
+}
+
+// Aggregate computes the summary tables: visits per page and per hour. The
+// visits are split between workers, each counting its share in its own
+// maps; the partial results are merged at the end, no lock on the hot path.
+func Aggregate(visits []Visit, workers int) []Table {
+	type pageStats struct {
+		visits   int
+		visitors map[int]bool
+		total    time.Duration
+	}
+	type partial struct {
+		pages map[string]*pageStats
+		hours [24]int
+	}
+
+	parts := make([]partial, max(workers, 1))
+	var wg sync.WaitGroup
+	chunk := (len(visits) + len(parts) - 1) / len(parts)
+	for w := range parts {
+		wg.Add(1)
+		go func(p *partial, vs []Visit) {
+			defer wg.Done()
+			p.pages = make(map[string]*pageStats)
+			for _, v := range vs {
+				s, ok := p.pages[v.Page]
+				if !ok {
+					s = &pageStats{visitors: make(map[int]bool)}
+					p.pages[v.Page] = s
+				}
+				s.visits++
+				s.visitors[v.Visitor] = true
+				s.total += v.Duration
+				p.hours[v.Start.Hour()]++
+			}
+		}(&parts[w], visits[min(w*chunk, len(visits)):min((w+1)*chunk, len(visits))])
+	}
+	wg.Wait()
+
+	pages := make(map[string]*pageStats)
+	var hours [24]int
+	for _, p := range parts {
+		for name, s := range p.pages {
+			all, ok := pages[name]
+			if !ok {
+				all = &pageStats{visitors: make(map[int]bool)}
+				pages[name] = all
+			}
+			all.visits += s.visits
+			all.total += s.total
+			for v := range s.visitors {
+				all.visitors[v] = true
+			}
+		}
+		for h, n := range p.hours {
+			hours[h] += n
+		}
+	}
+
+	byPage := Table{
+		Title: "pages",
+		Columns: []Column{
+			{"page", Text}, {"visits", Int}, {"unique visitors", Int}, {"average stay", Duration}, {"share", Float},
+		},
+	}
+	names := make([]string, 0, len(pages))
+	for name := range pages {
+		names = append(names, name)
+	}
+	// most visited first, by name on a tie: the output is the same every run.
+	sort.Slice(names, func(i, j int) bool {
+		a, b := pages[names[i]], pages[names[j]]
+		if a.visits != b.visits {
+			return a.visits > b.visits
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		s := pages[name]
+		byPage.Rows = append(byPage.Rows, []any{
+			name, s.visits, len(s.visitors), (s.total / time.Duration(s.visits)).Round(time.Millisecond),
+			float64(s.visits) / float64(len(visits)),
+		})
+	}
+
+	byHour := Table{Title: "hours", Columns: []Column{{"hour", Text}, {"visits", Int}}}
+	for h, n := range hours {
+		byHour.Rows = append(byHour.Rows, []any{fmt.Sprintf("%02d:00", h), n})
+	}
+	return []Table{byPage, byHour}
+}
+
+// Format writes tables to a stream.
+type Format interface {
+	Name() string
+	// Ext is the file extension, with the dot.
+	Ext() string
+	Write(w io.Writer, tables []Table) error
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = make(map[string]Format)
+)
+
+// Register makes a format available to Lookup, replacing one of the same name.
+func Register(f Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[f.Name()] = f
+}
+
+// Lookup returns the format registered under name.
+func Lookup(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	f, ok := formats[name]
+	return f, ok
+}
+
+// Formats returns the names of the registered formats, sorted.
+func Formats() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(CSV{})
+	Register(XLSX{})
+}
+
+// cellText formats a value for text formats.
+func cellText(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return fmt.Sprintf("%.4f", v)
+	case time.Duration:
+		return fmt.Sprintf("%.3f", v.Seconds())
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,78 @@
+package report
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+var day = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+func TestGenerate(t *testing.T) {
+	a, b := Generate(day, 1000, 1), Generate(day, 1000, 1)
+	if !reflect.DeepEqual(a, b) {
+		t.Error("one seed gave two sets of visits")
+	}
+	if reflect.DeepEqual(a, Generate(day, 1000, 2)) {
+		t.Error("two seeds gave the same visits")
+	}
+	for _, v := range a {
+		if v.Start.Before(day) || !v.Start.Before(day.Add(24*time.Hour)) || v.Duration <= 0 || v.Page == "" {
+			t.Fatalf("a visit out of the day: %+v", v)
+		}
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	at := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+	visits := []Visit{
+		{1, "/docs", at(9), 2 * time.Second},
+		{2, "/docs", at(9), 4 * time.Second},
+		{1, "/docs", at(10), 3 * time.Second},
+		{3, "/", at(23), time.Second},
+	}
+	for _, workers := range []int{0, 1, 3, 8} {
+		tables := Aggregate(visits, workers)
+		if len(tables) != 2 || tables[0].Title != "pages" || tables[1].Title != "hours" {
+			t.Fatalf("%d workers: tables %v", workers, tables)
+		}
+		want := [][]any{
+			{"/docs", 3, 2, 3 * time.Second, 0.75},
+			{"/", 1, 1, time.Second, 0.25},
+		}
+		if !reflect.DeepEqual(tables[0].Rows, want) {
+			t.Errorf("%d workers: pages %v, want %v", workers, tables[0].Rows, want)
+		}
+		hours := tables[1].Rows
+		if len(hours) != 24 || !reflect.DeepEqual(hours[9], []any{"09:00", 2}) || !reflect.DeepEqual(hours[23], []any{"23:00", 1}) || hours[0][1] != 0 {
+			t.Errorf("%d workers: hours %v", workers, hours)
+		}
+	}
+}
+
+// TestAggregateWorkers checks that the split between workers does not
+// change the result.
+func TestAggregateWorkers(t *testing.T) {
+	visits := Generate(day, 5000, 1)
+	one := Aggregate(visits, 1)
+	if many := Aggregate(visits, 7); !reflect.DeepEqual(one, many) {
+		t.Error("7 workers aggregate differently from 1")
+	}
+	total := 0
+	for _, row := range one[1].Rows {
+		total += row[1].(int)
+	}
+	if total != len(visits) {
+		t.Errorf("%d visits over the hours, want %d", total, len(visits))
+	}
+}
+
+func TestFormats(t *testing.T) {
+	if got := Formats(); !slices.Contains(got, "csv") || !slices.Contains(got, "xlsx") {
+		t.Errorf("Formats = %v, want csv and xlsx", got)
+	}
+	if _, ok := Lookup("pdf"); ok {
+		t.Error("Lookup found an unregistered format")
+	}
+}
@@ -0,0 +1,175 @@
+package report
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// XLSX writes an Excel workbook, one sheet per table, with a bold header row.
+//
+// An .xlsx file is a zip archive of XML documents (Office Open XML). The
+// smallest workbook Excel and LibreOffice accept needs five of them: the
+// content types, the package relationships, the workbook, its relationships,
+// and one document per sheet. Strings are stored inline in the cells, which
+// saves the shared strings table; a styles document provides the bold font.
+type XLSX struct{}
+
+func (XLSX) Name() string { return "xlsx" }
+func (XLSX) Ext() string  { return ".xlsx" }
+
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+%s</Types>`
+	xlsxSheetType = `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+`
+	xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+	xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets>
+%s</sheets>
+</workbook>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+%s</Relationships>`
+	// cellXfs 0 is the default style, 1 the bold header, 2 a number with 2
+	// decimals, 3 a percentage.
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="1"><fill><patternFill patternType="none"/></fill></fills>
+<borders count="1"><border/></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="4">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+<xf numFmtId="2" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="10" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+</cellXfs>
+</styleSheet>`
+)
+
+const (
+	styleHeader  = 1
+	styleDecimal = 2
+	stylePercent = 3
+)
+
+func (XLSX) Write(w io.Writer, tables []Table) error {
+	zw := zip.NewWriter(w)
+	var sheetTypes, sheets, sheetRels strings.Builder
+	for i, t := range tables {
+		n := i + 1
+		fmt.Fprintf(&sheetTypes, xlsxSheetType, n)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`+"\n", xmlEscape(sheetName(t.Title)), n, n)
+		fmt.Fprintf(&sheetRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`+"\n", n, n)
+	}
+
+	files := []struct{ name, body string }{
+		{"[Content_Types].xml", fmt.Sprintf(xlsxContentTypes, sheetTypes.String())},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, sheets.String())},
+		{"xl/_rels/workbook.xml.rels", fmt.Sprintf(xlsxWorkbookRels, sheetRels.String())},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for i, t := range tables {
+		files = append(files, struct{ name, body string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheetXML(t)})
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, f.body); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func sheetXML(t Table) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + "\n")
+
+	b.WriteString(`<row r="1">`)
+	for j, c := range t.Columns {
+		inlineString(&b, cellRef(j, 1), c.Name, styleHeader)
+	}
+	b.WriteString("</row>\n")
+
+	for i, row := range t.Rows {
+		r := i + 2
+		fmt.Fprintf(&b, `<row r="%d">`, r)
+		for j, v := range row {
+			ref := cellRef(j, r)
+			switch v := v.(type) {
+			case int:
+				fmt.Fprintf(&b, `<c r="%s"><v>%d</v></c>`, ref, v)
+			case float64:
+				// shares are fractions: shown as a percentage.
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%g</v></c>`, ref, stylePercent, v)
+			case time.Duration:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%g</v></c>`, ref, styleDecimal, v.Seconds())
+			default:
+				inlineString(&b, ref, fmt.Sprint(v), 0)
+			}
+		}
+		b.WriteString("</row>\n")
+	}
+	b.WriteString("</sheetData></worksheet>")
+	return b.String()
+}
+
+func inlineString(b *strings.Builder, ref, s string, style int) {
+	fmt.Fprintf(b, `<c r="%s" t="inlineStr"`, ref)
+	if style != 0 {
+		fmt.Fprintf(b, ` s="%d"`, style)
+	}
+	fmt.Fprintf(b, `><is><t>%s</t></is></c>`, xmlEscape(s))
+}
+
+// cellRef returns the A1 name of a cell: column 0 is A, 25 Z, 26 AA.
+func cellRef(col, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return fmt.Sprintf("%s%d", name, row)
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// sheetName applies the rules of Excel: at most 31 characters, none of
+// []:*?/\.
+func sheetName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, s)
+	if len(s) > 31 {
+		s = s[:31]
+	}
+	if s == "" {
+		s = "Sheet"
+	}
+	return s
+}
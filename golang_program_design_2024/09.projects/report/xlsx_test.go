@@ -0,0 +1,92 @@
+package report
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+type sheet struct {
+	Rows []struct {
+		Cells []struct {
+			Ref    string `xml:"r,attr"`
+			Style  string `xml:"s,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// TestXLSX reads the workbook back with archive/zip and encoding/xml.
+func TestXLSX(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (XLSX{}).Write(&buf, sample); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	want := []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels",
+		"xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("parts %v, want %v", names, want)
+	}
+
+	f, err := zr.Open("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var s sheet
+	if err := xml.NewDecoder(f).Decode(&s); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Rows) != 3 {
+		t.Fatalf("%d rows, want the header and 2", len(s.Rows))
+	}
+	type cell struct{ ref, style, text string }
+	var got []cell
+	for _, c := range s.Rows[2].Cells {
+		got = append(got, cell{c.Ref, c.Style, c.Value + c.Inline})
+	}
+	wantCells := []cell{{"A3", "", `say "hi", <b>`}, {"B3", "", "1"}, {"C3", "2", "1"}, {"D3", "3", "0.25"}}
+	if !reflect.DeepEqual(got, wantCells) {
+		t.Errorf("row 3: %v, want %v", got, wantCells)
+	}
+	if h := s.Rows[0].Cells[2]; h.Inline != "average stay" || h.Style != "1" {
+		t.Errorf("header cell %+v", h)
+	}
+}
+
+func TestCellRef(t *testing.T) {
+	for _, tt := range []struct {
+		col, row int
+		want     string
+	}{
+		{0, 1, "A1"}, {25, 2, "Z2"}, {26, 3, "AA3"}, {51, 1, "AZ1"}, {52, 1, "BA1"}, {701, 9, "ZZ9"}, {702, 1, "AAA1"},
+	} {
+		if got := cellRef(tt.col, tt.row); got != tt.want {
+			t.Errorf("cellRef(%d, %d) = %s, want %s", tt.col, tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestSheetName(t *testing.T) {
+	for in, want := range map[string]string{
+		"pages":                                "pages",
+		"a/b:c?":                               "a_b_c_",
+		"":                                     "Sheet",
+		"a title far longer than excel allows": "a title far longer than excel a",
+	} {
+		if got := sheetName(in); got != want {
+			t.Errorf("sheetName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
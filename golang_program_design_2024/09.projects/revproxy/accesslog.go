@@ -0,0 +1,71 @@
+package revproxy
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// entryKey is the context key of the entry a request fills in on its way
+// through the handlers, for AccessLog to read afterwards.
+type entryKey struct{}
+
+type entry struct {
+	route string
+}
+
+// recorder is a ResponseWriter keeping the status and size of the response.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach Flush and the deadlines of the
+// real writer, ReverseProxy flushes streamed responses through it.
+func (r *recorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
+// AccessLog logs a line per request once next has answered: method, host,
+// path, the route it took, status, size and duration.
+func AccessLog(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &recorder{ResponseWriter: w}
+		e := &entry{}
+		// deferred: ReverseProxy aborts the handler with a panic when the
+		// target fails in the middle of the body, that request is logged too.
+		defer func() {
+			if rec.status == 0 {
+				rec.status = http.StatusOK // nothing written at all
+			}
+			log.Info("access",
+				"method", r.Method,
+				"host", r.Host,
+				"path", r.URL.Path,
+				"route", e.route,
+				"status", rec.status,
+				"bytes", rec.bytes,
+				"took", time.Since(start).Round(time.Microsecond),
+				"remote", r.RemoteAddr,
+			)
+		}()
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), entryKey{}, e)))
+	})
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/revproxy"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+revproxy forwards requests to the backends of a JSON config.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/revproxy/cmd/revproxy \
+		-config golang_program_design_2024/09.projects/revproxy/example.json -addr :8080
+	curl -H 'Host: api.localhost' localhost:8080/users
+
+	go run ./golang_program_design_2024/09.projects/revproxy/cmd/revproxy -demo
+
+-demo starts backends on local test servers, puts the proxy in front of them
+and prints, end to end over real HTTP, where requests go, the headers
+rewritten, timeouts, errors and the access log. Its cases are tests:
+go test ./golang_program_design_2024/09.projects/revproxy
+*/
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	path := flag.String("config", "", "JSON config file")
+	demo := flag.Bool("demo", false, "run the proxy in front of local test backends")
+	flag.Parse()
+
+	if *demo {
+		if err := runDemo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	log := xlog.New("09.projects", "revproxy")
+	if *path == "" {
+		fmt.Fprintln(os.Stderr, "-config is required")
+		os.Exit(2)
+	}
+	cfg, err := revproxy.LoadConfig(*path)
+	if err != nil {
+		log.Error("invalid config", "err", err)
+		os.Exit(2)
+	}
+	p, err := revproxy.New(cfg, log)
+	if err != nil {
+		log.Error("invalid config", "err", err)
+		os.Exit(2)
+	}
+	srv := &http.Server{Addr: *addr, Handler: revproxy.AccessLog(log, p), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	log.Info("listening", "addr", *addr, "routes", len(cfg.Routes))
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Error("serve", "err", err)
+		os.Exit(1)
+	}
+}
+
+// seen is what a backend received, echoed back as JSON.
+type seen struct {
+	Backend string      `json:"backend"`
+	Host    string      `json:"host"`
+	Path    string      `json:"path"`
+	Header  http.Header `json:"header"`
+}
+
+// echo is a backend answering with what it received.
+func echo(name string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if d, err := time.ParseDuration(r.URL.Query().Get("sleep")); err == nil {
+			select {
+			case <-time.After(d):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Server", "echo/1.0")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(seen{Backend: name, Host: r.Host, Path: r.URL.Path, Header: r.Header})
+	}))
+}
+
+const demoConfig = `{
+	"timeout": "2s",
+	"routes": [
+		{"name": "api", "host": "api.example.com", "target": "{api}", "timeout": "200ms",
+		 "request_headers": {"X-Gateway": "revproxy", "X-Debug": ""},
+		 "response_headers": {"Server": "", "X-Frame-Options": "DENY"}},
+		{"name": "api-v2", "host": "api.example.com", "path": "/v2", "target": "{v2}"},
+		{"name": "apps", "host": "*.apps.example.com", "target": "{apps}"},
+		{"name": "static", "path": "/static/", "strip_prefix": true, "target": "{static}/assets"},
+		{"name": "down", "path": "/down", "target": "{down}"}
+	]
+}`
+
+func runDemo() error {
+	api, v2, apps, static := echo("api"), echo("api-v2"), echo("apps"), echo("static")
+	defer api.Close()
+	defer v2.Close()
+	defer apps.Close()
+	defer static.Close()
+	// a backend that is gone: its address refuses connections.
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	text := strings.NewReplacer("{api}", api.URL, "{v2}", v2.URL, "{apps}", apps.URL,
+		"{static}", static.URL, "{down}", down.URL).Replace(demoConfig)
+	cfg, err := revproxy.ParseConfig(strings.NewReader(text))
+	if err != nil {
+		return err
+	}
+
+	var accessLog bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&accessLog, nil))
+	p, err := revproxy.New(cfg, log)
+	if err != nil {
+		return err
+	}
+	front := httptest.NewServer(revproxy.AccessLog(log, p))
+	defer front.Close()
+
+	do := func(host, path string, header ...string) (*http.Response, seen, error) {
+		req, err := http.NewRequest(http.MethodGet, front.URL+path, nil)
+		if err != nil {
+			return nil, seen{}, err
+		}
+		req.Host = host
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		start := time.Now()
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, seen{}, err
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		var s seen
+		json.Unmarshal(body, &s)
+		fmt.Printf("%-22s %-22s %s after %v", host, path, resp.Status, time.Since(start).Round(10*time.Millisecond))
+		if s.Backend != "" {
+			fmt.Printf(", %s got %s", s.Backend, s.Path)
+		}
+		fmt.Println()
+		return resp, s, nil
+	}
+
+	fmt.Println("== routing, timeouts and errors")
+	for _, r := range [][2]string{
+		{"api.example.com", "/users/1"},
+		{"API.example.com:8080", "/users/1"},
+		{"api.example.com", "/v2/users"},
+		{"api.example.com", "/v2users"},
+		{"shop.apps.example.com", "/"},
+		{"other.example.com", "/static/css/site.css"},
+		{"other.example.com", "/nothing"},
+		{"api.example.com", "/slow?sleep=1s"},
+		{"shop.apps.example.com", "/slow?sleep=300ms"},
+		{"other.example.com", "/down"},
+	} {
+		if _, _, err := do(r[0], r[1]); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("\n== headers")
+	resp, s, err := do("api.example.com", "/", "X-Debug", "1", "X-Forwarded-For", "6.6.6.6")
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{"X-Gateway", "X-Debug", "X-Forwarded-For", "X-Forwarded-Host"} {
+		fmt.Printf("  the backend got %s: %q\n", name, s.Header.Get(name))
+	}
+	fmt.Printf("  the backend saw the host %s\n", s.Host)
+	for _, name := range []string{"X-Frame-Options", "Server"} {
+		fmt.Printf("  the client got %s: %q\n", name, resp.Header.Get(name))
+	}
+
+	fmt.Println("\n== config")
+	for _, c := range []string{
+		`{"routes": [{"name": "a", "target": "http://x", "timout": "1s"}]}`,
+		`{"routes": [{"name": "a", "path": "a", "target": "x"}, {"name": "a", "target": "http://x"}]}`,
+		`{"timeout": 5, "routes": []}`,
+	} {
+		_, err := revproxy.ParseConfig(strings.NewReader(c))
+		fmt.Printf("%s\n  %v\n", c, strings.ReplaceAll(fmt.Sprint(err), "\n", "; "))
+	}
+
+	fmt.Println("\n== access log")
+	fmt.Print(accessLog.String())
+	return nil
+}
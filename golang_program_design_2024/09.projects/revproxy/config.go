@@ -0,0 +1,123 @@
+package revproxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Config is the JSON file the proxy is started with:
+//
+//	{
+//	  "routes": [
+//	    {"name": "api", "host": "api.example.com", "target": "http://127.0.0.1:9001", "timeout": "2s"},
+//	    {"name": "static", "path": "/static/", "strip_prefix": true, "target": "http://127.0.0.1:9002"}
+//	  ]
+//	}
+type Config struct {
+	Routes []Route `json:"routes"`
+	// Timeout applies to the routes that do not set their own.
+	Timeout Duration `json:"timeout"`
+}
+
+// Route sends the requests it matches to Target.
+type Route struct {
+	Name string `json:"name"`
+	// Host matches the Host header without its port, exactly or, for
+	// "*.example.com", any subdomain. Empty matches every host.
+	Host string `json:"host"`
+	// Path is a path prefix, "/" when empty. The longest matching prefix
+	// wins, and among equal prefixes a route with a host before one without.
+	Path string `json:"path"`
+	// StripPrefix removes Path from the path sent to the target.
+	StripPrefix bool   `json:"strip_prefix"`
+	Target      string `json:"target"`
+	// Timeout bounds the whole exchange with the target, answered with 504
+	// Gateway Timeout when it runs out.
+	Timeout Duration `json:"timeout"`
+
+	// Headers rewritten on the way in and on the way out. A header set to ""
+	// is removed.
+	RequestHeaders  map[string]string `json:"request_headers"`
+	ResponseHeaders map[string]string `json:"response_headers"`
+}
+
+// Duration is a time.Duration written as a string in JSON, like "1.5s".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"2s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadConfig reads and checks the config file at path.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	cfg, err := ParseConfig(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ParseConfig decodes a config, rejecting unknown fields: a typo in a field
+// name would otherwise silently leave it at its zero value.
+func ParseConfig(r io.Reader) (*Config, error) {
+	var cfg Config
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (c *Config) validate() error {
+	if len(c.Routes) == 0 {
+		return errors.New("no routes")
+	}
+	var errs []error
+	names := make(map[string]bool)
+	for i, rt := range c.Routes {
+		if rt.Name == "" {
+			errs = append(errs, fmt.Errorf("route %d: no name", i))
+		} else if names[rt.Name] {
+			errs = append(errs, fmt.Errorf("route %q: duplicate name", rt.Name))
+		}
+		names[rt.Name] = true
+		if rt.Path != "" && !strings.HasPrefix(rt.Path, "/") {
+			errs = append(errs, fmt.Errorf("route %q: path %q does not start with /", rt.Name, rt.Path))
+		}
+		if u, err := url.Parse(rt.Target); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("route %q: target %q is not an http(s) URL", rt.Name, rt.Target))
+		}
+		if rt.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("route %q: negative timeout", rt.Name))
+		}
+	}
+	return errors.Join(errs...)
+}
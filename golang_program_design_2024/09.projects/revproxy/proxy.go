@@ -0,0 +1,179 @@
+// Package revproxy is an HTTP reverse proxy routing requests to backends by
+// host and path, configured from a JSON file (see Config).
+//
+// The forwarding itself is httputil.ReverseProxy: it copies the request to
+// the target, removes the hop-by-hop headers (Connection, Keep-Alive, ...),
+// streams the response back, and handles upgrades like WebSocket. What this
+// package adds around it is the routing table, the header rewriting, a
+// timeout per route, and errors answered as 502 or 504 instead of a bare
+// failure. AccessLog logs one line per request.
+package revproxy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Proxy routes requests to the targets of its routes.
+type Proxy struct {
+	routes []*route // in matching order
+	log    *slog.Logger
+}
+
+type route struct {
+	Route
+	target  *url.URL
+	timeout time.Duration
+	proxy   *httputil.ReverseProxy
+}
+
+// New builds the proxy of cfg, which ParseConfig has already checked.
+func New(cfg *Config, log *slog.Logger) (*Proxy, error) {
+	p := &Proxy{log: log}
+	for _, rc := range cfg.Routes {
+		target, err := url.Parse(rc.Target)
+		if err != nil {
+			return nil, err
+		}
+		rt := &route{Route: rc, target: target, timeout: time.Duration(rc.Timeout)}
+		if rt.Path == "" {
+			rt.Path = "/"
+		}
+		rt.Host = strings.ToLower(rt.Host)
+		if rt.timeout == 0 {
+			rt.timeout = time.Duration(cfg.Timeout)
+		}
+		rt.proxy = &httputil.ReverseProxy{
+			Rewrite:        rt.rewrite,
+			ModifyResponse: rt.modifyResponse,
+			ErrorHandler:   p.errorHandler(rt),
+		}
+		p.routes = append(p.routes, rt)
+	}
+	// most specific first: longer paths, then routes bound to a host. The
+	// stable sort keeps the file order between equals.
+	sort.SliceStable(p.routes, func(i, j int) bool {
+		a, b := p.routes[i], p.routes[j]
+		if len(a.Path) != len(b.Path) {
+			return len(a.Path) > len(b.Path)
+		}
+		return a.Host != "" && b.Host == ""
+	})
+	return p, nil
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt := p.match(r)
+	if rt == nil {
+		http.Error(w, "no route for "+r.Host+r.URL.Path, http.StatusNotFound)
+		return
+	}
+	if e, ok := r.Context().Value(entryKey{}).(*entry); ok {
+		e.route = rt.Name
+	}
+	if rt.timeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), rt.timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+	}
+	rt.proxy.ServeHTTP(w, r)
+}
+
+func (p *Proxy) match(r *http.Request) *route {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	for _, rt := range p.routes {
+		if matchHost(rt.Host, host) && matchPath(rt.Path, r.URL.Path) {
+			return rt
+		}
+	}
+	return nil
+}
+
+func matchHost(pattern, host string) bool {
+	if pattern == "" || pattern == host {
+		return true
+	}
+	// "*.example.com" matches "a.example.com" and "a.b.example.com", not
+	// "example.com" itself.
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return false
+}
+
+// matchPath matches whole segments: "/api" matches "/api" and "/api/users",
+// not "/apis".
+func matchPath(prefix, path string) bool {
+	base := strings.TrimSuffix(prefix, "/")
+	return path == base || strings.HasPrefix(path, base+"/")
+}
+
+func stripPrefix(prefix, path string) string {
+	path = strings.TrimPrefix(path, strings.TrimSuffix(prefix, "/"))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// rewrite turns the incoming request into the one sent to the target.
+// ReverseProxy has already removed the X-Forwarded headers the client sent:
+// only the ones SetXForwarded adds can be trusted by the target.
+func (rt *route) rewrite(pr *httputil.ProxyRequest) {
+	if rt.StripPrefix {
+		pr.Out.URL.Path = stripPrefix(rt.Path, pr.Out.URL.Path)
+		if pr.Out.URL.RawPath != "" {
+			pr.Out.URL.RawPath = stripPrefix(rt.Path, pr.Out.URL.RawPath)
+		}
+	}
+	// SetURL joins the target path and the request path, and sets Host to
+	// the target: the original host travels in X-Forwarded-Host.
+	pr.SetURL(rt.target)
+	pr.SetXForwarded()
+	setHeaders(pr.Out.Header, rt.RequestHeaders)
+}
+
+func (rt *route) modifyResponse(resp *http.Response) error {
+	setHeaders(resp.Header, rt.ResponseHeaders)
+	return nil
+}
+
+func setHeaders(h http.Header, set map[string]string) {
+	for k, v := range set {
+		if v == "" {
+			h.Del(k)
+		} else {
+			h.Set(k, v)
+		}
+	}
+}
+
+// errorHandler answers when the target could not be reached or did not
+// answer in time. Nothing has been written to w yet.
+func (p *Proxy) errorHandler(rt *route) func(http.ResponseWriter, *http.Request, error) {
+	return func(w http.ResponseWriter, r *http.Request, err error) {
+		status := http.StatusBadGateway
+		switch {
+		case errors.Is(r.Context().Err(), context.DeadlineExceeded):
+			status = http.StatusGatewayTimeout
+		case errors.Is(r.Context().Err(), context.Canceled):
+			// the client went away, nobody reads the answer.
+			p.log.Debug("client canceled", "route", rt.Name, "path", r.URL.Path)
+		default:
+			p.log.Warn("backend error", "route", rt.Name, "target", rt.Target, "err", err)
+		}
+		http.Error(w, http.StatusText(status), status)
+	}
+}
@@ -0,0 +1,251 @@
+package revproxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backend answers with its name, the path it got and the request headers,
+// as JSON, and sets a Server header for the proxy to remove.
+func backend(t *testing.T, name string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "backend")
+		json.NewEncoder(w).Encode(echo{Backend: name, Path: r.URL.Path, Query: r.URL.RawQuery, Host: r.Host, Header: r.Header})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+type echo struct {
+	Backend, Path, Query, Host string
+	Header                     http.Header
+}
+
+// front starts the proxy of cfg behind AccessLog, logging into logs.
+func front(t *testing.T, cfg string, logs *bytes.Buffer) *httptest.Server {
+	t.Helper()
+	c, err := ParseConfig(strings.NewReader(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if logs != nil {
+		log = slog.New(slog.NewTextHandler(logs, nil))
+	}
+	p, err := New(c, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(AccessLog(log, p))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// get sends a request for path to the proxy with the Host header host.
+func get(t *testing.T, proxy *httptest.Server, host, path string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest("GET", proxy.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Host = host
+	req.Header.Set("Cookie", "session=secret")
+	req.Header.Set("X-Forwarded-For", "6.6.6.6") // spoofed
+	resp, err := proxy.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func decode(t *testing.T, resp *http.Response) echo {
+	t.Helper()
+	var e echo
+	if err := json.NewDecoder(resp.Body).Decode(&e); err != nil {
+		t.Fatalf("%s: %v", resp.Status, err)
+	}
+	return e
+}
+
+// TestRouting sends requests through the proxy to four backends, as in
+// example.json.
+func TestRouting(t *testing.T) {
+	api, v2, apps, static, def := backend(t, "api"), backend(t, "api-v2"), backend(t, "apps"), backend(t, "static"), backend(t, "default")
+	proxy := front(t, fmt.Sprintf(`{"routes": [
+		{"name": "api", "host": "api.localhost", "target": %q},
+		{"name": "api-v2", "host": "api.localhost", "path": "/v2", "target": %q},
+		{"name": "apps", "host": "*.apps.localhost", "target": %q},
+		{"name": "static", "path": "/static/", "strip_prefix": true, "target": %q},
+		{"name": "default", "target": %q}
+	]}`, api.URL, v2.URL, apps.URL, static.URL+"/assets", def.URL), nil)
+
+	for _, tt := range []struct {
+		host, path      string
+		backend, gotten string
+	}{
+		{"api.localhost", "/users?id=1", "api", "/users"},
+		{"API.localhost:8080", "/users", "api", "/users"},
+		{"api.localhost", "/v2/users", "api-v2", "/v2/users"},
+		{"api.localhost", "/v2", "api-v2", "/v2"},
+		{"api.localhost", "/v2users", "api", "/v2users"},
+		{"example.com", "/v2/users", "default", "/v2/users"},
+		{"one.apps.localhost", "/", "apps", "/"},
+		{"a.b.apps.localhost", "/x", "apps", "/x"},
+		{"apps.localhost", "/x", "default", "/x"},
+		{"example.com", "/static/css/site.css", "static", "/assets/css/site.css"},
+		{"example.com", "/static", "static", "/assets/"},
+		{"example.com", "/staticfiles", "default", "/staticfiles"},
+		// a longer path wins over a host.
+		{"api.localhost", "/static/app.js", "static", "/assets/app.js"},
+		{"example.com", "/", "default", "/"},
+	} {
+		resp := get(t, proxy, tt.host, tt.path)
+		if e := decode(t, resp); e.Backend != tt.backend || e.Path != tt.gotten {
+			t.Errorf("%s%s went to %s %s, want %s %s", tt.host, tt.path, e.Backend, e.Path, tt.backend, tt.gotten)
+		}
+	}
+	if e := decode(t, get(t, proxy, "api.localhost", "/users?id=1&x=y")); e.Query != "id=1&x=y" {
+		t.Errorf("query %q, want it untouched", e.Query)
+	}
+
+	// without a default route, a request nothing matches is a 404.
+	proxy = front(t, fmt.Sprintf(`{"routes": [{"name": "api", "host": "api.localhost", "target": %q}]}`, api.URL), nil)
+	if resp := get(t, proxy, "example.com", "/"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("no route: %s, want 404", resp.Status)
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	api := backend(t, "api")
+	proxy := front(t, fmt.Sprintf(`{"routes": [{
+		"name": "api", "target": %q,
+		"request_headers": {"X-Gateway": "revproxy", "Cookie": ""},
+		"response_headers": {"Server": "", "X-Content-Type-Options": "nosniff"}
+	}, {"name": "plain", "path": "/plain", "target": %q}]}`, api.URL, api.URL), nil)
+
+	resp := get(t, proxy, "api.localhost", "/")
+	e := decode(t, resp)
+	for name, want := range map[string]string{
+		"X-Gateway":         "revproxy",
+		"Cookie":            "",
+		"X-Forwarded-Host":  "api.localhost",
+		"X-Forwarded-For":   "127.0.0.1", // not the spoofed one
+		"X-Forwarded-Proto": "http",
+	} {
+		if got := e.Header.Get(name); got != want {
+			t.Errorf("request header %s = %q, want %q", name, got, want)
+		}
+	}
+	if e.Host != strings.TrimPrefix(api.URL, "http://") {
+		t.Errorf("Host at the target = %q, want the target's", e.Host)
+	}
+	if got := resp.Header.Get("Server"); got != "" {
+		t.Errorf("response header Server = %q, want it removed", got)
+	}
+	if got := resp.Header.Get("X-Content-Type-Options"); got != "nosniff" {
+		t.Errorf("response header X-Content-Type-Options = %q", got)
+	}
+
+	// the rules of a route are its own.
+	resp = get(t, proxy, "api.localhost", "/plain")
+	if e := decode(t, resp); e.Header.Get("Cookie") == "" || e.Header.Get("X-Gateway") != "" || resp.Header.Get("Server") != "backend" {
+		t.Errorf("another route: request %v, response %v", e.Header, resp.Header)
+	}
+}
+
+// TestErrors checks the answers when the target fails: 504 once the route's
+// timeout runs out, 502 when it cannot be reached.
+func TestErrors(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	var logs bytes.Buffer
+	proxy := front(t, fmt.Sprintf(`{"timeout": "10s", "routes": [
+		{"name": "slow", "path": "/slow", "target": %q, "timeout": "50ms"},
+		{"name": "down", "path": "/down", "target": %q}
+	]}`, slow.URL, down.URL), &logs)
+
+	start := time.Now()
+	if resp := get(t, proxy, "example.com", "/slow"); resp.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("slow target: %s, want 504", resp.Status)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("the timeout answered after %v", d)
+	}
+	if resp := get(t, proxy, "example.com", "/down"); resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("target down: %s, want 502", resp.Status)
+	}
+	if !strings.Contains(logs.String(), "backend error") {
+		t.Errorf("no warning logged for the target down:\n%s", logs.String())
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	api := backend(t, "api")
+	var logs bytes.Buffer
+	proxy := front(t, fmt.Sprintf(`{"routes": [{"name": "api", "path": "/api", "target": %q}]}`, api.URL), &logs)
+	get(t, proxy, "example.com", "/api/users")
+	get(t, proxy, "example.com", "/missing")
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("%d lines logged, want 2:\n%s", len(lines), logs.String())
+	}
+	for i, want := range []string{
+		"method=GET host=example.com path=/api/users route=api status=200",
+		"path=/missing route=\"\" status=404",
+	} {
+		if !strings.Contains(lines[i], want) {
+			t.Errorf("line %d: %s\nwant %s", i, lines[i], want)
+		}
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	cfg, err := ParseConfig(strings.NewReader(`{"timeout": "1.5s", "routes": [{"name": "a", "target": "http://127.0.0.1:1"}]}`))
+	if err != nil || time.Duration(cfg.Timeout) != 1500*time.Millisecond {
+		t.Fatalf("ParseConfig = %+v, %v", cfg, err)
+	}
+	for _, tt := range []struct{ config, err string }{
+		{`{"routes": []}`, "no routes"},
+		{`{"routes": [{"name": "a", "target": "http://x", "tiemout": "1s"}]}`, "unknown field"},
+		{`{"routes": [{"name": "a", "target": "http://x", "timeout": 5}]}`, "must be a string"},
+		{`{"routes": [{"name": "a", "target": "http://x", "timeout": "soon"}]}`, "invalid duration"},
+		{`{"routes": [{"target": "http://x"}]}`, "no name"},
+		{`{"routes": [{"name": "a", "target": "http://x"}, {"name": "a", "target": "http://y"}]}`, "duplicate name"},
+		{`{"routes": [{"name": "a", "path": "api", "target": "http://x"}]}`, "does not start with /"},
+		{`{"routes": [{"name": "a", "target": "ftp://x"}]}`, "not an http(s) URL"},
+		{`{"routes": [{"name": "a", "target": "http://x", "timeout": "-1s"}]}`, "negative timeout"},
+	} {
+		if _, err := ParseConfig(strings.NewReader(tt.config)); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: %v, want %q", tt.config, err, tt.err)
+		}
+	}
+	// every mistake is reported, not only the first.
+	_, err = ParseConfig(strings.NewReader(`{"routes": [{"target": "x"}, {"name": "b", "path": "b", "target": "http://x"}]}`))
+	if err == nil || strings.Count(err.Error(), "\n") != 2 {
+		t.Errorf("three mistakes: %v", err)
+	}
+	if _, err := LoadConfig("example.json"); err != nil {
+		t.Errorf("example.json: %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/snake"
+)
+
+/*
+snake is the snake game in the terminal. Arrows, wasd or hjkl move, p
+pauses, q quits.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/snake/cmd/snake
+	go run ./golang_program_design_2024/09.projects/snake/cmd/snake -w 30 -h 15 -speed 120ms
+	go run ./golang_program_design_2024/09.projects/snake/cmd/snake -demo
+
+The terminal is put in raw mode with stty, so a key arrives without Enter;
+it needs a Unix terminal. -demo needs none: an autopilot goroutine plays a
+game through the same channels as the keyboard. The rules, the keyboard
+decoding and the loop are tests:
+
+	go test ./golang_program_design_2024/09.projects/snake/...
+*/
+
+func main() {
+	demo := flag.Bool("demo", false, "let an autopilot play a game")
+	w := flag.Int("w", 24, "board width")
+	h := flag.Int("h", 12, "board height")
+	speed := flag.Duration("speed", 150*time.Millisecond, "time between two steps at the start")
+	flag.Parse()
+	if *demo {
+		runDemo()
+		return
+	}
+
+	restore, err := rawMode()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snake needs a terminal:", err)
+		os.Exit(1)
+	}
+	fmt.Print("\x1b[?25l") // hide the cursor
+	defer func() {
+		fmt.Print("\x1b[?25h")
+		restore()
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	keys := make(chan snake.Key)
+	// the reader stays blocked in Read when the game ends first: it goes
+	// away with the process.
+	go snake.ReadKeys(ctx, os.Stdin, keys)
+
+	loop := &snake.Loop{
+		Game:        snake.New(*w, *h, time.Now().UnixNano()),
+		Interval:    *speed,
+		MinInterval: *speed / 3,
+		Out:         os.Stdout,
+	}
+	st := loop.Run(ctx, keys)
+	fmt.Printf("score %d\r\n", st.Score)
+}
+
+// rawMode switches the terminal of stdin to raw mode without echo and
+// returns the function putting it back as it was.
+func rawMode() (restore func(), err error) {
+	stty := func(args ...string) (string, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin
+		out, err := cmd.Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() { stty(saved) }, nil
+}
+
+// runDemo lets the autopilot play a game and prints the end of it.
+func runDemo() {
+	start := time.Now()
+	g := snake.New(16, 8, 42)
+	st := autoplay(g, 10*time.Second)
+	g.Draw(os.Stdout)
+	fmt.Printf("score %d in %v, %s\n", st.Score, time.Since(start).Round(time.Millisecond), g.Reason)
+}
+
+// autoplay runs a loop on g with the autopilot playing through the same
+// channels as a keyboard: it gets copies of the state and sends keys, never
+// touching the game. It returns the final state, at the latest after timeout.
+func autoplay(g *snake.Game, timeout time.Duration) snake.State {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	states := make(chan snake.State, 1)
+	keys := make(chan snake.Key)
+	loop := &snake.Loop{
+		Game:        g,
+		Interval:    2 * time.Millisecond,
+		MinInterval: 2 * time.Millisecond,
+		States:      states,
+	}
+	go autopilot(ctx, states, keys)
+	return loop.Run(ctx, keys)
+}
+
+// autopilot steers toward the food, avoiding walls and its own body one
+// step ahead. It is not clever, it only has to beat the test's bar.
+func autopilot(ctx context.Context, states <-chan snake.State, keys chan<- snake.Key) {
+	deltas := map[snake.Dir]snake.Point{snake.Up: {X: 0, Y: -1}, snake.Right: {X: 1, Y: 0}, snake.Down: {X: 0, Y: 1}, snake.Left: {X: -1, Y: 0}}
+	for {
+		var st snake.State
+		select {
+		case st = <-states:
+		case <-ctx.Done():
+			return
+		}
+		if st.Over {
+			return
+		}
+		head := st.Snake[0]
+		body := make(map[snake.Point]bool)
+		for _, p := range st.Snake[:len(st.Snake)-1] {
+			body[p] = true
+		}
+		best, bestDist := st.Dir, -1
+		for d := snake.Up; d <= snake.Left; d++ {
+			if d == (st.Dir+2)%4 {
+				continue
+			}
+			n := snake.Point{X: head.X + deltas[d].X, Y: head.Y + deltas[d].Y}
+			if n.X < 0 || n.Y < 0 || n.X >= st.Width || n.Y >= st.Height || body[n] {
+				continue
+			}
+			dist := 1000 - abs(n.X-st.Food.X) - abs(n.Y-st.Food.Y)
+			if dist > bestDist {
+				best, bestDist = d, dist
+			}
+		}
+		if best != st.Dir {
+			select {
+			case keys <- snake.Key(best):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
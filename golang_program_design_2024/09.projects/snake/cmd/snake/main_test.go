@@ -0,0 +1,16 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/snake"
+)
+
+func TestAutopilot(t *testing.T) {
+	g := snake.New(16, 8, 42)
+	st := autoplay(g, 10*time.Second)
+	if st.Score < 10 {
+		t.Errorf("the autopilot scored %d, %s", st.Score, g.Reason)
+	}
+}
@@ -0,0 +1,165 @@
+// Package snake is the snake game, played in a terminal.
+//
+// It is built as "share memory by communicating": one goroutine owns the
+// Game and is the only one ever touching it. The keyboard is read by another
+// goroutine that only sends Keys on a channel, the clock is a ticker, and
+// anyone wanting to watch the game (the demo's autopilot) receives copies of
+// the state on a channel. No mutex anywhere, and no way for a key press to
+// land in the middle of a move.
+package snake
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"strings"
+)
+
+// Point is a cell of the board, X to the right and Y down.
+type Point struct{ X, Y int }
+
+// Dir is a direction of movement.
+type Dir int
+
+const (
+	Up Dir = iota
+	Right
+	Down
+	Left
+)
+
+var deltas = [4]Point{Up: {0, -1}, Right: {1, 0}, Down: {0, 1}, Left: {-1, 0}}
+
+func (d Dir) opposite() Dir { return (d + 2) % 4 }
+
+func (d Dir) String() string { return [4]string{"up", "right", "down", "left"}[d] }
+
+// Game is the state of one game. It is not safe for concurrent use: in
+// Loop, a single goroutine owns it.
+type Game struct {
+	Width, Height int
+	Snake         []Point // head first
+	Dir           Dir
+	Food          Point
+	Score         int
+	Over          bool
+	Reason        string // why the game is over
+
+	rng *rand.Rand
+}
+
+// New starts a game on a board of w x h cells with a snake of 3 cells in the
+// middle, going right. The same seed places the food in the same places.
+func New(w, h int, seed int64) *Game {
+	g := &Game{Width: w, Height: h, Dir: Right, rng: rand.New(rand.NewSource(seed))}
+	mid := Point{w / 2, h / 2}
+	for i := 0; i < 3; i++ {
+		g.Snake = append(g.Snake, Point{mid.X - i, mid.Y})
+	}
+	g.placeFood()
+	return g
+}
+
+// Turn changes the direction for the next step. Turning back onto itself is
+// ignored: the snake would bite its own neck.
+func (g *Game) Turn(d Dir) bool {
+	if d == g.Dir.opposite() || d == g.Dir {
+		return false
+	}
+	g.Dir = d
+	return true
+}
+
+// Step moves the snake one cell. It reports whether the snake ate.
+func (g *Game) Step() bool {
+	if g.Over {
+		return false
+	}
+	head := g.Snake[0]
+	d := deltas[g.Dir]
+	next := Point{head.X + d.X, head.Y + d.Y}
+	if next.X < 0 || next.Y < 0 || next.X >= g.Width || next.Y >= g.Height {
+		g.Over, g.Reason = true, "hit the wall"
+		return false
+	}
+	ate := next == g.Food
+	// the tail moves away in the same step, the head may take its cell,
+	// unless the snake grows and the tail stays.
+	body := g.Snake
+	if !ate {
+		body = body[:len(body)-1]
+	}
+	for _, p := range body {
+		if p == next {
+			g.Over, g.Reason = true, "bit itself"
+			return false
+		}
+	}
+	g.Snake = append([]Point{next}, body...)
+	if ate {
+		g.Score++
+		g.placeFood()
+	}
+	return ate
+}
+
+// placeFood puts the food on a random free cell, and ends the game when
+// there is none: the snake fills the board.
+func (g *Game) placeFood() {
+	taken := make(map[Point]bool, len(g.Snake))
+	for _, p := range g.Snake {
+		taken[p] = true
+	}
+	var free []Point
+	for y := 0; y < g.Height; y++ {
+		for x := 0; x < g.Width; x++ {
+			if p := (Point{x, y}); !taken[p] {
+				free = append(free, p)
+			}
+		}
+	}
+	if len(free) == 0 {
+		g.Over, g.Reason = true, "filled the board"
+		return
+	}
+	g.Food = free[g.rng.Intn(len(free))]
+}
+
+// State is a copy of the game, safe to hand to another goroutine.
+type State struct {
+	Width, Height int
+	Snake         []Point
+	Dir           Dir
+	Food          Point
+	Score         int
+	Over          bool
+}
+
+func (g *Game) State() State {
+	return State{g.Width, g.Height, append([]Point(nil), g.Snake...), g.Dir, g.Food, g.Score, g.Over}
+}
+
+// Draw writes the board with a border, the score under it.
+func (g *Game) Draw(w io.Writer) {
+	grid := make([][]byte, g.Height)
+	for y := range grid {
+		grid[y] = []byte(strings.Repeat(" ", g.Width))
+	}
+	grid[g.Food.Y][g.Food.X] = '*'
+	for i, p := range g.Snake {
+		c := byte('o')
+		if i == 0 {
+			c = '@'
+		}
+		grid[p.Y][p.X] = c
+	}
+	var b strings.Builder
+	border := "+" + strings.Repeat("-", g.Width) + "+\r\n"
+	b.WriteString(border)
+	for _, row := range grid {
+		b.WriteString("|" + string(row) + "|\r\n")
+	}
+	b.WriteString(border)
+	fmt.Fprintf(&b, "score %d  length %d\r\n", g.Score, len(g.Snake))
+	io.WriteString(w, b.String())
+}
@@ -0,0 +1,104 @@
+package snake
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// points makes a snake of x, y pairs, head first.
+func points(xy ...int) []Point {
+	var ps []Point
+	for i := 0; i < len(xy); i += 2 {
+		ps = append(ps, Point{xy[i], xy[i+1]})
+	}
+	return ps
+}
+
+func TestStep(t *testing.T) {
+	g := New(10, 5, 1)
+	if !reflect.DeepEqual(g.Snake, points(5, 2, 4, 2, 3, 2)) || g.Dir != Right {
+		t.Fatalf("start: %v going %v", g.Snake, g.Dir)
+	}
+	if g.Turn(Left) || g.Turn(Right) || g.Dir != Right {
+		t.Errorf("turning back or the same way changed the direction to %v", g.Dir)
+	}
+	g.Food = Point{9, 0}
+	if g.Step() || !reflect.DeepEqual(g.Snake, points(6, 2, 5, 2, 4, 2)) {
+		t.Errorf("a step: %v", g.Snake)
+	}
+
+	g.Food = Point{7, 2}
+	if !g.Step() || len(g.Snake) != 4 || g.Score != 1 || g.Snake[0] != (Point{7, 2}) {
+		t.Errorf("eating: length %d, score %d", len(g.Snake), g.Score)
+	}
+	for _, p := range g.Snake {
+		if p == g.Food {
+			t.Errorf("new food %v on the snake", g.Food)
+		}
+	}
+}
+
+func TestGameOver(t *testing.T) {
+	g := New(10, 5, 1)
+	g.Food = Point{0, 0}
+	for i := 0; i < 10 && !g.Over; i++ {
+		g.Step()
+	}
+	if !g.Over || g.Reason != "hit the wall" || g.Snake[0] != (Point{9, 2}) {
+		t.Fatalf("at the wall: over %v, %q, head %v", g.Over, g.Reason, g.Snake[0])
+	}
+	before := append([]Point(nil), g.Snake...)
+	if g.Step() || !reflect.DeepEqual(g.Snake, before) {
+		t.Error("the snake moved after the end")
+	}
+
+	// a snake of 5 turning down, left, up bites its own body.
+	g = New(10, 5, 1)
+	g.Snake = points(5, 2, 4, 2, 3, 2, 2, 2, 1, 2)
+	g.Food = Point{9, 0}
+	for _, d := range []Dir{Down, Left, Up} {
+		g.Turn(d)
+		g.Step()
+	}
+	if !g.Over || g.Reason != "bit itself" {
+		t.Errorf("biting itself: over %v, %q", g.Over, g.Reason)
+	}
+}
+
+// TestChaseTail runs in a square: the head enters the cell the tail leaves
+// in the same step.
+func TestChaseTail(t *testing.T) {
+	g := New(10, 5, 1)
+	g.Snake = points(5, 2, 5, 3, 4, 3, 4, 2)
+	g.Dir = Up
+	g.Food = Point{9, 0}
+	g.Turn(Left)
+	g.Step()
+	if g.Over || g.Snake[0] != (Point{4, 2}) {
+		t.Errorf("into the tail's cell: over %v, %q, %v", g.Over, g.Reason, g.Snake)
+	}
+}
+
+func TestFood(t *testing.T) {
+	// the only free cell of 4 x 1 has the food: eating it fills the board.
+	g := New(4, 1, 1)
+	if g.Food != (Point{3, 0}) || !g.Step() || !g.Over || g.Reason != "filled the board" {
+		t.Errorf("a snake of 4 on 4 cells: over %v, %q", g.Over, g.Reason)
+	}
+	a, b := New(20, 10, 7), New(20, 10, 7)
+	if a.Food != b.Food {
+		t.Errorf("one seed, food at %v and %v", a.Food, b.Food)
+	}
+}
+
+func TestDraw(t *testing.T) {
+	g := New(5, 2, 1)
+	g.Food = Point{4, 0}
+	var b strings.Builder
+	g.Draw(&b)
+	want := "+-----+\r\n|    *|\r\n|oo@  |\r\n+-----+\r\nscore 0  length 3\r\n"
+	if b.String() != want {
+		t.Errorf("Draw:\n%q\nwant\n%q", b.String(), want)
+	}
+}
@@ -0,0 +1,78 @@
+package snake
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// Key is a key press the game understands. The four first are the
+// directions, in the order of Dir.
+type Key int
+
+const (
+	KeyUp Key = iota
+	KeyRight
+	KeyDown
+	KeyLeft
+	KeyPause
+	KeyQuit
+)
+
+// ReadKeys reads the keyboard from r, in raw mode, and sends the keys on
+// keys until r ends, ctx is done or q is pressed. Arrows, wasd and hjkl
+// move, p or space pauses, q, Esc or Ctrl-C quit.
+//
+// It is the only goroutine reading r and it knows nothing of the game: it
+// turns bytes into Keys, the loop decides what they mean.
+func ReadKeys(ctx context.Context, r io.Reader, keys chan<- Key) error {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadByte()
+		if err != nil {
+			return err
+		}
+		k, ok := Key(0), true
+		switch b {
+		case 'w', 'k':
+			k = KeyUp
+		case 'd', 'l':
+			k = KeyRight
+		case 's', 'j':
+			k = KeyDown
+		case 'a', 'h':
+			k = KeyLeft
+		case 'p', ' ':
+			k = KeyPause
+		case 'q', 3: // 3 is Ctrl-C, not a signal in raw mode
+			k = KeyQuit
+		case 0x1b:
+			// an arrow is ESC [ A..D, all in one read; Esc alone has
+			// nothing after it.
+			if br.Buffered() < 2 {
+				k = KeyQuit
+				break
+			}
+			seq, _ := br.Peek(2)
+			arrow := map[byte]Key{'A': KeyUp, 'B': KeyDown, 'C': KeyRight, 'D': KeyLeft}
+			if k, ok = arrow[seq[1]]; seq[0] != '[' || !ok {
+				ok = false
+				break
+			}
+			br.Discard(2)
+		default:
+			ok = false
+		}
+		if !ok {
+			continue
+		}
+		select {
+		case keys <- k:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if k == KeyQuit {
+			return nil
+		}
+	}
+}
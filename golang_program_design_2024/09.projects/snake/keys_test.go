@@ -0,0 +1,53 @@
+package snake
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadKeys(t *testing.T) {
+	for _, tt := range []struct {
+		input string
+		want  []Key
+		err   error
+	}{
+		{"w\x1b[Bxd\x1b[Dkp q", []Key{KeyUp, KeyDown, KeyRight, KeyLeft, KeyUp, KeyPause, KeyPause, KeyQuit}, nil},
+		{"hjkl asdw", []Key{KeyLeft, KeyDown, KeyUp, KeyRight, KeyPause, KeyLeft, KeyDown, KeyRight, KeyUp}, io.EOF},
+		{"\x1b[A\x1b[C", []Key{KeyUp, KeyRight}, io.EOF},
+		{"\x1b[Zw", []Key{KeyUp}, io.EOF}, // an unknown sequence is skipped
+		{"\x1b", []Key{KeyQuit}, nil},     // Esc alone
+		{"\x03w", []Key{KeyQuit}, nil},    // Ctrl-C, the rest is not read
+	} {
+		keys := make(chan Key, 16)
+		err := ReadKeys(context.Background(), strings.NewReader(tt.input), keys)
+		close(keys)
+		var got []Key
+		for k := range keys {
+			got = append(got, k)
+		}
+		if !reflect.DeepEqual(got, tt.want) || !errors.Is(err, tt.err) {
+			t.Errorf("%q: %v, %v; want %v, %v", tt.input, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// TestReadKeysCancel stops a reader blocked on a send nobody receives.
+func TestReadKeysCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- ReadKeys(ctx, strings.NewReader("w"), make(chan Key)) }()
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ReadKeys = %v, want context.Canceled", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ReadKeys did not return after the cancel")
+	}
+}
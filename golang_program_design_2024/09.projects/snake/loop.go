@@ -0,0 +1,93 @@
+package snake
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Loop runs a game: it is the goroutine owning the Game.
+type Loop struct {
+	Game *Game
+	// Interval is the time between two steps at the start. It gets shorter
+	// as the score grows, down to MinInterval.
+	Interval, MinInterval time.Duration
+	// Out receives a frame after each step, nil draws nothing.
+	Out io.Writer
+	// States, if set, receives a copy of the game after each step. A slow
+	// receiver misses states, the game does not wait for it.
+	States chan<- State
+}
+
+// clear moves the cursor home and clears the screen.
+const clear = "\x1b[H\x1b[2J"
+
+// Run plays until the game is over, q is pressed, keys is closed or ctx is
+// done, and returns the final state.
+//
+// Keys pressed between two steps are queued and applied one per step: a
+// quick up-left turns up on this step and left on the next, instead of the
+// left overwriting the up and the snake reversing into itself.
+func (l *Loop) Run(ctx context.Context, keys <-chan Key) State {
+	g := l.Game
+	interval := l.Interval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var queue []Dir
+	paused := false
+	l.frame()
+	for !g.Over {
+		select {
+		case <-ctx.Done():
+			return g.State()
+		case k, ok := <-keys:
+			if !ok || k == KeyQuit {
+				return g.State()
+			}
+			if k == KeyPause {
+				paused = !paused
+				continue
+			}
+			if len(queue) < 3 {
+				queue = append(queue, Dir(k))
+			}
+		case <-ticker.C:
+			if paused {
+				continue
+			}
+			// turns that do nothing, like a second up, are dropped
+			// without costing a step.
+			for len(queue) > 0 {
+				d := queue[0]
+				queue = queue[1:]
+				if g.Turn(d) {
+					break
+				}
+			}
+			if g.Step() {
+				if next := l.Interval * 10 / time.Duration(10+g.Score); next >= l.MinInterval && next != interval {
+					interval = next
+					ticker.Reset(interval)
+				}
+			}
+			l.frame()
+		}
+	}
+	return g.State()
+}
+
+func (l *Loop) frame() {
+	if l.Out != nil {
+		io.WriteString(l.Out, clear)
+		l.Game.Draw(l.Out)
+		if l.Game.Over {
+			io.WriteString(l.Out, "game over: "+l.Game.Reason+"\r\n")
+		}
+	}
+	if l.States != nil {
+		select {
+		case l.States <- l.Game.State():
+		default:
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package snake
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+// start runs a loop on g stepping every interval, and returns the states it
+// sends, the keys it reads and its final state.
+func start(t *testing.T, g *Game, interval time.Duration) (<-chan State, chan<- Key, <-chan State) {
+	t.Helper()
+	states := make(chan State, 16)
+	keys := make(chan Key)
+	done := make(chan State, 1)
+	l := &Loop{Game: g, Interval: interval, MinInterval: interval, States: states}
+	go func() { done <- l.Run(context.Background(), keys) }()
+	testutil.RequireRecv(t, states, time.Second) // the first frame
+	return states, keys, done
+}
+
+// TestQueuedKeys sends two keys between two steps: both are applied, one
+// per step, instead of the second overwriting the first.
+func TestQueuedKeys(t *testing.T) {
+	g := New(10, 10, 1)
+	g.Food = Point{0, 0}
+	states, keys, done := start(t, g, 20*time.Millisecond)
+	keys <- KeyUp
+	keys <- KeyLeft
+	s1 := testutil.RequireRecv(t, states, time.Second)
+	s2 := testutil.RequireRecv(t, states, time.Second)
+	if s1.Dir != Up || s2.Dir != Left {
+		t.Errorf("directions %v then %v, want up then left", s1.Dir, s2.Dir)
+	}
+	keys <- KeyQuit
+	testutil.RequireRecv(t, done, time.Second)
+}
+
+func TestPause(t *testing.T) {
+	g := New(10, 10, 1)
+	g.Food = Point{0, 0}
+	states, keys, done := start(t, g, 5*time.Millisecond)
+	keys <- KeyPause
+	// drain a state sent before the pause.
+	for len(states) > 0 {
+		<-states
+	}
+	testutil.RequireNoRecv(t, states, 50*time.Millisecond)
+	keys <- KeyPause
+	testutil.RequireRecv(t, states, time.Second)
+	close(keys)
+	testutil.RequireRecv(t, done, time.Second)
+}
+
+// TestRunEnds checks the ways Run returns: the game over, ctx done.
+func TestRunEnds(t *testing.T) {
+	g := New(5, 3, 1)
+	g.Food = Point{0, 0}
+	_, _, done := start(t, g, time.Millisecond)
+	if st := testutil.RequireRecv(t, done, time.Second); !st.Over {
+		t.Errorf("Run returned before the wall: %+v", st)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l := &Loop{Game: New(10, 10, 1), Interval: time.Hour, MinInterval: time.Hour}
+	ended := make(chan State, 1)
+	go func() { ended <- l.Run(ctx, make(chan Key)) }()
+	cancel()
+	testutil.RequireRecv(t, ended, time.Second)
+}
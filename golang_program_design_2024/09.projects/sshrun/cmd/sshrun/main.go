@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/sshrun"
+)
+
+/*
+sshrun runs a command on several hosts over SSH and prints a summary of the
+exit statuses. The exit status is 0 when the command succeeded everywhere.
+
+This directory is its own module because of golang.org/x/crypto; run it from
+there.
+
+Usage:
+
+	cd golang_program_design_2024/09.projects/sshrun
+	go run ./cmd/sshrun -hosts web1,web2,deploy@db1:2222 'uptime'
+	go run ./cmd/sshrun -j 20 -timeout 30s -hosts "$(cat hosts.txt | paste -sd,)" 'systemctl is-active nginx'
+
+-local runs the command with sh on this machine for every host name, with
+$HOST set, which shows the pool and the output without any SSH server:
+
+	go run ./cmd/sshrun -local -j 2 -hosts a,b,c,d 'echo start; sleep 1; echo done; test $HOST != c'
+*/
+
+func main() {
+	defaultKey, defaultKnownHosts := sshrun.DefaultKeyFiles()
+	hosts := flag.String("hosts", "", "comma-separated hosts, [user@]host[:port]")
+	user := flag.String("user", os.Getenv("USER"), "default remote user")
+	keyFile := flag.String("i", defaultKey, "private key file")
+	knownHosts := flag.String("known-hosts", defaultKnownHosts, "known_hosts file checked for the host keys")
+	workers := flag.Int("j", 10, "hosts handled at the same time")
+	timeout := flag.Duration("timeout", time.Minute, "timeout per host")
+	local := flag.Bool("local", false, "run locally instead of over SSH")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: sshrun -hosts h1,h2 [flags] command")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *hosts == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var exe sshrun.Executor = sshrun.LocalExecutor{}
+	if !*local {
+		e, err := sshrun.NewSSHExecutor(*user, *keyFile, *knownHosts)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "sshrun:", err)
+			os.Exit(2)
+		}
+		exe = e
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results := sshrun.Run(ctx, exe, strings.Split(*hosts, ","), strings.Join(flag.Args(), " "), sshrun.Options{
+		Workers: *workers,
+		Timeout: *timeout,
+		Output:  os.Stdout,
+	})
+
+	fmt.Println()
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			fmt.Printf("%-20s error   %v\n", r.Host, r.Err)
+		default:
+			fmt.Printf("%-20s exit %-3d %v\n", r.Host, r.Status, r.Duration.Round(time.Millisecond))
+		}
+	}
+	s := sshrun.Summarize(results)
+	fmt.Printf("\n%d ok, %d failed, %d errors\n", s.OK, s.Failed, s.Errors)
+	statuses := make([]int, 0, len(s.ByStatus))
+	for status := range s.ByStatus {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		if status != 0 {
+			fmt.Printf("  status %d: %s\n", status, strings.Join(s.ByStatus[status], ", "))
+		}
+	}
+	if s.OK != len(results) {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,7 @@
+module github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/sshrun
+
+go 1.22
+
+require golang.org/x/crypto v0.22.0
+
+require golang.org/x/sys v0.19.0 // indirect
@@ -0,0 +1,104 @@
+package sshrun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SSHExecutor runs the commands over SSH. A host is "host", "host:port" or
+// "user@host:port"; the port defaults to 22 and the user to Config.User.
+type SSHExecutor struct {
+	Config *ssh.ClientConfig
+}
+
+// NewSSHExecutor authenticates as user with the private key in keyFile and
+// checks the host keys against knownHostsFile, as ssh does. An unknown or
+// changed host key is an error: accepting any key would let anyone on the
+// path pretend to be the host and collect the commands.
+func NewSSHExecutor(user, keyFile, knownHostsFile string) (*SSHExecutor, error) {
+	key, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", keyFile, err)
+	}
+	hostKeys, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, err
+	}
+	return &SSHExecutor{Config: &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeys,
+	}}, nil
+}
+
+// DefaultKeyFiles returns ~/.ssh/id_ed25519 and ~/.ssh/known_hosts.
+func DefaultKeyFiles() (key, knownHosts string) {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ssh", "id_ed25519"), filepath.Join(home, ".ssh", "known_hosts")
+}
+
+func (e *SSHExecutor) Exec(ctx context.Context, host, cmd string, stdout, stderr io.Writer) (int, error) {
+	cfg := *e.Config
+	if i := strings.LastIndex(host, "@"); i >= 0 {
+		cfg.User, host = host[:i], host[i+1:]
+	}
+	addr := host
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		addr = net.JoinHostPort(host, "22")
+	}
+
+	// dial with the context, then run the SSH handshake on that connection:
+	// ssh.Dial alone could not be cancelled.
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return -1, err
+	}
+	// closing the connection is the only way to interrupt the handshake or a
+	// running command, whatever stage it is at.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, &cfg)
+	if err != nil {
+		conn.Close()
+		return -1, err
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return -1, err
+	}
+	defer session.Close()
+	session.Stdout, session.Stderr = stdout, stderr
+
+	err = session.Run(cmd)
+	var exitErr *ssh.ExitError
+	switch {
+	case err == nil:
+		return 0, nil
+	case errors.As(err, &exitErr):
+		return exitErr.ExitStatus(), nil
+	case ctx.Err() != nil:
+		return -1, ctx.Err()
+	default:
+		// ssh.ExitMissingError among others: the command ended without
+		// telling how, the connection was probably lost.
+		return -1, err
+	}
+}
@@ -0,0 +1,190 @@
+// Package sshrun runs one command on many hosts at once and collects how it
+// went on each.
+//
+// A bounded pool of workers takes the hosts in turn, so a thousand hosts do
+// not mean a thousand SSH connections at the same time. The output of every
+// host is streamed as it comes, line by line with the host name in front,
+// and the lines of different hosts never mix within a line. Run returns one
+// Result per host, in the order of the hosts, with the exit status of the
+// command or the reason it could not run.
+//
+// The hosts are reached through an Executor: SSHExecutor for real machines,
+// LocalExecutor to try the pool and the output handling on this machine.
+package sshrun
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Executor runs cmd on host, writing its output to stdout and stderr. It
+// returns the exit status of the command; err is for a command that could
+// not be started or did not finish (unreachable host, failed login, timeout).
+type Executor interface {
+	Exec(ctx context.Context, host, cmd string, stdout, stderr io.Writer) (status int, err error)
+}
+
+type Options struct {
+	// Workers is the number of hosts handled at the same time.
+	Workers int
+	// Timeout bounds the whole run on one host, connection included.
+	Timeout time.Duration
+	// Output receives the prefixed output lines; nil discards them.
+	Output io.Writer
+}
+
+type Result struct {
+	Host     string
+	Status   int
+	Err      error
+	Duration time.Duration
+}
+
+// OK reports whether the command ran and exited with 0.
+func (r Result) OK() bool { return r.Err == nil && r.Status == 0 }
+
+// Run executes cmd on every host.
+func Run(ctx context.Context, exe Executor, hosts []string, cmd string, opts Options) []Result {
+	if opts.Workers <= 0 {
+		opts.Workers = runtime.NumCPU()
+	}
+	if opts.Output == nil {
+		opts.Output = io.Discard
+	}
+	out := &syncWriter{w: opts.Output}
+	results := make([]Result, len(hosts))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(opts.Workers, len(hosts)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = runOne(ctx, exe, hosts[i], cmd, opts.Timeout, out)
+			}
+		}()
+	}
+	for i := range hosts {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func runOne(ctx context.Context, exe Executor, host, cmd string, timeout time.Duration, out *syncWriter) Result {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	stdout := &prefixWriter{out: out, prefix: host + " | "}
+	stderr := &prefixWriter{out: out, prefix: host + " ! "}
+	start := time.Now()
+	status, err := exe.Exec(ctx, host, cmd, stdout, stderr)
+	// a last line without a newline.
+	stdout.flush()
+	stderr.flush()
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return Result{Host: host, Status: status, Err: err, Duration: time.Since(start)}
+}
+
+// syncWriter serializes the writes of all the hosts.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (s *syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+// prefixWriter cuts the output of one host into lines and writes each whole
+// line, prefixed, in a single Write: lines of two hosts cannot interleave.
+type prefixWriter struct {
+	out    io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			return len(b), nil
+		}
+		line := append([]byte(p.prefix), p.buf[:i+1]...)
+		p.buf = p.buf[i+1:]
+		if _, err := p.out.Write(line); err != nil {
+			return len(b), err
+		}
+	}
+}
+
+func (p *prefixWriter) flush() {
+	if len(p.buf) > 0 {
+		p.Write([]byte("\n"))
+	}
+}
+
+// Summary counts the results.
+type Summary struct {
+	OK, Failed, Errors int
+	// ByStatus groups the hosts by exit status, failed connections under -1.
+	ByStatus map[int][]string
+}
+
+func Summarize(results []Result) Summary {
+	s := Summary{ByStatus: make(map[int][]string)}
+	for _, r := range results {
+		status := r.Status
+		switch {
+		case r.Err != nil:
+			s.Errors++
+			status = -1
+		case r.Status == 0:
+			s.OK++
+		default:
+			s.Failed++
+		}
+		s.ByStatus[status] = append(s.ByStatus[status], r.Host)
+	}
+	return s
+}
+
+// LocalExecutor runs the command with sh on this machine, whatever the host:
+// a stand-in for SSH to try the rest. HOST is set to the host name in the
+// environment of the command.
+type LocalExecutor struct{}
+
+func (LocalExecutor) Exec(ctx context.Context, host, cmd string, stdout, stderr io.Writer) (int, error) {
+	c := exec.CommandContext(ctx, "sh", "-c", cmd)
+	c.Env = append(c.Environ(), "HOST="+host)
+	c.Stdout, c.Stderr = stdout, stderr
+	// a background child of sh may keep the output open after sh is killed.
+	c.WaitDelay = time.Second
+	err := c.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() != nil:
+		return -1, ctx.Err()
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode(), nil
+	case err != nil:
+		return -1, fmt.Errorf("local: %w", err)
+	}
+	return 0, nil
+}
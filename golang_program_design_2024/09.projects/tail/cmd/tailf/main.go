@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/tail"
+)
+
+/*
+tailf prints the lines appended to a file, surviving truncation and rotation.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/tail/cmd/tailf /var/log/syslog
+	go run ./golang_program_design_2024/09.projects/tail/cmd/tailf -from-start app.log
+	go run ./golang_program_design_2024/09.projects/tail/cmd/tailf -demo
+
+-demo writes, truncates and rotates a temp file while following it,
+printing the lines as they arrive. Its cases are tests: go test
+./golang_program_design_2024/09.projects/tail
+*/
+
+func main() {
+	fromStart := flag.Bool("from-start", false, "print the existing content first")
+	poll := flag.Duration("poll", 250*time.Millisecond, "poll interval at the end of the file")
+	demo := flag.Bool("demo", false, "follow a temp file written by the program itself")
+	flag.Parse()
+
+	if *demo {
+		if err := runDemo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: tailf [flags] file")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	f := tail.New(flag.Arg(0), tail.Options{Poll: *poll, FromStart: *fromStart})
+	errc := make(chan error, 1)
+	go func() { errc <- f.Run(ctx) }()
+	for line := range f.Lines() {
+		fmt.Println(line)
+	}
+	if err := <-errc; err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runDemo() error {
+	dir, err := os.MkdirTemp("", "tailf")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	const poll = 20 * time.Millisecond
+
+	// the log already has content: it is skipped, following starts at the end.
+	if err := os.WriteFile(path, []byte("old 1\nold 2\n"), 0o644); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := tail.New(path, tail.Options{Poll: poll})
+	errc := make(chan error, 1)
+	go func() { errc <- f.Run(ctx) }()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for line := range f.Lines() {
+			fmt.Printf("  %q\n", line)
+		}
+	}()
+
+	settle := func() { time.Sleep(5 * poll) }
+	appendTo := func(name, s string) {
+		file, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+		if err != nil {
+			panic(err)
+		}
+		file.WriteString(s)
+		file.Close()
+	}
+	settle()
+
+	fmt.Println("append")
+	appendTo(path, "line 1\nline 2\n")
+	settle()
+
+	fmt.Println("a line written in two steps")
+	appendTo(path, "line ")
+	settle()
+	appendTo(path, "3\n")
+	settle()
+
+	fmt.Println("truncate")
+	os.Truncate(path, 0)
+	settle()
+	appendTo(path, "after truncate\n")
+	settle()
+
+	fmt.Println("rotate: rename, a late write to the old file, new file")
+	os.Rename(path, path+".1")
+	appendTo(path+".1", "late line in old file\n")
+	settle()
+	appendTo(path, "new file 1\nnew file 2\n")
+	settle()
+
+	fmt.Println("rotate: the new file appears a while later")
+	os.Rename(path, path+".2")
+	settle()
+	appendTo(path, "after gap\n")
+	settle()
+
+	cancel()
+	<-done
+	return <-errc
+}
@@ -0,0 +1,197 @@
+// Package tail follows a growing file like tail -F: it streams every line
+// appended to it, and keeps going when the file is truncated or rotated.
+//
+// Log rotation usually renames app.log to app.log.1 and creates a fresh
+// app.log. An open file descriptor keeps pointing at the renamed file, so
+// the follower regularly stats the path and compares it with the file it has
+// open (os.SameFile). When they differ, it reads what is left of the old
+// file and reopens the path from the start. A file that got smaller than the
+// current offset was truncated in place, reading restarts at 0.
+package tail
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// Options tune a Follower.
+type Options struct {
+	// Poll is how long to wait at the end of the file before checking again.
+	Poll time.Duration
+	// FromStart streams the lines already in the file, otherwise following
+	// starts at its current end.
+	FromStart bool
+}
+
+// Follower streams the lines of one file.
+type Follower struct {
+	path  string
+	opts  Options
+	lines chan string
+
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64
+	partial strings.Builder // a line without its newline yet
+}
+
+func New(path string, opts Options) *Follower {
+	if opts.Poll <= 0 {
+		opts.Poll = 250 * time.Millisecond
+	}
+	return &Follower{path: path, opts: opts, lines: make(chan string)}
+}
+
+// Lines delivers the lines without their trailing newline. It is closed when
+// Run returns.
+func (f *Follower) Lines() <-chan string { return f.lines }
+
+// Run follows the file until ctx is done. A missing file is waited for; any
+// other error stops the follower and is returned.
+func (f *Follower) Run(ctx context.Context) error {
+	defer close(f.lines)
+	defer func() {
+		if f.file != nil {
+			f.file.Close()
+		}
+	}()
+
+	if err := f.open(ctx, !f.opts.FromStart); err != nil {
+		return ignoreCanceled(err)
+	}
+	for {
+		line, err := f.reader.ReadString('\n')
+		f.offset += int64(len(line))
+		if err == nil {
+			f.partial.WriteString(line[:len(line)-1])
+			if !f.emit(ctx, strings.TrimSuffix(f.partial.String(), "\r")) {
+				return nil
+			}
+			f.partial.Reset()
+			continue
+		}
+		if err != io.EOF {
+			return err
+		}
+		// the writer may be in the middle of a line: keep what we have and
+		// wait for the rest.
+		f.partial.WriteString(line)
+
+		if err := f.checkFile(ctx); err != nil {
+			return ignoreCanceled(err)
+		}
+	}
+}
+
+func (f *Follower) emit(ctx context.Context, line string) bool {
+	select {
+	case f.lines <- line:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// checkFile runs at the end of the file: it waits for more data, and reopens
+// or rewinds the file when it was rotated or truncated.
+func (f *Follower) checkFile(ctx context.Context) error {
+	if err := sleep(ctx, f.opts.Poll); err != nil {
+		return err
+	}
+
+	byName, err := os.Stat(f.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // rotated and not recreated yet, keep reading the old file meanwhile
+	}
+	if err != nil {
+		return err
+	}
+	current, err := f.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case !os.SameFile(byName, current):
+		// rotated. Lines may have been appended to the old file between our
+		// last read and the rename, so drain it first.
+		if current.Size() > f.offset {
+			return nil
+		}
+		f.flushPartial(ctx)
+		f.file.Close()
+		return f.open(ctx, false)
+	case current.Size() < f.offset:
+		// truncated in place: whatever was half read is gone.
+		f.partial.Reset()
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		f.offset = 0
+		f.reader.Reset(f.file)
+	}
+	return nil
+}
+
+// flushPartial emits the unterminated last line of a file being left behind.
+func (f *Follower) flushPartial(ctx context.Context) {
+	if f.partial.Len() > 0 {
+		f.emit(ctx, f.partial.String())
+		f.partial.Reset()
+	}
+}
+
+// open opens the path, waiting for it to appear, at its start or at its end.
+// A file that was not there yet is read from its start: every line of it is
+// new.
+func (f *Follower) open(ctx context.Context, atEnd bool) error {
+	for {
+		file, err := os.Open(f.path)
+		if err == nil {
+			f.file = file
+			f.offset = 0
+			if atEnd {
+				if f.offset, err = file.Seek(0, io.SeekEnd); err != nil {
+					return err
+				}
+			}
+			if f.reader == nil {
+				f.reader = bufio.NewReader(file)
+			} else {
+				f.reader.Reset(file)
+			}
+			return nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if err := sleep(ctx, f.opts.Poll); err != nil {
+			return err
+		}
+		atEnd = false
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func ignoreCanceled(err error) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,205 @@
+package tail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+const poll = 5 * time.Millisecond
+
+// settle waits long enough for the follower to poll the file a few times.
+func settle() { time.Sleep(10 * poll) }
+
+func appendTo(t *testing.T, path, s string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(s); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// follow runs a Follower on path until the test ends, and checks that Run
+// then returns nil and closes Lines.
+func follow(t *testing.T, path string, opts Options) *Follower {
+	t.Helper()
+	opts.Poll = poll
+	f := New(path, opts)
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- f.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := testutil.RequireRecv(t, errc, time.Second); err != nil {
+			t.Errorf("Run = %v after cancel, want nil", err)
+		}
+		testutil.RequireClosedWithin(t, f.Lines(), time.Second)
+	})
+	return f
+}
+
+// expect receives the want lines, in order, and then nothing more.
+func expect(t *testing.T, f *Follower, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if got := testutil.RequireRecv(t, f.Lines(), time.Second); got != w {
+			t.Fatalf("line %q, want %q", got, w)
+		}
+	}
+	testutil.RequireNoRecv(t, f.Lines(), 10*poll)
+}
+
+func TestFromEnd(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendTo(t, path, "old 1\nold 2\n")
+	f := follow(t, path, Options{})
+	settle()
+	appendTo(t, path, "new 1\nnew 2\n")
+	expect(t, f, "new 1", "new 2")
+}
+
+func TestFromStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendTo(t, path, "old 1\nold 2\n")
+	f := follow(t, path, Options{FromStart: true})
+	expect(t, f, "old 1", "old 2")
+	appendTo(t, path, "new\n")
+	expect(t, f, "new")
+}
+
+// TestPartialLine writes a line in several steps: it is delivered once, whole,
+// when its newline arrives.
+func TestPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f := follow(t, path, Options{FromStart: true})
+	appendTo(t, path, "hel")
+	expect(t, f)
+	appendTo(t, path, "lo, ")
+	expect(t, f)
+	appendTo(t, path, "world\r\nnext\n")
+	expect(t, f, "hello, world", "next")
+	appendTo(t, path, "\n\n")
+	expect(t, f, "", "")
+}
+
+func TestWaitForFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f := follow(t, path, Options{})
+	settle()
+	// created after Run started: every line of it is new.
+	appendTo(t, path, "first\n")
+	expect(t, f, "first")
+}
+
+func TestTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f := follow(t, path, Options{FromStart: true})
+	appendTo(t, path, "a fairly long line before the truncation\n")
+	expect(t, f, "a fairly long line before the truncation")
+
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	settle()
+	appendTo(t, path, "short\n")
+	expect(t, f, "short")
+
+	// a half written line lost to the truncation is dropped.
+	appendTo(t, path, "half a li")
+	settle()
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	settle()
+	appendTo(t, path, "whole\n")
+	expect(t, f, "whole")
+}
+
+// TestRotate renames the file, writes to the old one once more, as a writer
+// still holding it would, then creates the new one: nothing is lost.
+func TestRotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f := follow(t, path, Options{FromStart: true})
+	appendTo(t, path, "before\n")
+	expect(t, f, "before")
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendTo(t, path+".1", "late line in the old file\n")
+	settle()
+	appendTo(t, path, "new 1\nnew 2\n")
+	expect(t, f, "late line in the old file", "new 1", "new 2")
+
+	// the old file is not followed any more.
+	appendTo(t, path+".1", "too late\n")
+	expect(t, f)
+}
+
+func TestRotateSameTick(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f := follow(t, path, Options{FromStart: true})
+	appendTo(t, path, "before\n")
+	expect(t, f, "before")
+
+	// rename, write both files and end the old one with a half line, all
+	// between two polls.
+	os.Rename(path, path+".1")
+	appendTo(t, path+".1", "late\nno newline")
+	appendTo(t, path, "new\n")
+	expect(t, f, "late", "no newline", "new")
+}
+
+func TestRotateDelayed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f := follow(t, path, Options{FromStart: true})
+	appendTo(t, path, "before\n")
+	expect(t, f, "before")
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	// a while with no file at the path: the follower keeps the old one.
+	settle()
+	appendTo(t, path+".1", "still the old file\n")
+	expect(t, f, "still the old file")
+	appendTo(t, path, "after the gap\n")
+	expect(t, f, "after the gap")
+}
+
+func TestCancel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	appendTo(t, path, "1\n2\n3\n")
+	f := New(path, Options{Poll: poll, FromStart: true})
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- f.Run(ctx) }()
+	testutil.RequireRecv(t, f.Lines(), time.Second)
+
+	// nobody reads the other lines: Run is blocked on a send, and returns.
+	cancel()
+	if err := testutil.RequireRecv(t, errc, time.Second); err != nil {
+		t.Errorf("Run = %v, want nil", err)
+	}
+	if _, ok := <-f.Lines(); ok {
+		t.Error("Lines still open after Run returned")
+	}
+}
+
+func TestOpenError(t *testing.T) {
+	// a directory opens, but does not read.
+	f := New(t.TempDir(), Options{Poll: poll, FromStart: true})
+	errc := make(chan error, 1)
+	go func() { errc <- f.Run(context.Background()) }()
+	if err := testutil.RequireRecv(t, errc, time.Second); err == nil {
+		t.Error("following a directory: Run = nil, want an error")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+
+	todo "github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/todo-api"
+	"github.com/YongSangUn/learn-golang/internal/config"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/todo-api/cmd/todo-api
+	curl -i -X POST localhost:8080/todos -H 'Content-Type: application/json' -d '{"title":"learn go"}'
+	curl -i localhost:8080/todos
+
+The todos are kept in memory, lost at exit; with TODO_FILE set, in that
+JSON file:
+
+	TODO_FILE=/tmp/todos.json go run ./golang_program_design_2024/09.projects/todo-api/cmd/todo-api
+*/
+
+func main() {
+	log := xlog.New("09.projects", "todo-api")
+	cfg, err := config.Load("todo-api", os.Args[1:])
+	if err != nil {
+		log.Error("invalid config", "err", err)
+		os.Exit(2)
+	}
+
+	var store todo.Storage = todo.NewMemoryStorage()
+	if path := os.Getenv("TODO_FILE"); path != "" {
+		if store, err = todo.OpenFileStorage(path); err != nil {
+			log.Error("open storage", "err", err)
+			os.Exit(1)
+		}
+		log.Info("storage", "file", path)
+	}
+
+	svc := todo.NewService(store)
+	srv := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      todo.NewHandler(svc),
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Info("listening", "addr", cfg.Addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		log.Error("serve failed", "err", err)
+		os.Exit(1)
+	}
+	log.Info("shut down")
+}
@@ -0,0 +1,158 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FileStorage keeps todos in a JSON file, so they survive a restart. The
+// whole list is in memory, guarded by a RWMutex like MemoryStorage; every
+// write saves the whole file again. That is fine for the size of a todo
+// list, not for a database.
+//
+// A save writes a temporary file next to the real one and renames it over
+// it: a crash in the middle leaves the old file or the new one, never half
+// of each. If the save fails, the change is not applied in memory either,
+// so memory and file agree.
+type FileStorage struct {
+	path string
+
+	mu     sync.RWMutex
+	todos  map[int64]Todo
+	nextID int64
+}
+
+// fileData is the content of the file, with the json tags of Todo.
+type fileData struct {
+	NextID int64  `json:"next_id"`
+	Todos  []Todo `json:"todos"`
+}
+
+// OpenFileStorage loads the todos of path. A missing file is an empty list,
+// created with the first write.
+func OpenFileStorage(path string) (*FileStorage, error) {
+	s := &FileStorage{path: path, todos: make(map[int64]Todo), nextID: 1}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var fd fileData
+	if err := json.Unmarshal(data, &fd); err != nil {
+		return nil, &fs.PathError{Op: "decode", Path: path, Err: err}
+	}
+	for _, t := range fd.Todos {
+		s.todos[t.ID] = t
+		if t.ID >= s.nextID {
+			s.nextID = t.ID + 1 // a file edited by hand may be behind
+		}
+	}
+	if fd.NextID > s.nextID {
+		s.nextID = fd.NextID // IDs of deleted todos are not given again
+	}
+	return s, nil
+}
+
+// List returns the todos ordered by ID.
+func (s *FileStorage) List() ([]Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return sorted(s.todos), nil
+}
+
+func (s *FileStorage) Get(id int64) (Todo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.todos[id]
+	if !ok {
+		return Todo{}, ErrNotFound
+	}
+	return t, nil
+}
+
+func (s *FileStorage) Create(t Todo) (Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.ID = s.nextID
+	err := s.commit(s.nextID+1, func(todos map[int64]Todo) { todos[t.ID] = t })
+	if err != nil {
+		return Todo{}, err
+	}
+	return t, nil
+}
+
+func (s *FileStorage) Update(t Todo) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.todos[t.ID]; !ok {
+		return ErrNotFound
+	}
+	return s.commit(s.nextID, func(todos map[int64]Todo) { todos[t.ID] = t })
+}
+
+func (s *FileStorage) Delete(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.todos[id]; !ok {
+		return ErrNotFound
+	}
+	return s.commit(s.nextID, func(todos map[int64]Todo) { delete(todos, id) })
+}
+
+// commit applies change to a copy of the todos, saves the copy, and only
+// then makes it the current state. s.mu must be held for writing.
+func (s *FileStorage) commit(nextID int64, change func(map[int64]Todo)) error {
+	todos := make(map[int64]Todo, len(s.todos)+1)
+	for id, t := range s.todos {
+		todos[id] = t
+	}
+	change(todos)
+	if err := s.save(fileData{NextID: nextID, Todos: sorted(todos)}); err != nil {
+		return err
+	}
+	s.todos, s.nextID = todos, nextID
+	return nil
+}
+
+func (s *FileStorage) save(fd fileData) error {
+	data, err := json.MarshalIndent(fd, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // a no-op once renamed
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	// flushed to the disk before the rename makes it the file: after a
+	// power cut, the rename must not point to data still in a cache.
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// sorted returns the todos of m ordered by ID; map iteration order is random.
+func sorted(m map[int64]Todo) []Todo {
+	list := make([]Todo, 0, len(m))
+	for _, t := range m {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
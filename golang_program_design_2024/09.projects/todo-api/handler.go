@@ -0,0 +1,165 @@
+package todo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+/*
+Routes (method patterns of http.ServeMux, Go 1.22+):
+
+	GET    /todos       200 list
+	POST   /todos       201 created, Location: /todos/{id}
+	GET    /todos/{id}  200 | 404
+	PUT    /todos/{id}  200 | 404
+	DELETE /todos/{id}  204 | 404
+
+Bad JSON is a 400, a body that is not JSON a 415, and an input failing
+validation a 422 with the invalid fields. The mux itself answers 405 for a
+known path with the wrong method.
+*/
+
+const maxBodySize = 1 << 20
+
+// Handler adapts a Service to HTTP.
+type Handler struct {
+	svc *Service
+	mux *http.ServeMux
+}
+
+func NewHandler(svc *Service) *Handler {
+	h := &Handler{svc: svc, mux: http.NewServeMux()}
+	h.mux.HandleFunc("GET /todos", h.list)
+	h.mux.HandleFunc("POST /todos", h.create)
+	h.mux.HandleFunc("GET /todos/{id}", h.get)
+	h.mux.HandleFunc("PUT /todos/{id}", h.update)
+	h.mux.HandleFunc("DELETE /todos/{id}", h.delete)
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	todos, err := h.svc.List()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, todos)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	t, err := h.svc.Get(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	var in Input
+	if !decode(w, r, &in) {
+		return
+	}
+	t, err := h.svc.Create(in)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Location", fmt.Sprintf("/todos/%d", t.ID))
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func (h *Handler) update(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	var in Input
+	if !decode(w, r, &in) {
+		return
+	}
+	t, err := h.svc.Update(id, in)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+	if err := h.svc.Delete(id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: "invalid id"})
+		return 0, false
+	}
+	return id, true
+}
+
+// decode reads a single JSON object from the body, rejecting unknown fields
+// so a typo such as "titel" is reported instead of silently ignored.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		writeJSON(w, http.StatusUnsupportedMediaType, errorBody{Error: "content type must be application/json"})
+		return false
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: "invalid JSON: " + err.Error()})
+		return false
+	}
+	if dec.More() {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: "invalid JSON: trailing data"})
+		return false
+	}
+	return true
+}
+
+type errorBody struct {
+	Error  string            `json:"error"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// writeError maps the errors of the lower layers to status codes.
+func writeError(w http.ResponseWriter, err error) {
+	var verr *ValidationError
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeJSON(w, http.StatusNotFound, errorBody{Error: err.Error()})
+	case errors.As(err, &verr):
+		writeJSON(w, http.StatusUnprocessableEntity, errorBody{Error: "validation failed", Fields: verr.Fields})
+	default:
+		// internal details stay in the server, the client gets a generic message.
+		writeJSON(w, http.StatusInternalServerError, errorBody{Error: "internal error"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,166 @@
+package todo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// storages are the implementations every test runs against: the handlers
+// must not see a difference.
+var storages = []struct {
+	name string
+	open func(t *testing.T) Storage
+}{
+	{"memory", func(t *testing.T) Storage { return NewMemoryStorage() }},
+	{"file", func(t *testing.T) Storage {
+		s, err := OpenFileStorage(filepath.Join(t.TempDir(), "todos.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}},
+}
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestHandler returns a handler over store, with a fixed clock and one
+// todo: 1, "write tests".
+func newTestHandler(t *testing.T, store Storage) *Handler {
+	t.Helper()
+	svc := NewService(store)
+	svc.now = func() time.Time { return testNow }
+	if _, err := svc.Create(Input{Title: "write tests"}); err != nil {
+		t.Fatal(err)
+	}
+	return NewHandler(svc)
+}
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string // application/json if empty and there is a body
+		body        string
+		status      int
+		want        string // a part of the response body
+	}{
+		{"list", "GET", "/todos", "", "", 200, `"title":"write tests"`},
+		{"get", "GET", "/todos/1", "", "", 200, `"created_at":"2024-05-01T12:00:00Z"`},
+		{"get unknown", "GET", "/todos/99", "", "", 404, `"error":"todo not found"`},
+		{"get bad id", "GET", "/todos/abc", "", "", 400, `"error":"invalid id"`},
+		{"get negative id", "GET", "/todos/-1", "", "", 400, `"error":"invalid id"`},
+		{"create", "POST", "/todos", "", `{"title": "ship it"}`, 201, `"id":2`},
+		{"create trims", "POST", "/todos", "", `{"title": "  ship it  "}`, 201, `"title":"ship it"`},
+		{"create done", "POST", "/todos", "", `{"title": "x", "done": true}`, 201, `"done":true`},
+		{"create empty title", "POST", "/todos", "", `{"title": "   "}`, 422, `"fields":{"title":"is required"}`},
+		{"create long title", "POST", "/todos", "", `{"title": "` + strings.Repeat("a", 201) + `"}`, 422, "at most 200"},
+		{"create unknown field", "POST", "/todos", "", `{"titel": "x"}`, 400, `unknown field \"titel\"`},
+		{"create broken JSON", "POST", "/todos", "", `{"title":`, 400, "invalid JSON"},
+		{"create trailing data", "POST", "/todos", "", `{"title": "a"} {}`, 400, "trailing data"},
+		{"create not JSON", "POST", "/todos", "text/plain", `title=x`, 415, "application/json"},
+		{"create with charset", "POST", "/todos", "application/json; charset=utf-8", `{"title": "x"}`, 201, `"id":2`},
+		{"update", "PUT", "/todos/1", "", `{"title": "write more tests", "done": true}`, 200, `"done":true`},
+		{"update unknown", "PUT", "/todos/99", "", `{"title": "x"}`, 404, "not found"},
+		{"update invalid", "PUT", "/todos/1", "", `{"title": ""}`, 422, "is required"},
+		{"delete", "DELETE", "/todos/1", "", "", 204, ""},
+		{"delete unknown", "DELETE", "/todos/99", "", "", 404, "not found"},
+		{"wrong method", "PATCH", "/todos/1", "", "", 405, ""},
+		{"unknown path", "GET", "/nothing", "", "", 404, ""},
+	}
+	for _, st := range storages {
+		t.Run(st.name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					h := newTestHandler(t, st.open(t))
+					req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+					if tt.body != "" {
+						ct := tt.contentType
+						if ct == "" {
+							ct = "application/json"
+						}
+						req.Header.Set("Content-Type", ct)
+					}
+					w := httptest.NewRecorder()
+					h.ServeHTTP(w, req)
+
+					if w.Code != tt.status {
+						t.Errorf("status = %d, want %d; body %s", w.Code, tt.status, w.Body)
+					}
+					if !strings.Contains(w.Body.String(), tt.want) {
+						t.Errorf("body = %s, want it to contain %s", w.Body, tt.want)
+					}
+					if tt.want != "" && w.Header().Get("Content-Type") != "application/json" {
+						t.Errorf("Content-Type = %q", w.Header().Get("Content-Type"))
+					}
+				})
+			}
+		})
+	}
+}
+
+// TestHandlerFlow goes through the life of a todo over a real connection,
+// the way a client would.
+func TestHandlerFlow(t *testing.T) {
+	for _, st := range storages {
+		t.Run(st.name, func(t *testing.T) {
+			srv := httptest.NewServer(newTestHandler(t, st.open(t)))
+			defer srv.Close()
+
+			do := func(method, path, body string, want int) *http.Response {
+				t.Helper()
+				req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.StatusCode != want {
+					t.Fatalf("%s %s = %d, want %d", method, path, resp.StatusCode, want)
+				}
+				return resp
+			}
+			decode := func(resp *http.Response, v any) {
+				t.Helper()
+				defer resp.Body.Close()
+				if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			resp := do("POST", "/todos", `{"title": "learn go"}`, 201)
+			loc := resp.Header.Get("Location")
+			var created Todo
+			decode(resp, &created)
+			if loc != "/todos/2" || created.ID != 2 {
+				t.Fatalf("Location %q, id %d", loc, created.ID)
+			}
+
+			var got Todo
+			decode(do("GET", loc, "", 200), &got)
+			if got != created {
+				t.Errorf("GET = %+v, want %+v", got, created)
+			}
+
+			var updated Todo
+			decode(do("PUT", loc, `{"title": "learn go", "done": true}`, 200), &updated)
+			if !updated.Done || !updated.CreatedAt.Equal(created.CreatedAt) {
+				t.Errorf("PUT = %+v", updated)
+			}
+
+			do("DELETE", loc, "", 204).Body.Close()
+			do("GET", loc, "", 404).Body.Close()
+
+			var list []Todo
+			decode(do("GET", "/todos", "", 200), &list)
+			if len(list) != 1 || list[0].ID != 1 {
+				t.Errorf("list = %+v, want only todo 1", list)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package todo
+
+import "time"
+
+// Service holds the rules of the API, independent of HTTP.
+type Service struct {
+	store Storage
+	now   func() time.Time
+}
+
+func NewService(store Storage) *Service {
+	return &Service{store: store, now: time.Now}
+}
+
+func (s *Service) List() ([]Todo, error) {
+	return s.store.List()
+}
+
+func (s *Service) Get(id int64) (Todo, error) {
+	return s.store.Get(id)
+}
+
+func (s *Service) Create(in Input) (Todo, error) {
+	if err := in.Validate(); err != nil {
+		return Todo{}, err
+	}
+	now := s.now().UTC()
+	return s.store.Create(Todo{Title: in.Title, Done: in.Done, CreatedAt: now, UpdatedAt: now})
+}
+
+// Update replaces the client-owned fields of an existing todo.
+func (s *Service) Update(id int64, in Input) (Todo, error) {
+	if err := in.Validate(); err != nil {
+		return Todo{}, err
+	}
+	t, err := s.store.Get(id)
+	if err != nil {
+		return Todo{}, err
+	}
+	t.Title, t.Done, t.UpdatedAt = in.Title, in.Done, s.now().UTC()
+	if err := s.store.Update(t); err != nil {
+		return Todo{}, err
+	}
+	return t, nil
+}
+
+func (s *Service) Delete(id int64) error {
+	return s.store.Delete(id)
+}
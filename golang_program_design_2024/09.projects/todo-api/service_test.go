@@ -0,0 +1,115 @@
+package todo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		title     string
+		wantTitle string // after Validate
+		wantField string // the message for "title", "" if valid
+	}{
+		{"plain", "write tests", "write tests", ""},
+		{"trimmed", "\t write tests \n", "write tests", ""},
+		{"empty", "", "", "is required"},
+		{"blank", "   ", "", "is required"},
+		{"at the limit", strings.Repeat("a", maxTitleLen), strings.Repeat("a", maxTitleLen), ""},
+		{"too long", strings.Repeat("a", maxTitleLen+1), strings.Repeat("a", maxTitleLen+1), "must be at most 200 characters"},
+		{"long once trimmed is fine", " " + strings.Repeat("a", maxTitleLen) + " ", strings.Repeat("a", maxTitleLen), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := Input{Title: tt.title}
+			err := in.Validate()
+			if in.Title != tt.wantTitle {
+				t.Errorf("title %q after Validate, want %q", in.Title, tt.wantTitle)
+			}
+			var verr *ValidationError
+			switch {
+			case tt.wantField == "" && err != nil:
+				t.Errorf("Validate = %v, want nil", err)
+			case tt.wantField != "" && !errors.As(err, &verr):
+				t.Errorf("Validate = %v, want a *ValidationError", err)
+			case tt.wantField != "" && verr.Fields["title"] != tt.wantField:
+				t.Errorf("fields %v, want title: %s", verr.Fields, tt.wantField)
+			}
+		})
+	}
+}
+
+// TestServiceTimestamps checks the fields the server owns: Create sets both
+// timestamps, Update moves UpdatedAt only.
+func TestServiceTimestamps(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("KST", 9*3600))
+	svc := NewService(NewMemoryStorage())
+	svc.now = func() time.Time { return now }
+
+	created, err := svc.Create(Input{Title: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created.CreatedAt.Equal(now) || created.CreatedAt.Location() != time.UTC || created.UpdatedAt != created.CreatedAt {
+		t.Errorf("created at %v, updated at %v; want both %v in UTC", created.CreatedAt, created.UpdatedAt, now)
+	}
+
+	now = now.Add(time.Hour)
+	updated, err := svc.Update(created.ID, Input{Title: "b", Done: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.CreatedAt != created.CreatedAt || !updated.UpdatedAt.Equal(now) {
+		t.Errorf("after Update: created at %v, updated at %v", updated.CreatedAt, updated.UpdatedAt)
+	}
+	if got, _ := svc.Get(created.ID); got != updated {
+		t.Errorf("stored %+v, Update returned %+v", got, updated)
+	}
+
+	if _, err := svc.Update(99, Input{Title: "x"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update of a missing todo = %v, want ErrNotFound", err)
+	}
+	if _, err := svc.Update(created.ID, Input{}); err == nil {
+		t.Error("Update with an invalid input succeeded")
+	}
+}
+
+// brokenStorage fails every call, like a disk that is full.
+type brokenStorage struct{}
+
+var errDisk = errors.New("disk full at /var/lib/todo")
+
+func (brokenStorage) List() ([]Todo, error)     { return nil, errDisk }
+func (brokenStorage) Get(int64) (Todo, error)   { return Todo{}, errDisk }
+func (brokenStorage) Create(Todo) (Todo, error) { return Todo{}, errDisk }
+func (brokenStorage) Update(Todo) error         { return errDisk }
+func (brokenStorage) Delete(int64) error        { return errDisk }
+
+// TestHandlerStorageErrors checks that an unexpected error of the storage is
+// a 500 whose body does not show it to the client.
+func TestHandlerStorageErrors(t *testing.T) {
+	h := NewHandler(NewService(brokenStorage{}))
+	for _, tt := range []struct{ method, path, body string }{
+		{"GET", "/todos", ""},
+		{"GET", "/todos/1", ""},
+		{"POST", "/todos", `{"title": "x"}`},
+		{"PUT", "/todos/1", `{"title": "x"}`},
+		{"DELETE", "/todos/1", ""},
+	} {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("%s %s = %d, want 500", tt.method, tt.path, w.Code)
+		}
+		if strings.Contains(w.Body.String(), "disk") {
+			t.Errorf("%s %s: the body shows the internal error: %s", tt.method, tt.path, w.Body)
+		}
+	}
+}
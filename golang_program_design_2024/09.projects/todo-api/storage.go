@@ -0,0 +1,73 @@
+package todo
+
+import "sync"
+
+// Storage persists todos: MemoryStorage for tests and demos, FileStorage to
+// keep them across restarts. Implementations must be safe for concurrent
+// use, since net/http serves every request on its own goroutine.
+type Storage interface {
+	List() ([]Todo, error)
+	Get(id int64) (Todo, error)
+	// Create assigns the ID and returns the stored todo.
+	Create(t Todo) (Todo, error)
+	Update(t Todo) error
+	Delete(id int64) error
+}
+
+// MemoryStorage keeps todos in a map guarded by a RWMutex: many concurrent
+// readers, one writer at a time (see syncRWMutex in 04.concurrent/sync).
+type MemoryStorage struct {
+	mu     sync.RWMutex
+	todos  map[int64]Todo
+	nextID int64
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{todos: make(map[int64]Todo), nextID: 1}
+}
+
+// List returns the todos ordered by ID; map iteration order is random.
+func (m *MemoryStorage) List() ([]Todo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return sorted(m.todos), nil
+}
+
+func (m *MemoryStorage) Get(id int64) (Todo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.todos[id]
+	if !ok {
+		return Todo{}, ErrNotFound
+	}
+	return t, nil
+}
+
+func (m *MemoryStorage) Create(t Todo) (Todo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t.ID = m.nextID
+	m.nextID++
+	m.todos[t.ID] = t
+	return t, nil
+}
+
+func (m *MemoryStorage) Update(t Todo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.todos[t.ID]; !ok {
+		return ErrNotFound
+	}
+	m.todos[t.ID] = t
+	return nil
+}
+
+func (m *MemoryStorage) Delete(id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.todos[id]; !ok {
+		return ErrNotFound
+	}
+	delete(m.todos, id)
+	return nil
+}
@@ -0,0 +1,136 @@
+package todo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// TestStorage is the contract of Storage, for every implementation.
+func TestStorage(t *testing.T) {
+	for _, st := range storages {
+		t.Run(st.name, func(t *testing.T) {
+			s := st.open(t)
+			a, _ := s.Create(Todo{Title: "a"})
+			b, _ := s.Create(Todo{Title: "b"})
+			if a.ID != 1 || b.ID != 2 {
+				t.Fatalf("IDs %d, %d, want 1, 2", a.ID, b.ID)
+			}
+
+			b.Done = true
+			if err := s.Update(b); err != nil {
+				t.Fatal(err)
+			}
+			if got, _ := s.Get(2); !got.Done {
+				t.Errorf("Get after Update = %+v", got)
+			}
+			if err := s.Delete(1); err != nil {
+				t.Fatal(err)
+			}
+			c, _ := s.Create(Todo{Title: "c"})
+			if c.ID != 3 {
+				t.Errorf("ID after a Delete = %d, want 3: IDs are not reused", c.ID)
+			}
+			if list, _ := s.List(); len(list) != 2 || list[0].ID != 2 || list[1].ID != 3 {
+				t.Errorf("List = %+v, want 2 and 3 in order", list)
+			}
+
+			for name, err := range map[string]error{
+				"Get":    func() error { _, err := s.Get(1); return err }(),
+				"Update": s.Update(Todo{ID: 1}),
+				"Delete": s.Delete(1),
+			} {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("%s of a deleted todo: %v, want ErrNotFound", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestStorageConcurrent(t *testing.T) {
+	for _, st := range storages {
+		t.Run(st.name, func(t *testing.T) {
+			s := st.open(t)
+			var wg sync.WaitGroup
+			for i := 0; i < 20; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if _, err := s.Create(Todo{Title: "x"}); err != nil {
+						t.Error(err)
+					}
+					s.List()
+				}()
+			}
+			wg.Wait()
+			list, _ := s.List()
+			seen := make(map[int64]bool)
+			for _, td := range list {
+				seen[td.ID] = true
+			}
+			if len(list) != 20 || len(seen) != 20 {
+				t.Errorf("%d todos, %d distinct IDs, want 20", len(list), len(seen))
+			}
+		})
+	}
+}
+
+func TestFileStorageReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	s, err := OpenFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Create(Todo{Title: "a"})
+	s.Create(Todo{Title: "b", Done: true})
+	s.Delete(2)
+
+	s, err = OpenFileStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, _ := s.List()
+	if len(list) != 1 || list[0].Title != "a" {
+		t.Errorf("after reopening: %+v", list)
+	}
+	if c, _ := s.Create(Todo{Title: "c"}); c.ID != 3 {
+		t.Errorf("ID after reopening = %d, want 3: the deleted 2 is not given again", c.ID)
+	}
+	if tmp, _ := filepath.Glob(path + ".*.tmp"); len(tmp) > 0 {
+		t.Errorf("temporary files left: %v", tmp)
+	}
+}
+
+func TestFileStorageCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "todos.json")
+	os.WriteFile(path, []byte(`{"todos": [`), 0o644)
+	if _, err := OpenFileStorage(path); err == nil {
+		t.Error("opened a corrupt file")
+	}
+}
+
+// TestFileStorageSaveFails checks that a write that cannot be saved is not
+// applied in memory either.
+func TestFileStorageSaveFails(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	os.Mkdir(dir, 0o755)
+	s, err := OpenFileStorage(filepath.Join(dir, "todos.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Create(Todo{Title: "a"})
+
+	os.RemoveAll(dir) // no directory: the temporary file cannot be created
+	if _, err := s.Create(Todo{Title: "b"}); err == nil {
+		t.Fatal("Create succeeded without a directory")
+	}
+	if err := s.Delete(1); err == nil {
+		t.Fatal("Delete succeeded without a directory")
+	}
+	if list, _ := s.List(); len(list) != 1 || list[0].Title != "a" {
+		t.Errorf("List = %+v, want a alone, as on disk", list)
+	}
+}
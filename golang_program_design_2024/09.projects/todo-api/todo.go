@@ -0,0 +1,66 @@
+// Package todo is a small JSON REST API for todo items, split into three layers:
+//
+//	Handler  (HTTP: routing, decoding, status codes)
+//	  -> Service  (rules: validation, timestamps)
+//	    -> Storage  (persistence, an interface with swappable implementations)
+//
+// Each layer only knows the one below it through its exported methods, so
+// the storage can change without touching the handlers and vice versa.
+package todo
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Todo is the resource served by the API. The struct tags follow the
+// conventions of 05.standard_lib/json.go.
+type Todo struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Done      bool      `json:"done"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Input is what a client may send; ID and timestamps are owned by the server.
+type Input struct {
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+const maxTitleLen = 200
+
+// ErrNotFound is returned by every layer when the todo does not exist.
+var ErrNotFound = errors.New("todo not found")
+
+// ValidationError lists every invalid field of an Input.
+type ValidationError struct {
+	Fields map[string]string `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for field, msg := range e.Fields {
+		parts = append(parts, field+": "+msg)
+	}
+	return "invalid todo: " + strings.Join(parts, ", ")
+}
+
+// Validate normalizes the input and reports all problems at once.
+func (in *Input) Validate() error {
+	in.Title = strings.TrimSpace(in.Title)
+	fields := make(map[string]string)
+	switch {
+	case in.Title == "":
+		fields["title"] = "is required"
+	case len(in.Title) > maxTitleLen:
+		fields["title"] = fmt.Sprintf("must be at most %d characters", maxTitleLen)
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
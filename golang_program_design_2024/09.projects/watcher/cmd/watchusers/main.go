@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/watcher"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+watchusers keeps a list of users loaded from users.json (the file saved by
+encodeJson in 05.standard_lib/json.go) and reloads it whenever the file
+changes. A file that fails to decode is reported and the previous list kept,
+so a half-saved edit never leaves the program without users.
+
+Usage:
+
+	go run ./golang_program_design_2024/09.projects/watcher/cmd/watchusers
+	go run ./golang_program_design_2024/09.projects/watcher/cmd/watchusers -file users.json
+
+Without -file it works on a copy in a temp dir and edits it itself: a quick
+burst of writes (one event after debouncing), a broken save, a fix, a delete.
+*/
+
+type user struct {
+	Name string
+	Age  int
+}
+
+func main() {
+	file := flag.String("file", "", "users.json to watch, empty for the self-driving demo")
+	interval := flag.Duration("interval", 250*time.Millisecond, "poll interval")
+	flag.Parse()
+
+	log := xlog.New("09.projects", "watcher")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	path := *file
+	if path == "" {
+		dir, err := os.MkdirTemp("", "watchusers")
+		if err != nil {
+			log.Error("temp dir", "err", err)
+			os.Exit(1)
+		}
+		defer os.RemoveAll(dir)
+		path = filepath.Join(dir, "users.json")
+		os.WriteFile(path, []byte(`[{"Name":"Alice","Age":30},{"Name":"Bob","Age":25}]`), 0o644)
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		go func() {
+			edit(path, *interval)
+			cancel()
+		}()
+	}
+
+	users, err := load(path)
+	if err != nil {
+		log.Error("initial load", "err", err)
+	}
+	log.Info("loaded", "users", users)
+
+	w := watcher.New(path)
+	w.Interval = *interval
+	w.Debounce = 2 * *interval
+	go w.Run(ctx)
+
+	for {
+		select {
+		case ev, ok := <-w.Events():
+			if !ok {
+				return
+			}
+			log.Info("event", "op", ev.Op, "path", ev.Path)
+			if ev.Op == watcher.Delete {
+				log.Warn("users.json deleted, keeping the last list", "users", len(users))
+				continue
+			}
+			next, err := load(path)
+			if err != nil {
+				log.Error("reload failed, keeping the last list", "err", err)
+				continue
+			}
+			users = next
+			log.Info("reloaded", "users", users)
+		case err := <-w.Errors():
+			log.Error("watch", "err", err)
+		}
+	}
+}
+
+func load(path string) ([]user, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var users []user
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return users, nil
+}
+
+// edit plays the part of a user working on the file.
+func edit(path string, interval time.Duration) {
+	pause := func() { time.Sleep(6 * interval) }
+	pause()
+
+	// several saves in a row, closer together than the debounce delay.
+	for _, age := range []int{31, 32, 33} {
+		os.WriteFile(path, []byte(fmt.Sprintf(`[{"Name":"Alice","Age":%d},{"Name":"Bob","Age":25}]`, age)), 0o644)
+		time.Sleep(interval / 2)
+	}
+	pause()
+
+	os.WriteFile(path, []byte(`[{"Name":"Alice","Age":33},{"Name":"Bob"`), 0o644)
+	pause()
+
+	os.WriteFile(path, []byte(`[{"Name":"Alice","Age":33},{"Name":"Bob","Age":25},{"Name":"Carol","Age":41}]`), 0o644)
+	pause()
+
+	os.Remove(path)
+	pause()
+}
@@ -0,0 +1,243 @@
+// Package watcher reports file changes by polling: every interval it takes a
+// snapshot of the watched paths (modification time, size and a content hash
+// per file) and compares it with the previous one.
+//
+// Polling is slower than OS notifications (inotify, kqueue), but it is plain
+// Go, works on every platform and on network filesystems, and cannot miss an
+// event because a buffer overflowed.
+//
+// Editors often save a file in several steps (truncate, write, rename), which
+// would show up as a burst of events. Changes are therefore debounced: an
+// event is only emitted once its path has been quiet for the debounce delay,
+// and the steps are folded into one (create then modify is a create, create
+// then delete is nothing at all).
+package watcher
+
+import (
+	"context"
+	"hash/fnv"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Op is the kind of change.
+type Op int
+
+const (
+	Create Op = iota + 1
+	Modify
+	Delete
+)
+
+func (op Op) String() string {
+	switch op {
+	case Create:
+		return "CREATE"
+	case Modify:
+		return "MODIFY"
+	case Delete:
+		return "DELETE"
+	}
+	return "UNKNOWN"
+}
+
+// Event is a debounced change of one file.
+type Event struct {
+	Op   Op
+	Path string
+	Time time.Time // when the change was first seen
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+	hash    uint64
+}
+
+type pending struct {
+	op      Op
+	first   time.Time
+	last    time.Time
+	existed bool // whether the file existed before this burst of changes
+}
+
+// Watcher polls a set of files and directories (recursively).
+type Watcher struct {
+	Interval time.Duration
+	Debounce time.Duration
+	// Hash compares file contents too. It catches a rewrite that kept the
+	// size and landed in the same mtime tick, at the cost of reading every file.
+	Hash bool
+
+	paths   []string
+	events  chan Event
+	errors  chan error
+	state   map[string]fileState
+	pending map[string]*pending
+}
+
+func New(paths ...string) *Watcher {
+	return &Watcher{
+		Interval: 500 * time.Millisecond,
+		Debounce: 200 * time.Millisecond,
+		Hash:     true,
+		paths:    paths,
+		events:   make(chan Event),
+		errors:   make(chan error, 1),
+	}
+}
+
+// Events delivers the changes. It is closed when Run returns.
+func (w *Watcher) Events() <-chan Event { return w.events }
+
+// Errors delivers scan errors without blocking the watcher: when nobody
+// reads them, they are dropped.
+func (w *Watcher) Errors() <-chan error { return w.errors }
+
+// Run polls until ctx is done. The first snapshot is the baseline, files that
+// already exist do not produce Create events.
+func (w *Watcher) Run(ctx context.Context) {
+	defer close(w.events)
+	w.state = w.scan()
+	w.pending = make(map[string]*pending)
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			current := w.scan()
+			w.diff(w.state, current, now)
+			w.state = current
+			for _, ev := range w.ready(now) {
+				select {
+				case w.events <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *Watcher) scan() map[string]fileState {
+	state := make(map[string]fileState)
+	for _, root := range w.paths {
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil // a watched path may not exist yet
+				}
+				// a path that cannot be read is not gone: report it, keep
+				// what the last scan saw there, and walk on.
+				w.report(err)
+				w.keep(state, path)
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil // deleted between the listing and the stat
+			}
+			st := fileState{modTime: info.ModTime(), size: info.Size()}
+			if w.Hash {
+				st.hash = hashFile(path)
+			}
+			state[path] = st
+			return nil
+		})
+	}
+	return state
+}
+
+// keep copies into state what the last scan saw at path and below it.
+func (w *Watcher) keep(state map[string]fileState, path string) {
+	prefix := path + string(filepath.Separator)
+	for p, st := range w.state {
+		if p == path || strings.HasPrefix(p, prefix) {
+			state[p] = st
+		}
+	}
+}
+
+func (w *Watcher) report(err error) {
+	select {
+	case w.errors <- err:
+	default:
+	}
+}
+
+func hashFile(path string) uint64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	h := fnv.New64a()
+	io.Copy(h, f)
+	return h.Sum64()
+}
+
+// diff records the raw changes between two snapshots as pending events.
+func (w *Watcher) diff(before, after map[string]fileState, now time.Time) {
+	for path, st := range after {
+		old, existed := before[path]
+		switch {
+		case !existed:
+			w.record(path, Create, now)
+		case old != st:
+			w.record(path, Modify, now)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			w.record(path, Delete, now)
+		}
+	}
+}
+
+func (w *Watcher) record(path string, op Op, now time.Time) {
+	p, ok := w.pending[path]
+	if !ok {
+		w.pending[path] = &pending{op: op, first: now, last: now, existed: op != Create}
+		return
+	}
+	p.last = now
+	// fold the new change into the pending one, judging by the state of the
+	// file before the burst started and its state now.
+	switch {
+	case op == Delete && !p.existed:
+		delete(w.pending, path) // created and deleted again: nothing happened
+	case op == Delete:
+		p.op = Delete
+	case !p.existed:
+		p.op = Create
+	default:
+		p.op = Modify
+	}
+}
+
+// ready returns the pending events quiet for at least Debounce, oldest first.
+func (w *Watcher) ready(now time.Time) []Event {
+	var out []Event
+	for path, p := range w.pending {
+		if now.Sub(p.last) >= w.Debounce {
+			out = append(out, Event{Op: p.op, Path: path, Time: p.first})
+			delete(w.pending, path)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Time.Before(out[j].Time) })
+	return out
+}
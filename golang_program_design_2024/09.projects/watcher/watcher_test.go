@@ -0,0 +1,194 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+var t0 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func ms(n int) time.Time { return t0.Add(time.Duration(n) * time.Millisecond) }
+
+// snapshot is a scan of files with the given sizes.
+func snapshot(sizes map[string]int64) map[string]fileState {
+	state := make(map[string]fileState)
+	for path, size := range sizes {
+		state[path] = fileState{modTime: t0, size: size}
+	}
+	return state
+}
+
+// TestFold feeds scans to diff, the 200ms debounce apart at most, and checks
+// the one event each burst folds into.
+func TestFold(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		scans []map[string]int64 // the first one is the baseline
+		want  []Op
+	}{
+		{"create", []map[string]int64{{}, {"f": 1}}, []Op{Create}},
+		{"modify", []map[string]int64{{"f": 1}, {"f": 2}}, []Op{Modify}},
+		{"delete", []map[string]int64{{"f": 1}, {}}, []Op{Delete}},
+		{"create then modify", []map[string]int64{{}, {"f": 1}, {"f": 2}, {"f": 3}}, []Op{Create}},
+		{"create then delete", []map[string]int64{{}, {"f": 1}, {}}, nil},
+		{"delete then create", []map[string]int64{{"f": 1}, {}, {"f": 2}}, []Op{Modify}},
+		{"modify then delete", []map[string]int64{{"f": 1}, {"f": 2}, {}}, []Op{Delete}},
+		{"create, delete, create", []map[string]int64{{}, {"f": 1}, {}, {"f": 2}}, []Op{Create}},
+		{"unchanged", []map[string]int64{{"f": 1}, {"f": 1}}, nil},
+	} {
+		w := New()
+		w.pending = make(map[string]*pending)
+		for i := 1; i < len(tt.scans); i++ {
+			w.diff(snapshot(tt.scans[i-1]), snapshot(tt.scans[i]), ms(100*i))
+		}
+		var got []Op
+		for _, ev := range w.ready(ms(100*len(tt.scans) + 200)) {
+			if ev.Path != "f" {
+				t.Errorf("%s: event %+v", tt.name, ev)
+			}
+			got = append(got, ev.Op)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: events %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestDebounce checks that an event is held while its path keeps changing,
+// and delivered once it has been quiet for Debounce.
+func TestDebounce(t *testing.T) {
+	w := New()
+	w.Debounce = 200 * time.Millisecond
+	w.pending = make(map[string]*pending)
+	w.diff(snapshot(map[string]int64{"a": 1, "b": 1}), snapshot(map[string]int64{"a": 2, "b": 2}), ms(0))
+	w.diff(snapshot(map[string]int64{"a": 2, "b": 2}), snapshot(map[string]int64{"a": 3, "b": 2}), ms(150))
+
+	if got := w.ready(ms(199)); got != nil {
+		t.Errorf("ready before the debounce = %v", got)
+	}
+	if got := w.ready(ms(200)); len(got) != 1 || got[0] != (Event{Op: Modify, Path: "b", Time: ms(0)}) {
+		t.Errorf("ready once b is quiet = %v, want b alone", got)
+	}
+	if got := w.ready(ms(349)); got != nil {
+		t.Errorf("ready while a changed 199ms ago = %v", got)
+	}
+	if got := w.ready(ms(350)); len(got) != 1 || got[0] != (Event{Op: Modify, Path: "a", Time: ms(0)}) {
+		t.Errorf("ready once a is quiet = %v, want a, first seen at 0", got)
+	}
+	if len(w.pending) != 0 {
+		t.Errorf("%d events still pending", len(w.pending))
+	}
+}
+
+// TestScan scans a temp dir: the regular files, recursively, and a watched
+// path that does not exist yet.
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "a.txt"), "a")
+	write(t, filepath.Join(dir, "sub", "b.txt"), "bb")
+	w := New(dir, filepath.Join(dir, "missing"))
+	state := w.scan()
+	if len(state) != 2 || state[filepath.Join(dir, "sub", "b.txt")].size != 2 {
+		t.Errorf("scan = %v", state)
+	}
+
+	// a rewrite of the same size is caught by the hash alone.
+	before := state[filepath.Join(dir, "a.txt")]
+	write(t, filepath.Join(dir, "a.txt"), "b")
+	os.Chtimes(filepath.Join(dir, "a.txt"), before.modTime, before.modTime)
+	if after := w.scan()[filepath.Join(dir, "a.txt")]; after == before {
+		t.Error("a rewrite of the same size and mtime went unseen")
+	}
+}
+
+// TestScanError watches a path that cannot be read: the error is reported,
+// and what the last scan saw there is kept, not deleted.
+func TestScanError(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "file"), "x")
+	bad := filepath.Join(dir, "file", "sub") // ENOTDIR, not ENOENT
+	w := New(bad)
+	w.state = snapshot(map[string]int64{filepath.Join(bad, "a"): 1, bad + "x": 1})
+	state := w.scan()
+	if want := snapshot(map[string]int64{filepath.Join(bad, "a"): 1}); !reflect.DeepEqual(state, want) {
+		t.Errorf("scan = %v, want %v", state, want)
+	}
+	select {
+	case err := <-w.Errors():
+		if err == nil {
+			t.Error("nil error reported")
+		}
+	default:
+		t.Error("the error was not reported")
+	}
+}
+
+// TestScanUnreadableDir locks a subdirectory: its files stay in the scan.
+// Root reads it anyway, so the test cannot run as root.
+func TestScanUnreadableDir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root reads directories without the permission")
+	}
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "a.txt"), "a")
+	write(t, filepath.Join(dir, "locked", "b.txt"), "b")
+	w := New(dir)
+	w.state = w.scan()
+	os.Chmod(filepath.Join(dir, "locked"), 0)
+	t.Cleanup(func() { os.Chmod(filepath.Join(dir, "locked"), 0o755) })
+	write(t, filepath.Join(dir, "c.txt"), "c")
+
+	state := w.scan()
+	if _, ok := state[filepath.Join(dir, "locked", "b.txt")]; !ok || len(state) != 3 {
+		t.Errorf("scan = %v, want a.txt, c.txt and the locked b.txt", state)
+	}
+	if len(w.Errors()) != 1 {
+		t.Error("the error was not reported")
+	}
+}
+
+// TestRun watches a temp dir for real: a burst of writes to a new file is
+// one Create, the files there before are the baseline.
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	write(t, filepath.Join(dir, "old.txt"), "old")
+	w := New(dir)
+	w.Interval = 10 * time.Millisecond
+	w.Debounce = 50 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	go w.Run(ctx)
+	time.Sleep(30 * time.Millisecond) // the baseline scan
+
+	path := filepath.Join(dir, "new.txt")
+	for _, s := range []string{"1", "12", "123"} {
+		write(t, path, s)
+		time.Sleep(5 * time.Millisecond)
+	}
+	ev := testutil.RequireRecv(t, w.Events(), 2*time.Second)
+	if ev.Op != Create || ev.Path != path {
+		t.Errorf("event %+v, want a create of %s", ev, path)
+	}
+	os.Remove(path)
+	if ev := testutil.RequireRecv(t, w.Events(), 2*time.Second); ev.Op != Delete || ev.Path != path {
+		t.Errorf("event %+v, want a delete of %s", ev, path)
+	}
+
+	cancel()
+	testutil.RequireClosedWithin(t, w.Events(), 2*time.Second)
+}
+
+func write(t *testing.T, path, s string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(s), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
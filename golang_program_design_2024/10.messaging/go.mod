@@ -0,0 +1,19 @@
+module github.com/YongSangUn/learn-golang/golang_program_design_2024/10.messaging
+
+go 1.22
+
+require (
+	github.com/YongSangUn/learn-golang v0.0.0
+	github.com/nats-io/nats.go v1.34.1
+)
+
+require (
+	github.com/klauspost/compress v1.17.2 // indirect
+	github.com/nats-io/nkeys v0.4.7 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	golang.org/x/crypto v0.18.0 // indirect
+	golang.org/x/sys v0.16.0 // indirect
+)
+
+// internal/xlog of the main module.
+replace github.com/YongSangUn/learn-golang => ../..
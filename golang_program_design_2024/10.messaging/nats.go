@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+	"github.com/nats-io/nats.go"
+)
+
+/*
+A message broker sits between the programs that produce events and the ones
+that consume them: the producer does not know who listens, consumers come and
+go. NATS is a small broker (a single binary) with subjects instead of queues:
+
+- Publish sends a message to a subject, like "users.created".
+- Subscribe receives every message of a subject.
+- QueueSubscribe joins a queue group: the members share the messages, each
+  one goes to a single member. Running more copies of a consumer is how it
+  scales, the producer does not change.
+- Core NATS does not store messages: a consumer that is down misses them.
+  (JetStream, on the same server, adds persistence and acks, like the mq
+  project of 09.projects.)
+
+The client reconnects by itself. While it is disconnected, publishes are
+buffered and sent after the reconnect, and the handlers below only log the
+state changes; stop and restart the server while this runs to see them.
+
+On Ctrl-C the connection is drained rather than closed: the subscriptions stop
+receiving, the messages already delivered to the client are still handled,
+then the connection closes. No message accepted by a consumer is cut in half.
+
+	docker run --rm -p 4222:4222 nats:2
+	cd golang_program_design_2024/10.messaging
+	go run nats.go
+
+NATS_URL says where the server is (default nats://127.0.0.1:4222).
+*/
+
+const chapter = "10.messaging"
+
+const subjectUserCreated = "users.created"
+
+// User is the User of 05.standard_lib/json.go, with the same JSON tags; a
+// main package cannot be imported, so the type is repeated here.
+type User struct {
+	Name         string   `json:"name"`
+	Biographical string   `json:"bio,omitempty"`
+	Password     string   `json:"-"` // never leaves the process, not even in a message
+	Email        []string `json:"email"`
+}
+
+// Envelope wraps every message: consumers read the type and id before
+// deciding how to decode the data, and the id lets them drop duplicates.
+type Envelope struct {
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+func newEnvelope(typ string, v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	return json.Marshal(Envelope{ID: hex.EncodeToString(id), Type: typ, Time: time.Now().UTC(), Data: data})
+}
+
+func connect(name string) (*nats.Conn, error) {
+	log := xlog.New(chapter, name)
+	url := os.Getenv("NATS_URL")
+	if url == "" {
+		url = nats.DefaultURL
+	}
+	return nats.Connect(url,
+		nats.Name(name),
+		// keep trying forever, every second; the first connect too.
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(time.Second),
+		nats.RetryOnFailedConnect(true),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			log.Warn("disconnected", "err", err)
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			log.Info("reconnected", "url", nc.ConnectedUrl())
+		}),
+		nats.ClosedHandler(func(*nats.Conn) {
+			log.Info("connection closed")
+		}),
+		// the longest a Drain may take before the connection is closed anyway.
+		nats.DrainTimeout(10*time.Second),
+	)
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	consumers, err := connect("consumers")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var handled atomic.Int64
+	if err := consumerGroup(consumers, 3, &handled); err != nil {
+		log.Fatal(err)
+	}
+	if err := auditor(consumers); err != nil {
+		log.Fatal(err)
+	}
+
+	producer, err := connect("producer")
+	if err != nil {
+		log.Fatal(err)
+	}
+	sent := produce(ctx, producer, 20)
+	// Drain on the producer flushes what is still buffered before closing.
+	producer.Drain()
+
+	// give the consumers a moment, as a real program would wait for a signal.
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+	}
+	drain(consumers)
+	fmt.Printf("sent %d, handled %d\n", sent, handled.Load())
+}
+
+// produce publishes one user every 100ms until n are sent or ctx ends.
+func produce(ctx context.Context, nc *nats.Conn, n int) int {
+	log := xlog.New(chapter, "produce")
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			return i
+		case <-ticker.C:
+		}
+		u := User{Name: fmt.Sprintf("user%02d", i), Password: "P@ssw0rd", Email: []string{fmt.Sprintf("user%02d@test.com", i)}}
+		msg, err := newEnvelope(subjectUserCreated, u)
+		if err != nil {
+			log.Error("encode", "err", err)
+			continue
+		}
+		// Publish only queues the message in the client; an error means the
+		// client gave up (closed connection, full reconnect buffer).
+		if err := nc.Publish(subjectUserCreated, msg); err != nil {
+			log.Error("publish", "err", err)
+		}
+	}
+	return n
+}
+
+// consumerGroup starts members subscribers in the "mailer" queue group: each
+// user is welcomed once, by one of them.
+func consumerGroup(nc *nats.Conn, members int, handled *atomic.Int64) error {
+	for m := 0; m < members; m++ {
+		log := xlog.New(chapter, fmt.Sprintf("mailer-%d", m))
+		_, err := nc.QueueSubscribe(subjectUserCreated, "mailer", func(msg *nats.Msg) {
+			var env Envelope
+			var u User
+			if err := json.Unmarshal(msg.Data, &env); err != nil {
+				log.Error("bad envelope, dropped", "err", err)
+				return
+			}
+			if err := json.Unmarshal(env.Data, &u); err != nil {
+				log.Error("bad user, dropped", "id", env.ID, "err", err)
+				return
+			}
+			// a slow handler: Drain waits for it.
+			time.Sleep(50 * time.Millisecond)
+			log.Info("welcome mail sent", "id", env.ID, "name", u.Name, "email", u.Email)
+			handled.Add(1)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// auditor is a plain subscriber, outside the group: it sees every message.
+func auditor(nc *nats.Conn) error {
+	log := xlog.New(chapter, "auditor")
+	var mu sync.Mutex
+	seen := make(map[string]int)
+	_, err := nc.Subscribe("users.>", func(msg *nats.Msg) {
+		var env Envelope
+		if err := json.Unmarshal(msg.Data, &env); err != nil {
+			return
+		}
+		mu.Lock()
+		seen[env.Type]++
+		n := seen[env.Type]
+		mu.Unlock()
+		log.Debug("event", "subject", msg.Subject, "type", env.Type, "count", n)
+	})
+	return err
+}
+
+// drain stops the subscriptions, lets the pending messages be handled and
+// closes the connection. Drain itself returns at once, the closed status
+// says when it is over.
+func drain(nc *nats.Conn) {
+	if err := nc.Drain(); err != nil {
+		xlog.New(chapter, "drain").Error("drain", "err", err)
+		return
+	}
+	for !nc.IsClosed() {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+/*
+A trace follows one request through every service it touches. It is a tree
+of spans: each span is a timed operation (an HTTP request, a query, a
+function) with a parent, all sharing the trace id.
+
+In Go the current span travels in the context.Context: tracer.Start(ctx, ...)
+creates a child of the span found in ctx and returns a new ctx holding the
+child. The call tree of the functions passing ctx down IS the span tree.
+
+Between processes there is no ctx, so the span is written into the request:
+the client side injects a traceparent header (W3C Trace Context,
+"00-<trace id>-<parent span id>-01"), and the server side extracts it and
+continues the trace. otelhttp does both, for a Transport and for a Handler.
+
+This file runs two services in one process, on two ports, each with its own
+tracer provider as if they were separate programs:
+
+	client -> frontend GET /checkout -> backend GET /price?item=...  (x3)
+	                                 -> backend GET /stock?item=...
+
+The spans go to stdout by default. With OTEL_EXPORTER=otlp they are sent to
+an OpenTelemetry collector or Jaeger over OTLP/HTTP (localhost:4318, or
+OTEL_EXPORTER_OTLP_ENDPOINT), where the two services show up in one trace:
+
+	docker run --rm -p 16686:16686 -p 4318:4318 jaegertracing/all-in-one
+	cd golang_program_design_2024/11.observability
+	OTEL_EXPORTER=otlp go run tracing.go
+	open http://localhost:16686
+*/
+
+func main() {
+	ctx := context.Background()
+
+	// how the trace context is written into, and read from, HTTP headers.
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	backendTP, err := newTracerProvider(ctx, "backend")
+	if err != nil {
+		log.Fatal(err)
+	}
+	frontendTP, err := newTracerProvider(ctx, "frontend")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	backendURL := serve(otelhttp.NewHandler(backend(backendTP.Tracer("backend")), "backend",
+		otelhttp.WithTracerProvider(backendTP)))
+	frontendURL := serve(otelhttp.NewHandler(frontend(frontendTP.Tracer("frontend"), frontendTP, backendURL), "frontend",
+		otelhttp.WithTracerProvider(frontendTP)))
+
+	// the client does not trace: the frontend starts the trace.
+	for _, item := range []string{"book", "unobtainium"} {
+		resp, err := http.Get(frontendURL + "/checkout?items=" + item + ",pen,mug")
+		if err != nil {
+			log.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Fprintf(os.Stderr, "checkout %s: %d %s\n", item, resp.StatusCode, body)
+	}
+
+	// the spans are exported in batches: Shutdown sends the last one. A
+	// program that exits without it loses the end of its traces.
+	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := errors.Join(frontendTP.Shutdown(shutdownCtx), backendTP.Shutdown(shutdownCtx)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newTracerProvider returns the provider of one service, exporting to stdout
+// or OTLP depending on OTEL_EXPORTER.
+func newTracerProvider(ctx context.Context, service string) (*sdktrace.TracerProvider, error) {
+	var exp sdktrace.SpanExporter
+	var err error
+	switch os.Getenv("OTEL_EXPORTER") {
+	case "otlp":
+		// the endpoint and its options come from the standard
+		// OTEL_EXPORTER_OTLP_* variables.
+		exp, err = otlptracehttp.New(ctx, otlptracehttp.WithInsecure())
+	case "", "stdout":
+		exp, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		err = fmt.Errorf("OTEL_EXPORTER=%s: want stdout or otlp", os.Getenv("OTEL_EXPORTER"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		// the name the service appears under in the trace viewer.
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service))),
+		// record everything; a busy service would sample, e.g. 1 in 100.
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	), nil
+}
+
+// serve starts h on a free port and returns its URL.
+func serve(h http.Handler) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	go http.Serve(l, h)
+	return "http://" + l.Addr().String()
+}
+
+func frontend(tracer trace.Tracer, tp trace.TracerProvider, backendURL string) http.Handler {
+	// the transport starts a client span per request and injects traceparent.
+	client := &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport, otelhttp.WithTracerProvider(tp))}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /checkout", func(w http.ResponseWriter, r *http.Request) {
+		// r.Context() holds the server span started by otelhttp.NewHandler.
+		ctx := r.Context()
+		items := splitItems(r.URL.Query().Get("items"))
+		if len(items) == 0 {
+			http.Error(w, "no items", http.StatusBadRequest)
+			return
+		}
+		trace.SpanFromContext(ctx).SetAttributes(attribute.Int("checkout.items", len(items)))
+
+		total, err := priceAll(ctx, tracer, client, backendURL, items)
+		if err == nil {
+			err = checkStock(ctx, client, backendURL, items[0])
+		}
+		if err != nil {
+			span := trace.SpanFromContext(ctx)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "checkout failed")
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		fmt.Fprintf(w, "total %.2f (trace %s)\n", total, trace.SpanFromContext(ctx).SpanContext().TraceID())
+	})
+	return mux
+}
+
+// priceAll is a function with its own span, parent of the HTTP client spans.
+func priceAll(ctx context.Context, tracer trace.Tracer, client *http.Client, backendURL string, items []string) (float64, error) {
+	ctx, span := tracer.Start(ctx, "priceAll", trace.WithAttributes(attribute.StringSlice("items", items)))
+	defer span.End()
+
+	var total float64
+	for _, item := range items {
+		var p struct{ Price float64 }
+		if err := getJSON(ctx, client, backendURL+"/price?item="+item, &p); err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return 0, err
+		}
+		total += p.Price
+	}
+	span.SetAttributes(attribute.Float64("total", total))
+	return total, nil
+}
+
+func checkStock(ctx context.Context, client *http.Client, backendURL, item string) error {
+	var s struct{ InStock bool }
+	return getJSON(ctx, client, backendURL+"/stock?item="+item, &s)
+}
+
+// getJSON must build the request with ctx: without it the transport would
+// not find the parent span, and the backend would start a new trace.
+func getJSON(ctx context.Context, client *http.Client, url string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func backend(tracer trace.Tracer) http.Handler {
+	prices := map[string]float64{"book": 39.90, "pen": 1.50, "mug": 8}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /price", func(w http.ResponseWriter, r *http.Request) {
+		// the span in ctx was continued from the traceparent header: same
+		// trace id as the frontend.
+		fmt.Fprintf(os.Stderr, "backend: traceparent %s\n", r.Header.Get("traceparent"))
+		item := r.URL.Query().Get("item")
+		price, ok := lookupPrice(r.Context(), tracer, prices, item)
+		if !ok {
+			http.Error(w, "unknown item", http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(struct{ Price float64 }{price})
+	})
+	mux.HandleFunc("GET /stock", func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracer.Start(r.Context(), "inventory.query")
+		time.Sleep(time.Duration(5+rand.Intn(10)) * time.Millisecond)
+		span.End()
+		json.NewEncoder(w).Encode(struct{ InStock bool }{true})
+	})
+	return mux
+}
+
+// lookupPrice stands for a database query, a span of the backend.
+func lookupPrice(ctx context.Context, tracer trace.Tracer, prices map[string]float64, item string) (float64, bool) {
+	_, span := tracer.Start(ctx, "db.price", trace.WithAttributes(attribute.String("item", item)))
+	defer span.End()
+	time.Sleep(time.Duration(1+rand.Intn(5)) * time.Millisecond)
+	price, ok := prices[item]
+	if !ok {
+		span.AddEvent("cache miss and no row")
+		span.SetStatus(codes.Error, "unknown item")
+	}
+	return price, ok
+}
+
+func splitItems(s string) []string {
+	var items []string
+	for _, it := range strings.Split(s, ",") {
+		if it != "" {
+			items = append(items, it)
+		}
+	}
+	return items
+}
@@ -0,0 +1,103 @@
+/*
+Package benchmarks is the benchmarks of go test: the other programs of this
+chapter call testing.Benchmark from main, these are BenchmarkXxx functions
+in benchmarks_test.go, run by go test -bench. The code measured is the advice
+of the earlier chapters:
+
+	map preallocation     mapAdcanced, 02.data_struct/map.go: make(map, n)
+	slice append growth   02.data_struct/array_and_slice.go: make([]T, 0, n)
+	string concatenation  += against strings.Builder (all six ways: ../strconcat)
+
+Running them, from the root (-run '^$' skips the tests, -bench takes a regexp
+on the names, / between the levels of sub-benchmarks):
+
+	go test -run '^$' -bench . ./golang_program_design_2024/12.performance/benchmarks
+	go test -run '^$' -bench 'Map/prealloc' -benchtime 100x ./golang_program_design_2024/12.performance/benchmarks
+	go test -run '^$' -bench . -count 10 ./... > new.txt; benchstat old.txt new.txt
+
+Each line reads: name-GOMAXPROCS, iterations, ns/op, and with b.ReportAllocs
+(or -benchmem) B/op and allocs/op. -count 10 repeats them for benchstat
+(golang.org/x/perf/cmd/benchstat), which says whether a difference between
+two runs is more than noise.
+
+Profiles, written by go test for the benchmarks it ran:
+
+	go test -run '^$' -bench Concat -cpuprofile cpu.prof -memprofile mem.prof ./golang_program_design_2024/12.performance/benchmarks
+	go tool pprof -top -cum cpu.prof            where the time goes, callers included
+	go tool pprof -list 'ConcatPlus' cpu.prof   the time per line of the source
+	go tool pprof -sample_index=alloc_space -top mem.prof
+	go tool pprof -http :8080 cpu.prof          the same in a browser, flame graph included
+
+With a profile, go test leaves the test binary, benchmarks.test, in the
+current directory: pprof reads the symbols from it. In the CPU profile of
+Concat, runtime.concatstrings and mallocgc are on top, called from
+ConcatPlus; in the memory one, alloc_space (every byte ever allocated, not
+what is live: that is inuse_space) puts 99% of the bytes in ConcatPlus.
+-memprofilerate 1 records every allocation instead of one per 512 KB, for
+small benchmarks.
+*/
+package benchmarks
+
+import "strings"
+
+// FillMap sets keys[i] to i in a map created empty: it grows as it fills,
+// rehashing everything at each growth.
+func FillMap(keys []string) map[string]int {
+	m := make(map[string]int)
+	for i, k := range keys {
+		m[k] = i
+	}
+	return m
+}
+
+// FillMapPrealloc is FillMap with the size known: one allocation of buckets,
+// no growth.
+func FillMapPrealloc(keys []string) map[string]int {
+	m := make(map[string]int, len(keys))
+	for i, k := range keys {
+		m[k] = i
+	}
+	return m
+}
+
+// AppendGrow appends n ints to a nil slice: the capacity doubles (then grows
+// by 1.25) as needed, each growth a copy.
+func AppendGrow(n int) []int {
+	var s []int
+	for i := 0; i < n; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
+// AppendPrealloc appends n ints to a slice made with capacity n.
+func AppendPrealloc(n int) []int {
+	s := make([]int, 0, n)
+	for i := 0; i < n; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
+// ConcatPlus joins parts with +=: a new string per part, quadratic.
+func ConcatPlus(parts []string) string {
+	s := ""
+	for _, p := range parts {
+		s += p
+	}
+	return s
+}
+
+// ConcatBuilder joins parts with a strings.Builder, sized first.
+func ConcatBuilder(parts []string) string {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	var b strings.Builder
+	b.Grow(n)
+	for _, p := range parts {
+		b.WriteString(p)
+	}
+	return b.String()
+}
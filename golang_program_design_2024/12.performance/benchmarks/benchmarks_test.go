@@ -0,0 +1,95 @@
+package benchmarks
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+var sizes = []int{16, 1 << 10, 64 << 10}
+
+// The results go to package variables: a result nobody uses could let the
+// compiler drop the call, and the benchmark would measure nothing.
+var (
+	mapSink    map[string]int
+	sliceSink  []int
+	stringSink string
+)
+
+// The variants must agree before their speed means anything.
+func TestVariantsAgree(t *testing.T) {
+	keys := benchtools.Strings(1000, 8, 1)
+	if !maps.Equal(FillMap(keys), FillMapPrealloc(keys)) {
+		t.Error("FillMap and FillMapPrealloc differ")
+	}
+	if !slices.Equal(AppendGrow(1000), AppendPrealloc(1000)) {
+		t.Error("AppendGrow and AppendPrealloc differ")
+	}
+	if ConcatPlus(keys) != ConcatBuilder(keys) {
+		t.Error("ConcatPlus and ConcatBuilder differ")
+	}
+}
+
+// BenchmarkMap has a sub-benchmark per variant and size:
+// BenchmarkMap/grow/n=1K, BenchmarkMap/prealloc/n=64K...
+func BenchmarkMap(b *testing.B) {
+	for _, v := range []struct {
+		name string
+		fill func([]string) map[string]int
+	}{
+		{"grow", FillMap},
+		{"prealloc", FillMapPrealloc},
+	} {
+		b.Run(v.name, func(b *testing.B) {
+			benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+				keys := benchtools.Strings(n, 8, 1)
+				b.ResetTimer() // the keys are setup, not what is measured
+				for i := 0; i < b.N; i++ {
+					mapSink = v.fill(keys)
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkAppend(b *testing.B) {
+	for _, v := range []struct {
+		name string
+		fill func(int) []int
+	}{
+		{"grow", AppendGrow},
+		{"prealloc", AppendPrealloc},
+	} {
+		b.Run(v.name, func(b *testing.B) {
+			benchtools.Sized(b, sizes, func(b *testing.B, n int) {
+				for i := 0; i < b.N; i++ {
+					sliceSink = v.fill(n)
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkConcat stops at 1K parts: += on 64K parts copies some 16 GB per
+// call, and a benchmark needs many calls.
+func BenchmarkConcat(b *testing.B) {
+	for _, v := range []struct {
+		name   string
+		concat func([]string) string
+	}{
+		{"plus", ConcatPlus},
+		{"builder", ConcatBuilder},
+	} {
+		b.Run(v.name, func(b *testing.B) {
+			benchtools.Sized(b, sizes[:2], func(b *testing.B, n int) {
+				parts := benchtools.Strings(n, 8, 1)
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					stringSink = v.concat(parts)
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+/*
+How much does the buffer of a channel matter? Producers send ints, consumers
+receive them, and the benchmark counts items per second for an unbuffered
+channel and buffers of 1, 16, 256 and 4096, with one or several producers and
+consumers.
+
+An unbuffered send is a rendezvous: the sender waits for a receiver, and
+each item costs a handoff between two goroutines, often a trip through the
+scheduler. A buffer lets both sides run ahead of each other, and a goroutine
+can send or receive a whole run of items without being parked. Past the
+point where neither side waits for the other, more buffer only costs
+memory, and can hide a consumer too slow to keep up: a buffer absorbs
+bursts, it does not make a consumer faster.
+
+	go run ./golang_program_design_2024/12.performance/chanbuf
+	go run ./golang_program_design_2024/12.performance/chanbuf -work 200 -test.benchtime 200ms
+
+-work adds some work per item on the consumer side, in iterations of a
+loop: with real work, the channel is a smaller part of the cost and the
+buffer size matters less. The numbers also change with GOMAXPROCS: on one
+CPU the goroutines take turns, on several they contend for the channel
+lock, try both:
+
+	GOMAXPROCS=1 go run ./golang_program_design_2024/12.performance/chanbuf
+*/
+
+var buffers = []int{0, 1, 16, 256, 4096}
+
+type ratio struct{ producers, consumers int }
+
+func (r ratio) String() string { return fmt.Sprintf("%dP:%dC", r.producers, r.consumers) }
+
+var ratios = []ratio{{1, 1}, {4, 1}, {1, 4}, {4, 4}}
+
+// run moves n items from the producers to the consumers through a channel
+// of the given buffer.
+func run(n, buffer int, r ratio, work int) {
+	ch := make(chan int, buffer)
+	var prod, cons sync.WaitGroup
+	for p := 0; p < r.producers; p++ {
+		count := n / r.producers
+		if p == 0 {
+			count += n % r.producers
+		}
+		prod.Add(1)
+		go func() {
+			defer prod.Done()
+			for i := 0; i < count; i++ {
+				ch <- i
+			}
+		}()
+	}
+	for c := 0; c < r.consumers; c++ {
+		cons.Add(1)
+		go func() {
+			defer cons.Done()
+			sum := 0
+			for v := range ch {
+				for j := 0; j < work; j++ {
+					sum += v ^ j
+				}
+			}
+			sink(sum)
+		}()
+	}
+	prod.Wait()
+	close(ch)
+	cons.Wait()
+}
+
+var sinkMu sync.Mutex
+var sinkSum int
+
+func sink(v int) {
+	sinkMu.Lock()
+	sinkSum += v
+	sinkMu.Unlock()
+}
+
+// throughput is the items per second of each buffer size, by ratio.
+type throughput map[ratio]map[int]float64
+
+func main() {
+	work := flag.Int("work", 0, "iterations of work per item on the consumer side")
+	benchtools.Init()
+	flag.Parse()
+
+	fmt.Printf("items per second, GOMAXPROCS %d, work %d:\n", runtime.GOMAXPROCS(0), *work)
+	tp := throughput{}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "\t")
+	for _, b := range buffers {
+		fmt.Fprintf(tw, "buf %d\t", b)
+	}
+	fmt.Fprintln(tw)
+	for _, r := range ratios {
+		tp[r] = map[int]float64{}
+		fmt.Fprintf(tw, "%s\t", r)
+		for _, b := range buffers {
+			res := benchtools.Run(r.String(), func(tb *testing.B) {
+				run(tb.N, b, r, *work)
+			})
+			perSec := 1e9 / res.NsPerOp
+			tp[r][b] = perSec
+			fmt.Fprintf(tw, "%.1fM\t", perSec/1e6)
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+	fmt.Println()
+	tp.summary()
+}
+
+// summary prints, per ratio, the best buffer, its gain over unbuffered, and
+// the smallest buffer within 10% of the best: the size worth using, since
+// the rest of the buffer would only hold memory.
+func (tp throughput) summary() {
+	fmt.Println("summary:")
+	for _, r := range ratios {
+		t := tp[r]
+		best := buffers[0]
+		for _, b := range buffers {
+			if t[b] > t[best] {
+				best = b
+			}
+		}
+		enough := best
+		for _, b := range buffers {
+			if t[b] >= t[best]*0.9 {
+				enough = b
+				break
+			}
+		}
+		fmt.Printf("  %s  best buf %-4d %.1fx unbuffered; buf %d is within 10%% of it\n",
+			r, best, t[best]/t[0], enough)
+	}
+}
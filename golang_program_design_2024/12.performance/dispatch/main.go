@@ -0,0 +1,189 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+/*
+The same algorithm written three ways: for concrete types, for interface
+values, and with type parameters. What each costs at run time:
+
+	concrete    the compiler knows every type: calls are direct, often
+	            inlined, values stay unboxed.
+	interface   each value is boxed (a type word and a pointer to a copy,
+	            allocated unless the value fits in a pointer or is a small
+	            constant), each method call goes through the itab.
+	generic     Go compiles one copy per GC shape, not per type: all
+	            pointer types share one, and the copy gets a dictionary
+	            describing the actual type. Operators on basic types are
+	            as fast as concrete code; methods called on a type
+	            parameter go through the dictionary, close to an
+	            interface call, but the values stay unboxed.
+
+Two algorithms: the max of a slice of ints, with operators only, and the
+total area of a slice of shapes, with a method call per element. The
+allocations come from building the []Shape, measured apart.
+
+	go run ./golang_program_design_2024/12.performance/dispatch
+	go run ./golang_program_design_2024/12.performance/dispatch -test.benchtime 200ms
+
+All the functions are //go:noinline: inlined into the benchmark, the
+interface calls could be devirtualized and the comparison would measure
+this file, not the dispatch.
+*/
+
+// --- max, with operators ---
+
+//go:noinline
+func maxInts(s []int) int {
+	m := s[0]
+	for _, v := range s[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// Lesser is what an interface needs to offer for max: the operator > is not
+// available on interface values.
+type Lesser interface {
+	Less(Lesser) bool
+}
+
+type Int int
+
+func (a Int) Less(b Lesser) bool { return a < b.(Int) }
+
+//go:noinline
+func maxIface(s []Lesser) Lesser {
+	m := s[0]
+	for _, v := range s[1:] {
+		if m.Less(v) {
+			m = v
+		}
+	}
+	return m
+}
+
+//go:noinline
+func maxGeneric[T cmp.Ordered](s []T) T {
+	m := s[0]
+	for _, v := range s[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// --- total area, with a method ---
+
+type Shape interface {
+	Area() float64
+}
+
+type Rect struct{ W, H float64 }
+
+func (r Rect) Area() float64 { return r.W * r.H }
+
+//go:noinline
+func areaRects(s []Rect) float64 {
+	t := 0.0
+	for _, r := range s {
+		t += r.Area()
+	}
+	return t
+}
+
+//go:noinline
+func areaIface(s []Shape) float64 {
+	t := 0.0
+	for _, sh := range s {
+		t += sh.Area()
+	}
+	return t
+}
+
+//go:noinline
+func areaGeneric[S Shape](s []S) float64 {
+	t := 0.0
+	for _, sh := range s {
+		t += sh.Area()
+	}
+	return t
+}
+
+// --- boxing ---
+
+//go:noinline
+func boxInts(s []int) []Lesser {
+	out := make([]Lesser, len(s))
+	for i, v := range s {
+		out[i] = Int(v)
+	}
+	return out
+}
+
+//go:noinline
+func boxRects(s []Rect) []Shape {
+	out := make([]Shape, len(s))
+	for i, r := range s {
+		out[i] = r
+	}
+	return out
+}
+
+const n = 1000
+
+var (
+	sinkInt    int
+	sinkFloat  float64
+	sinkLesser Lesser
+	sinkShapes []Shape
+	sinkBoxed  []Lesser
+)
+
+func main() {
+	benchtools.Init()
+	flag.Parse()
+
+	ints := make([]int, n)
+	rects := make([]Rect, n)
+	for i := range ints {
+		ints[i] = (i * 7919) % n
+		rects[i] = Rect{float64(i % 10), 2}
+	}
+	lessers := boxInts(ints)
+	shapes := boxRects(rects)
+
+	fmt.Printf("one call over %d elements:\n", n)
+	t := benchtools.NewTable(os.Stdout)
+	t.Add(benchtools.Loop("max: concrete []int", func() { sinkInt = maxInts(ints) }))
+	t.Add(benchtools.Loop("max: generic []int", func() { sinkInt = maxGeneric(ints) }))
+	t.Add(benchtools.Loop("max: interface []Lesser", func() { sinkLesser = maxIface(lessers) }))
+	t.Flush()
+	fmt.Println()
+	t.Add(benchtools.Loop("area: concrete []Rect", func() { sinkFloat = areaRects(rects) }))
+	t.Add(benchtools.Loop("area: generic []Rect", func() { sinkFloat = areaGeneric(rects) }))
+	t.Add(benchtools.Loop("area: generic []Shape", func() { sinkFloat = areaGeneric(shapes) }))
+	t.Add(benchtools.Loop("area: interface []Shape", func() { sinkFloat = areaIface(shapes) }))
+	t.Flush()
+	fmt.Println()
+	t.Add(benchtools.Loop("box: []int to []Lesser", func() { sinkBoxed = boxInts(ints) }))
+	t.Add(benchtools.Loop("box: []Rect to []Shape", func() { sinkShapes = boxRects(rects) }))
+	t.Flush()
+
+	fmt.Println(`
+what to read in it: with operators, the generic max is the concrete one;
+with a method, the generic area is a dictionary call per element, near the
+interface one, and generic over []Shape is an interface call plus the
+dictionary. The interface versions also need their slice boxed first, an
+allocation per element: Int values under 256 come from a static table, a
+16 B Rect is always copied to the heap.`)
+}
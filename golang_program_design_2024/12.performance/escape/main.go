@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+/*
+Escape analysis is how the compiler decides where a value lives. A value
+that cannot outlive the function creating it goes on the stack, freed for
+free when the function returns. A value that may outlive it "escapes" and
+goes on the heap, allocated by the runtime and later freed by the GC.
+
+Nothing in the language says which: new(T), &T{} and make can all end up on
+the stack, and a plain variable can end up on the heap. The compiler tells
+its decisions with -gcflags=-m:
+
+	go build -gcflags=-m ./golang_program_design_2024/12.performance/escape 2>&1 | grep -v inlin
+
+Each pair below differs only in whether a value escapes; the comments
+quote what -m prints for it (with the Go 1.27 compiler, the exact wording
+moves a little between versions), and the benchmarks count the allocations:
+
+	go run ./golang_program_design_2024/12.performance/escape
+
+The functions are marked //go:noinline. Inlining copies a function into its
+caller, and the analysis then runs on the caller: a pointer returned by an
+inlined function may not escape at all. Without the directive, the pairs
+would only show what happens in this file.
+*/
+
+type point struct{ X, Y, Z float64 }
+
+var (
+	sinkPoint *point
+	sinkAny   any
+	sinkFunc  func() int
+	sinkInt   int
+)
+
+// --- 1. returning a pointer ---
+
+// newPointValue returns a copy: p lives in the frame of the function, the
+// caller gets its own copy in its own frame. Nothing escapes, -m says
+// nothing.
+//
+//go:noinline
+func newPointValue(x float64) point {
+	p := point{x, x, x}
+	return p
+}
+
+// newPointPtr returns the address of p: p must outlive the function.
+//
+//	./main.go:60:2: moved to heap: p
+//
+//go:noinline
+func newPointPtr(x float64) *point {
+	p := point{x, x, x}
+	return &p
+}
+
+// --- 2. interface boxing ---
+
+// sumConcrete takes the struct itself.
+//
+//go:noinline
+func sumConcrete(p point) float64 { return p.X + p.Y + p.Z }
+
+// sumBoxed takes an interface. An interface holds a pointer to its value,
+// so a point passed as any is copied to the heap, unless the compiler can
+// prove the interface does not escape; storing it in a global defeats that.
+//
+//	./main.go:79:15: leaking param: v
+//	./main.go:156:65: point{...} escapes to heap
+//
+//go:noinline
+func sumBoxed(v any) float64 {
+	sinkAny = v
+	p := v.(point)
+	return p.X + p.Y + p.Z
+}
+
+// --- 3. closure capture ---
+
+// counterCalled captures n in a closure called on the spot: the closure is
+// inlined at both calls, n stays a plain local. -m only says:
+//
+//	./main.go:95:9: can inline counterCalled.func1
+//
+//go:noinline
+func counterCalled() int {
+	n := 0
+	inc := func() int { n++; return n }
+	inc()
+	return inc()
+}
+
+// counterReturned returns the closure: it outlives the function, and so
+// does n, captured by reference.
+//
+//	./main.go:108:2: moved to heap: n
+//	./main.go:109:9: func literal escapes to heap
+//
+//go:noinline
+func counterReturned() func() int {
+	n := 0
+	return func() int { n++; return n }
+}
+
+// --- 4. slices of unknown size ---
+
+// sumFixed makes a slice of constant size: the backing array can be sized
+// at compile time and put in the frame.
+//
+//	./main.go:121:11: make([]int, 64) does not escape
+//
+//go:noinline
+func sumFixed() int {
+	s := make([]int, 64)
+	for i := range s {
+		s[i] = i
+	}
+	return s[len(s)-1]
+}
+
+// sumSized makes the same slice with a size known at run time only. It
+// does not escape, -m says so, and still it is allocated on the heap: the
+// size of a frame is fixed at compile time. Since Go 1.25 the compiler
+// reserves a small buffer of 32 bytes in the frame for such a make, used
+// when n turns out small enough; 64 ints are not.
+//
+//	./main.go:138:11: make([]int, n) does not escape
+//
+//go:noinline
+func sumSized(n int) int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i
+	}
+	return s[len(s)-1]
+}
+
+func main() {
+	fmt.Println("allocations per call, measured by testing.Benchmark:")
+	pairs := []struct {
+		name       string
+		stay, leak func()
+	}{
+		{"return a value / a pointer",
+			func() { sinkInt += int(newPointValue(1).X) },
+			func() { sinkPoint = newPointPtr(1) }},
+		{"concrete param / interface param",
+			func() { x := float64(sinkInt); sinkInt += int(sumConcrete(point{x, 2, 3})) },
+			func() { x := float64(sinkInt); sinkInt += int(sumBoxed(point{x, 2, 3})) }},
+		{"closure called / returned",
+			func() { sinkInt += counterCalled() },
+			func() { sinkFunc = counterReturned() }},
+		{"make([]int, 64) / make([]int, n)",
+			func() { sinkInt += sumFixed() },
+			func() { sinkInt += sumSized(64) }},
+		{"make([]int, n) for n = 4 / n = 64",
+			func() { sinkInt += sumSized(4) },
+			func() { sinkInt += sumSized(64) }},
+	}
+	for _, p := range pairs {
+		stay, leak := benchtools.Loop("stays", p.stay), benchtools.Loop("escapes", p.leak)
+		fmt.Printf("  %-36s %d allocs %3d B %7s   vs   %d allocs %3d B %7s\n", p.name,
+			stay.AllocsPerOp, stay.BytesPerOp, benchtools.FormatNs(stay.NsPerOp),
+			leak.AllocsPerOp, leak.BytesPerOp, benchtools.FormatNs(leak.NsPerOp))
+	}
+
+	// and a surprise of boxing: an integer from 0 to 255 needs no
+	// allocation, the runtime has a static array of them; -m still says
+	// 42 escapes to heap.
+	small := benchtools.Loop("any(42)", func() { sinkAny = 42 })
+	big := benchtools.Loop("any(1000 + a variable)", func() { sinkAny = sinkInt + 1000 })
+	fmt.Printf("\n  %s: %d allocs, %s: %d allocs\n", small.Name, small.AllocsPerOp, big.Name, big.AllocsPerOp)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/memdash"
+)
+
+/*
+The GC has two knobs.
+
+GOGC (debug.SetGCPercent) sets how much the heap may grow over the live
+data before the next collection: at 100, a program keeping 64 MB live
+collects when the heap reaches 128 MB. Higher means fewer collections and
+more memory, lower the opposite. The live data itself is what it is: GOGC
+only trades CPU spent collecting for memory waiting to be collected.
+
+GOMEMLIMIT (debug.SetMemoryLimit, Go 1.19) is a soft limit on the memory of
+the runtime: when the heap gets near it, the GC collects whatever GOGC says.
+With GOGC=off it is the only trigger: no collection at all until the limit,
+the most memory-frugal way to use a container's memory without risking its
+OOM killer. Too tight, under the live data plus some room, and the GC runs
+back to back; the runtime caps it at about half the CPU, then lets the
+limit be exceeded rather than stall the program.
+
+The program below allocates at a steady rate while keeping a fixed amount
+of live data, under a few settings, and samples runtime.MemStats each
+second: the heap, the collections, their pauses, the CPU they take.
+
+	go run ./golang_program_design_2024/12.performance/gctuning
+	go run ./golang_program_design_2024/12.performance/gctuning -live 128 -rate 512 -duration 5s -only limit
+	go run ./golang_program_design_2024/12.performance/gctuning -duration 20s -dash localhost:6061
+
+The same settings are environment variables, for a program not written to
+call debug: GOGC=400 GOMEMLIMIT=512MiB ./server.
+*/
+
+type setting struct {
+	name  string
+	gogc  int   // -1 is off
+	limit int64 // bytes, math.MaxInt64 is none
+	about string
+}
+
+func main() {
+	liveMB := flag.Int("live", 64, "live data kept by the workload, in MB")
+	rateMB := flag.Int("rate", 256, "allocation rate, in MB/s")
+	duration := flag.Duration("duration", 3*time.Second, "length of each experiment")
+	only := flag.String("only", "", "run only the experiments whose name contains this")
+	dash := flag.String("dash", "", "serve the live memory dashboard on this address, e.g. localhost:6061")
+	flag.Parse()
+
+	if *dash != "" {
+		stop, err := memdash.Serve(*dash, time.Second)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer stop()
+	}
+
+	live := int64(*liveMB) << 20
+	settings := []setting{
+		{"default", 100, math.MaxInt64, "GOGC=100: collect when the heap doubles the live data"},
+		{"gogc50", 50, math.MaxInt64, "GOGC=50: half the room, twice the collections"},
+		{"gogc400", 400, math.MaxInt64, "GOGC=400: 4x the room, a quarter of the collections"},
+		{"off+limit", -1, live * 2, "GOGC=off, GOMEMLIMIT=2x live: collect only near the limit"},
+		{"limit", 100, live * 11 / 10, "GOGC=100, GOMEMLIMIT=1.1x live: the limit wins, collections back to back"},
+	}
+
+	fmt.Printf("workload: %d MB live, allocating %d MB/s, %v per experiment, GOMAXPROCS %d\n",
+		*liveMB, *rateMB, *duration, runtime.GOMAXPROCS(0))
+	var results []result
+	for _, s := range settings {
+		if *only != "" && !strings.Contains(s.name, *only) {
+			continue
+		}
+		results = append(results, experiment(s, live, int64(*rateMB)<<20, *duration))
+	}
+	debug.SetGCPercent(100)
+	debug.SetMemoryLimit(math.MaxInt64)
+
+	fmt.Println("\nsummary:")
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "setting\tGC/s\tavg pause\tmax pause\tGC CPU\tpeak heap\tpeak held\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%.1f\t%v\t%v\t%.1f%%\t%s\t%s\t\n", r.name, r.gcPerSec,
+			r.avgPause.Round(time.Microsecond), r.maxPause.Round(time.Microsecond), r.gcCPU*100, mb(r.peakHeap), mb(r.peakHeld))
+	}
+	tw.Flush()
+}
+
+type result struct {
+	name               string
+	gcPerSec           float64
+	avgPause, maxPause time.Duration
+	gcCPU              float64 // fraction of the CPU time of the process
+	peakHeap, peakHeld uint64
+}
+
+func mb(b uint64) string { return fmt.Sprintf("%.0f MB", float64(b)/(1<<20)) }
+
+func experiment(s setting, live, rate int64, d time.Duration) result {
+	fmt.Printf("\n== %s: %s\n", s.name, s.about)
+	// start each experiment from the same clean state.
+	debug.SetGCPercent(100)
+	debug.SetMemoryLimit(math.MaxInt64)
+	// collects and gives the free memory back to the OS, so the memory
+	// mapped by the previous experiment does not count in this one.
+	debug.FreeOSMemory()
+
+	w := newWorkload(live)
+	debug.SetGCPercent(s.gogc)
+	debug.SetMemoryLimit(s.limit)
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	startGC := ms.NumGC
+	lastGC := ms.NumGC
+	cpu0 := readCPU()
+	r := result{name: s.name}
+	var pauses []time.Duration
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		w.run(rate, stop)
+		close(done)
+	}()
+	start := time.Now()
+	tick := time.NewTicker(time.Second)
+	for sec := 1; time.Since(start) < d; sec++ {
+		<-tick.C
+		runtime.ReadMemStats(&ms)
+		// PauseNs is a ring of the last 256 pauses, the one of GC n at
+		// (n+255) % 256.
+		var secPauses []time.Duration
+		for n := lastGC + 1; n <= ms.NumGC && n+256 > ms.NumGC; n++ {
+			secPauses = append(secPauses, time.Duration(ms.PauseNs[(n+255)%256]))
+		}
+		pauses = append(pauses, secPauses...)
+		avg, max := stats(secPauses)
+		// the heap memory the process holds: mapped and not given back.
+		held := ms.HeapSys - ms.HeapReleased
+		fmt.Printf("  %2ds  heap %7s  next GC at %7s  held %7s  GCs %3d (+%d)  pause avg %6v max %6v\n",
+			sec, mb(ms.HeapAlloc), mb(ms.NextGC), mb(held), ms.NumGC-startGC, ms.NumGC-lastGC,
+			avg.Round(time.Microsecond), max.Round(time.Microsecond))
+		lastGC = ms.NumGC
+		r.peakHeap = max64(r.peakHeap, ms.HeapAlloc)
+		r.peakHeld = max64(r.peakHeld, held)
+	}
+	tick.Stop()
+	close(stop)
+	<-done
+	elapsed := time.Since(start)
+
+	cpu1 := readCPU()
+	r.gcPerSec = float64(lastGC-startGC) / elapsed.Seconds()
+	r.avgPause, r.maxPause = stats(pauses)
+	if total := cpu1.total - cpu0.total; total > 0 {
+		r.gcCPU = (cpu1.gc - cpu0.gc) / total
+	}
+	fmt.Printf("  allocated %s in %v\n", mb(uint64(w.allocated)), elapsed.Round(time.Millisecond))
+	return r
+}
+
+func stats(ds []time.Duration) (avg, max time.Duration) {
+	for _, d := range ds {
+		avg += d
+		if d > max {
+			max = d
+		}
+	}
+	if len(ds) > 0 {
+		avg /= time.Duration(len(ds))
+	}
+	return avg, max
+}
+
+func max64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// cpu is the CPU time of the process, in total and in the GC, from
+// runtime/metrics: MemStats.GCCPUFraction counts since the start of the
+// program, not per experiment.
+type cpu struct{ total, gc float64 }
+
+func readCPU() cpu {
+	samples := []metrics.Sample{
+		{Name: "/cpu/classes/total:cpu-seconds"},
+		{Name: "/cpu/classes/gc/total:cpu-seconds"},
+	}
+	metrics.Read(samples)
+	return cpu{samples[0].Value.Float64(), samples[1].Value.Float64()}
+}
+
+// workload keeps live bytes in blocks of 64 KB and replaces them at a given
+// rate: the live data stays the same, everything replaced is garbage.
+type workload struct {
+	blocks    [][]byte
+	rng       *rand.Rand
+	allocated int64
+}
+
+const blockSize = 64 << 10
+
+func newWorkload(live int64) *workload {
+	w := &workload{blocks: make([][]byte, live/blockSize), rng: rand.New(rand.NewSource(1))}
+	for i := range w.blocks {
+		w.blocks[i] = make([]byte, blockSize)
+	}
+	return w
+}
+
+// run allocates rate bytes per second, in batches every 10ms, until stop.
+func (w *workload) run(rate int64, stop <-chan struct{}) {
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	perBatch := int(rate / 100 / blockSize)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick.C:
+		}
+		for i := 0; i < perBatch; i++ {
+			b := make([]byte, blockSize)
+			b[0] = byte(i) // touch it, as a real program would
+			w.blocks[w.rng.Intn(len(w.blocks))] = b
+			w.allocated += blockSize
+		}
+	}
+}
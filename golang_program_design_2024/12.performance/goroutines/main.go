@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+/*
+"Goroutines are cheap", in numbers.
+
+A goroutine is a G in the M:N scheduler (04.concurrent/goroutine.go): a
+small struct and a stack, run by one of the P's on one of the M's (OS
+threads). An OS thread reserves a stack of megabytes up front; a goroutine
+starts with 2 KB (sometimes more: the runtime averages the stack sizes it
+saw at each GC and starts new goroutines there) and grows it on demand.
+Growing means allocating a stack twice the size and copying the old one
+into it, pointers adjusted: a function prologue checks for room and calls
+runtime.morestack when there is none.
+
+Part 1 spawns idle goroutines, blocked on a channel, and reads MemStats
+before and after: StackInuse is the memory of the stacks, Sys all the
+memory the runtime took from the OS, and the difference divided by the
+count is the cost of one goroutine.
+
+Part 2 recurses inside a single goroutine, stops at the deepest point, and
+reads StackInuse there: the stack doubles as the recursion goes deeper.
+Running the same recursion a second time in the same goroutine is faster:
+the stack is already big (until a GC shrinks it back).
+
+	go run ./golang_program_design_2024/12.performance/goroutines
+	go run ./golang_program_design_2024/12.performance/goroutines -n 1000000
+*/
+
+func main() {
+	n := flag.Int("n", 100000, "idle goroutines to spawn")
+	flag.Parse()
+
+	spawn(*n)
+	fmt.Println()
+	stackGrowth()
+}
+
+func readMem() runtime.MemStats {
+	runtime.GC()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms
+}
+
+func kb(b float64) string { return fmt.Sprintf("%.2f KB", b/1024) }
+
+// spawn starts n goroutines blocked on a channel, measures them, then
+// releases them.
+func spawn(n int) {
+	fmt.Printf("== %d idle goroutines\n", n)
+	before := readMem()
+	g0 := runtime.NumGoroutine()
+
+	release := make(chan struct{})
+	var started, stopped sync.WaitGroup
+	started.Add(n)
+	stopped.Add(n)
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		go func() {
+			started.Done()
+			<-release
+			stopped.Done()
+		}()
+	}
+	started.Wait()
+	elapsed := time.Since(start)
+
+	after := readMem()
+	count := float64(runtime.NumGoroutine() - g0)
+	fmt.Printf("  spawned in %v, %v per goroutine\n", elapsed.Round(time.Millisecond), elapsed/time.Duration(n))
+	fmt.Printf("  stacks    +%6.1f MB   %s per goroutine\n",
+		float64(after.StackInuse-before.StackInuse)/(1<<20), kb(float64(after.StackInuse-before.StackInuse)/count))
+	fmt.Printf("  runtime   +%6.1f MB   %s per goroutine (stacks, G structs, scheduler)\n",
+		float64(after.Sys-before.Sys)/(1<<20), kb(float64(after.Sys-before.Sys)/count))
+
+	close(release)
+	stopped.Wait()
+	// a G is never freed, only reused: the memory of the stacks goes back,
+	// the G structs stay for the life of the process.
+	runtime.GC()
+	end := readMem()
+	fmt.Printf("  released: stacks back to %s over the start, %d goroutines left\n",
+		kb(float64(int64(end.StackInuse)-int64(before.StackInuse))), runtime.NumGoroutine())
+}
+
+// recurse calls itself depth times with a frame of over 128 bytes, then
+// calls atBottom.
+//
+//go:noinline
+func recurse(depth int, atBottom func()) int {
+	var frame [16]int // 128 B that must be on the stack: the frame grows with it
+	frame[depth%16] = depth
+	if depth == 0 {
+		atBottom()
+		return frame[0]
+	}
+	return recurse(depth-1, atBottom) + frame[depth%16]
+}
+
+func stackGrowth() {
+	fmt.Println("== stack growth, recursion in one goroutine, frames of 128 B and more")
+	fmt.Printf("  %8s  %12s  %12s  %12s\n", "depth", "stack", "1st run", "2nd run")
+	for _, depth := range []int{0, 10, 100, 1000, 10000, 100000} {
+		base := readMem().StackInuse
+		atBottom := make(chan struct{})
+		resume := make(chan struct{})
+		done := make(chan [2]time.Duration)
+		go func() {
+			var times [2]time.Duration
+			for run := 0; run < 2; run++ {
+				start := time.Now()
+				recurse(depth, func() {
+					if run == 0 {
+						// pause at the deepest point, for main to measure
+						// the stack; the pause is not counted.
+						t := time.Since(start)
+						atBottom <- struct{}{}
+						<-resume
+						start = time.Now().Add(-t)
+					}
+				})
+				times[run] = time.Since(start)
+			}
+			done <- times
+		}()
+		<-atBottom
+		var ms runtime.MemStats
+		runtime.ReadMemStats(&ms) // no GC here: it could shrink the stack
+		stack := int64(ms.StackInuse) - int64(base)
+		close(resume)
+		times := <-done
+		fmt.Printf("  %8d  %12s  %12v  %12v\n", depth, kb(float64(stack)), times[0], times[1])
+	}
+	fmt.Println("  (stack is the growth of StackInuse over the other goroutines; at small")
+	fmt.Println("  depths the 2 KB or 8 KB stack fits without growing, and rounds to ~0)")
+}
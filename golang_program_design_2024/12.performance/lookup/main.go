@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"slices"
+	"testing"
+	"text/tabwriter"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+/*
+Three ways to answer "is x in the set", and the cost of one lookup as the
+set grows:
+
+	linear scan     a loop over a slice, O(n), but sequential memory the CPU
+	                prefetches, and no hashing
+	binary search   a sorted slice, O(log n), branches the CPU cannot predict
+	map             O(1) on average, but each lookup hashes the key and
+	                follows a pointer or two
+
+Big-O says the map wins; the constant factors say not for small n. Where
+the crossover lies depends on the machine and the key type, so the program
+measures it, then recommends a structure for a few sizes from the numbers
+it just measured.
+
+	go run ./golang_program_design_2024/12.performance/lookup
+	go run ./golang_program_design_2024/12.performance/lookup -test.benchtime 100ms
+
+Lookup is not the only cost: a map takes more memory and a sorted slice must
+be kept sorted, so for a set that changes all the time the map still wins
+at sizes where the scan looks faster.
+*/
+
+var sizes = []int{4, 16, 64, 256, 1 << 10, 4 << 10, 64 << 10, 1 << 20}
+
+type strategy struct {
+	name  string
+	build func(keys []int) func(x int) bool
+}
+
+var strategies = []strategy{
+	{"linear", func(keys []int) func(int) bool {
+		s := slices.Clone(keys)
+		return func(x int) bool {
+			for _, k := range s {
+				if k == x {
+					return true
+				}
+			}
+			return false
+		}
+	}},
+	{"binary", func(keys []int) func(int) bool {
+		s := slices.Clone(keys)
+		slices.Sort(s)
+		return func(x int) bool {
+			_, ok := slices.BinarySearch(s, x)
+			return ok
+		}
+	}},
+	{"map", func(keys []int) func(int) bool {
+		m := make(map[int]struct{}, len(keys))
+		for _, k := range keys {
+			m[k] = struct{}{}
+		}
+		return func(x int) bool {
+			_, ok := m[x]
+			return ok
+		}
+	}},
+}
+
+// results holds the ns per lookup of each strategy, by size.
+type results map[int]map[string]float64
+
+var sink bool
+
+func main() {
+	benchtools.Init()
+	flag.Parse()
+
+	rng := rand.New(rand.NewSource(1))
+	res := results{}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "n\tlinear\tbinary\tmap\t")
+	// the sizes named as in the sub-benchmarks of go test: n=64K.
+	for _, n := range sizes {
+		keys := rng.Perm(4 * n)[:n]
+		// half the probes are in the set, half are not: a miss is the worst
+		// case of the scan.
+		probes := make([]int, 1024)
+		for i := range probes {
+			if i%2 == 0 {
+				probes[i] = keys[rng.Intn(n)]
+			} else {
+				probes[i] = 4*n + rng.Intn(n)
+			}
+		}
+		res[n] = map[string]float64{}
+		fmt.Fprintf(tw, "%s\t", benchtools.SizeName(n))
+		for _, s := range strategies {
+			contains := s.build(keys)
+			r := benchtools.Run(s.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					sink = contains(probes[i%len(probes)])
+				}
+			})
+			res[n][s.name] = r.NsPerOp
+			fmt.Fprintf(tw, "%s\t", benchtools.FormatNs(r.NsPerOp))
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+
+	fmt.Println("\nrecommended, from the numbers above:")
+	for _, n := range []int{3, 10, 50, 100, 500, 10000, 2000000} {
+		fmt.Printf("  n = %-8d %s\n", n, res.recommend(n))
+	}
+}
+
+// recommend returns the fastest strategy for n elements, from the
+// measurement at the nearest measured size, or map when another is not
+// clearly faster: a map is the simplest to keep up to date, the others must
+// win by more than 20% to be worth it.
+func (r results) recommend(n int) string {
+	nearest := sizes[0]
+	for _, s := range sizes {
+		if dist(s, n) < dist(nearest, n) {
+			nearest = s
+		}
+	}
+	best, bestNs := "map", r[nearest]["map"]
+	for _, s := range strategies {
+		if ns := r[nearest][s.name]; ns < bestNs/1.2 && ns < r[nearest][best] {
+			best = s.name
+		}
+	}
+	m := r[nearest]
+	return fmt.Sprintf("%-7s (at %s: linear %s, binary %s, map %s)", best, benchtools.SizeName(nearest),
+		benchtools.FormatNs(m["linear"]), benchtools.FormatNs(m["binary"]), benchtools.FormatNs(m["map"]))
+}
+
+// dist compares sizes by ratio, not difference: 100 is nearer to 64 than
+// to 256.
+func dist(a, b int) float64 {
+	if a > b {
+		return float64(a) / float64(b)
+	}
+	return float64(b) / float64(a)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/memdash"
+)
+
+/*
+internal/memdash draws the memory of a running program in the browser. This
+program gives it something to draw, a workload going through phases in a
+loop:
+
+	grow      keeps allocating and holding 1 MB blocks: the heap climbs,
+	          and the next-GC line climbs with it, at twice the live heap
+	churn     replaces the blocks with new ones: the heap saws between the
+	          live data and the next GC, the GC count climbs
+	fan out   starts 10000 goroutines waiting on a timer: the goroutine line
+	          jumps, the stacks grow by ~20 MB
+	release   drops everything: the heap falls at the next GC, sys stays up
+	          until the scavenger returns the memory to the OS, minutes later
+
+	go run ./golang_program_design_2024/12.performance/memdash
+	open http://localhost:6061/
+
+Any other program watches itself the same way, with two lines in main:
+
+	stop, err := memdash.Serve("localhost:6061", time.Second)
+	defer stop()
+*/
+
+func main() {
+	addr := flag.String("addr", "localhost:6061", "address of the dashboard")
+	phase := flag.Duration("phase", 10*time.Second, "length of each phase")
+	flag.Parse()
+
+	stop, err := memdash.Serve(*addr, time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer stop()
+	fmt.Printf("dashboard on http://%s/, Ctrl-C to stop\n", *addr)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	for ctx.Err() == nil {
+		workload(ctx, *phase)
+	}
+}
+
+var held [][]byte
+
+func workload(ctx context.Context, phase time.Duration) {
+	run := func(name string, every time.Duration, step func(i int)) {
+		fmt.Println(time.Now().Format("15:04:05"), name)
+		t := time.NewTicker(every)
+		defer t.Stop()
+		end := time.After(phase)
+		for i := 0; ; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case <-end:
+				return
+			case <-t.C:
+				step(i)
+			}
+		}
+	}
+
+	run("grow", 50*time.Millisecond, func(int) {
+		held = append(held, make([]byte, 1<<20))
+	})
+	run("churn", 10*time.Millisecond, func(i int) {
+		if len(held) > 0 {
+			held[i%len(held)] = make([]byte, 1<<20)
+		}
+	})
+	for i := 0; i < 10000; i++ {
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-time.After(phase):
+			}
+		}()
+	}
+	run("fan out", phase, func(int) {})
+	held = nil
+	run("release", phase, func(int) {})
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	_ "net/http/pprof" // registers /debug/pprof/ on http.DefaultServeMux
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/12.performance/memleak/profdiff"
+)
+
+/*
+A leak in Go is memory still referenced, so the GC cannot take it back. The
+three classic ways to keep something referenced by mistake:
+
+	a map used as a cache, growing with every request and never pruned;
+	a goroutine blocked forever on a channel nobody will read, holding
+	  its stack and everything it references;
+	a goroutine looping on a ticker, started per request and never stopped.
+
+The workflow to find them does not need to know where to look:
+
+ 1. take a heap profile and a goroutine profile, after a GC;
+ 2. let the program run;
+ 3. take them again, diff them: what grew points at the leak, by the
+    line that allocated the memory or where the goroutines are parked.
+
+The program below leaks in the three ways while serving fake requests, and
+runs the workflow on itself. The same profiles are served by net/http/pprof
+and written to files, to be compared by hand:
+
+	go run ./golang_program_design_2024/12.performance/memleak -duration 5s -serve :6060
+	go tool pprof -diff_base heap-before.pb.gz heap-after.pb.gz
+	curl 'localhost:6060/debug/pprof/goroutine?debug=1' | head
+
+	go run ./golang_program_design_2024/12.performance/memleak -diff heap-before.pb.gz heap-after.pb.gz
+*/
+
+func main() {
+	duration := flag.Duration("duration", 3*time.Second, "how long to run the leaking workload")
+	serve := flag.String("serve", "", "keep serving /debug/pprof/ on this address after the report")
+	diff := flag.Bool("diff", false, "diff the two heap profiles given as arguments and exit")
+	flag.Parse()
+
+	if *diff {
+		if flag.NArg() != 2 {
+			log.Fatal("usage: memleak -diff before.pb.gz after.pb.gz")
+		}
+		if err := diffFiles(flag.Arg(0), flag.Arg(1)); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *serve != "" {
+		go func() { log.Println(http.ListenAndServe(*serve, nil)) }()
+	}
+	hunt(*duration)
+	if *serve != "" {
+		fmt.Printf("\nserving http://%s/debug/pprof/, Ctrl-C to stop\n", *serve)
+		select {}
+	}
+}
+
+// --- the leaks ---
+
+// cache keeps the response of each request by request id. The ids never
+// repeat, nothing is ever evicted: it only grows.
+var cache = struct {
+	sync.Mutex
+	m map[string][]byte
+}{m: make(map[string][]byte)}
+
+func cacheResponse(id string) {
+	resp := make([]byte, 1024)
+	cache.Lock()
+	cache.m[id] = resp
+	cache.Unlock()
+}
+
+// lookupWithTimeout asks a slow backend and gives up after a while. The
+// goroutine answering sends on an unbuffered channel: when the caller has
+// given up, nobody receives, and the goroutine waits forever with its
+// buffer. A buffer of 1 would let it finish.
+func lookupWithTimeout(timeout time.Duration) ([]byte, error) {
+	result := make(chan []byte)
+	go func() {
+		buf := make([]byte, 4096)
+		time.Sleep(2 * timeout) // the slow backend
+		result <- buf
+	}()
+	select {
+	case b := <-result:
+		return b, nil
+	case <-time.After(timeout):
+		return nil, errors.New("timeout")
+	}
+}
+
+// subscribe polls for updates on a ticker. Nothing ever stops it: no
+// context, no Stop, no return. Each subscription keeps a goroutine, a
+// ticker and its state for the life of the process.
+func subscribe(topic string) {
+	state := make([]byte, 2048)
+	go func() {
+		ticker := time.NewTicker(50 * time.Millisecond)
+		for range ticker.C {
+			state[0]++
+		}
+	}()
+}
+
+// handle is a fake request, leaking a little in each way.
+func handle(i int) {
+	cacheResponse(fmt.Sprint("req-", i))
+	if i%10 == 0 {
+		lookupWithTimeout(time.Millisecond)
+	}
+	if i%20 == 0 {
+		subscribe(fmt.Sprint("topic-", i))
+	}
+}
+
+// --- the hunt ---
+
+// snapshot returns the heap and goroutine profiles, after a GC: the heap
+// profile is as of the last GC, and without one the garbage of the
+// workload would pass for a leak.
+func snapshot() (heap, goroutines []byte) {
+	runtime.GC()
+	var h, g bytes.Buffer
+	pprof.Lookup("heap").WriteTo(&h, 0)
+	pprof.Lookup("goroutine").WriteTo(&g, 0)
+	return h.Bytes(), g.Bytes()
+}
+
+func hunt(d time.Duration) {
+	// a warm up, so the first snapshot has the steady state and not the
+	// start of the program.
+	for i := 0; i < 100; i++ {
+		handle(i)
+	}
+	heap0, gr0 := snapshot()
+	os.WriteFile("heap-before.pb.gz", heap0, 0o644)
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	fmt.Printf("before: heap %.1f MB, %d goroutines\n", float64(ms.HeapAlloc)/(1<<20), runtime.NumGoroutine())
+
+	fmt.Printf("serving fake requests for %v...\n", d)
+	n := 100
+	for deadline := time.Now().Add(d); time.Now().Before(deadline); n++ {
+		handle(n)
+		time.Sleep(100 * time.Microsecond)
+	}
+
+	heap1, gr1 := snapshot()
+	os.WriteFile("heap-after.pb.gz", heap1, 0o644)
+	runtime.ReadMemStats(&ms)
+	fmt.Printf("after %d requests: heap %.1f MB, %d goroutines\n\n", n, float64(ms.HeapAlloc)/(1<<20), runtime.NumGoroutine())
+
+	report("heap growth by allocation site (inuse_space)", heap0, heap1, "inuse_space", func(v int64) string {
+		return fmt.Sprintf("%8.1f KB", float64(v)/1024)
+	})
+	report("goroutine growth by where they are parked", gr0, gr1, "goroutine", func(v int64) string {
+		return fmt.Sprintf("%8d", v)
+	})
+	fmt.Println("profiles written to heap-before.pb.gz and heap-after.pb.gz")
+}
+
+func report(title string, before, after []byte, sampleType string, format func(int64) string) {
+	p0, err := profdiff.ParseBytes(before)
+	if err != nil {
+		log.Fatal(err)
+	}
+	p1, err := profdiff.ParseBytes(after)
+	if err != nil {
+		log.Fatal(err)
+	}
+	deltas, err := profdiff.Diff(p0, p1, sampleType)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(title + ":")
+	for i, d := range deltas {
+		if i == 5 || d.Growth() <= 0 {
+			break
+		}
+		fmt.Printf("  +%s  %s\n", format(d.Growth()), d.Site)
+	}
+	fmt.Println()
+}
+
+func diffFiles(before, after string) error {
+	var ps [2]*profdiff.Profile
+	for i, name := range []string{before, after} {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		ps[i], err = profdiff.Parse(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	deltas, err := profdiff.Diff(ps[0], ps[1], "inuse_space")
+	if err != nil {
+		return err
+	}
+	for _, d := range deltas {
+		fmt.Printf("%+12d B  %s\n", d.Growth(), d.Site)
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+// Package profdiff reads pprof profiles and diffs two of them by site, to
+// point at what grew between two snapshots: the heap profiles of a leaking
+// program taken a minute apart show where the leaked memory is allocated,
+// its goroutine profiles where the leaked goroutines are blocked.
+//
+// go tool pprof -diff_base does the same interactively; this does it in
+// code, with no dependency, to be run by the program itself or a test.
+//
+// A profile is a gzipped protocol buffer (profile.proto in
+// github.com/google/pprof). Only the few fields needed are decoded, by hand,
+// which is also a small lesson in the protobuf wire format.
+package profdiff
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Frame is a line of a function of a stack.
+type Frame struct {
+	Func, File string
+	Line       int64
+}
+
+func (f Frame) String() string { return fmt.Sprintf("%s %s:%d", f.Func, f.File, f.Line) }
+
+// Sample is a stack, leaf first, and its values, one per sample type.
+type Sample struct {
+	Stack  []Frame
+	Values []int64
+}
+
+// Profile is a decoded profile.
+type Profile struct {
+	Types   []string // the sample types, like inuse_space or goroutine
+	Samples []Sample
+}
+
+var errTruncated = errors.New("profdiff: truncated profile")
+
+// Parse reads a profile, gzipped or not.
+func Parse(r io.Reader) (*Profile, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		r = zr
+	} else {
+		r = br
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return decode(data)
+}
+
+// The protobuf wire format is a list of fields, each a key, the field
+// number and a wire type, and a value: a varint for type 0, a length and
+// that many bytes for type 2 (strings, nested messages, packed lists), 8 or
+// 4 bytes for types 1 and 5.
+type field struct {
+	num    int
+	wire   int
+	varint uint64
+	bytes  []byte
+}
+
+func fields(b []byte, fn func(f field) error) error {
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		if n <= 0 {
+			return errTruncated
+		}
+		b = b[n:]
+		f := field{num: int(key >> 3), wire: int(key & 7)}
+		switch f.wire {
+		case 0:
+			if f.varint, n = binary.Uvarint(b); n <= 0 {
+				return errTruncated
+			}
+			b = b[n:]
+		case 1, 5:
+			size := 8
+			if f.wire == 5 {
+				size = 4
+			}
+			if len(b) < size {
+				return errTruncated
+			}
+			b = b[size:]
+		case 2:
+			size, n := binary.Uvarint(b)
+			if n <= 0 || uint64(len(b)-n) < size {
+				return errTruncated
+			}
+			f.bytes, b = b[n:n+int(size)], b[n+int(size):]
+		default:
+			return fmt.Errorf("profdiff: wire type %d", f.wire)
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// varints returns the values of a repeated integer field: one value, or a
+// packed list of them.
+func (f field) varints(dst []uint64) ([]uint64, error) {
+	if f.wire == 0 {
+		return append(dst, f.varint), nil
+	}
+	for b := f.bytes; len(b) > 0; {
+		v, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		dst, b = append(dst, v), b[n:]
+	}
+	return dst, nil
+}
+
+// decode reads the fields of message Profile it needs: sample_type (1),
+// sample (2), location (4), function (5) and string_table (6). Strings are
+// indexes into the table, which can come last: references are resolved at
+// the end.
+func decode(data []byte) (*Profile, error) {
+	type line struct{ fn, line uint64 }
+	type function struct{ name, file uint64 }
+	type sample struct{ locs, values []uint64 }
+	var (
+		types     []uint64
+		samples   []sample
+		locations = map[uint64][]line{}
+		functions = map[uint64]function{}
+		strs      []string
+	)
+	err := fields(data, func(f field) error {
+		switch f.num {
+		case 1: // ValueType{type = 1, unit = 2}
+			return fields(f.bytes, func(g field) error {
+				if g.num == 1 {
+					types = append(types, g.varint)
+				}
+				return nil
+			})
+		case 2: // Sample{location_id = 1, value = 2}
+			var s sample
+			err := fields(f.bytes, func(g field) (err error) {
+				switch g.num {
+				case 1:
+					s.locs, err = g.varints(s.locs)
+				case 2:
+					s.values, err = g.varints(s.values)
+				}
+				return err
+			})
+			samples = append(samples, s)
+			return err
+		case 4: // Location{id = 1, line = 4 {function_id = 1, line = 2}}
+			var id uint64
+			var lines []line
+			err := fields(f.bytes, func(g field) error {
+				switch g.num {
+				case 1:
+					id = g.varint
+				case 4:
+					var l line
+					err := fields(g.bytes, func(h field) error {
+						switch h.num {
+						case 1:
+							l.fn = h.varint
+						case 2:
+							l.line = h.varint
+						}
+						return nil
+					})
+					lines = append(lines, l)
+					return err
+				}
+				return nil
+			})
+			locations[id] = lines
+			return err
+		case 5: // Function{id = 1, name = 2, filename = 4}
+			var id uint64
+			var fn function
+			err := fields(f.bytes, func(g field) error {
+				switch g.num {
+				case 1:
+					id = g.varint
+				case 2:
+					fn.name = g.varint
+				case 4:
+					fn.file = g.varint
+				}
+				return nil
+			})
+			functions[id] = fn
+			return err
+		case 6:
+			strs = append(strs, string(f.bytes))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	str := func(i uint64) string {
+		if i < uint64(len(strs)) {
+			return strs[i]
+		}
+		return "?"
+	}
+	p := &Profile{}
+	for _, t := range types {
+		p.Types = append(p.Types, str(t))
+	}
+	for _, s := range samples {
+		var out Sample
+		// a location holds several lines when calls were inlined, the
+		// innermost first, as in the stack.
+		for _, id := range s.locs {
+			for _, l := range locations[id] {
+				fn := functions[l.fn]
+				out.Stack = append(out.Stack, Frame{Func: str(fn.name), File: str(fn.file), Line: int64(l.line)})
+			}
+		}
+		for _, v := range s.values {
+			out.Values = append(out.Values, int64(v))
+		}
+		p.Samples = append(p.Samples, out)
+	}
+	return p, nil
+}
+
+// ParseBytes is Parse of a profile in memory.
+func ParseBytes(b []byte) (*Profile, error) { return Parse(bytes.NewReader(b)) }
+
+// Site is where a sample is attributed: the first frame of its stack
+// outside the runtime, the code that allocated, or where a goroutine is
+// parked.
+func Site(stack []Frame) Frame {
+	for _, f := range stack {
+		if !strings.HasPrefix(f.Func, "runtime.") && !strings.HasPrefix(f.Func, "internal/") {
+			return f
+		}
+	}
+	if len(stack) > 0 {
+		return stack[0]
+	}
+	return Frame{Func: "?"}
+}
+
+// Delta is the change of a value at a site between two profiles.
+type Delta struct {
+	Site          Frame
+	Before, After int64
+}
+
+func (d Delta) Growth() int64 { return d.After - d.Before }
+
+// Diff sums the value of sampleType by site in both profiles and returns
+// the sites which changed, the largest growth first.
+func Diff(before, after *Profile, sampleType string) ([]Delta, error) {
+	sum := func(p *Profile) (map[Frame]int64, error) {
+		i := -1
+		for j, t := range p.Types {
+			if t == sampleType {
+				i = j
+			}
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("profdiff: no sample type %s in %v", sampleType, p.Types)
+		}
+		m := map[Frame]int64{}
+		for _, s := range p.Samples {
+			m[Site(s.Stack)] += s.Values[i]
+		}
+		return m, nil
+	}
+	b, err := sum(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := sum(after)
+	if err != nil {
+		return nil, err
+	}
+	var out []Delta
+	for site, v := range a {
+		if v != b[site] {
+			out = append(out, Delta{site, b[site], v})
+		}
+	}
+	for site, v := range b {
+		if _, ok := a[site]; !ok {
+			out = append(out, Delta{site, v, 0})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Growth() > out[j].Growth() })
+	return out, nil
+}
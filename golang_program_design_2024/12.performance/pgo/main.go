@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+/*
+Profile-guided optimization (PGO, Go 1.21): the compiler reads a CPU
+profile of the program in production and optimizes where the time goes.
+Two things change with it:
+
+  - hot call sites get a much larger inlining budget: a function too big
+    to inline everywhere is inlined where the profile says it is hot;
+  - an interface call that the profile shows mostly reaching one concrete
+    type is devirtualized: if f is a gain, call gain.Apply directly, and
+    inline it; else make the indirect call.
+
+work.go has one of each: a chain of Filters called through an interface,
+mostly gains, and mix, over the inlining budget, called once per sample.
+
+The workflow:
+
+ 1. run a representative workload with a CPU profile, save it as
+    default.pgo in the directory of the main package;
+ 2. go build: with -pgo=auto, the default since Go 1.21, the build uses
+    default.pgo when it finds one;
+ 3. compare the build with and without it, on the same benchmark.
+
+-compare does the three, from the root of the repository:
+
+	go run ./golang_program_design_2024/12.performance/pgo -compare
+
+or by hand:
+
+	go build -pgo=off -o pgo-off ./golang_program_design_2024/12.performance/pgo
+	./pgo-off -profile golang_program_design_2024/12.performance/pgo/default.pgo
+	go build -o pgo-on ./golang_program_design_2024/12.performance/pgo
+	./pgo-off -bench; ./pgo-on -bench
+
+To see the decisions of the compiler:
+
+	go build -gcflags=-m=2 ./golang_program_design_2024/12.performance/pgo 2>&1 | grep -i pgo
+
+In real programs expect 2 to 14%: PGO does not change algorithms. Here
+the hot loop is nothing but the calls PGO removes, and the build with it
+takes about half the time. The profile is committed with the code in a
+real project, refreshed from production from time to time; here
+default.pgo is in .gitignore, collected on the machine that runs the
+lesson.
+*/
+
+// pkg is the package path of this program, from the root of the repository.
+const pkg = "./golang_program_design_2024/12.performance/pgo"
+
+func main() {
+	profile := flag.String("profile", "", "run the workload with a CPU profile written to this file")
+	duration := flag.Duration("duration", 5*time.Second, "length of the -profile workload")
+	bench := flag.Bool("bench", false, "benchmark process and print its ns/op")
+	compare := flag.Bool("compare", false, "profile, build with and without PGO, and compare the builds")
+	rounds := flag.Int("rounds", 5, "benchmark runs of each build for -compare")
+	benchtools.Init()
+	flag.Parse()
+
+	var err error
+	switch {
+	case *profile != "":
+		err = collect(*profile, *duration)
+	case *bench:
+		r := benchmark()
+		fmt.Printf("%.2f\n", r.NsPerOp)
+	case *compare:
+		err = compareBuilds(*rounds)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func benchmark() benchtools.Result {
+	chain := parseChain(chainDesc)
+	in, out := newSamples(4096), make([]float64, 4096)
+	return benchtools.Loop("process 4096 samples", func() { process(chain, in, out) })
+}
+
+// collect runs the workload, the same as the benchmark, for d under a CPU
+// profile: a real program would be profiled under its real load instead.
+func collect(path string, d time.Duration) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := pprof.StartCPUProfile(f); err != nil {
+		return err
+	}
+	chain := parseChain(chainDesc)
+	in, out := newSamples(4096), make([]float64, 4096)
+	for start := time.Now(); time.Since(start) < d; {
+		process(chain, in, out)
+	}
+	pprof.StopCPUProfile()
+	return f.Close()
+}
+
+func compareBuilds(rounds int) error {
+	if _, err := os.Stat(pkg); err != nil {
+		return fmt.Errorf("run -compare from the root of the repository: %w", err)
+	}
+	dir, err := os.MkdirTemp("", "pgo")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	off, on := filepath.Join(dir, "off"), filepath.Join(dir, "on")
+	profile := filepath.Join(pkg, "default.pgo")
+
+	fmt.Println("1. build without PGO")
+	if err := goCmd("build", "-pgo=off", "-o", off, pkg); err != nil {
+		return err
+	}
+	fmt.Println("2. profile the workload, 5s, to", profile)
+	if err := run(off, "-profile", profile); err != nil {
+		return err
+	}
+	fmt.Println("3. build with PGO")
+	if err := goCmd("build", "-pgo="+profile, "-o", on, pkg); err != nil {
+		return err
+	}
+	var decisions bytes.Buffer
+	cmd := exec.Command("go", "build", "-pgo="+profile, "-gcflags=-m=2", "-o", os.DevNull, pkg)
+	cmd.Stderr = &decisions
+	cmd.Run()
+	for _, line := range strings.Split(decisions.String(), "\n") {
+		if strings.Contains(line, "PGO devirtualizing") || strings.Contains(line, "hot-callsite") ||
+			strings.Contains(line, "inlining call to mix") {
+			fmt.Println("  ", line)
+		}
+	}
+
+	fmt.Printf("4. benchmark both, %d rounds each, alternating; best of each\n", rounds)
+	best := map[string]float64{}
+	for i := 0; i < rounds; i++ {
+		for _, b := range []string{off, on} {
+			ns, err := benchNs(b)
+			if err != nil {
+				return err
+			}
+			if v, ok := best[b]; !ok || ns < v {
+				best[b] = ns
+			}
+		}
+	}
+	t := benchtools.NewTable(os.Stdout)
+	t.Add(benchtools.Result{Name: "without PGO", NsPerOp: best[off]})
+	t.Add(benchtools.Result{Name: "with PGO", NsPerOp: best[on]})
+	t.Flush()
+	fmt.Printf("PGO: %+.1f%%\n", (best[on]/best[off]-1)*100)
+	return nil
+}
+
+func goCmd(args ...string) error { return run("go", args...) }
+
+func run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+func benchNs(bin string) (float64, error) {
+	out, err := exec.Command(bin, "-bench").Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+}
@@ -0,0 +1,105 @@
+package main
+
+import "math"
+
+// The hot code: an audio-style chain of filters applied to every sample.
+// The chain is built at run time from a description, so the compiler
+// cannot know which Filter each call reaches: every f.Apply is an indirect
+// call, and nothing behind it can be inlined. A profile shows that almost
+// every call reaches gain, and PGO uses that.
+
+// Filter transforms one sample. The implementations have pointer
+// receivers: a value receiver called through an interface goes through an
+// autogenerated wrapper, which the profile and PGO see instead of the
+// method.
+type Filter interface {
+	Apply(x float64) float64
+}
+
+type gain struct{ k float64 }
+
+func (g *gain) Apply(x float64) float64 { return x * g.k }
+
+type clip struct{ lo, hi float64 }
+
+func (c *clip) Apply(x float64) float64 { return min(max(x, c.lo), c.hi) }
+
+type drive struct{ amount float64 }
+
+func (d *drive) Apply(x float64) float64 { return math.Tanh(x * d.amount) }
+
+// parseChain builds a chain from a description like "gggcgd": g a gain,
+// c a clip, d a drive.
+func parseChain(desc string) []Filter {
+	var chain []Filter
+	for i, c := range desc {
+		switch c {
+		case 'g':
+			chain = append(chain, &gain{1 + float64(i%3)/100})
+		case 'c':
+			chain = append(chain, &clip{-2, 2})
+		case 'd':
+			chain = append(chain, &drive{0.5})
+		}
+	}
+	return chain
+}
+
+// process runs each sample through the chain and mixes it into the output:
+// the loop the CPU profile finds hot.
+func process(chain []Filter, in, out []float64) {
+	for i, x := range in {
+		for _, f := range chain {
+			x = f.Apply(x)
+		}
+		out[i] = mix(out[i], x, i)
+	}
+}
+
+// mix is too big for the default inlining budget (an inline cost of 80):
+// without a profile every sample pays a call. With one, a hot call site
+// gets a budget of 2000, and mix is inlined into process.
+func mix(acc, x float64, i int) float64 {
+	w := 0.5
+	switch i % 4 {
+	case 0:
+		w = 0.25
+	case 1:
+		w = 0.5
+	case 2:
+		w = 0.75
+	}
+	if x > 1 {
+		x = 1 + (x-1)*0.5
+	} else if x < -1 {
+		x = -1 + (x+1)*0.5
+	}
+	if acc > 4 || acc < -4 {
+		acc *= 0.5
+	}
+	// a soft knee on the mix, as a real mixer would have
+	y := acc*(1-w) + x*w
+	switch {
+	case y > 2:
+		y = 2 + (y-2)*0.25
+	case y < -2:
+		y = -2 + (y+2)*0.25
+	case y > 0.5:
+		y = 0.5 + (y-0.5)*0.9
+	case y < -0.5:
+		y = -0.5 + (y+0.5)*0.9
+	}
+	return y
+}
+
+// chainDesc is the chain of the workload, mostly gains as a real chain would
+// be mostly cheap stages.
+const chainDesc = "ggggggggggggc"
+
+func newSamples(n int) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = math.Sin(float64(i) / 50)
+	}
+	return s
+}
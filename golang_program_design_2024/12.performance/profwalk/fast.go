@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// entry is one parsed line of the access log.
+type entry struct {
+	path   string
+	status int
+	ms     int
+}
+
+type pathStats struct {
+	count, errors, total, max int
+}
+
+// add counts e in s, allocating s on the first entry of a path.
+func (s *pathStats) add(e entry) *pathStats {
+	if s == nil {
+		s = &pathStats{}
+	}
+	s.count++
+	if e.status >= 500 {
+		s.errors++
+	}
+	s.total += e.ms
+	if e.ms > s.max {
+		s.max = e.ms
+	}
+	return s
+}
+
+// lineRE is compiled once, when the program starts: fix 1.
+var lineRE = regexp.MustCompile(`^\S+ (GET|POST|PUT|DELETE) (\S+) (\d{3}) (\d+)ms$`)
+
+// reportFast is reportSlow with the three fixes; the bytes it writes are the
+// same, checked by main before measuring anything.
+func reportFast(in io.Reader, out io.Writer) error {
+	stats := map[string]*pathStats{}
+	// fix 2: a buffered reader, a syscall per 64 KB instead of per byte.
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		if e, ok := parseFast(sc.Text()); ok {
+			stats[e.path] = stats[e.path].add(e)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	paths := make([]string, 0, len(stats))
+	for p := range stats {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	// fix 2 again, and fix 3: a buffered writer, appended to with
+	// strconv.Append*, one write per 4 KB.
+	w := bufio.NewWriter(out)
+	var num []byte
+	for _, p := range paths {
+		s := stats[p]
+		w.WriteString(p)
+		for _, f := range [...]struct {
+			name string
+			v    int
+			unit string
+		}{{" requests=", s.count, ""}, {" errors=", s.errors, ""}, {" avg=", s.total / s.count, "ms"}, {" max=", s.max, "ms"}} {
+			w.WriteString(f.name)
+			num = strconv.AppendInt(num[:0], int64(f.v), 10)
+			w.Write(num)
+			w.WriteString(f.unit)
+		}
+		w.WriteByte('\n')
+	}
+	return w.Flush()
+}
+
+func parseFast(line string) (entry, bool) {
+	m := lineRE.FindStringSubmatch(line)
+	if m == nil {
+		return entry{}, false
+	}
+	status, _ := strconv.Atoi(m[3])
+	ms, _ := strconv.Atoi(m[4])
+	return entry{path: m[2], status: status, ms: ms}, true
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+/*
+An optimization led by a CPU profile, step by step. The program is a report
+on an access log: requests, errors, average and max latency per path. The
+first version, slow.go, is written the obvious way. Guessing where it is
+slow is how one ends up optimizing the wrong thing; profiling it is not:
+
+	go run ./golang_program_design_2024/12.performance/profwalk -cpuprofile slow.prof
+	go tool pprof -top -cum slow.prof | head -40
+
+The top of the profile (flat is time in the function itself, cum with what
+it calls) points at three places:
+
+	regexp.MustCompile            cum 48%   parseSlow compiles per line
+	syscall.read, os.(*File).Read cum 38%   readLineSlow reads byte by byte
+	runtime.concatstrings         cum  6%   the report built with +=
+
+and runtime.mallocgc (30%) spread over the first and the third, with the
+GC work their garbage causes. The small writes of the report do not show:
+2000 writes cost little next to a million reads. (Measured on the default
+50k-line log; the shares move with the input and the machine, the culprits
+do not.) fast.go fixes the three, and the writes with them. The rules of
+the walk:
+
+ 1. the output must not change: a test runs both versions on the same log
+    and compares the bytes, to be green before measuring anything;
+ 2. each bottleneck gets its own benchmark, slow and fixed side by side,
+    so each fix is measured on its own and not only the sum of them;
+ 3. profile again after the fixes: 85% is now FindStringSubmatch itself,
+    the next thing to replace (by strings.Fields, say) if it matters.
+
+	go test ./golang_program_design_2024/12.performance/profwalk
+	go run ./golang_program_design_2024/12.performance/profwalk
+	go run ./golang_program_design_2024/12.performance/profwalk -cpuprofile fast.prof -version fast
+*/
+
+func main() {
+	lines := flag.Int("lines", 50000, "lines of access log to generate")
+	paths := flag.Int("paths", 2000, "distinct paths in the log")
+	cpuprofile := flag.String("cpuprofile", "", "write a CPU profile of -version to this file, and exit")
+	version := flag.String("version", "slow", "version to profile: slow or fast")
+	benchtools.Init()
+	flag.Parse()
+
+	dir, err := os.MkdirTemp("", "profwalk")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	logPath := filepath.Join(dir, "access.log")
+	if err := os.WriteFile(logPath, genLog(*lines, *paths), 0o644); err != nil {
+		log.Fatal(err)
+	}
+
+	if *cpuprofile != "" {
+		report := reportSlow
+		if *version == "fast" {
+			report = reportFast
+		}
+		if err := profile(*cpuprofile, report, logPath, filepath.Join(dir, "report.txt")); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("profile of the %s version written to %s\n", *version, *cpuprofile)
+		return
+	}
+
+	benchmarks(logPath, dir)
+}
+
+// genLog returns n lines of access log, with 1 line in 200 malformed.
+func genLog(n, paths int) []byte {
+	rng := rand.New(rand.NewSource(1))
+	methods := []string{"GET", "GET", "GET", "POST", "PUT", "DELETE"}
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var b bytes.Buffer
+	for i := 0; i < n; i++ {
+		if i%200 == 199 {
+			b.WriteString("-- log rotated --\n")
+			continue
+		}
+		status := 200
+		switch r := rng.Intn(100); {
+		case r < 3:
+			status = 500
+		case r < 10:
+			status = 404
+		}
+		fmt.Fprintf(&b, "%s %s /api/items/%d %d %dms\n", start.Add(time.Duration(i)*time.Millisecond).Format(time.RFC3339),
+			methods[rng.Intn(len(methods))], rng.Intn(paths), status, 1+rng.Intn(500))
+	}
+	return b.Bytes()
+}
+
+// run runs report from the log file to a new file, the way the program
+// would be used: on real files, where the syscalls are real too.
+func run(report func(io.Reader, io.Writer) error, logPath, outPath string) error {
+	in, err := os.Open(logPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	if err := report(in, out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func profile(path string, report func(io.Reader, io.Writer) error, logPath, outPath string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := pprof.StartCPUProfile(f); err != nil {
+		return err
+	}
+	defer pprof.StopCPUProfile()
+	// a few seconds of samples at least: the profiler samples 100 times a
+	// second, a short run gives too few to trust.
+	for start := time.Now(); time.Since(start) < 3*time.Second; {
+		if err := run(report, logPath, outPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// benchmarks is rule 2: one pair per bottleneck, then the whole.
+func benchmarks(logPath, dir string) {
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	sample := lines[:1000]
+	report := strings.Repeat("/api/items/1234 requests=100 errors=3 avg=250ms max=499ms\n", 2000)
+
+	pairs := []struct {
+		name       string
+		slow, fast func()
+	}{
+		{"parse 1000 lines", func() {
+			for _, l := range sample {
+				parseSlow(l)
+			}
+		}, func() {
+			for _, l := range sample {
+				parseFast(l)
+			}
+		}},
+		{"read 1 MB of log", func() {
+			f, _ := os.Open(logPath)
+			defer f.Close()
+			r := io.LimitReader(f, 1<<20)
+			for {
+				if _, err := readLineSlow(r); err != nil {
+					break
+				}
+			}
+		}, func() {
+			f, _ := os.Open(logPath)
+			defer f.Close()
+			sc := bufio.NewScanner(io.LimitReader(f, 1<<20))
+			for sc.Scan() {
+			}
+		}},
+		{"build 2000 report lines", func() {
+			s := ""
+			for i := 0; i < 2000; i++ {
+				s += "/api/items/" + strconv.Itoa(i)
+				s += " requests=100 errors=3 avg=250ms max=499ms\n"
+			}
+		}, func() {
+			var b strings.Builder
+			for i := 0; i < 2000; i++ {
+				b.WriteString("/api/items/")
+				b.WriteString(strconv.Itoa(i))
+				b.WriteString(" requests=100 errors=3 avg=250ms max=499ms\n")
+			}
+			_ = b.String()
+		}},
+		{"write 2000 report lines", func() {
+			f, _ := os.Create(filepath.Join(dir, "w.txt"))
+			defer f.Close()
+			for _, l := range splitLines(report) {
+				fmt.Fprint(f, l)
+			}
+		}, func() {
+			f, _ := os.Create(filepath.Join(dir, "w.txt"))
+			defer f.Close()
+			w := bufio.NewWriter(f)
+			w.WriteString(report)
+			w.Flush()
+		}},
+		{"whole report", func() {
+			run(reportSlow, logPath, filepath.Join(dir, "slow.txt"))
+		}, func() {
+			run(reportFast, logPath, filepath.Join(dir, "fast.txt"))
+		}},
+	}
+
+	fmt.Println("slow vs fast, per bottleneck (testing.Benchmark):")
+	t := benchtools.NewTable(os.Stdout)
+	for i, p := range pairs {
+		if i > 0 {
+			fmt.Println()
+		}
+		t.Add(benchtools.Loop(p.name+", slow", p.slow))
+		t.Add(benchtools.Loop(p.name+", fast", p.fast))
+		t.Flush()
+	}
+}
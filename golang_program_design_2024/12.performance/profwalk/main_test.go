@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestSameOutput is rule 1: both versions write the same bytes.
+func TestSameOutput(t *testing.T) {
+	log := genLog(5000, 200)
+	var slow, fast bytes.Buffer
+	if err := reportSlow(bytes.NewReader(log), &slow); err != nil {
+		t.Fatal(err)
+	}
+	if err := reportFast(bytes.NewReader(log), &fast); err != nil {
+		t.Fatal(err)
+	}
+	if slow.Len() == 0 || !bytes.Equal(slow.Bytes(), fast.Bytes()) {
+		t.Errorf("the outputs differ: %d and %d bytes", slow.Len(), fast.Len())
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, line := range []string{
+		"2024-05-01T00:00:00Z GET /api/items/7 200 12ms",
+		"2024-05-01T00:00:00Z DELETE /a 500 1ms",
+		"-- log rotated --",
+		"2024-05-01T00:00:00Z PATCH /a 200 1ms",
+		"2024-05-01T00:00:00Z GET /a 200 12",
+		"",
+	} {
+		se, sok := parseSlow(line)
+		fe, fok := parseFast(line)
+		if se != fe || sok != fok {
+			t.Errorf("%q: slow %+v %v, fast %+v %v", line, se, sok, fe, fok)
+		}
+	}
+	if e, ok := parseFast("2024-05-01T00:00:00Z GET /api/items/7 404 12ms"); !ok || e != (entry{"/api/items/7", 404, 12}) {
+		t.Errorf("parseFast = %+v, %v", e, ok)
+	}
+}
+
+// TestReadLine reads byte by byte what a bufio.Scanner reads, the last
+// line without a newline included: it comes with io.EOF.
+func TestReadLine(t *testing.T) {
+	const in = "one\ntwo\n\nthree"
+	var slow []string
+	r := strings.NewReader(in)
+	for {
+		l, err := readLineSlow(r)
+		if err == nil || l != "" {
+			slow = append(slow, l)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var fast []string
+	for sc := bufio.NewScanner(strings.NewReader(in)); sc.Scan(); {
+		fast = append(fast, sc.Text())
+	}
+	if !slices.Equal(slow, fast) {
+		t.Errorf("readLineSlow %q, bufio.Scanner %q", slow, fast)
+	}
+}
+
+func TestSplitLines(t *testing.T) {
+	if got := splitLines("a\nbc\n\nd"); !slices.Equal(got, []string{"a\n", "bc\n", "\n", "d"}) {
+		t.Errorf("splitLines = %q", got)
+	}
+}
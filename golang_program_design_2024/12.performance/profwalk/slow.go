@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// reportSlow is the first version, written the obvious way. The profile
+// finds three problems in it, each one fixed in fast.go:
+//
+//  1. parseSlow compiles its regexp for every line;
+//  2. readLineSlow reads one byte per Read, a syscall each on a file, and
+//     the report goes out in small writes, a syscall each too;
+//  3. the report is built with +=, copying it whole for each piece added.
+func reportSlow(in io.Reader, out io.Writer) error {
+	stats := map[string]*pathStats{}
+	for {
+		line, err := readLineSlow(in)
+		if line != "" {
+			if e, ok := parseSlow(line); ok {
+				stats[e.path] = stats[e.path].add(e)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	paths := make([]string, 0, len(stats))
+	for p := range stats {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	report := ""
+	for _, p := range paths {
+		s := stats[p]
+		report += p
+		report += " requests=" + strconv.Itoa(s.count)
+		report += " errors=" + strconv.Itoa(s.errors)
+		report += " avg=" + strconv.Itoa(s.total/s.count) + "ms"
+		report += " max=" + strconv.Itoa(s.max) + "ms"
+		report += "\n"
+	}
+	for _, line := range splitLines(report) {
+		if _, err := fmt.Fprint(out, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readLineSlow(r io.Reader) (string, error) {
+	var line []byte
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n == 1 {
+			if b[0] == '\n' {
+				return string(line), nil
+			}
+			line = append(line, b[0])
+		}
+		if err != nil {
+			return string(line), err
+		}
+	}
+}
+
+func parseSlow(line string) (entry, bool) {
+	re := regexp.MustCompile(`^\S+ (GET|POST|PUT|DELETE) (\S+) (\d{3}) (\d+)ms$`)
+	m := re.FindStringSubmatch(line)
+	if m == nil {
+		return entry{}, false
+	}
+	status, _ := strconv.Atoi(m[3])
+	ms, _ := strconv.Atoi(m[4])
+	return entry{path: m[2], status: status, ms: ms}, true
+}
+
+// splitLines splits s after each newline, keeping them.
+func splitLines(s string) []string {
+	var lines []string
+	for len(s) > 0 {
+		i := 0
+		for i < len(s) && s[i] != '\n' {
+			i++
+		}
+		if i < len(s) {
+			i++
+		}
+		lines = append(lines, s[:i])
+		s = s[i:]
+	}
+	return lines
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+/*
+Six ways to build a string from parts, measured on small, medium and large
+inputs:
+
+	+=                   a new string, and a copy of everything so far, per part
+	fmt.Sprintf          parses its format at run time, boxes its arguments
+	strings.Join         sums the lengths, allocates once, copies once
+	strings.Builder      appends to a []byte, and String() reuses it without a copy
+	strings.Builder+Grow the same, with the final size reserved up front
+	bytes.Buffer         a Builder that can also be read, String() copies
+
++= in a loop is quadratic: building n parts copies 1, 2, ... n parts, its
+cost grows with the square of the input. Not to be confused with a+b+c in
+one expression, which the compiler turns into one runtime call sizing the
+result at once, as fast as it gets for a fixed number of parts.
+
+	go run ./golang_program_design_2024/12.performance/strconcat
+	go run ./golang_program_design_2024/12.performance/strconcat -test.benchtime 200ms
+*/
+
+type method struct {
+	name string
+	fn   func(parts []string) string
+}
+
+var methods = []method{
+	{"+=", func(parts []string) string {
+		s := ""
+		for _, p := range parts {
+			s += p
+		}
+		return s
+	}},
+	{"fmt.Sprintf", func(parts []string) string {
+		s := ""
+		for _, p := range parts {
+			s = fmt.Sprintf("%s%s", s, p)
+		}
+		return s
+	}},
+	{"strings.Join", func(parts []string) string {
+		return strings.Join(parts, "")
+	}},
+	{"strings.Builder", func(parts []string) string {
+		var b strings.Builder
+		for _, p := range parts {
+			b.WriteString(p)
+		}
+		return b.String()
+	}},
+	{"strings.Builder+Grow", func(parts []string) string {
+		n := 0
+		for _, p := range parts {
+			n += len(p)
+		}
+		var b strings.Builder
+		b.Grow(n)
+		for _, p := range parts {
+			b.WriteString(p)
+		}
+		return b.String()
+	}},
+	{"bytes.Buffer", func(parts []string) string {
+		var b bytes.Buffer
+		for _, p := range parts {
+			b.WriteString(p)
+		}
+		return b.String()
+	}},
+}
+
+type sizeClass struct {
+	name  string
+	parts int
+	size  int // bytes per part
+}
+
+var sizes = []sizeClass{
+	{"small (3 x 8 B)", 3, 8},
+	{"medium (64 x 16 B)", 64, 16},
+	{"large (4096 x 32 B)", 4096, 32},
+}
+
+// measure is the result of a method, Name, on a size class.
+type measure struct {
+	size string
+	benchtools.Result
+}
+
+var sink string
+
+func main() {
+	benchtools.Init()
+	flag.Parse()
+	fmt.Println("building a string from parts, per call:")
+	var all []measure
+	t := benchtools.NewTable(os.Stdout)
+	for _, sc := range sizes {
+		parts := benchtools.Strings(sc.parts, sc.size, 1)
+		want := strings.Join(parts, "")
+		fmt.Printf("\n%s\n", sc.name)
+		for _, m := range methods {
+			if m.fn(parts) != want {
+				panic(m.name + " builds the wrong string")
+			}
+			r := t.Add(benchtools.Loop(m.name, func() { sink = m.fn(parts) }))
+			all = append(all, measure{sc.name, r})
+		}
+		t.Flush()
+	}
+	fmt.Println()
+	winners(all)
+}
+
+// winners prints the fastest method of each size class, and how much
+// slower the slowest is.
+func winners(all []measure) {
+	bySize := map[string][]measure{}
+	for _, m := range all {
+		bySize[m.size] = append(bySize[m.size], m)
+	}
+	fmt.Println("winner per size class:")
+	for _, sc := range sizes {
+		ms := bySize[sc.name]
+		sort.Slice(ms, func(i, j int) bool { return ms[i].NsPerOp < ms[j].NsPerOp })
+		best, worst := ms[0], ms[len(ms)-1]
+		fmt.Printf("  %-20s %s (%s, %d allocs), then %s; slowest %s, %.0fx slower\n",
+			sc.name, best.Name, benchtools.FormatNs(best.NsPerOp), best.AllocsPerOp, ms[1].Name, worst.Name, worst.NsPerOp/best.NsPerOp)
+	}
+}
@@ -0,0 +1,105 @@
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+An interface value holds two words: the dynamic type of what it holds, and a
+pointer to the value. The reflect package gives access to both:
+
+	reflect.TypeOf(x)   a reflect.Type: its name, its Kind, its fields, methods
+	reflect.ValueOf(x)  a reflect.Value: the value itself, read and set
+
+Type vs Kind: type Celsius float64 has the type Celsius and the kind
+Float64. Code switching on reflection switches on Kind: there are 26 kinds,
+and any number of types.
+
+Settability: ValueOf(x) holds a copy of x, so setting it could not change x,
+and reflect panics instead. ValueOf(&x).Elem() is x itself, and can be set.
+Unexported fields can be read through reflection, never set.
+
+Methods: Value.MethodByName("Greet").Call(args) calls a method chosen at run
+time. The method set is the one of the type: methods on *T are not found on
+a T value. Wrong arguments panic, at run time, where a direct call would not
+compile.
+*/
+
+func init() {
+	lessons.Register("13.reflection/basics", "TypeOf and ValueOf, Kind, setting through pointers, calling methods", DemoBasics)
+}
+
+// Celsius has the kind of float64, not its type.
+type Celsius float64
+
+// greeter has a method on the value and one on the pointer.
+type greeter struct {
+	Name  string
+	count int // unexported: readable by reflect, not settable
+}
+
+func (g greeter) Greet(greeting string) string { return greeting + ", " + g.Name }
+
+func (g *greeter) Rename(name string) { g.Name = name }
+
+// DemoBasics inspects types and values, sets them, and calls methods by
+// name; basics_test.go has the cases.
+func DemoBasics() {
+	fmt.Println("== types and kinds")
+	for _, v := range []any{Celsius(21.5), &stdlib.User{}, []map[string]int{}} {
+		t := reflect.TypeOf(v)
+		fmt.Printf("  %-22s kind %-6s elem %v\n", t, t.Kind(), elem(t))
+	}
+	var s fmt.Stringer
+	fmt.Printf("  %-22s a nil interface has no type\n", fmt.Sprint(reflect.TypeOf(s)))
+
+	fmt.Println("== setting")
+	x := 3
+	fmt.Println("  ValueOf(x).CanSet():", reflect.ValueOf(x).CanSet(), "- a copy")
+	reflect.ValueOf(&x).Elem().SetInt(42)
+	fmt.Println("  ValueOf(&x).Elem().SetInt(42): x =", x)
+	g := greeter{Name: "Ana", count: 1}
+	gv := reflect.ValueOf(&g).Elem()
+	gv.FieldByName("Name").SetString("Bo")
+	fmt.Printf("  FieldByName(Name).SetString: %q, count settable: %v\n", g.Name, gv.FieldByName("count").CanSet())
+	err := catch(func() { reflect.ValueOf(&x).Elem().SetString("no") })
+	fmt.Println("  SetString on an int:", err)
+
+	fmt.Println("== methods by name")
+	out := reflect.ValueOf(g).MethodByName("Greet").Call([]reflect.Value{reflect.ValueOf("Hello")})
+	fmt.Printf("  Greet(\"Hello\"): %q\n", out[0].String())
+	reflect.ValueOf(&g).MethodByName("Rename").Call([]reflect.Value{reflect.ValueOf("Cy")})
+	fmt.Printf("  (*greeter).Rename(\"Cy\"): %q\n", g.Name)
+	fmt.Printf("  greeter has %d methods, *greeter %d:\n", reflect.TypeOf(g).NumMethod(), reflect.TypeOf(&g).NumMethod())
+	// every method of a type, by index: what a plugin system or a RPC
+	// server does to find the handlers of a type.
+	pt := reflect.TypeOf(&g)
+	for i := 0; i < pt.NumMethod(); i++ {
+		m := pt.Method(i)
+		fmt.Printf("    %-7s %s\n", m.Name, m.Type) // the receiver is the first argument
+	}
+}
+
+// elem is the element type of t, nil for the kinds without one.
+func elem(t reflect.Type) reflect.Type {
+	switch t.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Map, reflect.Pointer, reflect.Slice:
+		return t.Elem()
+	}
+	return nil
+}
+
+// catch runs f and returns its panic as an error.
+func catch(f func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	f()
+	return nil
+}
@@ -0,0 +1,107 @@
+package reflection
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+)
+
+func TestTypes(t *testing.T) {
+	var s fmt.Stringer
+	for _, tt := range []struct {
+		name string
+		v    any
+		want string // the type, its kind, and its elem
+	}{
+		{"type Celsius, kind float64", Celsius(21.5), "reflection.Celsius float64 <nil>"},
+		{"pointer: Elem the struct", &stdlib.User{}, "*stdlib.User ptr stdlib.User"},
+		{"composite", []map[string]int{}, "[]map[string]int slice map[string]int"},
+	} {
+		typ := reflect.TypeOf(tt.v)
+		if got := fmt.Sprint(typ, " ", typ.Kind(), " ", elem(typ)); got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	if typ := reflect.TypeOf([]map[string]int{}); typ.Elem().Elem().Kind() != reflect.Int {
+		t.Errorf("Elem of Elem of %s: %s", typ, typ.Elem().Elem())
+	}
+	if typ := reflect.TypeOf(stdlib.User{}); typ.PkgPath() != "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib" {
+		t.Errorf("PkgPath = %q", typ.PkgPath())
+	}
+	if reflect.TypeOf(nil) != nil || reflect.TypeOf(s) != nil {
+		t.Error("nil, or a nil interface, has a type")
+	}
+}
+
+func TestSet(t *testing.T) {
+	x := 3
+	if reflect.ValueOf(x).CanSet() {
+		t.Error("ValueOf(x) is settable: a copy")
+	}
+	v := reflect.ValueOf(&x).Elem()
+	v.SetInt(42)
+	if !v.CanSet() || x != 42 {
+		t.Errorf("ValueOf(&x).Elem(): settable %v, x = %d", v.CanSet(), x)
+	}
+
+	g := greeter{Name: "Ana", count: 1}
+	gv := reflect.ValueOf(&g).Elem()
+	gv.FieldByName("Name").SetString("Bo")
+	if g.Name != "Bo" {
+		t.Errorf("exported field: %q", g.Name)
+	}
+	if count := gv.FieldByName("count"); count.CanSet() || count.Int() != 1 {
+		t.Errorf("unexported field: settable %v, read %d", count.CanSet(), count.Int())
+	}
+
+	nums := []int{1, 2, 3}
+	reflect.ValueOf(nums).Index(0).SetInt(10) // a slice refers to its array
+	if nums[0] != 10 {
+		t.Errorf("slice element: %v", nums)
+	}
+
+	for name, f := range map[string]func(){
+		"Set of the wrong kind":  func() { reflect.ValueOf(&x).Elem().SetString("no") },
+		"Set of a copy":          func() { reflect.ValueOf(x).SetInt(1) },
+		"Set of an unexported":   func() { gv.FieldByName("count").SetInt(2) },
+		"Set of a nil pointer's": func() { reflect.ValueOf((*int)(nil)).Elem().SetInt(1) },
+	} {
+		if catch(f) == nil {
+			t.Errorf("%s: no panic", name)
+		}
+	}
+}
+
+func TestMethods(t *testing.T) {
+	g := greeter{Name: "Bo"}
+	out := reflect.ValueOf(g).MethodByName("Greet").Call([]reflect.Value{reflect.ValueOf("Hello")})
+	if out[0].String() != "Hello, Bo" {
+		t.Errorf("Greet by name = %q", out[0])
+	}
+	if reflect.ValueOf(g).MethodByName("Rename").IsValid() {
+		t.Error("a method on *T found on a T value")
+	}
+	if n, pn := reflect.TypeOf(g).NumMethod(), reflect.TypeOf(&g).NumMethod(); n != 1 || pn != 2 {
+		t.Errorf("T has %d methods, *T %d; want 1 and 2", n, pn)
+	}
+	reflect.ValueOf(&g).MethodByName("Rename").Call([]reflect.Value{reflect.ValueOf("Cy")})
+	if g.Name != "Cy" {
+		t.Errorf("after Rename: %q", g.Name)
+	}
+	if catch(func() { reflect.ValueOf(g).MethodByName("Greet").Call([]reflect.Value{reflect.ValueOf(1)}) }) == nil {
+		t.Error("a wrong argument type did not panic")
+	}
+
+	// by index, sorted by name, the receiver first.
+	pt := reflect.TypeOf(&g)
+	var got []string
+	for i := 0; i < pt.NumMethod(); i++ {
+		got = append(got, pt.Method(i).Name+" "+pt.Method(i).Type.String())
+	}
+	want := []string{"Greet func(*reflection.greeter, string) string", "Rename func(*reflection.greeter, string)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("methods of *greeter: %q", got)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	reflection "github.com/YongSangUn/learn-golang/golang_program_design_2024/13.reflection"
+)
+
+/*
+reflection runs the lessons of chapter 13.reflection, in order: the basics,
+the struct tags and the validation, then the benchmarks. Their cases are
+tests: go test ./golang_program_design_2024/13.reflection/...
+
+Usage:
+
+	go run ./golang_program_design_2024/13.reflection/cmd/reflection
+
+One lesson alone: go run ./cmd/learn run 13.reflection/tags
+*/
+
+func main() {
+	reflection.DemoBasics()
+	reflection.DemoTags()
+	reflection.DemoValidate()
+	reflection.DemoCost()
+}
@@ -0,0 +1,86 @@
+package reflection
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+Reflection costs at run time what the compiler does for free at compile
+time:
+
+- FieldByName searches the fields by name on every call; Field(i), with i
+  found once and kept, is an index. Libraries cache the field indexes per
+  type (encoding/json keeps them in a sync.Map).
+- Value.Interface() and reflect.ValueOf box the value: an allocation for
+  anything that does not fit in a pointer.
+- Call builds a []reflect.Value for the arguments and one for the results,
+  and goes through the generic call path: no inlining, allocations.
+
+And what no benchmark shows: the type errors of reflective code are panics
+at run time. Generics (06.generics) cover part of what reflection was used
+for, checked by the compiler. Reflection remains for code that must work on
+types it does not know: encoders, validators (validate), ORMs.
+
+	go run ./cmd/learn run 13.reflection/cost
+*/
+
+func init() {
+	lessons.Register("13.reflection/cost", "the cost of reflection, measured: fields, method calls, ToMap", DemoCost)
+}
+
+var (
+	costSink    any
+	costStrSink string
+)
+
+// userToMap is ToMap written by hand for one type: what code generation
+// would produce.
+func userToMap(u *stdlib.User) map[string]any {
+	m := map[string]any{"name": u.Name, "email": u.Email}
+	if u.Biographical != "" {
+		m["bio"] = u.Biographical
+	}
+	return m
+}
+
+// DemoCost benchmarks direct access against reflection.
+func DemoCost() {
+	u := &stdlib.User{Name: "Jackson", Biographical: "This is Jackson.", Email: []string{"j@example.com"}}
+	g := greeter{Name: "Ana"}
+	rv := reflect.ValueOf(u).Elem()
+	nameIndex := func() int { f, _ := rv.Type().FieldByName("Name"); return f.Index[0] }()
+	greet := reflect.ValueOf(g).MethodByName("Greet")
+	hello := []reflect.Value{reflect.ValueOf("Hello")}
+
+	t := benchtools.NewTable(os.Stdout)
+	fmt.Println("== read a field")
+	t.Add(benchtools.Loop("u.Name", func() { costStrSink = u.Name }))
+	t.Add(benchtools.Loop("Field(i).String()", func() { costStrSink = rv.Field(nameIndex).String() }))
+	t.Add(benchtools.Loop("FieldByName.String()", func() { costStrSink = rv.FieldByName("Name").String() }))
+	t.Add(benchtools.Loop("Field(i).Interface()", func() { costSink = rv.Field(nameIndex).Interface() }))
+	t.Flush()
+
+	fmt.Println("== call a method")
+	t.Add(benchtools.Loop("g.Greet(...)", func() { costStrSink = g.Greet("Hello") }))
+	t.Add(benchtools.Loop("Method.Call, kept", func() { costStrSink = greet.Call(hello)[0].String() }))
+	t.Add(benchtools.Loop("MethodByName.Call", func() {
+		costStrSink = reflect.ValueOf(g).MethodByName("Greet").Call(hello)[0].String()
+	}))
+	t.Flush()
+
+	fmt.Println("== struct to map")
+	t.Add(benchtools.Loop("by hand", func() { costSink = userToMap(u) }))
+	t.Add(benchtools.Loop("ToMap (reflect)", func() { costSink, _ = ToMap(u) }))
+	t.Flush()
+	// Typical result: Field(i) costs some 2x the direct read, FieldByName
+	// 25x, and Interface() allocates. A method Call kept in a Value is 7x a
+	// direct call, found by name every time 17x, with allocations each. ToMap
+	// is 3x the hand-written function: building the map is the same work in
+	// both, the reflection comes on top.
+}
@@ -0,0 +1,3 @@
+// Package reflection is chapter 13.reflection: the reflect package, types
+// and values at run time, struct tags, and what it costs.
+package reflection
@@ -0,0 +1,162 @@
+package reflection
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A struct tag is a string after a field, read at run time only, through
+reflection: reflect.StructField.Tag. By convention it is a list of
+key:"value" pairs, and Tag.Get(key) returns the value of one:
+
+	Name     string `json:"name"`
+	Password string `json:"-"`          json skips the field
+	Bio      string `json:"bio,omitempty"`
+
+Tag.Lookup tells a key absent from a key present with an empty value,
+which Get cannot. The compiler does not check the tags: a typo, json:name
+without quotes, is ignored silently (go vet's structtag check finds it).
+
+This is how encoding/json works, and ToMap below does the same with the
+same tags: a struct to a map[string]any, nested structs to nested maps, a
+few dozen lines for what JSON does through bytes.
+*/
+
+func init() {
+	lessons.Register("13.reflection/tags", "struct tags, and a struct-to-map converter reading the json tags", DemoTags)
+}
+
+// ErrNotStruct is returned by ToMap for anything but a struct or a pointer
+// to one.
+var ErrNotStruct = errors.New("reflection: not a struct")
+
+// ToMap returns the exported fields of the struct v, or of the struct v
+// points to, keyed by their json names. Fields tagged json:"-" are left out,
+// and so are empty ones tagged omitempty. Struct fields, and pointers to
+// structs, become maps in turn; other values are kept as they are.
+func ToMap(v any) (map[string]any, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %T", ErrNotStruct, v)
+	}
+	return structToMap(rv), nil
+}
+
+func structToMap(rv reflect.Value) map[string]any {
+	t := rv.Type()
+	m := make(map[string]any, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue // Interface() on it would panic
+		}
+		name, omitempty, skip := jsonName(f)
+		if skip {
+			continue
+		}
+		fv := rv.Field(i)
+		if omitempty && isEmpty(fv) {
+			continue
+		}
+		m[name] = toValue(fv)
+	}
+	return m
+}
+
+// toValue is fv as a map value: a nested struct as a map, through pointers.
+func toValue(fv reflect.Value) any {
+	switch {
+	case fv.Kind() == reflect.Struct:
+		return structToMap(fv)
+	case fv.Kind() == reflect.Pointer && fv.Type().Elem().Kind() == reflect.Struct:
+		if fv.IsNil() {
+			return nil
+		}
+		return structToMap(fv.Elem())
+	}
+	return fv.Interface()
+}
+
+// jsonName parses the json tag of f: the key, omitempty, and whether to
+// skip the field. No tag, or no name in it, keeps the name of the field.
+func jsonName(f reflect.StructField) (name string, omitempty, skip bool) {
+	tag, ok := f.Tag.Lookup("json")
+	if !ok {
+		return f.Name, false, false
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, slices.Contains(strings.Split(opts, ","), "omitempty"), false
+}
+
+// isEmpty is what omitempty of encoding/json calls empty: false, 0, a nil
+// pointer or interface, an empty string, slice, map or array. A struct is
+// never empty.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// Account nests a User, by value and by pointer.
+type Account struct {
+	ID      int          `json:"id"`
+	Owner   stdlib.User  `json:"owner"`
+	Backup  *stdlib.User `json:"backup,omitempty"`
+	Balance float64      // no tag: the field name
+	note    string       // unexported: never in the map
+}
+
+// DemoTags reads the tags of the User of json.go, then converts it with
+// ToMap next to encoding/json; tags_test.go has the cases.
+func DemoTags() {
+	fmt.Println("== the tags of stdlib.User")
+	t := reflect.TypeOf(stdlib.User{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fmt.Printf("  %-12s %-9s %s\n", f.Name, f.Type, f.Tag)
+	}
+
+	fmt.Println("== ToMap")
+	u := stdlib.User{Name: "Jackson", Password: "P@ssw0rd"}
+	m, _ := ToMap(u)
+	fmt.Println("ToMap(u):      ", m)
+	acc := Account{ID: 7, Owner: u, Backup: &stdlib.User{Name: "Dick"}, Balance: 12.5, note: "vip"}
+	m, _ = ToMap(acc)
+	fmt.Println("ToMap(account):", m)
+	b, _ := json.Marshal(acc)
+	fmt.Println("json.Marshal:  ", string(b))
+	_, err := ToMap(42)
+	fmt.Println("ToMap(42):     ", err)
+}
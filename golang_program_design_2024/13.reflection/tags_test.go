@@ -0,0 +1,108 @@
+package reflection
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+)
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestTags(t *testing.T) {
+	f, _ := reflect.TypeOf(stdlib.User{}).FieldByName("Biographical")
+	if got := f.Tag.Get("json"); got != "bio,omitempty" {
+		t.Errorf(`Tag.Get("json") = %q`, got)
+	}
+	if _, ok := f.Tag.Lookup("xml"); ok {
+		t.Error(`Lookup("xml") found a key absent`)
+	}
+}
+
+func TestToMap(t *testing.T) {
+	u := stdlib.User{Name: "Jackson", Password: "P@ssw0rd", Email: []string{"j@example.com"}}
+	withBio := u
+	withBio.Biographical = "This is Jackson."
+	for _, tt := range []struct {
+		name string
+		v    any
+		want map[string]any
+	}{
+		{`"-" skipped, omitempty empty left out`, u, map[string]any{"name": "Jackson", "email": []string{"j@example.com"}}},
+		{"pointer to a struct", &withBio, map[string]any{"name": "Jackson", "email": []string{"j@example.com"}, "bio": "This is Jackson."}},
+		{"nil pointer", (*stdlib.User)(nil), nil},
+		{"nested", Account{ID: 7, Owner: stdlib.User{Name: "Ann"}, Balance: 1.5, note: "vip"}, map[string]any{
+			"id": 7, "owner": map[string]any{"name": "Ann", "email": []string(nil)}, "Balance": 1.5,
+		}},
+		{"pointer to a nested struct", Account{Backup: &stdlib.User{Name: "Dick"}}, map[string]any{
+			"id": 0, "owner": map[string]any{"name": "", "email": []string(nil)}, "Balance": 0.0,
+			"backup": map[string]any{"name": "Dick", "email": []string(nil)},
+		}},
+	} {
+		got, err := ToMap(tt.v)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: %v, %v; want %v", tt.name, got, err, tt.want)
+		}
+	}
+	for _, v := range []any{42, "s", nil, []stdlib.User{}} {
+		if _, err := ToMap(v); !errors.Is(err, ErrNotStruct) {
+			t.Errorf("ToMap(%#v) = %v, want ErrNotStruct", v, err)
+		}
+	}
+}
+
+// TestToMapJSON checks that ToMap keeps the keys encoding/json writes.
+func TestToMapJSON(t *testing.T) {
+	u := stdlib.User{Name: "Jackson", Biographical: "bio", Password: "x"}
+	for _, acc := range []Account{
+		{ID: 7, Owner: u, Balance: 12.5},
+		{ID: 8, Owner: u, Backup: &u},
+	} {
+		m, _ := ToMap(acc)
+		var viaJSON map[string]any
+		b, _ := json.Marshal(acc)
+		json.Unmarshal(b, &viaJSON)
+		if !slices.Equal(sortedKeys(m), sortedKeys(viaJSON)) {
+			t.Errorf("keys %q, json %q", sortedKeys(m), sortedKeys(viaJSON))
+		}
+		owner, _ := m["owner"].(map[string]any)
+		if !slices.Equal(sortedKeys(owner), sortedKeys(viaJSON["owner"].(map[string]any))) {
+			t.Errorf("owner keys %q, json %s", sortedKeys(owner), b)
+		}
+	}
+}
+
+func TestIsEmpty(t *testing.T) {
+	var p *int
+	var e error
+	for _, tt := range []struct {
+		v    any
+		want bool
+	}{
+		{"", true}, {"a", false},
+		{0, true}, {int8(-1), false},
+		{uint(0), true}, {0.0, true}, {0.5, false},
+		{false, true}, {true, false},
+		{[]int{}, true}, {map[string]int{"a": 1}, false}, {[0]int{}, true},
+		{p, true}, {new(int), false},
+		{struct{}{}, false},
+	} {
+		if got := isEmpty(reflect.ValueOf(tt.v)); got != tt.want {
+			t.Errorf("isEmpty(%#v) = %v", tt.v, got)
+		}
+	}
+	if !isEmpty(reflect.ValueOf(&e).Elem()) {
+		t.Error("a nil interface is not empty")
+	}
+}
@@ -0,0 +1,53 @@
+package reflection
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/13.reflection/validate"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+The validate package is the tags lesson put to work: the User of json.go has
+a validate tag next to its json one, and validate.Struct checks a decoded
+request against it. json.Unmarshal checks that the JSON fits the types;
+the tags check what the types cannot say: a name of 3 to 50 characters, a
+well-formed email.
+
+Every failed field is reported at once, as validate.Errors: a client fixes
+all its mistakes in one round trip, not one per request. The tests of the
+package, in validate/validate_test.go, cover Person and nested structs; the
+ones next to this file, the User:
+
+	go test ./golang_program_design_2024/13.reflection/...
+*/
+
+func init() {
+	lessons.Register("13.reflection/validate", "struct tag validation of a decoded request, with validate.Struct", DemoValidate)
+}
+
+// DemoValidate decodes requests into a User and validates them.
+func DemoValidate() {
+	fmt.Println("== validate.Struct")
+	for _, body := range []string{
+		`{"name": "Jackson", "email": ["j@example.com"]}`, // the password is not in JSON (json:"-")
+		`{"name": "Jo", "bio": "", "email": ["jo@example", "jo at example.com"]}`,
+	} {
+		var u stdlib.User
+		json.Unmarshal([]byte(body), &u)
+		var errs validate.Errors
+		errors.As(validate.Struct(u), &errs)
+		fmt.Printf("%s: %d errors\n", body, len(errs))
+		for _, fe := range errs {
+			fmt.Printf("    %-10s %-10s %s\n", fe.Field, fe.Rule, fe.Msg)
+		}
+	}
+
+	err := validate.Struct(struct {
+		Age int `validate:"min=18,emial"`
+	}{})
+	fmt.Println("a typo in a tag:", err)
+}
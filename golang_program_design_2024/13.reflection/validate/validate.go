@@ -0,0 +1,366 @@
+// Package validate checks the fields of a struct against the rules in their
+// validate tags, through reflection:
+//
+//	type User struct {
+//		Name   string   `validate:"required,min=3,max=50"`
+//		Emails []string `validate:"max=5,dive,email"`
+//	}
+//	err := validate.Struct(u) // nil, or Errors: every field that failed
+//
+// The rules, separated by commas:
+//
+//	required   not the zero value; for strings, slices and maps, not empty
+//	min=N      strings: at least N characters; slices, maps: N elements;
+//	max=N      numbers: the value itself
+//	email      a bare address, name@host; the empty string passes (see required)
+//	oneof=a b  one of the values separated by spaces: strings and integers
+//	dive       the rules after it apply to each element of a slice, array or map
+//
+// A required that fails skips the other rules of its value, and the values
+// inside it. Struct fields, pointers to structs and slices and maps of
+// structs are validated in turn, tags or not; errors name them by path:
+// Owner.Emails[1]. The rules of a type are parsed once and cached, as
+// encoding/json caches its fields.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ErrNotStruct is returned by Struct for anything but a struct or a non-nil
+// pointer to one.
+var ErrNotStruct = errors.New("validate: not a struct")
+
+// FieldError is a rule a field failed.
+type FieldError struct {
+	Field string // the path of the field: Owner.Emails[1]
+	Rule  string // the rule, with its parameter: min=3
+	Msg   string
+}
+
+func (e *FieldError) Error() string { return fmt.Sprintf("%s: %s", e.Field, e.Msg) }
+
+// Errors is every FieldError of a struct, in field order.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// TagError is a tag that cannot be applied: an unknown rule, a bad
+// parameter, a rule on a kind it does not support. It is a bug in the
+// struct, not in the value: Struct returns it alone, at once.
+type TagError struct {
+	Type  reflect.Type
+	Field string
+	Tag   string
+	Err   error
+}
+
+func (e *TagError) Error() string {
+	return fmt.Sprintf("validate: %s.%s `validate:%q`: %v", e.Type, e.Field, e.Tag, e.Err)
+}
+
+func (e *TagError) Unwrap() error { return e.Err }
+
+type rule struct {
+	name, param string
+	n           float64 // the parameter of min and max
+}
+
+func (r rule) String() string {
+	if r.param == "" {
+		return r.name
+	}
+	return r.name + "=" + r.param
+}
+
+type field struct {
+	index int
+	name  string
+	tag   string
+	rules []rule // on the field
+	dive  []rule // on each element of the field
+}
+
+// cache holds the []field of each struct type, or its *TagError.
+var cache sync.Map
+
+func fieldsOf(t reflect.Type) ([]field, error) {
+	type entry struct {
+		fields []field
+		err    error
+	}
+	if e, ok := cache.Load(t); ok {
+		return e.(entry).fields, e.(entry).err
+	}
+	var fields []field
+	var err error
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		f := field{index: i, name: sf.Name, tag: sf.Tag.Get("validate")}
+		f.rules, f.dive, err = parseTag(f.tag)
+		if err != nil {
+			err = &TagError{Type: t, Field: sf.Name, Tag: f.tag, Err: err}
+			break
+		}
+		fields = append(fields, f)
+	}
+	cache.Store(t, entry{fields, err})
+	return fields, err
+}
+
+func parseTag(tag string) (rules, dive []rule, err error) {
+	if tag == "" {
+		return nil, nil, nil
+	}
+	target := &rules
+	for _, s := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(s, "=")
+		r := rule{name: name, param: param}
+		switch name {
+		case "dive":
+			if target == &dive {
+				return nil, nil, errors.New("dive twice")
+			}
+			target = &dive
+			continue
+		case "required", "email":
+			if param != "" {
+				return nil, nil, fmt.Errorf("%s takes no parameter", name)
+			}
+		case "min", "max":
+			if r.n, err = strconv.ParseFloat(param, 64); err != nil {
+				return nil, nil, fmt.Errorf("%s: %q is not a number", name, param)
+			}
+		case "oneof":
+			if param == "" {
+				return nil, nil, errors.New("oneof without values")
+			}
+		default:
+			return nil, nil, fmt.Errorf("unknown rule %q", name)
+		}
+		*target = append(*target, r)
+	}
+	return rules, dive, nil
+}
+
+// Struct validates the struct v, or the struct v points to. It returns nil,
+// Errors, or a *TagError.
+func Struct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%w: %T", ErrNotStruct, v)
+	}
+	var errs Errors
+	if err := validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(rv reflect.Value, path string, errs *Errors) error {
+	fields, err := fieldsOf(rv.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		fv := rv.Field(f.index)
+		p := f.name
+		if path != "" {
+			p = path + "." + f.name
+		}
+		missing, err := apply(f.rules, fv, p, errs)
+		if err != nil {
+			return tagError(rv.Type(), f, err)
+		}
+		if missing {
+			continue
+		}
+		if err := descend(fv, p, f.dive, errs); err != nil {
+			return tagError(rv.Type(), f, err)
+		}
+	}
+	return nil
+}
+
+// tagError wraps err in a *TagError, unless it is one already: the one of
+// a nested struct.
+func tagError(t reflect.Type, f field, err error) error {
+	var te *TagError
+	if errors.As(err, &te) {
+		return err
+	}
+	return &TagError{Type: t, Field: f.name, Tag: f.tag, Err: err}
+}
+
+// descend validates what v contains: the fields of a struct, the elements
+// of a slice, array or map, with the rules of dive on each element.
+func descend(v reflect.Value, path string, dive []rule, errs *Errors) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return descend(v.Elem(), path, dive, errs)
+	case reflect.Struct:
+		return validateStruct(v, path, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			missing, err := apply(dive, v.Index(i), p, errs)
+			if err != nil {
+				return err
+			}
+			if missing {
+				continue
+			}
+			if err := descend(v.Index(i), p, nil, errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) }) // errors in a stable order
+		for _, k := range keys {
+			p := fmt.Sprintf("%s[%v]", path, k)
+			missing, err := apply(dive, v.MapIndex(k), p, errs)
+			if err != nil {
+				return err
+			}
+			if missing {
+				continue
+			}
+			if err := descend(v.MapIndex(k), p, nil, errs); err != nil {
+				return err
+			}
+		}
+	default:
+		if len(dive) > 0 {
+			return fmt.Errorf("dive does not apply to %s", v.Kind())
+		}
+	}
+	return nil
+}
+
+// apply checks v against rules, adding a FieldError per rule failed, and
+// reports whether required failed: there is nothing more to check in v. The
+// error is for a rule that does not apply to the kind of v.
+func apply(rules []rule, v reflect.Value, path string, errs *Errors) (missing bool, err error) {
+	for _, r := range rules {
+		msg, err := check(r, v)
+		if err != nil {
+			return false, err
+		}
+		if msg == "" {
+			continue
+		}
+		*errs = append(*errs, &FieldError{Field: path, Rule: r.String(), Msg: msg})
+		if r.name == "required" {
+			return true, nil // the other rules would only repeat it
+		}
+	}
+	return false, nil
+}
+
+// check returns why v fails r, or "" if it passes.
+func check(r rule, v reflect.Value) (string, error) {
+	switch r.name {
+	case "required":
+		if isEmpty(v) {
+			return "is required", nil
+		}
+	case "min", "max":
+		n, unit, ok := size(v)
+		if !ok {
+			return "", fmt.Errorf("%s does not apply to %s", r.name, v.Kind())
+		}
+		if r.name == "min" && n < r.n {
+			return fmt.Sprintf("must be at least %s%s, is %s", r.param, unit, fmtNum(n)), nil
+		}
+		if r.name == "max" && n > r.n {
+			return fmt.Sprintf("must be at most %s%s, is %s", r.param, unit, fmtNum(n)), nil
+		}
+	case "email":
+		if v.Kind() != reflect.String {
+			return "", fmt.Errorf("email does not apply to %s", v.Kind())
+		}
+		if s := v.String(); s != "" && !isEmail(s) {
+			return fmt.Sprintf("%q is not an email address", s), nil
+		}
+	case "oneof":
+		var s string
+		switch v.Kind() {
+		case reflect.String:
+			s = v.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			s = strconv.FormatInt(v.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			s = strconv.FormatUint(v.Uint(), 10)
+		default:
+			return "", fmt.Errorf("oneof does not apply to %s", v.Kind())
+		}
+		for _, allowed := range strings.Fields(r.param) {
+			if s == allowed {
+				return "", nil
+			}
+		}
+		return fmt.Sprintf("must be one of %s, is %q", r.param, s), nil
+	}
+	return "", nil
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// size is what min and max compare: a length, or the value of a number.
+func size(v reflect.Value) (n float64, unit string, ok bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), " elements", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	}
+	return 0, "", false
+}
+
+func fmtNum(n float64) string { return strconv.FormatFloat(n, 'f', -1, 64) }
+
+// isEmail accepts name@host, as net/mail parses it, and nothing around it:
+// no display name, no angle brackets.
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s && addr.Name == ""
+}
@@ -0,0 +1,175 @@
+package validate_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	datastruct "github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct"
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/13.reflection/validate"
+)
+
+// fields returns the paths and rules of the FieldErrors in err, as
+// "Field rule" strings, or fails the test if err is not an Errors.
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var errs validate.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error %v (%T) is not validate.Errors", err, err)
+	}
+	out := make([]string, len(errs))
+	for i, fe := range errs {
+		out[i] = fe.Field + " " + fe.Rule
+	}
+	return out
+}
+
+func check(t *testing.T, v any, want ...string) {
+	t.Helper()
+	got := fields(t, validate.Struct(v))
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("Struct(%+v)\n got %q\nwant %q", v, got, want)
+	}
+}
+
+func TestUser(t *testing.T) {
+	valid := stdlib.User{Name: "Jackson", Password: "P@ssw0rd", Email: []string{"j@example.com"}}
+	tests := []struct {
+		name string
+		edit func(u *stdlib.User)
+		want []string
+	}{
+		{"valid", func(u *stdlib.User) {}, nil},
+		{"no email is fine", func(u *stdlib.User) { u.Email = nil }, nil},
+		{"name missing", func(u *stdlib.User) { u.Name = "" }, []string{"Name required"}},
+		{"name too short", func(u *stdlib.User) { u.Name = "Jo" }, []string{"Name min=3"}},
+		{"name in characters, not bytes", func(u *stdlib.User) { u.Name = "张三丰" }, nil},
+		{"name too long", func(u *stdlib.User) { u.Name = strings.Repeat("a", 51) }, []string{"Name max=50"}},
+		{"bio too long", func(u *stdlib.User) { u.Biographical = strings.Repeat("b", 201) }, []string{"Biographical max=200"}},
+		{"short password", func(u *stdlib.User) { u.Password = "1234" }, []string{"Password min=8"}},
+		{"bad email", func(u *stdlib.User) { u.Email = []string{"j@example.com", "not-an-email"} }, []string{"Email[1] email"}},
+		{"display name refused", func(u *stdlib.User) { u.Email = []string{"Jo <j@example.com>"} }, []string{"Email[0] email"}},
+		{"too many emails", func(u *stdlib.User) { u.Email = make([]string, 6) }, []string{"Email max=5"}},
+		{
+			"every error at once",
+			func(u *stdlib.User) { *u = stdlib.User{Email: []string{"x"}} },
+			[]string{"Name required", "Password required", "Email[0] email"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := valid
+			u.Email = append([]string(nil), valid.Email...)
+			tt.edit(&u)
+			check(t, &u, tt.want...)
+		})
+	}
+}
+
+func TestPerson(t *testing.T) {
+	tests := []struct {
+		name string
+		p    datastruct.Person
+		want []string
+	}{
+		{"valid", datastruct.Person{Name: "Alice", Age: 30, Emails: []string{"alice@example.com"}}, nil},
+		{"age 0 is a value", datastruct.Person{Name: "Baby"}, nil},
+		{"negative age", datastruct.Person{Name: "Bob", Age: -1}, []string{"Age min=0"}},
+		{"too old", datastruct.Person{Name: "Bob", Age: 151}, []string{"Age max=150"}},
+		{"empty email", datastruct.Person{Name: "Eve", Emails: []string{""}}, nil}, // email alone does not require
+		{"no name, bad email", datastruct.Person{Emails: []string{"@"}}, []string{"Name required", "Emails[0] email"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, tt.p, tt.want...)
+		})
+	}
+}
+
+// Team nests the types: fields, pointers, slices and maps of structs.
+type Team struct {
+	Name    string                       `validate:"required,oneof=core infra web"`
+	Lead    datastruct.Person            // no tag: validated all the same
+	Deputy  *datastruct.Person           // nil: nothing to validate
+	Members []datastruct.Person          `validate:"min=1"`
+	Admins  map[string]stdlib.User       `validate:"max=2"`
+	Levels  []int                        `validate:"dive,min=1,max=3"`
+	ByRole  map[string]datastruct.Person `validate:"dive,required"`
+}
+
+func TestNested(t *testing.T) {
+	ok := datastruct.Person{Name: "Ok", Age: 20}
+	team := Team{
+		Name:    "infra",
+		Lead:    datastruct.Person{Age: 200},
+		Members: []datastruct.Person{ok, {Name: "Mo", Emails: []string{"mo@"}}},
+		Admins:  map[string]stdlib.User{"root": {Name: "r", Password: "longenough"}},
+		Levels:  []int{1, 4, 0},
+		ByRole:  map[string]datastruct.Person{"dev": ok, "ops": {}},
+	}
+	check(t, team,
+		"Lead.Name required",
+		"Lead.Age max=150",
+		"Members[1].Emails[0] email",
+		"Admins[root].Name min=3",
+		"Levels[1] max=3",
+		"Levels[2] min=1",
+		"ByRole[ops] required",
+	)
+
+	team.Deputy = &datastruct.Person{Age: -5}
+	team.Name = "sales"
+	err := validate.Struct(team)
+	got := fields(t, err)
+	if len(got) != 10 || got[0] != "Name oneof=core infra web" || got[3] != "Deputy.Name required" {
+		t.Errorf("with a deputy and a bad name: %q", got)
+	}
+	if !strings.Contains(err.Error(), `Name: must be one of core infra web, is "sales"`) {
+		t.Errorf("message: %s", err)
+	}
+}
+
+func TestNotStruct(t *testing.T) {
+	for _, v := range []any{42, "x", nil, (*datastruct.Person)(nil), []datastruct.Person{}} {
+		if err := validate.Struct(v); !errors.Is(err, validate.ErrNotStruct) {
+			t.Errorf("Struct(%#v) = %v, want ErrNotStruct", v, err)
+		}
+	}
+}
+
+func TestTagErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+		want string
+	}{
+		{"unknown rule", struct {
+			A string `validate:"requird"`
+		}{}, `unknown rule "requird"`},
+		{"min not a number", struct {
+			A string `validate:"min=three"`
+		}{}, `"three" is not a number`},
+		{"min on a bool", struct {
+			A bool `validate:"min=1"`
+		}{}, "min does not apply to bool"},
+		{"email on an int", struct {
+			A int `validate:"email"`
+		}{}, "email does not apply to int"},
+		{"dive on a string", struct {
+			A string `validate:"dive,min=1"`
+		}{}, "dive does not apply to string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate.Struct(tt.v)
+			var te *validate.TagError
+			if !errors.As(err, &te) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Struct = %v, want a *TagError with %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package reflection
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/13.reflection/validate"
+)
+
+// TestValidateUser validates decoded requests against the tags of the User.
+func TestValidateUser(t *testing.T) {
+	for _, tt := range []struct {
+		body   string
+		fields []string // the failed fields, in order
+	}{
+		{`{"name": "Jackson", "email": ["j@example.com"]}`, []string{"Password"}},                                             // not in JSON (json:"-")
+		{`{"name": "Jo", "bio": "", "email": ["jo@example", "jo at example.com"]}`, []string{"Name", "Password", "Email[1]"}}, // "jo@example" is well formed,
+	} {
+		var u stdlib.User
+		if err := json.Unmarshal([]byte(tt.body), &u); err != nil {
+			t.Fatal(err)
+		}
+		var errs validate.Errors
+		if !errors.As(validate.Struct(u), &errs) || len(errs) != len(tt.fields) {
+			t.Errorf("%s: %v, want %d errors", tt.body, errs, len(tt.fields))
+			continue
+		}
+		for i, fe := range errs {
+			if fe.Field != tt.fields[i] {
+				t.Errorf("%s: error %d on %s, want %s", tt.body, i, fe.Field, tt.fields[i])
+			}
+		}
+	}
+
+	u := stdlib.User{Name: "Jackson", Password: "P@ssw0rd", Email: []string{"j@example.com"}}
+	if err := validate.Struct(&u); err != nil {
+		t.Errorf("a valid User: %v", err)
+	}
+
+	var te *validate.TagError
+	if err := validate.Struct(struct {
+		Age int `validate:"min=18,emial"`
+	}{}); !errors.As(err, &te) {
+		t.Errorf("a typo in a tag: %v, want a *TagError", err)
+	}
+}
@@ -0,0 +1,42 @@
+package httpdemo
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/server"
+)
+
+// NewAPI returns the server of the chapter, behind its middleware: the
+// users API of json.go, and two routes for the middleware and shutdown
+// lessons.
+//
+//	GET /slow?d=300ms  answers after d, or 503 if the request ends first
+//	GET /panic         panics, for Recover
+func NewAPI(log *slog.Logger) http.Handler {
+	mux := http.NewServeMux()
+	(&users{byName: make(map[string]stdlib.User)}).routes(mux)
+	mux.HandleFunc("GET /slow", slow)
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]int
+		m["boom"]++ // assignment to entry in nil map
+	})
+	return server.Chain(mux, server.RequestID, server.Logging(log), server.Recover(log))
+}
+
+func slow(w http.ResponseWriter, r *http.Request) {
+	d, err := time.ParseDuration(r.URL.Query().Get("d"))
+	if err != nil {
+		d = 300 * time.Millisecond
+	}
+	select {
+	case <-time.After(d):
+		fmt.Fprintf(w, "slept %s\n", d)
+	case <-r.Context().Done():
+		// the client left, or the server was closed: no one reads this.
+		server.WriteError(w, server.Errorf(http.StatusServiceUnavailable, "cancelled"))
+	}
+}
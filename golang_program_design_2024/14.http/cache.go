@@ -0,0 +1,104 @@
+package httpdemo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/client"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A response that does not change for a minute need not be asked for more
+than once a minute. client.Cache is a RoundTripper, like Retry: it keeps
+the responses to GET requests in the LRU cache of 02.data_struct/lru, by
+URL, each for the max-age of its Cache-Control header or a default TTL.
+A hit never leaves the process; the least recently used response makes
+room for a new one when the cache is full.
+
+	hc := &http.Client{Transport: client.NewCache(&client.Retry{Next: client.NewTransport()}, 1000, time.Minute)}
+
+The server decides what may be kept: Cache-Control no-store or private,
+max-age=0, keep a response out. What changes per user, or must be fresh,
+says so; the cache of the client, like the ones between it and the server,
+trusts it.
+*/
+
+func init() {
+	lessons.Register("14.http/cache", "a response cache for the client: an LRU with a TTL as a RoundTripper", lessons.Checked(DemoCache))
+}
+
+// DemoCache sends requests through a client.Cache and shows the ones the
+// server saw; cache_test.go has the cases.
+func DemoCache() error {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+		case "/private":
+			w.Header().Set("Cache-Control", "private")
+		}
+		fmt.Fprintf(w, "%s at %d", r.URL.Path, hits.Load())
+	}))
+	defer srv.Close()
+
+	hc := &http.Client{Transport: client.NewCache(client.NewTransport(), 2, 50*time.Millisecond)}
+	for _, path := range []string{"/fresh", "/fresh", "/private", "/private", "/plain", "/plain"} {
+		resp, err := hc.Get(srv.URL + path)
+		if err != nil {
+			return err
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("GET %-9s %-4s %q\n", path, resp.Header.Get("X-Cache"), b)
+	}
+	time.Sleep(60 * time.Millisecond)
+	resp, err := hc.Get(srv.URL + "/plain")
+	if err != nil {
+		return err
+	}
+	client.Drain(resp)
+	fmt.Printf("GET /plain after the TTL of 50ms: %s, %d requests sent\n", resp.Header.Get("X-Cache"), hits.Load())
+
+	// the users client, unchanged, through the cache
+	api := httptest.NewServer(NewAPI(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer api.Close()
+	var apiHits atomic.Int64
+	counted := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		apiHits.Add(1)
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	users := &client.Users{
+		HTTP:    &http.Client{Transport: client.NewCache(counted, 100, time.Minute), Timeout: 5 * time.Second},
+		BaseURL: api.URL,
+	}
+	ctx := context.Background()
+	if _, err := users.Create(ctx, stdlib.User{Name: "Jackson", Password: "P@ssw0rd"}); err != nil {
+		return err
+	}
+	apiHits.Store(0)
+	for i := 0; i < 5; i++ {
+		if _, err := users.Get(ctx, "Jackson"); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("users.Get 5 times: %d request sent\n", apiHits.Load())
+	return nil
+}
+
+// roundTripFunc makes a function an http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
@@ -0,0 +1,107 @@
+package httpdemo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/client"
+)
+
+// TestCacheLRU fills a cache of 2 responses with a TTL of 50ms; the cases
+// of Cache-Control are in client/client_test.go.
+func TestCacheLRU(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/fresh" {
+			w.Header().Set("Cache-Control", "max-age=60")
+		}
+		fmt.Fprintf(w, "%s at %d", r.URL.Path, hits.Load())
+	}))
+	defer srv.Close()
+
+	cache := client.NewCache(client.NewTransport(), 2, 50*time.Millisecond)
+	hc := &http.Client{Transport: cache}
+	get := func(path string) string {
+		resp, err := hc.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return string(b) + " " + resp.Header.Get("X-Cache")
+	}
+	// sent returns how many requests reached the server during f.
+	sent := func(f func()) int64 {
+		before := hits.Load()
+		f()
+		return hits.Load() - before
+	}
+
+	if first, second := get("/fresh"), get("/fresh"); first != "/fresh at 1 MISS" || second != "/fresh at 1 HIT" {
+		t.Errorf("max-age=60: %q, then %q", first, second)
+	}
+	n := sent(func() {
+		resp, err := hc.Post(srv.URL+"/fresh", "text/plain", strings.NewReader("x"))
+		if err == nil {
+			client.Drain(resp)
+		}
+	})
+	if n != 1 {
+		t.Errorf("a POST: %d requests sent, want it to reach the server", n)
+	}
+	if n := sent(func() { get("/plain"); get("/plain"); time.Sleep(60 * time.Millisecond); get("/plain") }); n != 2 {
+		t.Errorf("the default TTL of 50ms: %d requests sent for 3 GETs, want 2", n)
+	}
+
+	// full: /a makes room by dropping the least recently used, /plain.
+	get("/fresh")
+	if n := sent(func() { get("/a"); get("/fresh"); get("/plain") }); n != 2 || cache.Len() != 2 {
+		t.Errorf("/a, /fresh, /plain: %d requests sent, %d kept; want /fresh a hit", n, cache.Len())
+	}
+}
+
+// TestCacheUsers runs the users client, unchanged, through the cache.
+func TestCacheUsers(t *testing.T) {
+	api := httptest.NewServer(NewAPI(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer api.Close()
+	var sent atomic.Int64
+	counted := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		sent.Add(1)
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	users := &client.Users{
+		HTTP:    &http.Client{Transport: client.NewCache(counted, 100, time.Minute), Timeout: 5 * time.Second},
+		BaseURL: api.URL,
+	}
+	ctx := context.Background()
+	if _, err := users.Create(ctx, stdlib.User{Name: "Jackson", Password: "P@ssw0rd"}); err != nil {
+		t.Fatal(err)
+	}
+	sent.Store(0)
+	for i := 0; i < 5; i++ {
+		if u, err := users.Get(ctx, "Jackson"); err != nil || u.Name != "Jackson" {
+			t.Fatalf("Get %d: %+v, %v", i, u, err)
+		}
+	}
+	if n := sent.Load(); n != 1 {
+		t.Errorf("users.Get 5 times: %d requests sent, want 1", n)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := users.Get(ctx, "Nobody"); err == nil {
+			t.Error("Get(Nobody) found a user")
+		}
+	}
+	if n := sent.Load(); n != 3 {
+		t.Errorf("a 404 twice: %d requests sent in all, want 3, the 404 not kept", n)
+	}
+}
@@ -0,0 +1,200 @@
+package httpdemo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/client"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+http.Get uses http.DefaultClient: no timeout at all, and a transport that
+keeps 2 idle connections per host. Fine for a script; a service calling
+another one a thousand times a second wants its own client, made once and
+shared (it is safe for concurrent use, and the pool of connections is in
+it):
+
+- a Transport with a pool sized for its traffic, and a timeout per step:
+  dial, TLS handshake, response headers (client.NewTransport);
+- a Timeout on the Client, for the request as a whole, body included;
+- a context per request, for the deadline of the caller: the client of a
+  server passes on r.Context(), and the call ends when the request that
+  caused it does;
+- retries of what may pass, after a backoff with jitter (client.Retry);
+- every body read to the end and closed, or the connection is lost. The
+  transport of recent Go versions reads up to 256 KB on Close, for 50ms;
+  client.Drain reads as much, whatever the version, without a time limit.
+
+The errGroup of 04.concurrent/sync fetches its URLs with client.New. The
+code that sends requests takes a client.Doer, so its tests can point it at
+an httptest.Server, as below and in client/client_test.go.
+*/
+
+func init() {
+	lessons.Register("14.http/client", "an HTTP client: connection reuse, pool size, timeouts, retries, a typed client", lessons.Checked(DemoClient))
+}
+
+// countingServer is an httptest.Server that counts the connections its
+// clients opened.
+type countingServer struct {
+	*httptest.Server
+	conns atomic.Int64
+}
+
+func newCountingServer(h http.Handler) *countingServer {
+	s := &countingServer{Server: httptest.NewUnstartedServer(h)}
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.conns.Add(1)
+		}
+	}
+	s.Start()
+	return s
+}
+
+// DemoClient measures connection reuse and pooling, then shows timeouts,
+// retries and the users client against test servers; client_test.go has
+// the cases.
+func DemoClient() error {
+	fmt.Println("== 20 requests one after the other")
+	big := newCountingServer(sized())
+	defer big.Close()
+	for _, tt := range []struct {
+		name   string
+		size   int
+		finish func(*http.Response)
+	}{
+		{"1 MB, Close unread", 1 << 20, func(resp *http.Response) { resp.Body.Close() }},
+		{"1 MB, read to the end", 1 << 20, readAll},
+		{"64 KB, Drain", 64 << 10, client.Drain},
+	} {
+		n, err := reuseConns(big, tt.size, tt.finish)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("  %-24s %2d connections\n", tt.name, n)
+	}
+
+	fmt.Println("== 5 rounds of 10 requests at once")
+	busy := newCountingServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond) // the 10 requests of a round overlap
+	}))
+	defer busy.Close()
+	fmt.Printf("  %-24s %2d connections\n", "default: 2 idle per host", poolConns(busy, http.DefaultTransport.(*http.Transport).Clone()))
+	fmt.Printf("  %-24s %2d connections\n", "NewTransport: 32", poolConns(busy, client.NewTransport()))
+
+	fmt.Println("== timeouts")
+	api := httptest.NewServer(NewAPI(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer api.Close()
+	hc := client.New(10 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, api.URL+"/slow?d=2s", nil)
+	_, err := hc.Do(req)
+	fmt.Println("  the context:", err)
+	hc.Timeout = 50 * time.Millisecond
+	_, err = hc.Get(api.URL + "/slow?d=2s")
+	fmt.Println("  Client.Timeout:", err)
+
+	fmt.Println("== retries")
+	var calls atomic.Int64
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "ok")
+	}))
+	defer flaky.Close()
+	hc = &http.Client{Transport: &client.Retry{Next: client.NewTransport(), MinBackoff: 10 * time.Millisecond}}
+	resp, err := hc.Get(flaky.URL)
+	if err != nil {
+		return err
+	}
+	client.Drain(resp)
+	fmt.Printf("  503, 503, then %d: %d calls\n", resp.StatusCode, calls.Load())
+	rt := &client.Retry{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	var waits []string
+	for attempt := 0; attempt < 5; attempt++ {
+		waits = append(waits, rt.Backoff(attempt).Round(time.Millisecond).String())
+	}
+	fmt.Println("  backoff, up to 100ms, 200ms, 400ms, 800ms, 1s:", strings.Join(waits, " "))
+
+	fmt.Println("== the users client")
+	users := &client.Users{HTTP: client.New(5 * time.Second), BaseURL: api.URL}
+	ctx = context.Background()
+	if _, err := users.Create(ctx, stdlib.User{Name: "Jackson", Password: "P@ssw0rd", Email: []string{"j@example.com"}}); err != nil {
+		return err
+	}
+	u, err := users.Get(ctx, "Jackson")
+	fmt.Printf("  Get(Jackson): %+v, %v\n", u, err)
+	_, err = users.Get(ctx, "Nobody")
+	fmt.Println("  Get(Nobody):", err)
+	_, err = users.Create(ctx, stdlib.User{Name: "Jo"})
+	fmt.Println("  Create(Jo):", err)
+	return nil
+}
+
+// sized answers ?n=N with N bytes.
+func sized() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+		io.WriteString(w, strings.Repeat("x", n))
+	})
+}
+
+func readAll(resp *http.Response) {
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// reuseConns sends 20 requests for size bytes one after the other, ends
+// each response with finish, and returns the connections they opened.
+func reuseConns(srv *countingServer, size int, finish func(*http.Response)) (int64, error) {
+	hc := &http.Client{Transport: client.NewTransport()}
+	before := srv.conns.Load()
+	for i := 0; i < 20; i++ {
+		resp, err := hc.Get(fmt.Sprintf("%s?n=%d", srv.URL, size))
+		if err != nil {
+			return 0, err
+		}
+		finish(resp)
+	}
+	return srv.conns.Load() - before, nil
+}
+
+// poolConns sends 5 rounds of 10 requests at once through transport, and
+// returns the connections they opened. The default pool keeps 2 idle
+// connections of the 10 of a round: the 8 others are dialled again every
+// round, some 42 in all. A pool of 10 or more keeps them all.
+func poolConns(srv *countingServer, transport *http.Transport) int64 {
+	hc := &http.Client{Transport: transport}
+	before := srv.conns.Load()
+	for round := 0; round < 5; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if resp, err := hc.Get(srv.URL); err == nil {
+					client.Drain(resp)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	transport.CloseIdleConnections()
+	return srv.conns.Load() - before
+}
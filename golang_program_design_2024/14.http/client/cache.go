@@ -0,0 +1,153 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/lru"
+)
+
+// Cache is an http.RoundTripper keeping the responses to GET requests in an
+// LRU cache, by URL: the same URL asked again within the TTL is answered
+// from memory, with an X-Cache: HIT header, and the server never sees it.
+//
+// It is a private cache of the simple kind, not RFC 9111:
+//
+//   - only GET requests without an Authorization header, and 200 responses;
+//   - the max-age of the response is its TTL, the TTL of the Cache if it
+//     has none; no-store, no-cache, private or max-age=0 keep it out;
+//   - a request with Cache-Control no-cache or no-store goes to the server;
+//   - responses with a Vary header, or a body over 1 MB, are not kept:
+//     the key would need more than the URL, or the memory would go to a
+//     few large bodies.
+//
+// Put it above Retry: a hit needs no retry, and a miss is retried like
+// any request.
+type Cache struct {
+	Next http.RoundTripper // http.DefaultTransport if nil
+	TTL  time.Duration     // for responses without a max-age
+
+	entries *lru.Sync[string, *cachedResponse]
+}
+
+// maxCachedBody is the largest body Cache keeps.
+const maxCachedBody = 1 << 20
+
+// cachedResponse is what Cache keeps of a response: enough to make a new
+// one for each hit, the body read once into memory.
+type cachedResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// NewCache returns a Cache of size responses in front of next.
+func NewCache(next http.RoundTripper, size int, ttl time.Duration) *Cache {
+	return &Cache{Next: next, TTL: ttl, entries: lru.NewSync(lru.New[string, *cachedResponse](size))}
+}
+
+func (c *Cache) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := c.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if !cacheableRequest(req) {
+		return next.RoundTrip(req)
+	}
+	key := req.URL.String()
+	if cr, ok := c.entries.Get(key); ok {
+		return cr.response(req, "HIT"), nil
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	ttl, ok := c.ttl(resp)
+	if !ok {
+		return resp, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCachedBody+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if len(body) > maxCachedBody {
+		// too large: the caller reads it from the network, what was read first
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	cr := &cachedResponse{status: resp.StatusCode, header: resp.Header.Clone(), body: body}
+	c.entries.PutTTL(key, cr, ttl)
+	return cr.response(req, "MISS"), nil
+}
+
+// Len returns the number of responses in the cache.
+func (c *Cache) Len() int { return c.entries.Len() }
+
+func cacheableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.Header.Get("Authorization") != "" {
+		return false
+	}
+	cc := req.Header.Get("Cache-Control")
+	return !hasDirective(cc, "no-cache") && !hasDirective(cc, "no-store")
+}
+
+// ttl returns how long resp may be kept, and false if it may not be.
+func (c *Cache) ttl(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Vary") != "" {
+		return 0, false
+	}
+	cc := resp.Header.Get("Cache-Control")
+	if hasDirective(cc, "no-store") || hasDirective(cc, "no-cache") || hasDirective(cc, "private") {
+		return 0, false
+	}
+	for _, d := range strings.Split(cc, ",") {
+		if v, ok := strings.CutPrefix(strings.TrimSpace(d), "max-age="); ok {
+			secs, err := strconv.Atoi(v)
+			if err != nil || secs <= 0 {
+				return 0, false
+			}
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+	return c.TTL, c.TTL > 0
+}
+
+// hasDirective reports whether the Cache-Control value cc has directive,
+// alone or with a value.
+func hasDirective(cc, directive string) bool {
+	for _, d := range strings.Split(cc, ",") {
+		d = strings.ToLower(strings.TrimSpace(d))
+		if d == directive || strings.HasPrefix(d, directive+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// response makes a new response to req from cr: every hit gets its own
+// header and body, a caller changing them does not change the cache.
+func (cr *cachedResponse) response(req *http.Request, xcache string) *http.Response {
+	h := cr.header.Clone()
+	h.Set("X-Cache", xcache)
+	return &http.Response{
+		Status:        strconv.Itoa(cr.status) + " " + http.StatusText(cr.status),
+		StatusCode:    cr.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(cr.body)),
+		ContentLength: int64(len(cr.body)),
+		Request:       req,
+	}
+}
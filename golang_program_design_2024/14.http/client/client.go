@@ -0,0 +1,111 @@
+// Package client is the client side of 14.http: an http.Client tuned for
+// talking to a few hosts a lot, retries and a response cache as
+// RoundTrippers, and a typed client of the users API of the chapter.
+//
+//	hc := client.New(10 * time.Second) // pooled connections, retries
+//	users := &client.Users{HTTP: hc, BaseURL: "http://localhost:8080"}
+//	u, err := users.Get(ctx, "Jackson") // a *StatusError for a 404
+//
+// The code that sends requests depends on Doer, not on *http.Client: a
+// test gives it an httptest.Server, or a fake Doer, instead of the network.
+//
+// Two rules for every response: close the body, and read it to the end
+// first. A body closed before its end closes the connection with it, unless
+// the transport reads the rest itself (recent versions of Go do, up to 256
+// KB, for 50ms); read to the end, the connection goes back to the pool for
+// the next request. Drain does both.
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Doer sends a request: *http.Client is one, so is anything wrapping it.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// NewTransport returns a Transport for a client talking to a few hosts,
+// many requests each. The settings that differ from http.DefaultTransport:
+//
+//	MaxIdleConnsPerHost    2 by default: with 10 requests at once, 8
+//	                       connections are closed after each round and
+//	                       dialled again for the next
+//	ResponseHeaderTimeout  none by default: a server that accepts and never
+//	                       answers holds the request until its context ends
+//
+// The timeouts of each step bound what the context of a request may not:
+// dialling, the TLS handshake, the wait for the headers.
+func NewTransport() *http.Transport {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, KeepAlive: 30 * time.Second}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   32,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: 10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// New returns a client with a NewTransport behind a Retry, and timeout for
+// each request as a whole, retries included: the headers, the body, and
+// the waits between attempts. A request with a shorter deadline in its
+// context ends at that deadline.
+func New(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Transport: &Retry{Next: NewTransport()},
+		Timeout:   timeout,
+	}
+}
+
+// maxDrain bounds what Drain reads: a body larger than that costs more to
+// read than a new connection. The transport draining on Close stops there
+// too.
+const maxDrain = 256 << 10
+
+// Drain reads what is left of the body of resp, up to 256 KB, and closes
+// it, so the connection can be reused.
+func Drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	resp.Body.Close()
+}
+
+// StatusError is a response that is not 2xx.
+type StatusError struct {
+	Method string
+	URL    string
+	Status int
+	Msg    string // the "error" of the JSON body, or the start of the body
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.Status, e.Msg)
+}
+
+// checkStatus returns a *StatusError for a response that is not 2xx, after
+// reading its message and draining it.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode/100 == 2 {
+		return nil
+	}
+	defer Drain(resp)
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<10))
+	msg := strings.TrimSpace(string(body))
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Error != "" {
+		msg = e.Error
+	}
+	return &StatusError{Method: resp.Request.Method, URL: resp.Request.URL.String(), Status: resp.StatusCode, Msg: msg}
+}
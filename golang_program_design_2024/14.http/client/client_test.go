@@ -0,0 +1,322 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	httpdemo "github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/client"
+)
+
+// newAPI serves the users API of the chapter for the length of the test.
+func newAPI(t *testing.T) *client.Users {
+	t.Helper()
+	srv := httptest.NewServer(httpdemo.NewAPI(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	t.Cleanup(srv.Close)
+	return &client.Users{HTTP: client.New(5 * time.Second), BaseURL: srv.URL}
+}
+
+func TestUsers(t *testing.T) {
+	users := newAPI(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"Jackson", "Ana Lee"} {
+		in := stdlib.User{Name: name, Password: "P@ssw0rd", Email: []string{"a@example.com"}}
+		got, err := users.Create(ctx, in)
+		if err != nil {
+			t.Fatalf("Create(%s): %v", name, err)
+		}
+		if got.Name != name || got.Password != "" {
+			t.Errorf("Create(%s) = %+v, want the name and no password", name, got)
+		}
+	}
+	u, err := users.Get(ctx, "Ana Lee") // a space in the path
+	if err != nil || u.Name != "Ana Lee" {
+		t.Errorf("Get = %+v, %v", u, err)
+	}
+	list, err := users.List(ctx)
+	if err != nil || len(list) != 2 || list[0].Name != "Ana Lee" {
+		t.Errorf("List = %+v, %v", list, err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		call   func() error
+		status int
+	}{
+		{"unknown user", func() error { _, err := users.Get(ctx, "Nobody"); return err }, 404},
+		{"invalid user", func() error { _, err := users.Create(ctx, stdlib.User{Name: "Jo"}); return err }, 422},
+		{"existing user", func() error {
+			_, err := users.Create(ctx, stdlib.User{Name: "Jackson", Password: "P@ssw0rd"})
+			return err
+		}, 409},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var se *client.StatusError
+			if err := tt.call(); !errors.As(err, &se) || se.Status != tt.status {
+				t.Errorf("err = %v, want a *StatusError %d", err, tt.status)
+			}
+		})
+	}
+}
+
+// doerFunc is a Doer from a function: a fake server, without the network.
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func respond(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestUsersFake(t *testing.T) {
+	var sent *http.Request
+	var sentBody string
+	users := &client.Users{BaseURL: "http://api.test/", HTTP: doerFunc(func(req *http.Request) (*http.Response, error) {
+		sent = req
+		b, _ := io.ReadAll(req.Body)
+		sentBody = string(b)
+		return respond(req, 201, `{"name": "Jackson"}`), nil
+	})}
+	if _, err := users.Create(context.Background(), stdlib.User{Name: "Jackson", Password: "P@ssw0rd"}); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Method != "POST" || sent.URL.String() != "http://api.test/users" {
+		t.Errorf("sent %s %s", sent.Method, sent.URL)
+	}
+	if sent.Header.Get("Content-Type") != "application/json" || !strings.Contains(sentBody, `"password":"P@ssw0rd"`) {
+		t.Errorf("sent %s with %s", sent.Header.Get("Content-Type"), sentBody)
+	}
+
+	// an error that is not JSON: the message is the body.
+	users.HTTP = doerFunc(func(req *http.Request) (*http.Response, error) {
+		return respond(req, 502, "upstream down\n"), nil
+	})
+	_, err := users.Get(context.Background(), "Jackson")
+	var se *client.StatusError
+	if !errors.As(err, &se) || se.Status != 502 || se.Msg != "upstream down" {
+		t.Errorf("err = %#v", err)
+	}
+
+	wantErr := errors.New("no route to host")
+	users.HTTP = doerFunc(func(*http.Request) (*http.Response, error) { return nil, wantErr })
+	if _, err := users.List(context.Background()); !errors.Is(err, wantErr) {
+		t.Errorf("err = %v, want %v", err, wantErr)
+	}
+}
+
+// script is a server answering the statuses of its list in turn, then 200,
+// and keeping the bodies it received.
+type script struct {
+	mu         sync.Mutex
+	statuses   []int
+	retryAfter string
+	bodies     []string
+}
+
+func (s *script) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bodies = append(s.bodies, string(b))
+	if len(s.bodies) > len(s.statuses) {
+		io.WriteString(w, "ok")
+		return
+	}
+	if s.retryAfter != "" {
+		w.Header().Set("Retry-After", s.retryAfter)
+	}
+	w.WriteHeader(s.statuses[len(s.bodies)-1])
+}
+
+func TestRetry(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		header     string // an Idempotency-Key, if any
+		statuses   []int
+		retryAfter string
+		calls      int
+		status     int
+	}{
+		{"ok at once", "GET", "", nil, "", 1, 200},
+		{"503 then ok", "GET", "", []int{503}, "", 2, 200},
+		{"502, 504, 503 then ok", "GET", "", []int{502, 504, 503}, "", 4, 200},
+		{"more failures than retries", "GET", "", []int{503, 503, 503, 503, 503}, "", 4, 503},
+		{"500 is not retried", "GET", "", []int{500}, "", 1, 500},
+		{"404 is not retried", "GET", "", []int{404}, "", 1, 404},
+		{"POST after 502: not retried", "POST", "", []int{502}, "", 1, 502},
+		{"POST after 429: retried", "POST", "", []int{429}, "", 2, 200},
+		{"POST with an Idempotency-Key", "POST", "order-42", []int{502, 502}, "", 3, 200},
+		{"Retry-After obeyed", "GET", "", []int{429}, "0", 2, 200},
+		{"Retry-After too long", "GET", "", []int{429}, "3600", 1, 429},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &script{statuses: tt.statuses, retryAfter: tt.retryAfter}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+			hc := &http.Client{Transport: &client.Retry{MinBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}}
+
+			req, _ := http.NewRequest(tt.method, srv.URL, strings.NewReader("body"))
+			if tt.header != "" {
+				req.Header.Set("Idempotency-Key", tt.header)
+			}
+			resp, err := hc.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.Drain(resp)
+			if resp.StatusCode != tt.status || len(s.bodies) != tt.calls {
+				t.Errorf("got %d after %d calls, want %d after %d", resp.StatusCode, len(s.bodies), tt.status, tt.calls)
+			}
+			for i, b := range s.bodies {
+				if b != "body" {
+					t.Errorf("call %d sent %q, want the body again", i, b)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	srv := httptest.NewServer(&script{statuses: []int{503, 503, 503}})
+	defer srv.Close()
+	hc := &http.Client{Transport: &client.Retry{MinBackoff: time.Second, MaxBackoff: time.Second}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+	start := time.Now()
+	_, err := hc.Do(req)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("took %s: the backoff ignored the context", took)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	rt := &client.Retry{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt := 0; attempt < 8; attempt++ {
+		ceiling := min(100*time.Millisecond<<attempt, time.Second)
+		for i := 0; i < 100; i++ {
+			if d := rt.Backoff(attempt); d < 0 || d > ceiling {
+				t.Fatalf("Backoff(%d) = %s, want in [0, %s]", attempt, d, ceiling)
+			}
+		}
+	}
+	if d := rt.Backoff(100); d < 0 || d > time.Second {
+		t.Errorf("Backoff(100) = %s: the shift overflowed", d)
+	}
+}
+
+func TestCache(t *testing.T) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if cc := r.URL.Query().Get("cc"); cc != "" {
+			w.Header().Set("Cache-Control", cc)
+		}
+		if r.URL.Query().Has("vary") {
+			w.Header().Set("Vary", "Accept-Language")
+		}
+		if r.URL.Query().Has("missing") {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Has("large") {
+			io.WriteString(w, strings.Repeat("x", 2<<20))
+			return
+		}
+		io.WriteString(w, "body")
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		query  string
+		header string // a request header, "Name: value"
+		sent   int64  // requests to the server for two GETs
+	}{
+		{"default TTL", "", "", 1},
+		{"max-age", "cc=max-age=60", "", 1},
+		{"max-age=0", "cc=max-age=0", "", 2},
+		{"no-store", "cc=no-store", "", 2},
+		{"private", "cc=public,+private", "", 2},
+		{"Vary", "vary", "", 2},
+		{"404", "missing", "", 2},
+		{"over 1 MB", "large", "", 2},
+		{"request no-cache", "", "Cache-Control: no-cache", 2},
+		{"Authorization", "", "Authorization: Bearer x", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hc := &http.Client{Transport: client.NewCache(nil, 10, time.Minute)}
+			before := hits.Load()
+			var bodies []string
+			for i := 0; i < 2; i++ {
+				req, _ := http.NewRequest(http.MethodGet, srv.URL+"/?"+tt.query, nil)
+				if name, value, ok := strings.Cut(tt.header, ": "); ok {
+					req.Header.Set(name, value)
+				}
+				resp, err := hc.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if err != nil {
+					t.Fatal(err)
+				}
+				bodies = append(bodies, string(b))
+			}
+			if n := hits.Load() - before; n != tt.sent {
+				t.Errorf("%d requests sent, want %d", n, tt.sent)
+			}
+			if bodies[0] != bodies[1] {
+				t.Errorf("the bodies differ: %d and %d bytes", len(bodies[0]), len(bodies[1]))
+			}
+		})
+	}
+}
+
+// TestCacheCopies checks that a caller changing a hit does not change the
+// next one.
+func TestCacheCopies(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Version", "1")
+		io.WriteString(w, "body")
+	}))
+	defer srv.Close()
+	hc := &http.Client{Transport: client.NewCache(nil, 10, time.Minute)}
+
+	for i, want := range []string{"MISS", "HIT", "HIT"} {
+		resp, err := hc.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if got := resp.Header.Get("X-Cache"); got != want || string(b) != "body" || resp.Header.Get("X-Version") != "1" {
+			t.Errorf("GET %d: X-Cache %s, body %q, header %v", i, got, b, resp.Header)
+		}
+		resp.Header.Set("X-Version", "changed")
+	}
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/09.projects/apiclient"
+)
+
+// Retry is an http.RoundTripper that sends a request again after a failure
+// that may pass: a network error, 429 Too Many Requests, 502, 503 or 504.
+// It is to the client what middleware is to the server: it wraps the
+// transport, and every request of the client goes through it.
+//
+// Only what is safe to send twice is retried after a network error or a
+// 502 or 504, where the server may have done the work: the idempotent
+// methods, or a request with an Idempotency-Key header. 429 and 503 say
+// that nothing was done: any request is retried. A request with a body is
+// retried only if it has GetBody, which http.NewRequest sets for the
+// readers of bytes and strings.
+//
+// 09.projects/apiclient retries the same way one level up, in a Client,
+// with a rate limit on top; its RetryAfter reads the header for both.
+type Retry struct {
+	Next       http.RoundTripper // http.DefaultTransport if nil
+	MaxRetries int               // 3 if 0; -1 for none
+	MinBackoff time.Duration     // 100ms if 0
+	MaxBackoff time.Duration     // 2s if 0; also the longest Retry-After obeyed
+}
+
+func (rt *Retry) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := rt.Next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	maxRetries := rt.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = 3
+	}
+	ctx := req.Context()
+	attemptReq := req
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(ctx)
+			attemptReq.Body = body
+		}
+		resp, err := next.RoundTrip(attemptReq)
+		if attempt >= maxRetries || ctx.Err() != nil || !rt.retryable(req, resp, err) {
+			return resp, err
+		}
+		delay := rt.Backoff(attempt)
+		if resp != nil {
+			if d, ok := apiclient.RetryAfter(resp.Header.Get("Retry-After")); ok {
+				if d > rt.maxBackoff() {
+					return resp, nil // longer than we wait: the caller sees the 429
+				}
+				delay = d
+			}
+			Drain(resp) // the connection serves the next attempt
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// retryable reports whether the outcome of an attempt is worth another.
+func (rt *Retry) retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false // the body is spent
+	}
+	safe := idempotent(req)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false // the host does not exist: it will not exist in 100ms either
+		}
+		return safe && !errors.Is(err, context.Canceled)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return safe
+	}
+	return false
+}
+
+func idempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func (rt *Retry) maxBackoff() time.Duration {
+	if rt.MaxBackoff == 0 {
+		return 2 * time.Second
+	}
+	return rt.MaxBackoff
+}
+
+// Backoff returns the wait after the failed attempt: a random duration up
+// to MinBackoff << attempt, capped at MaxBackoff. The ceiling doubles at
+// each attempt, so a server that is down is not hammered; the randomness,
+// the jitter, spreads the clients that failed together, which would
+// otherwise all come back at the same instant and fail together again.
+func (rt *Retry) Backoff(attempt int) time.Duration {
+	d := rt.MinBackoff
+	if d == 0 {
+		d = 100 * time.Millisecond
+	}
+	d <<= attempt
+	if d > rt.maxBackoff() || d <= 0 {
+		d = rt.maxBackoff()
+	}
+	return time.Duration(rand.Int63n(int64(d) + 1))
+}
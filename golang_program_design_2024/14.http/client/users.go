@@ -0,0 +1,76 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+)
+
+// Users is a client of the users API of 14.http (see httpdemo.NewAPI). Every
+// method takes the context of the request: its deadline and cancellation
+// are those of the caller, not of the client.
+type Users struct {
+	HTTP    Doer
+	BaseURL string // e.g. http://localhost:8080, without the trailing slash
+}
+
+// Create adds u, sending its password, which the json:"-" of User keeps out
+// of a plain json.Marshal.
+func (c *Users) Create(ctx context.Context, u stdlib.User) (stdlib.User, error) {
+	body, err := json.Marshal(struct {
+		stdlib.User
+		Password string `json:"password"`
+	}{u, u.Password})
+	if err != nil {
+		return stdlib.User{}, err
+	}
+	var out stdlib.User
+	err = c.do(ctx, http.MethodPost, "/users", body, &out)
+	return out, err
+}
+
+// Get returns the user named name, or a *StatusError with Status 404.
+func (c *Users) Get(ctx context.Context, name string) (stdlib.User, error) {
+	var u stdlib.User
+	err := c.do(ctx, http.MethodGet, "/users/"+url.PathEscape(name), nil, &u)
+	return u, err
+}
+
+// List returns every user, by name.
+func (c *Users) List(ctx context.Context) ([]stdlib.User, error) {
+	var list []stdlib.User
+	err := c.do(ctx, http.MethodGet, "/users", nil, &list)
+	return list, err
+}
+
+// do sends a request with body as JSON, if not nil, and decodes the answer
+// into out.
+func (c *Users) do(ctx context.Context, method, path string, body []byte, out any) error {
+	var r io.Reader // a nil *bytes.Reader would not be a nil io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	if err := checkStatus(resp); err != nil {
+		return err
+	}
+	defer Drain(resp)
+	return json.NewDecoder(resp.Body).Decode(out)
+}
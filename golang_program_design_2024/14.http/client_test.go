@@ -0,0 +1,76 @@
+package httpdemo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/client"
+)
+
+// TestReuse checks that a connection is reused only once the body before
+// was read to the end.
+func TestReuse(t *testing.T) {
+	srv := newCountingServer(sized())
+	defer srv.Close()
+	for _, tt := range []struct {
+		name   string
+		size   int
+		finish func(*http.Response)
+		conns  int64
+	}{
+		{"1 MB, Close unread: a connection each", 1 << 20, func(resp *http.Response) { resp.Body.Close() }, 20},
+		{"1 MB, read to the end: one connection", 1 << 20, readAll, 1},
+		{"64 KB, Drain: one connection", 64 << 10, client.Drain, 1},
+		{"empty, Drain: one connection", 0, client.Drain, 1},
+	} {
+		n, err := reuseConns(srv, tt.size, tt.finish)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != tt.conns {
+			t.Errorf("%s: %d connections", tt.name, n)
+		}
+	}
+}
+
+func TestPool(t *testing.T) {
+	srv := newCountingServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond) // the 10 requests of a round overlap
+	}))
+	defer srv.Close()
+	if n := poolConns(srv, http.DefaultTransport.(*http.Transport).Clone()); n <= 20 {
+		t.Errorf("the default pool: %d connections for 50 requests, want the 8 of each round dialled again", n)
+	}
+	if n := poolConns(srv, client.NewTransport()); n > 12 {
+		t.Errorf("NewTransport: %d connections, want 10, give or take a race between rounds", n)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	srv := httptest.NewServer(NewAPI(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer srv.Close()
+	hc := client.New(10 * time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/slow?d=2s", nil)
+	start := time.Now()
+	_, err := hc.Do(req)
+	if took := time.Since(start); !errors.Is(err, context.DeadlineExceeded) || took > time.Second {
+		t.Errorf("the deadline of the context: %v after %s", err, took)
+	}
+
+	hc.Timeout = 50 * time.Millisecond
+	_, err = hc.Get(srv.URL + "/slow?d=2s")
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Errorf("the Timeout of the client: %v", err)
+	}
+}
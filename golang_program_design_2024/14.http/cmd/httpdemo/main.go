@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	httpdemo "github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/server"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+httpdemo runs the lessons of chapter 14.http, in order: handlers, routing,
+the JSON API, middleware, the graceful shutdown, the client and its cache.
+Their cases are tests: go test ./golang_program_design_2024/14.http/...
+With -serve, it serves the API of the chapter until Ctrl-C, then shuts down
+gracefully.
+
+Usage:
+
+	go run ./golang_program_design_2024/14.http/cmd/httpdemo
+	go run ./golang_program_design_2024/14.http/cmd/httpdemo -serve 127.0.0.1:8080
+	curl -i -X POST localhost:8080/users -H 'Content-Type: application/json' \
+		-d '{"name": "Jackson", "password": "P@ssw0rd"}'
+	curl -i localhost:8080/slow?d=5s   # then Ctrl-C the server: the answer still comes
+
+One lesson alone: go run ./cmd/learn run 14.http/middleware
+*/
+
+func main() {
+	addr := flag.String("serve", "", "serve the API on this address instead of running the lessons")
+	timeout := flag.Duration("shutdown-timeout", 10*time.Second, "how long to wait for the requests in flight")
+	flag.Parse()
+
+	if *addr != "" {
+		log := xlog.New("14.http", "httpdemo")
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		srv := &http.Server{
+			Addr:              *addr,
+			Handler:           httpdemo.NewAPI(log),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		log.Info("listening", "addr", *addr)
+		if err := server.Run(ctx, srv, *timeout); err != nil {
+			log.Error("serve failed", "err", err)
+			os.Exit(1)
+		}
+		log.Info("shut down")
+		return
+	}
+
+	httpdemo.DemoHandlers()
+	httpdemo.DemoRouting()
+	httpdemo.DemoJSON()
+	httpdemo.DemoMiddleware()
+	failed := 0
+	for _, demo := range []func() error{httpdemo.DemoShutdown, httpdemo.DemoClient, httpdemo.DemoCache} {
+		if err := demo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
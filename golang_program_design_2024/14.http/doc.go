@@ -0,0 +1,5 @@
+// Package httpdemo is chapter 14.http: an HTTP server with net/http,
+// handlers, routing with ServeMux, JSON in and out, middleware and a
+// graceful shutdown, then the client that calls it. The reusable parts are
+// in the server and client packages.
+package httpdemo
@@ -0,0 +1,99 @@
+package httpdemo
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A server answers requests with an http.Handler, an interface of one method:
+
+	type Handler interface {
+		ServeHTTP(w http.ResponseWriter, r *http.Request)
+	}
+
+r is what the client sent; the handler writes the answer to w: the headers,
+then the status with w.WriteHeader, then the body with w.Write. A Write
+before WriteHeader sends a 200; a header set after it is lost.
+
+A type with state implements Handler with a method. A plain function is
+adapted with http.HandlerFunc, a func type with a ServeHTTP method that
+calls the function itself. mux.HandleFunc(p, f) is mux.Handle(p,
+http.HandlerFunc(f)).
+
+net/http serves every request on a goroutine of its own: a handler with
+state is called concurrently, and guards it (an atomic counter below).
+
+httptest.NewRecorder is a ResponseWriter that keeps what is written: a
+handler can be called directly, as a function, without a server or a
+network. The lessons of this chapter test their handlers that way.
+*/
+
+func init() {
+	lessons.Register("14.http/handlers", "http.Handler and http.HandlerFunc, called through httptest.NewRecorder", DemoHandlers)
+}
+
+// hits is a Handler with state: the number of requests it has answered.
+type hits struct{ n atomic.Int64 }
+
+func (h *hits) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, "hit %d\n", h.n.Add(1))
+}
+
+// hello is a handler function: it becomes a Handler through HandlerFunc.
+func hello(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		http.Error(w, "missing name", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "hello, %s\n", name)
+	w.Header().Set("X-Too-Late", "yes") // after WriteHeader: never sent
+}
+
+// serve calls h with a request built from method, target and body, and
+// returns the recorded response.
+func serve(h http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, r)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+// DemoHandlers calls a Handler and a HandlerFunc through a recorder, then
+// through a server; handlers_test.go has the cases.
+func DemoHandlers() {
+	h := &hits{}
+	for i := 0; i < 3; i++ {
+		fmt.Print(serve(h, "GET", "/", "").Body.String())
+	}
+
+	var f http.Handler = http.HandlerFunc(hello) // a conversion, not a call
+	for _, target := range []string{"/?name=Gopher", "/"} {
+		w := serve(f, "GET", target, "")
+		fmt.Printf("GET %-14s %d %-20q Content-Type %q, X-Too-Late %q\n", target, w.Code, w.Body.String(),
+			w.Header().Get("Content-Type"), w.Result().Header.Get("X-Too-Late"))
+	}
+
+	// the same handler behind a real server: what a client sees is the
+	// same, over TCP.
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	got, err := get(srv.URL + "/?name=net")
+	fmt.Printf("GET %s/?name=net: %q, %v\n", srv.URL, got, err)
+}
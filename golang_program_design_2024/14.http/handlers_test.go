@@ -0,0 +1,57 @@
+package httpdemo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestHits(t *testing.T) {
+	h := &hits{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve(h, "GET", "/", "")
+		}()
+	}
+	wg.Wait()
+	if w := serve(h, "GET", "/", ""); w.Body.String() != "hit 11\n" {
+		t.Errorf("after 10 concurrent requests: %q", w.Body)
+	}
+}
+
+func TestHello(t *testing.T) {
+	f := http.HandlerFunc(hello)
+	for _, tt := range []struct {
+		target      string
+		code        int
+		body        string
+		contentType string
+	}{
+		{"/?name=Gopher", 200, "hello, Gopher\n", "text/plain; charset=utf-8"},
+		{"/?name=a+b", 200, "hello, a b\n", "text/plain; charset=utf-8"},
+		{"/", 400, "missing name\n", "text/plain; charset=utf-8"}, // by http.Error
+	} {
+		w := serve(f, "GET", tt.target, "")
+		if w.Code != tt.code || w.Body.String() != tt.body || w.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("GET %s: %d %q %q", tt.target, w.Code, w.Body, w.Header().Get("Content-Type"))
+		}
+		if got := w.Result().Header.Get("X-Too-Late"); got != "" {
+			t.Errorf("GET %s: a header set after WriteHeader was sent: %q", tt.target, got)
+		}
+	}
+
+	// the same behind a real server.
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	if got, err := get(srv.URL + "/?name=net"); err != nil || got != "200 hello, net" {
+		t.Errorf("through httptest.NewServer: %q, %v", got, err)
+	}
+	if got, _ := get(srv.URL); !strings.HasPrefix(got, "400 ") {
+		t.Errorf("no name, through httptest.NewServer: %q", got)
+	}
+}
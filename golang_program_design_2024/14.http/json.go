@@ -0,0 +1,139 @@
+package httpdemo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/13.reflection/validate"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/server"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A JSON API is json.go of 05.standard_lib on both ends of a connection: a
+json.Decoder reads the body of the request, a json.Encoder writes the
+response, and the struct tags of User decide the names. The server package
+wraps the steps a handler must not forget:
+
+- the Content-Type: a form posted by mistake is a 415, not a parse error;
+- the size: http.MaxBytesReader stops a body at 1 MB, a 413;
+- unknown fields: DisallowUnknownFields makes "emial" a 400, instead of an
+  email silently missing;
+- the status: the header before the body, as a handler cannot change it
+  once the first byte is out.
+
+Then the values: json.Unmarshal checks the types, validate.Struct of
+13.reflection checks the rules of the validate tags, a 422 that lists every
+invalid field. The password is in the request but never in a response: the
+json:"-" of User.Password keeps it out, so the request has a type of its
+own, with a Password that JSON reads.
+*/
+
+func init() {
+	lessons.Register("14.http/json", "a JSON API: decoding, validation, status codes, with the server package", DemoJSON)
+}
+
+// users is the store of the users API: a map guarded by a RWMutex, as
+// net/http calls the handlers concurrently.
+type users struct {
+	mu     sync.RWMutex
+	byName map[string]stdlib.User
+}
+
+// signup is the body of POST /users: a User, and the password its json:"-"
+// does not read. The Password of signup hides the one of the embedded User.
+type signup struct {
+	stdlib.User
+	Password string `json:"password"`
+}
+
+// routes registers the users API on mux.
+func (s *users) routes(mux *http.ServeMux) {
+	mux.HandleFunc("POST /users", s.create)
+	mux.HandleFunc("GET /users", s.list)
+	mux.HandleFunc("GET /users/{name}", s.get)
+}
+
+func (s *users) create(w http.ResponseWriter, r *http.Request) {
+	var in signup
+	if err := server.ReadJSON(w, r, &in); err != nil {
+		server.WriteError(w, err)
+		return
+	}
+	u := in.User
+	u.Password = in.Password
+	var invalid validate.Errors
+	if err := validate.Struct(u); errors.As(err, &invalid) {
+		fields := make(map[string]string, len(invalid))
+		for _, fe := range invalid {
+			fields[fe.Field] = fe.Msg
+		}
+		server.WriteJSON(w, http.StatusUnprocessableEntity, server.ErrorBody{Error: "validation failed", Fields: fields})
+		return
+	} else if err != nil {
+		server.WriteError(w, err) // a *TagError: a bug of ours, a 500
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, taken := s.byName[u.Name]; taken {
+		server.WriteError(w, server.Errorf(http.StatusConflict, "user %s exists", u.Name))
+		return
+	}
+	s.byName[u.Name] = u
+	w.Header().Set("Location", "/users/"+u.Name)
+	server.WriteJSON(w, http.StatusCreated, u)
+}
+
+func (s *users) get(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	u, ok := s.byName[r.PathValue("name")]
+	s.mu.RUnlock()
+	if !ok {
+		server.WriteError(w, server.Errorf(http.StatusNotFound, "no user %s", r.PathValue("name")))
+		return
+	}
+	server.WriteJSON(w, http.StatusOK, u)
+}
+
+func (s *users) list(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	list := make([]stdlib.User, 0, len(s.byName))
+	for _, u := range s.byName {
+		list = append(list, u)
+	}
+	s.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	server.WriteJSON(w, http.StatusOK, list)
+}
+
+// DemoJSON sends good and bad requests to the users API; json_test.go
+// has the cases.
+func DemoJSON() {
+	mux := http.NewServeMux()
+	(&users{byName: make(map[string]stdlib.User)}).routes(mux)
+
+	const jsonType = "application/json"
+	for _, tt := range []struct{ method, target, contentType, body string }{
+		{"POST", "/users", jsonType, `{"name": "Jackson", "password": "P@ssw0rd", "email": ["j@example.com"]}`},
+		{"POST", "/users", jsonType, `{"name": "Jackson", "password": "P@ssw0rd"}`},
+		{"POST", "/users", "application/x-www-form-urlencoded", "name=Jackson"},
+		{"POST", "/users", jsonType, `{"name": "Jackson", "emial": ["j@example.com"]}`},
+		{"POST", "/users", jsonType, `{"name": "Jo", "password": "1234", "email": ["jo at example.com"]}`},
+		{"GET", "/users/Jackson", "", ""},
+		{"GET", "/users/Nobody", "", ""},
+	} {
+		w := serve(mux, tt.method, tt.target, tt.body, "Content-Type", tt.contentType)
+		body := strings.TrimSpace(w.Body.String())
+		if len(body) > 90 {
+			body = body[:87] + "..."
+		}
+		fmt.Printf("%s %s -> %d %s\n", tt.method, tt.target, w.Code, body)
+	}
+}
@@ -0,0 +1,81 @@
+package httpdemo
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	stdlib "github.com/YongSangUn/learn-golang/golang_program_design_2024/05.standard_lib"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/server"
+)
+
+func TestUsersAPI(t *testing.T) {
+	mux := http.NewServeMux()
+	(&users{byName: make(map[string]stdlib.User)}).routes(mux)
+
+	const jsonType = "application/json"
+	for _, tt := range []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		code        int
+	}{
+		{"created", "POST", "/users", jsonType, `{"name": "Jackson", "password": "P@ssw0rd", "email": ["j@example.com"]}`, 201},
+		{"the same name again", "POST", "/users", jsonType, `{"name": "Jackson", "password": "P@ssw0rd"}`, 409},
+		{"a form, not JSON", "POST", "/users", "application/x-www-form-urlencoded", "name=Jackson", 415},
+		{"broken JSON", "POST", "/users", jsonType, `{"name": "Jackson",`, 400},
+		{"a typo in a field", "POST", "/users", jsonType, `{"name": "Jackson", "emial": ["j@example.com"]}`, 400},
+		{"two values", "POST", "/users", jsonType, `{"name": "Ana"} {"name": "Bob"}`, 400},
+		{"too large", "POST", "/users", jsonType, `{"bio": "` + strings.Repeat("x", server.MaxBodySize) + `"}`, 413},
+		{"invalid fields", "POST", "/users", jsonType, `{"name": "Jo", "password": "1234", "email": ["jo at example.com"]}`, 422},
+		{"found", "GET", "/users/Jackson", "", "", 200},
+		{"not found", "GET", "/users/Nobody", "", "", 404},
+		{"list", "GET", "/users", "", "", 200},
+	} {
+		w := serve(mux, tt.method, tt.target, tt.body, "Content-Type", tt.contentType)
+		if w.Code != tt.code {
+			t.Errorf("%s: %d %s, want %d", tt.name, w.Code, w.Body, tt.code)
+		}
+		if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, jsonType) {
+			t.Errorf("%s: Content-Type %q", tt.name, ct)
+		}
+	}
+}
+
+// TestUsersAPIBodies reads the responses: the created user without its
+// password, the fields of a 422, the list in order.
+func TestUsersAPIBodies(t *testing.T) {
+	mux := http.NewServeMux()
+	(&users{byName: make(map[string]stdlib.User)}).routes(mux)
+	for _, name := range []string{"Jackson", "Ana"} {
+		w := serve(mux, "POST", "/users", `{"name": "`+name+`", "password": "P@ssw0rd"}`, "Content-Type", "application/json")
+		if w.Code != 201 || w.Header().Get("Location") != "/users/"+name {
+			t.Fatalf("create %s: %d, Location %q", name, w.Code, w.Header().Get("Location"))
+		}
+	}
+
+	w := serve(mux, "GET", "/users/Jackson", "")
+	var got map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if _, leaked := got["password"]; leaked || got["name"] != "Jackson" {
+		t.Errorf("GET /users/Jackson = %s", w.Body)
+	}
+
+	w = serve(mux, "POST", "/users", `{"name": "Jo", "password": "1234"}`, "Content-Type", "application/json")
+	var invalid struct{ Fields map[string]string }
+	json.Unmarshal(w.Body.Bytes(), &invalid)
+	if len(invalid.Fields) != 2 || invalid.Fields["Name"] == "" || invalid.Fields["Password"] == "" {
+		t.Errorf("a 422 lists %v, want Name and Password", invalid.Fields)
+	}
+
+	var list []stdlib.User
+	json.Unmarshal(serve(mux, "GET", "/users", "").Body.Bytes(), &list)
+	if len(list) != 2 || list[0].Name != "Ana" || list[1].Name != "Jackson" {
+		t.Errorf("GET /users = %+v", list)
+	}
+}
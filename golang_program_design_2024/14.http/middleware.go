@@ -0,0 +1,77 @@
+package httpdemo
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/server"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A middleware is a func(http.Handler) http.Handler: it returns a handler that
+does its part and calls the next one, or answers instead of it. What every
+route needs (a log line, a request ID, surviving a panic, authentication as
+in 09.projects/jwtauth) is written once, around the mux, not in each
+handler.
+
+The order matters. server.Chain(mux, RequestID, Logging, Recover) is
+RequestID(Logging(Recover(mux))):
+
+- RequestID first: the ID is in the context before anything logs.
+- Logging around Recover: the 500 that Recover writes for a panic is in the
+  log line, with its duration.
+- Recover last, next to the handlers: it catches their panics, and its own
+  log line has the request ID.
+
+A middleware that needs the status of the response wraps the ResponseWriter
+in its own, which records WriteHeader. The wrapper hides the methods of the
+writer it wraps, http.Flusher among them; its Unwrap method lets
+http.NewResponseController find them again.
+*/
+
+func init() {
+	lessons.Register("14.http/middleware", "middleware chains: request ID, logging, recovery, and their order", DemoMiddleware)
+}
+
+// trace is a middleware that appends to *steps before and after the next
+// handler, to show the order of a chain.
+func trace(name string, steps *[]string) server.Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*steps = append(*steps, "> "+name)
+			next.ServeHTTP(w, r)
+			*steps = append(*steps, "< "+name)
+		})
+	}
+}
+
+// DemoMiddleware sends requests through the chain of NewAPI, logging to
+// stdout; middleware_test.go has the cases.
+func DemoMiddleware() {
+	var steps []string
+	h := server.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		steps = append(steps, "handler")
+	}), trace("A", &steps), trace("B", &steps))
+	serve(h, "GET", "/", "")
+	fmt.Println("Chain(h, A, B):", strings.Join(steps, ", "))
+
+	// the log lines without the time, to read them.
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "stack" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	api := NewAPI(log)
+	serve(api, "GET", "/users/nobody", "")
+	serve(api, "GET", "/users/nobody", "", server.RequestIDHeader, "from-the-proxy")
+	w := serve(api, "GET", "/panic", "", server.RequestIDHeader, "boom-1")
+	fmt.Printf("the panic: %d %s", w.Code, w.Body.String())
+	serve(api, "GET", "/slow?d=1ms", "")
+}
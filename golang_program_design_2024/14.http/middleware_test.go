@@ -0,0 +1,85 @@
+package httpdemo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/server"
+)
+
+func TestChain(t *testing.T) {
+	var steps []string
+	h := server.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		steps = append(steps, "handler")
+	}), trace("A", &steps), trace("B", &steps))
+	serve(h, "GET", "/", "")
+	if got := strings.Join(steps, ","); got != "> A,> B,handler,< B,< A" {
+		t.Errorf("Chain(h, A, B) ran %s, want A(B(h))", got)
+	}
+}
+
+// newLoggedAPI returns NewAPI logging to a buffer.
+func newLoggedAPI() (http.Handler, *bytes.Buffer) {
+	var logs bytes.Buffer
+	return NewAPI(slog.New(slog.NewTextHandler(&logs, nil))), &logs
+}
+
+func TestRequestID(t *testing.T) {
+	api, logs := newLoggedAPI()
+	w := serve(api, "GET", "/users/nobody", "")
+	id := w.Header().Get(server.RequestIDHeader)
+	if len(id) != 16 {
+		t.Errorf("a new request ID: %q", id)
+	}
+	if !strings.Contains(logs.String(), "status=404") || !strings.Contains(logs.String(), "request_id="+id) {
+		t.Errorf("the log line lacks the status or the ID:\n%s", logs)
+	}
+	if other := serve(api, "GET", "/", "").Header().Get(server.RequestIDHeader); other == id {
+		t.Errorf("two requests with the ID %s", id)
+	}
+
+	w = serve(api, "GET", "/users/nobody", "", server.RequestIDHeader, "from-the-proxy")
+	if got := w.Header().Get(server.RequestIDHeader); got != "from-the-proxy" {
+		t.Errorf("the ID of the request was replaced by %q", got)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	api, logs := newLoggedAPI()
+	w := serve(api, "GET", "/panic", "", server.RequestIDHeader, "boom-1")
+	if w.Code != 500 || !strings.Contains(w.Body.String(), "internal error") {
+		t.Errorf("a panic: %d %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "nil map") {
+		t.Errorf("the panic value in the response: %s", w.Body)
+	}
+	panicAt, statusAt := strings.Index(logs.String(), "msg=panic"), strings.Index(logs.String(), "status=500")
+	if panicAt < 0 || statusAt < panicAt {
+		t.Errorf("want Recover's line, then Logging's with the 500:\n%s", logs)
+	}
+	if !strings.Contains(logs.String(), "request_id=boom-1") {
+		t.Errorf("no request ID in the log:\n%s", logs)
+	}
+	if w := serve(api, "GET", "/slow?d=1ms", ""); w.Code != 200 {
+		t.Errorf("after the panic: %d %s", w.Code, w.Body)
+	}
+}
+
+// TestFlush streams through the recorders of Logging and Recover.
+func TestFlush(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := server.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "part 1")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}), server.Logging(log), server.Recover(log))
+	if w := serve(h, "GET", "/stream", ""); !w.Flushed || w.Body.String() != "part 1" {
+		t.Errorf("flushed %v, body %q", w.Flushed, w.Body)
+	}
+}
@@ -0,0 +1,101 @@
+package httpdemo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+http.ServeMux routes a request to the handler of the pattern it matches.
+Since Go 1.22 a pattern is [METHOD ][HOST]/PATH, with wildcards:
+
+	GET /users/{id}        one segment, read with r.PathValue("id")
+	/files/{path...}       the rest of the path, slashes included
+	/static/               a trailing slash: the subtree, /static/a/b too
+	/{$}                   {$} ends the path: / alone, not the subtree
+	GET /                  GET also matches HEAD
+
+When several patterns match, the most specific wins, whatever the order
+they were registered in: /users/new beats /users/{id}, and GET /users/{id}
+beats /users/{id}. Two patterns where neither is more specific, such as
+/users/{id} and /{x}/new, panic at registration, not at run time.
+
+The mux answers on its own: a 404 for no match, a 405 with an Allow header
+for a path whose patterns want another method, and a redirect to /static/
+for /static, when only the subtree is registered. Before Go 1.22 the mux knew
+neither methods nor wildcards: the handlers checked r.Method and cut
+r.URL.Path themselves, or a third-party router did it.
+*/
+
+func init() {
+	lessons.Register("14.http/routing", "ServeMux patterns: methods, wildcards, precedence, 404 and 405", DemoRouting)
+}
+
+// echoRoute answers with the name of the route and its wildcards, so the
+// demo sees which one matched.
+func echoRoute(name string, wildcards ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		out := name
+		for _, wc := range wildcards {
+			out += " " + wc + "=" + r.PathValue(wc)
+		}
+		fmt.Fprint(w, out)
+	}
+}
+
+// DemoRouting sends requests through a mux and shows the route of each;
+// routing_test.go has the cases.
+func DemoRouting() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", echoRoute("home"))
+	mux.HandleFunc("GET /users/{id}", echoRoute("get user", "id"))
+	mux.HandleFunc("DELETE /users/{id}", echoRoute("delete user", "id"))
+	mux.HandleFunc("GET /users/new", echoRoute("new user form"))
+	mux.HandleFunc("/users/{id}/posts/{post}", echoRoute("post, any method", "id", "post"))
+	mux.HandleFunc("GET /files/{path...}", echoRoute("file", "path"))
+	mux.HandleFunc("/static/", echoRoute("static subtree"))
+	mux.HandleFunc("api.example.com/", echoRoute("api host"))
+
+	for _, tt := range []struct{ method, target string }{
+		{"GET", "/users/42"},
+		{"DELETE", "/users/42"},
+		{"GET", "/users/new"},
+		{"PUT", "/users/7/posts/3"},
+		{"GET", "/files/docs/a/b.txt"},
+		{"GET", "http://api.example.com/users/42"},
+		{"GET", "/nope"},
+		{"POST", "/users/42"},
+		{"GET", "/static"},
+	} {
+		w := serve(mux, tt.method, tt.target, "")
+		detail := fmt.Sprintf("%d %q", w.Code, strings.TrimSpace(w.Body.String()))
+		switch {
+		case w.Code/100 == 3:
+			detail = fmt.Sprintf("%d Location: %s", w.Code, w.Header().Get("Location"))
+		case w.Code == 405:
+			detail += " Allow: " + w.Header().Get("Allow")
+		}
+		fmt.Printf("%-6s %-34s %s\n", tt.method, tt.target, detail)
+	}
+
+	// a conflict is a bug of the program: found at registration.
+	err := catch(func() {
+		mux.HandleFunc("/{x}/new", echoRoute("conflict"))
+	})
+	first, _, _ := strings.Cut(fmt.Sprint(err), "\n") // the message goes on to explain why
+	fmt.Println("a conflict:", first)
+}
+
+// catch runs f and returns what it panicked with, as an error, or nil.
+func catch(f func()) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("%v", v)
+		}
+	}()
+	f()
+	return nil
+}
@@ -0,0 +1,58 @@
+package httpdemo
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRouting(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", echoRoute("home"))
+	mux.HandleFunc("GET /users/{id}", echoRoute("get user", "id"))
+	mux.HandleFunc("DELETE /users/{id}", echoRoute("delete user", "id"))
+	mux.HandleFunc("GET /users/new", echoRoute("new user form"))
+	mux.HandleFunc("/users/{id}/posts/{post}", echoRoute("post, any method", "id", "post"))
+	mux.HandleFunc("GET /files/{path...}", echoRoute("file", "path"))
+	mux.HandleFunc("/static/", echoRoute("static subtree"))
+	mux.HandleFunc("api.example.com/", echoRoute("api host"))
+
+	for _, tt := range []struct {
+		method, target string
+		code           int
+		body           string // or the Allow header of a 405, the Location of a redirect
+	}{
+		{"GET", "/", 200, "home"},
+		{"GET", "/users/42", 200, "get user id=42"},
+		{"HEAD", "/users/42", 200, "get user id=42"}, // a server drops the body
+		{"DELETE", "/users/42", 200, "delete user id=42"},
+		{"GET", "/users/new", 200, "new user form"},
+		{"PUT", "/users/7/posts/3", 200, "post, any method id=7 post=3"},
+		{"GET", "/files/docs/a/b.txt", 200, "file path=docs/a/b.txt"},
+		{"GET", "/static/css/site.css", 200, "static subtree"},
+		{"GET", "http://api.example.com/users/42", 200, "api host"},
+		{"GET", "/nope", 404, "404 page not found\n"},
+		{"GET", "/users/42/", 404, "404 page not found\n"},
+		{"POST", "/users/42", 405, "DELETE, GET, HEAD"},
+		{"GET", "/static", 307, "/static/"}, // a 301 in older versions of Go
+	} {
+		w := serve(mux, tt.method, tt.target, "")
+		body := w.Body.String()
+		switch {
+		case w.Code/100 == 3:
+			body = w.Header().Get("Location") // the body is an HTML link to it
+		case w.Code == 405:
+			body = w.Header().Get("Allow")
+		}
+		code := w.Code == tt.code || w.Code/100 == 3 && tt.code/100 == 3
+		if !code || body != tt.body {
+			t.Errorf("%s %s: %d %q, want %d %q", tt.method, tt.target, w.Code, body, tt.code, tt.body)
+		}
+	}
+
+	// a conflict is found at registration.
+	err := catch(func() { mux.HandleFunc("/{x}/new", echoRoute("conflict")) })
+	if err == nil || !strings.Contains(err.Error(), "conflicts with") {
+		t.Errorf("a conflicting pattern: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Package server has the pieces every HTTP server of the chapters writes
+// again: JSON in and out, middleware, and a graceful shutdown.
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+//		var u User
+//		if err := server.ReadJSON(w, r, &u); err != nil {
+//			server.WriteError(w, err) // 400, 413 or 415
+//			return
+//		}
+//		server.WriteJSON(w, http.StatusCreated, u)
+//	})
+//	h := server.Chain(mux, server.RequestID, server.Logging(log), server.Recover(log))
+//
+//	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+//	defer stop()
+//	err := server.Run(ctx, &http.Server{Addr: ":8080", Handler: h}, 10*time.Second)
+//
+// The JSON follows 05.standard_lib/json.go: struct tags name the fields, an
+// Encoder writes to the ResponseWriter, a Decoder reads the body.
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+)
+
+// MaxBodySize is the largest body ReadJSON accepts: a client cannot make
+// the server read a gigabyte to find out it is not a User.
+const MaxBodySize = 1 << 20
+
+// Error is an error with the status to answer it with. ReadJSON returns
+// one for what the client got wrong; a handler may return its own.
+type Error struct {
+	Status int
+	Msg    string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("%d %s", e.Status, e.Msg) }
+
+// Errorf returns an *Error with the message formatted as fmt.Sprintf does.
+func Errorf(status int, format string, args ...any) *Error {
+	return &Error{Status: status, Msg: fmt.Sprintf(format, args...)}
+}
+
+// ReadJSON decodes the body of r, a single JSON object, into v. Unknown
+// fields are an error: a typo such as "emial" is reported instead of
+// silently ignored. The error is an *Error:
+//
+//	415  the Content-Type is not application/json
+//	413  the body is larger than MaxBodySize
+//	400  the JSON is invalid, does not fit v, or is followed by more
+func ReadJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	if mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mt != "application/json" {
+		return Errorf(http.StatusUnsupportedMediaType, "content type must be application/json")
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			return Errorf(http.StatusRequestEntityTooLarge, "body larger than %d bytes", tooBig.Limit)
+		}
+		return Errorf(http.StatusBadRequest, "invalid JSON: %v", err)
+	}
+	if dec.More() {
+		return Errorf(http.StatusBadRequest, "invalid JSON: more than one value")
+	}
+	return nil
+}
+
+// WriteJSON answers with status and v as JSON.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v) // too late for another status: the header is out
+}
+
+// ErrorBody is the JSON of an error answer.
+type ErrorBody struct {
+	Error  string `json:"error"`
+	Fields any    `json:"fields,omitempty"` // what was wrong with each field, if the handler knows
+}
+
+// WriteError answers with the status and message of err, an *Error. Any
+// other error is a 500 with a generic message: its details are for the
+// logs of the server, not for the client.
+func WriteError(w http.ResponseWriter, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = &Error{Status: http.StatusInternalServerError, Msg: "internal error"}
+	}
+	WriteJSON(w, e.Status, ErrorBody{Error: e.Msg})
+}
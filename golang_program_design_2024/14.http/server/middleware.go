@@ -0,0 +1,154 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps a handler in another, which does something before or
+// after calling it, or instead of it.
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps h in the middleware, the first one outermost: a request goes
+// through them in the order given, the response back in reverse.
+//
+//	Chain(h, RequestID, Logging(log))  ==  RequestID(Logging(log)(h))
+func Chain(h http.Handler, mw ...Middleware) http.Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// RequestIDHeader carries the request ID, in the request and the response.
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestIDFrom returns the ID stored by RequestID, or "".
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID gives every request an ID: the X-Request-ID of the request, set
+// by a proxy in front or by the client, or a new one. It is in the context
+// of the request for the handlers and the logs, and in the response for
+// the client, who quotes it in a bug report.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = newID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// recorder is a ResponseWriter that remembers the status and the size of
+// the response, for Logging and Recover.
+type recorder struct {
+	http.ResponseWriter
+	status int // 0 until the header is written
+	bytes  int
+}
+
+func (rec *recorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK // what the first Write sends
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.NewResponseController reach the ResponseWriter of the
+// server, and its Flush, Hijack and deadlines, through the recorder.
+func (rec *recorder) Unwrap() http.ResponseWriter { return rec.ResponseWriter }
+
+// record returns w as a *recorder, wrapping it unless it is one already.
+func record(w http.ResponseWriter) *recorder {
+	if rec, ok := w.(*recorder); ok {
+		return rec
+	}
+	return &recorder{ResponseWriter: w}
+}
+
+// Logging logs a line per request, once it is answered: method, path,
+// status, size, duration, and the request ID if RequestID is before it.
+func Logging(log *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := record(w)
+			next.ServeHTTP(rec, r)
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK // nothing written: net/http sends a 200
+			}
+			log.Info("request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", status,
+				"bytes", rec.bytes,
+				"duration", time.Since(start).Round(time.Microsecond),
+				"request_id", RequestIDFrom(r.Context()),
+			)
+		})
+	}
+}
+
+// Recover turns a panic in a handler into a 500 and a log line with the
+// stack. net/http would recover it too, but by closing the connection: the
+// client gets no answer, and the log no request ID.
+//
+// http.ErrAbortHandler is let through: it is the way to abort a response on
+// purpose. A panic after the header was sent cannot become a 500 either:
+// the connection is closed, as net/http would.
+func Recover(log *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := record(w)
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				log.Error("panic",
+					"err", v,
+					"path", r.URL.Path,
+					"request_id", RequestIDFrom(r.Context()),
+					"stack", string(debug.Stack()),
+				)
+				if rec.status != 0 {
+					panic(http.ErrAbortHandler)
+				}
+				WriteError(rec, fmt.Errorf("panic: %v", v)) // a 500, the value stays in the log
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Run listens on srv.Addr and serves until ctx is done, then shuts down
+// gracefully, as Serve does. ctx is usually from signal.NotifyContext: Ctrl-C
+// or a SIGTERM from the orchestrator starts the shutdown.
+func Run(ctx context.Context, srv *http.Server, timeout time.Duration) error {
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return Serve(ctx, srv, l, timeout)
+}
+
+// Serve serves on l until ctx is done, then shuts srv down gracefully:
+// srv.Shutdown closes the listener, so no new connection gets in, closes the
+// idle connections, and waits for the requests in flight to be answered.
+// If they take longer than timeout, the connections left are closed and
+// Serve returns context.DeadlineExceeded.
+//
+// Serve returns nil after a clean shutdown, or the error that stopped the
+// server: a listener error, say. Unlike srv.Serve, it never returns
+// http.ErrServerClosed: that is the normal end, not an error.
+func Serve(ctx context.Context, srv *http.Server, l net.Listener, timeout time.Duration) error {
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(l) }()
+
+	select {
+	case err := <-served:
+		return err // the server stopped on its own: ctx has nothing left to shut down
+	case <-ctx.Done():
+	}
+
+	// ctx is done: the shutdown needs a context of its own.
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		srv.Close() // the requests too slow to finish: cut them
+	}
+	if serveErr := <-served; !errors.Is(serveErr, http.ErrServerClosed) {
+		return serveErr
+	}
+	return err
+}
@@ -0,0 +1,150 @@
+package httpdemo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/14.http/server"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+	"github.com/YongSangUn/learn-golang/internal/xlog"
+)
+
+/*
+A server stopped with os.Exit, or killed by Ctrl-C, drops the requests it
+was answering: the clients get a reset connection, a half-written
+response, or a write applied without its answer. A graceful shutdown:
+
+ 1. signal.NotifyContext turns SIGINT (Ctrl-C) or SIGTERM (what a service
+    manager or Kubernetes sends) into a cancelled context, instead of the
+    default of dying at once;
+ 2. srv.Shutdown closes the listeners, so new connections are refused, and
+    closes the idle connections;
+ 3. it waits for the requests in flight to finish, up to the deadline of
+    its context: a request that never ends must not block the exit forever;
+ 4. past the deadline, srv.Close cuts the connections left.
+
+server.Serve does steps 2 to 4 when its context is done; the cmd of the
+chapter gives it the context of step 1:
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err := server.Run(ctx, srv, 10*time.Second)
+
+ListenAndServe returns http.ErrServerClosed as soon as Shutdown starts, not
+when it ends: a main that returns then exits before the requests are done.
+Serve waits for Shutdown itself. Shutdown does not wait for hijacked
+connections, WebSockets say: srv.RegisterOnShutdown tells them to close.
+*/
+
+func init() {
+	lessons.Register("14.http/shutdown", "graceful shutdown: Shutdown waits for requests in flight, up to a deadline", lessons.Checked(DemoShutdown))
+}
+
+// shutdownRun is a server started by startServer.
+type shutdownRun struct {
+	url    string
+	cancel context.CancelFunc // the signal
+	active chan struct{}      // a connection turned active: a request is in flight
+	done   chan error         // what server.Serve returned
+}
+
+// startServer serves NewAPI on a free port, until cancel is called, with a
+// shutdown timeout.
+func startServer(timeout time.Duration) (*shutdownRun, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &shutdownRun{
+		url:    "http://" + l.Addr().String(),
+		cancel: cancel,
+		active: make(chan struct{}, 1),
+		done:   make(chan error, 1),
+	}
+	srv := &http.Server{
+		Handler: NewAPI(xlog.New("14.http", "DemoShutdown")),
+		ConnState: func(_ net.Conn, s http.ConnState) {
+			if s == http.StateActive {
+				select {
+				case run.active <- struct{}{}:
+				default:
+				}
+			}
+		},
+	}
+	go func() { run.done <- server.Serve(ctx, srv, l, timeout) }()
+	return run, nil
+}
+
+// get returns the status and body of a GET, or the error of the client.
+func get(url string) (string, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return fmt.Sprintf("%d %s", resp.StatusCode, strings.TrimSpace(string(body))), err
+}
+
+// DemoShutdown shuts a server down with a request in flight, with time
+// enough and without; shutdown_test.go has the cases.
+func DemoShutdown() error {
+	for _, tt := range []struct {
+		timeout time.Duration
+		sleep   string
+	}{
+		{time.Second, "300ms"},
+		{100 * time.Millisecond, "5s"},
+	} {
+		fmt.Printf("== shutdown timeout %s, a request of %s in flight\n", tt.timeout, tt.sleep)
+		r, err := shutdownDuring(tt.timeout, tt.sleep)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("in flight: %q, %v\n", r.got, r.err)
+		fmt.Printf("a new request: %v\n", r.lateErr)
+		fmt.Printf("Serve returned %v after %s\n", r.serveErr, r.took.Round(10*time.Millisecond))
+	}
+	return nil
+}
+
+// shutdownResult is what shutdownDuring saw.
+type shutdownResult struct {
+	got      string        // the answer to the request in flight
+	err      error         // or its error
+	lateErr  error         // the error of a request sent after the signal
+	serveErr error         // what server.Serve returned
+	took     time.Duration // from the signal to the return of Serve
+}
+
+// shutdownDuring starts a server with a shutdown timeout, sends it a
+// request of /slow?d=sleep, and signals it while the request is in flight.
+func shutdownDuring(timeout time.Duration, sleep string) (shutdownResult, error) {
+	run, err := startServer(timeout)
+	if err != nil {
+		return shutdownResult{}, err
+	}
+	var r shutdownResult
+	inFlight := make(chan struct{})
+	go func() {
+		r.got, r.err = get(run.url + "/slow?d=" + sleep)
+		close(inFlight)
+	}()
+	<-run.active
+
+	start := time.Now()
+	run.cancel() // Ctrl-C
+	time.Sleep(20 * time.Millisecond)
+	_, r.lateErr = get(run.url + "/users")
+	r.serveErr = <-run.done
+	r.took = time.Since(start)
+	<-inFlight
+	return r, nil
+}
@@ -0,0 +1,44 @@
+package httpdemo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		timeout  time.Duration
+		sleep    string
+		finishes bool
+	}{
+		{"the request finishes", time.Second, "300ms", true},
+		{"the request is too slow", 100 * time.Millisecond, "5s", false},
+	} {
+		r, err := shutdownDuring(tt.timeout, tt.sleep)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.lateErr == nil {
+			t.Errorf("%s: a request after the signal was answered", tt.name)
+		}
+		if tt.finishes {
+			if r.err != nil || !strings.HasPrefix(r.got, "200 slept") {
+				t.Errorf("%s: the request in flight got %q, %v", tt.name, r.got, r.err)
+			}
+			if r.serveErr != nil || r.took < 200*time.Millisecond {
+				t.Errorf("%s: Serve returned %v after %s, want nil after the request", tt.name, r.serveErr, r.took)
+			}
+			continue
+		}
+		if r.err == nil {
+			t.Errorf("%s: the request in flight got %q, want it cut", tt.name, r.got)
+		}
+		if !errors.Is(r.serveErr, context.DeadlineExceeded) || r.took > 2*time.Second {
+			t.Errorf("%s: Serve returned %v after %s, want the deadline", tt.name, r.serveErr, r.took)
+		}
+	}
+}
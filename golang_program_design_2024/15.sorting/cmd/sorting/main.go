@@ -0,0 +1,22 @@
+package main
+
+import (
+	sorting "github.com/YongSangUn/learn-golang/golang_program_design_2024/15.sorting"
+)
+
+/*
+sorting runs the lessons of chapter 15.sorting, in order: the sort package,
+then the slices package and its comparators. Their cases are tests:
+go test ./golang_program_design_2024/15.sorting
+
+Usage:
+
+	go run ./golang_program_design_2024/15.sorting/cmd/sorting
+
+One lesson alone: go run ./cmd/learn run 15.sorting/compare
+*/
+
+func main() {
+	sorting.DemoSort()
+	sorting.DemoCompare()
+}
@@ -0,0 +1,110 @@
+package sorting
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	datastruct "github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+The slices package (Go 1.21) sorts with generics, no interface and no
+reflection:
+
+	slices.Sort(s)                 s of a cmp.Ordered type
+	slices.SortFunc(s, cmp)        any s, with a comparator on elements
+	slices.SortStableFunc(s, cmp)  the same, stable
+	slices.BinarySearch(s, x)      where x is, or would be, and if it is there
+	slices.BinarySearchFunc(s, x, cmp)
+
+A comparator returns a negative number, zero or a positive number for a
+before b, equal, or after: cmp.Compare(a, b) for ordered values,
+strings.Compare for strings. It says "equal" where a less function cannot,
+and that is what makes comparators chain: compare by the first key, and by
+the next one only on a tie. cmp.Or (Go 1.22) returns its first non-zero
+argument, which is that chain in one expression:
+
+	cmp.Or(cmp.Compare(a.Age, b.Age), strings.Compare(a.Name, b.Name))
+
+Chain below does the same with comparators built once: By(key) compares by
+a key, Desc reverses one, and Chain tries them in order.
+*/
+
+func init() {
+	lessons.Register("15.sorting/compare", "slices.SortFunc, BinarySearch, and multi-key comparators that chain", DemoCompare)
+}
+
+// By returns a comparator of T by the key returned by key.
+func By[T any, K cmp.Ordered](key func(T) K) func(a, b T) int {
+	return func(a, b T) int { return cmp.Compare(key(a), key(b)) }
+}
+
+// Desc returns compare reversed: descending where compare is ascending.
+func Desc[T any](compare func(a, b T) int) func(a, b T) int {
+	return func(a, b T) int { return compare(b, a) }
+}
+
+// Chain returns a comparator trying compares in order: the first one not
+// returning 0 decides. Elements equal for all of them are equal.
+func Chain[T any](compares ...func(a, b T) int) func(a, b T) int {
+	return func(a, b T) int {
+		for _, compare := range compares {
+			if c := compare(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+}
+
+// The comparators of Person the demo and the tests use.
+var (
+	personAge  = By(func(p datastruct.Person) int { return p.Age })
+	personName = By(func(p datastruct.Person) string { return p.Name })
+
+	// ByAgeThenName orders people by age, then by name for the same age.
+	ByAgeThenName = Chain(personAge, personName)
+	// ByAgeDescThenName orders the oldest first, then by name.
+	ByAgeDescThenName = Chain(Desc(personAge), personName)
+)
+
+// DemoCompare sorts and searches the team with the slices package and
+// chained comparators; sorting_test.go has the cases.
+func DemoCompare() {
+	people := team()
+	slices.SortFunc(people, ByAgeThenName)
+	fmt.Printf("%-34s %s\n", "SortFunc(ByAgeThenName)", names(people))
+	people = team()
+	slices.SortFunc(people, func(a, b datastruct.Person) int {
+		return cmp.Or(cmp.Compare(a.Age, b.Age), strings.Compare(a.Name, b.Name))
+	})
+	fmt.Printf("%-34s %s\n", "SortFunc, cmp.Or inline", names(people))
+	people = team()
+	slices.SortFunc(people, ByAgeDescThenName)
+	fmt.Printf("%-34s %s\n", "SortFunc(ByAgeDescThenName)", names(people))
+
+	// A stable sort on one key keeps the order of the others: the same
+	// result as the chain, in two passes.
+	people = team()
+	slices.SortStableFunc(people, personName)
+	slices.SortStableFunc(people, personAge)
+	fmt.Printf("%-34s %s\n", "SortStableFunc by name, then age", names(people))
+
+	// binary search
+	ages := []int{25, 25, 31, 31, 31, 40}
+	for _, age := range []int{31, 35} {
+		i, found := slices.BinarySearch(ages, age)
+		fmt.Printf("BinarySearch(%v, %d) = %d, %v\n", ages, age, i, found)
+	}
+
+	// Searching needs the order of the sort: by name, the slice sorted by
+	// age is not sorted, and the answer is wrong without an error.
+	people = team()
+	slices.SortFunc(people, ByAgeThenName)
+	ana := datastruct.Person{Name: "Ana", Age: 40}
+	i, found := slices.BinarySearchFunc(people, ana, personName)
+	fmt.Printf("BinarySearchFunc(by age, Ana/40, by name) = %d, %v: Ana is at %d\n", i, found, len(people)-1)
+}
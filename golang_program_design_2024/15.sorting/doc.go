@@ -0,0 +1,4 @@
+// Package sorting is chapter 15.sorting: the sort package, sort.Interface,
+// stable and unstable sorts, binary search, and the generic slices.SortFunc
+// and slices.BinarySearch with comparators that chain.
+package sorting
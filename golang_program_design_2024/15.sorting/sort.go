@@ -0,0 +1,125 @@
+package sorting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	datastruct "github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+The sort package sorts in place, in three ways:
+
+	sort.Ints(s), sort.Strings(s)  the common slices, in increasing order
+	sort.Slice(s, less)            any slice, with a less function on indexes
+	sort.Sort(data)                any sort.Interface: Len, Less(i, j), Swap
+
+sort.Interface is the original API, and still the one for what is not a
+slice: a type says how long it is, how to compare two of its elements and
+how to swap them, and sort.Sort needs nothing else. sort.Slice does the
+same through reflection for the swap, with the less function as a closure.
+
+Stable or not: sort.Sort and sort.Slice are not stable, elements that
+compare equal may come out in any order. sort.Stable and sort.SliceStable
+keep them in the order they had, a little slower. It matters when sorting
+by one key after another, or when equal elements must keep an order the
+user saw.
+
+sort.Search(n, f) is a binary search: the smallest i in [0, n) for which
+f(i) is true, n if there is none. f must be false, then true, over the
+range, which is what a sorted slice gives with f(i) = s[i] >= x. The result
+is where x is, or where it would be inserted.
+*/
+
+func init() {
+	lessons.Register("15.sorting/sort", "sort.Slice, sort.Interface on Person, stable vs unstable, sort.Search", DemoSort)
+}
+
+// ByAge sorts people by age, youngest first, through sort.Interface.
+type ByAge []datastruct.Person
+
+func (a ByAge) Len() int           { return len(a) }
+func (a ByAge) Less(i, j int) bool { return a[i].Age < a[j].Age }
+func (a ByAge) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
+// ByName sorts people by name. It embeds ByAge for Len and Swap and
+// replaces Less only: a new order is one method.
+type ByName struct{ ByAge }
+
+func (n ByName) Less(i, j int) bool { return n.ByAge[i].Name < n.ByAge[j].Name }
+
+// SearchAge returns the index of the first person of at least age in
+// people, sorted by age: len(people) if everyone is younger.
+func SearchAge(people []datastruct.Person, age int) int {
+	return sort.Search(len(people), func(i int) bool { return people[i].Age >= age })
+}
+
+// team is the fixture of the demos: several people share an age, in an
+// order that is not the one of their names.
+func team() []datastruct.Person {
+	return []datastruct.Person{
+		{Name: "Mia", Age: 31},
+		{Name: "Ben", Age: 25},
+		{Name: "Zoe", Age: 31},
+		{Name: "Ana", Age: 40},
+		{Name: "Leo", Age: 25},
+		{Name: "Kim", Age: 31},
+	}
+}
+
+func names(people []datastruct.Person) string {
+	out := make([]string, len(people))
+	for i, p := range people {
+		out[i] = fmt.Sprintf("%s/%d", p.Name, p.Age)
+	}
+	return strings.Join(out, " ")
+}
+
+// DemoSort sorts the team with each API; sorting_test.go has the cases.
+func DemoSort() {
+	ints := []int{5, 2, 8, 1}
+	sort.Ints(ints)
+	fmt.Printf("%-34s %v\n", "sort.Ints", ints)
+
+	people := team()
+	sort.Sort(ByAge(people))
+	fmt.Printf("%-34s %s\n", "sort.Sort(ByAge)", names(people))
+	sort.Sort(ByName{people})
+	fmt.Printf("%-34s %s\n", "sort.Sort(ByName), embedding ByAge", names(people))
+	sort.Sort(sort.Reverse(ByAge(people)))
+	fmt.Printf("%-34s %s\n", "sort.Reverse(ByAge)", names(people))
+
+	// sorted by name, then stably by age: equal ages stay in name order.
+	people = team()
+	sort.Slice(people, func(i, j int) bool { return people[i].Name < people[j].Name })
+	fmt.Printf("%-34s %s\n", "sort.Slice by name", names(people))
+	sort.SliceStable(people, func(i, j int) bool { return people[i].Age < people[j].Age })
+	fmt.Printf("%-34s %s\n", "then sort.SliceStable by age", names(people))
+
+	// The unstable sort gives no such promise. With 6 elements it happens
+	// to keep them (insertion sort, under 12); on a longer slice the
+	// equal ages come out shuffled.
+	many := make([]datastruct.Person, 0, 200)
+	for i := 0; i < 200; i++ {
+		many = append(many, datastruct.Person{Name: fmt.Sprintf("p%03d", i), Age: i % 3})
+	}
+	stable := append([]datastruct.Person(nil), many...)
+	sort.SliceStable(stable, func(i, j int) bool { return stable[i].Age < stable[j].Age })
+	sort.Slice(many, func(i, j int) bool { return many[i].Age < many[j].Age })
+	moved := 0
+	for i := range many {
+		if many[i].Name != stable[i].Name {
+			moved++
+		}
+	}
+	fmt.Printf("sort.Slice of 200 people: %d not where SliceStable put them\n", moved)
+
+	// sort.Search on the team sorted by age
+	people = team()
+	sort.Stable(ByAge(people))
+	for _, age := range []int{25, 30, 41} {
+		fmt.Printf("SearchAge(%d) = %d of %d\n", age, SearchAge(people, age), len(people))
+	}
+}
@@ -0,0 +1,278 @@
+package sorting_test
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	datastruct "github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct"
+	sorting "github.com/YongSangUn/learn-golang/golang_program_design_2024/15.sorting"
+)
+
+// randomPeople returns n people with few distinct ages, so that many
+// compare equal, and unique names in a random order.
+func randomPeople(n int, seed int64) []datastruct.Person {
+	r := rand.New(rand.NewSource(seed))
+	people := make([]datastruct.Person, n)
+	for i, id := range r.Perm(n) {
+		people[i] = datastruct.Person{Name: fmt.Sprintf("p%04d", id), Age: r.Intn(5)}
+	}
+	return people
+}
+
+// sameElements reports whether a and b hold the same people, in any order:
+// a sort must not lose or duplicate one.
+func sameElements(a, b []datastruct.Person) bool {
+	key := func(p datastruct.Person) string { return fmt.Sprintf("%s/%d", p.Name, p.Age) }
+	ka, kb := make([]string, len(a)), make([]string, len(b))
+	for i := range a {
+		ka[i] = key(a[i])
+	}
+	for i := range b {
+		kb[i] = key(b[i])
+	}
+	slices.Sort(ka)
+	slices.Sort(kb)
+	return slices.Equal(ka, kb)
+}
+
+// team is the fixture of the lessons: ages shared, in an order that is not
+// the one of the names.
+func team() []datastruct.Person {
+	return []datastruct.Person{
+		{Name: "Mia", Age: 31}, {Name: "Ben", Age: 25}, {Name: "Zoe", Age: 31},
+		{Name: "Ana", Age: 40}, {Name: "Leo", Age: 25}, {Name: "Kim", Age: 31},
+	}
+}
+
+func names(people []datastruct.Person) string {
+	out := make([]string, len(people))
+	for i, p := range people {
+		out[i] = fmt.Sprintf("%s/%d", p.Name, p.Age)
+	}
+	return strings.Join(out, " ")
+}
+
+// TestOrders sorts the team with each API of both lessons.
+func TestOrders(t *testing.T) {
+	const byName, byAgeThenName = "Ana/40 Ben/25 Kim/31 Leo/25 Mia/31 Zoe/31", "Ben/25 Leo/25 Kim/31 Mia/31 Zoe/31 Ana/40"
+	age := sorting.By(func(p datastruct.Person) int { return p.Age })
+	name := sorting.By(func(p datastruct.Person) string { return p.Name })
+	for _, tt := range []struct {
+		name   string
+		sortFn func(s []datastruct.Person)
+		want   string
+	}{
+		{"sort.Sort(ByName), embedding ByAge", func(s []datastruct.Person) { sort.Sort(sorting.ByName{ByAge: s}) }, byName},
+		{"sort.Slice: less on indexes", func(s []datastruct.Person) { sort.Slice(s, func(i, j int) bool { return s[i].Name < s[j].Name }) }, byName},
+		{"sort.Stable(ByAge) keeps the input order", func(s []datastruct.Person) { sort.Stable(sorting.ByAge(s)) }, "Ben/25 Leo/25 Mia/31 Zoe/31 Kim/31 Ana/40"},
+		{"SliceStable after a sort by name", func(s []datastruct.Person) {
+			sort.Sort(sorting.ByName{ByAge: s})
+			sort.SliceStable(s, func(i, j int) bool { return s[i].Age < s[j].Age })
+		}, byAgeThenName},
+		{"sort.Reverse(ByAge) after a sort by name", func(s []datastruct.Person) {
+			sort.Sort(sorting.ByName{ByAge: s})
+			sort.Stable(sort.Reverse(sorting.ByAge(s)))
+		}, "Ana/40 Kim/31 Mia/31 Zoe/31 Ben/25 Leo/25"},
+		{"SortFunc(ByAgeThenName)", func(s []datastruct.Person) { slices.SortFunc(s, sorting.ByAgeThenName) }, byAgeThenName},
+		{"cmp.Or: the same chain inline", func(s []datastruct.Person) {
+			slices.SortFunc(s, func(a, b datastruct.Person) int {
+				return cmp.Or(cmp.Compare(a.Age, b.Age), strings.Compare(a.Name, b.Name))
+			})
+		}, byAgeThenName},
+		{"Desc on the first key only", func(s []datastruct.Person) { slices.SortFunc(s, sorting.ByAgeDescThenName) }, "Ana/40 Kim/31 Mia/31 Zoe/31 Ben/25 Leo/25"},
+		{"two stable passes, last key first", func(s []datastruct.Person) {
+			slices.SortStableFunc(s, name)
+			slices.SortStableFunc(s, age)
+		}, byAgeThenName},
+	} {
+		people := team()
+		tt.sortFn(people)
+		if got := names(people); got != tt.want {
+			t.Errorf("%s:\n%s\nwant\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestSearchTeam searches the team sorted by age, and by another order
+// than its sort.
+func TestSearchTeam(t *testing.T) {
+	people := team()
+	sort.Stable(sorting.ByAge(people))
+	for _, tt := range []struct{ age, want int }{{0, 0}, {25, 0}, {30, 2}, {31, 2}, {40, 5}, {41, 6}} {
+		if got := sorting.SearchAge(people, tt.age); got != tt.want {
+			t.Errorf("SearchAge(%d) = %d, want %d", tt.age, got, tt.want)
+		}
+	}
+
+	ages := []int{25, 25, 31, 31, 31, 40}
+	for _, tt := range []struct {
+		x     int
+		i     int
+		found bool
+	}{{31, 2, true}, {35, 5, false}, {10, 0, false}, {50, 6, false}} {
+		i, found := slices.BinarySearch(ages, tt.x)
+		if i != tt.i || found != tt.found {
+			t.Errorf("BinarySearch(%d) = %d, %v; want %d, %v", tt.x, i, found, tt.i, tt.found)
+		}
+		if ins := slices.Insert(slices.Clone(ages), i, tt.x); !slices.IsSorted(ins) {
+			t.Errorf("inserting %d at %d: %v", tt.x, i, ins)
+		}
+	}
+
+	slices.SortFunc(people, sorting.ByAgeThenName)
+	if i, found := slices.BinarySearchFunc(people, datastruct.Person{Name: "Mia", Age: 31}, sorting.ByAgeThenName); !found || people[i].Name != "Mia" {
+		t.Errorf("Mia/31 by the sort comparator: %d, %v", i, found)
+	}
+	if i, found := slices.BinarySearchFunc(people, 31, func(p datastruct.Person, age int) int { return cmp.Compare(p.Age, age) }); !found || i != 2 {
+		t.Errorf("the first 31 by a key: %d, %v", i, found)
+	}
+	// by name, a slice sorted by age is not sorted: Ana, last, is missed
+	// without an error.
+	byName := sorting.By(func(p datastruct.Person) string { return p.Name })
+	if _, found := slices.BinarySearchFunc(people, datastruct.Person{Name: "Ana", Age: 40}, byName); found {
+		t.Error("found Ana by name in a slice sorted by age")
+	}
+}
+
+func TestInterface(t *testing.T) {
+	for _, n := range []int{0, 1, 5, 13, 300} {
+		orig := randomPeople(n, int64(n))
+
+		people := slices.Clone(orig)
+		sort.Sort(sorting.ByAge(people))
+		for i := 1; i < len(people); i++ {
+			if people[i-1].Age > people[i].Age {
+				t.Fatalf("n=%d: ByAge not sorted at %d: %v", n, i, people)
+			}
+		}
+		if !sameElements(orig, people) {
+			t.Errorf("n=%d: ByAge changed the elements", n)
+		}
+
+		sort.Sort(sorting.ByName{ByAge: people})
+		if !slices.IsSortedFunc(people, func(a, b datastruct.Person) int { return cmp.Compare(a.Name, b.Name) }) {
+			t.Errorf("n=%d: ByName not sorted", n)
+		}
+	}
+}
+
+// TestStable checks that equal ages keep their order: the index in the
+// input is the tie-breaker a stable sort implies.
+func TestStable(t *testing.T) {
+	orig := randomPeople(500, 1)
+	pos := make(map[string]int) // the names are unique
+	for i, p := range orig {
+		pos[p.Name] = i
+	}
+	stableOrder := func(people []datastruct.Person) bool {
+		for i := 1; i < len(people); i++ {
+			a, b := people[i-1], people[i]
+			if a.Age == b.Age && pos[a.Name] > pos[b.Name] {
+				return false
+			}
+		}
+		return true
+	}
+	for name, sortFn := range map[string]func([]datastruct.Person){
+		"sort.Stable":      func(s []datastruct.Person) { sort.Stable(sorting.ByAge(s)) },
+		"sort.SliceStable": func(s []datastruct.Person) { sort.SliceStable(s, func(i, j int) bool { return s[i].Age < s[j].Age }) },
+		"slices.SortStableFunc": func(s []datastruct.Person) {
+			slices.SortStableFunc(s, func(a, b datastruct.Person) int { return cmp.Compare(a.Age, b.Age) })
+		},
+	} {
+		people := slices.Clone(orig)
+		sortFn(people)
+		if !stableOrder(people) {
+			t.Errorf("%s is not stable", name)
+		}
+	}
+}
+
+func TestSearchAge(t *testing.T) {
+	people := randomPeople(100, 2)
+	sort.Stable(sorting.ByAge(people))
+	for age := -1; age <= 6; age++ {
+		want := len(people)
+		for i, p := range people {
+			if p.Age >= age {
+				want = i
+				break
+			}
+		}
+		if got := sorting.SearchAge(people, age); got != want {
+			t.Errorf("SearchAge(%d) = %d, want %d", age, got, want)
+		}
+	}
+	if got := sorting.SearchAge(nil, 3); got != 0 {
+		t.Errorf("SearchAge(nil) = %d", got)
+	}
+}
+
+func TestComparators(t *testing.T) {
+	ana30 := datastruct.Person{Name: "Ana", Age: 30}
+	ben30 := datastruct.Person{Name: "Ben", Age: 30}
+	ben25 := datastruct.Person{Name: "Ben", Age: 25}
+	for _, tt := range []struct {
+		name    string
+		compare func(a, b datastruct.Person) int
+		a, b    datastruct.Person
+		want    int
+	}{
+		{"age decides", sorting.ByAgeThenName, ben25, ana30, -1},
+		{"name on a tie", sorting.ByAgeThenName, ben30, ana30, 1},
+		{"equal", sorting.ByAgeThenName, ana30, ana30, 0},
+		{"desc age decides", sorting.ByAgeDescThenName, ben25, ana30, 1},
+		{"desc keeps the name ascending", sorting.ByAgeDescThenName, ana30, ben30, -1},
+		{"empty chain: all equal", sorting.Chain[datastruct.Person](), ana30, ben25, 0},
+	} {
+		if got := tt.compare(tt.a, tt.b); cmp.Compare(got, 0) != tt.want {
+			t.Errorf("%s: compare(%v, %v) = %d, want the sign %d", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	// A chain sorts like the stable passes it replaces.
+	chained := randomPeople(300, 3)
+	passes := slices.Clone(chained)
+	slices.SortFunc(chained, sorting.ByAgeThenName)
+	slices.SortStableFunc(passes, sorting.By(func(p datastruct.Person) string { return p.Name }))
+	slices.SortStableFunc(passes, sorting.By(func(p datastruct.Person) int { return p.Age }))
+	if !slices.EqualFunc(chained, passes, func(a, b datastruct.Person) bool { return a.Name == b.Name }) {
+		t.Error("Chain and two stable passes disagree")
+	}
+}
+
+func TestBinarySearch(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	s := make([]int, 200)
+	for i := range s {
+		s[i] = r.Intn(100)
+	}
+	slices.Sort(s)
+	for x := -1; x <= 101; x++ {
+		i, found := slices.BinarySearch(s, x)
+		want := slices.IndexFunc(s, func(v int) bool { return v >= x })
+		if want < 0 {
+			want = len(s)
+		}
+		if i != want || found != slices.Contains(s, x) {
+			t.Errorf("BinarySearch(%d) = %d, %v; want %d, %v", x, i, found, want, slices.Contains(s, x))
+		}
+		if j := sort.SearchInts(s, x); j != i {
+			t.Errorf("sort.SearchInts(%d) = %d, slices.BinarySearch %d", x, j, i)
+		}
+	}
+
+	people := randomPeople(100, 5)
+	slices.SortFunc(people, sorting.ByAgeThenName)
+	for _, p := range people {
+		i, found := slices.BinarySearchFunc(people, p, sorting.ByAgeThenName)
+		if !found || sorting.ByAgeThenName(people[i], p) != 0 {
+			t.Errorf("BinarySearchFunc(%v) = %d, %v", p, i, found)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package probabilistic
+
+import (
+	"fmt"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/16.probabilistic/bloom"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A Bloom filter trades certainty for memory. "Not in the set" is always
+right; "in the set" is wrong at a rate chosen when the filter is made, and
+each tenfold drop of that rate costs 4.8 more bits per key, whatever the
+keys are.
+
+The typical use is in front of something expensive: a database checks a
+filter before reading a file from disk for a key it may not have; a
+crawler skips the URLs it probably fetched. A false positive costs one
+lookup, or one page missed; the filter saves the lookup for every key that
+is not there.
+*/
+
+func init() {
+	lessons.Register("16.probabilistic/bloom", "a Bloom filter: sizes and measured false positive rates", DemoBloom)
+}
+
+// DemoBloom fills filters of several rates and measures their false
+// positives on keys never added; bloom/bloom_test.go has the cases.
+func DemoBloom() {
+	const n, probes = 100000, 200000
+	for _, p := range []float64{0.1, 0.01, 0.001} {
+		f := bloom.New(n, p)
+		for i := 0; i < n; i++ {
+			f.AddString(fmt.Sprintf("https://example.com/%d", i))
+		}
+		fp := 0
+		for i := 0; i < probes; i++ {
+			if f.ContainsString(fmt.Sprintf("https://example.net/%d", i)) {
+				fp++
+			}
+		}
+		fmt.Printf("p=%-5g %.4f false positives measured, %d KB, %.1f bits/key, k=%d\n",
+			p, float64(fp)/probes, f.M()/8/1024, float64(f.M())/n, f.K())
+	}
+}
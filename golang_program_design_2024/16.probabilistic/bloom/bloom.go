@@ -0,0 +1,127 @@
+// Package bloom is a Bloom filter: a set that answers "certainly not in
+// it" or "probably in it", in a fixed and small amount of memory.
+//
+//	f := bloom.New(1_000_000, 0.01) // a million keys, 1% false positives
+//	f.AddString("https://go.dev")
+//	f.ContainsString("https://go.dev") // true: always, once added
+//	f.ContainsString("https://x.dev")  // false, or true 1% of the time
+//
+// The filter is m bits and k hash functions. Add sets the k bits of a key;
+// Contains checks them. A key never added may find its k bits set by
+// others: a false positive, more likely as the filter fills. A key added
+// always finds its bits: no false negatives. Nothing can be removed, a bit
+// is shared by many keys.
+//
+// For n keys and a false positive rate p, the best sizes are
+//
+//	m = -n·ln p / (ln 2)²   about 9.6 bits per key for 1%, 14.4 for 0.1%
+//	k = m/n · ln 2          7 hashes for 1%, 10 for 0.1%
+//
+// whatever the size of the keys: a million URLs in 1.2 MB, where a map of
+// them takes the URLs themselves and more.
+package bloom
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// Filter is a Bloom filter. It is not safe for concurrent use.
+type Filter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    int    // number of hash functions
+	n    uint64 // keys added
+}
+
+// New returns a filter sized for n keys at a false positive rate of p, in
+// (0, 1). Past n keys it still works, with a higher rate.
+func New(n int, p float64) *Filter {
+	if n < 1 {
+		n = 1
+	}
+	m := math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2))
+	k := int(math.Round(m / float64(n) * math.Ln2))
+	return NewWithSize(uint64(m), max(k, 1))
+}
+
+// NewWithSize returns a filter of m bits and k hash functions.
+func NewWithSize(m uint64, k int) *Filter {
+	m = max(m, 64)
+	return &Filter{bits: make([]uint64, (m+63)/64), m: m, k: max(k, 1)}
+}
+
+// M returns the number of bits of the filter.
+func (f *Filter) M() uint64 { return f.m }
+
+// K returns the number of hash functions.
+func (f *Filter) K() int { return f.k }
+
+// Len returns the number of Adds, duplicates counted.
+func (f *Filter) Len() uint64 { return f.n }
+
+// hashes returns two hashes of key, FNV-1a and FNV-1 on 64 bits. The k
+// functions are h1 + i·h2, for i from 0 to k-1: two hashes are enough to
+// make k independent enough ones (Kirsch and Mitzenmacher, 2006), and
+// hashing the key once or twice instead of k times is the speed of the
+// filter.
+func hashes(key []byte) (h1, h2 uint64) {
+	a := fnv.New64a()
+	a.Write(key)
+	b := fnv.New64()
+	b.Write(key)
+	return a.Sum64(), b.Sum64() | 1 // odd: the k indexes never all collapse
+}
+
+// Add adds key.
+func (f *Filter) Add(key []byte) {
+	h1, h2 := hashes(key)
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.n++
+}
+
+// Contains reports whether key may have been added: false is certain, true
+// is wrong at the false positive rate.
+func (f *Filter) Contains(key []byte) bool {
+	h1, h2 := hashes(key)
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// AddString adds key.
+func (f *Filter) AddString(key string) { f.Add([]byte(key)) }
+
+// ContainsString reports whether key may have been added.
+func (f *Filter) ContainsString(key string) bool { return f.Contains([]byte(key)) }
+
+// CheckAndAdd adds key and reports whether it may have been there already:
+// the test and the add of a deduplication, hashing once.
+func (f *Filter) CheckAndAdd(key []byte) (seen bool) {
+	h1, h2 := hashes(key)
+	seen = true
+	for i := 0; i < f.k; i++ {
+		bit := (h1 + uint64(i)*h2) % f.m
+		w, mask := bit/64, uint64(1)<<(bit%64)
+		if f.bits[w]&mask == 0 {
+			seen = false
+			f.bits[w] |= mask
+		}
+	}
+	f.n++
+	return seen
+}
+
+// FalsePositiveRate returns the expected false positive rate for the keys
+// added so far: (1 - e^(-kn/m))^k, the chance that the k bits of a new key
+// are all set.
+func (f *Filter) FalsePositiveRate() float64 {
+	return math.Pow(1-math.Exp(-float64(f.k)*float64(f.n)/float64(f.m)), float64(f.k))
+}
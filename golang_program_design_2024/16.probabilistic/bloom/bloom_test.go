@@ -0,0 +1,121 @@
+package bloom
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+func TestSizes(t *testing.T) {
+	for _, tt := range []struct {
+		n    int
+		p    float64
+		bits float64 // per key
+		k    int
+	}{
+		{1000, 0.01, 9.59, 7},
+		{1000, 0.001, 14.38, 10},
+		{1000, 0.1, 4.79, 3},
+	} {
+		f := New(tt.n, tt.p)
+		perKey := float64(f.M()) / float64(tt.n)
+		if math.Abs(perKey-tt.bits) > 0.01 || f.K() != tt.k {
+			t.Errorf("New(%d, %g): %.2f bits per key, k=%d; want %.2f, %d", tt.n, tt.p, perKey, f.K(), tt.bits, tt.k)
+		}
+	}
+}
+
+func TestNoFalseNegatives(t *testing.T) {
+	f := New(10000, 0.01)
+	for i := 0; i < 10000; i++ {
+		f.AddString(fmt.Sprintf("key-%d", i))
+	}
+	for i := 0; i < 10000; i++ {
+		if !f.ContainsString(fmt.Sprintf("key-%d", i)) {
+			t.Fatalf("key-%d added, not found", i)
+		}
+	}
+	if f.Len() != 10000 {
+		t.Errorf("Len = %d", f.Len())
+	}
+}
+
+// TestFalsePositiveRate fills filters to their n, then measures the rate on
+// 100000 keys never added: it must stay close to the target, and to the
+// rate the filter expects.
+func TestFalsePositiveRate(t *testing.T) {
+	for _, p := range []float64{0.1, 0.01, 0.001} {
+		t.Run(fmt.Sprint(p), func(t *testing.T) {
+			const n, probes = 20000, 100000
+			f := New(n, p)
+			for i := 0; i < n; i++ {
+				f.AddString(fmt.Sprintf("https://example.com/page/%d", i))
+			}
+			fp := 0
+			for i := 0; i < probes; i++ {
+				if f.ContainsString(fmt.Sprintf("https://example.org/other/%d", i)) {
+					fp++
+				}
+			}
+			rate := float64(fp) / probes
+			t.Logf("target %g, expected %.4f, measured %.4f (%d bits, k=%d)", p, f.FalsePositiveRate(), rate, f.M(), f.K())
+			if rate > 1.5*p {
+				t.Errorf("measured false positive rate %.4f, target %g", rate, p)
+			}
+			if math.Abs(f.FalsePositiveRate()-p) > p/5 {
+				t.Errorf("expected rate %.4f, target %g", f.FalsePositiveRate(), p)
+			}
+		})
+	}
+}
+
+// TestOverfull checks that the rate grows past n, as expected.
+func TestOverfull(t *testing.T) {
+	f := New(1000, 0.01)
+	for i := 0; i < 4000; i++ {
+		f.AddString(fmt.Sprint(i))
+	}
+	fp := 0
+	for i := 0; i < 10000; i++ {
+		if f.ContainsString(fmt.Sprint("x", i)) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 10000; rate < 0.1 || f.FalsePositiveRate() < 0.1 {
+		t.Errorf("4 times full: rate %.3f, expected %.3f; want well over 1%%", rate, f.FalsePositiveRate())
+	}
+}
+
+func TestCheckAndAdd(t *testing.T) {
+	f := New(100, 0.001)
+	if f.CheckAndAdd([]byte("a")) {
+		t.Error("a seen before it was added")
+	}
+	if !f.CheckAndAdd([]byte("a")) || !f.ContainsString("a") {
+		t.Error("a not seen the second time")
+	}
+	if f.Len() != 2 {
+		t.Errorf("Len = %d", f.Len())
+	}
+}
+
+var sink bool
+
+// BenchmarkContains looks up the keys of a filter sized for n of them, a
+// hit each time: k bits to read, in a bit array that outgrows the caches.
+func BenchmarkContains(b *testing.B) {
+	benchtools.Sized(b, []int{1 << 10, 1 << 20}, func(b *testing.B, n int) {
+		f := New(n, 0.01)
+		keys := make([][]byte, n)
+		for i, s := range benchtools.Strings(n, 32, 1) {
+			keys[i] = []byte(s)
+			f.Add(keys[i])
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			sink = f.Contains(keys[i%n])
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	probabilistic "github.com/YongSangUn/learn-golang/golang_program_design_2024/16.probabilistic"
+)
+
+/*
+probabilistic runs the lessons of chapter 16.probabilistic, in order: the
+Bloom filter, the count-min sketch, HyperLogLog, then the deduplication of
+a stream of URLs. Their bounds are tests:
+go test ./golang_program_design_2024/16.probabilistic/...
+
+Usage:
+
+	go run ./golang_program_design_2024/16.probabilistic/cmd/probabilistic
+
+One lesson alone: go run ./cmd/learn run 16.probabilistic/dedup
+*/
+
+func main() {
+	probabilistic.DemoBloom()
+	probabilistic.DemoCountMin()
+	probabilistic.DemoHyperLogLog()
+	probabilistic.DemoDedup()
+}
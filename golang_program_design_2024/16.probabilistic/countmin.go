@@ -0,0 +1,59 @@
+package probabilistic
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/16.probabilistic/countmin"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A count-min sketch counts every key of a stream in w×d counters, never
+less than the truth, more by at most ε of the total with probability 1-δ.
+Where a map of counts grows with every new key, the sketch stays the same
+size: the top pages of a site, the IPs sending the most requests, counted
+at the speed of the stream.
+
+The error is absolute, a share of the total: for the heavy hitters it is
+small next to their count; for the keys seen once it can be larger than
+the count itself. Ask it for the big ones.
+*/
+
+func init() {
+	lessons.Register("16.probabilistic/countmin", "a count-min sketch: the heavy hitters of a stream, against exact counts", DemoCountMin)
+}
+
+// DemoCountMin counts a skewed stream of page views exactly and with a
+// sketch, and compares the top pages; countmin/countmin_test.go has the
+// cases.
+func DemoCountMin() {
+	const eps, delta = 0.001, 0.01
+	s := countmin.New(eps, delta)
+	exact := make(map[string]uint64)
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < 500000; i++ {
+		page := fmt.Sprintf("/article/%d", int(r.ExpFloat64()*2000))
+		s.AddString(page, 1)
+		exact[page]++
+	}
+
+	pages := make([]string, 0, len(exact))
+	for p := range exact {
+		pages = append(pages, p)
+	}
+	sort.Slice(pages, func(i, j int) bool { return exact[pages[i]] > exact[pages[j]] })
+
+	worst := uint64(0)
+	for _, p := range pages {
+		worst = max(worst, s.CountString(p)-exact[p])
+	}
+	fmt.Printf("%d pages, worst overestimate %d, ε·total = %d\n", len(pages), worst, uint64(eps*float64(s.Total())))
+	for _, p := range pages[:3] {
+		fmt.Printf("  %-14s exact %5d, sketch %5d\n", p, exact[p], s.CountString(p))
+	}
+	mapBytes := len(pages) * (16 + 8 + 16) // string header, count, and the bytes of the name, roughly
+	fmt.Printf("memory: sketch %d KB fixed, the map some %d KB and growing with the keys\n",
+		s.Width()*s.Depth()*8/1024, mapBytes/1024)
+}
@@ -0,0 +1,92 @@
+// Package countmin is a count-min sketch: the approximate count of every
+// key of a stream, in a fixed amount of memory whatever the number of
+// keys.
+//
+//	s := countmin.New(0.001, 0.01) // within 0.1% of the total, 99% of the time
+//	s.AddString("/index.html", 1)
+//	s.CountString("/index.html")   // never less than the true count
+//
+// The sketch is d rows of w counters, and a hash per row. Add increments
+// the counter of the key in each row; Count returns the smallest of them.
+// Every counter a key maps to also counts the keys colliding with it, so
+// each is an overestimate, never an underestimate; the smallest is the
+// least wrong. With w = ⌈e/ε⌉ and d = ⌈ln(1/δ)⌉, the estimate exceeds the
+// true count by more than ε times the total of all counts with a
+// probability of at most δ.
+//
+// It is for the heavy hitters: a key counted 10000 times in a stream of a
+// million is found within 1000 with ε = 0.001. A key seen twice may read
+// as a thousand: the error is relative to the total, not to the key.
+package countmin
+
+import (
+	"hash/fnv"
+	"math"
+)
+
+// Sketch is a count-min sketch. It is not safe for concurrent use.
+type Sketch struct {
+	w, d   int
+	counts []uint64 // d rows of w, row after row
+	total  uint64
+}
+
+// New returns a sketch whose estimates exceed the true count by more than
+// epsilon times the total with a probability of at most delta.
+func New(epsilon, delta float64) *Sketch {
+	w := int(math.Ceil(math.E / epsilon))
+	d := int(math.Ceil(math.Log(1 / delta)))
+	return NewWithSize(w, d)
+}
+
+// NewWithSize returns a sketch of d rows of w counters.
+func NewWithSize(w, d int) *Sketch {
+	w, d = max(w, 1), max(d, 1)
+	return &Sketch{w: w, d: d, counts: make([]uint64, w*d)}
+}
+
+// Width returns the number of counters per row, Depth the number of rows.
+func (s *Sketch) Width() int { return s.w }
+func (s *Sketch) Depth() int { return s.d }
+
+// Total returns the sum of the counts added.
+func (s *Sketch) Total() uint64 { return s.total }
+
+// index returns the counter of row for the hashes of a key: h1 + row·h2,
+// the two FNV hashes combined like in the bloom package.
+func (s *Sketch) index(row int, h1, h2 uint64) int {
+	return row*s.w + int((h1+uint64(row)*h2)%uint64(s.w))
+}
+
+func hashes(key []byte) (h1, h2 uint64) {
+	a := fnv.New64a()
+	a.Write(key)
+	b := fnv.New64()
+	b.Write(key)
+	return a.Sum64(), b.Sum64() | 1
+}
+
+// Add adds n to the count of key.
+func (s *Sketch) Add(key []byte, n uint64) {
+	h1, h2 := hashes(key)
+	for row := 0; row < s.d; row++ {
+		s.counts[s.index(row, h1, h2)] += n
+	}
+	s.total += n
+}
+
+// Count returns the estimated count of key: at least its true count.
+func (s *Sketch) Count(key []byte) uint64 {
+	h1, h2 := hashes(key)
+	least := uint64(math.MaxUint64)
+	for row := 0; row < s.d; row++ {
+		least = min(least, s.counts[s.index(row, h1, h2)])
+	}
+	return least
+}
+
+// AddString adds n to the count of key.
+func (s *Sketch) AddString(key string, n uint64) { s.Add([]byte(key), n) }
+
+// CountString returns the estimated count of key.
+func (s *Sketch) CountString(key string) uint64 { return s.Count([]byte(key)) }
@@ -0,0 +1,62 @@
+package countmin
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestSize(t *testing.T) {
+	s := New(0.001, 0.01)
+	if s.Width() != 2719 || s.Depth() != 5 {
+		t.Errorf("%d x %d, want 2719 x 5", s.Width(), s.Depth())
+	}
+}
+
+// TestBounds counts a skewed stream exactly and with the sketch: no
+// estimate is under the truth, and the ones over it by more than ε·total
+// are rare, under δ.
+func TestBounds(t *testing.T) {
+	const eps, delta = 0.001, 0.01
+	s := New(eps, delta)
+	exact := make(map[string]uint64)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200000; i++ {
+		// a Zipf-like stream: a few keys take most of the counts
+		key := fmt.Sprintf("/page/%d", int(r.ExpFloat64()*300))
+		s.AddString(key, 1)
+		exact[key]++
+	}
+	s.AddString("/big", 5000)
+	exact["/big"] += 5000
+	if s.Total() != 205000 {
+		t.Errorf("Total = %d", s.Total())
+	}
+
+	bound := uint64(eps * float64(s.Total()))
+	over := 0
+	for key, n := range exact {
+		est := s.CountString(key)
+		if est < n {
+			t.Fatalf("%s: estimate %d under the count %d", key, est, n)
+		}
+		if est-n > bound {
+			over++
+		}
+	}
+	if rate := float64(over) / float64(len(exact)); rate > delta {
+		t.Errorf("%d of %d keys off by more than %d: %.3f > δ", over, len(exact), bound, rate)
+	}
+	if got := s.CountString("/never"); got > bound {
+		t.Logf("a key never added reads %d (bound %d)", got, bound)
+	}
+}
+
+func TestSmall(t *testing.T) {
+	s := NewWithSize(1, 1) // one counter: everything collides
+	s.AddString("a", 2)
+	s.AddString("b", 3)
+	if s.CountString("a") != 5 || s.CountString("zzz") != 5 {
+		t.Error("one counter must count everything")
+	}
+}
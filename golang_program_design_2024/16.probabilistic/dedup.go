@@ -0,0 +1,63 @@
+package probabilistic
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/pipeline"
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/16.probabilistic/bloom"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+A crawler sees the same URLs again and again, in the links of every page.
+Before fetching, it asks "did I fetch this one?": a set of every URL would
+answer exactly, and grow with the crawl. A Bloom filter answers in a fixed
+size, and is wrong in one direction only: it may skip a URL it never
+fetched (a false positive), never fetch one twice.
+
+The deduplication, Dedup, is a stage of the pipeline of
+04.concurrent/pipeline: a Filter keeping the URLs the Bloom filter has not
+seen. The filter is not
+safe for concurrent use, and a Filter stage is one goroutine: the stage
+owns it, no lock needed. Fanning the stage out would need one.
+*/
+
+func init() {
+	lessons.Register("16.probabilistic/dedup", "deduplicating a stream of URLs in a channel pipeline with a Bloom filter", DemoDedup)
+}
+
+// Dedup is the deduplication stage: it sends the strings of in the first
+// time a Bloom filter sized for n distinct ones at the false positive rate
+// p sees them. A few never seen are dropped too, at the rate p.
+func Dedup(ctx context.Context, in <-chan string, n int, p float64) <-chan string {
+	seen := bloom.New(n, p)
+	return pipeline.Filter(ctx, in, func(s string) bool {
+		return !seen.CheckAndAdd([]byte(s))
+	})
+}
+
+// DemoDedup sends a stream of URLs with duplicates through Dedup, and
+// compares with an exact set; dedup_test.go has the cases.
+func DemoDedup() {
+	const distinct, total = 50000, 200000
+	r := rand.New(rand.NewSource(3))
+	urls := make([]string, total)
+	exact := make(map[string]bool)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/page/%d", r.Intn(distinct))
+		exact[urls[i]] = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kept := 0
+	for range Dedup(ctx, pipeline.Generate(ctx, urls...), len(exact), 0.001) {
+		kept++
+	}
+	fmt.Printf("%d URLs in, %d distinct, %d passed: %d wrongly skipped, for a 0.1%% target\n",
+		total, len(exact), kept, len(exact)-kept)
+	fmt.Printf("memory: Bloom filter %d KB; the URLs alone %d KB\n",
+		bloom.New(len(exact), 0.001).M()/8/1024, len(exact)*len(urls[0])/1024)
+}
@@ -0,0 +1,38 @@
+package probabilistic
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/04.concurrent/pipeline"
+)
+
+func TestDedup(t *testing.T) {
+	const distinct, total, p = 50000, 200000, 0.001
+	r := rand.New(rand.NewSource(3))
+	urls := make([]string, total)
+	exact := make(map[string]bool)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("https://example.com/page/%d", r.Intn(distinct))
+		exact[urls[i]] = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kept := make(map[string]int)
+	for u := range Dedup(ctx, pipeline.Generate(ctx, urls...), len(exact), p) {
+		kept[u]++
+		if kept[u] > 1 {
+			t.Errorf("%s passed twice", u)
+		}
+		if !exact[u] {
+			t.Errorf("%s was never sent", u)
+		}
+	}
+	// the false positives: 0.1% of the distinct URLs, 3 times that at most.
+	if skipped := len(exact) - len(kept); float64(skipped) > 3*p*float64(len(exact)) {
+		t.Errorf("%d of %d distinct URLs skipped, over 3 times the %g target", skipped, len(exact), p)
+	}
+}
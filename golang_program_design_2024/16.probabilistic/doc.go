@@ -0,0 +1,7 @@
+// Package probabilistic is chapter 16.probabilistic: data structures that
+// answer approximately, in a fixed amount of memory, what an exact answer
+// would need memory for every key to answer: a Bloom filter for "seen
+// before?", a count-min sketch for "how often?", HyperLogLog for "how many
+// distinct?". The structures are in the bloom, countmin and hyperloglog
+// packages; the lessons measure their errors against exact answers.
+package probabilistic
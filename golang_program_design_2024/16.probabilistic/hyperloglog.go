@@ -0,0 +1,51 @@
+package probabilistic
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/16.probabilistic/hyperloglog"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+Counting distinct keys exactly takes a set of them: memory for every one.
+HyperLogLog takes 2^p bytes, whatever the count, for a standard error of
+1.04/√(2^p): 16 KB for 0.8%. Redis has it as PFADD and PFCOUNT; databases
+use it for COUNT(DISTINCT) estimates.
+
+Counters merge: one per server, or per day, and the union of their streams
+is the register-wise maximum, which a set of exact counts cannot do
+without keeping the keys.
+*/
+
+func init() {
+	lessons.Register("16.probabilistic/hyperloglog", "HyperLogLog: distinct counts in kilobytes, merged across counters", DemoHyperLogLog)
+}
+
+// DemoHyperLogLog counts distinct visitors at several precisions, then
+// merges the counters of two days; hyperloglog/hyperloglog_test.go has the
+// cases.
+func DemoHyperLogLog() {
+	const distinct = 200000
+	for _, p := range []uint8{8, 12, 16} {
+		h := hyperloglog.New(p)
+		for i := 0; i < 3*distinct; i++ {
+			h.AddString(fmt.Sprintf("visitor-%d", i%distinct)) // each one 3 times
+		}
+		got := h.Count()
+		relErr := math.Abs(float64(got)-distinct) / distinct
+		stdErr := 1.04 / math.Sqrt(float64(h.Size()))
+		fmt.Printf("p=%-2d %6d bytes: %d counted, error %.2f%%, standard error %.2f%%\n",
+			p, h.Size(), got, 100*relErr, 100*stdErr)
+	}
+
+	monday, tuesday := hyperloglog.New(14), hyperloglog.New(14)
+	for i := 0; i < 50000; i++ {
+		monday.AddString(fmt.Sprint("visitor-", i))
+		tuesday.AddString(fmt.Sprint("visitor-", i+30000)) // 20000 came both days
+	}
+	m, t := monday.Count(), tuesday.Count()
+	monday.Merge(tuesday) // same precision: no error
+	fmt.Printf("two days merged: %d + %d visitors, %d distinct (exact 80000)\n", m, t, monday.Count())
+}
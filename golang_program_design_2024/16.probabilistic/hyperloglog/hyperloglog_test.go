@@ -0,0 +1,71 @@
+package hyperloglog
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// TestError counts streams of known cardinality, every key added 3 times,
+// and checks the error against 3 standard errors, 1.04/√m.
+func TestError(t *testing.T) {
+	for _, p := range []uint8{10, 14} {
+		stdErr := 1.04 / math.Sqrt(float64(int(1)<<p))
+		for _, n := range []int{10, 100, 1000, 10000, 100000, 500000} {
+			c := New(p)
+			for rep := 0; rep < 3; rep++ {
+				for i := 0; i < n; i++ {
+					c.AddString(fmt.Sprintf("user-%d", i))
+				}
+			}
+			got := c.Count()
+			relErr := math.Abs(float64(got)-float64(n)) / float64(n)
+			if relErr > 3*stdErr {
+				t.Errorf("p=%d n=%d: counted %d, error %.2f%% > 3×%.2f%%", p, n, got, 100*relErr, 100*stdErr)
+			}
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	if n := New(12).Count(); n != 0 {
+		t.Errorf("empty counter: %d", n)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b, all := New(12), New(12), New(12)
+	for i := 0; i < 30000; i++ {
+		key := fmt.Sprint("k", i)
+		if i < 20000 {
+			a.AddString(key)
+		}
+		if i >= 10000 {
+			b.AddString(key) // 10000 keys in both
+		}
+		all.AddString(key)
+	}
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+	if a.Count() != all.Count() {
+		t.Errorf("merged %d, counted at once %d: a merge must lose nothing", a.Count(), all.Count())
+	}
+	if err := a.Merge(New(10)); !errors.Is(err, ErrPrecision) {
+		t.Errorf("merge of precisions 12 and 10: %v", err)
+	}
+}
+
+func TestPrecisionClamped(t *testing.T) {
+	if New(2).Size() != 16 || New(20).Size() != 1<<16 {
+		t.Error("precision not clamped to [4, 16]")
+	}
+	c := New(4) // the smallest: the rank can reach 61
+	for i := 0; i < 1000; i++ {
+		c.AddString(fmt.Sprint(i))
+	}
+	if n := c.Count(); n < 300 || n > 3000 {
+		t.Errorf("p=4, 1000 keys: %d", n)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	iodemo "github.com/YongSangUn/learn-golang/golang_program_design_2024/17.io"
+)
+
+/*
+iodemo runs the lessons of chapter 17.io, in order: readers and writers
+composed, pipes between goroutines, bufio.Scanner, then the line counter.
+Their cases are tests: go test ./golang_program_design_2024/17.io/...
+
+Usage:
+
+	go run ./golang_program_design_2024/17.io/cmd/iodemo
+
+One lesson alone: go run ./cmd/learn run 17.io/pipe
+*/
+
+func main() {
+	iodemo.DemoCompose()
+	err := iodemo.DemoPipe()
+	iodemo.DemoScanner()
+	if err := errors.Join(err, iodemo.DemoLineCount()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
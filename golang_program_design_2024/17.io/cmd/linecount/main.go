@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/17.io/linecount"
+)
+
+/*
+linecount counts the lines, words and bytes of files, or of the standard
+input without arguments, like wc -lwc: a line per file, then the total.
+
+Usage:
+
+	go run ./golang_program_design_2024/17.io/cmd/linecount [file ...]
+*/
+
+func main() {
+	if len(os.Args) < 2 {
+		counts, err := linecount.Count(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "linecount:", err)
+			os.Exit(1)
+		}
+		print(counts, "")
+		return
+	}
+	var total linecount.Counts
+	status := 0
+	for _, name := range os.Args[1:] {
+		counts, err := countFile(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "linecount:", err)
+			status = 1
+			continue
+		}
+		print(counts, name)
+		total.Lines += counts.Lines
+		total.Words += counts.Words
+		total.Bytes += counts.Bytes
+	}
+	if len(os.Args) > 2 {
+		print(total, "total")
+	}
+	os.Exit(status)
+}
+
+func countFile(name string) (linecount.Counts, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return linecount.Counts{}, err
+	}
+	defer f.Close()
+	return linecount.Count(f)
+}
+
+func print(c linecount.Counts, name string) {
+	fmt.Printf("%8d %8d %8d %s\n", c.Lines, c.Words, c.Bytes, name)
+}
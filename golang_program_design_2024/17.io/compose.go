@@ -0,0 +1,90 @@
+package iodemo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/17.io/linecount"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+An io.Reader has one method, an io.Writer one:
+
+	Read(p []byte) (n int, err error)  // fills p, up to len(p); io.EOF at the end
+	Write(p []byte) (n int, err error) // writes all of p, or says why not
+
+Files, sockets, HTTP bodies, gzip streams, hashes and buffers implement
+them, and so anything taking an io.Reader reads from any of them. The io
+package composes them without knowing what they are:
+
+- io.Copy(dst, src) moves a stream in 32 KB chunks, or lets src write
+  itself (io.WriterTo) or dst read (io.ReaderFrom): a file to a socket
+  goes through sendfile, no copy in user space.
+- io.TeeReader(r, w) writes to w what is read from r: hash a download
+  while saving it.
+- io.MultiWriter(w1, w2...) writes to each in turn: a log to a file and to
+  the console.
+- io.LimitReader(r, n) ends after n bytes: an upload of at most 1 MB.
+
+Read may return fewer bytes than asked, and data with an error: use them
+first, then look at err. io.ReadFull and io.ReadAll loop for the rest.
+*/
+
+func init() {
+	lessons.Register("17.io/compose", "io.Reader and io.Writer composed: Copy, TeeReader, MultiWriter, LimitReader", DemoCompose)
+}
+
+// upperReader is a Reader of its own: the bytes of r, ASCII letters in
+// upper case. Read is all it takes to plug into everything below.
+type upperReader struct{ r io.Reader }
+
+func (u upperReader) Read(p []byte) (int, error) {
+	n, err := u.r.Read(p)
+	for i, b := range p[:n] { // the n bytes read, even with an error
+		if 'a' <= b && b <= 'z' {
+			p[i] = b - 'a' + 'A'
+		}
+	}
+	return n, err
+}
+
+// DemoCompose chains readers and writers; compose_test.go has the cases.
+func DemoCompose() {
+	text := strings.Repeat("the quick brown fox\n", 1000)
+
+	var dst bytes.Buffer
+	n, _ := io.Copy(&dst, upperReader{strings.NewReader(text)})
+	fmt.Printf("%-34s %d bytes, %q...\n", "io.Copy(upperReader)", n, dst.String()[:9])
+
+	// TeeReader: the hash of what is copied, in the same pass.
+	h := sha256.New()
+	dst.Reset()
+	io.Copy(&dst, io.TeeReader(strings.NewReader(text), h))
+	fmt.Printf("%-34s %d bytes, sha256 %s...\n", "io.Copy(io.TeeReader(r, hash))", dst.Len(), hex.EncodeToString(h.Sum(nil)[:6]))
+
+	// MultiWriter: one stream, three destinations.
+	var saved bytes.Buffer
+	var counter linecount.Counter
+	h.Reset()
+	io.Copy(io.MultiWriter(&saved, h, &counter), strings.NewReader(text))
+	fmt.Printf("%-34s %d bytes, sha256 %s..., %s\n", "io.MultiWriter(buf, hash, counter)", saved.Len(), hex.EncodeToString(h.Sum(nil)[:6]), counter.Counts())
+
+	// LimitReader: read at most limit bytes. Reading limit+1 tells a stream of
+	// exactly limit bytes from a longer one, cut.
+	const limit = 1024
+	got, _ := io.ReadAll(io.LimitReader(strings.NewReader(text), limit+1))
+	fmt.Printf("%-34s %d bytes read of %d: more than %d, too long\n", "io.LimitReader(r, 1 KB + 1)", len(got), len(text), limit)
+
+	// Read may return less than asked; ReadFull loops until p is full.
+	buf := make([]byte, 10)
+	short, _ := io.MultiReader(strings.NewReader("hello"), strings.NewReader("world")).Read(buf)
+	full, _ := io.ReadFull(io.MultiReader(strings.NewReader("hello"), strings.NewReader("world")), buf)
+	fmt.Printf("%-34s Read %d bytes, ReadFull %d: %q\n", "two readers, a buffer of 10", short, full, buf)
+	_, err := io.ReadFull(strings.NewReader("abc"), buf)
+	fmt.Printf("%-34s %v\n", "ReadFull of 3 bytes", err)
+}
@@ -0,0 +1,107 @@
+package iodemo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/17.io/linecount"
+)
+
+const text = "the quick brown fox\n"
+
+func TestUpperReader(t *testing.T) {
+	in := strings.Repeat("The quick, brown fox 42!\n", 1000)
+	want := strings.Repeat("THE QUICK, BROWN FOX 42!\n", 1000)
+	var dst bytes.Buffer
+	if n, err := io.Copy(&dst, upperReader{strings.NewReader(in)}); err != nil || n != int64(len(in)) || dst.String() != want {
+		t.Errorf("io.Copy: %d bytes, %v", n, err)
+	}
+	// a byte at a time, and the bytes read with an error upper-cased too.
+	if err := iotest.TestReader(upperReader{iotest.OneByteReader(strings.NewReader(in))}, []byte(want)); err != nil {
+		t.Error(err)
+	}
+	got, err := io.ReadAll(upperReader{iotest.DataErrReader(strings.NewReader("abc"))})
+	if err != nil || string(got) != "ABC" {
+		t.Errorf("data with io.EOF: %q, %v", got, err)
+	}
+}
+
+func TestTeeMulti(t *testing.T) {
+	in := strings.Repeat(text, 1000)
+	sum := sha256.Sum256([]byte(in))
+
+	h := sha256.New()
+	var dst bytes.Buffer
+	io.Copy(&dst, io.TeeReader(strings.NewReader(in), h))
+	if dst.String() != in || !bytes.Equal(h.Sum(nil), sum[:]) {
+		t.Error("TeeReader: the copy or the hash differs")
+	}
+
+	var saved bytes.Buffer
+	var counter linecount.Counter
+	h.Reset()
+	io.Copy(io.MultiWriter(&saved, h, &counter), strings.NewReader(in))
+	if saved.String() != in || !bytes.Equal(h.Sum(nil), sum[:]) || counter.Counts() != (linecount.Counts{Lines: 1000, Words: 4000, Bytes: 20000}) {
+		t.Errorf("MultiWriter: %d bytes saved, %s", saved.Len(), counter.Counts())
+	}
+
+	// MultiWriter stops at the first writer failing.
+	failing := errors.New("full")
+	var after bytes.Buffer
+	full := writerFunc(func([]byte) (int, error) { return 0, failing })
+	_, err := io.Copy(io.MultiWriter(full, &after), strings.NewReader(in))
+	if !errors.Is(err, failing) || after.Len() != 0 {
+		t.Errorf("a failing writer: %v, %d bytes written after it", err, after.Len())
+	}
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) { return f(p) }
+
+func TestLimitReader(t *testing.T) {
+	const limit = 1024
+	in := strings.Repeat(text, 1000)
+	for _, tt := range []struct {
+		name string
+		size int
+		want int // read through LimitReader(r, limit+1)
+	}{
+		{"longer: cut after limit+1", len(in), limit + 1},
+		{"exactly the limit", limit, limit},
+		{"shorter", 10, 10},
+	} {
+		got, err := io.ReadAll(io.LimitReader(strings.NewReader(in[:tt.size]), limit+1))
+		if err != nil || len(got) != tt.want {
+			t.Errorf("%s: %d bytes, %v; want %d", tt.name, len(got), err, tt.want)
+		}
+	}
+}
+
+func TestReadFull(t *testing.T) {
+	buf := make([]byte, 10)
+	two := func() io.Reader { return io.MultiReader(strings.NewReader("hello"), strings.NewReader("world")) }
+	if n, err := two().Read(buf); n != 5 || err != nil {
+		t.Errorf("Read of two readers: %d, %v; want the first one only", n, err)
+	}
+	if n, err := io.ReadFull(two(), buf); n != 10 || err != nil || string(buf) != "helloworld" {
+		t.Errorf("ReadFull: %d %q, %v", n, buf, err)
+	}
+	for _, tt := range []struct {
+		in   string
+		want error
+	}{
+		{"", io.EOF},
+		{"abc", io.ErrUnexpectedEOF},
+		{"0123456789abc", nil},
+	} {
+		if _, err := io.ReadFull(strings.NewReader(tt.in), buf); err != tt.want {
+			t.Errorf("ReadFull of %q: %v, want %v", tt.in, err, tt.want)
+		}
+	}
+}
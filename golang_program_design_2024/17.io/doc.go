@@ -0,0 +1,7 @@
+// Package iodemo is chapter 17.io: io.Reader and io.Writer, the two
+// one-method interfaces every stream of the standard library implements,
+// and what composes them: io.Copy, TeeReader, MultiWriter, LimitReader,
+// Pipe between goroutines, and bufio.Scanner with split functions of its
+// own. It ends with linecount, a wc counting a stream in constant memory.
+// Named iodemo, not io, so it does not shadow the package it is about.
+package iodemo
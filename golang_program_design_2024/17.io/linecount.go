@@ -0,0 +1,67 @@
+package iodemo
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/17.io/linecount"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+linecount puts the chapter together: a Counter is an io.Writer, Count is
+io.Copy into one. It never holds more than the 32 KB chunk of io.Copy and
+the few bytes of a rune cut between two chunks: the same memory for 1 KB
+or for 1 GB, where io.ReadAll then strings.Count would hold all of it.
+
+cmd/linecount is the utility, a wc -lwc:
+
+	go run ./golang_program_design_2024/17.io/cmd/linecount README.md go.mod
+	git log | go run ./golang_program_design_2024/17.io/cmd/linecount
+
+The demo counts 256 MB that never exist at once: a Reader repeating a
+line for ever, cut by io.LimitReader.
+*/
+
+func init() {
+	lessons.Register("17.io/linecount", "a streaming line, word and byte counter, in constant memory", lessons.Checked(DemoLineCount))
+}
+
+// repeatReader yields line over and over, never io.EOF.
+type repeatReader struct {
+	line string
+	off  int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		c := copy(p[n:], r.line[r.off:])
+		n += c
+		r.off = (r.off + c) % len(r.line)
+	}
+	return n, nil
+}
+
+// DemoLineCount counts a 256 MB stream, measuring the memory allocated;
+// linecount_test.go has the cases.
+func DemoLineCount() error {
+	const line, size = "a line of the stream: 32 bytes.\n", 256 << 20
+	counts, allocated, err := countRepeated(line, size)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s: %d KB allocated for %d MB read\n", counts, allocated/1024, size>>20)
+	return nil
+}
+
+// countRepeated counts line repeated over size bytes, and returns the bytes
+// allocated meanwhile.
+func countRepeated(line string, size int64) (linecount.Counts, uint64, error) {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	counts, err := linecount.Count(io.LimitReader(&repeatReader{line: line}, size))
+	runtime.ReadMemStats(&after)
+	return counts, after.TotalAlloc - before.TotalAlloc, err
+}
@@ -0,0 +1,125 @@
+// Package linecount counts the lines, words and bytes of a stream, like
+// wc, in constant memory: the input is never held whole, nor a line of it.
+//
+//	counts, err := linecount.Count(os.Stdin)
+//
+// Counter is an io.Writer: io.Copy feeds it, io.MultiWriter counts a stream
+// on its way somewhere else, io.TeeReader counts what a reader is read of.
+package linecount
+
+import (
+	"fmt"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Counts are the totals of a stream.
+type Counts struct {
+	Lines int64 // newlines, plus one for a last line without its own
+	Words int64 // runs of non-space runes
+	Bytes int64
+}
+
+func (c Counts) String() string {
+	return fmt.Sprintf("%d lines, %d words, %d bytes", c.Lines, c.Words, c.Bytes)
+}
+
+// Counter counts what is written to it. The zero value is ready to use;
+// read the totals with Counts, at any point of the stream.
+type Counter struct {
+	c      Counts
+	inWord bool
+	inLine bool    // bytes since the last newline
+	carry  [4]byte // the start of a rune split between two writes
+	ncarry int
+}
+
+// Write counts p. It never fails.
+func (w *Counter) Write(p []byte) (int, error) {
+	n := len(p)
+	w.c.Bytes += int64(n)
+	if w.ncarry > 0 {
+		// complete the split rune, one byte at a time, then go on with the
+		// rest of p.
+		for len(p) > 0 && !utf8.FullRune(w.carry[:w.ncarry]) {
+			w.carry[w.ncarry] = p[0]
+			w.ncarry++
+			p = p[1:]
+		}
+		if !utf8.FullRune(w.carry[:w.ncarry]) {
+			return n, nil // p ended in the middle of the rune again
+		}
+		r, _ := utf8.DecodeRune(w.carry[:w.ncarry])
+		w.rune(r)
+		w.ncarry = 0
+	}
+	// the state in locals while the bytes are ASCII, the common case: no
+	// decoding, no method call, no store to w per byte.
+	lines, words, inWord, inLine := w.c.Lines, w.c.Words, w.inWord, w.inLine
+	for len(p) > 0 {
+		if b := p[0]; b < utf8.RuneSelf {
+			if b == '\n' {
+				lines++
+			}
+			inLine = b != '\n'
+			space := asciiSpace[b]
+			if !space && !inWord {
+				words++
+			}
+			inWord = !space
+			p = p[1:]
+			continue
+		}
+		w.c.Lines, w.c.Words, w.inWord, w.inLine = lines, words, inWord, inLine
+		if !utf8.FullRune(p) {
+			w.ncarry = copy(w.carry[:], p)
+			return n, nil
+		}
+		r, size := utf8.DecodeRune(p)
+		w.rune(r)
+		p = p[size:]
+		lines, words, inWord, inLine = w.c.Lines, w.c.Words, w.inWord, w.inLine
+	}
+	w.c.Lines, w.c.Words, w.inWord, w.inLine = lines, words, inWord, inLine
+	return n, nil
+}
+
+// asciiSpace is unicode.IsSpace for the ASCII bytes.
+var asciiSpace = [utf8.RuneSelf]bool{'\t': true, '\n': true, '\v': true, '\f': true, '\r': true, ' ': true}
+
+func (w *Counter) rune(r rune) {
+	if r == '\n' {
+		w.c.Lines++
+		w.inLine = false
+	} else {
+		w.inLine = true
+	}
+	space := unicode.IsSpace(r)
+	if !space && !w.inWord {
+		w.c.Words++
+	}
+	w.inWord = !space
+}
+
+// Counts returns the totals of what was written so far. A last line
+// without a newline counts as a line; bytes of a rune cut short at the end
+// count as one word character, as utf8.RuneError would.
+func (w *Counter) Counts() Counts {
+	c := w.c
+	if w.inLine || w.ncarry > 0 {
+		c.Lines++
+	}
+	if w.ncarry > 0 && !w.inWord {
+		c.Words++
+	}
+	return c
+}
+
+// Count reads r to the end and returns its totals. The error is the one of
+// r, with the counts of what was read before it.
+func Count(r io.Reader) (Counts, error) {
+	var w Counter
+	_, err := io.Copy(&w, r)
+	return w.Counts(), err
+}
@@ -0,0 +1,110 @@
+package linecount
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/YongSangUn/learn-golang/internal/benchtools"
+)
+
+var cases = []struct {
+	name string
+	in   string
+	want Counts
+}{
+	{"empty", "", Counts{0, 0, 0}},
+	{"one line", "hello world\n", Counts{1, 2, 12}},
+	{"no final newline", "a b\nc", Counts{2, 3, 5}},
+	{"blank lines", "\n\n\n", Counts{3, 0, 3}},
+	{"spaces around", "  lead  and   trail  \n", Counts{1, 3, 22}},
+	{"tabs and CRLF", "a\tb\r\nc\r\n", Counts{2, 3, 8}},
+	{"UTF-8", "héllo wörld\n日本 語\n", Counts{2, 4, 25}},
+	{"no-break space", "a b\n", Counts{1, 2, 5}}, // unicode.IsSpace, not only ASCII
+	{"invalid UTF-8", "a\xffb \xfe\n", Counts{1, 2, 6}},
+	{"cut rune at the end", "ab \xe6\x97", Counts{1, 2, 5}},
+}
+
+func TestCountStringsReader(t *testing.T) {
+	for _, tc := range cases {
+		got, err := Count(strings.NewReader(tc.in))
+		if err != nil || got != tc.want {
+			t.Errorf("%s: Count = %+v, %v, want %+v", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+// TestCountOneByte reads a byte at a time: every rune of more than one
+// byte is split between writes, every word too.
+func TestCountOneByte(t *testing.T) {
+	for _, tc := range cases {
+		got, err := Count(iotest.OneByteReader(strings.NewReader(tc.in)))
+		if err != nil || got != tc.want {
+			t.Errorf("%s: Count one byte at a time = %+v, %v, want %+v", tc.name, got, err, tc.want)
+		}
+	}
+}
+
+func TestCountBuffer(t *testing.T) {
+	var buf bytes.Buffer
+	for i := 0; i < 1000; i++ {
+		buf.WriteString("the quick brown fox\n")
+	}
+	got, err := Count(&buf)
+	want := Counts{Lines: 1000, Words: 4000, Bytes: 20000}
+	if err != nil || got != want {
+		t.Errorf("Count = %+v, %v, want %+v", got, err, want)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("%d bytes left in the buffer: Count should read to the end", buf.Len())
+	}
+}
+
+// TestMultiWriter counts a stream on its way to a buffer.
+func TestMultiWriter(t *testing.T) {
+	var out bytes.Buffer
+	var c Counter
+	in := "one\ntwo three\n"
+	if _, err := io.Copy(io.MultiWriter(&out, &c), strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != in {
+		t.Errorf("copy = %q, want %q", out.String(), in)
+	}
+	if got := c.Counts(); got != (Counts{2, 3, 14}) {
+		t.Errorf("Counts = %+v", got)
+	}
+	c.Write([]byte("four")) // Counts may be read mid-stream, and again later
+	if got := c.Counts(); got != (Counts{3, 4, 18}) {
+		t.Errorf("Counts after more = %+v", got)
+	}
+}
+
+func TestCountError(t *testing.T) {
+	boom := errors.New("boom")
+	r := io.MultiReader(strings.NewReader("a b\nc\n"), iotest.ErrReader(boom))
+	got, err := Count(r)
+	if !errors.Is(err, boom) || got != (Counts{2, 3, 6}) {
+		t.Errorf("Count = %+v, %v, want the counts before the error and boom", got, err)
+	}
+}
+
+func TestString(t *testing.T) {
+	if s := (Counts{1, 2, 3}).String(); s != "1 lines, 2 words, 3 bytes" {
+		t.Errorf("String = %q", s)
+	}
+}
+
+// BenchmarkCount counts a text of n lines.
+func BenchmarkCount(b *testing.B) {
+	benchtools.Sized(b, []int{1 << 10, 64 << 10}, func(b *testing.B, n int) {
+		text := strings.Repeat("the quick brown fox jumps over the lazy dög\n", n)
+		b.SetBytes(int64(len(text)))
+		for i := 0; i < b.N; i++ {
+			Count(strings.NewReader(text))
+		}
+	})
+}
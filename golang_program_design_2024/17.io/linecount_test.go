@@ -0,0 +1,42 @@
+package iodemo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/17.io/linecount"
+)
+
+func TestRepeatReader(t *testing.T) {
+	r := &repeatReader{line: "abc\n"}
+	buf := make([]byte, 10)
+	for i, want := range []string{"abc\nabc\nab", "c\nabc\nabc\n"} {
+		if n, err := r.Read(buf); n != 10 || err != nil || string(buf) != want {
+			t.Errorf("Read %d: %q, %d, %v; want %q", i, buf[:n], n, err, want)
+		}
+	}
+}
+
+// TestCountConstantMemory counts 64 MB: the memory allocated is the buffer
+// of io.Copy, not the stream.
+func TestCountConstantMemory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("64 MB")
+	}
+	const line, size = "a line of the stream: 32 bytes.\n", 64 << 20
+	counts, allocated, err := countRepeated(line, size)
+	want := linecount.Counts{Lines: size / int64(len(line)), Words: 7 * size / int64(len(line)), Bytes: size}
+	if err != nil || counts != want {
+		t.Errorf("%s, %v; want %s", counts, err, want)
+	}
+	if allocated > 1<<20 {
+		t.Errorf("%d KB allocated for %d MB read", allocated/1024, size>>20)
+	}
+}
+
+func TestCountText(t *testing.T) {
+	counts, err := linecount.Count(strings.NewReader("the quick brown fox\njumps over\nthe lazy dog"))
+	if err != nil || counts != (linecount.Counts{Lines: 3, Words: 9, Bytes: 43}) {
+		t.Errorf("three lines, the last without newline: %s, %v", counts, err)
+	}
+}
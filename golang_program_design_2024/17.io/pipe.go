@@ -0,0 +1,98 @@
+package iodemo
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/17.io/linecount"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+io.Pipe returns a connected io.PipeReader and io.PipeWriter: what one
+goroutine writes, another reads. It has no buffer: a Write waits until
+Reads have taken all of it. Memory stays flat whatever the size of the
+stream, and a slow reader slows the writer down.
+
+Its use is an API taking an io.Reader fed by code that writes to an
+io.Writer: an HTTP request body produced by a JSON encoder or gzip, on the
+fly, instead of a bytes.Buffer holding all of it.
+
+	pr, pw := io.Pipe()
+	go func() {
+		err := produce(pw)      // writes to pw
+		pw.CloseWithError(err)  // nil: the reader gets io.EOF
+	}()
+	http.Post(url, "application/gzip", pr)
+
+Each side closes for the other: CloseWithError on the writer ends the
+reader with that error, Close on the reader makes the next Write fail with
+io.ErrClosedPipe, and the producer stops instead of blocking for ever.
+*/
+
+func init() {
+	lessons.Register("17.io/pipe", "io.Pipe between goroutines: gzip on the fly, errors and early close", lessons.Checked(DemoPipe))
+}
+
+// produce writes lines lines, gzipped, to w.
+func produce(w io.Writer, lines int) error {
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw) // small writes, batched before gzip sees them
+	for i := 0; i < lines; i++ {
+		if _, err := fmt.Fprintf(bw, "line %d of the stream\n", i); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil { // forgotten, the last 4 KB are lost
+		return err
+	}
+	return zw.Close() // writes the gzip footer
+}
+
+// DemoPipe connects a gzip producer to a line counter through io.Pipe,
+// then closes each side early; pipe_test.go has the cases.
+func DemoPipe() error {
+	const lines = 100000
+	pr, pw := io.Pipe()
+	go func() { pw.CloseWithError(produce(pw, lines)) }()
+	zr, err := gzip.NewReader(pr)
+	if err != nil {
+		return err
+	}
+	counts, err := linecount.Count(zr)
+	fmt.Printf("gzip producer -> pipe -> line counter: %s, %v\n", counts, err)
+
+	// the writer fails: the reader gets its error, not a clean io.EOF.
+	pr, pw = io.Pipe()
+	go func() {
+		io.WriteString(pw, "half a stream\n")
+		pw.CloseWithError(errors.New("disk on fire"))
+	}()
+	counts, err = linecount.Count(pr)
+	fmt.Printf("the writer fails: %s, then %v\n", counts, err)
+
+	// the reader gives up early: the writer's next Write fails, and the
+	// producer returns instead of waiting for a reader that is gone.
+	fmt.Println("the reader closes: the producer returns", readThenClose(lines))
+
+	// the same stream, in a strings.Builder: what the pipe saves.
+	var all strings.Builder
+	produce(&all, lines)
+	fmt.Printf("memory: %d KB of gzip in a buffer; the pipe held none of it\n", all.Len()/1024)
+	return nil
+}
+
+// readThenClose reads a little of a stream of lines lines through a pipe,
+// closes the reader, and returns what the producer returned.
+func readThenClose(lines int) error {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- produce(pw, lines) }()
+	bufio.NewReader(pr).ReadString('\n') // the gzip bytes: read a little, then stop
+	pr.Close()
+	return <-done
+}
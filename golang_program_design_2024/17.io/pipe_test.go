@@ -0,0 +1,67 @@
+package iodemo
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/17.io/linecount"
+	"github.com/YongSangUn/learn-golang/internal/testutil"
+)
+
+func TestPipeGzip(t *testing.T) {
+	for _, lines := range []int{0, 1, 100000} {
+		pr, pw := io.Pipe()
+		go func() { pw.CloseWithError(produce(pw, lines)) }()
+		zr, err := gzip.NewReader(pr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if counts, err := linecount.Count(zr); err != nil || counts.Lines != int64(lines) {
+			t.Errorf("%d lines: %s, %v", lines, counts, err)
+		}
+	}
+}
+
+func TestPipeWriterError(t *testing.T) {
+	errDisk := errors.New("disk on fire")
+	pr, pw := io.Pipe()
+	go func() {
+		io.WriteString(pw, "half a stream\n")
+		pw.CloseWithError(errDisk)
+	}()
+	counts, err := linecount.Count(pr)
+	if !errors.Is(err, errDisk) || counts.Lines != 1 {
+		t.Errorf("%s, %v; want the line, then the writer's error", counts, err)
+	}
+}
+
+// TestPipeReaderClose closes the reader early: the producer returns
+// io.ErrClosedPipe instead of blocking.
+func TestPipeReaderClose(t *testing.T) {
+	done := make(chan error, 1)
+	go func() { done <- readThenClose(100000) }()
+	err := testutil.RequireRecv(t, done, 2*time.Second)
+	if !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("the producer returned %v, want io.ErrClosedPipe", err)
+	}
+}
+
+// TestPipeUnbuffered checks that a Write returns only once it is read.
+func TestPipeUnbuffered(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	wrote := make(chan struct{})
+	go func() {
+		io.WriteString(pw, "ping")
+		close(wrote)
+	}()
+	testutil.RequireNoRecv(t, wrote, 20*time.Millisecond)
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(pr, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("read %q, %v", buf, err)
+	}
+	testutil.RequireClosedWithin(t, wrote, time.Second)
+}
@@ -0,0 +1,130 @@
+package iodemo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+bufio.Scanner reads a stream token by token: lines by default, or what a
+split function cuts.
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+	}
+	if err := sc.Err(); err != nil { ... } // nil at io.EOF
+
+bufio.ScanLines, ScanWords, ScanRunes and ScanBytes are the split
+functions of the package. One of our own has the same signature:
+
+	func(data []byte, atEOF bool) (advance int, token []byte, err error)
+
+It looks at what is buffered: a token found, it returns how far to
+advance and the token; none yet, 0, nil, nil, and the Scanner reads more.
+At EOF it must return what is left as the last token, or it is lost.
+
+Two traps: a token is at most 64 KB by default, a longer line stops the
+scan with bufio.ErrTooLong, which only sc.Err reports (sc.Buffer raises
+the limit); and sc.Bytes is the Scanner's buffer, overwritten by the next
+Scan: copy it to keep it.
+*/
+
+func init() {
+	lessons.Register("17.io/scanner", "bufio.Scanner: lines, words, split functions of our own, long lines", DemoScanner)
+}
+
+// scanAll returns the tokens of s, split by split, and the error of the
+// scan.
+func scanAll(s string, split bufio.SplitFunc) ([]string, error) {
+	sc := bufio.NewScanner(strings.NewReader(s))
+	sc.Split(split)
+	var out []string
+	for sc.Scan() {
+		out = append(out, sc.Text())
+	}
+	return out, sc.Err()
+}
+
+// scanCommas splits on commas, trimming the spaces around each field.
+func scanCommas(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, ','); i >= 0 {
+		return i + 1, field(data[:i]), nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), field(data), nil // the last field, no comma after it
+	}
+	return 0, nil, nil // need more data
+}
+
+// field trims the spaces of b. A nil token is no token to the Scanner,
+// skipped: bytes.TrimSpace returns nil for "" or "  ", so an empty field
+// must become an empty slice that is not nil, or it disappears.
+func field(b []byte) []byte {
+	if t := bytes.TrimSpace(b); t != nil {
+		return t
+	}
+	return []byte{}
+}
+
+// scanParagraphs splits on blank lines: a token is a run of non-blank
+// lines, joined by their newlines.
+func scanParagraphs(data []byte, atEOF bool) (int, []byte, error) {
+	start := 0
+	for start < len(data) && data[start] == '\n' { // skip the blank lines before
+		start++
+	}
+	if i := bytes.Index(data[start:], []byte("\n\n")); i >= 0 {
+		return start + i + 2, data[start : start+i], nil
+	}
+	if atEOF && start < len(data) {
+		return len(data), bytes.TrimRight(data[start:], "\n"), nil
+	}
+	return start, nil, nil // the blank lines consumed, the paragraph not complete
+}
+
+// errBadRecord stops a scan: a split function returning an error ends it,
+// the error in sc.Err.
+var errBadRecord = errors.New("record does not start with #")
+
+func scanRecords(data []byte, atEOF bool) (int, []byte, error) {
+	advance, line, err := bufio.ScanLines(data, atEOF)
+	if err == nil && line != nil && !bytes.HasPrefix(line, []byte("#")) {
+		return 0, nil, fmt.Errorf("%w: %q", errBadRecord, line)
+	}
+	return advance, line, err
+}
+
+// DemoScanner scans with the split functions of bufio and with our own;
+// scanner_test.go has the cases.
+func DemoScanner() {
+	for _, tt := range []struct {
+		name  string
+		in    string
+		split bufio.SplitFunc
+	}{
+		{"ScanLines", "one\ntwo\r\nthree", bufio.ScanLines},
+		{"ScanWords", "  the\tquick \n brown  ", bufio.ScanWords},
+		{"ScanRunes", "añ日", bufio.ScanRunes},
+		{"scanCommas", "alice, 30 ,paris,, bob", scanCommas},
+		{"scanParagraphs", "\n\nfirst para\nstill first\n\n\nsecond\n", scanParagraphs},
+		{"scanRecords", "#1\n#2\nthree\n#4\n", scanRecords},
+		{"a 100 KB line", strings.Repeat("x", 100*1024) + "\nshort\n", bufio.ScanLines},
+	} {
+		got, err := scanAll(tt.in, tt.split)
+		in := tt.in
+		if len(in) > 40 {
+			in = in[:20] + "..."
+		}
+		fmt.Printf("%-14s %-40q %q", tt.name, in, got)
+		if err != nil {
+			fmt.Print(", ", err)
+		}
+		fmt.Println()
+	}
+}
@@ -0,0 +1,88 @@
+package iodemo
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestSplit(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		in    string
+		split bufio.SplitFunc
+		want  []string
+		err   error
+	}{
+		{"ScanLines: \\r\\n too, no final newline", "one\ntwo\r\nthree", bufio.ScanLines, []string{"one", "two", "three"}, nil},
+		{"ScanLines: an empty line kept", "a\n\nb\n", bufio.ScanLines, []string{"a", "", "b"}, nil},
+		{"ScanWords", "  the\tquick \n brown  ", bufio.ScanWords, []string{"the", "quick", "brown"}, nil},
+		{"ScanRunes: runes, not bytes", "añ日", bufio.ScanRunes, []string{"a", "ñ", "日"}, nil},
+		{"commas", "alice, 30 ,paris,, bob", scanCommas, []string{"alice", "30", "paris", "", "bob"}, nil},
+		{"commas: a trailing comma", "a,b,", scanCommas, []string{"a", "b"}, nil},
+		{"commas: spaces only", "  ", scanCommas, []string{""}, nil},
+		{"paragraphs", "\n\nfirst para\nstill first\n\n\nsecond\n", scanParagraphs, []string{"first para\nstill first", "second"}, nil},
+		{"paragraphs: blank lines only", "\n\n\n", scanParagraphs, nil, nil},
+		{"records", "#1\n#2\n", scanRecords, []string{"#1", "#2"}, nil},
+		{"records: an error stops the scan", "#1\n#2\nthree\n#4\n", scanRecords, []string{"#1", "#2"}, errBadRecord},
+		{"a 100 KB line: ErrTooLong", strings.Repeat("x", 100*1024) + "\nshort\n", bufio.ScanLines, nil, bufio.ErrTooLong},
+	} {
+		got, err := scanAll(tt.in, tt.split)
+		if !reflect.DeepEqual(got, tt.want) || !errors.Is(err, tt.err) {
+			t.Errorf("%s: %q, %v; want %q, %v", tt.name, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+// TestSplitOneByte feeds the split functions of our own a byte at a time:
+// every token is cut across reads, and must come out the same.
+func TestSplitOneByte(t *testing.T) {
+	for _, tt := range []struct {
+		in    string
+		split bufio.SplitFunc
+	}{
+		{"alice, 30 ,paris,, bob", scanCommas},
+		{"\n\nfirst para\nstill first\n\n\nsecond\n", scanParagraphs},
+	} {
+		want, _ := scanAll(tt.in, tt.split)
+		sc := bufio.NewScanner(iotest.OneByteReader(strings.NewReader(tt.in)))
+		sc.Split(tt.split)
+		var got []string
+		for sc.Scan() {
+			got = append(got, sc.Text())
+		}
+		if sc.Err() != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%q a byte at a time: %q, %v; want %q", tt.in, got, sc.Err(), want)
+		}
+	}
+}
+
+func TestScannerBuffer(t *testing.T) {
+	long := strings.Repeat("x", 100*1024) + "\nshort\n"
+	sc := bufio.NewScanner(strings.NewReader(long))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024) // start at 64 KB, grow to 1 MB
+	n := 0
+	for sc.Scan() {
+		n++
+	}
+	if sc.Err() != nil || n != 2 {
+		t.Errorf("sc.Buffer: %d lines, %v", n, sc.Err())
+	}
+
+	// Bytes is the Scanner's buffer: keeping it without a copy keeps
+	// whatever the next Scans write over it.
+	sc = bufio.NewScanner(strings.NewReader("aaaa\nbbbb\ncccc\n"))
+	sc.Buffer(make([]byte, 8), 8) // small, so the buffer is reused
+	var kept, copied [][]byte
+	for sc.Scan() {
+		kept = append(kept, sc.Bytes())
+		copied = append(copied, bytes.Clone(sc.Bytes()))
+	}
+	if string(kept[0]) == "aaaa" || string(copied[0]) != "aaaa" {
+		t.Errorf("kept %q, copied %q", kept[0], copied[0])
+	}
+}
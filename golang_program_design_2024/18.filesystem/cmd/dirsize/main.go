@@ -0,0 +1,58 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/18.filesystem/dirsize"
+)
+
+/*
+dirsize prints the total size of each directory given, or of the current
+one, like du -s: the bytes of the regular files under it, symbolic links
+not followed. The directories that cannot be read are reported and left
+out of the total; the exit status is then 1.
+
+Usage:
+
+	go run ./golang_program_design_2024/18.filesystem/cmd/dirsize [-workers n] [dir ...]
+*/
+
+func main() {
+	workers := flag.Int("workers", runtime.NumCPU()*4, "directories read at once")
+	flag.Parse()
+	dirs := flag.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"."}
+	}
+	status := 0
+	for _, dir := range dirs {
+		r, err := dirsize.Size(os.DirFS(dir), ".", *workers)
+		if err != nil {
+			// the paths in err are relative to dir, the root of its DirFS.
+			fmt.Fprintf(os.Stderr, "dirsize: %s: %v\n", dir, err)
+			status = 1
+		}
+		if r.Dirs == 0 && r.Files == 0 {
+			continue // dir itself could not be read
+		}
+		fmt.Printf("%10s %7d files %6d dirs  %s\n", human(r.Bytes), r.Files, r.Dirs, dir)
+	}
+	os.Exit(status)
+}
+
+// human prints n bytes with a binary unit, like du -h.
+func human(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%c", float64(n)/float64(div), "KMGTPE"[exp])
+}
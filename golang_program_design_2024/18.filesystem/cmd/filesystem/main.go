@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/18.filesystem"
+)
+
+/*
+filesystem runs the lessons of chapter 18.filesystem, in order: files with
+package os, filepath.WalkDir, io/fs, embed, then the concurrent du.
+Their cases are tests: go test ./golang_program_design_2024/18.filesystem/...
+
+Usage:
+
+	go run ./golang_program_design_2024/18.filesystem/cmd/filesystem
+
+One lesson alone: go run ./cmd/learn run 18.filesystem/walk
+*/
+
+func main() {
+	failed := 0
+	for _, demo := range []func() error{filesystem.DemoFiles, filesystem.DemoWalk, filesystem.DemoFS, filesystem.DemoEmbed, filesystem.DemoDirSize} {
+		if err := demo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,116 @@
+package filesystem
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/18.filesystem/dirsize"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+dirsize.Size is du -s on a pool of goroutines: a fixed number of workers
+take directories from a queue, read them, add up their files and queue
+their subdirectories. The package doc tells why the queue has no bound.
+
+cmd/dirsize is the utility:
+
+	go run ./golang_program_design_2024/18.filesystem/cmd/dirsize -workers 8 /usr/share
+
+Reading a directory is a system call that waits for the disk: on a cold
+cache, or a network file system, the workers overlap those waits and the
+walk goes several times faster. Once the tree is in the page cache there
+is nothing to wait for, only CPU, and more workers than CPUs only add the
+cost of the lock and of the hand-offs. The demo times both walks on
+GOROOT/src, warm: run it on a machine of one CPU, then of eight.
+*/
+
+func init() {
+	lessons.Register("18.filesystem/dirsize", "a concurrent du: a bounded pool of workers walking a tree", lessons.Checked(DemoDirSize))
+}
+
+// walkSize is the serial du: filepath.WalkDir, one directory at a time.
+func walkSize(root string) (dirsize.Result, error) {
+	var r dirsize.Result
+	err := filepath.WalkDir(root, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			r.Dirs++
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			r.Bytes += info.Size()
+			r.Files++
+		}
+		return nil
+	})
+	return r, err
+}
+
+// sampleTree creates 20 directories of 5 subdirectories of a file each
+// under dir, and a symbolic link to one of them, followed by neither walk.
+func sampleTree(dir string) error {
+	var paths []string
+	for i := 0; i < 20; i++ {
+		for j := 0; j < 5; j++ {
+			paths = append(paths, fmt.Sprintf("d%d/sub%d/file%d.txt", i, j, i*j))
+		}
+	}
+	if err := makeTree(dir, paths...); err != nil {
+		return err
+	}
+	return os.Symlink(filepath.Join(dir, "d0"), filepath.Join(dir, "link"))
+}
+
+// DemoDirSize runs dirsize and the serial walk on a temporary tree, then
+// times both on GOROOT/src; dirsize_test.go has the cases.
+func DemoDirSize() error {
+	dir, err := os.MkdirTemp("", "dirsize-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := sampleTree(dir); err != nil {
+		return err
+	}
+	want, err := walkSize(dir)
+	if err != nil {
+		return err
+	}
+	got, err := dirsize.Size(os.DirFS(dir), ".", 8)
+	fmt.Printf("serial walk %+v, Size with 8 workers %+v, %v\n", want, got, err)
+
+	src := filepath.Join(runtime.GOROOT(), "src")
+	if _, err := os.Stat(src); err != nil {
+		fmt.Println("GOROOT/src timings skipped: no GOROOT/src")
+		return nil
+	}
+	walkSize(src) // the tree into the page cache: the timings below are warm
+	start := time.Now()
+	want, err = walkSize(src)
+	if err != nil {
+		return err
+	}
+	serial := time.Since(start)
+	fmt.Printf("GOROOT/src, serial WalkDir: %d files, %d MB in %v, %d CPUs\n", want.Files, want.Bytes>>20, serial.Round(time.Millisecond), runtime.NumCPU())
+	for _, workers := range []int{1, 4, 16} {
+		start := time.Now()
+		got, err := dirsize.Size(os.DirFS(src), ".", workers)
+		if err != nil {
+			return err
+		}
+		elapsed := time.Since(start)
+		fmt.Printf("GOROOT/src, workers=%-2d %v, %.2fx the serial walk, the same total: %v\n",
+			workers, elapsed.Round(time.Millisecond), float64(elapsed)/float64(serial), got == want)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Package dirsize adds up the sizes of the files under a directory, like
+// du -s, reading directories on a fixed number of goroutines.
+//
+//	total, err := dirsize.Size(os.DirFS("/var/log"), ".", 8)
+//
+// It works on an fs.FS: os.DirFS for the disk, fstest.MapFS in tests, an
+// embed.FS, a zip.Reader. A directory that cannot be read is counted as
+// empty and its error joined to the others: the total of what could be
+// read, like du printing "permission denied" and going on.
+//
+// The workers are a pool of a fixed size taking directories from a queue.
+// Reading a directory queues its subdirectories: the queue grows without
+// bound, so a worker never waits for room, where a worker waiting to
+// submit to a bounded pool whose workers all wait the same way would
+// deadlock.
+package dirsize
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"sync"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/02.data_struct/queue"
+)
+
+// Result is the total of a tree.
+type Result struct {
+	Bytes int64 // the sizes of the regular files
+	Files int   // regular files
+	Dirs  int   // directories, root included
+}
+
+// Add adds the totals of o to r.
+func (r *Result) Add(o Result) {
+	r.Bytes += o.Bytes
+	r.Files += o.Files
+	r.Dirs += o.Dirs
+}
+
+// walker is the state shared by the workers of one Size.
+type walker struct {
+	fsys fs.FS
+
+	mu      sync.Mutex
+	cond    *sync.Cond // signalled when a directory is queued, or the walk is done
+	dirs    queue.Queue[string]
+	pending int // directories queued or being read: 0 is the end of the walk
+	total   Result
+	errs    []error
+}
+
+// Size returns the total of the tree at root in fsys, reading directories
+// on workers goroutines, at least 1. The symbolic links under root are not
+// followed, nor counted; other files that are not regular (devices,
+// sockets) neither.
+func Size(fsys fs.FS, root string, workers int) (Result, error) {
+	info, err := fs.Stat(fsys, root)
+	if err != nil {
+		return Result{}, err
+	}
+	if !info.IsDir() {
+		if info.Mode().IsRegular() {
+			return Result{Bytes: info.Size(), Files: 1}, nil
+		}
+		return Result{}, nil
+	}
+
+	w := &walker{fsys: fsys, pending: 1}
+	w.cond = sync.NewCond(&w.mu)
+	w.dirs.Push(root)
+	var wg sync.WaitGroup
+	for i := 0; i < max(workers, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				dir, ok := w.next()
+				if !ok {
+					return
+				}
+				w.read(dir)
+			}
+		}()
+	}
+	wg.Wait()
+	return w.total, errors.Join(w.errs...)
+}
+
+// next waits for a directory to read. It returns false once the walk is
+// done: nothing queued and nothing being read, which could queue more.
+func (w *walker) next() (string, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for w.dirs.Len() == 0 && w.pending > 0 {
+		w.cond.Wait()
+	}
+	return w.dirs.Pop()
+}
+
+// read reads dir, adds up its files and queues its subdirectories.
+func (w *walker) read(dir string) {
+	entries, err := fs.ReadDir(w.fsys, dir)
+	local := Result{Dirs: 1}
+	var subdirs []string
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, e := range entries { // those read before an error too
+		switch {
+		case e.IsDir():
+			subdirs = append(subdirs, path.Join(dir, e.Name()))
+		case e.Type().IsRegular():
+			info, err := e.Info()
+			if err != nil { // removed since ReadDir, for one
+				errs = append(errs, err)
+				continue
+			}
+			local.Bytes += info.Size()
+			local.Files++
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.total.Add(local)
+	w.errs = append(w.errs, errs...)
+	for _, d := range subdirs {
+		w.dirs.Push(d)
+	}
+	w.pending += len(subdirs) - 1 // its subdirectories start, dir is done
+	if w.pending == 0 {
+		w.cond.Broadcast() // the end: every waiting worker returns
+	} else {
+		for range subdirs {
+			w.cond.Signal()
+		}
+	}
+}
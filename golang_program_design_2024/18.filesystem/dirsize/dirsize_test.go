@@ -0,0 +1,151 @@
+package dirsize
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+)
+
+// tree returns a MapFS of depth levels, each directory with files files of
+// size bytes and width subdirectories, and its expected Result.
+func tree(depth, width, files, size int) (fstest.MapFS, Result) {
+	fsys := fstest.MapFS{}
+	want := Result{Dirs: 1}
+	var fill func(dir string, level int)
+	fill = func(dir string, level int) {
+		for i := 0; i < files; i++ {
+			fsys[fmt.Sprintf("%s/f%d.txt", dir, i)] = &fstest.MapFile{Data: make([]byte, size)}
+			want.Files++
+			want.Bytes += int64(size)
+		}
+		if level == depth {
+			return
+		}
+		for i := 0; i < width; i++ {
+			sub := fmt.Sprintf("%s/d%d", dir, i)
+			fsys[sub] = &fstest.MapFile{Mode: fs.ModeDir}
+			want.Dirs++
+			fill(sub, level+1)
+		}
+	}
+	fill("root", 0)
+	return fsys, want
+}
+
+// walkSize is the serial answer, with fs.WalkDir.
+func walkSize(fsys fs.FS, root string) (Result, error) {
+	var r Result
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			r.Dirs++
+			return nil
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			r.Bytes += info.Size()
+			r.Files++
+		}
+		return nil
+	})
+	return r, err
+}
+
+func TestSize(t *testing.T) {
+	fsys, want := tree(4, 3, 5, 100)
+	serial, err := walkSize(fsys, "root")
+	if err != nil || serial != want {
+		t.Fatalf("fs.WalkDir = %+v, %v, want %+v", serial, err, want)
+	}
+	for _, workers := range []int{0, 1, 4, 64} {
+		got, err := Size(fsys, "root", workers)
+		if err != nil || got != want {
+			t.Errorf("Size with %d workers = %+v, %v, want %+v", workers, got, err, want)
+		}
+	}
+}
+
+func TestSizeRoots(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.txt":     {Data: []byte("hello")},
+		"empty":     {Mode: fs.ModeDir},
+		"link":      {Data: []byte("a.txt"), Mode: fs.ModeSymlink},
+		"dir/b.txt": {Data: []byte("hi")},
+	}
+	for _, tt := range []struct {
+		root string
+		want Result
+	}{
+		{".", Result{Bytes: 7, Files: 2, Dirs: 3}}, // the link is not counted
+		{"a.txt", Result{Bytes: 5, Files: 1}},
+		{"empty", Result{Dirs: 1}},
+	} {
+		got, err := Size(fsys, tt.root, 2)
+		if err != nil || got != tt.want {
+			t.Errorf("Size(%q) = %+v, %v, want %+v", tt.root, got, err, tt.want)
+		}
+	}
+	if _, err := Size(fsys, "missing", 2); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Size of a missing root: %v, want fs.ErrNotExist", err)
+	}
+}
+
+// failFS fails ReadDir of the directories in fail.
+type failFS struct {
+	fstest.MapFS
+	fail map[string]bool
+}
+
+var errDenied = errors.New("permission denied")
+
+func (f failFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if f.fail[name] {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errDenied}
+	}
+	return f.MapFS.ReadDir(name)
+}
+
+func TestSizeErrors(t *testing.T) {
+	fsys, _ := tree(2, 2, 1, 10)
+	f := failFS{fsys, map[string]bool{"root/d0": true, "root/d1/d1": true}}
+	got, err := Size(f, "root", 4)
+	// root, d1, d1/d0 read: 3 files; d0 and d1/d1 counted as directories
+	// without content.
+	want := Result{Bytes: 30, Files: 3, Dirs: 5}
+	if got != want {
+		t.Errorf("Size = %+v, want %+v: the total of what could be read", got, want)
+	}
+	if !errors.Is(err, errDenied) {
+		t.Fatalf("err = %v, want errDenied", err)
+	}
+	var pe *fs.PathError
+	if joined, ok := err.(interface{ Unwrap() []error }); !ok || len(joined.Unwrap()) != 2 || !errors.As(err, &pe) {
+		t.Errorf("err = %v, want the 2 errors joined", err)
+	}
+}
+
+func TestSizeDisk(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a/x.bin", "a/b/y.bin", "c/z.bin"} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, make([]byte, 1000), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := Size(os.DirFS(dir), ".", 4)
+	if want := (Result{Bytes: 3000, Files: 3, Dirs: 4}); err != nil || got != want {
+		t.Errorf("Size = %+v, %v, want %+v", got, err, want)
+	}
+}
@@ -0,0 +1,25 @@
+package filesystem
+
+import (
+	"os"
+	"testing"
+
+	"github.com/YongSangUn/learn-golang/golang_program_design_2024/18.filesystem/dirsize"
+)
+
+// TestSizeSerial checks dirsize against the serial walk on the disk.
+func TestSizeSerial(t *testing.T) {
+	dir := t.TempDir()
+	if err := sampleTree(dir); err != nil {
+		t.Fatal(err)
+	}
+	want, err := walkSize(dir)
+	if err != nil || want.Files != 100 || want.Dirs != 121 {
+		t.Fatalf("walkSize = %+v, %v, want 100 files, 121 dirs", want, err)
+	}
+	for _, workers := range []int{1, 8} {
+		if got, err := dirsize.Size(os.DirFS(dir), ".", workers); err != nil || got != want {
+			t.Errorf("Size, workers=%d: %+v, %v, want %+v", workers, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+// Package filesystem is chapter 18.filesystem: files with package os, trees
+// with path/filepath, and io/fs, the interface that lets the same code read
+// the disk, a zip, the files embedded in the binary with embed, or a
+// fstest.MapFS in a test. It ends with dirsize, a du walking a tree on a
+// pool of goroutines.
+package filesystem
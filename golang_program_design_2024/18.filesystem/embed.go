@@ -0,0 +1,130 @@
+package filesystem
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"text/template"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+embed puts files into the binary at build time, behind a directive on a
+package variable:
+
+	//go:embed assets
+	var assets embed.FS
+
+The binary then runs anywhere, with no files beside it: templates, static
+files for a server, default configs, migrations. The paths are relative to
+the directory of the source file, may not go up with "..", and the files
+are read at build time: change one, build again.
+
+A directory is embedded with what it holds, except the names starting with
+"." or "_": "assets/static/.hidden" is left out. "all:assets" keeps them.
+A string or a []byte variable takes a single file.
+
+embed.FS is an fs.FS, read-only: fs.ReadFile, fs.Sub, template.ParseFS,
+http.FS take it as they take os.DirFS, and a test gives the same code a
+fstest.MapFS.
+*/
+
+//go:embed assets
+var assets embed.FS
+
+func init() {
+	lessons.Register("18.filesystem/embed", "embed.FS: a config, templates and static files in the binary", lessons.Checked(DemoEmbed))
+}
+
+// config is assets/config.json.
+type config struct {
+	Name     string `json:"name"`
+	Greeting string `json:"greeting"`
+	Port     int    `json:"port"`
+}
+
+// loadConfig reads assets/config.json from the binary.
+func loadConfig() (config, error) {
+	var cfg config
+	data, err := assets.ReadFile("assets/config.json")
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, json.Unmarshal(data, &cfg)
+}
+
+// embedded returns the paths of the embedded files.
+func embedded() ([]string, error) {
+	var names []string
+	err := fs.WalkDir(assets, ".", func(p string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			names = append(names, p)
+		}
+		return err
+	})
+	return names, err
+}
+
+// greet executes the embedded greeting template with cfg.
+func greet(cfg config) (string, error) {
+	tmpl, err := template.ParseFS(assets, "assets/templates/*.tmpl")
+	if err != nil {
+		return "", err
+	}
+	var out strings.Builder
+	err = tmpl.ExecuteTemplate(&out, "greeting.tmpl", cfg)
+	return out.String(), err
+}
+
+// staticHandler serves assets/static at the root, as a binary would serve
+// its web front end.
+func staticHandler() (http.Handler, error) {
+	static, err := fs.Sub(assets, "assets/static") // without the prefix: /hello.txt
+	if err != nil {
+		return nil, err
+	}
+	return http.FileServer(http.FS(static)), nil
+}
+
+// DemoEmbed reads, parses and serves the embedded assets; embed_test.go
+// has the cases.
+func DemoEmbed() error {
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("config.json read from the binary: %+v\n", cfg)
+	_, err = assets.ReadFile("assets/static/.hidden")
+	fmt.Println("dotfiles are left out:", err)
+	names, _ := embedded()
+	fmt.Println("the embedded files:", strings.Join(names, " "))
+
+	out, err := greet(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Print("template.ParseFS: ", out)
+
+	h, err := staticHandler()
+	if err != nil {
+		return err
+	}
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	for _, path := range []string{"/hello.txt", "/config.json"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			return err
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("http.FS: GET %s: %d %q\n", path, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
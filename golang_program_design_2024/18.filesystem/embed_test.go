@@ -0,0 +1,66 @@
+package filesystem
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEmbedded(t *testing.T) {
+	cfg, err := loadConfig()
+	if err != nil || cfg.Name != "learn-golang" || cfg.Greeting != "Hello" {
+		t.Errorf("config.json: %+v, %v", cfg, err)
+	}
+	names, err := embedded()
+	want := []string{"assets/config.json", "assets/static/hello.txt", "assets/templates/greeting.tmpl"}
+	if err != nil || !slices.Equal(names, want) {
+		t.Errorf("embedded = %q, %v, want %q", names, err, want)
+	}
+	// a file beside the others, left out of the binary.
+	if _, err := assets.ReadFile("assets/static/.hidden"); err == nil {
+		t.Error("a dotfile was embedded")
+	}
+	if _, err := fs.Stat(assets, "embed.go"); err == nil {
+		t.Error("a file outside assets was embedded")
+	}
+}
+
+func TestGreet(t *testing.T) {
+	for _, tt := range []struct {
+		cfg  config
+		want string
+	}{
+		{config{Name: "learn-golang", Greeting: "Hello"}, "Hello, learn-golang! Served from the binary.\n"},
+		{config{Name: "gopher", Greeting: "Hi"}, "Hi, gopher! Served from the binary.\n"},
+	} {
+		if got, err := greet(tt.cfg); err != nil || got != tt.want {
+			t.Errorf("greet(%+v) = %q, %v, want %q", tt.cfg, got, err, tt.want)
+		}
+	}
+}
+
+func TestStaticHandler(t *testing.T) {
+	h, err := staticHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/hello.txt", http.StatusOK, "hello from an embedded file"},
+		{"/config.json", http.StatusNotFound, ""}, // outside the Sub
+		{"/.hidden", http.StatusNotFound, ""},
+		{"/../config.json", http.StatusNotFound, ""},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if body := strings.TrimSpace(w.Body.String()); w.Code != tt.status || (tt.body != "" && body != tt.body) {
+			t.Errorf("GET %s: %d %q, want %d %q", tt.path, w.Code, body, tt.status, tt.body)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/YongSangUn/learn-golang/internal/atomicfile"
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+Package os opens files; what to do is in the flags:
+
+	os.ReadFile(name)                        // all of it, for small files
+	os.WriteFile(name, data, 0o644)          // create or truncate, write, close
+	os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) // append
+	os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)   // create, fail if it exists
+
+The permission is only used to create, and the umask of the process takes
+bits off it. The errors are *fs.PathError: errors.Is(err, fs.ErrNotExist),
+fs.ErrExist, fs.ErrPermission tell them apart on every system.
+
+Close matters on a written file: the data may only reach the disk there,
+and Close reports the error. defer f.Close() drops it; return it.
+
+A file rewritten in place is half old, half new for a reader at the wrong
+moment, or after a crash. Write a temporary file in the same directory,
+Sync it, and rename it over the old one: a rename within a file system
+replaces the name at once, readers see one file or the other.
+internal/atomicfile does it, for this lesson and for jsonstore.Save.
+
+Locks: see lock_unix.go. The standard library has no portable file lock.
+*/
+
+func init() {
+	lessons.Register("18.filesystem/files", "os files: create, read, append, exclusive create, temp files, atomic replace, locks", lessons.Checked(DemoFiles))
+}
+
+// appendString appends s to the file name, creating it if needed.
+func appendString(name, s string) error {
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(s)
+	return errors.Join(err, f.Close()) // the write error, or the one of Close
+}
+
+// createExclusive creates the file name, failing with fs.ErrExist if it
+// exists: O_EXCL checks and creates atomically, two processes racing, one
+// wins. The portable lock file.
+func createExclusive(name string) error {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// DemoFiles creates, reads and appends to files in a temporary directory;
+// files_test.go has the cases.
+func DemoFiles() error {
+	dir, err := os.MkdirTemp("", "filesystem-*") // a new directory, a random name
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("first\n"), 0o644); err != nil {
+		return err
+	}
+	if err := appendString(notes, "second\n"); err != nil {
+		return err
+	}
+	data, _ := os.ReadFile(notes)
+	info, err := os.Stat(notes)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("WriteFile, then O_APPEND: %q, %d bytes, %v\n", data, info.Size(), info.Mode())
+
+	lock := filepath.Join(dir, "job.lock")
+	err = createExclusive(lock)
+	fmt.Printf("O_EXCL: %v, then %v\n", err, createExclusive(lock))
+
+	_, err = os.ReadFile(filepath.Join(dir, "missing.txt"))
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		fmt.Printf("a missing file: fs.ErrNotExist %v, op %q, %v\n", errors.Is(err, fs.ErrNotExist), pe.Op, pe.Err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "upload-*.part") // * becomes a random string
+	if err != nil {
+		return err
+	}
+	tmp.Close()
+	fmt.Println("CreateTemp:", filepath.Base(tmp.Name()))
+
+	config := filepath.Join(dir, "config.json")
+	os.WriteFile(config, []byte(`{"version": 1}`), 0o644)
+	if err := atomicfile.WriteFile(config, []byte(`{"version": 2}`), 0o644); err != nil {
+		return err
+	}
+	data, _ = os.ReadFile(config)
+	leftover, _ := filepath.Glob(filepath.Join(dir, "*.tmp"))
+	fmt.Printf("atomic replace: %s, %d temporary files left\n", data, len(leftover))
+	fmt.Println("atomic replace in a missing directory:", atomicfile.WriteFile(filepath.Join(dir, "no-such-dir", "x.json"), nil, 0o644))
+
+	return demoLocks(dir)
+}
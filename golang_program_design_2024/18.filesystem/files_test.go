@@ -0,0 +1,58 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendString(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "notes.txt")
+	for _, s := range []string{"first\n", "second\n"} {
+		if err := appendString(name, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(name)
+	if err != nil || string(data) != "first\nsecond\n" {
+		t.Errorf("created, then appended: %q, %v", data, err)
+	}
+}
+
+func TestCreateExclusive(t *testing.T) {
+	lock := filepath.Join(t.TempDir(), "job.lock")
+	if err := createExclusive(lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := createExclusive(lock); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("the second create: %v, want fs.ErrExist", err)
+	}
+}
+
+func TestPathError(t *testing.T) {
+	_, err := os.ReadFile(filepath.Join(t.TempDir(), "missing.txt"))
+	var pe *fs.PathError
+	if !errors.Is(err, fs.ErrNotExist) || !errors.As(err, &pe) || pe.Op != "open" {
+		t.Errorf("a missing file: %v", err)
+	}
+}
+
+func TestCreateTemp(t *testing.T) {
+	dir := t.TempDir()
+	names := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		f, err := os.CreateTemp(dir, "upload-*.part") // * becomes a random string
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		base := filepath.Base(f.Name())
+		if !strings.HasPrefix(base, "upload-") || !strings.HasSuffix(base, ".part") || names[base] {
+			t.Errorf("CreateTemp: %s", base)
+		}
+		names[base] = true
+	}
+}
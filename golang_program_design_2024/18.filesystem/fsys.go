@@ -0,0 +1,121 @@
+package filesystem
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing/fstest"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+io/fs.FS is a read-only file system in one method:
+
+	type FS interface {
+		Open(name string) (fs.File, error)
+	}
+
+with slash-separated paths, relative, no "." or ".." inside: "docs/a.md".
+fs.ReadFile, fs.ReadDir, fs.Stat, fs.Glob, fs.Sub and fs.WalkDir work on
+any FS, faster if it has the optional method (ReadFileFS, ReadDirFS...).
+
+Implementations: os.DirFS(dir) for the disk, embed.FS for the files in the
+binary, zip.Reader, and fstest.MapFS, a map of path to content. Code
+taking an fs.FS instead of a directory name is tested without a
+temporary directory: the test gives it a MapFS. fsys_test.go does, for
+todos below.
+
+fstest.TestFS checks an FS of one's own: every file it lists opens, reads
+and stats the same through each of the interfaces.
+*/
+
+func init() {
+	lessons.Register("18.filesystem/fsys", "io/fs.FS: code that reads the disk, a MapFS or an embed.FS alike", lessons.Checked(DemoFS))
+}
+
+// todos returns the TODO comments of the .go files of fsys, as
+// "path:line: text". It knows nothing of the disk: fsys may be anything.
+func todos(fsys fs.FS) ([]string, error) {
+	var out []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".go" {
+			return err
+		}
+		f, err := fsys.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for n := 1; sc.Scan(); n++ {
+			if _, text, ok := strings.Cut(sc.Text(), "// TODO"); ok {
+				out = append(out, fmt.Sprintf("%s:%d: %s", p, n, strings.TrimLeft(text, ": ")))
+			}
+		}
+		return sc.Err()
+	})
+	return out, err
+}
+
+// sources is the tree of DemoFS: two TODOs in Go files, one in a file
+// that is not.
+var sources = fstest.MapFS{
+	"main.go":       {Data: []byte("package main\n\n// TODO: flags\nfunc main() {}\n")},
+	"db/db.go":      {Data: []byte("package db\n// TODO retry on busy\n")},
+	"db/README.md":  {Data: []byte("// TODO not Go, not read\n")},
+	"db/db_test.go": {Data: []byte("package db\n")},
+}
+
+// writeFS copies the files of fsys under dir on the disk.
+func writeFS(dir string, fsys fstest.MapFS) error {
+	for name, f := range fsys {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, f.Data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DemoFS runs todos on a MapFS and on the disk, and checks the FS of each;
+// fsys_test.go has the cases.
+func DemoFS() error {
+	got, err := todos(sources)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("todos of a MapFS: %q\n", got)
+
+	// the same function on the disk, through os.DirFS.
+	dir, err := os.MkdirTemp("", "fsys-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := writeFS(dir, sources); err != nil {
+		return err
+	}
+	disk, err := todos(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("todos of os.DirFS: %q\n", disk)
+
+	sub, _ := fs.Sub(sources, "db") // the FS of a subdirectory
+	subTodos, _ := todos(sub)
+	fmt.Printf("fs.Sub, db as an FS: %q\n", subTodos)
+	matches, _ := fs.Glob(sources, "*/*.go")
+	fmt.Println("fs.Glob */*.go:", matches)
+	_, err = sources.Open("../etc/passwd")
+	fmt.Printf("paths are checked, no ..: ValidPath %v, %v\n", fs.ValidPath("../etc/passwd"), err)
+	fmt.Println("fstest.TestFS of os.DirFS:", fstest.TestFS(os.DirFS(dir), "main.go", "db/db.go"))
+	return nil
+}
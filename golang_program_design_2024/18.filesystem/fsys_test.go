@@ -0,0 +1,104 @@
+package filesystem
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// todos reads an fs.FS: the test needs no directory on disk.
+func TestTodos(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		fsys fstest.MapFS
+		want []string
+	}{
+		{"empty", fstest.MapFS{}, nil},
+		{"none", fstest.MapFS{"a.go": {Data: []byte("package a\n")}}, nil},
+		{"colon or not", fstest.MapFS{"a.go": {Data: []byte("// TODO: one\nx := 1 // TODO two\n")}},
+			[]string{"a.go:1: one", "a.go:2: two"}},
+		{"only .go files", fstest.MapFS{
+			"a.go":       {Data: []byte("// TODO go\n")},
+			"notes.txt":  {Data: []byte("// TODO text\n")},
+			"dir/b.go":   {Data: []byte("\n\n// TODO deep\n")},
+			"dir.go/c.x": {Data: []byte("// TODO in a directory named .go\n")},
+		}, []string{"a.go:1: go", "dir/b.go:3: deep"}},
+	} {
+		got, err := todos(tt.fsys)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%s: todos = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+// errFS fails every Open of a file.
+type errFS struct{ fstest.MapFS }
+
+func (f errFS) Open(name string) (fs.File, error) {
+	if name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.Open(name)
+}
+
+func TestTodosError(t *testing.T) {
+	_, err := todos(errFS{fstest.MapFS{"a.go": {Data: []byte("// TODO\n")}}})
+	if !errors.Is(err, fs.ErrPermission) {
+		t.Errorf("todos = %v, want fs.ErrPermission", err)
+	}
+}
+
+// TestTodosDisk runs todos on the same tree on the disk, through os.DirFS.
+func TestTodosDisk(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeFS(dir, sources); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"db/db.go:2: retry on busy", "main.go:3: flags"}
+	for name, fsys := range map[string]fs.FS{"MapFS": sources, "os.DirFS": os.DirFS(dir)} {
+		if got, err := todos(fsys); err != nil || !slices.Equal(got, want) {
+			t.Errorf("todos of the %s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if err := fstest.TestFS(os.DirFS(dir), "main.go", "db/db.go", "db/README.md"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSub(t *testing.T) {
+	sub, err := fs.Sub(sources, "db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := todos(sub); err != nil || !slices.Equal(got, []string{"db.go:2: retry on busy"}) {
+		t.Errorf("todos of db = %q, %v", got, err)
+	}
+	if matches, err := fs.Glob(sources, "*/*.go"); err != nil || !slices.Equal(matches, []string{"db/db.go", "db/db_test.go"}) {
+		t.Errorf("fs.Glob */*.go = %q, %v", matches, err)
+	}
+}
+
+func TestValidPath(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		ok   bool
+	}{
+		{".", true},
+		{"db/db.go", true},
+		{"../etc/passwd", false},
+		{"db/../main.go", false},
+		{"/etc/passwd", false},
+		{"db/", false},
+		{"./main.go", false},
+	} {
+		if got := fs.ValidPath(tt.name); got != tt.ok {
+			t.Errorf("ValidPath(%q) = %v", tt.name, got)
+		}
+		if _, err := sources.Open(tt.name); !tt.ok && err == nil {
+			t.Errorf("Open(%q) succeeded", tt.name)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd)
+
+package filesystem
+
+import "fmt"
+
+// demoLocks is Unix only: flock is a Unix call, and Windows locks are
+// mandatory, another lesson.
+func demoLocks(dir string) error {
+	fmt.Println("flock: skipped, Unix only")
+	return nil
+}
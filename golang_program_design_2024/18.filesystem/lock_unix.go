@@ -0,0 +1,67 @@
+//go:build linux || darwin || freebsd
+
+package filesystem
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+/*
+File locks are advisory on Unix: flock stops another flock, not a read or
+a write. Every process touching the file must take the lock for it to mean
+anything. And the caveats:
+
+- flock locks an open file: two Opens of the same file in one process are
+  two holders, and the second is refused like another process would be.
+- fcntl locks (F_SETLK, the POSIX ones) belong to the process, not to the
+  open file: closing any descriptor of the file, even one opened by a
+  library, releases them all. SQLite documents years of pain with them.
+- Over NFS flock may be emulated, or local to one machine.
+- Windows locks are mandatory: LockFileEx makes a write by another
+  process fail.
+
+The standard library has no portable lock: cmd/go has one, internal. A
+lock file created with O_EXCL (files.go) works everywhere, but stays
+behind if its process dies.
+*/
+
+// flock takes the exclusive lock of f, or fails at once with
+// syscall.EWOULDBLOCK if another open file holds it: LOCK_NB, fail instead
+// of waiting.
+func flock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+}
+
+// funlock releases the lock of f; closing f does too.
+func funlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
+
+// demoLocks takes flock locks on a file of dir through two open files;
+// lock_unix_test.go has the cases.
+func demoLocks(dir string) error {
+	name := filepath.Join(dir, "shared.db")
+	if err := os.WriteFile(name, []byte("data"), 0o644); err != nil {
+		return err
+	}
+	a, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	b, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	err = flock(a)
+	fmt.Printf("flock: the first open file %v, the second %v\n", err, flock(b))
+	fmt.Println("advisory: a write without flock passes:", os.WriteFile(name, []byte("ignored the lock"), 0o644))
+	funlock(a)
+	fmt.Println("unlocked: the other one gets it:", flock(b))
+	return nil
+}
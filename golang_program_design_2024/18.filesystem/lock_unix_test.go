@@ -0,0 +1,50 @@
+//go:build linux || darwin || freebsd
+
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestFlock(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "shared.db")
+	if err := os.WriteFile(name, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	open := func() *os.File {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+	a, b := open(), open()
+	if err := flock(a); err != nil {
+		t.Fatal(err)
+	}
+	// in one process too: flock locks the open file.
+	if err := flock(b); !errors.Is(err, syscall.EWOULDBLOCK) {
+		t.Errorf("a second open file: %v, want EWOULDBLOCK", err)
+	}
+	if err := os.WriteFile(name, []byte("ignored the lock"), 0o644); err != nil {
+		t.Errorf("advisory, a write without flock: %v", err)
+	}
+	if err := funlock(a); err != nil {
+		t.Fatal(err)
+	}
+	if err := flock(b); err != nil {
+		t.Errorf("after the unlock: %v", err)
+	}
+
+	// closing the holder releases the lock as well.
+	c := open()
+	b.Close()
+	if err := flock(c); err != nil {
+		t.Errorf("after Close of the holder: %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/YongSangUn/learn-golang/internal/lessons"
+)
+
+/*
+filepath.WalkDir(root, fn) calls fn for root and everything under it, in
+lexical order, directories before their content:
+
+	func(path string, d fs.DirEntry, err error) error
+
+- d is what the directory listing said: the name and the type, no Stat
+  per file. d.Info() stats when the size or the time is needed. The older
+  filepath.Walk stats every file, slower on large trees.
+- err is not nil when a directory could not be read: fn decides, return
+  it to stop, nil to go on without that directory.
+- fn returns fs.SkipDir to skip a directory (.git, node_modules), or,
+  called on a file, the rest of its directory; fs.SkipAll to stop there,
+  with no error.
+
+path/filepath uses the separator of the system, \ on Windows; path and
+io/fs use /. filepath.Rel, Join, Ext, Base and Match work on the paths.
+*/
+
+func init() {
+	lessons.Register("18.filesystem/walk", "filepath.WalkDir: the order, SkipDir, SkipAll, errors, Glob and Rel", lessons.Checked(DemoWalk))
+}
+
+// makeTree creates the files of paths, with their directories, under dir.
+func makeTree(dir string, paths ...string) error {
+	for _, p := range paths {
+		name := filepath.Join(dir, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, []byte(p+"\n"), 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkAll returns every path under dir, dir itself included, relative to
+// it and slash-separated, directories ending in /.
+func walkAll(dir string) ([]string, error) {
+	var all []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		if d.IsDir() {
+			rel += "/"
+		}
+		all = append(all, filepath.ToSlash(rel))
+		return nil
+	})
+	return all, err
+}
+
+// goFiles returns the .go files under dir, the .git directories skipped.
+func goFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return fs.SkipDir
+		}
+		if !d.IsDir() && filepath.Ext(p) == ".go" {
+			rel, _ := filepath.Rel(dir, p)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	return files, err
+}
+
+// firstMatch returns the name of the first file under dir matching
+// pattern, "" if none does, and stops the walk there: SkipAll, no error.
+func firstMatch(dir, pattern string) (string, error) {
+	var first string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if match, _ := filepath.Match(pattern, d.Name()); match {
+			first = d.Name()
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return first, err
+}
+
+// unreadable returns the names of the directories under dir that could not
+// be read, the walk going on without them.
+func unreadable(dir string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			names = append(names, filepath.Base(p))
+			return nil // go on without it
+		}
+		return nil
+	})
+	return names, err
+}
+
+var errForbidden = errors.New("a forbidden file")
+
+// forbid fails on the first file under dir with the extension ext: the
+// error of fn stops the walk, and WalkDir returns it.
+func forbid(dir, ext string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if filepath.Ext(p) == ext {
+			return fmt.Errorf("%s: %w", d.Name(), errForbidden)
+		}
+		return nil
+	})
+}
+
+// projectTree is the small project DemoWalk walks.
+var projectTree = []string{"go.mod", "main.go", ".git/HEAD", ".git/objects/ab/cdef",
+	"internal/db/db.go", "internal/db/db_test.go", "docs/guide.md", "docs/img/logo.png"}
+
+// DemoWalk walks a small project tree; walk_test.go has the cases.
+func DemoWalk() error {
+	dir, err := os.MkdirTemp("", "walk-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	if err := makeTree(dir, projectTree...); err != nil {
+		return err
+	}
+
+	all, err := walkAll(dir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("lexical order, directories first: %s ... %d paths\n", strings.Join(all[:5], " "), len(all))
+	files, _ := goFiles(dir)
+	fmt.Println("SkipDir .git, the .go files:", files)
+	first, _ := firstMatch(dir, "*_test.go")
+	fmt.Println("SkipAll at the first *_test.go:", first)
+
+	if os.Getuid() != 0 { // root reads it anyway
+		locked := filepath.Join(dir, "docs", "img")
+		os.Chmod(locked, 0)
+		names, err := unreadable(dir)
+		os.Chmod(locked, 0o755)
+		fmt.Printf("an unreadable directory: %v, the walk ends with %v\n", names, err)
+	}
+	fmt.Println("an error from fn stops the walk:", forbid(dir, ".png"))
+
+	// Glob: one directory level per *, no **.
+	matches, _ := filepath.Glob(filepath.Join(dir, "internal", "*", "*.go"))
+	for i, m := range matches {
+		matches[i] = filepath.Base(m)
+	}
+	fmt.Println("Glob internal/*/*.go:", matches)
+	return nil
+}
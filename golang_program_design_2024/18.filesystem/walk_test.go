@@ -0,0 +1,90 @@
+package filesystem
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func project(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := makeTree(dir, projectTree...); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestWalkAll(t *testing.T) {
+	all, err := walkAll(project(t))
+	want := []string{"./", ".git/", ".git/HEAD", ".git/objects/", ".git/objects/ab/", ".git/objects/ab/cdef",
+		"docs/", "docs/guide.md", "docs/img/", "docs/img/logo.png", "go.mod",
+		"internal/", "internal/db/", "internal/db/db.go", "internal/db/db_test.go", "main.go"}
+	if err != nil || !slices.Equal(all, want) {
+		t.Errorf("walkAll = %q, %v\nwant %q", all, err, want)
+	}
+}
+
+func TestSkip(t *testing.T) {
+	dir := project(t)
+	files, err := goFiles(dir)
+	if want := []string{"internal/db/db.go", "internal/db/db_test.go", "main.go"}; err != nil || !slices.Equal(files, want) {
+		t.Errorf("goFiles = %q, %v, want %q", files, err, want)
+	}
+	for _, tt := range []struct{ pattern, want string }{
+		{"*_test.go", "db_test.go"},
+		{"*.go", "db.go"}, // internal/ comes before main.go
+		{"*.rs", ""},
+	} {
+		if got, err := firstMatch(dir, tt.pattern); err != nil || got != tt.want {
+			t.Errorf("firstMatch(%s) = %q, %v, want %q", tt.pattern, got, err, tt.want)
+		}
+	}
+}
+
+func TestWalkErrors(t *testing.T) {
+	dir := project(t)
+	if err := forbid(dir, ".png"); !errors.Is(err, errForbidden) {
+		t.Errorf("forbid .png = %v, want errForbidden", err)
+	}
+	if err := forbid(dir, ".rs"); err != nil {
+		t.Errorf("forbid .rs = %v", err)
+	}
+	if _, err := walkAll(filepath.Join(dir, "missing")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("walkAll of a missing root = %v", err)
+	}
+
+	if os.Getuid() == 0 {
+		t.Skip("root reads an unreadable directory")
+	}
+	locked := filepath.Join(dir, "docs", "img")
+	os.Chmod(locked, 0)
+	defer os.Chmod(locked, 0o755)
+	if names, err := unreadable(dir); err != nil || !slices.Equal(names, []string{"img"}) {
+		t.Errorf("unreadable = %q, %v, want [img]", names, err)
+	}
+}
+
+func TestGlob(t *testing.T) {
+	dir := project(t)
+	for _, tt := range []struct {
+		pattern string
+		want    []string
+	}{
+		{"internal/*/*.go", []string{"internal/db/db.go", "internal/db/db_test.go"}},
+		{"*/*.go", nil}, // one level per *
+		{"*.go", []string{"main.go"}},
+		{"docs/*/*.png", []string{"docs/img/logo.png"}},
+	} {
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(tt.pattern)))
+		for i, m := range matches {
+			rel, _ := filepath.Rel(dir, m)
+			matches[i] = filepath.ToSlash(rel)
+		}
+		if err != nil || !slices.Equal(matches, tt.want) {
+			t.Errorf("Glob %s = %q, %v, want %q", tt.pattern, matches, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+// Package atomicfile writes files atomically: the data goes to a temporary
+// file in the same directory, synced to the disk, then renamed over the
+// target. 18.filesystem/files.go explains why each step is there.
+package atomicfile
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// WriteFile replaces name with data: readers see the old file or the new
+// one, never a part of it. On failure name is left as it was, and the
+// temporary file removed.
+func WriteFile(name string, data []byte, perm fs.FileMode) (err error) {
+	// in the same directory: a rename across file systems is a copy.
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name()) // the temporary file of a failed write
+		}
+	}()
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Chmod(perm); err != nil { // CreateTemp makes it 0o600
+		return err
+	}
+	if err = f.Sync(); err != nil { // on the disk before the rename points at it
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
@@ -0,0 +1,31 @@
+package atomicfile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+	os.WriteFile(config, []byte(`{"version": 1}`), 0o600)
+	// the mode is set with Chmod, the umask does not apply.
+	if err := WriteFile(config, []byte(`{"version": 2}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(config)
+	info, err := os.Stat(config)
+	if err != nil || string(data) != `{"version": 2}` || info.Mode().Perm() != 0o644 {
+		t.Errorf("replaced: %s, %v, %v", data, info.Mode(), err)
+	}
+	if leftover, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(leftover) != 0 {
+		t.Errorf("temporary files left: %v", leftover)
+	}
+
+	if err := WriteFile(filepath.Join(dir, "no-such-dir", "x.json"), nil, 0o644); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("in a missing directory: %v, want fs.ErrNotExist", err)
+	}
+}
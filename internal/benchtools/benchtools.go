@@ -0,0 +1,221 @@
+// Package benchtools is the benchmark harness shared by the performance
+// chapters. The chapters are programs, not test files: they call
+// testing.Benchmark from main and print what it measured. This package keeps
+// that the same everywhere:
+//
+//	Init            the -test.benchtime and -test.count flags, for every program
+//	Run, Loop       a benchmark, with allocations always reported
+//	Sized, RunSized one sub-benchmark per input size, named n=SIZE
+//	Table           the same columns, units and rounding in every chapter
+//	Ints, Strings,  seeded fixtures, the same input on every run
+//	Bytes
+//
+// A typical chapter:
+//
+//	benchtools.Init()
+//	flag.Parse()
+//	t := benchtools.NewTable(os.Stdout)
+//	t.Add(benchtools.Loop("strings.Join", func() { sink = strings.Join(parts, "") }))
+//	t.Add(benchtools.Loop("strings.Builder", func() { sink = build(parts) }))
+//	t.Flush()
+package benchtools
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"testing"
+	"text/tabwriter"
+	"time"
+)
+
+// Init registers the -test.* flags of the testing package, so that a
+// program benchmarking from main takes -test.benchtime 200ms like go test
+// takes -benchtime. Call it before flag.Parse.
+func Init() { testing.Init() }
+
+// Result is a benchmark result reduced to what the chapters print.
+type Result struct {
+	Name        string
+	N           int     // iterations run
+	NsPerOp     float64 // not rounded to the nanosecond, unlike BenchmarkResult.NsPerOp
+	AllocsPerOp int64
+	BytesPerOp  int64
+}
+
+// PerOp is the time of one operation.
+func (r Result) PerOp() time.Duration { return time.Duration(r.NsPerOp) }
+
+func (r Result) String() string {
+	return fmt.Sprintf("%s %s/op %d allocs/op %d B/op", r.Name, FormatNs(r.NsPerOp), r.AllocsPerOp, r.BytesPerOp)
+}
+
+// Run benchmarks f like testing.Benchmark, with allocations reported.
+func Run(name string, f func(b *testing.B)) Result {
+	br := testing.Benchmark(func(b *testing.B) {
+		b.ReportAllocs()
+		f(b)
+	})
+	r := Result{Name: name, N: br.N, AllocsPerOp: br.AllocsPerOp(), BytesPerOp: br.AllocedBytesPerOp()}
+	if br.N > 0 {
+		r.NsPerOp = float64(br.T.Nanoseconds()) / float64(br.N)
+	}
+	return r
+}
+
+// Loop benchmarks f, called b.N times: the usual case, a function with
+// nothing to set up or to time apart.
+func Loop(name string, f func()) Result {
+	return Run(name, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			f()
+		}
+	})
+}
+
+// SizeName names a sized sub-benchmark: n=1024, n=64K, n=1M.
+func SizeName(n int) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("n=%dM", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("n=%dK", n>>10)
+	}
+	return fmt.Sprintf("n=%d", n)
+}
+
+// Sized runs fn as sub-benchmarks of b, one per size: for benchmarks in
+// test files, go test -bench 'Lookup/n=1K'.
+func Sized(b *testing.B, sizes []int, fn func(b *testing.B, n int)) {
+	for _, n := range sizes {
+		b.Run(SizeName(n), func(b *testing.B) {
+			b.ReportAllocs()
+			fn(b, n)
+		})
+	}
+}
+
+// RunSized is Sized for a program: one Result per size, named name/n=SIZE.
+func RunSized(name string, sizes []int, fn func(b *testing.B, n int)) []Result {
+	out := make([]Result, 0, len(sizes))
+	for _, n := range sizes {
+		out = append(out, Run(name+"/"+SizeName(n), func(b *testing.B) { fn(b, n) }))
+	}
+	return out
+}
+
+// FormatNs formats a time per operation with 3 significant digits, in the
+// unit that suits it: 4.21ns, 312ns, 1.25µs, 40.0ms.
+func FormatNs(ns float64) string {
+	switch {
+	case ns < 999.5: // rounds to 1000: shown as 1.00µs
+		return sig3(ns) + "ns"
+	case ns < 999.5e3:
+		return sig3(ns/1e3) + "µs"
+	case ns < 999.5e6:
+		return sig3(ns/1e6) + "ms"
+	}
+	return sig3(ns/1e9) + "s"
+}
+
+// sig3 formats v, under 1000, with 3 significant digits, trailing zeros
+// kept so the columns line up: 4.20, 31.0, 312.
+func sig3(v float64) string {
+	switch {
+	case v < 9.995:
+		return fmt.Sprintf("%.2f", v)
+	case v < 99.95:
+		return fmt.Sprintf("%.1f", v)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+// Table prints results in aligned columns: name, time, allocations and
+// bytes per operation, and the ratio to the fastest row of the table.
+// Rows are buffered until Flush, to compute the ratios.
+type Table struct {
+	w    io.Writer
+	rows []Result
+}
+
+// NewTable returns a table printing to w.
+func NewTable(w io.Writer) *Table { return &Table{w: w} }
+
+// Add adds a row and returns it, to keep the result at hand.
+func (t *Table) Add(r Result) Result {
+	t.rows = append(t.rows, r)
+	return t.rows[len(t.rows)-1]
+}
+
+// Flush prints the rows added since the last Flush.
+func (t *Table) Flush() {
+	if len(t.rows) == 0 {
+		return
+	}
+	best := Fastest(t.rows)
+	width := 0
+	for _, r := range t.rows {
+		width = max(width, len(r.Name))
+	}
+	// the names aligned left, padded by hand, the numbers right.
+	tw := tabwriter.NewWriter(t.w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for _, r := range t.rows {
+		fmt.Fprintf(tw, "  %-*s\t%s/op\t%d allocs/op\t%d B/op\t%s\t\n",
+			width, r.Name, FormatNs(r.NsPerOp), r.AllocsPerOp, r.BytesPerOp, ratio(r, best))
+	}
+	tw.Flush()
+	t.rows = t.rows[:0]
+}
+
+func ratio(r, best Result) string {
+	if best.NsPerOp == 0 || len(r.Name) == 0 {
+		return ""
+	}
+	if r.Name == best.Name {
+		return "fastest"
+	}
+	return fmt.Sprintf("%.1fx", r.NsPerOp/best.NsPerOp)
+}
+
+// Fastest returns the result with the lowest time per operation.
+func Fastest(rs []Result) Result {
+	var best Result
+	for i, r := range rs {
+		if i == 0 || r.NsPerOp < best.NsPerOp {
+			best = r
+		}
+	}
+	return best
+}
+
+// Ints returns n ints in [0, max), the same for the same seed.
+func Ints(n, max int, seed int64) []int {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([]int, n)
+	for i := range out {
+		out[i] = rng.Intn(max)
+	}
+	return out
+}
+
+// Strings returns n strings of size lowercase letters, the same for the
+// same seed.
+func Strings(n, size int, seed int64) []string {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([]string, n)
+	b := make([]byte, size)
+	for i := range out {
+		for j := range b {
+			b[j] = 'a' + byte(rng.Intn(26))
+		}
+		out[i] = string(b)
+	}
+	return out
+}
+
+// Bytes returns n random bytes, the same for the same seed.
+func Bytes(n int, seed int64) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(seed)).Read(b)
+	return b
+}
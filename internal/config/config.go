@@ -0,0 +1,303 @@
+// Package config loads the settings of the server-style examples from four
+// layers, each overriding the previous one:
+//
+//	defaults < config file (JSON or YAML) < environment (LEARN_*) < flags
+//
+// Every setting has a single name used in all layers, e.g. "read_timeout" is
+// the key in the file, LEARN_READ_TIMEOUT in the environment and
+// -read_timeout on the command line.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EnvPrefix is prepended to the upper-cased setting name to get its variable.
+const EnvPrefix = "LEARN_"
+
+// Config is the typed result of Load.
+type Config struct {
+	Addr            string        // listen address, host:port
+	ReadTimeout     time.Duration // per request/line read deadline
+	WriteTimeout    time.Duration
+	ShutdownTimeout time.Duration // how long to drain connections on shutdown
+	MaxClients      int
+	LogFormat       string // "text" or "json", see internal/xlog
+}
+
+// Default returns the lowest layer.
+func Default() Config {
+	return Config{
+		Addr:            "127.0.0.1:8080",
+		ReadTimeout:     5 * time.Second,
+		WriteTimeout:    5 * time.Second,
+		ShutdownTimeout: 10 * time.Second,
+		MaxClients:      100,
+		LogFormat:       "text",
+	}
+}
+
+// field binds a setting name to the Config member it fills.
+type field struct {
+	name  string
+	usage string
+	set   func(c *Config, v string) error
+}
+
+var fields = []field{
+	{"addr", "listen address", func(c *Config, v string) error {
+		c.Addr = v
+		return nil
+	}},
+	{"read_timeout", "read timeout", durationSetter(func(c *Config) *time.Duration { return &c.ReadTimeout })},
+	{"write_timeout", "write timeout", durationSetter(func(c *Config) *time.Duration { return &c.WriteTimeout })},
+	{"shutdown_timeout", "graceful shutdown timeout", durationSetter(func(c *Config) *time.Duration { return &c.ShutdownTimeout })},
+	{"max_clients", "maximum concurrent clients", func(c *Config, v string) error {
+		n, err := strconv.Atoi(v)
+		c.MaxClients = n
+		return err
+	}},
+	{"log_format", "log format: text or json", func(c *Config, v string) error {
+		c.LogFormat = v
+		return nil
+	}},
+}
+
+func durationSetter(member func(c *Config) *time.Duration) func(*Config, string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		*member(c) = d
+		return err
+	}
+}
+
+func lookupField(name string) (field, bool) {
+	for _, f := range fields {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// Load builds a Config from args (usually os.Args[1:]) and the real environment.
+// The config file is given with -config or LEARN_CONFIG.
+func Load(name string, args []string) (Config, error) {
+	return load(name, args, os.LookupEnv)
+}
+
+// load is Load with the environment lookup injected, so a fixed environment
+// can be used instead of the process one.
+func load(name string, args []string, lookupEnv func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+
+	// flags are parsed first only to find -config, they are applied last.
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("config", "", "config file (.json, .yaml or .yml), env "+EnvPrefix+"CONFIG")
+	flagValues := make(map[string]*string, len(fields))
+	for _, f := range fields {
+		flagValues[f.name] = fs.String(f.name, "", f.usage)
+	}
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	if *path == "" {
+		*path, _ = lookupEnv(EnvPrefix + "CONFIG")
+	}
+	if *path != "" {
+		if err := applyFile(&cfg, *path); err != nil {
+			return cfg, err
+		}
+	}
+
+	for _, f := range fields {
+		if v, ok := lookupEnv(EnvPrefix + strings.ToUpper(f.name)); ok {
+			if err := f.set(&cfg, v); err != nil {
+				return cfg, fmt.Errorf("env %s%s: %w", EnvPrefix, strings.ToUpper(f.name), err)
+			}
+		}
+	}
+
+	// only flags given on the command line override, an unset flag is not "".
+	var err error
+	fs.Visit(func(fl *flag.Flag) {
+		if f, ok := lookupField(fl.Name); ok && err == nil {
+			if e := f.set(&cfg, *flagValues[f.name]); e != nil {
+				err = fmt.Errorf("flag -%s: %w", f.name, e)
+			}
+		}
+	})
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, cfg.Validate()
+}
+
+// LoadFlags is Load for a program with settings of its own instead of
+// Config: it fills the flags of fs that the command line did not set (given
+// holds the names of those it did) from the environment, then from the
+// config file at path, "" for none. The variable of a flag is prefix and its
+// name upper-cased, dashes turned into underscores: -data-dir is
+// LEARNCTL_DATA_DIR with the prefix "LEARNCTL_". Keys of the file naming no
+// flag of fs are ignored, so that one file can hold the settings of several
+// commands.
+func LoadFlags(fs *flag.FlagSet, given map[string]bool, prefix, path string) error {
+	return loadFlags(fs, given, prefix, path, os.LookupEnv)
+}
+
+func loadFlags(fs *flag.FlagSet, given map[string]bool, prefix, path string, lookupEnv func(string) (string, bool)) error {
+	var values map[string]string
+	if path != "" {
+		var err error
+		if values, err = readFile(path); err != nil {
+			return err
+		}
+	}
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if given[f.Name] || err != nil {
+			return
+		}
+		env := prefix + strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))
+		if v, ok := lookupEnv(env); ok {
+			if e := f.Value.Set(v); e != nil {
+				err = fmt.Errorf("env %s: %w", env, e)
+			}
+		} else if v, ok := values[f.Name]; ok {
+			if e := f.Value.Set(v); e != nil {
+				err = fmt.Errorf("config file %s: %s: %w", path, f.Name, e)
+			}
+		}
+	})
+	return err
+}
+
+// readFile reads the flat settings of a JSON or YAML file.
+func readFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var values map[string]string
+	switch ext := filepath.Ext(path); ext {
+	case ".json":
+		values, err = parseJSON(f)
+	case ".yaml", ".yml":
+		values, err = parseYAML(f)
+	default:
+		err = fmt.Errorf("unsupported config file type %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+func applyFile(cfg *Config, path string) error {
+	values, err := readFile(path)
+	if err != nil {
+		return err
+	}
+	for key, v := range values {
+		f, ok := lookupField(key)
+		if !ok {
+			return fmt.Errorf("config file %s: unknown key %q", path, key)
+		}
+		if err := f.set(cfg, v); err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// parseJSON flattens a JSON object into strings, so numbers and durations go
+// through the same setters as environment variables and flags. Numbers are
+// kept as written (UseNumber): as a float64, 1000000 would print as 1e+06,
+// which strconv.Atoi rejects.
+func parseJSON(r io.Reader) (map[string]string, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	var raw map[string]interface{}
+	if err := dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if _, ok := v.(map[string]interface{}); ok {
+			return nil, fmt.Errorf("key %q: nested objects are not supported", k)
+		}
+		values[k] = fmt.Sprint(v)
+	}
+	return values, nil
+}
+
+// parseYAML understands the flat subset of YAML a settings file needs:
+// "key: value" lines, comments and optional quotes. Nesting is rejected rather
+// than silently misread.
+func parseYAML(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for i, line := range strings.Split(string(data), "\n") {
+		if j := strings.Index(line, " #"); j >= 0 {
+			line = line[:j]
+		}
+		if trimmed := strings.TrimSpace(line); trimmed == "" || trimmed[0] == '#' || trimmed == "---" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("line %d: nested values are not supported", i+1)
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", i+1)
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		values[strings.TrimSpace(key)] = value
+	}
+	return values, nil
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("addr: %w", err))
+	}
+	if c.ReadTimeout <= 0 {
+		errs = append(errs, errors.New("read_timeout: must be positive"))
+	}
+	if c.WriteTimeout <= 0 {
+		errs = append(errs, errors.New("write_timeout: must be positive"))
+	}
+	if c.ShutdownTimeout < 0 {
+		errs = append(errs, errors.New("shutdown_timeout: must not be negative"))
+	}
+	if c.MaxClients < 1 {
+		errs = append(errs, errors.New("max_clients: must be at least 1"))
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("log_format: %q is neither text nor json", c.LogFormat))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,186 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// env is a fixed environment for load.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestPrecedence sets addr in every layer it is given: the highest one wins,
+// and the settings no layer mentions keep their defaults.
+func TestPrecedence(t *testing.T) {
+	file := writeFile(t, "learn.json", `{"addr": "127.0.0.1:1", "max_clients": 7}`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		wantAddr string
+		wantMax  int
+	}{
+		{"defaults", nil, nil, "127.0.0.1:8080", 100},
+		{"file over defaults", []string{"-config", file}, nil, "127.0.0.1:1", 7},
+		{"file from the environment", nil, map[string]string{"LEARN_CONFIG": file}, "127.0.0.1:1", 7},
+		{"env over file", []string{"-config", file}, map[string]string{"LEARN_ADDR": "127.0.0.1:2"}, "127.0.0.1:2", 7},
+		{"flag over env", []string{"-config", file, "-addr", "127.0.0.1:3"}, map[string]string{"LEARN_ADDR": "127.0.0.1:2"}, "127.0.0.1:3", 7},
+		{"flag over file and env, per setting", []string{"-config", file, "-max_clients", "9"}, map[string]string{"LEARN_ADDR": "127.0.0.1:2"}, "127.0.0.1:2", 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load("test", tt.args, env(tt.env))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Addr != tt.wantAddr || cfg.MaxClients != tt.wantMax {
+				t.Errorf("addr %s, max_clients %d; want %s, %d", cfg.Addr, cfg.MaxClients, tt.wantAddr, tt.wantMax)
+			}
+			if cfg.ReadTimeout != Default().ReadTimeout {
+				t.Errorf("read_timeout = %v, no layer sets it", cfg.ReadTimeout)
+			}
+		})
+	}
+}
+
+func TestFiles(t *testing.T) {
+	want := Default()
+	want.Addr = ":9000"
+	want.ReadTimeout = 2 * time.Second
+	want.MaxClients = 1000000
+	want.LogFormat = "json"
+
+	tests := []struct {
+		name, content string
+	}{
+		{"learn.json", `{"addr": ":9000", "read_timeout": "2s", "max_clients": 1000000, "log_format": "json"}`},
+		{"learn.yaml", "# the server\naddr: \":9000\"\nread_timeout: 2s # per line\nmax_clients: 1000000\nlog_format: 'json'\n"},
+		{"learn.yml", "---\naddr: :9000\nread_timeout: 2s\nmax_clients: 1000000\nlog_format: json\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := load("test", []string{"-config", writeFile(t, tt.name, tt.content)}, env(nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg != want {
+				t.Errorf("got %+v\nwant %+v", cfg, want)
+			}
+		})
+	}
+}
+
+func TestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // name and content, if any
+		content string
+		args    []string
+		env     map[string]string
+		want    string // in the error
+	}{
+		{name: "unknown key", file: "c.json", content: `{"port": 80}`, want: `unknown key "port"`},
+		{name: "nested JSON", file: "c.json", content: `{"addr": {"host": "x"}}`, want: "nested objects"},
+		{name: "nested YAML", file: "c.yaml", content: "server:\n  addr: :80\n", want: "line 2: nested values"},
+		{name: "file type", file: "c.toml", content: `addr = ":80"`, want: "unsupported config file type"},
+		{name: "bad number in the file", file: "c.json", content: `{"max_clients": 1.5}`, want: "max_clients"},
+		{name: "bad env", env: map[string]string{"LEARN_READ_TIMEOUT": "soon"}, want: "env LEARN_READ_TIMEOUT"},
+		{name: "bad flag", args: []string{"-max_clients", "many"}, want: "flag -max_clients"},
+		{name: "unknown flag", args: []string{"-port", "80"}, want: "-port"},
+		{name: "invalid values", args: []string{"-addr", "nowhere", "-log_format", "xml"}, want: "log_format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"-config", writeFile(t, tt.file, tt.content)}, args...)
+			}
+			_, err := load("test", args, env(tt.env))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadFlags gives -data-dir in the layers of each case to a program with
+// its own flags: the same precedence as Load, flags set on the command line
+// untouched.
+func TestLoadFlags(t *testing.T) {
+	file := writeFile(t, "ctl.yaml", "data-dir: /from/file\nworkers: 4\nother-command: ignored\n")
+	tests := []struct {
+		name        string
+		args        []string
+		path        string
+		env         map[string]string
+		wantDir     string
+		wantWorkers int
+	}{
+		{"defaults", nil, "", nil, "/default", 1},
+		{"file", nil, file, nil, "/from/file", 4},
+		{"env over file", nil, file, map[string]string{"CTL_DATA_DIR": "/from/env"}, "/from/env", 4},
+		{"flag over env", []string{"-data-dir", "/from/flag"}, file, map[string]string{"CTL_DATA_DIR": "/from/env"}, "/from/flag", 4},
+		{"env without a file", nil, "", map[string]string{"CTL_WORKERS": "8"}, "/default", 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("ctl", flag.ContinueOnError)
+			dir := fs.String("data-dir", "/default", "")
+			workers := fs.Int("workers", 1, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			given := make(map[string]bool)
+			fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+			if err := loadFlags(fs, given, "CTL_", tt.path, env(tt.env)); err != nil {
+				t.Fatal(err)
+			}
+			if *dir != tt.wantDir || *workers != tt.wantWorkers {
+				t.Errorf("data-dir %s, workers %d; want %s, %d", *dir, *workers, tt.wantDir, tt.wantWorkers)
+			}
+		})
+	}
+
+	fs := flag.NewFlagSet("ctl", flag.ContinueOnError)
+	fs.Int("workers", 1, "")
+	if err := loadFlags(fs, nil, "CTL_", "", env(map[string]string{"CTL_WORKERS": "many"})); err == nil || !strings.Contains(err.Error(), "env CTL_WORKERS") {
+		t.Errorf("a bad variable: %v", err)
+	}
+	if err := loadFlags(fs, nil, "CTL_", writeFile(t, "ctl.json", `{"workers": "many"}`), env(nil)); err == nil || !strings.Contains(err.Error(), "workers") {
+		t.Errorf("a bad value in the file: %v", err)
+	}
+	if err := loadFlags(fs, nil, "CTL_", filepath.Join(t.TempDir(), "missing.json"), env(nil)); err == nil {
+		t.Error("a missing file is not an error")
+	}
+}
+
+func TestValidateReportsAll(t *testing.T) {
+	c := Config{Addr: "nowhere", LogFormat: "xml"}
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("an empty config is valid")
+	}
+	for _, name := range []string{"addr", "read_timeout", "write_timeout", "max_clients", "log_format"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("%v: no %s", err, name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package lessons is the registry of the chapter lessons. Each lesson file
+// registers its exported Demo function from an init func:
+//
+//	func init() {
+//		lessons.Register("04.concurrent/channel", "unbuffered and buffered channels, select, range", DemoChannels)
+//	}
+//
+// The id is the path of the file under golang_program_design_2024, without
+// .go, so it says where to read the code. cmd/learn imports every chapter
+// and runs a lesson by id.
+package lessons
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Lesson is a registered lesson.
+type Lesson struct {
+	ID      string // e.g. "04.concurrent/channel"
+	Summary string // one line, for the listing
+	Run     func()
+}
+
+// Chapter is the first element of the id: "04.concurrent".
+func (l Lesson) Chapter() string {
+	chapter, _, _ := strings.Cut(l.ID, "/")
+	return chapter
+}
+
+// Dir is the directory of the lesson file, relative to the chapters root:
+// the lesson runs from there, like `go run channel.go` did, so relative
+// paths in it still work.
+func (l Lesson) Dir() string { return path.Dir(l.ID) }
+
+var (
+	mu       sync.Mutex
+	registry = make(map[string]Lesson)
+)
+
+// Register adds a lesson. Like http.Handle, it panics on an empty or
+// duplicate id: both are mistakes in the code, not at run time.
+func Register(id, summary string, run func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	if id == "" || run == nil {
+		panic("lessons: Register with an empty id or a nil func")
+	}
+	if _, dup := registry[id]; dup {
+		panic(fmt.Sprintf("lessons: Register called twice for %s", id))
+	}
+	registry[id] = Lesson{ID: id, Summary: summary, Run: run}
+}
+
+// Checked adapts a demo that checks its own results to a lesson: the lesson
+// prints the error and exits with status 1, as the program used to.
+func Checked(demo func() error) func() {
+	return func() {
+		if err := demo(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// Find returns the lesson with the given id.
+func Find(id string) (Lesson, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+	l, ok := registry[id]
+	return l, ok
+}
+
+// All returns every lesson, sorted by id: chapter order, since the chapter
+// directories are numbered.
+func All() []Lesson {
+	mu.Lock()
+	defer mu.Unlock()
+	out := make([]Lesson, 0, len(registry))
+	for _, l := range registry {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
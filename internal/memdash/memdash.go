@@ -0,0 +1,205 @@
+// Package memdash serves a live page of the memory of the running program:
+// runtime.MemStats and the number of goroutines, sampled on a ticker and
+// drawn in the browser as they change. Any chapter can watch its workload
+// with one line:
+//
+//	stop, err := memdash.Serve("localhost:6061", time.Second)
+//
+// then open http://localhost:6061/. The page is an html/template rendered
+// once; a script fetches /samples every interval and redraws.
+package memdash
+
+import (
+	"context"
+	"encoding/json"
+	"html/template"
+	"net"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Sample is one reading of the runtime.
+type Sample struct {
+	Time       time.Time `json:"time"`
+	HeapAlloc  uint64    `json:"heapAlloc"`  // bytes of live and not yet collected objects
+	HeapSys    uint64    `json:"heapSys"`    // bytes of heap obtained from the OS
+	HeapInuse  uint64    `json:"heapInuse"`  // bytes in spans holding objects
+	StackInuse uint64    `json:"stackInuse"` // bytes of goroutine stacks
+	Sys        uint64    `json:"sys"`        // all the bytes obtained from the OS
+	NextGC     uint64    `json:"nextGC"`     // heap size of the next collection
+	NumGC      uint32    `json:"numGC"`
+	PauseNs    uint64    `json:"pauseNs"` // pause of the last collection
+	Goroutines int       `json:"goroutines"`
+}
+
+func read() Sample {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return Sample{
+		Time:       time.Now(),
+		HeapAlloc:  ms.HeapAlloc,
+		HeapSys:    ms.HeapSys,
+		HeapInuse:  ms.HeapInuse,
+		StackInuse: ms.StackInuse,
+		Sys:        ms.Sys,
+		NextGC:     ms.NextGC,
+		NumGC:      ms.NumGC,
+		PauseNs:    ms.PauseNs[(ms.NumGC+255)%256],
+		Goroutines: runtime.NumGoroutine(),
+	}
+}
+
+// Sampler keeps the last samples taken on a ticker.
+type Sampler struct {
+	Interval time.Duration
+
+	mu      sync.Mutex
+	samples []Sample // oldest first, at most max
+	max     int
+}
+
+// NewSampler returns a sampler keeping keep samples taken every interval.
+func NewSampler(interval time.Duration, keep int) *Sampler {
+	return &Sampler{Interval: interval, max: keep}
+}
+
+// Run samples until ctx is done. ReadMemStats stops the world for a few
+// microseconds: once a second is harmless, every millisecond is not.
+func (s *Sampler) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	s.add(read())
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			s.add(read())
+		}
+	}
+}
+
+func (s *Sampler) add(x Sample) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.samples) == s.max {
+		copy(s.samples, s.samples[1:])
+		s.samples = s.samples[:len(s.samples)-1]
+	}
+	s.samples = append(s.samples, x)
+}
+
+// Samples returns a copy of the samples, oldest first.
+func (s *Sampler) Samples() []Sample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Sample(nil), s.samples...)
+}
+
+// Handler serves the page on / and the samples as JSON on /samples.
+func (s *Sampler) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		page.Execute(w, struct {
+			IntervalMs int64
+			Keep       int
+		}{s.Interval.Milliseconds(), s.max})
+	})
+	mux.HandleFunc("GET /samples", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Samples())
+	})
+	return mux
+}
+
+// Serve samples every interval, keeping 5 minutes of samples at 1s, and
+// serves the dashboard on addr in the background. The listener is opened
+// before Serve returns, so a busy port is an error here and not in a log.
+// stop ends both.
+func Serve(addr string, interval time.Duration) (stop func(), err error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := NewSampler(interval, 300)
+	go s.Run(ctx)
+	srv := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go srv.Serve(ln)
+	return func() {
+		cancel()
+		srv.Close()
+	}, nil
+}
+
+var page = template.Must(template.New("page").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>memdash</title>
+<style>
+body { font: 14px monospace; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+td { padding: 2px 12px; text-align: right; }
+td:first-child { text-align: left; color: #555; }
+canvas { border: 1px solid #ccc; }
+.heap { color: #c33; } .sys { color: #36c; } .next { color: #999; } .g { color: #393; }
+</style>
+</head>
+<body>
+<h3>memdash <small id="status"></small></h3>
+<table>
+<tr><td class="heap">heap alloc</td><td id="heapAlloc"></td><td>heap sys</td><td id="heapSys"></td></tr>
+<tr><td class="next">next GC at</td><td id="nextGC"></td><td>heap in use</td><td id="heapInuse"></td></tr>
+<tr><td class="sys">sys</td><td id="sys"></td><td>stacks</td><td id="stackInuse"></td></tr>
+<tr><td class="g">goroutines</td><td id="goroutines"></td><td>GCs / last pause</td><td id="gc"></td></tr>
+</table>
+<canvas id="mem" width="800" height="200"></canvas><br>
+<canvas id="gor" width="800" height="100"></canvas>
+<script>
+const interval = {{.IntervalMs}}, keep = {{.Keep}};
+const mb = b => (b / 1048576).toFixed(1) + " MB";
+
+function plot(id, samples, series) {
+  const c = document.getElementById(id), ctx = c.getContext("2d");
+  ctx.clearRect(0, 0, c.width, c.height);
+  let max = 1;
+  for (const s of samples) for (const [key] of series) max = Math.max(max, s[key]);
+  for (const [key, color] of series) {
+    ctx.strokeStyle = color;
+    ctx.beginPath();
+    samples.forEach((s, i) => {
+      const x = c.width - (samples.length - 1 - i) * c.width / keep;
+      const y = c.height - s[key] / max * (c.height - 10);
+      i ? ctx.lineTo(x, y) : ctx.moveTo(x, y);
+    });
+    ctx.stroke();
+  }
+  ctx.fillStyle = "#555";
+  ctx.fillText(id === "mem" ? mb(max) : max + " goroutines", 4, 12);
+}
+
+async function tick() {
+  try {
+    const samples = await (await fetch("samples")).json();
+    const last = samples[samples.length - 1];
+    for (const k of ["heapAlloc", "heapSys", "heapInuse", "stackInuse", "sys", "nextGC"])
+      document.getElementById(k).textContent = mb(last[k]);
+    document.getElementById("goroutines").textContent = last.goroutines;
+    document.getElementById("gc").textContent = last.numGC + " / " + (last.pauseNs / 1000).toFixed(0) + " µs";
+    document.getElementById("status").textContent = new Date(last.time).toLocaleTimeString();
+    plot("mem", samples, [["sys", "#36c"], ["nextGC", "#999"], ["heapAlloc", "#c33"]]);
+    plot("gor", samples, [["goroutines", "#393"]]);
+  } catch (e) {
+    document.getElementById("status").textContent = "disconnected";
+  }
+}
+tick();
+setInterval(tick, interval);
+</script>
+</body>
+</html>
+`))
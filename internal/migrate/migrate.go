@@ -0,0 +1,143 @@
+// Package migrate brings a database schema up to date by running numbered
+// SQL files in order, each exactly once.
+//
+// Migrations are files named NNNN_description.sql (0001_create_users.sql,
+// 0002_add_index.sql, ...), usually embedded in the program with embed.FS.
+// The schema_version table records the versions applied; Up runs the files
+// with a higher version, each in its own transaction together with its
+// schema_version row, so a failing migration leaves no trace and the next run
+// retries it.
+//
+// Files are executed with a single Exec, which SQLite and PostgreSQL accept
+// for several statements; MySQL needs multiStatements=true in its DSN. The
+// bookkeeping queries use ? placeholders.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration is one SQL file.
+type Migration struct {
+	Version int
+	Name    string // the file name
+	SQL     string
+}
+
+// Load reads the migrations of dir in fsys, sorted by version. Files not
+// ending in .sql are ignored; a bad name or two files with the same version
+// are errors.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	var list []Migration
+	seen := make(map[int]string)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		prefix, _, ok := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("migrate: %s: name must look like 0001_description.sql", name)
+		}
+		if other, dup := seen[version]; dup {
+			return nil, fmt.Errorf("migrate: %s and %s have the same version", other, name)
+		}
+		seen[version] = name
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, Migration{Version: version, Name: name, SQL: string(data)})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+const createVersionTable = `CREATE TABLE IF NOT EXISTS schema_version (
+	version    INTEGER PRIMARY KEY,
+	name       TEXT    NOT NULL,
+	applied_at INTEGER NOT NULL
+)`
+
+// Applied returns the versions already run, with the unix time they ran at.
+func Applied(ctx context.Context, db *sql.DB) (map[int]time.Time, error) {
+	if _, err := db.ExecContext(ctx, createVersionTable); err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	rows, err := db.QueryContext(ctx, `SELECT version, applied_at FROM schema_version`)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	defer rows.Close()
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var at int64
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = time.Unix(at, 0)
+	}
+	return applied, rows.Err()
+}
+
+// Up runs the migrations not applied yet, in version order, and returns the
+// names of those it ran. It refuses to run when the database has a version
+// that is not in the list: it was migrated by a newer program.
+func Up(ctx context.Context, db *sql.DB, migrations []Migration) ([]string, error) {
+	applied, err := Applied(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[int]bool, len(migrations))
+	for _, m := range migrations {
+		known[m.Version] = true
+	}
+	for v := range applied {
+		if !known[v] {
+			return nil, fmt.Errorf("migrate: the database has version %d, unknown to this program", v)
+		}
+	}
+
+	var ran []string
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := run(ctx, db, m); err != nil {
+			return ran, err
+		}
+		ran = append(ran, m.Name)
+	}
+	return ran, nil
+}
+
+func run(ctx context.Context, db *sql.DB, m Migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, m.SQL); err != nil {
+		return fmt.Errorf("migrate: %s: %w", m.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Name, time.Now().Unix()); err != nil {
+		return fmt.Errorf("migrate: %s: %w", m.Name, err)
+	}
+	return tx.Commit()
+}
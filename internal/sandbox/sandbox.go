@@ -0,0 +1,197 @@
+// Package sandbox runs example binaries in a child process with a wall-clock
+// deadline, CPU time and memory limits, and a cap on the captured output, so a
+// runaway demo (an endless loop, a goroutine leak, a print storm) cannot wedge
+// the host that started it.
+//
+// Resource limits are applied with syscall.Setrlimit. Since rlimits belong to
+// the process that sets them, the host re-executes its own binary, sets the
+// limits in that fresh process and then replaces it with the target via exec.
+// For this to work, every program that calls Run must call Init first thing
+// in main:
+//
+//	func main() {
+//		sandbox.Init()
+//		...
+//		res, err := sandbox.Run(ctx, sandbox.DefaultLimits, "./demo")
+//	}
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// environment variables used to pass the limits to the re-executed process.
+const (
+	envExec   = "LEARN_SANDBOX_EXEC"
+	envCPU    = "LEARN_SANDBOX_CPU"
+	envMemory = "LEARN_SANDBOX_MEMORY"
+)
+
+// Limits describes the resources a single run may use. Zero means unlimited.
+type Limits struct {
+	WallTime  time.Duration // deadline of the whole run
+	CPUTime   time.Duration // RLIMIT_CPU, rounded up to whole seconds
+	Memory    uint64        // RLIMIT_DATA in bytes (heap and other writable mappings)
+	MaxOutput int           // bytes of combined stdout/stderr kept, the child is killed beyond it
+}
+
+// DefaultLimits are generous enough for every chapter in this repo.
+var DefaultLimits = Limits{
+	WallTime:  10 * time.Second,
+	CPUTime:   5 * time.Second,
+	Memory:    512 << 20,
+	MaxOutput: 64 << 10,
+}
+
+// Result is the outcome of a finished run.
+type Result struct {
+	Output    []byte // combined stdout and stderr, at most Limits.MaxOutput bytes
+	ExitCode  int    // -1 if the process was killed by a signal
+	Duration  time.Duration
+	TimedOut  bool // killed because Limits.WallTime elapsed
+	Truncated bool // killed because the output exceeded Limits.MaxOutput
+}
+
+// ErrNotInitialized is returned by Run when Init was not called in main.
+var ErrNotInitialized = errors.New("sandbox: Init was not called")
+
+var initialized bool
+
+// Init must be called at the beginning of main. In the host process it only
+// records that the sandbox is ready; in a process re-executed by Run it applies
+// the limits and execs the target, and never returns.
+func Init() {
+	initialized = true
+	if os.Getenv(envExec) != "1" {
+		return
+	}
+	if err := setLimits(os.Getenv(envCPU), os.Getenv(envMemory)); err != nil {
+		fmt.Fprintf(os.Stderr, "sandbox: set limits: %v\n", err)
+		os.Exit(126)
+	}
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "sandbox: missing target")
+		os.Exit(126)
+	}
+	err := execTarget(os.Args[1], os.Args[1:], cleanEnv(os.Environ()))
+	// exec only returns on failure.
+	fmt.Fprintf(os.Stderr, "sandbox: exec %s: %v\n", os.Args[1], err)
+	os.Exit(127)
+}
+
+// Run executes name with args under the given limits and waits for it to exit.
+// A non-zero exit code is not an error, it is reported in Result.ExitCode.
+func Run(ctx context.Context, limits Limits, name string, args ...string) (*Result, error) {
+	if !initialized {
+		return nil, ErrNotInitialized
+	}
+	target, err := exec.LookPath(name)
+	if err != nil {
+		return nil, err
+	}
+	self, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	if limits.WallTime > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.WallTime)
+		defer cancel()
+	}
+	ctx, kill := context.WithCancel(ctx)
+	defer kill()
+
+	out := &capWriter{limit: limits.MaxOutput, overflow: kill}
+	cmd := exec.CommandContext(ctx, self, append([]string{target}, args...)...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.Env = append(os.Environ(),
+		envExec+"=1",
+		envCPU+"="+strconv.FormatInt(int64((limits.CPUTime+time.Second-1)/time.Second), 10),
+		envMemory+"="+strconv.FormatUint(limits.Memory, 10),
+	)
+	// the demo may start its own children, make sure all of them die with it.
+	killGroup(cmd)
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err = cmd.Run()
+	res := &Result{
+		Output:    out.Bytes(),
+		Duration:  time.Since(start),
+		ExitCode:  cmd.ProcessState.ExitCode(),
+		Truncated: out.Truncated(),
+	}
+	res.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) && !res.TimedOut && !res.Truncated {
+		return res, err
+	}
+	return res, nil
+}
+
+func cleanEnv(env []string) []string {
+	kept := env[:0]
+	for _, kv := range env {
+		switch {
+		case hasKey(kv, envExec), hasKey(kv, envCPU), hasKey(kv, envMemory):
+		default:
+			kept = append(kept, kv)
+		}
+	}
+	return kept
+}
+
+func hasKey(kv, key string) bool {
+	return len(kv) > len(key) && kv[:len(key)] == key && kv[len(key)] == '='
+}
+
+// capWriter keeps the first limit bytes written to it and calls overflow once
+// when more than that arrives. It is shared by stdout and stderr, hence the lock.
+type capWriter struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+	overflow  func()
+}
+
+func (w *capWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.limit <= 0 {
+		return w.buf.Write(p)
+	}
+	if room := w.limit - w.buf.Len(); len(p) > room {
+		w.buf.Write(p[:room])
+		if !w.truncated {
+			w.truncated = true
+			w.overflow()
+		}
+		// pretend success, the process is being killed anyway.
+		return len(p), nil
+	}
+	return w.buf.Write(p)
+}
+
+func (w *capWriter) Bytes() []byte {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return bytes.Clone(w.buf.Bytes())
+}
+
+func (w *capWriter) Truncated() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.truncated
+}
@@ -0,0 +1,30 @@
+//go:build !(linux || darwin || freebsd)
+
+package sandbox
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setLimits is a no-op where rlimits are not available, only the wall-clock
+// deadline and the output cap apply.
+func setLimits(cpu, memory string) error {
+	return nil
+}
+
+// execTarget emulates exec by running the target as a child and exiting with its code.
+func execTarget(path string, argv, env []string) error {
+	cmd := exec.Command(path, argv[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = env
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			return err
+		}
+	}
+	os.Exit(cmd.ProcessState.ExitCode())
+	return nil
+}
+
+func killGroup(cmd *exec.Cmd) {}
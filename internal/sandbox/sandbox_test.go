@@ -0,0 +1,123 @@
+package sandbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// childEnv makes the test binary, run by Run as the target, play a demo:
+// "hello" prints and exits 3, "sleep" never ends, "print" prints forever,
+// "spin" burns CPU forever, "alloc" allocates without end.
+const childEnv = "SANDBOX_TEST_CHILD"
+
+func TestMain(m *testing.M) {
+	Init() // in the process Run re-executes: the limits, then the exec
+	switch os.Getenv(childEnv) {
+	case "hello":
+		fmt.Println("hello")
+		fmt.Fprintln(os.Stderr, "from stderr")
+		os.Exit(3)
+	case "sleep":
+		time.Sleep(time.Hour)
+		os.Exit(0)
+	case "print":
+		for {
+			fmt.Println(strings.Repeat("x", 99))
+		}
+	case "spin":
+		for {
+		}
+	case "alloc":
+		var keep [][]byte
+		for {
+			b := make([]byte, 16<<20)
+			for i := range b {
+				b[i] = 1
+			}
+			keep = append(keep, b)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// run runs the test binary as the child named by the childEnv value.
+func run(t *testing.T, child string, limits Limits) *Result {
+	t.Helper()
+	t.Setenv(childEnv, child)
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := Run(context.Background(), limits, self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestRun(t *testing.T) {
+	res := run(t, "hello", DefaultLimits)
+	if res.ExitCode != 3 || res.TimedOut || res.Truncated {
+		t.Errorf("exit code %d, timed out %v, truncated %v; want 3, false, false", res.ExitCode, res.TimedOut, res.Truncated)
+	}
+	if out := string(res.Output); !strings.Contains(out, "hello\n") || !strings.Contains(out, "from stderr\n") {
+		t.Errorf("output %q, want stdout and stderr", out)
+	}
+}
+
+// TestWallTime runs a child that sleeps: it is killed at the deadline.
+func TestWallTime(t *testing.T) {
+	res := run(t, "sleep", Limits{WallTime: 200 * time.Millisecond})
+	if !res.TimedOut || res.ExitCode != -1 {
+		t.Errorf("timed out %v, exit code %d; want true, -1", res.TimedOut, res.ExitCode)
+	}
+	if res.Duration > 5*time.Second {
+		t.Errorf("killed after %v, the deadline was 200ms", res.Duration)
+	}
+}
+
+// TestMaxOutput runs a child that prints forever: the output is cut at the
+// cap, and the child killed.
+func TestMaxOutput(t *testing.T) {
+	res := run(t, "print", Limits{WallTime: 10 * time.Second, MaxOutput: 1000})
+	if !res.Truncated || res.TimedOut || len(res.Output) != 1000 {
+		t.Errorf("truncated %v, timed out %v, %d bytes; want true, false, 1000", res.Truncated, res.TimedOut, len(res.Output))
+	}
+	if !bytes.HasPrefix(res.Output, []byte(strings.Repeat("x", 99)+"\n")) {
+		t.Errorf("output starts %q", res.Output[:min(len(res.Output), 20)])
+	}
+}
+
+func TestNotInitialized(t *testing.T) {
+	initialized = false
+	defer func() { initialized = true }()
+	if _, err := Run(context.Background(), DefaultLimits, "true"); err != ErrNotInitialized {
+		t.Errorf("Run before Init = %v, want ErrNotInitialized", err)
+	}
+}
+
+func TestCapWriter(t *testing.T) {
+	overflows := 0
+	w := &capWriter{limit: 5, overflow: func() { overflows++ }}
+	for _, s := range []string{"abc", "def", "ghi"} {
+		if n, err := w.Write([]byte(s)); n != 3 || err != nil {
+			t.Errorf("Write(%q) = %d, %v", s, n, err)
+		}
+	}
+	if got := string(w.Bytes()); got != "abcde" || !w.Truncated() || overflows != 1 {
+		t.Errorf("kept %q, truncated %v, %d overflows; want abcde, true, 1", got, w.Truncated(), overflows)
+	}
+}
+
+func TestCleanEnv(t *testing.T) {
+	env := []string{"HOME=/root", envExec + "=1", envCPU + "=5", envMemory + "=100", envExec + "X=kept"}
+	got := cleanEnv(env)
+	if want := []string{"HOME=/root", envExec + "X=kept"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("cleanEnv = %q, want %q", got, want)
+	}
+}
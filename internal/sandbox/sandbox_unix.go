@@ -0,0 +1,39 @@
+//go:build linux || darwin || freebsd
+
+package sandbox
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+func setLimits(cpu, memory string) error {
+	if err := setLimit(syscall.RLIMIT_CPU, cpu); err != nil {
+		return err
+	}
+	// RLIMIT_AS would also count the address space the Go runtime reserves up
+	// front (about 1GB even for hello world), RLIMIT_DATA only counts memory in use.
+	return setLimit(syscall.RLIMIT_DATA, memory)
+}
+
+func setLimit(resource int, value string) error {
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || n == 0 {
+		return err
+	}
+	return syscall.Setrlimit(resource, &syscall.Rlimit{Cur: n, Max: n})
+}
+
+func execTarget(path string, argv, env []string) error {
+	return syscall.Exec(path, argv, env)
+}
+
+// killGroup starts the child in its own process group and kills the whole
+// group on cancellation, instead of only the direct child.
+func killGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
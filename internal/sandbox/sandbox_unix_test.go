@@ -0,0 +1,34 @@
+//go:build linux || darwin || freebsd
+
+package sandbox
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// TestCPUTime runs a child that spins: RLIMIT_CPU kills it after a second
+// of CPU, long before the wall-clock deadline.
+func TestCPUTime(t *testing.T) {
+	res := run(t, "spin", Limits{WallTime: 30 * time.Second, CPUTime: time.Second})
+	if res.TimedOut || res.ExitCode != -1 {
+		t.Errorf("timed out %v, exit code %d; want killed by a signal before the deadline", res.TimedOut, res.ExitCode)
+	}
+	if res.Duration < time.Second || res.Duration > 20*time.Second {
+		t.Errorf("killed after %v, want about the second of CPU", res.Duration)
+	}
+}
+
+// TestMemory runs a child that allocates without end: with RLIMIT_DATA the
+// runtime cannot grow the heap past the limit, and the child dies.
+func TestMemory(t *testing.T) {
+	res := run(t, "alloc", Limits{WallTime: 30 * time.Second, Memory: 256 << 20})
+	if res.TimedOut || res.ExitCode == 0 {
+		t.Errorf("timed out %v, exit code %d; want the child dead before the deadline", res.TimedOut, res.ExitCode)
+	}
+	// the runtime reports it, or under -race the race detector, first.
+	if !bytes.Contains(res.Output, []byte("out of memory")) && !bytes.Contains(res.Output, []byte("failed to allocate")) {
+		t.Errorf("output %q, want an allocation failure", res.Output[:min(len(res.Output), 200)])
+	}
+}
@@ -0,0 +1,81 @@
+// Package testutil holds assertion helpers for tests of the concurrency
+// chapters. They wait on a channel with a timeout instead of sleeping for a
+// guessed duration, so a test is as fast as the code allows and only fails
+// when something really hangs.
+package testutil
+
+import (
+	"testing"
+	"time"
+)
+
+// RequireRecv waits up to timeout for a value on ch and returns it.
+// It fails the test if ch is closed or nothing arrives in time.
+func RequireRecv[T any](t testing.TB, ch <-chan T, timeout time.Duration) T {
+	t.Helper()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatalf("channel closed, expected a value")
+		}
+		return v
+	case <-timer.C:
+		t.Fatalf("no value received within %v", timeout)
+	}
+	var zero T
+	return zero
+}
+
+// RequireNoRecv fails the test if a value arrives on ch within d.
+// A closed channel counts as a receive, use it on channels that stay open.
+func RequireNoRecv[T any](t testing.TB, ch <-chan T, d time.Duration) {
+	t.Helper()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case v, ok := <-ch:
+		if !ok {
+			t.Fatalf("channel closed, expected no receive within %v", d)
+		}
+		t.Fatalf("unexpected value received: %v", v)
+	case <-timer.C:
+	}
+}
+
+// RequireClosedWithin drains ch and fails the test if it is not closed within d.
+// It returns the values drained before the close.
+func RequireClosedWithin[T any](t testing.TB, ch <-chan T, d time.Duration) []T {
+	t.Helper()
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	var drained []T
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return drained
+			}
+			drained = append(drained, v)
+		case <-timer.C:
+			t.Fatalf("channel not closed within %v (%d values drained)", d, len(drained))
+			return drained
+		}
+	}
+}
+
+// RequireDone waits for fn to return, failing the test if it takes longer than d.
+// It is handy for wg.Wait or a shutdown call that might deadlock.
+func RequireDone(t testing.TB, d time.Duration, fn func()) {
+	t.Helper()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		fn()
+	}()
+	RequireClosedWithin(t, done, d)
+}
@@ -0,0 +1,26 @@
+package testutil
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// RequireGoroutines waits up to d for the number of goroutines to drop to
+// want, failing the test if it does not. A goroutine that was told to exit
+// takes a moment to be gone: take want before starting the code under test,
+// then check after stopping it.
+func RequireGoroutines(t testing.TB, want int, d time.Duration) {
+	t.Helper()
+	deadline := time.Now().Add(d)
+	for {
+		n := runtime.NumGoroutine()
+		if n <= want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines after %v, want %d: leaked", n, d, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
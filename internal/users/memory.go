@@ -0,0 +1,100 @@
+package users
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository keeps the users in a map. The zero value is not usable,
+// use NewMemoryRepository.
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	byID    map[int64]User
+	byEmail map[string]int64
+	nextID  int64
+	now     func() time.Time
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{byID: make(map[int64]User), byEmail: make(map[string]int64), nextID: 1, now: time.Now}
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	email := normalizeEmail(u.Email)
+	if _, taken := r.byEmail[email]; taken {
+		return ErrEmailTaken
+	}
+	u.ID, u.Email, u.CreatedAt = r.nextID, email, r.now()
+	r.nextID++
+	r.byID[u.ID] = *u
+	r.byEmail[email] = u.ID
+	return nil
+}
+
+// Get returns a copy: the caller cannot change the stored user by accident,
+// just like with a row read from a database.
+func (r *MemoryRepository) Get(ctx context.Context, id int64) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	u, ok := r.byID[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &u, nil
+}
+
+func (r *MemoryRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	r.mu.RLock()
+	id, ok := r.byEmail[normalizeEmail(email)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return r.Get(ctx, id)
+}
+
+func (r *MemoryRepository) List(ctx context.Context) ([]User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]User, 0, len(r.byID))
+	for _, u := range r.byID {
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list, nil
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, u *User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.byID[u.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	email := normalizeEmail(u.Email)
+	if id, taken := r.byEmail[email]; taken && id != u.ID {
+		return ErrEmailTaken
+	}
+	delete(r.byEmail, old.Email)
+	old.Name, old.Email = u.Name, email
+	r.byID[u.ID] = old
+	r.byEmail[email] = u.ID
+	*u = old
+	return nil
+}
+
+func (r *MemoryRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.byID[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(r.byID, id)
+	delete(r.byEmail, u.Email)
+	return nil
+}
@@ -0,0 +1,6 @@
+CREATE TABLE users (
+	id         INTEGER PRIMARY KEY,
+	name       TEXT    NOT NULL,
+	email      TEXT    NOT NULL,
+	created_at INTEGER NOT NULL -- unix nanoseconds, portable across databases
+);
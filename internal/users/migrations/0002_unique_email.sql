@@ -0,0 +1,3 @@
+-- emails are stored lower-cased, so this also rejects Alice@x and alice@x;
+-- SQLRepository turns the violation into ErrEmailTaken.
+CREATE UNIQUE INDEX users_email ON users (email);
@@ -0,0 +1,118 @@
+package users
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// SQLRepository stores the users in the users table created by Migrations.
+// It only uses database/sql, the driver is up to the program.
+type SQLRepository struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+func NewSQLRepository(db *sql.DB) *SQLRepository {
+	return &SQLRepository{db: db, now: time.Now}
+}
+
+func (r *SQLRepository) Create(ctx context.Context, u *User) error {
+	email := normalizeEmail(u.Email)
+	now := r.now()
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO users (name, email, created_at) VALUES (?, ?, ?)`, u.Name, email, now.UnixNano())
+	if err != nil {
+		return mapError(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	u.ID, u.Email, u.CreatedAt = id, email, now
+	return nil
+}
+
+// mapError turns the unique index violation into ErrEmailTaken. Drivers have
+// no common error type for it, but they all name the constraint in the message.
+func mapError(err error) error {
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "unique") || strings.Contains(msg, "duplicate") {
+		return ErrEmailTaken
+	}
+	return err
+}
+
+const selectUser = `SELECT id, name, email, created_at FROM users`
+
+func scanUser(row interface{ Scan(...any) error }) (*User, error) {
+	var u User
+	var created int64
+	if err := row.Scan(&u.ID, &u.Name, &u.Email, &created); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	u.CreatedAt = time.Unix(0, created)
+	return &u, nil
+}
+
+func (r *SQLRepository) Get(ctx context.Context, id int64) (*User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, selectUser+` WHERE id = ?`, id))
+}
+
+func (r *SQLRepository) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return scanUser(r.db.QueryRowContext(ctx, selectUser+` WHERE email = ?`, normalizeEmail(email)))
+}
+
+func (r *SQLRepository) List(ctx context.Context) ([]User, error) {
+	rows, err := r.db.QueryContext(ctx, selectUser+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *u)
+	}
+	return list, rows.Err()
+}
+
+func (r *SQLRepository) Update(ctx context.Context, u *User) error {
+	email := normalizeEmail(u.Email)
+	res, err := r.db.ExecContext(ctx, `UPDATE users SET name = ?, email = ? WHERE id = ?`, u.Name, email, u.ID)
+	if err != nil {
+		return mapError(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	stored, err := r.Get(ctx, u.ID)
+	if err != nil {
+		return err
+	}
+	*u = *stored
+	return nil
+}
+
+func (r *SQLRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+// Package users stores user accounts behind the Repository interface, with
+// two implementations: SQLRepository on any database/sql database, and
+// MemoryRepository in a map. Code written against the interface runs on a
+// real database in production and on the map in examples and tests, where
+// starting a database would be slow or impossible.
+//
+// Both implementations must behave the same, errors included: an unknown id
+// is ErrNotFound and a second account with the same email ErrEmailTaken,
+// whatever the storage.
+package users
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/YongSangUn/learn-golang/internal/migrate"
+)
+
+var (
+	ErrNotFound   = errors.New("users: not found")
+	ErrEmailTaken = errors.New("users: email already registered")
+)
+
+type User struct {
+	ID        int64
+	Name      string
+	Email     string
+	CreatedAt time.Time
+}
+
+// Repository is the storage of users.
+type Repository interface {
+	// Create stores u and sets its ID and CreatedAt.
+	Create(ctx context.Context, u *User) error
+	Get(ctx context.Context, id int64) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	// List returns every user by id.
+	List(ctx context.Context) ([]User, error)
+	// Update saves the name and email of u.
+	Update(ctx context.Context, u *User) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// normalizeEmail makes "Alice@Example.com " and "alice@example.com" the same
+// account.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// Migrations returns the schema of the users table, for migrate.Up.
+func Migrations() ([]migrate.Migration, error) {
+	return migrate.Load(migrationFiles, "migrations")
+}
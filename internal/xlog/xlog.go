@@ -0,0 +1,93 @@
+// Package xlog is the structured logger shared by the chapters. It wraps
+// log/slog so every record carries the same fields:
+//
+//	chapter    the chapter directory, e.g. "04.concurrent"
+//	example    the demo function that logged, e.g. "syncMutex"
+//	goroutine  the id of the goroutine that logged
+//
+// The output format is text on stderr by default; set XLOG_FORMAT=json to get
+// JSON lines, or call SetHandler to plug in any other slog.Handler.
+package xlog
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+var (
+	mu   sync.RWMutex
+	base slog.Handler = NewHandler(os.Stderr, os.Getenv("XLOG_FORMAT") == "json")
+)
+
+// NewHandler returns the handler used by default, writing text or JSON to w.
+// The timestamp is shortened to the time of day, which is all a demo needs.
+func NewHandler(w io.Writer, json bool) slog.Handler {
+	opts := &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.String(a.Key, a.Value.Time().Format("15:04:05.000"))
+			}
+			return a
+		},
+	}
+	if json {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// SetHandler replaces the handler used by loggers created afterwards.
+func SetHandler(h slog.Handler) {
+	mu.Lock()
+	defer mu.Unlock()
+	base = h
+}
+
+// New returns a logger for one example of a chapter.
+func New(chapter, example string) *slog.Logger {
+	mu.RLock()
+	h := base
+	mu.RUnlock()
+	return slog.New(goroutineHandler{h}).With("chapter", chapter, "example", example)
+}
+
+// goroutineHandler adds the id of the calling goroutine to every record.
+// It must be applied at Handle time, not with With, since a logger is usually
+// shared by many goroutines.
+type goroutineHandler struct {
+	slog.Handler
+}
+
+func (h goroutineHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(slog.Uint64("goroutine", goid()))
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h goroutineHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return goroutineHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h goroutineHandler) WithGroup(name string) slog.Handler {
+	return goroutineHandler{h.Handler.WithGroup(name)}
+}
+
+// goid parses the id out of the first line of the stack trace,
+// "goroutine 18 [running]:". The runtime deliberately offers no API for it,
+// so this is for logging only: never key program logic on a goroutine id.
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}